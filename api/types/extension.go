@@ -52,12 +52,18 @@ func (t *CertExtensionType) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// CertExtensionModeCriticalOption injects the configured name/value pair
+// into the certificate's critical options instead of its extensions.
+const CertExtensionModeCriticalOption CertExtensionMode = 1
+
 var certExtensionModeName = map[CertExtensionMode]string{
-	CertExtensionMode_EXTENSION: "extension",
+	CertExtensionMode_EXTENSION:     "extension",
+	CertExtensionModeCriticalOption: "critical-option",
 }
 
 var certExtensionModeValue = map[string]CertExtensionMode{
-	"extension": CertExtensionMode_EXTENSION,
+	"extension":       CertExtensionMode_EXTENSION,
+	"critical-option": CertExtensionModeCriticalOption,
 }
 
 func (t CertExtensionMode) MarshalJSON() ([]byte, error) {
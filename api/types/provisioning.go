@@ -37,6 +37,21 @@ const (
 	JoinMethodEC2 JoinMethod = "ec2"
 	// JoinMethodIAM indicates that the node will join with the IAM join method.
 	JoinMethodIAM JoinMethod = "iam"
+	// JoinMethodGCP indicates that the node will join by presenting a
+	// GCP-signed instance identity token.
+	JoinMethodGCP JoinMethod = "gcp"
+	// JoinMethodKubernetes indicates that the node will join by presenting
+	// a projected Kubernetes service account token.
+	JoinMethodKubernetes JoinMethod = "kubernetes"
+	// JoinMethodGitLab indicates that the node will join by presenting a
+	// GitLab CI ID token.
+	JoinMethodGitLab JoinMethod = "gitlab"
+	// JoinMethodCircleCI indicates that the node will join by presenting a
+	// CircleCI OIDC token.
+	JoinMethodCircleCI JoinMethod = "circleci"
+	// JoinMethodSPIFFE indicates that the node will join by presenting an
+	// X.509 SVID from a trusted SPIFFE trust domain.
+	JoinMethodSPIFFE JoinMethod = "spiffe"
 )
 
 // ProvisionToken is a provisioning token
@@ -175,6 +190,36 @@ func (p *ProvisionTokenV2) CheckAndSetDefaults() error {
 				return trace.BadParameter(`allow rule for %q join method must set "aws_account" or "aws_arn"`, JoinMethodEC2)
 			}
 		}
+	case JoinMethodGCP:
+		// allowed projects and service accounts are configured via token
+		// metadata labels, AWS allow rules do not apply
+		if hasAllowRules {
+			return trace.BadParameter("allow rules are not compatible with the %q join method", JoinMethodGCP)
+		}
+	case JoinMethodKubernetes:
+		// allowed service accounts are configured via token metadata
+		// labels, AWS allow rules do not apply
+		if hasAllowRules {
+			return trace.BadParameter("allow rules are not compatible with the %q join method", JoinMethodKubernetes)
+		}
+	case JoinMethodGitLab:
+		// allowed projects, refs and environments are configured via token
+		// metadata labels, AWS allow rules do not apply
+		if hasAllowRules {
+			return trace.BadParameter("allow rules are not compatible with the %q join method", JoinMethodGitLab)
+		}
+	case JoinMethodCircleCI:
+		// allowed organization and projects are configured via token
+		// metadata labels, AWS allow rules do not apply
+		if hasAllowRules {
+			return trace.BadParameter("allow rules are not compatible with the %q join method", JoinMethodCircleCI)
+		}
+	case JoinMethodSPIFFE:
+		// allowed trust domain and SPIFFE IDs are configured via token
+		// metadata labels, AWS allow rules do not apply
+		if hasAllowRules {
+			return trace.BadParameter("allow rules are not compatible with the %q join method", JoinMethodSPIFFE)
+		}
 	default:
 		return trace.BadParameter("unknown join method %q", p.Spec.JoinMethod)
 	}
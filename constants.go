@@ -680,6 +680,10 @@ const (
 	// UsageWindowsDesktopOnly specifies certificate usage metadata that limits
 	// certificate to be only used for Windows desktop access
 	UsageWindowsDesktopOnly = "usage:windows_desktop"
+
+	// UsageSSHOnly marks the TLS certificate issued alongside an ssh-only
+	// user cert request so it can not be used against any TLS endpoint.
+	UsageSSHOnly = "usage:ssh"
 )
 
 const (
@@ -106,6 +106,12 @@ func (a *Server) UpsertLock(ctx context.Context, lock types.Lock) error {
 		return trace.Wrap(err)
 	}
 
+	// record the creation time so lock propagation latencies can be measured
+	// from agent acknowledgments
+	if err := a.recordLockCreation(ctx, lock.GetName()); err != nil {
+		log.WithError(err).Warning("Failed to record lock creation time.")
+	}
+
 	if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.LockCreate{
 		Metadata: apievents.Metadata{
 			Type: events.LockCreatedEvent,
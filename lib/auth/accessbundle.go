@@ -0,0 +1,240 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// accessBundlePrefix is the backend prefix access bundles are stored
+	// under.
+	accessBundlePrefix = "access_bundles"
+	// defaultAccessBundleDuration is the access duration of a bundle that
+	// does not set one.
+	defaultAccessBundleDuration = 4 * time.Hour
+	// maxAccessBundleDuration caps the access duration a bundle may grant.
+	maxAccessBundleDuration = 24 * time.Hour
+)
+
+// AccessBundle is a named, time-boxed group of resources that is requested
+// and approved as a single access request. Bundles are defined by cluster
+// administrators so that on-call engineers file one request per incident
+// instead of one per resource.
+type AccessBundle struct {
+	// Name is the name of the bundle.
+	Name string `json:"name"`
+	// Description explains what the bundle grants and when to request it.
+	Description string `json:"description,omitempty"`
+	// Roles are the roles requested alongside the resources.
+	Roles []string `json:"roles,omitempty"`
+	// Resources are the resources the bundle covers. Resource IDs without a
+	// cluster name default to the local cluster.
+	Resources []types.ResourceID `json:"resources,omitempty"`
+	// MaxDuration is the access duration granted when a request created
+	// from the bundle is approved. Defaults to four hours.
+	MaxDuration time.Duration `json:"max_duration,omitempty"`
+	// UpdatedAt is the time the bundle was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the bundle and fills in defaults.
+func (b *AccessBundle) CheckAndSetDefaults() error {
+	if b.Name == "" {
+		return trace.BadParameter("access bundle name is required")
+	}
+	if len(b.Roles) == 0 && len(b.Resources) == 0 {
+		return trace.BadParameter("access bundle %q must include at least one role or resource", b.Name)
+	}
+	for _, resource := range b.Resources {
+		if resource.Kind == "" || resource.Name == "" {
+			return trace.BadParameter("access bundle %q includes a resource without a kind or name", b.Name)
+		}
+	}
+	if b.MaxDuration == 0 {
+		b.MaxDuration = defaultAccessBundleDuration
+	}
+	if b.MaxDuration < 0 || b.MaxDuration > maxAccessBundleDuration {
+		return trace.BadParameter("access bundle duration must be between 0 and %v", maxAccessBundleDuration)
+	}
+	return nil
+}
+
+// UpsertAccessBundle creates or updates an access bundle.
+func (a *Server) UpsertAccessBundle(ctx context.Context, bundle AccessBundle) error {
+	if err := bundle.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	bundle.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(accessBundlePrefix, bundle.Name),
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetAccessBundle returns the access bundle with the given name.
+func (a *Server) GetAccessBundle(ctx context.Context, name string) (*AccessBundle, error) {
+	if name == "" {
+		return nil, trace.BadParameter("access bundle name is required")
+	}
+	item, err := a.bk.Get(ctx, backend.Key(accessBundlePrefix, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("access bundle %q is not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var bundle AccessBundle
+	if err := utils.FastUnmarshal(item.Value, &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// GetAccessBundles returns all defined access bundles.
+func (a *Server) GetAccessBundles(ctx context.Context) ([]AccessBundle, error) {
+	startKey := backend.Key(accessBundlePrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bundles := make([]AccessBundle, 0, len(result.Items))
+	for _, item := range result.Items {
+		var bundle AccessBundle
+		if err := utils.FastUnmarshal(item.Value, &bundle); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// DeleteAccessBundle removes the access bundle with the given name.
+func (a *Server) DeleteAccessBundle(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("access bundle name is required")
+	}
+	err := a.bk.Delete(ctx, backend.Key(accessBundlePrefix, name))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("access bundle %q is not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+// newAccessRequestFromBundle assembles a pending access request for the
+// given user covering all roles and resources of the named bundle.
+func (a *Server) newAccessRequestFromBundle(ctx context.Context, user, bundleName string) (types.AccessRequest, error) {
+	bundle, err := a.GetAccessBundle(ctx, bundleName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resourceIDs := make([]types.ResourceID, 0, len(bundle.Resources))
+	for _, resource := range bundle.Resources {
+		if resource.ClusterName == "" {
+			resource.ClusterName = clusterName.GetClusterName()
+		}
+		resourceIDs = append(resourceIDs, resource)
+	}
+	req, err := types.NewAccessRequestWithResources(uuid.New().String(), user, bundle.Roles, resourceIDs)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.SetRequestReason("access bundle " + bundle.Name)
+	req.SetAccessExpiry(a.clock.Now().UTC().Add(bundle.MaxDuration))
+	return req, nil
+}
+
+// AccessBundleRequestSummary describes the access request created from a
+// bundle.
+type AccessBundleRequestSummary struct {
+	// Name is the name of the created access request.
+	Name string `json:"name"`
+	// AccessExpiry is the time the granted access expires once approved.
+	AccessExpiry time.Time `json:"access_expiry"`
+}
+
+// UpsertAccessBundle creates or updates an access bundle.
+func (a *ServerWithRoles) UpsertAccessBundle(ctx context.Context, bundle AccessBundle) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertAccessBundle(ctx, bundle)
+}
+
+// GetAccessBundle returns the access bundle with the given name.
+func (a *ServerWithRoles) GetAccessBundle(ctx context.Context, name string) (*AccessBundle, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessBundle(ctx, name)
+}
+
+// GetAccessBundles returns all defined access bundles.
+func (a *ServerWithRoles) GetAccessBundles(ctx context.Context) ([]AccessBundle, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessBundles(ctx)
+}
+
+// DeleteAccessBundle removes the access bundle with the given name.
+func (a *ServerWithRoles) DeleteAccessBundle(ctx context.Context, name string) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAccessBundle(ctx, name)
+}
+
+// CreateAccessRequestFromBundle files a pending access request for the
+// calling user covering everything in the named bundle. The request goes
+// through the regular access request pipeline, so threshold and reviewer
+// rules apply unchanged.
+func (a *ServerWithRoles) CreateAccessRequestFromBundle(ctx context.Context, bundleName string) (*AccessBundleRequestSummary, error) {
+	req, err := a.authServer.newAccessRequestFromBundle(ctx, a.context.User.GetName(), bundleName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// CreateAccessRequest applies the pending-request exception for the
+	// current user and clamps the access expiry to the caller's identity.
+	if err := a.CreateAccessRequest(ctx, req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &AccessBundleRequestSummary{
+		Name:         req.GetName(),
+		AccessExpiry: req.GetAccessExpiry(),
+	}, nil
+}
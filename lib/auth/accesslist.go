@@ -0,0 +1,245 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// accessListPrefix is the backend prefix access lists are stored under.
+	accessListPrefix = "access_lists"
+
+	// defaultAccessListReviewInterval is the review interval of a list that
+	// does not set one.
+	defaultAccessListReviewInterval = 90 * 24 * time.Hour
+)
+
+// AccessList grants long-lived role membership to a set of users. Unlike an
+// access request, the grant does not expire on its own, instead the owners
+// have to re-attest the membership periodically: once a list goes unreviewed
+// past its interval, its members stop receiving the granted roles until an
+// owner reviews it.
+type AccessList struct {
+	// Name is the name of the access list.
+	Name string `json:"name"`
+	// Description explains what the list grants and who belongs on it.
+	Description string `json:"description,omitempty"`
+	// Owners are the users responsible for reviewing the list.
+	Owners []string `json:"owners"`
+	// Members are the users the list grants roles to.
+	Members []string `json:"members,omitempty"`
+	// Roles are the roles granted to the members.
+	Roles []string `json:"roles"`
+	// ReviewInterval is how often the owners have to re-attest the
+	// membership. Defaults to ninety days.
+	ReviewInterval time.Duration `json:"review_interval,omitempty"`
+	// LastReviewed is when an owner last attested the membership.
+	LastReviewed time.Time `json:"last_reviewed,omitempty"`
+	// UpdatedAt is the time the list was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the access list and fills in defaults.
+func (l *AccessList) CheckAndSetDefaults() error {
+	if l.Name == "" {
+		return trace.BadParameter("access list name is required")
+	}
+	if len(l.Owners) == 0 {
+		return trace.BadParameter("access list %q requires at least one owner", l.Name)
+	}
+	if len(l.Roles) == 0 {
+		return trace.BadParameter("access list %q must grant at least one role", l.Name)
+	}
+	if l.ReviewInterval < 0 {
+		return trace.BadParameter("access list %q review interval cannot be negative", l.Name)
+	}
+	if l.ReviewInterval == 0 {
+		l.ReviewInterval = defaultAccessListReviewInterval
+	}
+	return nil
+}
+
+// reviewedAsOf reports whether the membership attestation is still fresh at
+// the given time.
+func (l *AccessList) reviewedAsOf(now time.Time) bool {
+	return now.Before(l.LastReviewed.Add(l.ReviewInterval))
+}
+
+// UpsertAccessList creates or updates an access list. The review clock of a
+// new list starts at creation time.
+func (a *Server) UpsertAccessList(ctx context.Context, list AccessList) error {
+	if err := list.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if list.LastReviewed.IsZero() {
+		list.LastReviewed = a.clock.Now().UTC()
+	}
+	list.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(list)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(accessListPrefix, list.Name),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetAccessList returns the access list with the given name.
+func (a *Server) GetAccessList(ctx context.Context, name string) (*AccessList, error) {
+	if name == "" {
+		return nil, trace.BadParameter("access list name is required")
+	}
+	item, err := a.bk.Get(ctx, backend.Key(accessListPrefix, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("access list %q is not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var list AccessList
+	if err := utils.FastUnmarshal(item.Value, &list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &list, nil
+}
+
+// GetAccessLists returns all defined access lists.
+func (a *Server) GetAccessLists(ctx context.Context) ([]AccessList, error) {
+	startKey := backend.Key(accessListPrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	lists := make([]AccessList, 0, len(result.Items))
+	for _, item := range result.Items {
+		var list AccessList
+		if err := utils.FastUnmarshal(item.Value, &list); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// DeleteAccessList removes the access list with the given name.
+func (a *Server) DeleteAccessList(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("access list name is required")
+	}
+	err := a.bk.Delete(ctx, backend.Key(accessListPrefix, name))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("access list %q is not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+// ReviewAccessList records a membership attestation, restarting the review
+// clock of the list.
+func (a *Server) ReviewAccessList(ctx context.Context, name string) (*AccessList, error) {
+	list, err := a.GetAccessList(ctx, name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	list.LastReviewed = a.clock.Now().UTC()
+	if err := a.UpsertAccessList(ctx, *list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return list, nil
+}
+
+// accessListRolesForUser returns the roles granted to the user by access
+// lists whose membership attestation is still fresh. Stale lists grant
+// nothing until an owner reviews them.
+func (a *Server) accessListRolesForUser(ctx context.Context, username string) ([]string, error) {
+	lists, err := a.GetAccessLists(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	now := a.clock.Now()
+	var roles []string
+	for _, list := range lists {
+		if !apiutils.SliceContainsStr(list.Members, username) {
+			continue
+		}
+		if !list.reviewedAsOf(now) {
+			log.Debugf("Access list %q went unreviewed past its interval, not granting its roles to %v.", list.Name, username)
+			continue
+		}
+		roles = append(roles, list.Roles...)
+	}
+	return apiutils.Deduplicate(roles), nil
+}
+
+// UpsertAccessList creates or updates an access list.
+func (a *ServerWithRoles) UpsertAccessList(ctx context.Context, list AccessList) error {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertAccessList(ctx, list)
+}
+
+// GetAccessList returns the access list with the given name.
+func (a *ServerWithRoles) GetAccessList(ctx context.Context, name string) (*AccessList, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessList(ctx, name)
+}
+
+// GetAccessLists returns all defined access lists.
+func (a *ServerWithRoles) GetAccessLists(ctx context.Context) ([]AccessList, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessLists(ctx)
+}
+
+// DeleteAccessList removes the access list with the given name.
+func (a *ServerWithRoles) DeleteAccessList(ctx context.Context, name string) error {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAccessList(ctx, name)
+}
+
+// ReviewAccessList records a membership attestation. Owners may review
+// their own lists, reviewing someone else's list requires update access to
+// users.
+func (a *ServerWithRoles) ReviewAccessList(ctx context.Context, name string) (*AccessList, error) {
+	list, err := a.authServer.GetAccessList(ctx, name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !apiutils.SliceContainsStr(list.Owners, a.context.User.GetName()) {
+		if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return a.authServer.ReviewAccessList(ctx, name)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessListCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Lists without a name, owners or roles are rejected.
+	err := s.a.UpsertAccessList(ctx, AccessList{Owners: []string{"alice"}, Roles: []string{"dev"}})
+	require.True(t, trace.IsBadParameter(err))
+	err = s.a.UpsertAccessList(ctx, AccessList{Name: "oncall", Roles: []string{"dev"}})
+	require.True(t, trace.IsBadParameter(err))
+	err = s.a.UpsertAccessList(ctx, AccessList{Name: "oncall", Owners: []string{"alice"}})
+	require.True(t, trace.IsBadParameter(err))
+	err = s.a.UpsertAccessList(ctx, AccessList{
+		Name:           "oncall",
+		Owners:         []string{"alice"},
+		Roles:          []string{"dev"},
+		ReviewInterval: -time.Hour,
+	})
+	require.True(t, trace.IsBadParameter(err))
+
+	err = s.a.UpsertAccessList(ctx, AccessList{
+		Name:    "oncall",
+		Owners:  []string{"alice"},
+		Members: []string{"bob"},
+		Roles:   []string{"dev"},
+	})
+	require.NoError(t, err)
+
+	list, err := s.a.GetAccessList(ctx, "oncall")
+	require.NoError(t, err)
+	// The review interval defaults and the review clock starts at creation.
+	require.Equal(t, defaultAccessListReviewInterval, list.ReviewInterval)
+	require.False(t, list.LastReviewed.IsZero())
+
+	lists, err := s.a.GetAccessLists(ctx)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+
+	require.NoError(t, s.a.DeleteAccessList(ctx, "oncall"))
+	_, err = s.a.GetAccessList(ctx, "oncall")
+	require.True(t, trace.IsNotFound(err))
+	err = s.a.DeleteAccessList(ctx, "oncall")
+	require.True(t, trace.IsNotFound(err))
+}
+
+func TestAccessListMembership(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	clock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(clock)
+
+	err := s.a.UpsertAccessList(ctx, AccessList{
+		Name:           "oncall",
+		Owners:         []string{"alice"},
+		Members:        []string{"bob"},
+		Roles:          []string{"dev", "access"},
+		ReviewInterval: 30 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	err = s.a.UpsertAccessList(ctx, AccessList{
+		Name:           "dbas",
+		Owners:         []string{"alice"},
+		Members:        []string{"bob", "carol"},
+		Roles:          []string{"dba", "access"},
+		ReviewInterval: 30 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	// Fresh lists grant their roles to members, deduplicated across lists.
+	roles, err := s.a.accessListRolesForUser(ctx, "bob")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"dev", "dba", "access"}, roles)
+
+	roles, err = s.a.accessListRolesForUser(ctx, "carol")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"dba", "access"}, roles)
+
+	// Non-members get nothing.
+	roles, err = s.a.accessListRolesForUser(ctx, "mallory")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	// Once the review interval lapses, the grants stop.
+	clock.Advance(31 * 24 * time.Hour)
+	roles, err = s.a.accessListRolesForUser(ctx, "bob")
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	// Reviewing a list restarts its clock and restores the grant.
+	list, err := s.a.ReviewAccessList(ctx, "oncall")
+	require.NoError(t, err)
+	require.Equal(t, clock.Now().UTC(), list.LastReviewed)
+	roles, err = s.a.accessListRolesForUser(ctx, "bob")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"dev", "access"}, roles)
+}
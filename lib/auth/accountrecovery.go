@@ -72,6 +72,14 @@ func (s *Server) StartAccountRecovery(ctx context.Context, req *proto.StartAccou
 		return nil, trace.Wrap(err)
 	}
 
+	policy, err := s.GetRecoveryFlowPolicy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !policy.VerifierEnabled(RecoveryVerifierCodes) {
+		return nil, trace.AccessDenied("recovery codes are not an enabled recovery verifier, ask your administrator to approve the recovery instead")
+	}
+
 	// Only user's with email as their username can start recovery.
 	if _, err := mail.ParseAddress(req.GetUsername()); err != nil {
 		log.Debugf("Failed to start account recovery, user %s is not in valid email format", req.GetUsername())
@@ -179,6 +187,9 @@ func (s *Server) verifyRecoveryCode(ctx context.Context, user string, givenCode
 			log.Error(trace.DebugReport(err))
 			return trace.AccessDenied(startRecoveryGenericErrMsg)
 		}
+		if remaining := countUnusedRecoveryCodes(recovery.GetCodes()); remaining == 0 {
+			log.Warnf("User %q used their last recovery code, new codes should be generated.", user)
+		}
 		break
 	}
 
@@ -610,3 +621,50 @@ func generateRecoveryCodes() ([]string, error) {
 
 	return tokenList, nil
 }
+
+// countUnusedRecoveryCodes returns how many of the given codes have not been
+// consumed yet.
+func countUnusedRecoveryCodes(codes []types.RecoveryCode) int {
+	var unused int
+	for _, code := range codes {
+		if !code.IsUsed {
+			unused++
+		}
+	}
+	return unused
+}
+
+// RecoveryCodesSummary describes the state of a user's recovery codes without
+// revealing the codes themselves.
+type RecoveryCodesSummary struct {
+	// Created is when the current set of codes was generated.
+	Created time.Time `json:"created"`
+	// Total is the number of codes in the set.
+	Total int `json:"total"`
+	// Remaining is the number of codes not consumed yet.
+	Remaining int `json:"remaining"`
+}
+
+// GetRecoveryCodesSummary returns how many recovery codes the user has left,
+// so running low on codes can be noticed before the user is locked out.
+func (s *Server) GetRecoveryCodesSummary(ctx context.Context, username string) (*RecoveryCodesSummary, error) {
+	rc, err := s.GetRecoveryCodes(ctx, username, true /* withSecrets */)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	codes := rc.GetCodes()
+	return &RecoveryCodesSummary{
+		Created:   rc.Spec.Created,
+		Total:     len(codes),
+		Remaining: countUnusedRecoveryCodes(codes),
+	}, nil
+}
+
+// GetRecoveryCodesSummary returns how many recovery codes the user has left,
+// so running low on codes can be noticed before the user is locked out.
+func (a *ServerWithRoles) GetRecoveryCodesSummary(ctx context.Context, username string) (*RecoveryCodesSummary, error) {
+	if err := a.currentUserAction(username); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetRecoveryCodesSummary(ctx, username)
+}
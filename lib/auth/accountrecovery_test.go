@@ -1405,3 +1405,29 @@ func getMockedWebauthnAndRegisterRes(authSrv *Server, tokenID string, usage prot
 
 	return dev, regRes, trace.Wrap(err)
 }
+
+func TestGetRecoveryCodesSummary(t *testing.T) {
+	t.Parallel()
+	srv := newTestTLSServer(t)
+	ctx := context.Background()
+
+	user := "summary@fake.com"
+	rc, err := srv.Auth().generateAndUpsertRecoveryCodes(ctx, user)
+	require.NoError(t, err)
+
+	summary, err := srv.Auth().GetRecoveryCodesSummary(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, numOfRecoveryCodes, summary.Total)
+	require.Equal(t, numOfRecoveryCodes, summary.Remaining)
+	require.False(t, summary.Created.IsZero())
+
+	// Consuming a code lowers the remaining count.
+	require.NoError(t, srv.Auth().verifyRecoveryCode(ctx, user, []byte(rc.Codes[0])))
+	summary, err = srv.Auth().GetRecoveryCodesSummary(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, numOfRecoveryCodes-1, summary.Remaining)
+
+	// Users without recovery codes return NotFound.
+	_, err = srv.Auth().GetRecoveryCodesSummary(ctx, "no-codes@fake.com")
+	require.True(t, trace.IsNotFound(err))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/inventory"
+)
+
+// AgentInventoryEntry describes one agent known to the cluster, aggregated
+// from its presence heartbeats and, when one is held open, its inventory
+// control stream.
+type AgentInventoryEntry struct {
+	// ServerID is the host UUID of the agent.
+	ServerID string `json:"server_id"`
+	// Hostname is the hostname the agent reported, when known.
+	Hostname string `json:"hostname,omitempty"`
+	// Version is the Teleport version the agent runs.
+	Version string `json:"version,omitempty"`
+	// Addr is the advertised address, empty for tunnel agents.
+	Addr string `json:"addr,omitempty"`
+	// OS is the operating system reported via the agent's "os" label,
+	// empty when the agent does not report one.
+	OS string `json:"os,omitempty"`
+	// Services are the services the agent runs, e.g. Node or Proxy.
+	Services []string `json:"services"`
+	// Connected is true when the agent currently holds an inventory
+	// control stream open to this auth server.
+	Connected bool `json:"connected"`
+	// LastSeen is the time of the agent's most recent heartbeat, derived
+	// from the expiry of its presence record.
+	LastSeen time.Time `json:"last_seen,omitempty"`
+}
+
+// AgentInventory lists every agent known to the cluster together with a
+// version histogram, so outdated agents can be found before upgrades.
+type AgentInventory struct {
+	// Agents are the known agents sorted by server ID.
+	Agents []AgentInventoryEntry `json:"agents"`
+	// VersionCounts is the number of agents per reported version.
+	VersionCounts map[string]int `json:"version_counts"`
+}
+
+// GetAgentInventory aggregates agent version and capability information
+// from presence heartbeats and open inventory control streams.
+func (a *Server) GetAgentInventory(ctx context.Context) (*AgentInventory, error) {
+	byID := make(map[string]*AgentInventoryEntry)
+	entry := func(serverID string) *AgentInventoryEntry {
+		e, ok := byID[serverID]
+		if !ok {
+			e = &AgentInventoryEntry{ServerID: serverID}
+			byID[serverID] = e
+		}
+		return e
+	}
+	addService := func(e *AgentInventoryEntry, service types.SystemRole) {
+		name := string(service)
+		for _, existing := range e.Services {
+			if existing == name {
+				return
+			}
+		}
+		e.Services = append(e.Services, name)
+	}
+	fromServer := func(server types.Server, service types.SystemRole) {
+		e := entry(server.GetName())
+		e.Hostname = server.GetHostname()
+		e.Addr = server.GetAddr()
+		if version := server.GetTeleportVersion(); version != "" {
+			e.Version = version
+		}
+		if os, ok := server.GetAllLabels()["os"]; ok {
+			e.OS = os
+		}
+		// heartbeats are announced with a fixed TTL, so the expiry of the
+		// presence record puts a timestamp on the last one
+		if expiry := server.Expiry(); !expiry.IsZero() {
+			lastSeen := expiry.Add(-apidefaults.ServerAnnounceTTL)
+			if lastSeen.After(e.LastSeen) {
+				e.LastSeen = lastSeen
+			}
+		}
+		addService(e, service)
+	}
+
+	nodes, err := a.GetNodes(ctx, apidefaults.Namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, node := range nodes {
+		fromServer(node, types.RoleNode)
+	}
+	proxies, err := a.GetProxies()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, proxy := range proxies {
+		fromServer(proxy, types.RoleProxy)
+	}
+	authServers, err := a.GetAuthServers()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, authServer := range authServers {
+		fromServer(authServer, types.RoleAuth)
+	}
+
+	// agents holding a control stream report their version and services
+	// directly, which wins over the presence-derived values
+	a.inventory.Iter(func(handle inventory.UpstreamHandle) {
+		hello := handle.Hello()
+		e := entry(hello.ServerID)
+		e.Connected = true
+		if hello.Version != "" {
+			e.Version = hello.Version
+		}
+		for _, service := range hello.Services {
+			addService(e, service)
+		}
+	})
+
+	result := &AgentInventory{
+		Agents:        make([]AgentInventoryEntry, 0, len(byID)),
+		VersionCounts: make(map[string]int),
+	}
+	for _, e := range byID {
+		sort.Strings(e.Services)
+		result.Agents = append(result.Agents, *e)
+		result.VersionCounts[e.Version]++
+	}
+	sort.Slice(result.Agents, func(i, j int) bool {
+		return result.Agents[i].ServerID < result.Agents[j].ServerID
+	})
+	return result, nil
+}
+
+// GetAgentInventory aggregates agent version and capability information
+// from presence heartbeats and open inventory control streams.
+func (a *ServerWithRoles) GetAgentInventory(ctx context.Context) (*AgentInventory, error) {
+	if err := a.action(apidefaults.Namespace, types.KindNode, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAgentInventory(ctx)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestGetAgentInventory(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	node := &types.ServerV2{
+		Kind:    types.KindNode,
+		Version: types.V2,
+		Metadata: types.Metadata{
+			Name:      "node-1",
+			Namespace: apidefaults.Namespace,
+			Labels:    map[string]string{"os": "linux"},
+		},
+		Spec: types.ServerSpecV2{
+			Hostname: "node-1.example.com",
+			Addr:     "127.0.0.1:3022",
+			Version:  "10.3.1",
+		},
+	}
+	node.SetExpiry(s.a.clock.Now().Add(apidefaults.ServerAnnounceTTL))
+	_, err := s.a.UpsertNode(ctx, node)
+	require.NoError(t, err)
+
+	proxy := &types.ServerV2{
+		Kind:    types.KindProxy,
+		Version: types.V2,
+		Metadata: types.Metadata{
+			Name:      "proxy-1",
+			Namespace: apidefaults.Namespace,
+		},
+		Spec: types.ServerSpecV2{
+			Hostname: "proxy-1.example.com",
+			Version:  "11.0.0",
+		},
+	}
+	require.NoError(t, s.a.UpsertProxy(proxy))
+
+	inventory, err := s.a.GetAgentInventory(ctx)
+	require.NoError(t, err)
+
+	byID := make(map[string]AgentInventoryEntry)
+	for _, agent := range inventory.Agents {
+		byID[agent.ServerID] = agent
+	}
+	require.Contains(t, byID, "node-1")
+	require.Contains(t, byID, "proxy-1")
+
+	require.Equal(t, "10.3.1", byID["node-1"].Version)
+	require.Equal(t, "linux", byID["node-1"].OS)
+	require.Equal(t, []string{string(types.RoleNode)}, byID["node-1"].Services)
+	require.False(t, byID["node-1"].Connected)
+	require.WithinDuration(t, s.a.clock.Now(), byID["node-1"].LastSeen, time.Second)
+
+	require.Equal(t, "11.0.0", byID["proxy-1"].Version)
+	require.Equal(t, []string{string(types.RoleProxy)}, byID["proxy-1"].Services)
+
+	require.Equal(t, 1, inventory.VersionCounts["10.3.1"])
+	require.Equal(t, 1, inventory.VersionCounts["11.0.0"])
+}
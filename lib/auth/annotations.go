@@ -0,0 +1,164 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// resourceAnnotationsPrefix is the backend prefix under which resource
+// annotations are stored.
+const resourceAnnotationsPrefix = "resource_annotations"
+
+const (
+	// maxAnnotationCount caps the number of annotations per resource.
+	maxAnnotationCount = 64
+	// maxAnnotationKeyLength caps the length of an annotation key.
+	maxAnnotationKeyLength = 256
+	// maxAnnotationValueLength caps the length of an annotation value, large
+	// enough for runbook URLs but well below label-style abuse.
+	maxAnnotationValueLength = 2048
+)
+
+// annotatableKinds is the set of resource kinds annotations can be attached
+// to. Annotations carry external system references (CMDB IDs, owner emails,
+// runbook URLs); unlike labels they are never used for RBAC decisions.
+var annotatableKinds = []string{
+	types.KindNode,
+	types.KindApp,
+	types.KindDatabase,
+	types.KindKubernetesCluster,
+	types.KindWindowsDesktop,
+}
+
+// checkAnnotatableKind verifies that the given resource kind supports
+// annotations.
+func checkAnnotatableKind(kind string) error {
+	for _, k := range annotatableKinds {
+		if k == kind {
+			return nil
+		}
+	}
+	return trace.BadParameter("resource kind %q does not support annotations, supported kinds: %v", kind, annotatableKinds)
+}
+
+// validateAnnotations enforces the size limits on an annotations map.
+func validateAnnotations(annotations map[string]string) error {
+	if len(annotations) > maxAnnotationCount {
+		return trace.BadParameter("too many annotations: %v, the limit is %v", len(annotations), maxAnnotationCount)
+	}
+	for key, value := range annotations {
+		if key == "" {
+			return trace.BadParameter("annotation keys can not be empty")
+		}
+		if len(key) > maxAnnotationKeyLength {
+			return trace.BadParameter("annotation key %q exceeds the maximum length of %v", key[:maxAnnotationKeyLength], maxAnnotationKeyLength)
+		}
+		if len(value) > maxAnnotationValueLength {
+			return trace.BadParameter("value of annotation %q exceeds the maximum length of %v", key, maxAnnotationValueLength)
+		}
+	}
+	return nil
+}
+
+func resourceAnnotationsKey(kind, name string) []byte {
+	return backend.Key(resourceAnnotationsPrefix, kind, name)
+}
+
+// SetResourceAnnotations replaces the annotations attached to the resource
+// with the given kind and name. An empty map removes all annotations.
+func (a *Server) SetResourceAnnotations(ctx context.Context, kind, name string, annotations map[string]string) error {
+	if err := checkAnnotatableKind(kind); err != nil {
+		return trace.Wrap(err)
+	}
+	if name == "" {
+		return trace.BadParameter("missing resource name")
+	}
+	if err := validateAnnotations(annotations); err != nil {
+		return trace.Wrap(err)
+	}
+	if len(annotations) == 0 {
+		if err := a.bk.Delete(ctx, resourceAnnotationsKey(kind, name)); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+	value, err := utils.FastMarshal(annotations)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   resourceAnnotationsKey(kind, name),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetResourceAnnotations returns the annotations attached to the resource
+// with the given kind and name. Resources without annotations yield an empty
+// map.
+func (a *Server) GetResourceAnnotations(ctx context.Context, kind, name string) (map[string]string, error) {
+	if err := checkAnnotatableKind(kind); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if name == "" {
+		return nil, trace.BadParameter("missing resource name")
+	}
+	item, err := a.bk.Get(ctx, resourceAnnotationsKey(kind, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	annotations := map[string]string{}
+	if err := utils.FastUnmarshal(item.Value, &annotations); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return annotations, nil
+}
+
+// SetResourceAnnotations replaces the annotations attached to a resource.
+// Annotations are never used for RBAC decisions, so updating them requires
+// update access to the annotated resource kind only.
+func (a *ServerWithRoles) SetResourceAnnotations(ctx context.Context, kind, name string, annotations map[string]string) error {
+	if err := checkAnnotatableKind(kind); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(apidefaults.Namespace, kind, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetResourceAnnotations(ctx, kind, name, annotations)
+}
+
+// GetResourceAnnotations returns the annotations attached to a resource.
+func (a *ServerWithRoles) GetResourceAnnotations(ctx context.Context, kind, name string) (map[string]string, error) {
+	if err := checkAnnotatableKind(kind); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(apidefaults.Namespace, kind, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetResourceAnnotations(ctx, kind, name)
+}
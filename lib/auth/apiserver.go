@@ -91,11 +91,72 @@ func NewAPIServer(config *APIConfig) (http.Handler, error) {
 	// Operations on certificate authorities
 	srv.GET("/:version/domain", srv.withAuth(srv.getDomainName))    // DELETE IN 11.0.0 REST method replaced by gRPC
 	srv.GET("/:version/cacert", srv.withAuth(srv.getClusterCACert)) // DELETE IN 11.0.0 REST method replaced by gRPC
+	srv.GET("/:version/cacertpins", srv.withAuth(srv.getClusterCACertPins))
 
 	srv.POST("/:version/authorities/:type", srv.withAuth(srv.upsertCertAuthority))
 	srv.POST("/:version/authorities/:type/rotate", srv.withAuth(srv.rotateCertAuthority))
 	srv.POST("/:version/authorities/:type/rotate/external", srv.withAuth(srv.rotateExternalCertAuthority))
+	srv.POST("/:version/externalauthorities/rotate", srv.withAuth(srv.rotateExternalCertAuthorities))
+	srv.POST("/:version/authorities/:type/backup", srv.withAuth(srv.exportCAKeyBackup))
+	srv.POST("/:version/authorities/:type/restore", srv.withAuth(srv.importCAKeyBackup))
 	srv.DELETE("/:version/authorities/:type/:domain", srv.withAuth(srv.deleteCertAuthority))
+	srv.POST("/:version/scopedcerts", srv.withAuth(srv.createScopedAPICerts))
+	srv.POST("/:version/attestedcerts", srv.withAuth(srv.generateUserCertsWithAttestation))
+	srv.POST("/:version/backendencryption/rotate", srv.withAuth(srv.rotateBackendEncryptionKey))
+	srv.GET("/:version/preflight", srv.withAuth(srv.runPreflightChecks))
+	srv.GET("/:version/certkeypolicy", srv.withAuth(srv.getCertKeyPolicy))
+	srv.POST("/:version/certkeypolicy", srv.withAuth(srv.setCertKeyPolicy))
+	srv.POST("/:version/subjectkeypair", srv.withAuth(srv.generateSubjectKeyPair))
+	srv.POST("/:version/outputtemplates", srv.withAuth(srv.upsertOutputTemplate))
+	srv.GET("/:version/outputtemplates", srv.withAuth(srv.getOutputTemplates))
+	srv.DELETE("/:version/outputtemplates/:name", srv.withAuth(srv.deleteOutputTemplate))
+	srv.POST("/:version/outputtemplates/render", srv.withAuth(srv.renderOutputTemplates))
+	srv.GET("/:version/bots", srv.withAuth(srv.getBots))
+	srv.GET("/:version/bots/:name", srv.withAuth(srv.getBot))
+	srv.GET("/:version/configbundle", srv.withAuth(srv.generateUserConfigBundle))
+	srv.POST("/:version/workloadidentity/token", srv.withAuth(srv.generateWorkloadIdentityToken))
+	srv.POST("/:version/accessbundles", srv.withAuth(srv.upsertAccessBundle))
+	srv.GET("/:version/accessbundles", srv.withAuth(srv.getAccessBundles))
+	srv.GET("/:version/accessbundles/:name", srv.withAuth(srv.getAccessBundle))
+	srv.DELETE("/:version/accessbundles/:name", srv.withAuth(srv.deleteAccessBundle))
+	srv.POST("/:version/accessbundles/:name/request", srv.withAuth(srv.createAccessRequestFromBundle))
+
+	// Scheduled (future-start) access requests
+	srv.POST("/:version/accessrequests/scheduled", srv.withAuth(srv.createScheduledAccessRequest))
+
+	// Access lists with periodic membership reviews
+	srv.POST("/:version/accesslists", srv.withAuth(srv.upsertAccessList))
+	srv.GET("/:version/accesslists", srv.withAuth(srv.getAccessLists))
+	srv.GET("/:version/accesslists/:name", srv.withAuth(srv.getAccessList))
+	srv.DELETE("/:version/accesslists/:name", srv.withAuth(srv.deleteAccessList))
+	srv.POST("/:version/accesslists/:name/review", srv.withAuth(srv.reviewAccessList))
+
+	// Access request previews (dry runs)
+	srv.POST("/:version/accessrequests/preview", srv.withAuth(srv.previewAccessRequest))
+
+	// Auto-approval rules for access requests
+	srv.POST("/:version/autoapprovalrules", srv.withAuth(srv.upsertAccessAutoApprovalRule))
+	srv.GET("/:version/autoapprovalrules", srv.withAuth(srv.getAccessAutoApprovalRules))
+	srv.DELETE("/:version/autoapprovalrules/:name", srv.withAuth(srv.deleteAccessAutoApprovalRule))
+
+	// Elevation duration limits for approved access requests
+	srv.POST("/:version/accessrequests/duration", srv.withAuth(srv.createAccessRequestWithMaxDuration))
+	srv.POST("/:version/accessrequestdurationpolicy", srv.withAuth(srv.setAccessRequestDurationPolicy))
+	srv.GET("/:version/accessrequestdurationpolicy", srv.withAuth(srv.getAccessRequestDurationPolicy))
+	srv.DELETE("/:version/accessrequestdurationpolicy", srv.withAuth(srv.deleteAccessRequestDurationPolicy))
+	srv.POST("/:version/accessescalation", srv.withAuth(srv.setAccessEscalationPolicy))
+	srv.GET("/:version/accessescalation", srv.withAuth(srv.getAccessEscalationPolicy))
+	srv.POST("/:version/hostprincipalpolicy", srv.withAuth(srv.setHostPrincipalPolicy))
+	srv.GET("/:version/hostprincipalpolicy", srv.withAuth(srv.getHostPrincipalPolicy))
+	srv.POST("/:version/dataresidency/policy", srv.withAuth(srv.setDataResidencyPolicy))
+	srv.GET("/:version/dataresidency/policy", srv.withAuth(srv.getDataResidencyPolicy))
+	srv.GET("/:version/dataresidency/status", srv.withAuth(srv.getDataResidencyStatus))
+	srv.GET("/:version/certissuances/:user", srv.withAuth(srv.getCertIssuances))
+	srv.POST("/:version/lockpropagation/acks", srv.withAuth(srv.ackLockPropagation))
+	srv.GET("/:version/lockpropagation/:lock", srv.withAuth(srv.getLockPropagationStatus))
+	srv.POST("/:version/spiffe/federations", srv.withAuth(srv.upsertSPIFFEFederation))
+	srv.GET("/:version/spiffe/federations", srv.withAuth(srv.getSPIFFEFederations))
+	srv.DELETE("/:version/spiffe/federations/:trust_domain", srv.withAuth(srv.deleteSPIFFEFederation))
 	srv.GET("/:version/authorities/:type/:domain", srv.withAuth(srv.getCertAuthority))
 	srv.GET("/:version/authorities/:type", srv.withAuth(srv.getCertAuthorities))
 
@@ -111,17 +172,122 @@ func NewAPIServer(config *APIConfig) (http.Handler, error) {
 	srv.POST("/:version/users", srv.withAuth(srv.upsertUser))
 	srv.PUT("/:version/users/:user/web/password", srv.withAuth(srv.changePassword))
 	srv.POST("/:version/users/:user/web/password", srv.withAuth(srv.upsertPassword))
-	srv.POST("/:version/users/:user/web/password/check", srv.withRate(srv.withAuth(srv.checkPassword)))
+	srv.POST("/:version/users/:user/web/password/check", srv.withRate(srv.withAuth(srv.checkPassword))) // DEPRECATED, replaced by the audited verify endpoint below.
+	srv.POST("/:version/users/:user/web/verify", srv.withRate(srv.withAuth(srv.verifyAuthentication)))
 	srv.POST("/:version/users/:user/web/sessions", srv.withAuth(srv.createWebSession))
+	srv.POST("/:version/users/:user/web/stepdown", srv.withAuth(srv.stepDownWebSession))
 	srv.POST("/:version/users/:user/web/authenticate", srv.withAuth(srv.authenticateWebUser))
 	srv.POST("/:version/users/:user/ssh/authenticate", srv.withAuth(srv.authenticateSSHUser))
 	srv.GET("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.getWebSession))
+	srv.GET("/:version/users/:user/web/sessions/:sid/details", srv.withAuth(srv.getWebSessionDetails))
 	srv.DELETE("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.deleteWebSession))
 
 	// Servers and presence heartbeat
 	srv.POST("/:version/namespaces/:namespace/nodes/keepalive", srv.withAuth(srv.keepAliveNode))
+	srv.GET("/:version/namespaces/:namespace/nodehistory", srv.withAuth(srv.getNodesAt))
+
+	// Resource annotations
+	srv.POST("/:version/annotations/:kind/:name", srv.withAuth(srv.setResourceAnnotations))
+	srv.GET("/:version/annotations/:kind/:name", srv.withAuth(srv.getResourceAnnotations))
+
+	// short-lived session join URLs
+	srv.POST("/:version/sessionjoinurls", srv.withAuth(srv.createSessionJoinURL))
+	srv.GET("/:version/sessionjoinurls/:token", srv.withAuth(srv.redeemSessionJoinURL))
+
+	// aggregated access request reporting
+	srv.GET("/:version/accessrequestinsights", srv.withAuth(srv.getAccessRequestInsights))
+
+	// aggregated agent inventory
+	srv.GET("/:version/agentinventory", srv.withAuth(srv.getAgentInventory))
+
+	// cert TTL clamp policies
+	srv.POST("/:version/certttlclamppolicies", srv.withAuth(srv.upsertCertTTLClampPolicy))
+	srv.GET("/:version/certttlclamppolicies", srv.withAuth(srv.getCertTTLClampPolicies))
+	srv.DELETE("/:version/certttlclamppolicies/:name", srv.withAuth(srv.deleteCertTTLClampPolicy))
+
+	// MFA device management
+	srv.POST("/:version/mfadevices/rename", srv.withAuth(srv.renameMFADevice))
+	srv.GET("/:version/mfadevices/legacyusers", srv.withAuth(srv.getLegacyMFAUsers))
+	srv.GET("/:version/users/:user/mfadevices", srv.withAuth(srv.getMFADevicesByUser))
+
+	// recovery code state, without secrets
+	srv.GET("/:version/users/:user/recoverycodes/summary", srv.withAuth(srv.getRecoveryCodesSummary))
+
+	// TOTP parameters
+	srv.POST("/:version/totppolicy", srv.withAuth(srv.setTOTPPolicy))
+	srv.GET("/:version/totppolicy", srv.withAuth(srv.getTOTPPolicy))
+	srv.DELETE("/:version/totppolicy", srv.withAuth(srv.deleteTOTPPolicy))
+
+	// password hashing policy
+	srv.POST("/:version/passwordhashpolicy", srv.withAuth(srv.setPasswordHashPolicy))
+	srv.GET("/:version/passwordhashpolicy", srv.withAuth(srv.getPasswordHashPolicy))
+	srv.DELETE("/:version/passwordhashpolicy", srv.withAuth(srv.deletePasswordHashPolicy))
+
+	// password policy
+	srv.POST("/:version/passwordpolicy", srv.withAuth(srv.setPasswordPolicy))
+	srv.GET("/:version/passwordpolicy", srv.withAuth(srv.getPasswordPolicy))
+	srv.DELETE("/:version/passwordpolicy", srv.withAuth(srv.deletePasswordPolicy))
+
+	// login lockout policy
+	srv.POST("/:version/lockoutpolicy", srv.withAuth(srv.setLockoutPolicy))
+	srv.GET("/:version/lockoutpolicy", srv.withAuth(srv.getLockoutPolicy))
+	srv.DELETE("/:version/lockoutpolicy", srv.withAuth(srv.deleteLockoutPolicy))
+
+	// trusted device enrollment and inventory
+	srv.POST("/:version/trusteddevices", srv.withAuth(srv.enrollDevice))
+	srv.GET("/:version/trusteddevices", srv.withAuth(srv.getTrustedDevices))
+	srv.GET("/:version/trusteddevices/:id", srv.withAuth(srv.getTrustedDevice))
+	srv.DELETE("/:version/trusteddevices/:id", srv.withAuth(srv.deleteTrustedDevice))
+	srv.POST("/:version/devicetrustpolicy", srv.withAuth(srv.setDeviceTrustPolicy))
+	srv.GET("/:version/devicetrustpolicy", srv.withAuth(srv.getDeviceTrustPolicy))
+	srv.DELETE("/:version/devicetrustpolicy", srv.withAuth(srv.deleteDeviceTrustPolicy))
+
+	// concurrent web session limit
+	srv.POST("/:version/websessionlimitpolicy", srv.withAuth(srv.setWebSessionLimitPolicy))
+	srv.GET("/:version/websessionlimitpolicy", srv.withAuth(srv.getWebSessionLimitPolicy))
+	srv.DELETE("/:version/websessionlimitpolicy", srv.withAuth(srv.deleteWebSessionLimitPolicy))
+
+	// SSO MFA delegation
+	srv.POST("/:version/ssomfapolicy", srv.withAuth(srv.setSSOMFAPolicy))
+	srv.GET("/:version/ssomfapolicy", srv.withAuth(srv.getSSOMFAPolicy))
+	srv.DELETE("/:version/ssomfapolicy", srv.withAuth(srv.deleteSSOMFAPolicy))
+
+	// per-connector web session TTL caps
+	srv.POST("/:version/websessionttlpolicy", srv.withAuth(srv.setWebSessionTTLPolicy))
+	srv.GET("/:version/websessionttlpolicy", srv.withAuth(srv.getWebSessionTTLPolicy))
+	srv.DELETE("/:version/websessionttlpolicy", srv.withAuth(srv.deleteWebSessionTTLPolicy))
+
+	// new-device login notification webhook
+	srv.POST("/:version/loginnotificationpolicy", srv.withAuth(srv.setLoginNotificationPolicy))
+	srv.GET("/:version/loginnotificationpolicy", srv.withAuth(srv.getLoginNotificationPolicy))
+	srv.DELETE("/:version/loginnotificationpolicy", srv.withAuth(srv.deleteLoginNotificationPolicy))
+
+	// built-in Slack notifier for access requests
+	srv.POST("/:version/slacknotifier", srv.withAuth(srv.setSlackNotifierConfig))
+	srv.GET("/:version/slacknotifier", srv.withAuth(srv.getSlackNotifierConfig))
+	srv.DELETE("/:version/slacknotifier", srv.withAuth(srv.deleteSlackNotifierConfig))
+
+	// account recovery approval queue and verifier policy
+	srv.POST("/:version/recoveryapprovals", srv.withRate(srv.withAuth(srv.requestRecoveryApproval)))
+	srv.GET("/:version/recoveryapprovals", srv.withAuth(srv.getRecoveryApprovalRequests))
+	srv.POST("/:version/recoveryapprovals/:user/approve", srv.withAuth(srv.approveRecoveryRequest))
+	srv.DELETE("/:version/recoveryapprovals/:user", srv.withAuth(srv.denyRecoveryRequest))
+	srv.POST("/:version/recoveryflowpolicy", srv.withAuth(srv.setRecoveryFlowPolicy))
+	srv.GET("/:version/recoveryflowpolicy", srv.withAuth(srv.getRecoveryFlowPolicy))
+	srv.DELETE("/:version/recoveryflowpolicy", srv.withAuth(srv.deleteRecoveryFlowPolicy))
+
+	// refresh-token style web session renewal
+	srv.POST("/:version/users/:user/web/sessions/:sid/refreshtoken", srv.withAuth(srv.issueWebSessionRefreshToken))
+	srv.POST("/:version/users/:user/web/renew", srv.withAuth(srv.renewWebSession))
+
+	// per-user session inventory and revocation
+	srv.GET("/:version/users/:user/sessions", srv.withAuth(srv.getUserSessions))
+	srv.GET("/:version/users/:user/sessions/metadata", srv.withAuth(srv.getWebSessionsMetadata))
+	srv.DELETE("/:version/users/:user/sessions", srv.withAuth(srv.revokeUserSessions))
 	srv.POST("/:version/authservers", srv.withAuth(srv.upsertAuthServer))
 	srv.GET("/:version/authservers", srv.withAuth(srv.getAuthServers))
+	srv.POST("/:version/authservers/:id/promote", srv.withAuth(srv.promoteAuthServer))
+	srv.GET("/:version/authservers/leadership", srv.withAuth(srv.getAuthLeadership))
 	srv.POST("/:version/proxies", srv.withAuth(srv.upsertProxy))
 	srv.GET("/:version/proxies", srv.withAuth(srv.getProxies))
 	srv.DELETE("/:version/proxies", srv.withAuth(srv.deleteAllProxies))
@@ -147,10 +313,22 @@ func NewAPIServer(config *APIConfig) (http.Handler, error) {
 
 	// trusted clusters
 	srv.POST("/:version/trustedclusters/validate", srv.withAuth(srv.validateTrustedCluster))
+	srv.POST("/:version/trustedclusters/preview", srv.withAuth(srv.previewTrustedCluster))
 
 	// Tokens
 	srv.POST("/:version/tokens", srv.withAuth(srv.generateToken))
+	srv.POST("/:version/tokens/list", srv.withAuth(srv.listTokens))
+	srv.POST("/:version/tokens/bulk", srv.withAuth(srv.generateTokens))
+	srv.POST("/:version/tokens/bundle", srv.withAuth(srv.generateTokenBundle))
 	srv.POST("/:version/tokens/register", srv.withAuth(srv.registerUsingToken))
+	srv.POST("/:version/tokens/register/gcp", srv.withAuth(srv.registerUsingGCPMethod))
+	srv.POST("/:version/tokens/register/kubernetes", srv.withAuth(srv.registerUsingKubernetesMethod))
+	srv.POST("/:version/tokens/register/gitlab", srv.withAuth(srv.registerUsingGitLabMethod))
+	srv.POST("/:version/tokens/register/circleci", srv.withAuth(srv.registerUsingCircleCIMethod))
+	srv.POST("/:version/tokens/register/spiffe", srv.withAuth(srv.registerUsingSPIFFEMethod))
+	srv.POST("/:version/spiffebundles", srv.withAuth(srv.upsertSPIFFETrustBundle))
+	srv.GET("/:version/spiffebundles/:domain", srv.withAuth(srv.getSPIFFETrustBundle))
+	srv.DELETE("/:version/spiffebundles/:domain", srv.withAuth(srv.deleteSPIFFETrustBundle))
 
 	// Active sessions
 	srv.GET("/:version/namespaces/:namespace/sessions/:id/stream", srv.withAuth(srv.getSessionChunk))
@@ -168,6 +346,8 @@ func NewAPIServer(config *APIConfig) (http.Handler, error) {
 	srv.GET("/:version/configuration/static_tokens", srv.withAuth(srv.getStaticTokens))
 	srv.DELETE("/:version/configuration/static_tokens", srv.withAuth(srv.deleteStaticTokens))
 	srv.POST("/:version/configuration/static_tokens", srv.withAuth(srv.setStaticTokens))
+	srv.POST("/:version/configuration/static_tokens/tokens", srv.withAuth(srv.addStaticToken))
+	srv.DELETE("/:version/configuration/static_tokens/tokens/:token", srv.withAuth(srv.removeStaticToken))
 
 	// OIDC
 	srv.POST("/:version/oidc/requests/create", srv.withAuth(srv.createOIDCAuthRequest)) // DELETE in 11.0.0
@@ -330,406 +510,1800 @@ func (s *APIServer) keepAliveNode(auth ClientI, w http.ResponseWriter, r *http.R
 	return message("ok"), nil
 }
 
+// getNodesAt returns the nodes that were present in the namespace at the
+// timestamp given in the 'time' query parameter, RFC 3339 encoded.
+func (s *APIServer) getNodesAt(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("time"))
+	if err != nil {
+		return nil, trace.BadParameter("failed to parse the 'time' query parameter: %v", err)
+	}
+	nodes, err := auth.GetNodesAt(r.Context(), p.ByName("namespace"), at)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nodes, nil
+}
+
+// setResourceAnnotationsReq is the body of a set annotations request.
+type setResourceAnnotationsReq struct {
+	// Annotations is the full replacement annotations map.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// setResourceAnnotations replaces the annotations attached to a resource
+func (s *APIServer) setResourceAnnotations(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setResourceAnnotationsReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetResourceAnnotations(r.Context(), p.ByName("kind"), p.ByName("name"), req.Annotations); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// getResourceAnnotations returns the annotations attached to a resource
+func (s *APIServer) getResourceAnnotations(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	annotations, err := auth.GetResourceAnnotations(r.Context(), p.ByName("kind"), p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return annotations, nil
+}
+
+func (s *APIServer) createSessionJoinURL(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req CreateSessionJoinURLRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	joinURL, err := auth.CreateSessionJoinURL(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return joinURL, nil
+}
+
+func (s *APIServer) redeemSessionJoinURL(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	authorization, err := auth.RedeemSessionJoinURL(r.Context(), p.ByName("token"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return authorization, nil
+}
+
+func (s *APIServer) getAccessRequestInsights(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	insights, err := auth.GetAccessRequestInsights(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return insights, nil
+}
+
+type renameMFADeviceReq struct {
+	Request RenameMFADeviceRequest `json:"request"`
+}
+
+func (s *APIServer) renameMFADevice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req renameMFADeviceReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.RenameMFADevice(r.Context(), req.Request); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getLegacyMFAUsers(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	users, err := auth.GetLegacyMFAUsers(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return users, nil
+}
+
+func (s *APIServer) getMFADevicesByUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	devices, err := auth.GetMFADevicesByUser(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return devices, nil
+}
+
+func (s *APIServer) getRecoveryCodesSummary(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	summary, err := auth.GetRecoveryCodesSummary(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return summary, nil
+}
+
+func (s *APIServer) getAgentInventory(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	inventory, err := auth.GetAgentInventory(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return inventory, nil
+}
+
+type upsertCertTTLClampPolicyReq struct {
+	Policy CertTTLClampPolicy `json:"policy"`
+}
+
+func (s *APIServer) upsertCertTTLClampPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertCertTTLClampPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertCertTTLClampPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getCertTTLClampPolicies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policies, err := auth.GetCertTTLClampPolicies(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policies, nil
+}
+
+func (s *APIServer) deleteCertTTLClampPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteCertTTLClampPolicy(r.Context(), p.ByName("name")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setTOTPPolicyReq struct {
+	Policy TOTPPolicy `json:"policy"`
+}
+
+func (s *APIServer) setTOTPPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setTOTPPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetTOTPPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getTOTPPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetTOTPPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteTOTPPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteTOTPPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setPasswordHashPolicyReq struct {
+	Policy PasswordHashPolicy `json:"policy"`
+}
+
+func (s *APIServer) setPasswordHashPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setPasswordHashPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetPasswordHashPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getPasswordHashPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetPasswordHashPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deletePasswordHashPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeletePasswordHashPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setPasswordPolicyReq struct {
+	Policy PasswordPolicy `json:"policy"`
+}
+
+func (s *APIServer) setPasswordPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setPasswordPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetPasswordPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getPasswordPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetPasswordPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deletePasswordPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeletePasswordPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setLockoutPolicyReq struct {
+	Policy LockoutPolicy `json:"policy"`
+}
+
+func (s *APIServer) setLockoutPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setLockoutPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetLockoutPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getLockoutPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetLockoutPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteLockoutPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteLockoutPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) enrollDevice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req EnrollDeviceRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	device, err := auth.EnrollDevice(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return device, nil
+}
+
+func (s *APIServer) getTrustedDevice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	device, err := auth.GetTrustedDevice(r.Context(), p.ByName("id"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return device, nil
+}
+
+func (s *APIServer) getTrustedDevices(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	devices, err := auth.GetTrustedDevices(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return devices, nil
+}
+
+func (s *APIServer) deleteTrustedDevice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteTrustedDevice(r.Context(), p.ByName("id")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setDeviceTrustPolicyReq struct {
+	Policy DeviceTrustPolicy `json:"policy"`
+}
+
+func (s *APIServer) setDeviceTrustPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setDeviceTrustPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetDeviceTrustPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getDeviceTrustPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetDeviceTrustPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteDeviceTrustPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteDeviceTrustPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setWebSessionLimitPolicyReq struct {
+	Policy WebSessionLimitPolicy `json:"policy"`
+}
+
+func (s *APIServer) setWebSessionLimitPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setWebSessionLimitPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetWebSessionLimitPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getWebSessionLimitPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetWebSessionLimitPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteWebSessionLimitPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteWebSessionLimitPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setSSOMFAPolicyReq struct {
+	Policy SSOMFAPolicy `json:"policy"`
+}
+
+func (s *APIServer) setSSOMFAPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setSSOMFAPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetSSOMFAPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getSSOMFAPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetSSOMFAPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteSSOMFAPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteSSOMFAPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setWebSessionTTLPolicyReq struct {
+	Policy WebSessionTTLPolicy `json:"policy"`
+}
+
+func (s *APIServer) setWebSessionTTLPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setWebSessionTTLPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetWebSessionTTLPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getWebSessionTTLPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetWebSessionTTLPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteWebSessionTTLPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteWebSessionTTLPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setLoginNotificationPolicyReq struct {
+	Policy LoginNotificationPolicy `json:"policy"`
+}
+
+func (s *APIServer) setLoginNotificationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setLoginNotificationPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetLoginNotificationPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getLoginNotificationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetLoginNotificationPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteLoginNotificationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteLoginNotificationPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setSlackNotifierConfigReq struct {
+	Config SlackNotifierConfig `json:"config"`
+}
+
+func (s *APIServer) setSlackNotifierConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setSlackNotifierConfigReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetSlackNotifierConfig(r.Context(), req.Config); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getSlackNotifierConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	config, err := auth.GetSlackNotifierConfig(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return config, nil
+}
+
+func (s *APIServer) deleteSlackNotifierConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteSlackNotifierConfig(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) requestRecoveryApproval(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req RecoveryApprovalRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := auth.RequestRecoveryApproval(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return out, nil
+}
+
+func (s *APIServer) getRecoveryApprovalRequests(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	requests, err := auth.GetRecoveryApprovalRequests(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return requests, nil
+}
+
+func (s *APIServer) approveRecoveryRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	token, err := auth.ApproveRecoveryRequest(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := services.MarshalUserToken(token)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return json.RawMessage(out), nil
+}
+
+func (s *APIServer) denyRecoveryRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DenyRecoveryRequest(r.Context(), p.ByName("user")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type setRecoveryFlowPolicyReq struct {
+	Policy RecoveryFlowPolicy `json:"policy"`
+}
+
+func (s *APIServer) setRecoveryFlowPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setRecoveryFlowPolicyReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetRecoveryFlowPolicy(r.Context(), req.Policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getRecoveryFlowPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetRecoveryFlowPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+func (s *APIServer) deleteRecoveryFlowPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteRecoveryFlowPolicy(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 // upsertProxy is called by remote SSH nodes when they ping back into the auth service
 func (s *APIServer) upsertProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	return s.upsertServer(auth, types.RoleProxy, r, p)
 }
 
-// getProxies returns registered proxies
-func (s *APIServer) getProxies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	servers, err := auth.GetProxies()
+// getProxies returns registered proxies
+func (s *APIServer) getProxies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	servers, err := auth.GetProxies()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return marshalServers(servers, version)
+}
+
+// deleteAllProxies deletes all proxies
+func (s *APIServer) deleteAllProxies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	err := auth.DeleteAllProxies()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// deleteProxy deletes proxy
+func (s *APIServer) deleteProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	name := p.ByName("name")
+	if name == "" {
+		return nil, trace.BadParameter("missing proxy name")
+	}
+	err := auth.DeleteProxy(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// upsertAuthServer is called by remote Auth servers when they ping back into the auth service
+func (s *APIServer) upsertAuthServer(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	return s.upsertServer(auth, types.RoleAuth, r, p)
+}
+
+// getAuthServers returns registered auth servers
+func (s *APIServer) getAuthServers(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	servers, err := auth.GetAuthServers()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return marshalServers(servers, version)
+}
+
+// promoteAuthServer promotes an auth server to the primary role
+func (s *APIServer) promoteAuthServer(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	leadership, err := auth.PromoteAuthServer(r.Context(), p.ByName("id"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return leadership, nil
+}
+
+// getAuthLeadership returns the current auth server leadership record
+func (s *APIServer) getAuthLeadership(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	leadership, err := auth.GetAuthLeadership(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return leadership, nil
+}
+
+func marshalServers(servers []types.Server, version string) (interface{}, error) {
+	items := make([]json.RawMessage, len(servers))
+	for i, server := range servers {
+		data, err := services.MarshalServer(server, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items[i] = data
+	}
+	return items, nil
+}
+
+type upsertReverseTunnelRawReq struct {
+	ReverseTunnel json.RawMessage `json:"reverse_tunnel"`
+	TTL           time.Duration   `json:"ttl"`
+}
+
+// upsertReverseTunnel is called by admin to create a reverse tunnel to remote proxy
+func (s *APIServer) upsertReverseTunnel(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertReverseTunnelRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	tun, err := services.UnmarshalReverseTunnel(req.ReverseTunnel)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := services.ValidateReverseTunnel(tun); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.TTL != 0 {
+		tun.SetExpiry(s.Now().UTC().Add(req.TTL))
+	}
+	if err := auth.UpsertReverseTunnel(tun); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+// getReverseTunnels returns a list of reverse tunnels
+func (s *APIServer) getReverseTunnels(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	reverseTunnels, err := auth.GetReverseTunnels(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	items := make([]json.RawMessage, len(reverseTunnels))
+	for i, tunnel := range reverseTunnels {
+		data, err := services.MarshalReverseTunnel(tunnel, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items[i] = data
+	}
+	return items, nil
+}
+
+// deleteReverseTunnel deletes reverse tunnel
+func (s *APIServer) deleteReverseTunnel(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	domainName := p.ByName("domain")
+	err := auth.DeleteReverseTunnel(domainName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("reverse tunnel %v deleted", domainName)), nil
+}
+
+func (s *APIServer) previewTrustedCluster(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req previewTrustedClusterRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	tc, err := services.UnmarshalTrustedCluster(req.TrustedCluster)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	preview, err := auth.PreviewTrustedCluster(r.Context(), tc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return preview, nil
+}
+
+func (s *APIServer) validateTrustedCluster(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var validateRequestRaw ValidateTrustedClusterRequestRaw
+	if err := httplib.ReadJSON(r, &validateRequestRaw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	validateRequest, err := validateRequestRaw.ToNative()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	validateResponse, err := auth.ValidateTrustedCluster(r.Context(), validateRequest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	validateResponseRaw, err := validateResponse.ToRaw()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return validateResponseRaw, nil
+}
+
+func (s *APIServer) deleteWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user, sessionID := p.ByName("user"), p.ByName("sid")
+	err := auth.WebSessions().Delete(r.Context(), types.DeleteWebSessionRequest{
+		User:      user,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("session %q for user %q deleted", sessionID, user)), nil
+}
+
+func (s *APIServer) getWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user, sid := p.ByName("user"), p.ByName("sid")
+	sess, err := auth.GetWebSessionInfo(r.Context(), user, sid)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+}
+
+// webSessionDetailsRawResponse carries enriched web session details with
+// the session itself in its versioned wire format.
+type webSessionDetailsRawResponse struct {
+	Session         json.RawMessage         `json:"session"`
+	AssumedRequests []AssumedRequestDetails `json:"assumed_requests,omitempty"`
+	Elevated        bool                    `json:"elevated"`
+	ElevatedUntil   time.Time               `json:"elevated_until,omitempty"`
+}
+
+func (s *APIServer) getWebSessionDetails(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user, sid := p.ByName("user"), p.ByName("sid")
+	details, err := auth.GetWebSessionDetails(r.Context(), user, sid)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	session, err := services.MarshalWebSession(details.Session, services.WithVersion(version))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &webSessionDetailsRawResponse{
+		Session:         session,
+		AssumedRequests: details.AssumedRequests,
+		Elevated:        details.Elevated,
+		ElevatedUntil:   details.ElevatedUntil,
+	}, nil
+}
+
+type WebSessionReq struct {
+	// User is the user name associated with the session id.
+	User string `json:"user"`
+	// PrevSessionID is the id of current session.
+	PrevSessionID string `json:"prev_session_id"`
+	// AccessRequestID is an optional field that holds the id of an approved access request.
+	AccessRequestID string `json:"access_request_id"`
+	// Switchback is a flag to indicate if user is wanting to switchback from an assumed role
+	// back to their default role.
+	Switchback bool `json:"switchback"`
+}
+
+// StepDownWebSessionReq is a request to voluntarily drop roles or assumed
+// access requests from an existing web session.
+type StepDownWebSessionReq struct {
+	// User is the user name associated with the session id.
+	User string `json:"user"`
+	// PrevSessionID is the id of current session.
+	PrevSessionID string `json:"prev_session_id"`
+	// DropRoles is the list of roles to drop from the session.
+	DropRoles []string `json:"drop_roles,omitempty"`
+	// DropAccessRequestIDs is the list of assumed access requests to drop
+	// from the session, along with the roles and resources they granted.
+	DropAccessRequestIDs []string `json:"drop_access_request_ids,omitempty"`
+}
+
+func (s *APIServer) stepDownWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req StepDownWebSessionReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.User == "" {
+		req.User = p.ByName("user")
+	}
+	sess, err := auth.StepDownWebSession(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sess, nil
+}
+
+func (s *APIServer) createWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req WebSessionReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// DELETE IN 8.0: proxy v5 sends request with no user field.
+	// And since proxy v6, request will come with user field set, so grabbing user
+	// by param is not required.
+	if req.User == "" {
+		req.User = p.ByName("user")
+	}
+
+	if req.PrevSessionID != "" {
+		sess, err := auth.ExtendWebSession(r.Context(), req)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return sess, nil
+	}
+	sess, err := auth.CreateWebSession(r.Context(), req.User)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+}
+
+func (s *APIServer) authenticateWebUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req AuthenticateUserRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Username = p.ByName("user")
+	sess, err := auth.AuthenticateWebUser(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+}
+
+func (s *APIServer) authenticateSSHUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req AuthenticateSSHRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Username = p.ByName("user")
+	return auth.AuthenticateSSHUser(r.Context(), req)
+}
+
+// changePassword updates users password based on the old password.
+func (s *APIServer) changePassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req services.ChangePasswordReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if err := auth.ChangePassword(req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("password has been changed for user %q", req.User)), nil
+}
+
+type upsertPasswordReq struct {
+	Password string `json:"password"`
+}
+
+func (s *APIServer) upsertPassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertPasswordReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	user := p.ByName("user")
+	err := auth.UpsertPassword(user, []byte(req.Password))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("password for for user %q upserted", user)), nil
+}
+
+type upsertUserRawReq struct {
+	User json.RawMessage `json:"user"`
+}
+
+func (s *APIServer) upsertUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertUserRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	user, err := services.UnmarshalUser(req.User)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.UpsertUser(user)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("'%v' user upserted", user.GetName())), nil
+}
+
+type checkPasswordReq struct {
+	Password string `json:"password"`
+	OTPToken string `json:"otp_token"`
+}
+
+func (s *APIServer) checkPassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req checkPasswordReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	user := p.ByName("user")
+	if err := auth.CheckPassword(user, []byte(req.Password), req.OTPToken); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("%q user password matches", user)), nil
+}
+
+func (s *APIServer) verifyAuthentication(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req VerifyAuthenticationRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Username = p.ByName("user")
+	if err := auth.VerifyAuthentication(r.Context(), req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("%q user credentials verified", req.Username)), nil
+}
+
+func (s *APIServer) getUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user, err := auth.GetUser(p.ByName("user"), false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.MarshalUser(user, services.WithVersion(version), services.PreserveResourceID()))
+}
+
+func rawMessage(data []byte, err error) (interface{}, error) {
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	m := json.RawMessage(data)
+	return &m, nil
+}
+
+func (s *APIServer) getUsers(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	users, err := auth.GetUsers(false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]json.RawMessage, len(users))
+	for i, user := range users {
+		data, err := services.MarshalUser(user, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+// DELETE IN: 5.2 REST method is replaced by grpc method with context.
+func (s *APIServer) deleteUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	user := p.ByName("user")
+	if err := auth.DeleteUser(r.Context(), user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("user %q deleted", user)), nil
+}
+
+type generateHostCertReq struct {
+	Key         []byte            `json:"key"`
+	HostID      string            `json:"hostname"`
+	NodeName    string            `json:"node_name"`
+	Principals  []string          `json:"principals"`
+	ClusterName string            `json:"auth_domain"`
+	Roles       types.SystemRoles `json:"roles"`
+	TTL         time.Duration     `json:"ttl"`
+}
+
+func (s *APIServer) generateHostCert(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req *generateHostCertReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if len(req.Roles) != 1 {
+		return nil, trace.BadParameter("exactly one system role is required")
+	}
+
+	cert, err := auth.GenerateHostCert(req.Key, req.HostID, req.NodeName, req.Principals, req.ClusterName, req.Roles[0], req.TTL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return string(cert), nil
+}
+
+// DELETE IN 11.0.0
+func (s *APIServer) generateToken(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req proto.GenerateTokenRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	token, err := auth.GenerateToken(r.Context(), &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return token, nil
+}
+
+func (s *APIServer) generateTokenBundle(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req proto.GenerateTokenRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bundle, err := auth.GenerateTokenBundle(r.Context(), &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundle, nil
+}
+
+func (s *APIServer) generateTokens(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req GenerateTokensRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := auth.GenerateTokens(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return resp, nil
+}
+
+// listTokensRawResponse is the wire form of a token listing page, carrying
+// the tokens as raw JSON resources.
+type listTokensRawResponse struct {
+	// Tokens is the page of marshaled provision tokens.
+	Tokens []json.RawMessage `json:"tokens"`
+	// NextKey is the key to resume the listing from, empty when the listing
+	// is complete.
+	NextKey string `json:"next_key,omitempty"`
+}
+
+func (s *APIServer) listTokens(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req ListTokensRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := auth.ListTokens(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	items := make([]json.RawMessage, 0, len(resp.Tokens))
+	for _, token := range resp.Tokens {
+		data, err := services.MarshalProvisionToken(token, services.WithVersion(version))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items = append(items, data)
+	}
+	return listTokensRawResponse{Tokens: items, NextKey: resp.NextKey}, nil
+}
+
+func (s *APIServer) registerUsingToken(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req types.RegisterUsingTokenRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingToken(r.Context(), &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return certs, nil
+}
+
+func (s *APIServer) registerUsingGCPMethod(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req registerUsingGCPReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.RegisterReq == nil {
+		return nil, trace.BadParameter("missing register request")
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RegisterReq.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingGCPMethod(r.Context(), req.RegisterReq, req.IDToken)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return marshalServers(servers, version)
+
+	return certs, nil
 }
 
-// deleteAllProxies deletes all proxies
-func (s *APIServer) deleteAllProxies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	err := auth.DeleteAllProxies()
+func (s *APIServer) registerUsingKubernetesMethod(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req registerUsingKubernetesReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.RegisterReq == nil {
+		return nil, trace.BadParameter("missing register request")
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RegisterReq.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingKubernetesMethod(r.Context(), req.RegisterReq, req.IDToken)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message("ok"), nil
+
+	return certs, nil
 }
 
-// deleteProxy deletes proxy
-func (s *APIServer) deleteProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	name := p.ByName("name")
-	if name == "" {
-		return nil, trace.BadParameter("missing proxy name")
+func (s *APIServer) registerUsingGitLabMethod(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req registerUsingGitLabReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
 	}
-	err := auth.DeleteProxy(name)
+	if req.RegisterReq == nil {
+		return nil, trace.BadParameter("missing register request")
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RegisterReq.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingGitLabMethod(r.Context(), req.RegisterReq, req.IDToken)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message("ok"), nil
+
+	return certs, nil
 }
 
-// upsertAuthServer is called by remote Auth servers when they ping back into the auth service
-func (s *APIServer) upsertAuthServer(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	return s.upsertServer(auth, types.RoleAuth, r, p)
+func (s *APIServer) registerUsingCircleCIMethod(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req registerUsingCircleCIReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.RegisterReq == nil {
+		return nil, trace.BadParameter("missing register request")
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RegisterReq.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingCircleCIMethod(r.Context(), req.RegisterReq, req.IDToken)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return certs, nil
 }
 
-// getAuthServers returns registered auth servers
-func (s *APIServer) getAuthServers(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	servers, err := auth.GetAuthServers()
+func (s *APIServer) registerUsingSPIFFEMethod(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
+	var req registerUsingSPIFFEReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.RegisterReq == nil {
+		return nil, trace.BadParameter("missing register request")
+	}
+
+	// Pass along the remote address the request came from to the registration function.
+	req.RegisterReq.RemoteAddr = r.RemoteAddr
+
+	certs, err := auth.RegisterUsingSPIFFEMethod(r.Context(), req.RegisterReq, req.SVIDChainPEM, req.Signature)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return marshalServers(servers, version)
+
+	return certs, nil
 }
 
-func marshalServers(servers []types.Server, version string) (interface{}, error) {
-	items := make([]json.RawMessage, len(servers))
-	for i, server := range servers {
-		data, err := services.MarshalServer(server, services.WithVersion(version), services.PreserveResourceID())
+func (s *APIServer) upsertSPIFFETrustBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var bundle SPIFFETrustBundle
+	if err := httplib.ReadJSON(r, &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertSPIFFETrustBundle(r.Context(), bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getSPIFFETrustBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bundle, err := auth.GetSPIFFETrustBundle(r.Context(), p.ByName("domain"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundle, nil
+}
+
+func (s *APIServer) deleteSPIFFETrustBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteSPIFFETrustBundle(r.Context(), p.ByName("domain")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) rotateCertAuthority(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req RotateRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.RotateCertAuthority(r.Context(), req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+type rotateExternalCertAuthoritiesRawReq struct {
+	CAs []json.RawMessage `json:"cas"`
+}
+
+func (s *APIServer) rotateExternalCertAuthorities(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req rotateExternalCertAuthoritiesRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cas := make([]types.CertAuthority, 0, len(req.CAs))
+	for _, raw := range req.CAs {
+		ca, err := services.UnmarshalCertAuthority(raw)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		items[i] = data
+		cas = append(cas, ca)
 	}
-	return items, nil
+	if err := auth.RotateExternalCertAuthorities(r.Context(), cas); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-type upsertReverseTunnelRawReq struct {
-	ReverseTunnel json.RawMessage `json:"reverse_tunnel"`
-	TTL           time.Duration   `json:"ttl"`
+func (s *APIServer) exportCAKeyBackup(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req ExportCAKeyBackupRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Type = types.CertAuthType(p.ByName("type"))
+	backup, err := auth.ExportCAKeyBackup(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return backup, nil
 }
 
-// upsertReverseTunnel is called by admin to create a reverse tunnel to remote proxy
-func (s *APIServer) upsertReverseTunnel(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req upsertReverseTunnelRawReq
+func (s *APIServer) importCAKeyBackup(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req ImportCAKeyBackupRequest
 	if err := httplib.ReadJSON(r, &req); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	tun, err := services.UnmarshalReverseTunnel(req.ReverseTunnel)
+	if err := auth.ImportCAKeyBackup(r.Context(), req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getCertIssuances(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	issuances, err := auth.GetCertIssuances(r.Context(), p.ByName("user"))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if err := services.ValidateReverseTunnel(tun); err != nil {
+	return issuances, nil
+}
+
+func (s *APIServer) issueWebSessionRefreshToken(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	token, err := auth.IssueWebSessionRefreshToken(r.Context(), p.ByName("user"), p.ByName("sid"))
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if req.TTL != 0 {
-		tun.SetExpiry(s.Now().UTC().Add(req.TTL))
+	return webSessionRefreshTokenResponse{RefreshToken: token}, nil
+}
+
+type webSessionRefreshTokenResponse struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (s *APIServer) renewWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req RenewWebSessionRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
 	}
-	if err := auth.UpsertReverseTunnel(tun); err != nil {
+	session, token, err := auth.RenewWebSession(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	raw, err := services.MarshalWebSession(session, services.WithVersion(version))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return renewWebSessionResponse{Session: raw, RefreshToken: token}, nil
+}
+
+type renewWebSessionResponse struct {
+	Session      json.RawMessage `json:"session"`
+	RefreshToken string          `json:"refresh_token"`
+}
+
+func (s *APIServer) getUserSessions(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	sessions, err := auth.GetUserSessions(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sessions, nil
+}
+
+func (s *APIServer) getWebSessionsMetadata(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	records, err := auth.GetWebSessionsMetadata(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return records, nil
+}
+
+func (s *APIServer) revokeUserSessions(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	revoked, err := auth.RevokeUserSessions(r.Context(), p.ByName("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return revoked, nil
+}
+
+type ackLockPropagationReq struct {
+	LockName string `json:"lock_name"`
+}
+
+func (s *APIServer) ackLockPropagation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req ackLockPropagationReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.AckLockPropagation(r.Context(), req.LockName); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getLockPropagationStatus(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	status, err := auth.GetLockPropagationStatus(r.Context(), p.ByName("lock"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return status, nil
+}
+
+func (s *APIServer) createScopedAPICerts(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req ScopedCertsRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	certs, err := auth.CreateScopedAPICerts(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return certs, nil
+}
+
+func (s *APIServer) generateUserConfigBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bundle, err := auth.GenerateUserConfigBundle(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundle, nil
+}
+
+func (s *APIServer) generateWorkloadIdentityToken(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req WorkloadIdentityTokenRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := auth.GenerateWorkloadIdentityToken(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return resp, nil
+}
+
+func (s *APIServer) upsertAccessBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var bundle AccessBundle
+	if err := httplib.ReadJSON(r, &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertAccessBundle(r.Context(), bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getAccessBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bundle, err := auth.GetAccessBundle(r.Context(), p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundle, nil
+}
+
+func (s *APIServer) getAccessBundles(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bundles, err := auth.GetAccessBundles(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundles, nil
+}
+
+func (s *APIServer) deleteAccessBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteAccessBundle(r.Context(), p.ByName("name")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) createAccessRequestFromBundle(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	summary, err := auth.CreateAccessRequestFromBundle(r.Context(), p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return summary, nil
+}
+
+type createScheduledAccessRequestReq struct {
+	Request json.RawMessage `json:"request"`
+	Start   time.Time       `json:"start"`
+}
+
+func (s *APIServer) createScheduledAccessRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createScheduledAccessRequestReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	accessRequest, err := services.UnmarshalAccessRequest(req.Request)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.CreateScheduledAccessRequest(r.Context(), accessRequest, req.Start); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) upsertAccessList(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var list AccessList
+	if err := httplib.ReadJSON(r, &list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertAccessList(r.Context(), list); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return message("ok"), nil
 }
 
-// getReverseTunnels returns a list of reverse tunnels
-func (s *APIServer) getReverseTunnels(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	reverseTunnels, err := auth.GetReverseTunnels(r.Context())
+func (s *APIServer) getAccessList(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	list, err := auth.GetAccessList(r.Context(), p.ByName("name"))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	items := make([]json.RawMessage, len(reverseTunnels))
-	for i, tunnel := range reverseTunnels {
-		data, err := services.MarshalReverseTunnel(tunnel, services.WithVersion(version), services.PreserveResourceID())
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		items[i] = data
-	}
-	return items, nil
+	return list, nil
 }
 
-// deleteReverseTunnel deletes reverse tunnel
-func (s *APIServer) deleteReverseTunnel(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	domainName := p.ByName("domain")
-	err := auth.DeleteReverseTunnel(domainName)
+func (s *APIServer) getAccessLists(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	lists, err := auth.GetAccessLists(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message(fmt.Sprintf("reverse tunnel %v deleted", domainName)), nil
+	return lists, nil
 }
 
-func (s *APIServer) validateTrustedCluster(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var validateRequestRaw ValidateTrustedClusterRequestRaw
-	if err := httplib.ReadJSON(r, &validateRequestRaw); err != nil {
+func (s *APIServer) deleteAccessList(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteAccessList(r.Context(), p.ByName("name")); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return message("ok"), nil
+}
 
-	validateRequest, err := validateRequestRaw.ToNative()
+func (s *APIServer) reviewAccessList(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	list, err := auth.ReviewAccessList(r.Context(), p.ByName("name"))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return list, nil
+}
 
-	validateResponse, err := auth.ValidateTrustedCluster(r.Context(), validateRequest)
+type previewAccessRequestReq struct {
+	Request json.RawMessage `json:"request"`
+}
+
+func (s *APIServer) previewAccessRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req previewAccessRequestReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	accessRequest, err := services.UnmarshalAccessRequest(req.Request)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	validateResponseRaw, err := validateResponse.ToRaw()
+	preview, err := auth.PreviewAccessRequest(r.Context(), accessRequest)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return preview, nil
+}
 
-	return validateResponseRaw, nil
+func (s *APIServer) upsertAccessAutoApprovalRule(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var rule AccessAutoApprovalRule
+	if err := httplib.ReadJSON(r, &rule); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertAccessAutoApprovalRule(r.Context(), rule); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-func (s *APIServer) deleteWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	user, sessionID := p.ByName("user"), p.ByName("sid")
-	err := auth.WebSessions().Delete(r.Context(), types.DeleteWebSessionRequest{
-		User:      user,
-		SessionID: sessionID,
-	})
+func (s *APIServer) getAccessAutoApprovalRules(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	rules, err := auth.GetAccessAutoApprovalRules(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message(fmt.Sprintf("session %q for user %q deleted", sessionID, user)), nil
+	return rules, nil
 }
 
-func (s *APIServer) getWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	user, sid := p.ByName("user"), p.ByName("sid")
-	sess, err := auth.GetWebSessionInfo(r.Context(), user, sid)
-	if err != nil {
+func (s *APIServer) deleteAccessAutoApprovalRule(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteAccessAutoApprovalRule(r.Context(), p.ByName("name")); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+	return message("ok"), nil
 }
 
-type WebSessionReq struct {
-	// User is the user name associated with the session id.
-	User string `json:"user"`
-	// PrevSessionID is the id of current session.
-	PrevSessionID string `json:"prev_session_id"`
-	// AccessRequestID is an optional field that holds the id of an approved access request.
-	AccessRequestID string `json:"access_request_id"`
-	// Switchback is a flag to indicate if user is wanting to switchback from an assumed role
-	// back to their default role.
-	Switchback bool `json:"switchback"`
+type createAccessRequestWithMaxDurationReq struct {
+	Request     json.RawMessage `json:"request"`
+	MaxDuration time.Duration   `json:"max_duration"`
 }
 
-func (s *APIServer) createWebSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req WebSessionReq
+func (s *APIServer) createAccessRequestWithMaxDuration(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createAccessRequestWithMaxDurationReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	// DELETE IN 8.0: proxy v5 sends request with no user field.
-	// And since proxy v6, request will come with user field set, so grabbing user
-	// by param is not required.
-	if req.User == "" {
-		req.User = p.ByName("user")
-	}
-
-	if req.PrevSessionID != "" {
-		sess, err := auth.ExtendWebSession(r.Context(), req)
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		return sess, nil
-	}
-	sess, err := auth.CreateWebSession(r.Context(), req.User)
+	accessRequest, err := services.UnmarshalAccessRequest(req.Request)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+	if err := auth.CreateAccessRequestWithMaxDuration(r.Context(), accessRequest, req.MaxDuration); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-func (s *APIServer) authenticateWebUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req AuthenticateUserRequest
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) setAccessRequestDurationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var policy AccessRequestDurationPolicy
+	if err := httplib.ReadJSON(r, &policy); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	req.Username = p.ByName("user")
-	sess, err := auth.AuthenticateWebUser(r.Context(), req)
-	if err != nil {
+	if err := auth.SetAccessRequestDurationPolicy(r.Context(), policy); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return rawMessage(services.MarshalWebSession(sess, services.WithVersion(version)))
+	return message("ok"), nil
 }
 
-func (s *APIServer) authenticateSSHUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req AuthenticateSSHRequest
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) getAccessRequestDurationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetAccessRequestDurationPolicy(r.Context())
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	req.Username = p.ByName("user")
-	return auth.AuthenticateSSHUser(r.Context(), req)
+	return policy, nil
 }
 
-// changePassword updates users password based on the old password.
-func (s *APIServer) changePassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req services.ChangePasswordReq
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) deleteAccessRequestDurationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteAccessRequestDurationPolicy(r.Context()); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return message("ok"), nil
+}
 
-	if err := auth.ChangePassword(req); err != nil {
+func (s *APIServer) setAccessEscalationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var policy AccessEscalationPolicy
+	if err := httplib.ReadJSON(r, &policy); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	return message(fmt.Sprintf("password has been changed for user %q", req.User)), nil
+	if err := auth.SetAccessEscalationPolicy(r.Context(), policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-type upsertPasswordReq struct {
-	Password string `json:"password"`
+func (s *APIServer) getAccessEscalationPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetAccessEscalationPolicy(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policy, nil
 }
 
-func (s *APIServer) upsertPassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req *upsertPasswordReq
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) setHostPrincipalPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var policy HostPrincipalPolicy
+	if err := httplib.ReadJSON(r, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetHostPrincipalPolicy(r.Context(), policy); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return message("ok"), nil
+}
 
-	user := p.ByName("user")
-	err := auth.UpsertPassword(user, []byte(req.Password))
+func (s *APIServer) getHostPrincipalPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetHostPrincipalPolicy(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	return message(fmt.Sprintf("password for for user %q upserted", user)), nil
+	return policy, nil
 }
 
-type upsertUserRawReq struct {
-	User json.RawMessage `json:"user"`
+func (s *APIServer) setDataResidencyPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var policy DataResidencyPolicy
+	if err := httplib.ReadJSON(r, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetDataResidencyPolicy(r.Context(), policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-func (s *APIServer) upsertUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req *upsertUserRawReq
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) getDataResidencyPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetDataResidencyPolicy(r.Context())
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	user, err := services.UnmarshalUser(req.User)
+	return policy, nil
+}
+
+func (s *APIServer) getDataResidencyStatus(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	status, err := auth.GetDataResidencyStatus(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return status, nil
+}
 
-	err = auth.UpsertUser(user)
+func (s *APIServer) getBots(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bots, err := auth.GetBots(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message(fmt.Sprintf("'%v' user upserted", user.GetName())), nil
+	return bots, nil
 }
 
-type checkPasswordReq struct {
-	Password string `json:"password"`
-	OTPToken string `json:"otp_token"`
+func (s *APIServer) getBot(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	bot, err := auth.GetBot(r.Context(), p.ByName("name"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bot, nil
 }
 
-func (s *APIServer) checkPassword(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req checkPasswordReq
+func (s *APIServer) upsertOutputTemplate(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertOutputTemplateReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	user := p.ByName("user")
-	if err := auth.CheckPassword(user, []byte(req.Password), req.OTPToken); err != nil {
+	if err := auth.UpsertOutputTemplate(r.Context(), req.Template); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	return message(fmt.Sprintf("%q user password matches", user)), nil
+	return message("ok"), nil
 }
 
-func (s *APIServer) getUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	user, err := auth.GetUser(p.ByName("user"), false)
+func (s *APIServer) getOutputTemplates(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	templates, err := auth.GetOutputTemplates(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return rawMessage(services.MarshalUser(user, services.WithVersion(version), services.PreserveResourceID()))
+	return templates, nil
 }
 
-func rawMessage(data []byte, err error) (interface{}, error) {
-	if err != nil {
+func (s *APIServer) deleteOutputTemplate(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteOutputTemplate(r.Context(), p.ByName("name")); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	m := json.RawMessage(data)
-	return &m, nil
+	return message("ok"), nil
 }
 
-func (s *APIServer) getUsers(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	users, err := auth.GetUsers(false)
+func (s *APIServer) renderOutputTemplates(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	outputs, err := auth.RenderOutputTemplates(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	out := make([]json.RawMessage, len(users))
-	for i, user := range users {
-		data, err := services.MarshalUser(user, services.WithVersion(version), services.PreserveResourceID())
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		out[i] = data
-	}
-	return out, nil
+	return outputs, nil
 }
 
-// DELETE IN: 5.2 REST method is replaced by grpc method with context.
-func (s *APIServer) deleteUser(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	user := p.ByName("user")
-	if err := auth.DeleteUser(r.Context(), user); err != nil {
+func (s *APIServer) getCertKeyPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	policy, err := auth.GetCertKeyPolicy(r.Context())
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return message(fmt.Sprintf("user %q deleted", user)), nil
-}
-
-type generateHostCertReq struct {
-	Key         []byte            `json:"key"`
-	HostID      string            `json:"hostname"`
-	NodeName    string            `json:"node_name"`
-	Principals  []string          `json:"principals"`
-	ClusterName string            `json:"auth_domain"`
-	Roles       types.SystemRoles `json:"roles"`
-	TTL         time.Duration     `json:"ttl"`
+	return policy, nil
 }
 
-func (s *APIServer) generateHostCert(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
-	var req *generateHostCertReq
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) setCertKeyPolicy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var policy CertKeyPolicy
+	if err := httplib.ReadJSON(r, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.SetCertKeyPolicy(r.Context(), policy); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return message("ok"), nil
+}
 
-	if len(req.Roles) != 1 {
-		return nil, trace.BadParameter("exactly one system role is required")
+func (s *APIServer) generateSubjectKeyPair(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	keyPair, err := auth.GenerateSubjectKeyPair(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
+	return keyPair, nil
+}
 
-	cert, err := auth.GenerateHostCert(req.Key, req.HostID, req.NodeName, req.Principals, req.ClusterName, req.Roles[0], req.TTL)
+func (s *APIServer) runPreflightChecks(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	report, err := auth.RunPreflightChecks(r.Context())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return report, nil
+}
 
-	return string(cert), nil
+func (s *APIServer) rotateBackendEncryptionKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.RotateBackendEncryptionKey(r.Context()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
 }
 
-// DELETE IN 11.0.0
-func (s *APIServer) generateToken(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
-	var req proto.GenerateTokenRequest
+func (s *APIServer) generateUserCertsWithAttestation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req attestedCertsReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
 		return nil, trace.Wrap(err)
 	}
-	token, err := auth.GenerateToken(r.Context(), &req)
+	certs, err := auth.GenerateUserCertsWithAttestation(r.Context(), req.CertsReq, req.Attestation)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return token, nil
+	return certs, nil
 }
 
-func (s *APIServer) registerUsingToken(auth ClientI, w http.ResponseWriter, r *http.Request, _ httprouter.Params, version string) (interface{}, error) {
-	var req types.RegisterUsingTokenRequest
+type upsertSPIFFEFederationReq struct {
+	Federation SPIFFEFederation `json:"federation"`
+}
+
+func (s *APIServer) upsertSPIFFEFederation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req upsertSPIFFEFederationReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	// Pass along the remote address the request came from to the registration function.
-	req.RemoteAddr = r.RemoteAddr
-
-	certs, err := auth.RegisterUsingToken(r.Context(), &req)
-	if err != nil {
+	if err := auth.UpsertSPIFFEFederation(r.Context(), req.Federation); err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	return certs, nil
+	return message("ok"), nil
 }
 
-func (s *APIServer) rotateCertAuthority(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
-	var req RotateRequest
-	if err := httplib.ReadJSON(r, &req); err != nil {
+func (s *APIServer) getSPIFFEFederations(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	federations, err := auth.GetSPIFFEFederations(r.Context())
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if err := auth.RotateCertAuthority(r.Context(), req); err != nil {
+	return federations, nil
+}
+
+func (s *APIServer) deleteSPIFFEFederation(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteSPIFFEFederation(r.Context(), p.ByName("trust_domain")); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return message("ok"), nil
@@ -849,6 +2423,14 @@ func (s *APIServer) getClusterCACert(auth ClientI, w http.ResponseWriter, r *htt
 	}, nil
 }
 
+func (s *APIServer) getClusterCACertPins(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	pins, err := auth.GetClusterCACertPins(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return pins, nil
+}
+
 func (s *APIServer) deleteCertAuthority(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	id := types.CertAuthID{
 		DomainName: p.ByName("domain"),
@@ -1375,6 +2957,28 @@ func (s *APIServer) setStaticTokens(auth ClientI, w http.ResponseWriter, r *http
 	return message(fmt.Sprintf("static tokens set: %+v", st)), nil
 }
 
+type addStaticTokenReq struct {
+	Token types.ProvisionTokenV1 `json:"token"`
+}
+
+func (s *APIServer) addStaticToken(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req addStaticTokenReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.AddStaticToken(r.Context(), req.Token); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) removeStaticToken(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.RemoveStaticToken(r.Context(), p.ByName("token")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 type upsertTunnelConnectionRawReq struct {
 	TunnelConnection json.RawMessage `json:"tunnel_connection"`
 }
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// Private key policies embedded in issued certificates. The policy describes
+// what is provably known about where the subject private key lives.
+const (
+	// PrivateKeyPolicyNone means nothing is known about the private key.
+	PrivateKeyPolicyNone = ""
+	// PrivateKeyPolicyHardwareKey means the private key was attested to
+	// live on a hardware token (YubiKey PIV slot, TPM) by a trusted
+	// attestation CA.
+	PrivateKeyPolicyHardwareKey = "hardware_key"
+)
+
+// AttestationStatement is a hardware key attestation statement submitted
+// alongside a certificate request. The attestation certificate signs the
+// public key being certified and chains up to a configured attestation CA
+// (e.g. the Yubico PIV attestation root or a TPM endorsement CA).
+type AttestationStatement struct {
+	// AttestationCert is the DER-encoded attestation certificate whose
+	// subject public key is the key being certified.
+	AttestationCert []byte `json:"attestation_cert"`
+	// IntermediateCerts are optional DER-encoded intermediates completing
+	// the chain from AttestationCert to an attestation CA.
+	IntermediateCerts [][]byte `json:"intermediate_certs,omitempty"`
+}
+
+// verifyHardwareKeyAttestation verifies an attestation statement against the
+// configured attestation CAs and checks that the attested key matches the
+// public key being certified. It returns the private key policy to embed in
+// the issued certificate.
+func (a *Server) verifyHardwareKeyAttestation(statement *AttestationStatement, publicSSHKey []byte) (string, error) {
+	if statement == nil {
+		return "", trace.BadParameter("missing attestation statement")
+	}
+	if a.attestationCAs == nil {
+		return "", trace.BadParameter("hardware key attestation is not configured on this cluster")
+	}
+	attestationCert, err := x509.ParseCertificate(statement.AttestationCert)
+	if err != nil {
+		return "", trace.Wrap(err, "failed to parse attestation certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range statement.IntermediateCerts {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return "", trace.Wrap(err, "failed to parse attestation intermediate")
+		}
+		intermediates.AddCert(cert)
+	}
+	if _, err := attestationCert.Verify(x509.VerifyOptions{
+		Roots:         a.attestationCAs,
+		Intermediates: intermediates,
+		// attestation certificates do not carry standard key usages
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return "", trace.AccessDenied("attestation certificate does not chain to a trusted attestation CA: %v", err)
+	}
+
+	// The attested key must be the key being certified, otherwise a caller
+	// could attach somebody else's attestation to a software key.
+	requestedKey, _, _, _, err := ssh.ParseAuthorizedKey(publicSSHKey)
+	if err != nil {
+		return "", trace.Wrap(err, "failed to parse public key")
+	}
+	attestedKey, err := ssh.NewPublicKey(attestationCert.PublicKey)
+	if err != nil {
+		return "", trace.Wrap(err, "failed to convert attested public key")
+	}
+	if subtle.ConstantTimeCompare(requestedKey.Marshal(), attestedKey.Marshal()) != 1 {
+		return "", trace.AccessDenied("attested public key does not match the key being certified")
+	}
+	return PrivateKeyPolicyHardwareKey, nil
+}
@@ -36,6 +36,7 @@ import (
 	insecurerand "math/rand"
 	"net"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -191,6 +192,16 @@ func NewServer(cfg *InitConfig, opts ...ServerOption) (*Server, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	var attestationCAs *x509.CertPool
+	if len(cfg.HardwareKeyAttestationCAs) != 0 {
+		attestationCAs = x509.NewCertPool()
+		for _, pem := range cfg.HardwareKeyAttestationCAs {
+			if !attestationCAs.AppendCertsFromPEM(pem) {
+				return nil, trace.BadParameter("failed to parse hardware key attestation CA certificate")
+			}
+		}
+	}
+
 	services := &Services{
 		Trust:                 cfg.Trust,
 		Presence:              cfg.Presence,
@@ -229,9 +240,12 @@ func NewServer(cfg *InitConfig, opts ...ServerOption) (*Server, error) {
 		Services:        services,
 		Cache:           services,
 		keyStore:        keyStore,
+		attestationCAs:  attestationCAs,
 		getClaimsFun:    getClaims,
 		inventory:       inventory.NewController(cfg.Presence),
 		traceClient:     cfg.TraceClient,
+		reissueThrottle: newHostReissueThrottle(),
+		loginChecker:    noopLoginAttemptChecker{},
 	}
 	for _, o := range opts {
 		if err := o(&as); err != nil {
@@ -313,6 +327,13 @@ var (
 		},
 	)
 
+	identityRequestsRejected = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: teleport.MetricIdentityRequestsRejected,
+			Help: "Number of RPCs rejected because the calling identity exceeded its concurrent request limit",
+		},
+	)
+
 	heartbeatsMissedByAuth = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: teleport.MetricHeartbeatsMissed,
@@ -333,7 +354,7 @@ var (
 	prometheusCollectors = []prometheus.Collector{
 		generateRequestsCount, generateThrottledRequestsCount,
 		generateRequestsCurrent, generateRequestsLatencies, UserLoginCount, heartbeatsMissedByAuth,
-		registeredAgents,
+		registeredAgents, identityRequestsRejected, lockPropagationLatencies,
 	}
 )
 
@@ -391,6 +412,14 @@ type Server struct {
 	// limiter limits the number of active connections per client IP.
 	limiter *limiter.ConnectionsLimiter
 
+	// reissueThrottle paces host certificate re-issuance while a CA
+	// rotation is in progress.
+	reissueThrottle *hostReissueThrottle
+
+	// loginChecker is consulted before web authentication requests are
+	// processed, see LoginAttemptChecker.
+	loginChecker LoginAttemptChecker
+
 	// Emitter is events emitter, used to submit discrete events
 	emitter apievents.Emitter
 
@@ -402,6 +431,10 @@ type Server struct {
 	// may be backed by HSMs
 	keyStore keystore.KeyStore
 
+	// attestationCAs holds CA certificates trusted to attest that private
+	// keys reside on hardware tokens, nil if attestation is not configured
+	attestationCAs *x509.CertPool
+
 	// lockWatcher is a lock watcher, used to verify cert generation requests.
 	lockWatcher *services.LockWatcher
 
@@ -439,6 +472,7 @@ func (a *Server) checkLockInForce(mode constants.LockingMode, targets []types.Lo
 // performed by auth server
 func (a *Server) runPeriodicOperations() {
 	ctx := context.TODO()
+	go a.watchAuthLeadership()
 	// run periodic functions with a semi-random period
 	// to avoid contention on the database in case if there are multiple
 	// auth servers running - so they don't compete trying
@@ -472,6 +506,9 @@ func (a *Server) runPeriodicOperations() {
 					log.Errorf("Failed to perform cert rotation check: %v.", err)
 				}
 			}
+			if err := a.checkAccessRequestEscalations(ctx); err != nil {
+				log.Errorf("Failed to check access request escalations: %v.", err)
+			}
 		case <-heartbeatCheckTicker.Next():
 			nodes, err := a.GetNodes(ctx, apidefaults.Namespace)
 			if err != nil {
@@ -669,9 +706,77 @@ func (a *Server) GetClusterCACert(ctx context.Context) (*proto.GetClusterCACertR
 	}, nil
 }
 
+// CAPin describes a single SPKI pin of the cluster host CA along with hints
+// about how long the pin remains usable.
+type CAPin struct {
+	// Pin is the SPKI hash in "<algorithm>:<hex>" format.
+	Pin string `json:"pin"`
+	// Pending is true when the pin belongs to an additional trusted
+	// certificate of an ongoing CA rotation. Pending pins become current
+	// once the rotation completes, so nodes should record them ahead of
+	// time to keep joining after the old CA is retired.
+	Pending bool `json:"pending"`
+	// NotAfter is the expiry time of the certificate behind the pin.
+	NotAfter time.Time `json:"not_after"`
+}
+
+// GetClusterCACertPins returns the SPKI pins of the local cluster host CA,
+// including pending pins of an ongoing rotation, so joining nodes can record
+// upcoming pins before the rotation completes.
+func (a *Server) GetClusterCACertPins(ctx context.Context) ([]CAPin, error) {
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hostCA, err := a.GetCertAuthority(ctx, types.CertAuthID{
+		Type:       types.HostCA,
+		DomainName: clusterName.GetClusterName(),
+	}, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	appendPins := func(pins []CAPin, keyPairs []*types.TLSKeyPair, pending bool) ([]CAPin, error) {
+		for _, pair := range keyPairs {
+			cert, err := tlsca.ParseCertificatePEM(pair.Cert)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			// report a pin per supported algorithm, so nodes can migrate
+			// their pinned configuration to a different algorithm
+			for _, algorithm := range utils.SPKIAlgorithms {
+				pin, err := utils.CalculateSPKIWithAlgorithm(cert, algorithm)
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				pins = append(pins, CAPin{
+					Pin:      pin,
+					Pending:  pending,
+					NotAfter: cert.NotAfter,
+				})
+			}
+		}
+		return pins, nil
+	}
+
+	pins, err := appendPins(nil, hostCA.GetActiveKeys().TLS, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pins, err = appendPins(pins, hostCA.GetAdditionalTrustedKeys().TLS, true)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return pins, nil
+}
+
 // GenerateHostCert uses the private key of the CA to sign the public key of the host
 // (along with meta data like host ID, node name, roles, and ttl) to generate a host certificate.
 func (a *Server) GenerateHostCert(hostPublicKey []byte, hostID, nodeName string, principals []string, clusterName string, role types.SystemRole, ttl time.Duration) ([]byte, error) {
+	if err := a.checkCASigningAllowed(context.TODO()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	domainName, err := a.GetDomainName()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -800,6 +905,18 @@ type certRequest struct {
 	// generation indicates the number of times this certificate has been
 	// renewed.
 	generation uint64
+	// scopes restricts which API operations the certificate may perform,
+	// no scopes means unrestricted.
+	scopes []string
+	// privateKeyPolicy is the private key policy the subject key was verified
+	// to satisfy, e.g. that it resides on a hardware token.
+	privateKeyPolicy string
+	// clientOrigin records the kind of client the certificate is issued to,
+	// e.g. a web UI session or a tsh login.
+	clientOrigin string
+	// deviceID is the ID of the trusted device the certificate is requested
+	// from, empty when no device was presented.
+	deviceID string
 }
 
 // check verifies the cert request is valid.
@@ -832,6 +949,10 @@ func certRequestClientIP(ip string) certRequestOption {
 	return func(r *certRequest) { r.clientIP = ip }
 }
 
+func certRequestPrivateKeyPolicy(policy string) certRequestOption {
+	return func(r *certRequest) { r.privateKeyPolicy = policy }
+}
+
 // GenerateUserTestCerts is used to generate user certificate, used internally for tests
 func (a *Server) GenerateUserTestCerts(key []byte, username string, ttl time.Duration, compatibility, routeToCluster, sourceIP string) ([]byte, []byte, error) {
 	user, err := a.GetUser(username, false)
@@ -983,6 +1104,10 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	if err := a.checkCASigningAllowed(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	if len(req.checker.GetAllowedResourceIDs()) > 0 && !modules.GetModules().Features().ResourceAccessRequests {
 		return nil, trace.AccessDenied("this Teleport cluster is not licensed for resource access requests, please contact the cluster administrator")
 	}
@@ -1007,6 +1132,11 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// Reject subject keys that do not satisfy the cluster key policy.
+	if err := a.checkCertificateKeyPolicy(ctx, req.checker, req.publicKey); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	// reuse the same RSA keys for SSH and TLS keys
 	cryptoPubKey, err := sshutils.CryptoPublicKey(req.publicKey)
 	if err != nil {
@@ -1050,6 +1180,19 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+
+		// Clamp policies may further cap the TTL for certificates routing
+		// to sensitive targets.
+		sessionTTL, err = a.clampSessionTTL(ctx, req, sessionTTL)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	// The device trust policy may refuse issuance outright when no enrolled
+	// device was presented.
+	if err := a.checkDeviceTrust(ctx, req); err != nil {
+		return nil, trace.Wrap(err)
 	}
 
 	clusterName, err := a.GetDomainName()
@@ -1198,6 +1341,11 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 		Renewable:          req.renewable,
 		Generation:         req.generation,
 		AllowedResourceIDs: req.checker.GetAllowedResourceIDs(),
+		Scopes:             req.scopes,
+		PrivateKeyPolicy:   req.privateKeyPolicy,
+		ClientOrigin:       req.clientOrigin,
+		DeviceID:           req.deviceID,
+		PinnedIP:           req.sourceIP,
 	}
 	subject, err := identity.Subject()
 	if err != nil {
@@ -1227,6 +1375,11 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 		log.WithError(err).Warn("Failed to emit certificate create event.")
 	}
 
+	if err := a.recordCertIssuance(ctx, tlsCert, identity, certRequest.NotAfter); err != nil {
+		log.WithError(err).Warn("Failed to record certificate issuance.")
+	}
+	a.recordAccessRequestUsage(ctx, req.activeRequests.AccessRequests)
+
 	// create certs struct to return to user
 	certs := &proto.Certs{
 		SSH: sshCert,
@@ -1261,8 +1414,8 @@ func (a *Server) generateUserCert(req certRequest) (*proto.Certs, error) {
 // The only exception to this rule is ConnectionProblemError, in case if it occurs
 // access will be denied, but login attempt will not be recorded
 // this is done to avoid potential user lockouts due to backend failures
-// In case if user exceeds defaults.MaxLoginAttempts
-// the user account will be locked for defaults.AccountLockInterval
+// In case if user exceeds the attempt limit of the lockout policy
+// the user account will be locked for the lock duration of the policy
 func (a *Server) WithUserLock(username string, authenticateFn func() error) error {
 	user, err := a.Services.GetUser(username, false)
 	if err != nil {
@@ -1274,6 +1427,10 @@ func (a *Server) WithUserLock(username string, authenticateFn func() error) erro
 		}
 		return trace.Wrap(err)
 	}
+	policy, err := a.GetLockoutPolicy(context.TODO())
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	status := user.GetStatus()
 	if status.IsLocked {
 		if status.RecoveryAttemptLockExpires.After(a.clock.Now().UTC()) {
@@ -1286,7 +1443,7 @@ func (a *Server) WithUserLock(username string, authenticateFn func() error) erro
 		}
 		if status.LockExpires.After(a.clock.Now().UTC()) {
 			log.Debugf("%v exceeds %v failed login attempts, locked until %v",
-				user.GetName(), defaults.MaxLoginAttempts, apiutils.HumanTimeFormat(status.LockExpires))
+				user.GetName(), policy.MaxAttempts, apiutils.HumanTimeFormat(status.LockExpires))
 
 			err := trace.AccessDenied(MaxFailedAttemptsErrMsg)
 			err.AddField(ErrFieldKeyUserMaxedAttempts, true)
@@ -1309,7 +1466,7 @@ func (a *Server) WithUserLock(username string, authenticateFn func() error) erro
 	}
 	// log failed attempt and possibly lock user
 	attempt := services.LoginAttempt{Time: a.clock.Now().UTC(), Success: false}
-	err = a.AddUserLoginAttempt(username, attempt, defaults.AttemptTTL)
+	err = a.AddUserLoginAttempt(username, attempt, policy.AttemptWindow)
 	if err != nil {
 		log.Error(trace.DebugReport(err))
 		return trace.Wrap(fnErr)
@@ -1319,13 +1476,13 @@ func (a *Server) WithUserLock(username string, authenticateFn func() error) erro
 		log.Error(trace.DebugReport(err))
 		return trace.Wrap(fnErr)
 	}
-	if !services.LastFailed(defaults.MaxLoginAttempts, loginAttempts) {
-		log.Debugf("%v user has less than %v failed login attempts", username, defaults.MaxLoginAttempts)
+	if !services.LastFailed(policy.MaxAttempts, loginAttempts) {
+		log.Debugf("%v user has less than %v failed login attempts", username, policy.MaxAttempts)
 		return trace.Wrap(fnErr)
 	}
-	lockUntil := a.clock.Now().UTC().Add(defaults.AccountLockInterval)
+	lockUntil := a.clock.Now().UTC().Add(policy.LockDuration)
 	log.Debug(fmt.Sprintf("%v exceeds %v failed login attempts, locked until %v",
-		username, defaults.MaxLoginAttempts, apiutils.HumanTimeFormat(lockUntil)))
+		username, policy.MaxAttempts, apiutils.HumanTimeFormat(lockUntil)))
 	user.SetLocked(lockUntil, "user has exceeded maximum failed login attempts")
 	err = a.UpsertUser(user)
 	if err != nil {
@@ -1464,7 +1621,7 @@ type newRegisterChallengeRequest struct {
 func (a *Server) createRegisterChallenge(ctx context.Context, req *newRegisterChallengeRequest) (*proto.MFARegisterChallenge, error) {
 	switch req.deviceType {
 	case proto.DeviceType_DEVICE_TYPE_TOTP:
-		otpKey, otpOpts, err := a.newTOTPKey(req.username)
+		otpKey, otpOpts, err := a.newTOTPKey(ctx, req.username)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -1832,10 +1989,11 @@ func (a *Server) registerWebauthnDevice(ctx context.Context, regResp *proto.MFAR
 	return dev, trace.Wrap(err)
 }
 
-// GetWebSession returns existing web session described by req. Explicitly
-// delegating to Services as it's directly implemented by Cache as well.
+// GetWebSession returns existing web session described by req, with its
+// bearer token opened. Explicitly defined as it's directly implemented by
+// Cache as well.
 func (a *Server) GetWebSession(ctx context.Context, req types.GetWebSessionRequest) (types.WebSession, error) {
-	return a.Services.GetWebSession(ctx, req)
+	return a.WebSessions().Get(ctx, req)
 }
 
 // GetWebToken returns existing web token described by req. Explicitly
@@ -1948,6 +2106,12 @@ func (a *Server) ExtendWebSession(ctx context.Context, req WebSessionReq, identi
 	// Keep preserving the login time.
 	sess.SetLoginTime(prevSession.GetLoginTime())
 
+	// The previous session is being replaced, it does not count against the
+	// concurrent web session limit.
+	if err := a.enforceWebSessionLimit(ctx, req.User, prevSession.GetName()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	if err := a.upsertWebSession(ctx, req.User, sess); err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -1983,6 +2147,33 @@ func (a *Server) getValidatedAccessRequest(ctx context.Context, user, accessRequ
 		return nil, trace.Wrap(err)
 	}
 
+	// Scheduled requests stay unassumable until their window opens, even
+	// when already approved.
+	start, err := a.getAccessRequestStart(ctx, accessRequestID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !start.IsZero() && a.GetClock().Now().Before(start) {
+		return nil, trace.AccessDenied("access request %q cannot be assumed before its scheduled start time %v", accessRequestID, start)
+	}
+
+	// A closed elevation window blocks new certificates even while the
+	// request object itself is still unexpired.
+	elevationExpiry, err := a.getAccessRequestElevationExpiry(ctx, accessRequestID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !elevationExpiry.IsZero() {
+		if !a.GetClock().Now().Before(elevationExpiry) {
+			return nil, trace.AccessDenied("access request %q has exceeded its maximum elevation duration, request re-approval", accessRequestID)
+		}
+		// Clamp the access expiry so issued certificates cannot outlive
+		// the window.
+		if elevationExpiry.Before(req.GetAccessExpiry()) {
+			req.SetAccessExpiry(elevationExpiry)
+		}
+	}
+
 	accessExpiry := req.GetAccessExpiry()
 	if accessExpiry.Before(a.GetClock().Now()) {
 		return nil, trace.BadParameter("access request %q has expired", accessRequestID)
@@ -1991,6 +2182,120 @@ func (a *Server) getValidatedAccessRequest(ctx context.Context, user, accessRequ
 	return req, nil
 }
 
+// StepDownWebSession creates a new web session for a user with specific
+// roles or assumed access requests dropped from the previous (current)
+// session. It is the inverse of assuming an access request: the user keeps
+// the session but continues with reduced privileges, no re-login required.
+// Dropped privileges can be regained with a switchback.
+func (a *Server) StepDownWebSession(ctx context.Context, req StepDownWebSessionReq, identity tlsca.Identity) (types.WebSession, error) {
+	prevSession, err := a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      req.User,
+		SessionID: req.PrevSessionID,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	expiresAt := prevSession.GetExpiryTime()
+	if !expiresAt.IsZero() && expiresAt.Before(a.clock.Now().UTC()) {
+		return nil, trace.NotFound("web session has expired")
+	}
+
+	accessInfo, err := services.AccessInfoFromLocalIdentity(identity, a)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	roles := accessInfo.Roles
+	traits := accessInfo.Traits
+	allowedResourceIDs := accessInfo.AllowedResourceIDs
+	accessRequests := identity.ActiveRequests
+
+	// roles granted by a dropped access request are removed unless they are
+	// also among the user's static roles
+	user, err := a.GetUser(req.User, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	staticRoles := make(map[string]bool)
+	for _, role := range user.GetRoles() {
+		staticRoles[role] = true
+	}
+
+	droppedRequestRoles := make(map[string]bool)
+	for _, requestID := range req.DropAccessRequestIDs {
+		if !apiutils.SliceContainsStr(accessRequests, requestID) {
+			return nil, trace.BadParameter("access request %q is not assumed by the session", requestID)
+		}
+		reqs, err := a.GetAccessRequests(ctx, types.AccessRequestFilter{User: req.User, ID: requestID})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if len(reqs) < 1 {
+			return nil, trace.NotFound("access request %q not found", requestID)
+		}
+		accessRequest := reqs[0]
+		for _, role := range accessRequest.GetRoles() {
+			droppedRequestRoles[role] = true
+		}
+		if len(accessRequest.GetRequestedResourceIDs()) > 0 {
+			// a session holds the resource IDs of at most one access
+			// request, so dropping the request resets them
+			allowedResourceIDs = nil
+		}
+		var kept []string
+		for _, id := range accessRequests {
+			if id != requestID {
+				kept = append(kept, id)
+			}
+		}
+		accessRequests = kept
+	}
+
+	droppedRoles := make(map[string]bool)
+	for _, role := range req.DropRoles {
+		if !apiutils.SliceContainsStr(roles, role) {
+			return nil, trace.BadParameter("role %q is not held by the session", role)
+		}
+		droppedRoles[role] = true
+	}
+
+	var keptRoles []string
+	for _, role := range roles {
+		if droppedRoles[role] {
+			continue
+		}
+		if droppedRequestRoles[role] && !staticRoles[role] {
+			continue
+		}
+		keptRoles = append(keptRoles, role)
+	}
+	if len(keptRoles) == 0 {
+		return nil, trace.BadParameter("can not step down from all roles, the session must retain at least one role")
+	}
+
+	sess, err := a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:                 req.User,
+		Roles:                keptRoles,
+		Traits:               traits,
+		SessionTTL:           utils.ToTTL(a.clock, expiresAt),
+		AccessRequests:       accessRequests,
+		RequestedResourceIDs: allowedResourceIDs,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// Keep preserving the login time so a later switchback can restore the
+	// default roles.
+	sess.SetLoginTime(prevSession.GetLoginTime())
+
+	if err := a.upsertWebSession(ctx, req.User, sess); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return sess, nil
+}
+
 // CreateWebSession creates a new web session for user without any
 // checks, is used by admins
 func (a *Server) CreateWebSession(ctx context.Context, user string) (types.WebSession, error) {
@@ -2033,11 +2338,18 @@ func (a *Server) GenerateToken(ctx context.Context, req *proto.GenerateTokenRequ
 	if err != nil {
 		return "", trace.Wrap(err)
 	}
+	meta := token.GetMetadata()
 	if len(req.Labels) != 0 {
-		meta := token.GetMetadata()
 		meta.Labels = req.Labels
-		token.SetMetadata(meta)
 	}
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	// record who created the token so listings can be filtered by creator
+	if meta.Labels[TokenCreatedByLabel] == "" {
+		meta.Labels[TokenCreatedByLabel] = ClientUsername(ctx)
+	}
+	token.SetMetadata(meta)
 
 	if err := a.UpsertToken(ctx, token); err != nil {
 		return "", trace.Wrap(err)
@@ -2061,6 +2373,173 @@ func (a *Server) GenerateToken(ctx context.Context, req *proto.GenerateTokenRequ
 	return req.Token, nil
 }
 
+// TokenCreatedByLabel is a token metadata label recording the user that
+// created the token. GenerateToken sets it automatically unless the caller
+// supplied the label explicitly.
+const TokenCreatedByLabel = "teleport.dev/created-by"
+
+// ListTokensRequest is a request to list provision tokens with optional
+// label and role filters and pagination.
+type ListTokensRequest struct {
+	// Labels filters tokens to those whose metadata labels match all the
+	// given labels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Roles filters tokens to those that allow at least one of the given
+	// system roles.
+	Roles types.SystemRoles `json:"roles,omitempty"`
+	// Limit is the maximum number of tokens to return per page, defaults to
+	// apidefaults.DefaultChunkSize.
+	Limit int `json:"limit,omitempty"`
+	// StartKey is the key to resume the listing from, as returned in
+	// NextKey of the previous page.
+	StartKey string `json:"start_key,omitempty"`
+}
+
+// ListTokensResponse is a single page of a filtered token listing.
+type ListTokensResponse struct {
+	// Tokens is the page of provision tokens.
+	Tokens []types.ProvisionToken `json:"tokens"`
+	// NextKey is the key to resume the listing from, empty when the listing
+	// is complete.
+	NextKey string `json:"next_key,omitempty"`
+}
+
+// ListTokens returns a page of provision tokens matching the request
+// filters, ordered by token name.
+func (a *Server) ListTokens(ctx context.Context, req ListTokensRequest) (ListTokensResponse, error) {
+	tokens, err := a.GetTokens(ctx)
+	if err != nil {
+		return ListTokensResponse{}, trace.Wrap(err)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].GetName() < tokens[j].GetName()
+	})
+
+	limit := req.Limit
+	if limit <= 0 || limit > apidefaults.DefaultChunkSize {
+		limit = apidefaults.DefaultChunkSize
+	}
+
+	var resp ListTokensResponse
+	for _, token := range tokens {
+		if req.StartKey != "" && token.GetName() <= req.StartKey {
+			continue
+		}
+		if !tokenMatchesFilter(token, req) {
+			continue
+		}
+		if len(resp.Tokens) == limit {
+			resp.NextKey = resp.Tokens[len(resp.Tokens)-1].GetName()
+			break
+		}
+		resp.Tokens = append(resp.Tokens, token)
+	}
+	return resp, nil
+}
+
+// tokenMatchesFilter checks the token against the label and role filters of
+// a listing request.
+func tokenMatchesFilter(token types.ProvisionToken, req ListTokensRequest) bool {
+	labels := token.GetMetadata().Labels
+	for key, value := range req.Labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	if len(req.Roles) != 0 {
+		match := false
+		for _, role := range req.Roles {
+			if token.GetRoles().Include(role) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// TokenCorrelationIDLabel is a token metadata label carrying the
+// correlation id assigned to the token when it was minted in a batch.
+const TokenCorrelationIDLabel = "teleport.dev/correlation-id"
+
+// maxBulkTokens caps how many tokens a single bulk generation request may
+// mint.
+const maxBulkTokens = 1000
+
+// GenerateTokensRequest is a request to mint a batch of provision tokens
+// sharing the same roles, TTL and labels.
+type GenerateTokensRequest struct {
+	// Count is the number of tokens to mint.
+	Count int `json:"count"`
+	// Roles is the list of system roles the tokens will allow.
+	Roles types.SystemRoles `json:"roles"`
+	// TTL is the time to live of the tokens, defaults to
+	// defaults.ProvisioningTokenTTL.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Labels is an optional set of metadata labels attached to every token
+	// in the batch.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GeneratedToken is a single token minted by a bulk generation request.
+type GeneratedToken struct {
+	// CorrelationID identifies the token within the batch. It is also
+	// recorded in the token's metadata labels.
+	CorrelationID string `json:"correlation_id"`
+	// Token is the token value.
+	Token string `json:"token"`
+}
+
+// GenerateTokensResponse is the batch of tokens minted by a bulk generation
+// request.
+type GenerateTokensResponse struct {
+	// Tokens is the list of minted tokens with their correlation ids.
+	Tokens []GeneratedToken `json:"tokens"`
+}
+
+// GenerateTokens mints a batch of provision tokens sharing the same spec in
+// one call, so provisioning pipelines can enroll many hosts without a round
+// trip per token. Each token carries a correlation id in its metadata
+// labels tying it back to the response.
+func (a *Server) GenerateTokens(ctx context.Context, req GenerateTokensRequest) (GenerateTokensResponse, error) {
+	if req.Count <= 0 {
+		return GenerateTokensResponse{}, trace.BadParameter("token count must be positive, got %v", req.Count)
+	}
+	if req.Count > maxBulkTokens {
+		return GenerateTokensResponse{}, trace.BadParameter("token count %v exceeds the maximum of %v per request", req.Count, maxBulkTokens)
+	}
+	if err := req.Roles.Check(); err != nil {
+		return GenerateTokensResponse{}, trace.Wrap(err)
+	}
+
+	resp := GenerateTokensResponse{Tokens: make([]GeneratedToken, 0, req.Count)}
+	for i := 0; i < req.Count; i++ {
+		correlationID := uuid.New().String()
+		labels := make(map[string]string, len(req.Labels)+1)
+		for key, value := range req.Labels {
+			labels[key] = value
+		}
+		labels[TokenCorrelationIDLabel] = correlationID
+
+		token, err := a.GenerateToken(ctx, &proto.GenerateTokenRequest{
+			Roles:  req.Roles,
+			TTL:    proto.Duration(req.TTL),
+			Labels: labels,
+		})
+		if err != nil {
+			return GenerateTokensResponse{}, trace.Wrap(err)
+		}
+		resp.Tokens = append(resp.Tokens, GeneratedToken{
+			CorrelationID: correlationID,
+			Token:         token,
+		})
+	}
+	return resp, nil
+}
+
 // ExtractHostID returns host id based on the hostname
 func ExtractHostID(hostName string, clusterName string) (string, error) {
 	suffix := "." + clusterName
@@ -2082,6 +2561,10 @@ func (a *Server) GenerateHostCerts(ctx context.Context, req *proto.HostCertsRequ
 		return nil, trace.Wrap(err)
 	}
 
+	if err := a.checkCASigningAllowed(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	if err := req.Role.Check(); err != nil {
 		return nil, err
 	}
@@ -2120,6 +2603,10 @@ func (a *Server) GenerateHostCerts(ctx context.Context, req *proto.HostCertsRequ
 			remoteHost)
 	}
 
+	if err := a.checkHostCertPrincipals(ctx, req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	if _, _, _, _, err := ssh.ParseAuthorizedKey(req.PublicSSHKey); err != nil {
 		return nil, trace.BadParameter("failed to parse SSH public key")
 	}
@@ -2162,6 +2649,18 @@ func (a *Server) GenerateHostCerts(ctx context.Context, req *proto.HostCertsRequ
 		}
 	}
 
+	// during rotation phases hosts re-issue their certificates en masse,
+	// pace them so the stampede does not starve interactive logins
+	if rotationThrottleApplies(ca.GetRotation()) {
+		if retryAfter, ok := a.reissueThrottle.acquire(a.clock.Now(), req.HostID); !ok {
+			generateThrottledRequestsCount.Inc()
+			log.Debugf("Node %q [%v] is paced during rotation, retry in %v.", req.NodeName, req.HostID, retryAfter)
+			return nil, trace.LimitExceeded(
+				"cluster certificate rotation is in progress and the re-issue rate limit has been reached, retry in %v",
+				retryAfter.Round(time.Second))
+		}
+	}
+
 	isAdminRole := req.Role == types.RoleAdmin
 
 	cert, signer, err := a.keyStore.GetTLSCertAndSigner(ca)
@@ -2247,6 +2746,20 @@ func (a *Server) GenerateHostCerts(ctx context.Context, req *proto.HostCertsRequ
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+
+	eventIdentity := identity.GetEventIdentity()
+	eventIdentity.Expires = certRequest.NotAfter
+	if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.CertificateCreate{
+		Metadata: apievents.Metadata{
+			Type: events.CertificateCreateEvent,
+			Code: events.CertificateCreateCode,
+		},
+		CertificateType: events.CertificateTypeHost,
+		Identity:        &eventIdentity,
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit certificate create event.")
+	}
+
 	return &proto.Certs{
 		SSH:        hostSSHCert,
 		TLS:        hostTLSCert,
@@ -2475,6 +2988,15 @@ func (a *Server) NewWebSession(ctx context.Context, req types.NewWebSessionReque
 	if sessionTTL == 0 {
 		sessionTTL = checker.AdjustSessionTTL(apidefaults.CertDuration)
 	}
+	// Cap the TTL by the connector that created the user, both new sessions
+	// and extensions of existing ones pass through here.
+	ttlLimit, err := a.webSessionTTLLimit(ctx, user)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if ttlLimit != 0 && sessionTTL > ttlLimit {
+		sessionTTL = ttlLimit
+	}
 	certs, err := a.generateUserCert(certRequest{
 		user:           user,
 		ttl:            sessionTTL,
@@ -2482,6 +3004,7 @@ func (a *Server) NewWebSession(ctx context.Context, req types.NewWebSessionReque
 		checker:        checker,
 		traits:         req.Traits,
 		activeRequests: services.RequestIDs{AccessRequests: req.AccessRequests},
+		clientOrigin:   tlsca.ClientOriginWeb,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -2586,6 +3109,9 @@ func (a *Server) CreateAccessRequest(ctx context.Context, req types.AccessReques
 	if err := a.Services.CreateAccessRequest(ctx, req); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.recordAccessRequestCreated(ctx, req); err != nil {
+		log.WithError(err).Debug("Failed to update access request insights.")
+	}
 	err = a.emitter.EmitAuditEvent(a.closeCtx, &apievents.AccessRequestCreate{
 		Metadata: apievents.Metadata{
 			Type: events.AccessRequestCreateEvent,
@@ -2604,6 +3130,14 @@ func (a *Server) CreateAccessRequest(ctx context.Context, req types.AccessReques
 	if err != nil {
 		log.WithError(err).Warn("Failed to emit access request create event.")
 	}
+	// Post the request to Slack before applying auto-approval rules so a
+	// rule-driven resolution has a message to amend.
+	a.notifySlackRequestCreated(ctx, req)
+	if req.GetState().IsPending() {
+		if err := a.autoResolveAccessRequest(ctx, req); err != nil {
+			log.WithError(err).Warn("Failed to apply auto-approval rules to access request.")
+		}
+	}
 	return nil
 }
 
@@ -2624,11 +3158,47 @@ func (a *Server) DeleteAccessRequest(ctx context.Context, name string) error {
 	return nil
 }
 
+// requestHasCustomThresholds reports whether the request carries review
+// thresholds beyond the implicit single-approval default.
+func requestHasCustomThresholds(req types.AccessRequest) bool {
+	thresholds := req.GetThresholds()
+	if len(thresholds) > 1 {
+		return true
+	}
+	for _, t := range thresholds {
+		if t.Name != "default" || t.Approve != 1 || t.Deny != 1 {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Server) SetAccessRequestState(ctx context.Context, params types.AccessRequestUpdate) error {
+	// Requests governed by custom review thresholds must be approved by
+	// matching reviews, a single state transition would sidestep the
+	// multi-approver policy the roles configured. Denials stay allowed,
+	// denying never requires a quorum.
+	if params.State.IsApproved() {
+		reqs, err := a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: params.RequestID})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(reqs) != 0 && requestHasCustomThresholds(reqs[0]) {
+			return trace.AccessDenied("access request %q is governed by review thresholds and cannot be approved directly, submit an approving review instead", params.RequestID)
+		}
+	}
 	req, err := a.Services.SetAccessRequestState(ctx, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.recordAccessRequestResolved(ctx, req, params.State); err != nil {
+		log.WithError(err).Debug("Failed to update access request insights.")
+	}
+	if params.State.IsApproved() {
+		if err := a.startAccessRequestElevation(ctx, req); err != nil {
+			log.WithError(err).Warn("Failed to start access request elevation window.")
+		}
+	}
 	event := &apievents.AccessRequestCreate{
 		Metadata: apievents.Metadata{
 			Type: events.AccessRequestUpdateEvent,
@@ -2660,6 +3230,7 @@ func (a *Server) SetAccessRequestState(ctx context.Context, params types.AccessR
 	if err != nil {
 		log.WithError(err).Warn("Failed to emit access request update event.")
 	}
+	a.notifySlackRequestResolved(ctx, req, params.State, params.Reason)
 	return trace.Wrap(err)
 }
 
@@ -2715,6 +3286,12 @@ func (a *Server) SubmitAccessReview(ctx context.Context, params types.AccessRevi
 		log.WithError(err).Warn("Failed to emit access request update event.")
 	}
 
+	// When the review tipped the request out of the pending state, reflect
+	// the resolution in Slack.
+	if !req.GetState().IsPending() {
+		a.notifySlackRequestResolved(ctx, req, req.GetState(), params.Review.Reason)
+	}
+
 	return req, nil
 }
 
@@ -2759,6 +3336,10 @@ func (a *Server) NewKeepAliver(ctx context.Context) (types.KeepAliver, error) {
 
 // GenerateCertAuthorityCRL generates an empty CRL for the local CA of a given type.
 func (a *Server) GenerateCertAuthorityCRL(ctx context.Context, caType types.CertAuthType) ([]byte, error) {
+	if err := a.checkCASigningAllowed(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	// Generate a CRL for the current cluster CA.
 	clusterName, err := a.GetClusterName()
 	if err != nil {
@@ -2792,11 +3373,17 @@ func (a *Server) GenerateCertAuthorityCRL(ctx context.Context, caType types.Cert
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	// Empty CRL valid for 1yr.
+	// CRL valid for 1yr, listing the serials revoked through
+	// RevokeUserSessions that have not expired on their own yet.
+	revocations, err := a.GetRevokedCertificates(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	template := &x509.RevocationList{
-		Number:     big.NewInt(1),
-		ThisUpdate: time.Now().Add(-1 * time.Minute), // 1 min in the past to account for clock skew.
-		NextUpdate: time.Now().Add(365 * 24 * time.Hour),
+		RevokedCertificateEntries: crlEntries(revocations),
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-1 * time.Minute), // 1 min in the past to account for clock skew.
+		NextUpdate:                time.Now().Add(365 * 24 * time.Hour),
 	}
 	crl, err := x509.CreateRevocationList(rand.Reader, template, tlsAuthority.Cert, tlsAuthority.Signer)
 	if err != nil {
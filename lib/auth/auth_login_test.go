@@ -744,3 +744,52 @@ func configureForMFA(t *testing.T, srv *TestTLSServer) *configureMFAResp {
 		WebDev:   webDev,
 	}
 }
+
+func TestServer_Authenticate_passwordlessRequired(t *testing.T) {
+	t.Parallel()
+	svr := newTestTLSServer(t)
+	authServer := svr.Auth()
+	ctx := context.Background()
+
+	authPreference, err := types.NewAuthPreference(types.AuthPreferenceSpecV2{
+		Type:          constants.Local,
+		SecondFactor:  constants.SecondFactorOptional,
+		Webauthn:      &types.Webauthn{RPID: "localhost"},
+		ConnectorName: constants.PasswordlessConnector,
+	})
+	require.NoError(t, err)
+	require.NoError(t, authServer.SetAuthPreference(ctx, authPreference))
+
+	const user = "alpaca"
+	const password = "p@ssw0rd"
+	_, _, err = CreateUserAndRole(authServer, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, authServer.UpsertPassword(user, []byte(password)))
+
+	// Password-backed login is rejected while passwordless is required.
+	_, err = authServer.AuthenticateWebUser(ctx, AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: []byte(password)},
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// Setting a new password through account reset is rejected as well.
+	resetToken, err := authServer.CreateResetPasswordToken(ctx, CreateUserTokenRequest{Name: user})
+	require.NoError(t, err)
+	_, err = authServer.ChangeUserAuthentication(ctx, &proto.ChangeUserAuthenticationRequest{
+		TokenID:     resetToken.GetName(),
+		NewPassword: []byte("n3w-p@ssw0rd"),
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Reverting to the default local connector restores password logins.
+	// Creating the reset token rotated the stored password, set it again.
+	authPreference.SetConnectorName(constants.LocalConnector)
+	require.NoError(t, authServer.SetAuthPreference(ctx, authPreference))
+	require.NoError(t, authServer.UpsertPassword(user, []byte(password)))
+	_, err = authServer.AuthenticateWebUser(ctx, AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: []byte(password)},
+	})
+	require.NoError(t, err)
+}
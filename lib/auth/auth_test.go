@@ -300,6 +300,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:          gotTLSCert.NotAfter,
 		RouteToCluster:   s.clusterName.GetClusterName(),
 		TeleportCluster:  s.clusterName.GetClusterName(),
+		ClientOrigin:     tlsca.ClientOriginTSH,
 	}
 	gotID, err := tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -332,6 +333,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:           gotTLSCert.NotAfter,
 		RouteToCluster:    "leaf.localhost",
 		TeleportCluster:   s.clusterName.GetClusterName(),
+		ClientOrigin:      tlsca.ClientOriginTSH,
 	}
 	gotID, err = tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -377,6 +379,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:           gotTLSCert.NotAfter,
 		RouteToCluster:    s.clusterName.GetClusterName(),
 		TeleportCluster:   s.clusterName.GetClusterName(),
+		ClientOrigin:      tlsca.ClientOriginTSH,
 	}
 	gotID, err = tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -410,6 +413,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:           gotTLSCert.NotAfter,
 		RouteToCluster:    s.clusterName.GetClusterName(),
 		TeleportCluster:   s.clusterName.GetClusterName(),
+		ClientOrigin:      tlsca.ClientOriginTSH,
 	}
 	gotID, err = tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -452,6 +456,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:           gotTLSCert.NotAfter,
 		RouteToCluster:    s.clusterName.GetClusterName(),
 		TeleportCluster:   s.clusterName.GetClusterName(),
+		ClientOrigin:      tlsca.ClientOriginTSH,
 	}
 	gotID, err = tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -485,6 +490,7 @@ func TestAuthenticateSSHUser(t *testing.T) {
 		Expires:           gotTLSCert.NotAfter,
 		RouteToCluster:    s.clusterName.GetClusterName(),
 		TeleportCluster:   s.clusterName.GetClusterName(),
+		ClientOrigin:      tlsca.ClientOriginTSH,
 	}
 	gotID, err = tlsca.FromSubject(gotTLSCert.Subject, gotTLSCert.NotAfter)
 	require.NoError(t, err)
@@ -647,6 +653,74 @@ func TestTokensCRUD(t *testing.T) {
 	require.Len(t, tokens, 2)
 }
 
+func TestGenerateTokenBundle(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+
+	ctx := context.Background()
+	bundle, err := s.a.GenerateTokenBundle(ctx, &proto.GenerateTokenRequest{
+		Roles: types.SystemRoles{types.RoleNode},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, bundle.Token)
+	require.NotEmpty(t, bundle.CAPins)
+	require.True(t, bundle.Expires.After(s.a.clock.Now()))
+
+	// the rendered command references the token and every active pin
+	require.Contains(t, bundle.JoinCommand, "--roles=node")
+	require.Contains(t, bundle.JoinCommand, "--token="+bundle.Token)
+	for _, pin := range bundle.CAPins {
+		require.Contains(t, bundle.JoinCommand, "--ca-pin="+pin)
+	}
+	require.Contains(t, bundle.InstallSnippet, bundle.Token)
+
+	// without a registered proxy the commands carry a placeholder address
+	require.Empty(t, bundle.ProxyAddr)
+	require.Contains(t, bundle.JoinCommand, proxyAddrPlaceholder)
+}
+
+func TestAddRemoveStaticToken(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+
+	ctx := context.Background()
+	err := s.a.AddStaticToken(ctx, types.ProvisionTokenV1{
+		Token: "static-token-one",
+		Roles: types.SystemRoles{types.RoleProxy},
+	})
+	require.NoError(t, err)
+
+	// the token is honored by validation right away, no restart involved
+	tokenResource, err := s.a.ValidateToken(ctx, "static-token-one")
+	require.NoError(t, err)
+	require.True(t, tokenResource.GetRoles().Include(types.RoleProxy))
+
+	// appending preserves previously configured tokens
+	err = s.a.AddStaticToken(ctx, types.ProvisionTokenV1{
+		Token: "static-token-two",
+		Roles: types.SystemRoles{types.RoleNode},
+	})
+	require.NoError(t, err)
+	st, err := s.a.GetStaticTokens()
+	require.NoError(t, err)
+	require.Len(t, st.GetStaticTokens(), 2)
+
+	// duplicate token values are rejected
+	err = s.a.AddStaticToken(ctx, types.ProvisionTokenV1{
+		Token: "static-token-one",
+		Roles: types.SystemRoles{types.RoleNode},
+	})
+	require.True(t, trace.IsAlreadyExists(err))
+
+	// removal takes effect immediately as well
+	require.NoError(t, s.a.RemoveStaticToken(ctx, "static-token-one"))
+	_, err = s.a.ValidateToken(ctx, "static-token-one")
+	require.Error(t, err)
+
+	err = s.a.RemoveStaticToken(ctx, "static-token-one")
+	require.True(t, trace.IsNotFound(err))
+}
+
 func TestBadTokens(t *testing.T) {
 	t.Parallel()
 	s := newAuthSuite(t)
@@ -66,12 +66,24 @@ func (a *ServerWithRoles) actionWithContext(ctx *services.Context, namespace, re
 	if len(verbs) == 0 {
 		return trace.BadParameter("no verbs provided for authorization check on resource %q", resource)
 	}
+	// scope checks precede RBAC, see Context.checkAPIScopes
+	if err := a.context.checkAPIScopes(resource, verbs...); err != nil {
+		return trace.Wrap(err)
+	}
+	if ctx.ClientOrigin == "" {
+		ctx.ClientOrigin = a.context.clientOrigin()
+	}
 	var errs []error
 	for _, verb := range verbs {
 		errs = append(errs, a.context.Checker.CheckAccessToRule(ctx, namespace, resource, verb, false))
 	}
 	// Convert generic aggregate error to AccessDenied.
 	if err := trace.NewAggregate(errs...); err != nil {
+		if origin := ctx.ClientOrigin; origin != "" {
+			// name the origin so that denials caused by origin-restricted
+			// role rules are understandable to the user
+			return trace.AccessDenied("%v (request issued from %q client)", err.Error(), origin)
+		}
 		return trace.AccessDenied(err.Error())
 	}
 	return nil
@@ -102,12 +114,19 @@ func (c actionConfig) action(namespace, resource string, verbs ...string) error
 	if len(verbs) == 0 {
 		return trace.BadParameter("no verbs provided for authorization check on resource %q", resource)
 	}
+	// scope checks precede RBAC, see Context.checkAPIScopes
+	if err := c.context.checkAPIScopes(resource, verbs...); err != nil {
+		return trace.Wrap(err)
+	}
 	var errs []error
 	for _, verb := range verbs {
-		errs = append(errs, c.context.Checker.CheckAccessToRule(&services.Context{User: c.context.User}, namespace, resource, verb, c.quiet))
+		errs = append(errs, c.context.Checker.CheckAccessToRule(&services.Context{User: c.context.User, ClientOrigin: c.context.clientOrigin()}, namespace, resource, verb, c.quiet))
 	}
 	// Convert generic aggregate error to AccessDenied.
 	if err := trace.NewAggregate(errs...); err != nil {
+		if origin := c.context.clientOrigin(); origin != "" {
+			return trace.AccessDenied("%v (request issued from %q client)", err.Error(), origin)
+		}
 		return trace.AccessDenied(err.Error())
 	}
 	return nil
@@ -121,10 +140,18 @@ func (a *ServerWithRoles) action(namespace, resource string, verbs ...string) er
 // even if they are not admins, e.g. update their own passwords,
 // or generate certificates, otherwise it will require admin privileges
 func (a *ServerWithRoles) currentUserAction(username string) error {
+	// Scoped certificates never get the self-service bypass: the self-service
+	// APIs have no scope mapping and several of them return live session
+	// credentials, so a scoped identity could otherwise trade up to an
+	// unrestricted session. Scoped identities are limited to the operations
+	// their scopes spell out, which are checked in action().
+	if a.context.scopedIdentity() {
+		return trace.AccessDenied("session scopes do not permit self-service operations")
+	}
 	if hasLocalUserRole(a.context) && username == a.context.User.GetName() {
 		return nil
 	}
-	return a.context.Checker.CheckAccessToRule(&services.Context{User: a.context.User},
+	return a.context.Checker.CheckAccessToRule(&services.Context{User: a.context.User, ClientOrigin: a.context.clientOrigin()},
 		apidefaults.Namespace, types.KindUser, types.VerbCreate, true)
 }
 
@@ -133,14 +160,54 @@ func (a *ServerWithRoles) currentUserAction(username string) error {
 // If not, it checks if the requester has the meta KindAuthConnector access
 // (which grants access to all connectors).
 func (a *ServerWithRoles) authConnectorAction(namespace string, resource string, verb string) error {
-	if err := a.context.Checker.CheckAccessToRule(&services.Context{User: a.context.User}, namespace, resource, verb, true); err != nil {
-		if err := a.context.Checker.CheckAccessToRule(&services.Context{User: a.context.User}, namespace, types.KindAuthConnector, verb, false); err != nil {
+	ruleCtx := &services.Context{User: a.context.User, ClientOrigin: a.context.clientOrigin()}
+	if err := a.context.Checker.CheckAccessToRule(ruleCtx, namespace, resource, verb, true); err != nil {
+		if err := a.context.Checker.CheckAccessToRule(ruleCtx, namespace, types.KindAuthConnector, verb, false); err != nil {
 			return trace.Wrap(err)
 		}
 	}
 	return nil
 }
 
+// adminActionMFA requires the caller of a destructive administrative action
+// to hold a certificate backed by a recent MFA check when the cluster-wide
+// per-session MFA requirement is on. Certificates issued through the
+// single-use MFA flow carry the verifying device ID and expire within a
+// minute, so their presence proves a fresh challenge.
+func (a *ServerWithRoles) adminActionMFA(ctx context.Context, action string) error {
+	authPref, err := a.authServer.GetAuthPreference(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !authPref.GetRequireSessionMFA() {
+		return nil
+	}
+	// Builtin services authenticate with host certificates and cannot answer
+	// an MFA challenge; only user identities are gated.
+	if !hasLocalUserRole(a.context) && !hasRemoteUserRole(a.context) {
+		return nil
+	}
+	if a.context.Identity.GetIdentity().MFAVerified != "" {
+		return nil
+	}
+	if err := a.authServer.emitter.EmitAuditEvent(a.CloseContext(), &apievents.AuthAttempt{
+		Metadata: apievents.Metadata{
+			Type: events.AuthAttemptEvent,
+			Code: events.AdminActionMFAFailureCode,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: a.context.User.GetName(),
+		},
+		Status: apievents.Status{
+			Success: false,
+			Error:   fmt.Sprintf("administrative action %q rejected without MFA verification", action),
+		},
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit admin action MFA failure event.")
+	}
+	return trace.AccessDenied("%v requires MFA verification, retry with a certificate issued through an MFA check", action)
+}
+
 // actionForListWithCondition extracts a restrictive filter condition to be
 // added to a list query after a simple resource check fails.
 func (a *ServerWithRoles) actionForListWithCondition(namespace, resource, identifier string) (*types.WhereExpr, error) {
@@ -497,6 +564,147 @@ func (a *ServerWithRoles) CreateCertAuthority(ca types.CertAuthority) error {
 	return trace.NotImplemented(notImplementedMessage)
 }
 
+// SetHostPrincipalPolicy updates the cluster host principal policy.
+func (a *ServerWithRoles) SetHostPrincipalPolicy(ctx context.Context, policy HostPrincipalPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetHostPrincipalPolicy(ctx, policy)
+}
+
+// GetHostPrincipalPolicy returns the cluster host principal policy.
+func (a *ServerWithRoles) GetHostPrincipalPolicy(ctx context.Context) (*HostPrincipalPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetHostPrincipalPolicy(ctx)
+}
+
+// SetDataResidencyPolicy updates the cluster data residency policy.
+func (a *ServerWithRoles) SetDataResidencyPolicy(ctx context.Context, policy DataResidencyPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuditConfig, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetDataResidencyPolicy(ctx, policy)
+}
+
+// GetDataResidencyPolicy returns the cluster data residency policy.
+func (a *ServerWithRoles) GetDataResidencyPolicy(ctx context.Context) (*DataResidencyPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuditConfig, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetDataResidencyPolicy(ctx)
+}
+
+// GetDataResidencyStatus reports compliance information for the data
+// residency policy.
+func (a *ServerWithRoles) GetDataResidencyStatus(ctx context.Context) (*DataResidencyStatus, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuditConfig, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetDataResidencyStatus(ctx)
+}
+
+// GetBots returns all certificate renewal bots registered in the cluster.
+func (a *ServerWithRoles) GetBots(ctx context.Context) ([]*Bot, error) {
+	// bots are backed by users and roles, require read access to both
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(apidefaults.Namespace, types.KindRole, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetBots(ctx)
+}
+
+// GetBot returns the certificate renewal bot with the given name.
+func (a *ServerWithRoles) GetBot(ctx context.Context, botName string) (*Bot, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(apidefaults.Namespace, types.KindRole, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetBot(ctx, botName)
+}
+
+// UpsertOutputTemplate creates or updates an output template.
+func (a *ServerWithRoles) UpsertOutputTemplate(ctx context.Context, tmpl OutputTemplate) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterConfig, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertOutputTemplate(ctx, tmpl)
+}
+
+// GetOutputTemplates returns all stored output templates.
+func (a *ServerWithRoles) GetOutputTemplates(ctx context.Context) ([]OutputTemplate, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterConfig, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetOutputTemplates(ctx)
+}
+
+// DeleteOutputTemplate removes an output template.
+func (a *ServerWithRoles) DeleteOutputTemplate(ctx context.Context, name string) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterConfig, types.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteOutputTemplate(ctx, name)
+}
+
+// RenderOutputTemplates renders all stored output templates for the calling
+// identity, typically alongside a bot certificate renewal.
+func (a *ServerWithRoles) RenderOutputTemplates(ctx context.Context) ([]RenderedOutput, error) {
+	// templates are rendered with the caller's own identity, no extra
+	// authorization is required
+	return a.authServer.RenderOutputTemplates(ctx, OutputTemplateData{
+		Username: a.context.User.GetName(),
+		Roles:    a.context.User.GetRoles(),
+	})
+}
+
+// GetCertKeyPolicy returns the cluster-level subject key policy.
+func (a *ServerWithRoles) GetCertKeyPolicy(ctx context.Context) (*CertKeyPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetCertKeyPolicy(ctx)
+}
+
+// SetCertKeyPolicy updates the cluster-level subject key policy.
+func (a *ServerWithRoles) SetCertKeyPolicy(ctx context.Context, policy CertKeyPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetCertKeyPolicy(ctx, policy)
+}
+
+// GenerateSubjectKeyPair generates a policy-compliant keypair on the server
+// for clients that cannot or do not want to generate their own.
+func (a *ServerWithRoles) GenerateSubjectKeyPair(ctx context.Context) (*SubjectKeyPair, error) {
+	// no extra authorization is required, the keypair is not bound to any
+	// identity until it is certified
+	return a.authServer.GenerateSubjectKeyPair(ctx)
+}
+
+// RunPreflightChecks validates the health of the auth server's dependencies
+// and reports the results.
+func (a *ServerWithRoles) RunPreflightChecks(ctx context.Context) (*PreflightReport, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAuthServer, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.RunPreflightChecks(ctx)
+}
+
+// RotateBackendEncryptionKey rotates the envelope key used to seal sensitive
+// values stored in the backend.
+func (a *ServerWithRoles) RotateBackendEncryptionKey(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindAuthServer, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.RotateBackendEncryptionKey(ctx)
+}
+
 // RotateCertAuthority starts or restarts certificate authority rotation process.
 func (a *ServerWithRoles) RotateCertAuthority(ctx context.Context, req RotateRequest) error {
 	if err := req.CheckAndSetDefaults(a.authServer.clock); err != nil {
@@ -505,6 +713,9 @@ func (a *ServerWithRoles) RotateCertAuthority(ctx context.Context, req RotateReq
 	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbCreate, types.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.adminActionMFA(ctx, "certificate authority rotation"); err != nil {
+		return trace.Wrap(err)
+	}
 	return a.authServer.RotateCertAuthority(ctx, req)
 }
 
@@ -522,6 +733,72 @@ func (a *ServerWithRoles) RotateExternalCertAuthority(ctx context.Context, ca ty
 	return a.authServer.RotateExternalCertAuthority(ctx, ca)
 }
 
+// RotateExternalCertAuthorities rotates all certificate authorities of a
+// remote cluster as a set, see RotateExternalCertAuthority.
+func (a *ServerWithRoles) RotateExternalCertAuthorities(ctx context.Context, cas []types.CertAuthority) error {
+	for _, ca := range cas {
+		if ca == nil {
+			return trace.BadParameter("missing certificate authority")
+		}
+		sctx := &services.Context{User: a.context.User, Resource: ca}
+		if err := a.actionWithContext(sctx, apidefaults.Namespace, types.KindCertAuthority, types.VerbRotate); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return a.authServer.RotateExternalCertAuthorities(ctx, cas)
+}
+
+// GetCertIssuances returns the certificate issuance log entries for a user.
+// Users may inspect their own issuances, inspecting other users' issuances
+// requires read access to users.
+func (a *ServerWithRoles) GetCertIssuances(ctx context.Context, username string) ([]CertIssuance, error) {
+	if err := a.currentUserAction(username); err != nil {
+		if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead, types.VerbList); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return a.authServer.GetCertIssuances(ctx, username)
+}
+
+// CreateScopedAPICerts mints a scope-restricted TLS certificate derived from
+// the caller's own session, see ScopedCertsRequest.
+func (a *ServerWithRoles) CreateScopedAPICerts(ctx context.Context, req ScopedCertsRequest) (*ScopedAPICerts, error) {
+	if !hasLocalUserRole(a.context) {
+		return nil, trace.AccessDenied("only local users may mint scoped API certificates")
+	}
+	identity := a.context.Identity.GetIdentity()
+	if len(identity.Scopes) != 0 {
+		return nil, trace.AccessDenied("scoped sessions cannot mint new credentials")
+	}
+	return a.authServer.CreateScopedAPICerts(ctx, a.context.User, identity, a.context.Checker, req)
+}
+
+// UpsertSPIFFEFederation imports or updates the bundle of an external SPIFFE
+// trust domain.
+func (a *ServerWithRoles) UpsertSPIFFEFederation(ctx context.Context, federation SPIFFEFederation) error {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertSPIFFEFederation(ctx, federation)
+}
+
+// GetSPIFFEFederations returns all imported external SPIFFE trust domains.
+func (a *ServerWithRoles) GetSPIFFEFederations(ctx context.Context) ([]SPIFFEFederation, error) {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbList, types.VerbReadNoSecrets); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetSPIFFEFederations(ctx)
+}
+
+// DeleteSPIFFEFederation removes the bundle of an external SPIFFE trust
+// domain.
+func (a *ServerWithRoles) DeleteSPIFFEFederation(ctx context.Context, trustDomain string) error {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteSPIFFEFederation(ctx, trustDomain)
+}
+
 // UpsertCertAuthority updates existing cert authority or updates the existing one.
 func (a *ServerWithRoles) UpsertCertAuthority(ca types.CertAuthority) error {
 	if ca == nil {
@@ -582,6 +859,14 @@ func (a *ServerWithRoles) GetClusterCACert(
 	return a.authServer.GetClusterCACert(ctx)
 }
 
+// GetClusterCACertPins returns the SPKI pins of the local cluster host CA,
+// including pending pins of an ongoing rotation.
+func (a *ServerWithRoles) GetClusterCACertPins(ctx context.Context) ([]CAPin, error) {
+	// Pins are derived from public CA certificates, so like GetClusterCACert
+	// all roles are allowed to read them.
+	return a.authServer.GetClusterCACertPins(ctx)
+}
+
 func (a *ServerWithRoles) DeleteCertAuthority(id types.CertAuthID) error {
 	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbDelete); err != nil {
 		return trace.Wrap(err)
@@ -604,14 +889,55 @@ func (a *ServerWithRoles) GenerateToken(ctx context.Context, req *proto.Generate
 	if err := a.action(apidefaults.Namespace, types.KindToken, types.VerbCreate); err != nil {
 		return "", trace.Wrap(err)
 	}
+	if err := a.adminActionMFA(ctx, "token generation"); err != nil {
+		return "", trace.Wrap(err)
+	}
 	return a.authServer.GenerateToken(ctx, req)
 }
 
+// GenerateTokens mints a batch of provision tokens sharing the same spec.
+func (a *ServerWithRoles) GenerateTokens(ctx context.Context, req GenerateTokensRequest) (GenerateTokensResponse, error) {
+	if err := a.action(apidefaults.Namespace, types.KindToken, types.VerbCreate); err != nil {
+		return GenerateTokensResponse{}, trace.Wrap(err)
+	}
+	if err := a.adminActionMFA(ctx, "token generation"); err != nil {
+		return GenerateTokensResponse{}, trace.Wrap(err)
+	}
+	return a.authServer.GenerateTokens(ctx, req)
+}
+
 func (a *ServerWithRoles) RegisterUsingToken(ctx context.Context, req *types.RegisterUsingTokenRequest) (*proto.Certs, error) {
 	// tokens have authz mechanism  on their own, no need to check
 	return a.authServer.RegisterUsingToken(ctx, req)
 }
 
+// RegisterUsingGCPMethod registers a new node using a GCP-signed instance
+// identity token.
+func (a *ServerWithRoles) RegisterUsingGCPMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	// tokens have authz mechanism  on their own, no need to check
+	return a.authServer.RegisterUsingGCPMethod(ctx, req, idToken)
+}
+
+// RegisterUsingKubernetesMethod registers a new node using a projected
+// Kubernetes service account token.
+func (a *ServerWithRoles) RegisterUsingKubernetesMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	// tokens have authz mechanism  on their own, no need to check
+	return a.authServer.RegisterUsingKubernetesMethod(ctx, req, idToken)
+}
+
+// RegisterUsingGitLabMethod registers a new node using a GitLab CI ID token.
+func (a *ServerWithRoles) RegisterUsingGitLabMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	// tokens have authz mechanism  on their own, no need to check
+	return a.authServer.RegisterUsingGitLabMethod(ctx, req, idToken)
+}
+
+// RegisterUsingCircleCIMethod registers a new node using a CircleCI OIDC
+// token.
+func (a *ServerWithRoles) RegisterUsingCircleCIMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	// tokens have authz mechanism  on their own, no need to check
+	return a.authServer.RegisterUsingCircleCIMethod(ctx, req, idToken)
+}
+
 // RegisterUsingIAMMethod registers the caller using the IAM join method and
 // returns signed certs to join the cluster.
 //
@@ -636,14 +962,12 @@ func (a *ServerWithRoles) GenerateHostCerts(ctx context.Context, req *proto.Host
 		return nil, trace.AccessDenied("username mismatch %q and %q", a.context.User.GetName(), HostFQDN(req.HostID, clusterName))
 	}
 
-	if req.Role == types.RoleInstance {
+	// instance certs always encode system roles, and combined agents may
+	// request a single cert encoding all the system roles they hold
+	if req.Role == types.RoleInstance || len(req.SystemRoles) != 0 {
 		if err := a.checkAdditionalSystemRoles(ctx, req); err != nil {
 			return nil, trace.Wrap(err)
 		}
-	} else {
-		if len(req.SystemRoles) != 0 {
-			return nil, trace.AccessDenied("additional system role encoding not supported for certs of type %q", req.Role)
-		}
 	}
 
 	existingRoles, err := types.NewTeleportRoles(a.context.User.GetRoles())
@@ -1481,6 +1805,23 @@ func (a *ServerWithRoles) GetAuthServers() ([]types.Server, error) {
 	return a.authServer.GetAuthServers()
 }
 
+// PromoteAuthServer promotes the auth server with the given server ID to the
+// primary role, fencing CA signing operations of the previous primary.
+func (a *ServerWithRoles) PromoteAuthServer(ctx context.Context, serverID string) (*AuthLeadership, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAuthServer, types.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.PromoteAuthServer(ctx, serverID)
+}
+
+// GetAuthLeadership returns the current auth server leadership record.
+func (a *ServerWithRoles) GetAuthLeadership(ctx context.Context) (*AuthLeadership, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAuthServer, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAuthLeadership(ctx)
+}
+
 // DeleteAllAuthServers deletes all auth servers
 func (a *ServerWithRoles) DeleteAllAuthServers() error {
 	if err := a.action(apidefaults.Namespace, types.KindAuthServer, types.VerbDelete); err != nil {
@@ -1569,6 +1910,15 @@ func (a *ServerWithRoles) GetTokens(ctx context.Context) ([]types.ProvisionToken
 	return a.authServer.GetTokens(ctx)
 }
 
+// ListTokens returns a page of provision tokens matching the request
+// filters.
+func (a *ServerWithRoles) ListTokens(ctx context.Context, req ListTokensRequest) (ListTokensResponse, error) {
+	if err := a.action(apidefaults.Namespace, types.KindToken, types.VerbList, types.VerbRead); err != nil {
+		return ListTokensResponse{}, trace.Wrap(err)
+	}
+	return a.authServer.ListTokens(ctx, req)
+}
+
 func (a *ServerWithRoles) GetToken(ctx context.Context, token string) (types.ProvisionToken, error) {
 	// The Proxy has permission to look up tokens by name in order to validate
 	// attempts to use the node join script.
@@ -1594,8 +1944,11 @@ func (a *ServerWithRoles) CreateToken(ctx context.Context, token types.Provision
 	return a.authServer.CreateToken(ctx, token)
 }
 
+// UpsertPassword sets a password without verifying the existing one, which is
+// reserved for administrative flows like resets. Users changing their own
+// password go through ChangePassword, which checks the current credentials.
 func (a *ServerWithRoles) UpsertPassword(user string, password []byte) error {
-	if err := a.currentUserAction(user); err != nil {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
 	return a.authServer.UpsertPassword(user, password)
@@ -1609,12 +1962,18 @@ func (a *ServerWithRoles) ChangePassword(req services.ChangePasswordReq) error {
 	return a.authServer.ChangePassword(req)
 }
 
+// CheckPassword checks the password and OTP token of the user.
+//
+// Deprecated: use VerifyAuthentication, which forwards client metadata for
+// throttling and auditing.
 func (a *ServerWithRoles) CheckPassword(user string, password []byte, otpToken string) error {
-	if err := a.currentUserAction(user); err != nil {
-		return trace.Wrap(err)
-	}
-	_, err := a.authServer.checkPassword(user, password, otpToken)
-	return trace.Wrap(err)
+	// Route the check through the audited verification path so even
+	// privileged callers leave a trail and count towards the lockout.
+	return a.VerifyAuthentication(context.TODO(), VerifyAuthenticationRequest{
+		Username: user,
+		Password: password,
+		OTPToken: otpToken,
+	})
 }
 
 func (a *ServerWithRoles) PreAuthenticatedSignIn(ctx context.Context, user string) (types.WebSession, error) {
@@ -1642,6 +2001,15 @@ func (a *ServerWithRoles) ExtendWebSession(ctx context.Context, req WebSessionRe
 	return a.authServer.ExtendWebSession(ctx, req, a.context.Identity.GetIdentity())
 }
 
+// StepDownWebSession creates a new web session for a user with specific
+// roles or assumed access requests dropped from the current session.
+func (a *ServerWithRoles) StepDownWebSession(ctx context.Context, req StepDownWebSessionReq) (types.WebSession, error) {
+	if err := a.currentUserAction(req.User); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.StepDownWebSession(ctx, req, a.context.Identity.GetIdentity())
+}
+
 // GetWebSessionInfo returns the web session for the given user specified with sid.
 // The session is stripped of any authentication details.
 // Implements auth.WebUIService
@@ -1845,13 +2213,29 @@ func (a *ServerWithRoles) GetAccessRequests(ctx context.Context, filter types.Ac
 	return filtered, nil
 }
 
-func (a *ServerWithRoles) CreateAccessRequest(ctx context.Context, req types.AccessRequest) error {
-	// An exception is made to allow users to create access *pending* requests for themselves.
+// createAccessRequestAction authorizes creating the given access request.
+// An exception is made to allow users to create *pending* requests for
+// themselves; for scoped identities the currentUserAction bypass is off, so
+// the scope check takes its place on their own pending requests.
+func (a *ServerWithRoles) createAccessRequestAction(req types.AccessRequest) error {
+	if a.context.scopedIdentity() {
+		if !req.GetState().IsPending() || !hasLocalUserRole(a.context) || req.GetUser() != a.context.User.GetName() {
+			return trace.AccessDenied("session scopes only permit creating pending access requests for the session user")
+		}
+		return trace.Wrap(a.context.checkAPIScopes(types.KindAccessRequest, types.VerbCreate))
+	}
 	if !req.GetState().IsPending() || a.currentUserAction(req.GetUser()) != nil {
 		if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbCreate); err != nil {
 			return trace.Wrap(err)
 		}
 	}
+	return nil
+}
+
+func (a *ServerWithRoles) CreateAccessRequest(ctx context.Context, req types.AccessRequest) error {
+	if err := a.createAccessRequestAction(req); err != nil {
+		return trace.Wrap(err)
+	}
 	// Ensure that an access request cannot outlive the identity that creates it.
 	if req.GetAccessExpiry().Before(a.authServer.GetClock().Now()) || req.GetAccessExpiry().After(a.context.Identity.GetIdentity().Expires) {
 		req.SetAccessExpiry(a.context.Identity.GetIdentity().Expires)
@@ -2110,6 +2494,12 @@ func (a *ServerWithRoles) DeleteUser(ctx context.Context, user string) error {
 	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.checkUserManagementDelegation(user); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.adminActionMFA(ctx, "user deletion"); err != nil {
+		return trace.Wrap(err)
+	}
 
 	return a.authServer.DeleteUser(ctx, user)
 }
@@ -2277,6 +2667,17 @@ func (a *ServerWithRoles) GenerateUserCerts(ctx context.Context, req proto.UserC
 	return a.generateUserCerts(ctx, req)
 }
 
+// GenerateUserCertsWithAttestation is like GenerateUserCerts but additionally
+// verifies a hardware key attestation statement for the requested public key
+// and embeds the resulting private key policy in the issued certificates.
+func (a *ServerWithRoles) GenerateUserCertsWithAttestation(ctx context.Context, req proto.UserCertsRequest, attestation *AttestationStatement) (*proto.Certs, error) {
+	policy, err := a.authServer.verifyHardwareKeyAttestation(attestation, req.PublicKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.generateUserCerts(ctx, req, certRequestPrivateKeyPolicy(policy))
+}
+
 func (a *ServerWithRoles) generateUserCerts(ctx context.Context, req proto.UserCertsRequest, opts ...certRequestOption) (*proto.Certs, error) {
 	var err error
 
@@ -2474,6 +2875,13 @@ func (a *ServerWithRoles) generateUserCerts(ctx context.Context, req proto.UserC
 			AccessRequests: req.AccessRequests,
 		},
 	}
+	if a.context.Identity != nil {
+		// reissued certs keep the client origin, device binding and source
+		// IP pin of the issuing identity
+		certReq.clientOrigin = a.context.Identity.GetIdentity().ClientOrigin
+		certReq.deviceID = a.context.Identity.GetIdentity().DeviceID
+		certReq.sourceIP = a.context.Identity.GetIdentity().PinnedIP
+	}
 	if user.GetName() != a.context.User.GetName() {
 		certReq.impersonator = a.context.User.GetName()
 	} else if req.UseRoleRequests || len(req.RoleRequests) > 0 {
@@ -2494,8 +2902,10 @@ func (a *ServerWithRoles) generateUserCerts(ctx context.Context, req proto.UserC
 	case proto.UserCertsRequest_Kubernetes:
 		certReq.usage = []string{teleport.UsageKubeOnly}
 	case proto.UserCertsRequest_SSH:
-		// SSH certs are ssh-only by definition, certReq.usage only applies to
-		// TLS certs.
+		// SSH certs are ssh-only by definition, but a TLS cert is still
+		// generated alongside them. Mark it ssh-only so no TLS endpoint
+		// accepts it if it leaks.
+		certReq.usage = []string{teleport.UsageSSHOnly}
 	case proto.UserCertsRequest_All:
 		// Unrestricted usage.
 	case proto.UserCertsRequest_WindowsDesktop:
@@ -2583,6 +2993,9 @@ func (a *ServerWithRoles) CreateResetPasswordToken(ctx context.Context, req Crea
 	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := a.checkUserManagementDelegation(req.Name); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return a.authServer.CreateResetPasswordToken(ctx, req)
 }
 
@@ -2624,6 +3037,9 @@ func (a *ServerWithRoles) UpsertUser(u types.User) error {
 	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbCreate, types.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.checkUserManagementDelegation(u.GetName()); err != nil {
+		return trace.Wrap(err)
+	}
 
 	createdBy := u.GetCreatedBy()
 	if createdBy.IsEmpty() {
@@ -2969,6 +3385,10 @@ func (a *ServerWithRoles) CreateAuditStream(ctx context.Context, sid session.ID)
 	if !ok || !role.IsServer() {
 		return nil, trace.AccessDenied("this request can be only executed by proxy, node or auth")
 	}
+	// enforce the data residency policy before any session data is accepted
+	if err := a.authServer.checkServerResidency(ctx, role.GetServerID()); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	stream, err := a.authServer.CreateAuditStream(ctx, sid)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -3139,6 +3559,9 @@ func (a *ServerWithRoles) UpsertRole(ctx context.Context, role types.Role) error
 	if err := a.action(apidefaults.Namespace, types.KindRole, types.VerbCreate, types.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := a.adminActionMFA(ctx, "role modification"); err != nil {
+		return trace.Wrap(err)
+	}
 
 	// Some options are only available with enterprise subscription
 	if err := checkRoleFeatureSupport(role); err != nil {
@@ -3525,6 +3948,16 @@ func (a *ServerWithRoles) UpsertTrustedCluster(ctx context.Context, tc types.Tru
 	return a.authServer.UpsertTrustedCluster(ctx, tc)
 }
 
+// PreviewTrustedCluster reports which remote roles would map to which local
+// roles under the proposed trusted cluster, without committing it.
+func (a *ServerWithRoles) PreviewTrustedCluster(ctx context.Context, tc types.TrustedCluster) (*TrustedClusterPreview, error) {
+	if err := a.action(apidefaults.Namespace, types.KindTrustedCluster, types.VerbCreate, types.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.PreviewTrustedCluster(ctx, tc)
+}
+
 func (a *ServerWithRoles) ValidateTrustedCluster(ctx context.Context, validateRequest *ValidateTrustedClusterRequest) (*ValidateTrustedClusterResponse, error) {
 	// the token provides it's own authorization and authentication
 	return a.authServer.validateTrustedCluster(ctx, validateRequest)
@@ -4422,6 +4855,26 @@ func (a *ServerWithRoles) UpsertLock(ctx context.Context, lock types.Lock) error
 	return a.authServer.UpsertLock(ctx, lock)
 }
 
+// AckLockPropagation records that the calling agent has committed the lock
+// to its local view. The agent is identified by its own certificate, so
+// acknowledgments cannot be spoofed on behalf of other agents.
+func (a *ServerWithRoles) AckLockPropagation(ctx context.Context, lockName string) error {
+	if !a.hasBuiltinRole(types.RoleNode, types.RoleProxy, types.RoleKube, types.RoleApp,
+		types.RoleDatabase, types.RoleWindowsDesktop, types.RoleInstance) {
+		return trace.AccessDenied("this request can be only executed by a Teleport agent")
+	}
+	return a.authServer.ackLockPropagation(ctx, lockName, a.context.Identity.GetIdentity().Username)
+}
+
+// GetLockPropagationStatus returns the per-agent acknowledgments received for
+// a lock, so revocation SLAs can be verified.
+func (a *ServerWithRoles) GetLockPropagationStatus(ctx context.Context, lockName string) (*LockPropagationStatus, error) {
+	if err := a.action(apidefaults.Namespace, types.KindLock, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetLockPropagationStatus(ctx, lockName)
+}
+
 // DeleteLock deletes a lock.
 func (a *ServerWithRoles) DeleteLock(ctx context.Context, name string) error {
 	if err := a.action(apidefaults.Namespace, types.KindLock, types.VerbDelete); err != nil {
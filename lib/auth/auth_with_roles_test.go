@@ -3644,3 +3644,57 @@ func TestListResources_WithRoles(t *testing.T) {
 		})
 	}
 }
+
+// TestAdminActionMFA verifies that destructive administrative APIs demand an
+// MFA-verified certificate once the cluster-wide per-session MFA requirement
+// is on.
+func TestAdminActionMFA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	srv := newTestTLSServer(t)
+
+	user, role, err := CreateUserAndRole(srv.Auth(), "admin-mfa-user", []string{"admin-mfa-user"})
+	require.NoError(t, err)
+	role.SetRules(types.Allow, append(role.GetRules(types.Allow),
+		types.NewRule(types.KindRole, services.RW()),
+		types.NewRule(types.KindToken, services.RW()),
+	))
+	require.NoError(t, srv.Auth().UpsertRole(ctx, role))
+
+	testRole, err := types.NewRole("admin-mfa-test-role", types.RoleSpecV5{})
+	require.NoError(t, err)
+
+	// Without the cluster-wide requirement any certificate will do.
+	clt, err := srv.NewClient(TestUser(user.GetName()))
+	require.NoError(t, err)
+	require.NoError(t, clt.UpsertRole(ctx, testRole))
+
+	authPref, err := types.NewAuthPreference(types.AuthPreferenceSpecV2{
+		Type:         constants.Local,
+		SecondFactor: constants.SecondFactorOn,
+		Webauthn: &types.Webauthn{
+			RPID: "localhost",
+		},
+		RequireSessionMFA: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, srv.Auth().SetAuthPreference(ctx, authPref))
+
+	// Certificates without MFA verification are now rejected.
+	err = clt.UpsertRole(ctx, testRole)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	_, err = clt.GenerateToken(ctx, &proto.GenerateTokenRequest{Roles: types.SystemRoles{types.RoleNode}})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// An MFA-verified certificate passes the gate.
+	mfaIdentity := TestUser(user.GetName())
+	mfaIdentity.MFAVerified = "test-device"
+	mfaClt, err := srv.NewClient(mfaIdentity)
+	require.NoError(t, err)
+	require.NoError(t, mfaClt.UpsertRole(ctx, testRole))
+
+	// Builtin services are not gated on MFA.
+	adminClt, err := srv.NewClient(TestAdmin())
+	require.NoError(t, err)
+	require.NoError(t, adminClt.UpsertRole(ctx, testRole))
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// accessAutoApprovalPrefix is the backend prefix auto-approval rules
+	// are stored under.
+	accessAutoApprovalPrefix = "access_autoapproval_rules"
+
+	// AutoApprovalDecisionApprove makes a matching rule approve the request.
+	AutoApprovalDecisionApprove = "approve"
+	// AutoApprovalDecisionDeny makes a matching rule deny the request.
+	AutoApprovalDecisionDeny = "deny"
+)
+
+// AccessAutoApprovalRule resolves freshly created access requests without
+// human review. A rule matches when all of its configured conditions hold,
+// unset conditions match everything. When several rules match the same
+// request, deny wins over approve.
+type AccessAutoApprovalRule struct {
+	// Name is the name of the rule.
+	Name string `json:"name"`
+	// Description explains why the rule exists.
+	Description string `json:"description,omitempty"`
+	// Decision is what a match does to the request, either "approve" or
+	// "deny".
+	Decision string `json:"decision"`
+	// RequestedRoles matches requests whose requested roles are all listed.
+	RequestedRoles []string `json:"requested_roles,omitempty"`
+	// Resources matches requests whose requested resources are all listed,
+	// in the same format tsh accepts.
+	Resources []string `json:"resources,omitempty"`
+	// RequesterRoles matches requesters holding at least one of the listed
+	// roles.
+	RequesterRoles []string `json:"requester_roles,omitempty"`
+	// RequesterTraits matches requesters carrying one of the listed values
+	// for every key.
+	RequesterTraits map[string][]string `json:"requester_traits,omitempty"`
+	// TimeOfDayStart and TimeOfDayEnd bound when the rule applies, in
+	// "HH:MM" UTC. A start after the end describes an overnight window.
+	TimeOfDayStart string `json:"time_of_day_start,omitempty"`
+	// TimeOfDayEnd is the end of the time-of-day window.
+	TimeOfDayEnd string `json:"time_of_day_end,omitempty"`
+	// UpdatedAt is the time the rule was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the rule.
+func (r *AccessAutoApprovalRule) CheckAndSetDefaults() error {
+	if r.Name == "" {
+		return trace.BadParameter("auto-approval rule name is required")
+	}
+	if r.Decision != AutoApprovalDecisionApprove && r.Decision != AutoApprovalDecisionDeny {
+		return trace.BadParameter("auto-approval rule %q has invalid decision %q, expected %q or %q",
+			r.Name, r.Decision, AutoApprovalDecisionApprove, AutoApprovalDecisionDeny)
+	}
+	if (r.TimeOfDayStart == "") != (r.TimeOfDayEnd == "") {
+		return trace.BadParameter("auto-approval rule %q must set both or neither of the time-of-day bounds", r.Name)
+	}
+	if r.TimeOfDayStart != "" {
+		if _, err := time.Parse("15:04", r.TimeOfDayStart); err != nil {
+			return trace.BadParameter("auto-approval rule %q has invalid time-of-day start %q, expected HH:MM", r.Name, r.TimeOfDayStart)
+		}
+		if _, err := time.Parse("15:04", r.TimeOfDayEnd); err != nil {
+			return trace.BadParameter("auto-approval rule %q has invalid time-of-day end %q, expected HH:MM", r.Name, r.TimeOfDayEnd)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the request filed by the
+// given user at the given time.
+func (r *AccessAutoApprovalRule) matches(req types.AccessRequest, requester types.User, now time.Time) bool {
+	if len(r.RequestedRoles) != 0 {
+		for _, role := range req.GetRoles() {
+			if !apiutils.SliceContainsStr(r.RequestedRoles, role) {
+				return false
+			}
+		}
+	}
+	if len(r.Resources) != 0 {
+		resourceIDs := req.GetRequestedResourceIDs()
+		if len(resourceIDs) == 0 {
+			return false
+		}
+		for _, id := range resourceIDs {
+			if !apiutils.SliceContainsStr(r.Resources, types.ResourceIDToString(id)) {
+				return false
+			}
+		}
+	}
+	if len(r.RequesterRoles) != 0 {
+		match := false
+		for _, role := range requester.GetRoles() {
+			if apiutils.SliceContainsStr(r.RequesterRoles, role) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	if len(r.RequesterTraits) != 0 {
+		traits := requester.GetTraits()
+		for key, values := range r.RequesterTraits {
+			match := false
+			for _, have := range traits[key] {
+				if apiutils.SliceContainsStr(values, have) {
+					match = true
+					break
+				}
+			}
+			if !match {
+				return false
+			}
+		}
+	}
+	if r.TimeOfDayStart != "" {
+		minutes := now.UTC().Hour()*60 + now.UTC().Minute()
+		start, _ := time.Parse("15:04", r.TimeOfDayStart)
+		end, _ := time.Parse("15:04", r.TimeOfDayEnd)
+		startMinutes := start.Hour()*60 + start.Minute()
+		endMinutes := end.Hour()*60 + end.Minute()
+		if startMinutes <= endMinutes {
+			if minutes < startMinutes || minutes >= endMinutes {
+				return false
+			}
+		} else if minutes < startMinutes && minutes >= endMinutes {
+			// overnight window, e.g. 22:00-06:00
+			return false
+		}
+	}
+	return true
+}
+
+// UpsertAccessAutoApprovalRule creates or updates an auto-approval rule.
+func (a *Server) UpsertAccessAutoApprovalRule(ctx context.Context, rule AccessAutoApprovalRule) error {
+	if err := rule.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	rule.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(rule)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(accessAutoApprovalPrefix, rule.Name),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetAccessAutoApprovalRules returns all defined auto-approval rules.
+func (a *Server) GetAccessAutoApprovalRules(ctx context.Context) ([]AccessAutoApprovalRule, error) {
+	startKey := backend.Key(accessAutoApprovalPrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rules := make([]AccessAutoApprovalRule, 0, len(result.Items))
+	for _, item := range result.Items {
+		var rule AccessAutoApprovalRule
+		if err := utils.FastUnmarshal(item.Value, &rule); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// DeleteAccessAutoApprovalRule removes the auto-approval rule with the
+// given name.
+func (a *Server) DeleteAccessAutoApprovalRule(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("auto-approval rule name is required")
+	}
+	err := a.bk.Delete(ctx, backend.Key(accessAutoApprovalPrefix, name))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("auto-approval rule %q is not found", name)
+	}
+	return trace.Wrap(err)
+}
+
+// matchAutoApprovalRules returns the rule that resolves the request, nil
+// when none applies. Deny rules win over approve rules.
+func (a *Server) matchAutoApprovalRules(ctx context.Context, req types.AccessRequest) (*AccessAutoApprovalRule, error) {
+	rules, err := a.GetAccessAutoApprovalRules(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	requester, err := a.GetUser(req.GetUser(), false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	now := a.clock.Now()
+	var matched *AccessAutoApprovalRule
+	for i := range rules {
+		rule := rules[i]
+		if !rule.matches(req, requester, now) {
+			continue
+		}
+		if matched == nil || rule.Decision == AutoApprovalDecisionDeny {
+			matched = &rule
+		}
+		if matched.Decision == AutoApprovalDecisionDeny {
+			break
+		}
+	}
+	return matched, nil
+}
+
+// autoResolveAccessRequest applies the auto-approval rules to a freshly
+// created pending request. It is best effort, a request that cannot be
+// auto-resolved simply stays pending for human review.
+func (a *Server) autoResolveAccessRequest(ctx context.Context, req types.AccessRequest) error {
+	matched, err := a.matchAutoApprovalRules(ctx, req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if matched == nil {
+		return nil
+	}
+	state := types.RequestState_DENIED
+	if matched.Decision == AutoApprovalDecisionApprove {
+		if requestHasCustomThresholds(req) {
+			// Multi-approver policies always outrank automation.
+			log.Debugf("Not auto-approving access request %v, it is governed by review thresholds.", req.GetName())
+			return nil
+		}
+		state = types.RequestState_APPROVED
+	}
+	resolved, err := a.Services.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: req.GetName(),
+		State:     state,
+		Reason:    "automatically resolved by rule " + matched.Name,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.recordAccessRequestResolved(ctx, resolved, state); err != nil {
+		log.WithError(err).Debug("Failed to update access request insights.")
+	}
+	if state.IsApproved() {
+		if err := a.startAccessRequestElevation(ctx, resolved); err != nil {
+			log.WithError(err).Warn("Failed to start access request elevation window.")
+		}
+	}
+	code := events.AccessRequestAutoApprovedCode
+	if state.IsDenied() {
+		code = events.AccessRequestAutoDeniedCode
+	}
+	if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.AccessRequestCreate{
+		Metadata: apievents.Metadata{
+			Type: events.AccessRequestAutoResolvedEvent,
+			Code: code,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: req.GetUser(),
+		},
+		ResourceMetadata: apievents.ResourceMetadata{
+			Expires: resolved.GetAccessExpiry(),
+		},
+		RequestID:    req.GetName(),
+		RequestState: state.String(),
+		Reason:       "rule " + matched.Name,
+		Roles:        req.GetRoles(),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit access request auto-resolve event.")
+	}
+	a.notifySlackRequestResolved(ctx, resolved, state, "automatically resolved by rule "+matched.Name)
+	return nil
+}
+
+// UpsertAccessAutoApprovalRule creates or updates an auto-approval rule.
+func (a *ServerWithRoles) UpsertAccessAutoApprovalRule(ctx context.Context, rule AccessAutoApprovalRule) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertAccessAutoApprovalRule(ctx, rule)
+}
+
+// GetAccessAutoApprovalRules returns all defined auto-approval rules.
+func (a *ServerWithRoles) GetAccessAutoApprovalRules(ctx context.Context) ([]AccessAutoApprovalRule, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessAutoApprovalRules(ctx)
+}
+
+// DeleteAccessAutoApprovalRule removes the auto-approval rule with the
+// given name.
+func (a *ServerWithRoles) DeleteAccessAutoApprovalRule(ctx context.Context, name string) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAccessAutoApprovalRule(ctx, name)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/events"
+)
+
+func TestAccessAutoApprovalRuleValidation(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	err := s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{Decision: AutoApprovalDecisionApprove})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{Name: "bad-decision", Decision: "escalate"})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:           "half-window",
+		Decision:       AutoApprovalDecisionApprove,
+		TimeOfDayStart: "09:00",
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:           "bad-time",
+		Decision:       AutoApprovalDecisionApprove,
+		TimeOfDayStart: "9am",
+		TimeOfDayEnd:   "5pm",
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	require.NoError(t, s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:     "valid",
+		Decision: AutoApprovalDecisionDeny,
+	}))
+	rules, err := s.a.GetAccessAutoApprovalRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.NoError(t, s.a.DeleteAccessAutoApprovalRule(ctx, "valid"))
+	err = s.a.DeleteAccessAutoApprovalRule(ctx, "valid")
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestAccessRequestAutoResolution(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	// Pin the fake clock to 10:00 UTC for the time-of-day assertions. The
+	// backend expires items against the real clock, so pick tomorrow to
+	// keep the stored requests alive.
+	tomorrow := time.Now().UTC().Add(24 * time.Hour)
+	clock := clockwork.NewFakeClockAt(time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, time.UTC))
+	s.a.SetClock(clock)
+
+	requestable, err := types.NewRoleV3("auto-elevated", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requestable))
+
+	requester, err := types.NewRoleV3("auto-requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"auto-elevated"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	oncall, err := types.NewUser("oncall-user")
+	require.NoError(t, err)
+	oncall.SetRoles([]string{"auto-requester"})
+	oncall.SetTraits(map[string][]string{"team": {"sre"}})
+	require.NoError(t, s.a.UpsertUser(oncall))
+
+	contractor, err := types.NewUser("contractor-user")
+	require.NoError(t, err)
+	contractor.SetRoles([]string{"auto-requester"})
+	contractor.SetTraits(map[string][]string{"team": {"contractors"}})
+	require.NoError(t, s.a.UpsertUser(contractor))
+
+	createRequest := func(user string) types.AccessRequest {
+		req, err := types.NewAccessRequest(uuid.New().String(), user, "auto-elevated")
+		require.NoError(t, err)
+		req.SetAccessExpiry(clock.Now().Add(4 * time.Hour))
+		require.NoError(t, s.a.CreateAccessRequest(ctx, req))
+		return req
+	}
+	requestState := func(id string) types.RequestState {
+		reqs, err := s.a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: id})
+		require.NoError(t, err)
+		require.Len(t, reqs, 1)
+		return reqs[0].GetState()
+	}
+
+	// Without rules, requests stay pending.
+	req := createRequest("oncall-user")
+	require.True(t, requestState(req.GetName()).IsPending())
+
+	// SRE requests during business hours are approved automatically, with
+	// an audited decision naming the rule.
+	require.NoError(t, s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:            "sre-business-hours",
+		Decision:        AutoApprovalDecisionApprove,
+		RequestedRoles:  []string{"auto-elevated"},
+		RequesterTraits: map[string][]string{"team": {"sre"}},
+		TimeOfDayStart:  "09:00",
+		TimeOfDayEnd:    "17:00",
+	}))
+	req = createRequest("oncall-user")
+	require.True(t, requestState(req.GetName()).IsApproved())
+	event := s.mockEmitter.LastEvent()
+	require.Equal(t, events.AccessRequestAutoResolvedEvent, event.GetType())
+	require.Equal(t, events.AccessRequestAutoApprovedCode, event.GetCode())
+
+	// Requests from users the rule does not cover stay pending.
+	req = createRequest("contractor-user")
+	require.True(t, requestState(req.GetName()).IsPending())
+
+	// Outside the time window the rule does not fire.
+	clock.Advance(10 * time.Hour)
+	req = createRequest("oncall-user")
+	require.True(t, requestState(req.GetName()).IsPending())
+	clock.Advance(14 * time.Hour)
+
+	// A matching deny rule wins over the approve rule.
+	require.NoError(t, s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:            "block-contractors",
+		Decision:        AutoApprovalDecisionDeny,
+		RequesterTraits: map[string][]string{"team": {"contractors", "sre"}},
+	}))
+	req = createRequest("oncall-user")
+	require.True(t, requestState(req.GetName()).IsDenied())
+	require.Equal(t, events.AccessRequestAutoDeniedCode, s.mockEmitter.LastEvent().GetCode())
+}
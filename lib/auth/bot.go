@@ -231,6 +231,83 @@ func (s *Server) deleteBot(ctx context.Context, botName string) error {
 	return trace.NewAggregate(userErr, roleErr)
 }
 
+// Bot is a first-class view of a certificate renewal bot, assembled from the
+// user, role and generation counter that back it.
+type Bot struct {
+	// Name is the name of the bot.
+	Name string `json:"name"`
+	// UserName is the name of the backing user.
+	UserName string `json:"user_name"`
+	// RoleName is the name of the backing role.
+	RoleName string `json:"role_name"`
+	// Roles are the roles the bot may impersonate when requesting
+	// certificates.
+	Roles []string `json:"roles"`
+	// Generation is the bot's current certificate generation counter, used
+	// to detect reuse of stolen renewable credentials.
+	Generation uint64 `json:"generation"`
+}
+
+// botFromUser assembles the Bot view for a user carrying the BotLabel.
+func (s *Server) botFromUser(ctx context.Context, user types.User) (*Bot, error) {
+	botName, ok := user.GetMetadata().Labels[types.BotLabel]
+	if !ok {
+		return nil, trace.BadParameter("user %q is not a bot user", user.GetName())
+	}
+	bot := &Bot{
+		Name:     botName,
+		UserName: user.GetName(),
+		RoleName: BotResourceName(botName),
+	}
+	if generation, ok := user.GetMetadata().Labels[types.BotGenerationLabel]; ok {
+		if parsed, err := strconv.ParseUint(generation, 10, 64); err == nil {
+			bot.Generation = parsed
+		}
+	}
+	role, err := s.GetRole(ctx, bot.RoleName)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	} else if impersonate := role.GetImpersonateConditions(types.Allow); impersonate.Roles != nil {
+		bot.Roles = impersonate.Roles
+	}
+	return bot, nil
+}
+
+// GetBots returns all certificate renewal bots registered in the cluster.
+func (s *Server) GetBots(ctx context.Context) ([]*Bot, error) {
+	users, err := s.getBotUsers(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bots := make([]*Bot, 0, len(users))
+	for _, user := range users {
+		bot, err := s.botFromUser(ctx, user)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		bots = append(bots, bot)
+	}
+	return bots, nil
+}
+
+// GetBot returns the certificate renewal bot with the given name.
+func (s *Server) GetBot(ctx context.Context, botName string) (*Bot, error) {
+	user, err := s.GetUser(BotResourceName(botName), false)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("bot %q not found", botName)
+		}
+		return nil, trace.Wrap(err)
+	}
+	bot, err := s.botFromUser(ctx, user)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bot, nil
+}
+
 // getBotUsers fetches all Users with the BotLabel field set. Users are fetched
 // without secrets.
 func (s *Server) getBotUsers(ctx context.Context) ([]types.User, error) {
@@ -0,0 +1,274 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// caBackupKeySize is the size of the symmetric key sealing the CA key
+// material in a backup.
+const caBackupKeySize = 32
+
+// CAKeyShare is one operator's sealed share of the key protecting a CA
+// backup. The share is encrypted to the operator's public key, only the
+// holder of the matching private key can open it.
+type CAKeyShare struct {
+	// Recipient is the hex SHA256 fingerprint of the operator public key
+	// the share is encrypted to.
+	Recipient string `json:"recipient"`
+	// Value is the RSA-OAEP encrypted Shamir share.
+	Value []byte `json:"value"`
+}
+
+// CAKeyBackup is a sealed export of a certificate authority's private key
+// material. The keys are encrypted with a random symmetric key which is in
+// turn split across the operator shares, so reconstructing the material
+// requires the cooperation of at least Threshold operators and raw keys are
+// never visible to one person.
+type CAKeyBackup struct {
+	// ClusterName is the cluster the authority belongs to.
+	ClusterName string `json:"cluster_name"`
+	// Type is the certificate authority type.
+	Type types.CertAuthType `json:"type"`
+	// Threshold is how many shares are needed to open the backup.
+	Threshold int `json:"threshold"`
+	// Nonce is the AES-GCM nonce sealing the key material.
+	Nonce []byte `json:"nonce"`
+	// EncryptedKeys is the sealed CA key material.
+	EncryptedKeys []byte `json:"encrypted_keys"`
+	// Shares are the operator shares of the sealing key.
+	Shares []CAKeyShare `json:"shares"`
+}
+
+// ExportCAKeyBackupRequest is a request to export a sealed CA key backup.
+type ExportCAKeyBackupRequest struct {
+	// Type is the certificate authority type to export.
+	Type types.CertAuthType `json:"type"`
+	// OperatorKeys are PEM-encoded RSA public keys of the operators the
+	// backup is escrowed to, one share each.
+	OperatorKeys [][]byte `json:"operator_keys"`
+	// Threshold is how many operators must cooperate to open the backup.
+	Threshold int `json:"threshold"`
+}
+
+// parseOperatorKey parses a PEM-encoded operator public key.
+func parseOperatorKey(keyPEM []byte) (*rsa.PublicKey, string, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, "", trace.BadParameter("operator key is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", trace.BadParameter("failed to parse operator key: %v", err)
+	}
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, "", trace.BadParameter("operator keys must be RSA public keys, got %T", pub)
+	}
+	fingerprint := sha256.Sum256(block.Bytes)
+	return rsaKey, hex.EncodeToString(fingerprint[:]), nil
+}
+
+// ExportCAKeyBackup exports the private key material of a certificate
+// authority sealed to a set of operator public keys with a threshold Shamir
+// split. The backup contains the key material as stored in the CA resource.
+func (a *Server) ExportCAKeyBackup(ctx context.Context, req ExportCAKeyBackupRequest) (*CAKeyBackup, error) {
+	if err := req.Type.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(req.OperatorKeys) < 2 {
+		return nil, trace.BadParameter("at least 2 operator keys are required for escrow")
+	}
+	if req.Threshold < 2 || req.Threshold > len(req.OperatorKeys) {
+		return nil, trace.BadParameter("the threshold must be between 2 and the number of operator keys %v, got %v", len(req.OperatorKeys), req.Threshold)
+	}
+
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ca, err := a.Services.GetCertAuthority(ctx, types.CertAuthID{
+		Type:       req.Type,
+		DomainName: clusterName.GetClusterName(),
+	}, true)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	keyMaterial, err := utils.FastMarshal(ca.GetActiveKeys())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	sealingKey := make([]byte, caBackupKeySize)
+	if _, err := rand.Read(sealingKey); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	aead, err := newBackupAEAD(sealingKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	backup := &CAKeyBackup{
+		ClusterName:   clusterName.GetClusterName(),
+		Type:          req.Type,
+		Threshold:     req.Threshold,
+		Nonce:         nonce,
+		EncryptedKeys: aead.Seal(nil, nonce, keyMaterial, nil),
+	}
+
+	shares, err := utils.SplitSecret(sealingKey, len(req.OperatorKeys), req.Threshold)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i, keyPEM := range req.OperatorKeys {
+		operatorKey, fingerprint, err := parseOperatorKey(keyPEM)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sealed, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, operatorKey, shares[i], nil)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		backup.Shares = append(backup.Shares, CAKeyShare{
+			Recipient: fingerprint,
+			Value:     sealed,
+		})
+	}
+
+	log.Infof("Exported sealed %v CA key backup escrowed to %v operators with threshold %v for user %v.",
+		req.Type, len(req.OperatorKeys), req.Threshold, ClientUsername(ctx))
+	return backup, nil
+}
+
+// ImportCAKeyBackupRequest is a request to restore CA key material from a
+// sealed backup.
+type ImportCAKeyBackupRequest struct {
+	// Backup is the sealed backup produced by ExportCAKeyBackup.
+	Backup CAKeyBackup `json:"backup"`
+	// Shares are the Shamir shares of the sealing key, each opened by its
+	// operator out of band. At least Backup.Threshold must be provided.
+	Shares [][]byte `json:"shares"`
+}
+
+// ImportCAKeyBackup restores the private key material of a certificate
+// authority from a sealed backup. The operators holding the escrow shares
+// decrypt them with their own private keys and submit the opened shares.
+func (a *Server) ImportCAKeyBackup(ctx context.Context, req ImportCAKeyBackupRequest) error {
+	if err := req.Backup.Type.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if len(req.Shares) < req.Backup.Threshold {
+		return trace.BadParameter("the backup requires %v shares to open, got %v", req.Backup.Threshold, len(req.Shares))
+	}
+
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if req.Backup.ClusterName != clusterName.GetClusterName() {
+		return trace.BadParameter("the backup belongs to cluster %q, this cluster is %q", req.Backup.ClusterName, clusterName.GetClusterName())
+	}
+
+	sealingKey, err := utils.CombineShares(req.Shares)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(sealingKey) != caBackupKeySize {
+		return trace.BadParameter("the combined shares do not form a valid sealing key")
+	}
+	aead, err := newBackupAEAD(sealingKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	keyMaterial, err := aead.Open(nil, req.Backup.Nonce, req.Backup.EncryptedKeys, nil)
+	if err != nil {
+		return trace.BadParameter("failed to open the backup, the shares are likely wrong or too few")
+	}
+	var keySet types.CAKeySet
+	if err := utils.FastUnmarshal(keyMaterial, &keySet); err != nil {
+		return trace.Wrap(err)
+	}
+
+	ca, err := a.Services.GetCertAuthority(ctx, types.CertAuthID{
+		Type:       req.Backup.Type,
+		DomainName: clusterName.GetClusterName(),
+	}, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := ca.SetActiveKeys(keySet); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.UpsertCertAuthority(ca); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Infof("Restored %v CA key material from a sealed backup for user %v.",
+		req.Backup.Type, ClientUsername(ctx))
+	return nil
+}
+
+// newBackupAEAD builds the AEAD sealing CA backups from the sealing key.
+func newBackupAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return aead, nil
+}
+
+// ExportCAKeyBackup exports a sealed CA key backup. As the backup contains
+// the (sealed) signing keys, it requires full read access to certificate
+// authorities.
+func (a *ServerWithRoles) ExportCAKeyBackup(ctx context.Context, req ExportCAKeyBackupRequest) (*CAKeyBackup, error) {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.ExportCAKeyBackup(ctx, req)
+}
+
+// ImportCAKeyBackup restores CA key material from a sealed backup.
+func (a *ServerWithRoles) ImportCAKeyBackup(ctx context.Context, req ImportCAKeyBackupRequest) error {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.ImportCAKeyBackup(ctx, req)
+}
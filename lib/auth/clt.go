@@ -327,117 +327,1561 @@ func (c *Client) RotateExternalCertAuthority(ctx context.Context, ca types.CertA
 	return trace.Wrap(err)
 }
 
-// UpsertCertAuthority updates or inserts new cert authority
-func (c *Client) UpsertCertAuthority(ca types.CertAuthority) error {
-	if err := services.ValidateCertAuthority(ca); err != nil {
-		return trace.Wrap(err)
+// RotateExternalCertAuthorities rotates all certificate authorities of a
+// remote cluster in a single call, so the auth server can validate and apply
+// them as a set.
+func (c *Client) RotateExternalCertAuthorities(ctx context.Context, cas []types.CertAuthority) error {
+	items := make([]json.RawMessage, 0, len(cas))
+	for _, ca := range cas {
+		if err := services.ValidateCertAuthority(ca); err != nil {
+			return trace.Wrap(err)
+		}
+		data, err := services.MarshalCertAuthority(ca)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		items = append(items, data)
 	}
-	data, err := services.MarshalCertAuthority(ca)
+	_, err := c.PostJSON(ctx, c.Endpoint("externalauthorities", "rotate"),
+		&rotateExternalCertAuthoritiesRawReq{CAs: items})
+	return trace.Wrap(err)
+}
+
+// ExportCAKeyBackup exports the key material of a certificate authority
+// sealed to a set of operator public keys with a threshold Shamir split.
+func (c *Client) ExportCAKeyBackup(ctx context.Context, req ExportCAKeyBackupRequest) (*CAKeyBackup, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("authorities", string(req.Type), "backup"), req)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, trace.Wrap(err)
 	}
-	_, err = c.PostJSON(context.TODO(), c.Endpoint("authorities", string(ca.GetType())),
-		&upsertCertAuthorityRawReq{CA: data})
+	var backup CAKeyBackup
+	if err := json.Unmarshal(out.Bytes(), &backup); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &backup, nil
+}
+
+// ImportCAKeyBackup restores the key material of a certificate authority
+// from a sealed backup and the opened operator shares.
+func (c *Client) ImportCAKeyBackup(ctx context.Context, req ImportCAKeyBackupRequest) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("authorities", string(req.Backup.Type), "restore"), req)
 	return trace.Wrap(err)
 }
 
-// CompareAndSwapCertAuthority updates existing cert authority if the existing cert authority
-// value matches the value stored in the backend.
-func (c *Client) CompareAndSwapCertAuthority(new, existing types.CertAuthority) error {
-	return trace.BadParameter("this function is not supported on the client")
+// GetCertIssuances returns the certificate issuance log entries for a user.
+func (c *Client) GetCertIssuances(ctx context.Context, username string) ([]CertIssuance, error) {
+	out, err := c.Get(ctx, c.Endpoint("certissuances", username), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var issuances []CertIssuance
+	if err := json.Unmarshal(out.Bytes(), &issuances); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return issuances, nil
 }
 
-// GetCertAuthorities returns a list of certificate authorities
-func (c *Client) GetCertAuthorities(ctx context.Context, caType types.CertAuthType, loadKeys bool, opts ...services.MarshalOption) ([]types.CertAuthority, error) {
-	if err := caType.Check(); err != nil {
+// GetLegacyMFAUsers returns the users who still have legacy U2F device
+// records.
+func (c *Client) GetLegacyMFAUsers(ctx context.Context) ([]LegacyMFAUser, error) {
+	out, err := c.Get(ctx, c.Endpoint("mfadevices", "legacyusers"), url.Values{})
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	out, err := c.Get(ctx, c.Endpoint("authorities", string(caType)), url.Values{
-		"load_keys": []string{fmt.Sprintf("%t", loadKeys)},
+	var users []LegacyMFAUser
+	if err := json.Unmarshal(out.Bytes(), &users); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return users, nil
+}
+
+// GetUserSessions enumerates the user's active web sessions, application
+// sessions and unexpired certificates.
+func (c *Client) GetUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	out, err := c.Get(ctx, c.Endpoint("users", username, "sessions"), url.Values{})
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	var sessions UserSessions
+	if err := json.Unmarshal(out.Bytes(), &sessions); err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	return sessions, nil
+}
+
+// GetWebSessionsMetadata returns the client metadata recorded for all of
+// the user's web sessions: IP, user agent and login method.
+func (c *Client) GetWebSessionsMetadata(ctx context.Context, username string) ([]WebSessionMetadata, error) {
+	out, err := c.Get(ctx, c.Endpoint("users", username, "sessions", "metadata"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var records []WebSessionMetadata
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return records, nil
+}
+
+// RevokeUserSessions revokes all of the user's sessions and unexpired
+// certificates in one sweep and returns what was revoked.
+func (c *Client) RevokeUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	out, err := c.Delete(ctx, c.Endpoint("users", username, "sessions"))
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	var revoked UserSessions
+	if err := json.Unmarshal(out.Bytes(), &revoked); err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	return revoked, nil
+}
+
+// AckLockPropagation records that the calling agent has committed a lock to
+// its local view.
+func (c *Client) AckLockPropagation(ctx context.Context, lockName string) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("lockpropagation", "acks"), ackLockPropagationReq{
+		LockName: lockName,
 	})
+	return trace.Wrap(err)
+}
+
+// GetLockPropagationStatus returns the per-agent acknowledgments received for
+// a lock.
+func (c *Client) GetLockPropagationStatus(ctx context.Context, lockName string) (*LockPropagationStatus, error) {
+	out, err := c.Get(ctx, c.Endpoint("lockpropagation", lockName), url.Values{})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	var items []json.RawMessage
-	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
-		return nil, err
+	var status LockPropagationStatus
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+		return nil, trace.Wrap(err)
 	}
-	re := make([]types.CertAuthority, len(items))
-	for i, raw := range items {
-		ca, err := services.UnmarshalCertAuthority(raw)
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		re[i] = ca
+	return &status, nil
+}
+
+// GetClusterCACertPins returns the SPKI pins of the cluster host CA,
+// including pending pins of an ongoing rotation.
+func (c *Client) GetClusterCACertPins(ctx context.Context) ([]CAPin, error) {
+	out, err := c.Get(ctx, c.Endpoint("cacertpins"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
-	return re, nil
+	var pins []CAPin
+	if err := json.Unmarshal(out.Bytes(), &pins); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return pins, nil
 }
 
-// GetCertAuthority returns certificate authority by given id. Parameter loadSigningKeys
-// controls if signing keys are loaded
-func (c *Client) GetCertAuthority(ctx context.Context, id types.CertAuthID, loadSigningKeys bool, opts ...services.MarshalOption) (types.CertAuthority, error) {
-	if err := id.Check(); err != nil {
+// CreateScopedAPICerts mints a scope-restricted TLS certificate derived from
+// the caller's own session, see ScopedCertsRequest.
+func (c *Client) CreateScopedAPICerts(ctx context.Context, req ScopedCertsRequest) (*ScopedAPICerts, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("scopedcerts"), req)
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	out, err := c.Get(ctx, c.Endpoint("authorities", string(id.Type), id.DomainName), url.Values{
-		"load_keys": []string{fmt.Sprintf("%t", loadSigningKeys)},
+	var certs ScopedAPICerts
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &certs, nil
+}
+
+// previewTrustedClusterRawReq is a request to preview a trusted cluster
+// relationship.
+type previewTrustedClusterRawReq struct {
+	// TrustedCluster is the marshaled trusted cluster resource.
+	TrustedCluster json.RawMessage `json:"trusted_cluster"`
+}
+
+// PreviewTrustedCluster reports which remote roles would map to which local
+// roles under the proposed trusted cluster, without committing it.
+func (c *Client) PreviewTrustedCluster(ctx context.Context, tc types.TrustedCluster) (*TrustedClusterPreview, error) {
+	data, err := services.MarshalTrustedCluster(tc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("trustedclusters", "preview"), &previewTrustedClusterRawReq{
+		TrustedCluster: data,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var preview TrustedClusterPreview
+	if err := json.Unmarshal(out.Bytes(), &preview); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &preview, nil
+}
+
+// GenerateUserConfigBundle renders a kubeconfig and an ssh_config snippet
+// covering the resources the calling user can access.
+func (c *Client) GenerateUserConfigBundle(ctx context.Context) (*UserConfigBundle, error) {
+	out, err := c.Get(ctx, c.Endpoint("configbundle"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bundle UserConfigBundle
+	if err := json.Unmarshal(out.Bytes(), &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// GenerateWorkloadIdentityToken mints an OIDC ID token for the calling
+// identity, to be exchanged for cloud credentials via identity federation.
+func (c *Client) GenerateWorkloadIdentityToken(ctx context.Context, req WorkloadIdentityTokenRequest) (*WorkloadIdentityTokenResponse, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("workloadidentity", "token"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var resp WorkloadIdentityTokenResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &resp, nil
+}
+
+// UpsertAccessBundle creates or updates an access bundle.
+func (c *Client) UpsertAccessBundle(ctx context.Context, bundle AccessBundle) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("accessbundles"), bundle)
+	return trace.Wrap(err)
+}
+
+// GetAccessBundle returns the access bundle with the given name.
+func (c *Client) GetAccessBundle(ctx context.Context, name string) (*AccessBundle, error) {
+	out, err := c.Get(ctx, c.Endpoint("accessbundles", name), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bundle AccessBundle
+	if err := json.Unmarshal(out.Bytes(), &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// GetAccessBundles returns all defined access bundles.
+func (c *Client) GetAccessBundles(ctx context.Context) ([]AccessBundle, error) {
+	out, err := c.Get(ctx, c.Endpoint("accessbundles"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bundles []AccessBundle
+	if err := json.Unmarshal(out.Bytes(), &bundles); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bundles, nil
+}
+
+// DeleteAccessBundle removes the access bundle with the given name.
+func (c *Client) DeleteAccessBundle(ctx context.Context, name string) error {
+	_, err := c.Delete(ctx, c.Endpoint("accessbundles", name))
+	return trace.Wrap(err)
+}
+
+// CreateAccessRequestFromBundle files a pending access request for the
+// calling user covering everything in the named bundle.
+func (c *Client) CreateAccessRequestFromBundle(ctx context.Context, bundleName string) (*AccessBundleRequestSummary, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("accessbundles", bundleName, "request"), struct{}{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var summary AccessBundleRequestSummary
+	if err := json.Unmarshal(out.Bytes(), &summary); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &summary, nil
+}
+
+// CreateScheduledAccessRequest files an access request that only becomes
+// assumable at the given future start time.
+func (c *Client) CreateScheduledAccessRequest(ctx context.Context, req types.AccessRequest, start time.Time) error {
+	data, err := services.MarshalAccessRequest(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(ctx, c.Endpoint("accessrequests", "scheduled"), createScheduledAccessRequestReq{
+		Request: data,
+		Start:   start,
+	})
+	return trace.Wrap(err)
+}
+
+// UpsertAccessList creates or updates an access list.
+func (c *Client) UpsertAccessList(ctx context.Context, list AccessList) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("accesslists"), list)
+	return trace.Wrap(err)
+}
+
+// GetAccessList returns the access list with the given name.
+func (c *Client) GetAccessList(ctx context.Context, name string) (*AccessList, error) {
+	out, err := c.Get(ctx, c.Endpoint("accesslists", name), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var list AccessList
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &list, nil
+}
+
+// GetAccessLists returns all defined access lists.
+func (c *Client) GetAccessLists(ctx context.Context) ([]AccessList, error) {
+	out, err := c.Get(ctx, c.Endpoint("accesslists"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var lists []AccessList
+	if err := json.Unmarshal(out.Bytes(), &lists); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return lists, nil
+}
+
+// DeleteAccessList removes the access list with the given name.
+func (c *Client) DeleteAccessList(ctx context.Context, name string) error {
+	_, err := c.Delete(ctx, c.Endpoint("accesslists", name))
+	return trace.Wrap(err)
+}
+
+// ReviewAccessList records a membership attestation, restarting the review
+// clock of the list.
+func (c *Client) ReviewAccessList(ctx context.Context, name string) (*AccessList, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("accesslists", name, "review"), struct{}{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var list AccessList
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &list, nil
+}
+
+// PreviewAccessRequest reports what submitting the prospective access
+// request would do, without creating it.
+func (c *Client) PreviewAccessRequest(ctx context.Context, req types.AccessRequest) (*AccessRequestPreview, error) {
+	data, err := services.MarshalAccessRequest(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("accessrequests", "preview"), previewAccessRequestReq{Request: data})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var preview AccessRequestPreview
+	if err := json.Unmarshal(out.Bytes(), &preview); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &preview, nil
+}
+
+// UpsertAccessAutoApprovalRule creates or updates an auto-approval rule.
+func (c *Client) UpsertAccessAutoApprovalRule(ctx context.Context, rule AccessAutoApprovalRule) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("autoapprovalrules"), rule)
+	return trace.Wrap(err)
+}
+
+// GetAccessAutoApprovalRules returns all defined auto-approval rules.
+func (c *Client) GetAccessAutoApprovalRules(ctx context.Context) ([]AccessAutoApprovalRule, error) {
+	out, err := c.Get(ctx, c.Endpoint("autoapprovalrules"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var rules []AccessAutoApprovalRule
+	if err := json.Unmarshal(out.Bytes(), &rules); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rules, nil
+}
+
+// DeleteAccessAutoApprovalRule removes the auto-approval rule with the
+// given name.
+func (c *Client) DeleteAccessAutoApprovalRule(ctx context.Context, name string) error {
+	_, err := c.Delete(ctx, c.Endpoint("autoapprovalrules", name))
+	return trace.Wrap(err)
+}
+
+// CreateAccessRequestWithMaxDuration files an access request whose elevated
+// access expires the given duration after approval, regardless of the
+// request expiry.
+func (c *Client) CreateAccessRequestWithMaxDuration(ctx context.Context, req types.AccessRequest, maxDuration time.Duration) error {
+	data, err := services.MarshalAccessRequest(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(ctx, c.Endpoint("accessrequests", "duration"), createAccessRequestWithMaxDurationReq{
+		Request:     data,
+		MaxDuration: maxDuration,
 	})
+	return trace.Wrap(err)
+}
+
+// SetAccessRequestDurationPolicy updates the cluster access request duration
+// policy.
+func (c *Client) SetAccessRequestDurationPolicy(ctx context.Context, policy AccessRequestDurationPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("accessrequestdurationpolicy"), policy)
+	return trace.Wrap(err)
+}
+
+// GetAccessRequestDurationPolicy returns the cluster access request duration
+// policy.
+func (c *Client) GetAccessRequestDurationPolicy(ctx context.Context) (AccessRequestDurationPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("accessrequestdurationpolicy"), url.Values{})
+	if err != nil {
+		return AccessRequestDurationPolicy{}, trace.Wrap(err)
+	}
+	var policy AccessRequestDurationPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return AccessRequestDurationPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteAccessRequestDurationPolicy removes the cluster access request
+// duration policy.
+func (c *Client) DeleteAccessRequestDurationPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("accessrequestdurationpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetAccessEscalationPolicy updates the cluster access escalation policy.
+func (c *Client) SetAccessEscalationPolicy(ctx context.Context, policy AccessEscalationPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("accessescalation"), policy)
+	return trace.Wrap(err)
+}
+
+// GetAccessEscalationPolicy returns the cluster access escalation policy.
+func (c *Client) GetAccessEscalationPolicy(ctx context.Context) (*AccessEscalationPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("accessescalation"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var policy AccessEscalationPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// SetHostPrincipalPolicy updates the cluster host principal policy.
+func (c *Client) SetHostPrincipalPolicy(ctx context.Context, policy HostPrincipalPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("hostprincipalpolicy"), policy)
+	return trace.Wrap(err)
+}
+
+// GetHostPrincipalPolicy returns the cluster host principal policy.
+func (c *Client) GetHostPrincipalPolicy(ctx context.Context) (*HostPrincipalPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("hostprincipalpolicy"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var policy HostPrincipalPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// SetDataResidencyPolicy updates the cluster data residency policy.
+func (c *Client) SetDataResidencyPolicy(ctx context.Context, policy DataResidencyPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("dataresidency", "policy"), policy)
+	return trace.Wrap(err)
+}
+
+// GetDataResidencyPolicy returns the cluster data residency policy.
+func (c *Client) GetDataResidencyPolicy(ctx context.Context) (*DataResidencyPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("dataresidency", "policy"), url.Values{})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return services.UnmarshalCertAuthority(out.Bytes())
+	var policy DataResidencyPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// GetDataResidencyStatus reports compliance information for the data
+// residency policy.
+func (c *Client) GetDataResidencyStatus(ctx context.Context) (*DataResidencyStatus, error) {
+	out, err := c.Get(ctx, c.Endpoint("dataresidency", "status"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var status DataResidencyStatus
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &status, nil
+}
+
+// GetBots returns all certificate renewal bots registered in the cluster.
+func (c *Client) GetBots(ctx context.Context) ([]*Bot, error) {
+	out, err := c.Get(ctx, c.Endpoint("bots"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bots []*Bot
+	if err := json.Unmarshal(out.Bytes(), &bots); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bots, nil
+}
+
+// GetBot returns the certificate renewal bot with the given name.
+func (c *Client) GetBot(ctx context.Context, botName string) (*Bot, error) {
+	out, err := c.Get(ctx, c.Endpoint("bots", botName), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bot Bot
+	if err := json.Unmarshal(out.Bytes(), &bot); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bot, nil
+}
+
+// upsertOutputTemplateReq is a request to create or update an output
+// template.
+type upsertOutputTemplateReq struct {
+	Template OutputTemplate `json:"template"`
+}
+
+// UpsertOutputTemplate creates or updates an output template.
+func (c *Client) UpsertOutputTemplate(ctx context.Context, tmpl OutputTemplate) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("outputtemplates"), upsertOutputTemplateReq{Template: tmpl})
+	return trace.Wrap(err)
+}
+
+// GetOutputTemplates returns all stored output templates.
+func (c *Client) GetOutputTemplates(ctx context.Context) ([]OutputTemplate, error) {
+	out, err := c.Get(ctx, c.Endpoint("outputtemplates"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var templates []OutputTemplate
+	if err := json.Unmarshal(out.Bytes(), &templates); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return templates, nil
+}
+
+// DeleteOutputTemplate removes an output template.
+func (c *Client) DeleteOutputTemplate(ctx context.Context, name string) error {
+	_, err := c.Delete(ctx, c.Endpoint("outputtemplates", name))
+	return trace.Wrap(err)
+}
+
+// RenderOutputTemplates renders all stored output templates for the calling
+// identity.
+func (c *Client) RenderOutputTemplates(ctx context.Context) ([]RenderedOutput, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("outputtemplates", "render"), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var outputs []RenderedOutput
+	if err := json.Unmarshal(out.Bytes(), &outputs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return outputs, nil
+}
+
+// GetCertKeyPolicy returns the cluster-level subject key policy.
+func (c *Client) GetCertKeyPolicy(ctx context.Context) (*CertKeyPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("certkeypolicy"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var policy CertKeyPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// SetCertKeyPolicy updates the cluster-level subject key policy.
+func (c *Client) SetCertKeyPolicy(ctx context.Context, policy CertKeyPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("certkeypolicy"), policy)
+	return trace.Wrap(err)
+}
+
+// GenerateSubjectKeyPair generates a policy-compliant keypair on the server
+// for clients that cannot or do not want to generate their own.
+func (c *Client) GenerateSubjectKeyPair(ctx context.Context) (*SubjectKeyPair, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("subjectkeypair"), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var keyPair SubjectKeyPair
+	if err := json.Unmarshal(out.Bytes(), &keyPair); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &keyPair, nil
+}
+
+// RunPreflightChecks validates the health of the auth server's dependencies
+// and reports the results.
+func (c *Client) RunPreflightChecks(ctx context.Context) (*PreflightReport, error) {
+	out, err := c.Get(ctx, c.Endpoint("preflight"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var report PreflightReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &report, nil
+}
+
+// RotateBackendEncryptionKey rotates the envelope key used to seal sensitive
+// values stored in the backend.
+func (c *Client) RotateBackendEncryptionKey(ctx context.Context) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("backendencryption", "rotate"), nil)
+	return trace.Wrap(err)
+}
+
+// attestedCertsReq is a request to generate user certificates backed by a
+// hardware key attestation statement.
+type attestedCertsReq struct {
+	// CertsReq is the underlying user certificates request.
+	CertsReq proto.UserCertsRequest `json:"certs_req"`
+	// Attestation attests that the requested public key resides on a
+	// hardware token.
+	Attestation *AttestationStatement `json:"attestation"`
+}
+
+// GenerateUserCertsWithAttestation generates user certificates for a key that
+// is attested to reside on a hardware token, embedding the verified private
+// key policy in the issued certificates.
+func (c *Client) GenerateUserCertsWithAttestation(ctx context.Context, req proto.UserCertsRequest, attestation *AttestationStatement) (*proto.Certs, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("attestedcerts"), attestedCertsReq{
+		CertsReq:    req,
+		Attestation: attestation,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &certs, nil
+}
+
+// UpsertSPIFFEFederation imports or updates the bundle of an external SPIFFE
+// trust domain.
+func (c *Client) UpsertSPIFFEFederation(ctx context.Context, federation SPIFFEFederation) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("spiffe", "federations"), upsertSPIFFEFederationReq{
+		Federation: federation,
+	})
+	return trace.Wrap(err)
+}
+
+// GetSPIFFEFederations returns all imported external SPIFFE trust domains.
+func (c *Client) GetSPIFFEFederations(ctx context.Context) ([]SPIFFEFederation, error) {
+	out, err := c.Get(ctx, c.Endpoint("spiffe", "federations"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var federations []SPIFFEFederation
+	if err := json.Unmarshal(out.Bytes(), &federations); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return federations, nil
+}
+
+// DeleteSPIFFEFederation removes the bundle of an external SPIFFE trust
+// domain.
+func (c *Client) DeleteSPIFFEFederation(ctx context.Context, trustDomain string) error {
+	_, err := c.Delete(ctx, c.Endpoint("spiffe", "federations", trustDomain))
+	return trace.Wrap(err)
+}
+
+// UpsertCertAuthority updates or inserts new cert authority
+func (c *Client) UpsertCertAuthority(ca types.CertAuthority) error {
+	if err := services.ValidateCertAuthority(ca); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := services.MarshalCertAuthority(ca)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(context.TODO(), c.Endpoint("authorities", string(ca.GetType())),
+		&upsertCertAuthorityRawReq{CA: data})
+	return trace.Wrap(err)
+}
+
+// CompareAndSwapCertAuthority updates existing cert authority if the existing cert authority
+// value matches the value stored in the backend.
+func (c *Client) CompareAndSwapCertAuthority(new, existing types.CertAuthority) error {
+	return trace.BadParameter("this function is not supported on the client")
+}
+
+// GetCertAuthorities returns a list of certificate authorities
+func (c *Client) GetCertAuthorities(ctx context.Context, caType types.CertAuthType, loadKeys bool, opts ...services.MarshalOption) ([]types.CertAuthority, error) {
+	if err := caType.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.Get(ctx, c.Endpoint("authorities", string(caType)), url.Values{
+		"load_keys": []string{fmt.Sprintf("%t", loadKeys)},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, err
+	}
+	re := make([]types.CertAuthority, len(items))
+	for i, raw := range items {
+		ca, err := services.UnmarshalCertAuthority(raw)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		re[i] = ca
+	}
+	return re, nil
+}
+
+// GetCertAuthority returns certificate authority by given id. Parameter loadSigningKeys
+// controls if signing keys are loaded
+func (c *Client) GetCertAuthority(ctx context.Context, id types.CertAuthID, loadSigningKeys bool, opts ...services.MarshalOption) (types.CertAuthority, error) {
+	if err := id.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.Get(ctx, c.Endpoint("authorities", string(id.Type), id.DomainName), url.Values{
+		"load_keys": []string{fmt.Sprintf("%t", loadSigningKeys)},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return services.UnmarshalCertAuthority(out.Bytes())
+}
+
+// DeleteCertAuthority deletes cert authority by ID
+func (c *Client) DeleteCertAuthority(id types.CertAuthID) error {
+	if err := id.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	_, err := c.Delete(context.TODO(), c.Endpoint("authorities", string(id.Type), id.DomainName))
+	return trace.Wrap(err)
+}
+
+// ActivateCertAuthority not implemented: can only be called locally.
+func (c *Client) ActivateCertAuthority(id types.CertAuthID) error {
+	return trace.NotImplemented(notImplementedMessage)
+}
+
+// DeactivateCertAuthority not implemented: can only be called locally.
+func (c *Client) DeactivateCertAuthority(id types.CertAuthID) error {
+	return trace.NotImplemented(notImplementedMessage)
+}
+
+// ListTokens returns a page of provision tokens matching the request
+// filters.
+func (c *Client) ListTokens(ctx context.Context, req ListTokensRequest) (ListTokensResponse, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "list"), req)
+	if err != nil {
+		return ListTokensResponse{}, trace.Wrap(err)
+	}
+	var raw listTokensRawResponse
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		return ListTokensResponse{}, trace.Wrap(err)
+	}
+	resp := ListTokensResponse{NextKey: raw.NextKey}
+	for _, data := range raw.Tokens {
+		token, err := services.UnmarshalProvisionToken(data)
+		if err != nil {
+			return ListTokensResponse{}, trace.Wrap(err)
+		}
+		resp.Tokens = append(resp.Tokens, token)
+	}
+	return resp, nil
+}
+
+// GenerateTokens mints a batch of provision tokens sharing the same spec.
+func (c *Client) GenerateTokens(ctx context.Context, req GenerateTokensRequest) (GenerateTokensResponse, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "bulk"), req)
+	if err != nil {
+		return GenerateTokensResponse{}, trace.Wrap(err)
+	}
+	var resp GenerateTokensResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return GenerateTokensResponse{}, trace.Wrap(err)
+	}
+	return resp, nil
+}
+
+// GenerateTokenBundle generates a provision token together with the cluster
+// coordinates and rendered commands a node needs to join.
+func (c *Client) GenerateTokenBundle(ctx context.Context, req *proto.GenerateTokenRequest) (*TokenBundle, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "bundle"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bundle TokenBundle
+	if err := json.Unmarshal(out.Bytes(), &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// RegisterUsingToken calls the auth service API to register a new node using a registration token
+// which was previously issued via GenerateToken.
+func (c *Client) RegisterUsingToken(ctx context.Context, req *types.RegisterUsingTokenRequest) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// registerUsingGCPReq is a request to register using the GCP join method.
+type registerUsingGCPReq struct {
+	// RegisterReq is the regular register request.
+	RegisterReq *types.RegisterUsingTokenRequest `json:"register_request"`
+	// IDToken is a GCP-signed instance identity token.
+	IDToken string `json:"id_token"`
+}
+
+// RegisterUsingGCPMethod calls the auth service API to register a new node
+// using a GCP-signed instance identity token.
+func (c *Client) RegisterUsingGCPMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register", "gcp"), registerUsingGCPReq{
+		RegisterReq: req,
+		IDToken:     idToken,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// registerUsingKubernetesReq is a request to register using the Kubernetes
+// join method.
+type registerUsingKubernetesReq struct {
+	// RegisterReq is the regular register request.
+	RegisterReq *types.RegisterUsingTokenRequest `json:"register_request"`
+	// IDToken is a projected Kubernetes service account token.
+	IDToken string `json:"id_token"`
+}
+
+// RegisterUsingKubernetesMethod calls the auth service API to register a new
+// node using a projected Kubernetes service account token.
+func (c *Client) RegisterUsingKubernetesMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register", "kubernetes"), registerUsingKubernetesReq{
+		RegisterReq: req,
+		IDToken:     idToken,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// registerUsingGitLabReq is a request to register using the GitLab join
+// method.
+type registerUsingGitLabReq struct {
+	// RegisterReq is the regular register request.
+	RegisterReq *types.RegisterUsingTokenRequest `json:"register_request"`
+	// IDToken is a GitLab CI ID token.
+	IDToken string `json:"id_token"`
+}
+
+// RegisterUsingGitLabMethod calls the auth service API to register a new
+// node using a GitLab CI ID token.
+func (c *Client) RegisterUsingGitLabMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register", "gitlab"), registerUsingGitLabReq{
+		RegisterReq: req,
+		IDToken:     idToken,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// registerUsingCircleCIReq is a request to register using the CircleCI join
+// method.
+type registerUsingCircleCIReq struct {
+	// RegisterReq is the regular register request.
+	RegisterReq *types.RegisterUsingTokenRequest `json:"register_request"`
+	// IDToken is a CircleCI OIDC token.
+	IDToken string `json:"id_token"`
+}
+
+// RegisterUsingCircleCIMethod calls the auth service API to register a new
+// node using a CircleCI OIDC token.
+func (c *Client) RegisterUsingCircleCIMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register", "circleci"), registerUsingCircleCIReq{
+		RegisterReq: req,
+		IDToken:     idToken,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// registerUsingSPIFFEReq is a request to register using the SPIFFE join
+// method.
+type registerUsingSPIFFEReq struct {
+	// RegisterReq is the regular register request.
+	RegisterReq *types.RegisterUsingTokenRequest `json:"register_request"`
+	// SVIDChainPEM is the PEM-encoded X.509 SVID certificate chain.
+	SVIDChainPEM []byte `json:"svid_chain_pem"`
+	// Signature is a signature made with the SVID key over the submitted
+	// public keys, proving possession of the SVID.
+	Signature []byte `json:"signature"`
+}
+
+// RegisterUsingSPIFFEMethod calls the auth service API to register a new
+// node using an X.509 SVID from a trusted SPIFFE trust domain.
+func (c *Client) RegisterUsingSPIFFEMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, svidChainPEM, signature []byte) (*proto.Certs, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register", "spiffe"), registerUsingSPIFFEReq{
+		RegisterReq:  req,
+		SVIDChainPEM: svidChainPEM,
+		Signature:    signature,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var certs proto.Certs
+	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &certs, nil
+}
+
+// UpsertSPIFFETrustBundle creates or updates the trust bundle of a SPIFFE
+// trust domain.
+func (c *Client) UpsertSPIFFETrustBundle(ctx context.Context, bundle SPIFFETrustBundle) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("spiffebundles"), bundle)
+	return trace.Wrap(err)
+}
+
+// GetSPIFFETrustBundle returns the trust bundle of the given SPIFFE trust
+// domain.
+func (c *Client) GetSPIFFETrustBundle(ctx context.Context, trustDomain string) (*SPIFFETrustBundle, error) {
+	out, err := c.Get(ctx, c.Endpoint("spiffebundles", trustDomain), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var bundle SPIFFETrustBundle
+	if err := json.Unmarshal(out.Bytes(), &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// DeleteSPIFFETrustBundle removes the trust bundle of the given SPIFFE
+// trust domain.
+func (c *Client) DeleteSPIFFETrustBundle(ctx context.Context, trustDomain string) error {
+	_, err := c.Delete(ctx, c.Endpoint("spiffebundles", trustDomain))
+	return trace.Wrap(err)
+}
+
+// DELETE IN: 5.1.0
+//
+// This logic has been moved to KeepAliveServer.
+//
+// KeepAliveNode updates node keep alive information.
+func (c *Client) KeepAliveNode(ctx context.Context, keepAlive types.KeepAlive) error {
+	return trace.BadParameter("not implemented, use StreamKeepAlives instead")
+}
+
+// KeepAliveServer not implemented: can only be called locally.
+func (c *Client) KeepAliveServer(ctx context.Context, keepAlive types.KeepAlive) error {
+	return trace.BadParameter("not implemented, use StreamKeepAlives instead")
+}
+
+// GetNodesAt returns the nodes that were present in the namespace at the
+// given time, reconstructed from the compacted presence history.
+func (c *Client) GetNodesAt(ctx context.Context, namespace string, at time.Time) ([]NodePresenceRecord, error) {
+	out, err := c.Get(ctx, c.Endpoint("namespaces", namespace, "nodehistory"), url.Values{
+		"time": []string{at.UTC().Format(time.RFC3339)},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var nodes []NodePresenceRecord
+	if err := json.Unmarshal(out.Bytes(), &nodes); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nodes, nil
+}
+
+// SetResourceAnnotations replaces the annotations attached to the resource
+// with the given kind and name. An empty map removes all annotations.
+func (c *Client) SetResourceAnnotations(ctx context.Context, kind, name string, annotations map[string]string) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("annotations", kind, name), setResourceAnnotationsReq{
+		Annotations: annotations,
+	})
+	return trace.Wrap(err)
+}
+
+// GetResourceAnnotations returns the annotations attached to the resource
+// with the given kind and name.
+func (c *Client) GetResourceAnnotations(ctx context.Context, kind, name string) (map[string]string, error) {
+	out, err := c.Get(ctx, c.Endpoint("annotations", kind, name), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	annotations := map[string]string{}
+	if err := json.Unmarshal(out.Bytes(), &annotations); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return annotations, nil
+}
+
+// CreateSessionJoinURL generates a short-lived URL that lets its visitors
+// attach to an active session.
+func (c *Client) CreateSessionJoinURL(ctx context.Context, req CreateSessionJoinURLRequest) (*SessionJoinURL, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("sessionjoinurls"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var joinURL SessionJoinURL
+	if err := json.Unmarshal(out.Bytes(), &joinURL); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &joinURL, nil
+}
+
+// RedeemSessionJoinURL resolves a session join token into the authorization
+// it grants.
+func (c *Client) RedeemSessionJoinURL(ctx context.Context, token string) (*SessionJoinAuthorization, error) {
+	out, err := c.Get(ctx, c.Endpoint("sessionjoinurls", token), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var authorization SessionJoinAuthorization
+	if err := json.Unmarshal(out.Bytes(), &authorization); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &authorization, nil
+}
+
+// GetAccessRequestInsights returns the aggregated access request report.
+func (c *Client) GetAccessRequestInsights(ctx context.Context) (*AccessRequestInsights, error) {
+	out, err := c.Get(ctx, c.Endpoint("accessrequestinsights"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var insights AccessRequestInsights
+	if err := json.Unmarshal(out.Bytes(), &insights); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &insights, nil
+}
+
+// GetAgentInventory returns every agent known to the cluster with its
+// version, services and last heartbeat.
+func (c *Client) GetAgentInventory(ctx context.Context) (*AgentInventory, error) {
+	out, err := c.Get(ctx, c.Endpoint("agentinventory"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var inventory AgentInventory
+	if err := json.Unmarshal(out.Bytes(), &inventory); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &inventory, nil
+}
+
+// RenameMFADevice renames an MFA device of the user who owns the privilege
+// token in the request.
+func (c *Client) RenameMFADevice(ctx context.Context, req RenameMFADeviceRequest) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("mfadevices", "rename"), renameMFADeviceReq{Request: req})
+	return trace.Wrap(err)
+}
+
+// GetMFADevicesByUser returns the registered MFA devices of the given user
+// without secrets.
+func (c *Client) GetMFADevicesByUser(ctx context.Context, user string) ([]MFADeviceInfo, error) {
+	out, err := c.Get(ctx, c.Endpoint("users", user, "mfadevices"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var devices []MFADeviceInfo
+	if err := json.Unmarshal(out.Bytes(), &devices); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return devices, nil
+}
+
+// GetRecoveryCodesSummary returns how many recovery codes the user has left.
+func (c *Client) GetRecoveryCodesSummary(ctx context.Context, user string) (*RecoveryCodesSummary, error) {
+	out, err := c.Get(ctx, c.Endpoint("users", user, "recoverycodes", "summary"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var summary RecoveryCodesSummary
+	if err := json.Unmarshal(out.Bytes(), &summary); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &summary, nil
+}
+
+// UpsertCertTTLClampPolicy creates or updates a cert TTL clamp policy.
+func (c *Client) UpsertCertTTLClampPolicy(ctx context.Context, policy CertTTLClampPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("certttlclamppolicies"), upsertCertTTLClampPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetCertTTLClampPolicies returns all cert TTL clamp policies.
+func (c *Client) GetCertTTLClampPolicies(ctx context.Context) ([]CertTTLClampPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("certttlclamppolicies"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var policies []CertTTLClampPolicy
+	if err := json.Unmarshal(out.Bytes(), &policies); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return policies, nil
+}
+
+// DeleteCertTTLClampPolicy deletes a cert TTL clamp policy by name.
+func (c *Client) DeleteCertTTLClampPolicy(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.Delete(ctx, c.Endpoint("certttlclamppolicies", name))
+	return trace.Wrap(err)
+}
+
+// SetTOTPPolicy configures the TOTP parameters of the cluster.
+func (c *Client) SetTOTPPolicy(ctx context.Context, policy TOTPPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("totppolicy"), setTOTPPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetTOTPPolicy returns the configured TOTP parameters, falling back to the
+// defaults when none were set.
+func (c *Client) GetTOTPPolicy(ctx context.Context) (TOTPPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("totppolicy"), url.Values{})
+	if err != nil {
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	var policy TOTPPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteTOTPPolicy reverts the cluster to the default TOTP parameters.
+func (c *Client) DeleteTOTPPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("totppolicy"))
+	return trace.Wrap(err)
+}
+
+// SetPasswordHashPolicy configures the password hashing method of the
+// cluster.
+func (c *Client) SetPasswordHashPolicy(ctx context.Context, policy PasswordHashPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("passwordhashpolicy"), setPasswordHashPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetPasswordHashPolicy returns the configured password hashing method,
+// falling back to bcrypt when none was set.
+func (c *Client) GetPasswordHashPolicy(ctx context.Context) (PasswordHashPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("passwordhashpolicy"), url.Values{})
+	if err != nil {
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	var policy PasswordHashPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeletePasswordHashPolicy reverts the cluster to bcrypt password storage.
+func (c *Client) DeletePasswordHashPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("passwordhashpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetPasswordPolicy configures the password policy of the cluster.
+func (c *Client) SetPasswordPolicy(ctx context.Context, policy PasswordPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("passwordpolicy"), setPasswordPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetPasswordPolicy returns the configured password policy, falling back to
+// the built-in checks when none was set.
+func (c *Client) GetPasswordPolicy(ctx context.Context) (PasswordPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("passwordpolicy"), url.Values{})
+	if err != nil {
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	var policy PasswordPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeletePasswordPolicy reverts the cluster to the built-in password checks.
+func (c *Client) DeletePasswordPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("passwordpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetLockoutPolicy configures the lockout policy of the cluster.
+func (c *Client) SetLockoutPolicy(ctx context.Context, policy LockoutPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("lockoutpolicy"), setLockoutPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetLockoutPolicy returns the configured lockout policy, falling back to the
+// built-in limits when none was set.
+func (c *Client) GetLockoutPolicy(ctx context.Context) (LockoutPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("lockoutpolicy"), url.Values{})
+	if err != nil {
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	var policy LockoutPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteLockoutPolicy reverts the cluster to the built-in lockout limits.
+func (c *Client) DeleteLockoutPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("lockoutpolicy"))
+	return trace.Wrap(err)
+}
+
+// EnrollDevice binds a device key to a user and returns the stored device
+// record with its generated ID.
+func (c *Client) EnrollDevice(ctx context.Context, req EnrollDeviceRequest) (*TrustedDevice, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("trusteddevices"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var device TrustedDevice
+	if err := json.Unmarshal(out.Bytes(), &device); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &device, nil
+}
+
+// GetTrustedDevice returns the enrolled device with the given ID.
+func (c *Client) GetTrustedDevice(ctx context.Context, id string) (*TrustedDevice, error) {
+	out, err := c.Get(ctx, c.Endpoint("trusteddevices", id), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var device TrustedDevice
+	if err := json.Unmarshal(out.Bytes(), &device); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &device, nil
+}
+
+// GetTrustedDevices returns the device inventory of the cluster.
+func (c *Client) GetTrustedDevices(ctx context.Context) ([]TrustedDevice, error) {
+	out, err := c.Get(ctx, c.Endpoint("trusteddevices"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var devices []TrustedDevice
+	if err := json.Unmarshal(out.Bytes(), &devices); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return devices, nil
+}
+
+// DeleteTrustedDevice removes the enrolled device with the given ID.
+func (c *Client) DeleteTrustedDevice(ctx context.Context, id string) error {
+	_, err := c.Delete(ctx, c.Endpoint("trusteddevices", id))
+	return trace.Wrap(err)
+}
+
+// SetDeviceTrustPolicy configures the device trust mode of the cluster.
+func (c *Client) SetDeviceTrustPolicy(ctx context.Context, policy DeviceTrustPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("devicetrustpolicy"), setDeviceTrustPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetDeviceTrustPolicy returns the configured device trust mode, falling
+// back to "off" when none was set.
+func (c *Client) GetDeviceTrustPolicy(ctx context.Context) (DeviceTrustPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("devicetrustpolicy"), url.Values{})
+	if err != nil {
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	var policy DeviceTrustPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteDeviceTrustPolicy disables device trust checks.
+func (c *Client) DeleteDeviceTrustPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("devicetrustpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetWebSessionLimitPolicy configures the web session limit of the cluster.
+func (c *Client) SetWebSessionLimitPolicy(ctx context.Context, policy WebSessionLimitPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("websessionlimitpolicy"), setWebSessionLimitPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetWebSessionLimitPolicy returns the configured web session limit, with
+// the limit disabled when none was set.
+func (c *Client) GetWebSessionLimitPolicy(ctx context.Context) (WebSessionLimitPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("websessionlimitpolicy"), url.Values{})
+	if err != nil {
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	var policy WebSessionLimitPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteWebSessionLimitPolicy disables the web session limit.
+func (c *Client) DeleteWebSessionLimitPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("websessionlimitpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetSSOMFAPolicy configures second factor delegation to the SSO provider.
+func (c *Client) SetSSOMFAPolicy(ctx context.Context, policy SSOMFAPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("ssomfapolicy"), setSSOMFAPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetSSOMFAPolicy returns the configured SSO MFA delegation policy, with
+// delegation disabled when none was set.
+func (c *Client) GetSSOMFAPolicy(ctx context.Context) (SSOMFAPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("ssomfapolicy"), url.Values{})
+	if err != nil {
+		return SSOMFAPolicy{}, trace.Wrap(err)
+	}
+	var policy SSOMFAPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return SSOMFAPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteSSOMFAPolicy disables second factor delegation to the SSO provider.
+func (c *Client) DeleteSSOMFAPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("ssomfapolicy"))
+	return trace.Wrap(err)
+}
+
+// SetWebSessionTTLPolicy configures the per-connector web session TTLs.
+func (c *Client) SetWebSessionTTLPolicy(ctx context.Context, policy WebSessionTTLPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("websessionttlpolicy"), setWebSessionTTLPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetWebSessionTTLPolicy returns the configured per-connector web session
+// TTLs, with no caps when none were set.
+func (c *Client) GetWebSessionTTLPolicy(ctx context.Context) (WebSessionTTLPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("websessionttlpolicy"), url.Values{})
+	if err != nil {
+		return WebSessionTTLPolicy{}, trace.Wrap(err)
+	}
+	var policy WebSessionTTLPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return WebSessionTTLPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
 }
 
-// DeleteCertAuthority deletes cert authority by ID
-func (c *Client) DeleteCertAuthority(id types.CertAuthID) error {
-	if err := id.Check(); err != nil {
-		return trace.Wrap(err)
+// DeleteWebSessionTTLPolicy removes the per-connector web session TTLs.
+func (c *Client) DeleteWebSessionTTLPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("websessionttlpolicy"))
+	return trace.Wrap(err)
+}
+
+// SetLoginNotificationPolicy configures the new-device login webhook.
+func (c *Client) SetLoginNotificationPolicy(ctx context.Context, policy LoginNotificationPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("loginnotificationpolicy"), setLoginNotificationPolicyReq{Policy: policy})
+	return trace.Wrap(err)
+}
+
+// GetLoginNotificationPolicy returns the configured login notification
+// policy, with the webhook disabled when none was set.
+func (c *Client) GetLoginNotificationPolicy(ctx context.Context) (LoginNotificationPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("loginnotificationpolicy"), url.Values{})
+	if err != nil {
+		return LoginNotificationPolicy{}, trace.Wrap(err)
 	}
-	_, err := c.Delete(context.TODO(), c.Endpoint("authorities", string(id.Type), id.DomainName))
+	var policy LoginNotificationPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return LoginNotificationPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteLoginNotificationPolicy disables the new-device login webhook.
+func (c *Client) DeleteLoginNotificationPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("loginnotificationpolicy"))
 	return trace.Wrap(err)
 }
 
-// ActivateCertAuthority not implemented: can only be called locally.
-func (c *Client) ActivateCertAuthority(id types.CertAuthID) error {
-	return trace.NotImplemented(notImplementedMessage)
+// SetSlackNotifierConfig configures the built-in Slack notifier for access
+// requests.
+func (c *Client) SetSlackNotifierConfig(ctx context.Context, config SlackNotifierConfig) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("slacknotifier"), setSlackNotifierConfigReq{Config: config})
+	return trace.Wrap(err)
 }
 
-// DeactivateCertAuthority not implemented: can only be called locally.
-func (c *Client) DeactivateCertAuthority(id types.CertAuthID) error {
-	return trace.NotImplemented(notImplementedMessage)
+// GetSlackNotifierConfig returns the Slack notifier configuration, disabled
+// when none was set.
+func (c *Client) GetSlackNotifierConfig(ctx context.Context) (SlackNotifierConfig, error) {
+	out, err := c.Get(ctx, c.Endpoint("slacknotifier"), url.Values{})
+	if err != nil {
+		return SlackNotifierConfig{}, trace.Wrap(err)
+	}
+	var config SlackNotifierConfig
+	if err := json.Unmarshal(out.Bytes(), &config); err != nil {
+		return SlackNotifierConfig{}, trace.Wrap(err)
+	}
+	return config, nil
 }
 
-// RegisterUsingToken calls the auth service API to register a new node using a registration token
-// which was previously issued via GenerateToken.
-func (c *Client) RegisterUsingToken(ctx context.Context, req *types.RegisterUsingTokenRequest) (*proto.Certs, error) {
-	if err := req.CheckAndSetDefaults(); err != nil {
+// DeleteSlackNotifierConfig disables the built-in Slack notifier.
+func (c *Client) DeleteSlackNotifierConfig(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("slacknotifier"))
+	return trace.Wrap(err)
+}
+
+// RequestRecoveryApproval files a recovery approval request for a locked-out
+// user, to be resolved by an administrator.
+func (c *Client) RequestRecoveryApproval(ctx context.Context, req RecoveryApprovalRequest) (*RecoveryApprovalRequest, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("recoveryapprovals"), req)
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	out, err := c.PostJSON(ctx, c.Endpoint("tokens", "register"), req)
+	var created RecoveryApprovalRequest
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &created, nil
+}
+
+// GetRecoveryApprovalRequests returns the pending recovery approval requests.
+func (c *Client) GetRecoveryApprovalRequests(ctx context.Context) ([]RecoveryApprovalRequest, error) {
+	out, err := c.Get(ctx, c.Endpoint("recoveryapprovals"), url.Values{})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	var requests []RecoveryApprovalRequest
+	if err := json.Unmarshal(out.Bytes(), &requests); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return requests, nil
+}
 
-	var certs proto.Certs
-	if err := json.Unmarshal(out.Bytes(), &certs); err != nil {
+// ApproveRecoveryRequest resolves a pending recovery approval request and
+// returns a recovery approved token.
+func (c *Client) ApproveRecoveryRequest(ctx context.Context, username string) (types.UserToken, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("recoveryapprovals", username, "approve"), nil)
+	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return services.UnmarshalUserToken(out.Bytes())
+}
 
-	return &certs, nil
+// DenyRecoveryRequest removes a pending recovery approval request.
+func (c *Client) DenyRecoveryRequest(ctx context.Context, username string) error {
+	_, err := c.Delete(ctx, c.Endpoint("recoveryapprovals", username))
+	return trace.Wrap(err)
 }
 
-// DELETE IN: 5.1.0
-//
-// This logic has been moved to KeepAliveServer.
-//
-// KeepAliveNode updates node keep alive information.
-func (c *Client) KeepAliveNode(ctx context.Context, keepAlive types.KeepAlive) error {
-	return trace.BadParameter("not implemented, use StreamKeepAlives instead")
+// SetRecoveryFlowPolicy configures which verification steps unlock account
+// recovery.
+func (c *Client) SetRecoveryFlowPolicy(ctx context.Context, policy RecoveryFlowPolicy) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("recoveryflowpolicy"), setRecoveryFlowPolicyReq{Policy: policy})
+	return trace.Wrap(err)
 }
 
-// KeepAliveServer not implemented: can only be called locally.
-func (c *Client) KeepAliveServer(ctx context.Context, keepAlive types.KeepAlive) error {
-	return trace.BadParameter("not implemented, use StreamKeepAlives instead")
+// GetRecoveryFlowPolicy returns the configured recovery flow policy, falling
+// back to the built-in recovery codes verifier when none was set.
+func (c *Client) GetRecoveryFlowPolicy(ctx context.Context) (RecoveryFlowPolicy, error) {
+	out, err := c.Get(ctx, c.Endpoint("recoveryflowpolicy"), url.Values{})
+	if err != nil {
+		return RecoveryFlowPolicy{}, trace.Wrap(err)
+	}
+	var policy RecoveryFlowPolicy
+	if err := json.Unmarshal(out.Bytes(), &policy); err != nil {
+		return RecoveryFlowPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteRecoveryFlowPolicy removes the recovery flow policy, reverting to the
+// built-in recovery codes verifier.
+func (c *Client) DeleteRecoveryFlowPolicy(ctx context.Context) error {
+	_, err := c.Delete(ctx, c.Endpoint("recoveryflowpolicy"))
+	return trace.Wrap(err)
 }
 
 // UpsertReverseTunnel is used by admins to create a new reverse tunnel
@@ -688,6 +2132,33 @@ func (c *Client) GetAuthServers() ([]types.Server, error) {
 	return re, nil
 }
 
+// PromoteAuthServer promotes the auth server with the given server ID to the
+// primary role, fencing CA signing operations of the previous primary.
+func (c *Client) PromoteAuthServer(ctx context.Context, serverID string) (*AuthLeadership, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("authservers", serverID, "promote"), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var leadership AuthLeadership
+	if err := json.Unmarshal(out.Bytes(), &leadership); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &leadership, nil
+}
+
+// GetAuthLeadership returns the current auth server leadership record.
+func (c *Client) GetAuthLeadership(ctx context.Context) (*AuthLeadership, error) {
+	out, err := c.Get(ctx, c.Endpoint("authservers", "leadership"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var leadership AuthLeadership
+	if err := json.Unmarshal(out.Bytes(), &leadership); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &leadership, nil
+}
+
 // DeleteAllAuthServers not implemented: can only be called locally.
 func (c *Client) DeleteAllAuthServers() error {
 	return trace.NotImplemented(notImplementedMessage)
@@ -791,6 +2262,9 @@ func (c *Client) ChangePassword(req services.ChangePasswordReq) error {
 }
 
 // CheckPassword checks if the suplied web access password is valid.
+//
+// Deprecated: use VerifyAuthentication, which forwards client metadata for
+// throttling and auditing.
 func (c *Client) CheckPassword(user string, password []byte, otpToken string) error {
 	_, err := c.PostJSON(
 		context.TODO(),
@@ -802,6 +2276,13 @@ func (c *Client) CheckPassword(user string, password []byte, otpToken string) er
 	return trace.Wrap(err)
 }
 
+// VerifyAuthentication verifies the user's password and optional second
+// factor without creating a session.
+func (c *Client) VerifyAuthentication(ctx context.Context, req VerifyAuthenticationRequest) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("users", req.Username, "web", "verify"), req)
+	return trace.Wrap(err)
+}
+
 // ExtendWebSession creates a new web session for a user based on another
 // valid web session
 func (c *Client) ExtendWebSession(ctx context.Context, req WebSessionReq) (types.WebSession, error) {
@@ -812,6 +2293,54 @@ func (c *Client) ExtendWebSession(ctx context.Context, req WebSessionReq) (types
 	return services.UnmarshalWebSession(out.Bytes())
 }
 
+// IssueWebSessionRefreshToken starts a session chain rooted at the given
+// web session and returns its first refresh token.
+func (c *Client) IssueWebSessionRefreshToken(ctx context.Context, user, sessionID string) (string, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("users", user, "web", "sessions", sessionID, "refreshtoken"), nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var resp struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return resp.RefreshToken, nil
+}
+
+// RenewWebSession exchanges a refresh token for a new web session and a new
+// refresh token. Presenting an already rotated token revokes the whole
+// session chain.
+func (c *Client) RenewWebSession(ctx context.Context, req RenewWebSessionRequest) (types.WebSession, string, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("users", req.User, "web", "renew"), req)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	var resp struct {
+		Session      json.RawMessage `json:"session"`
+		RefreshToken string          `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	session, err := services.UnmarshalWebSession(resp.Session)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return session, resp.RefreshToken, nil
+}
+
+// StepDownWebSession creates a new web session for a user with specific
+// roles or assumed access requests dropped from the current session
+func (c *Client) StepDownWebSession(ctx context.Context, req StepDownWebSessionReq) (types.WebSession, error) {
+	out, err := c.PostJSON(ctx, c.Endpoint("users", req.User, "web", "stepdown"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return services.UnmarshalWebSession(out.Bytes())
+}
+
 // CreateWebSession creates a new web session for a user
 func (c *Client) CreateWebSession(ctx context.Context, user string) (types.WebSession, error) {
 	out, err := c.PostJSON(
@@ -869,6 +2398,31 @@ func (c *Client) GetWebSessionInfo(ctx context.Context, user, sessionID string)
 	return services.UnmarshalWebSession(out.Bytes())
 }
 
+// GetWebSessionDetails returns the web session together with the details of
+// the access requests its certificates have assumed.
+func (c *Client) GetWebSessionDetails(ctx context.Context, user, sessionID string) (*WebSessionDetails, error) {
+	out, err := c.Get(
+		ctx,
+		c.Endpoint("users", user, "web", "sessions", sessionID, "details"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var raw webSessionDetailsRawResponse
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	session, err := services.UnmarshalWebSession(raw.Session)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &WebSessionDetails{
+		Session:         session,
+		AssumedRequests: raw.AssumedRequests,
+		Elevated:        raw.Elevated,
+		ElevatedUntil:   raw.ElevatedUntil,
+	}, nil
+}
+
 // DeleteWebSession deletes the web session specified with sid for the given user
 func (c *Client) DeleteWebSession(ctx context.Context, user string, sid string) error {
 	_, err := c.Delete(ctx, c.Endpoint("users", user, "web", "sessions", sid))
@@ -1204,6 +2758,18 @@ func (c *Client) SetStaticTokens(st types.StaticTokens) error {
 	return nil
 }
 
+// AddStaticToken appends a token to the static tokens resource.
+func (c *Client) AddStaticToken(ctx context.Context, token types.ProvisionTokenV1) error {
+	_, err := c.PostJSON(ctx, c.Endpoint("configuration", "static_tokens", "tokens"), &addStaticTokenReq{Token: token})
+	return trace.Wrap(err)
+}
+
+// RemoveStaticToken removes a token from the static tokens resource.
+func (c *Client) RemoveStaticToken(ctx context.Context, token string) error {
+	_, err := c.Delete(ctx, c.Endpoint("configuration", "static_tokens", "tokens", token))
+	return trace.Wrap(err)
+}
+
 // DeleteClusterName not implemented: can only be called locally.
 func (c *Client) DeleteClusterName() error {
 	return trace.NotImplemented(notImplementedMessage)
@@ -1388,9 +2954,21 @@ type WebService interface {
 	// ExtendWebSession creates a new web session for a user based on another
 	// valid web session
 	ExtendWebSession(ctx context.Context, req WebSessionReq) (types.WebSession, error)
+
+	// StepDownWebSession creates a new web session for a user with specific
+	// roles or assumed access requests dropped from the current session
+	StepDownWebSession(ctx context.Context, req StepDownWebSessionReq) (types.WebSession, error)
 	// CreateWebSession creates a new web session for a user
 	CreateWebSession(ctx context.Context, user string) (types.WebSession, error)
 
+	// IssueWebSessionRefreshToken starts a session chain rooted at the given
+	// web session and returns its first refresh token.
+	IssueWebSessionRefreshToken(ctx context.Context, user, sessionID string) (string, error)
+	// RenewWebSession exchanges a refresh token for a new web session and a
+	// new refresh token. Presenting an already rotated token revokes the
+	// whole session chain.
+	RenewWebSession(ctx context.Context, req RenewWebSessionRequest) (types.WebSession, string, error)
+
 	// AppSession defines application session features.
 	services.AppSession
 	// SnowflakeSession defines Snowflake session features.
@@ -1482,8 +3060,16 @@ type IdentityService interface {
 	ChangePassword(req services.ChangePasswordReq) error
 
 	// CheckPassword checks if the suplied web access password is valid.
+	//
+	// Deprecated: use VerifyAuthentication, which forwards client metadata
+	// for throttling and auditing.
 	CheckPassword(user string, password []byte, otpToken string) error
 
+	// VerifyAuthentication verifies the user's password and optional second
+	// factor without creating a session. Every attempt is audited and failed
+	// attempts count towards the account lockout.
+	VerifyAuthentication(ctx context.Context, req VerifyAuthenticationRequest) error
+
 	// GenerateToken creates a special provisioning token for a new SSH server
 	// that is valid for ttl period seconds.
 	//
@@ -1494,6 +3080,14 @@ type IdentityService interface {
 	// If TTL is not supplied, token will be valid until removed.
 	GenerateToken(ctx context.Context, req *proto.GenerateTokenRequest) (string, error)
 
+	// GenerateTokens mints a batch of provision tokens sharing the same
+	// spec, returning each token with a correlation id
+	GenerateTokens(ctx context.Context, req GenerateTokensRequest) (GenerateTokensResponse, error)
+
+	// GenerateTokenBundle generates a provision token together with the
+	// cluster coordinates and rendered commands a node needs to join
+	GenerateTokenBundle(ctx context.Context, req *proto.GenerateTokenRequest) (*TokenBundle, error)
+
 	// GenerateHostCert takes the public key in the Open SSH ``authorized_keys``
 	// plain text format, signs it using Host Certificate Authority private key and returns the
 	// resulting certificate.
@@ -1583,6 +3177,10 @@ type ProvisioningService interface {
 	// GetTokens returns a list of active invitation tokens for nodes and users
 	GetTokens(ctx context.Context) (tokens []types.ProvisionToken, err error)
 
+	// ListTokens returns a page of provision tokens matching the request
+	// filters
+	ListTokens(ctx context.Context, req ListTokensRequest) (ListTokensResponse, error)
+
 	// GetToken returns provisioning token
 	GetToken(ctx context.Context, token string) (types.ProvisionToken, error)
 
@@ -1602,6 +3200,38 @@ type ProvisioningService interface {
 	// RegisterUsingToken calls the auth service API to register a new node via registration token
 	// which has been previously issued via GenerateToken
 	RegisterUsingToken(ctx context.Context, req *types.RegisterUsingTokenRequest) (*proto.Certs, error)
+
+	// RegisterUsingGCPMethod registers a new node using a GCP-signed
+	// instance identity token.
+	RegisterUsingGCPMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error)
+
+	// RegisterUsingKubernetesMethod registers a new node using a projected
+	// Kubernetes service account token.
+	RegisterUsingKubernetesMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error)
+
+	// RegisterUsingGitLabMethod registers a new node using a GitLab CI ID
+	// token.
+	RegisterUsingGitLabMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error)
+
+	// RegisterUsingCircleCIMethod registers a new node using a CircleCI
+	// OIDC token.
+	RegisterUsingCircleCIMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error)
+
+	// RegisterUsingSPIFFEMethod registers a new node using an X.509 SVID
+	// from a trusted SPIFFE trust domain.
+	RegisterUsingSPIFFEMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, svidChainPEM, signature []byte) (*proto.Certs, error)
+
+	// UpsertSPIFFETrustBundle creates or updates the trust bundle of a
+	// SPIFFE trust domain.
+	UpsertSPIFFETrustBundle(ctx context.Context, bundle SPIFFETrustBundle) error
+
+	// GetSPIFFETrustBundle returns the trust bundle of the given SPIFFE
+	// trust domain.
+	GetSPIFFETrustBundle(ctx context.Context, trustDomain string) (*SPIFFETrustBundle, error)
+
+	// DeleteSPIFFETrustBundle removes the trust bundle of the given SPIFFE
+	// trust domain.
+	DeleteSPIFFETrustBundle(ctx context.Context, trustDomain string) error
 }
 
 // ClientI is a client to Auth service
@@ -1633,6 +3263,224 @@ type ClientI interface {
 	// NewKeepAliver returns a new instance of keep aliver
 	NewKeepAliver(ctx context.Context) (types.KeepAliver, error)
 
+	// GetNodesAt returns the nodes that were present in the namespace at
+	// the given time, reconstructed from the compacted presence history
+	GetNodesAt(ctx context.Context, namespace string, at time.Time) ([]NodePresenceRecord, error)
+
+	// SetResourceAnnotations replaces the annotations attached to a
+	// resource. An empty map removes all annotations.
+	SetResourceAnnotations(ctx context.Context, kind, name string, annotations map[string]string) error
+
+	// GetResourceAnnotations returns the annotations attached to a
+	// resource.
+	GetResourceAnnotations(ctx context.Context, kind, name string) (map[string]string, error)
+
+	// AddStaticToken appends a token to the static tokens resource. The
+	// change takes effect without a service restart.
+	AddStaticToken(ctx context.Context, token types.ProvisionTokenV1) error
+
+	// RemoveStaticToken removes a token from the static tokens resource.
+	RemoveStaticToken(ctx context.Context, token string) error
+
+	// ExportCAKeyBackup exports the key material of a certificate
+	// authority sealed to a set of operator public keys with a threshold
+	// Shamir split.
+	ExportCAKeyBackup(ctx context.Context, req ExportCAKeyBackupRequest) (*CAKeyBackup, error)
+
+	// ImportCAKeyBackup restores the key material of a certificate
+	// authority from a sealed backup and the opened operator shares.
+	ImportCAKeyBackup(ctx context.Context, req ImportCAKeyBackupRequest) error
+
+	// CreateSessionJoinURL generates a short-lived URL that lets its
+	// visitors attach to an active session.
+	CreateSessionJoinURL(ctx context.Context, req CreateSessionJoinURLRequest) (*SessionJoinURL, error)
+
+	// RedeemSessionJoinURL resolves a session join token into the
+	// authorization it grants.
+	RedeemSessionJoinURL(ctx context.Context, token string) (*SessionJoinAuthorization, error)
+
+	// GetAccessRequestInsights returns the aggregated access request
+	// report over approval latency, usage and requesters.
+	GetAccessRequestInsights(ctx context.Context) (*AccessRequestInsights, error)
+
+	// GetAgentInventory returns every agent known to the cluster with its
+	// version, services and last heartbeat.
+	GetAgentInventory(ctx context.Context) (*AgentInventory, error)
+
+	// GetWebSessionDetails returns the web session together with the details
+	// of the access requests its certificates have assumed.
+	GetWebSessionDetails(ctx context.Context, user, sessionID string) (*WebSessionDetails, error)
+
+	// RenameMFADevice renames an MFA device of the user who owns the
+	// privilege token in the request.
+	RenameMFADevice(ctx context.Context, req RenameMFADeviceRequest) error
+
+	// GetMFADevicesByUser returns the registered MFA devices of the given
+	// user without secrets.
+	GetMFADevicesByUser(ctx context.Context, user string) ([]MFADeviceInfo, error)
+
+	// GetRecoveryCodesSummary returns how many recovery codes the user has
+	// left.
+	GetRecoveryCodesSummary(ctx context.Context, user string) (*RecoveryCodesSummary, error)
+
+	// UpsertCertTTLClampPolicy creates or updates a policy that further
+	// caps certificate TTLs for requests routing to matching targets.
+	UpsertCertTTLClampPolicy(ctx context.Context, policy CertTTLClampPolicy) error
+
+	// GetCertTTLClampPolicies returns all cert TTL clamp policies.
+	GetCertTTLClampPolicies(ctx context.Context) ([]CertTTLClampPolicy, error)
+
+	// DeleteCertTTLClampPolicy deletes a cert TTL clamp policy by name.
+	DeleteCertTTLClampPolicy(ctx context.Context, name string) error
+
+	// SetTOTPPolicy configures the TOTP parameters of the cluster.
+	SetTOTPPolicy(ctx context.Context, policy TOTPPolicy) error
+
+	// GetTOTPPolicy returns the configured TOTP parameters, falling back
+	// to the defaults when none were set.
+	GetTOTPPolicy(ctx context.Context) (TOTPPolicy, error)
+
+	// DeleteTOTPPolicy reverts the cluster to the default TOTP parameters.
+	DeleteTOTPPolicy(ctx context.Context) error
+
+	// SetPasswordHashPolicy configures the password hashing method of the
+	// cluster.
+	SetPasswordHashPolicy(ctx context.Context, policy PasswordHashPolicy) error
+
+	// GetPasswordHashPolicy returns the configured password hashing method,
+	// falling back to bcrypt when none was set.
+	GetPasswordHashPolicy(ctx context.Context) (PasswordHashPolicy, error)
+
+	// DeletePasswordHashPolicy reverts the cluster to bcrypt password
+	// storage.
+	DeletePasswordHashPolicy(ctx context.Context) error
+
+	// SetPasswordPolicy configures the password policy of the cluster.
+	SetPasswordPolicy(ctx context.Context, policy PasswordPolicy) error
+
+	// GetPasswordPolicy returns the configured password policy, falling
+	// back to the built-in checks when none was set.
+	GetPasswordPolicy(ctx context.Context) (PasswordPolicy, error)
+
+	// DeletePasswordPolicy reverts the cluster to the built-in password
+	// checks.
+	DeletePasswordPolicy(ctx context.Context) error
+
+	// SetLockoutPolicy configures the lockout policy of the cluster.
+	SetLockoutPolicy(ctx context.Context, policy LockoutPolicy) error
+
+	// GetLockoutPolicy returns the configured lockout policy, falling back
+	// to the built-in limits when none was set.
+	GetLockoutPolicy(ctx context.Context) (LockoutPolicy, error)
+
+	// DeleteLockoutPolicy reverts the cluster to the built-in lockout
+	// limits.
+	DeleteLockoutPolicy(ctx context.Context) error
+
+	// EnrollDevice binds a device key to a user and returns the stored
+	// device record with its generated ID.
+	EnrollDevice(ctx context.Context, req EnrollDeviceRequest) (*TrustedDevice, error)
+
+	// GetTrustedDevice returns the enrolled device with the given ID.
+	GetTrustedDevice(ctx context.Context, id string) (*TrustedDevice, error)
+
+	// GetTrustedDevices returns the device inventory of the cluster.
+	GetTrustedDevices(ctx context.Context) ([]TrustedDevice, error)
+
+	// DeleteTrustedDevice removes the enrolled device with the given ID.
+	DeleteTrustedDevice(ctx context.Context, id string) error
+
+	// SetDeviceTrustPolicy configures the device trust mode of the cluster.
+	SetDeviceTrustPolicy(ctx context.Context, policy DeviceTrustPolicy) error
+
+	// GetDeviceTrustPolicy returns the configured device trust mode,
+	// falling back to "off" when none was set.
+	GetDeviceTrustPolicy(ctx context.Context) (DeviceTrustPolicy, error)
+
+	// DeleteDeviceTrustPolicy disables device trust checks.
+	DeleteDeviceTrustPolicy(ctx context.Context) error
+
+	// SetWebSessionLimitPolicy configures the web session limit of the
+	// cluster.
+	SetWebSessionLimitPolicy(ctx context.Context, policy WebSessionLimitPolicy) error
+
+	// GetWebSessionLimitPolicy returns the configured web session limit,
+	// with the limit disabled when none was set.
+	GetWebSessionLimitPolicy(ctx context.Context) (WebSessionLimitPolicy, error)
+
+	// DeleteWebSessionLimitPolicy disables the web session limit.
+	DeleteWebSessionLimitPolicy(ctx context.Context) error
+
+	// SetSSOMFAPolicy configures second factor delegation to the SSO
+	// provider.
+	SetSSOMFAPolicy(ctx context.Context, policy SSOMFAPolicy) error
+
+	// GetSSOMFAPolicy returns the configured SSO MFA delegation policy,
+	// with delegation disabled when none was set.
+	GetSSOMFAPolicy(ctx context.Context) (SSOMFAPolicy, error)
+
+	// DeleteSSOMFAPolicy disables second factor delegation to the SSO
+	// provider.
+	DeleteSSOMFAPolicy(ctx context.Context) error
+
+	// SetWebSessionTTLPolicy configures the per-connector web session TTLs.
+	SetWebSessionTTLPolicy(ctx context.Context, policy WebSessionTTLPolicy) error
+
+	// GetWebSessionTTLPolicy returns the configured per-connector web
+	// session TTLs, with no caps when none were set.
+	GetWebSessionTTLPolicy(ctx context.Context) (WebSessionTTLPolicy, error)
+
+	// DeleteWebSessionTTLPolicy removes the per-connector web session TTLs.
+	DeleteWebSessionTTLPolicy(ctx context.Context) error
+
+	// SetLoginNotificationPolicy configures the new-device login webhook.
+	SetLoginNotificationPolicy(ctx context.Context, policy LoginNotificationPolicy) error
+
+	// GetLoginNotificationPolicy returns the configured login notification
+	// policy, with the webhook disabled when none was set.
+	GetLoginNotificationPolicy(ctx context.Context) (LoginNotificationPolicy, error)
+
+	// DeleteLoginNotificationPolicy disables the new-device login webhook.
+	DeleteLoginNotificationPolicy(ctx context.Context) error
+
+	// SetSlackNotifierConfig configures the built-in Slack notifier for
+	// access requests.
+	SetSlackNotifierConfig(ctx context.Context, config SlackNotifierConfig) error
+
+	// GetSlackNotifierConfig returns the Slack notifier configuration,
+	// disabled when none was set.
+	GetSlackNotifierConfig(ctx context.Context) (SlackNotifierConfig, error)
+
+	// DeleteSlackNotifierConfig disables the built-in Slack notifier.
+	DeleteSlackNotifierConfig(ctx context.Context) error
+
+	// RequestRecoveryApproval files a recovery approval request for a
+	// locked-out user, to be resolved by an administrator.
+	RequestRecoveryApproval(ctx context.Context, req RecoveryApprovalRequest) (*RecoveryApprovalRequest, error)
+
+	// GetRecoveryApprovalRequests returns the pending recovery approval
+	// requests.
+	GetRecoveryApprovalRequests(ctx context.Context) ([]RecoveryApprovalRequest, error)
+
+	// ApproveRecoveryRequest resolves a pending recovery approval request
+	// and returns a recovery approved token.
+	ApproveRecoveryRequest(ctx context.Context, username string) (types.UserToken, error)
+
+	// DenyRecoveryRequest removes a pending recovery approval request.
+	DenyRecoveryRequest(ctx context.Context, username string) error
+
+	// SetRecoveryFlowPolicy configures which verification steps unlock
+	// account recovery.
+	SetRecoveryFlowPolicy(ctx context.Context, policy RecoveryFlowPolicy) error
+
+	// GetRecoveryFlowPolicy returns the configured recovery flow policy,
+	// falling back to the built-in recovery codes verifier when none was set.
+	GetRecoveryFlowPolicy(ctx context.Context) (RecoveryFlowPolicy, error)
+
+	// DeleteRecoveryFlowPolicy removes the recovery flow policy, reverting
+	// to the built-in recovery codes verifier.
+	DeleteRecoveryFlowPolicy(ctx context.Context) error
+
 	// RotateCertAuthority starts or restarts certificate authority rotation process.
 	RotateCertAuthority(ctx context.Context, req RotateRequest) error
 
@@ -1641,6 +3489,172 @@ type ClientI interface {
 	// the certificate authorities of trusted clusters.
 	RotateExternalCertAuthority(ctx context.Context, ca types.CertAuthority) error
 
+	// RotateExternalCertAuthorities rotates all certificate authorities of a
+	// remote cluster as a set, see RotateExternalCertAuthority.
+	RotateExternalCertAuthorities(ctx context.Context, cas []types.CertAuthority) error
+
+	// CreateScopedAPICerts mints a scope-restricted TLS certificate derived
+	// from the caller's own session, see ScopedCertsRequest.
+	CreateScopedAPICerts(ctx context.Context, req ScopedCertsRequest) (*ScopedAPICerts, error)
+
+	// GenerateUserCertsWithAttestation generates user certificates for a key
+	// that is attested to reside on a hardware token.
+	GenerateUserCertsWithAttestation(ctx context.Context, req proto.UserCertsRequest, attestation *AttestationStatement) (*proto.Certs, error)
+
+	// RotateBackendEncryptionKey rotates the envelope key used to seal
+	// sensitive values stored in the backend.
+	RotateBackendEncryptionKey(ctx context.Context) error
+
+	// RunPreflightChecks validates the health of the auth server's
+	// dependencies and reports the results.
+	RunPreflightChecks(ctx context.Context) (*PreflightReport, error)
+
+	// GetCertKeyPolicy returns the cluster-level subject key policy.
+	GetCertKeyPolicy(ctx context.Context) (*CertKeyPolicy, error)
+
+	// GenerateUserConfigBundle renders a kubeconfig and an ssh_config
+	// snippet covering the resources the calling user can access.
+	GenerateUserConfigBundle(ctx context.Context) (*UserConfigBundle, error)
+
+	// GenerateWorkloadIdentityToken mints an OIDC ID token for the calling
+	// identity, to be exchanged for cloud credentials via identity
+	// federation.
+	GenerateWorkloadIdentityToken(ctx context.Context, req WorkloadIdentityTokenRequest) (*WorkloadIdentityTokenResponse, error)
+
+	// UpsertAccessBundle creates or updates an access bundle.
+	UpsertAccessBundle(ctx context.Context, bundle AccessBundle) error
+
+	// GetAccessBundle returns the access bundle with the given name.
+	GetAccessBundle(ctx context.Context, name string) (*AccessBundle, error)
+
+	// GetAccessBundles returns all defined access bundles.
+	GetAccessBundles(ctx context.Context) ([]AccessBundle, error)
+
+	// DeleteAccessBundle removes the access bundle with the given name.
+	DeleteAccessBundle(ctx context.Context, name string) error
+
+	// CreateAccessRequestFromBundle files a pending access request for the
+	// calling user covering everything in the named bundle.
+	CreateAccessRequestFromBundle(ctx context.Context, bundleName string) (*AccessBundleRequestSummary, error)
+
+	// CreateScheduledAccessRequest files an access request that only
+	// becomes assumable at the given future start time.
+	CreateScheduledAccessRequest(ctx context.Context, req types.AccessRequest, start time.Time) error
+
+	// PreviewAccessRequest reports what submitting the prospective access
+	// request would do, without creating it.
+	PreviewAccessRequest(ctx context.Context, req types.AccessRequest) (*AccessRequestPreview, error)
+
+	// UpsertAccessList creates or updates an access list.
+	UpsertAccessList(ctx context.Context, list AccessList) error
+
+	// GetAccessList returns the access list with the given name.
+	GetAccessList(ctx context.Context, name string) (*AccessList, error)
+
+	// GetAccessLists returns all defined access lists.
+	GetAccessLists(ctx context.Context) ([]AccessList, error)
+
+	// DeleteAccessList removes the access list with the given name.
+	DeleteAccessList(ctx context.Context, name string) error
+
+	// ReviewAccessList records a membership attestation, restarting the
+	// review clock of the list.
+	ReviewAccessList(ctx context.Context, name string) (*AccessList, error)
+
+	// UpsertAccessAutoApprovalRule creates or updates an auto-approval
+	// rule.
+	UpsertAccessAutoApprovalRule(ctx context.Context, rule AccessAutoApprovalRule) error
+
+	// GetAccessAutoApprovalRules returns all defined auto-approval rules.
+	GetAccessAutoApprovalRules(ctx context.Context) ([]AccessAutoApprovalRule, error)
+
+	// DeleteAccessAutoApprovalRule removes the auto-approval rule with the
+	// given name.
+	DeleteAccessAutoApprovalRule(ctx context.Context, name string) error
+
+	// CreateAccessRequestWithMaxDuration files an access request whose
+	// elevated access expires the given duration after approval,
+	// regardless of the request expiry.
+	CreateAccessRequestWithMaxDuration(ctx context.Context, req types.AccessRequest, maxDuration time.Duration) error
+
+	// SetAccessRequestDurationPolicy updates the cluster access request
+	// duration policy.
+	SetAccessRequestDurationPolicy(ctx context.Context, policy AccessRequestDurationPolicy) error
+
+	// GetAccessRequestDurationPolicy returns the cluster access request
+	// duration policy.
+	GetAccessRequestDurationPolicy(ctx context.Context) (AccessRequestDurationPolicy, error)
+
+	// DeleteAccessRequestDurationPolicy removes the cluster access request
+	// duration policy.
+	DeleteAccessRequestDurationPolicy(ctx context.Context) error
+
+	// SetAccessEscalationPolicy updates the cluster access escalation
+	// policy.
+	SetAccessEscalationPolicy(ctx context.Context, policy AccessEscalationPolicy) error
+
+	// GetAccessEscalationPolicy returns the cluster access escalation
+	// policy.
+	GetAccessEscalationPolicy(ctx context.Context) (*AccessEscalationPolicy, error)
+
+	// SetHostPrincipalPolicy updates the cluster host principal policy.
+	SetHostPrincipalPolicy(ctx context.Context, policy HostPrincipalPolicy) error
+
+	// GetHostPrincipalPolicy returns the cluster host principal policy.
+	GetHostPrincipalPolicy(ctx context.Context) (*HostPrincipalPolicy, error)
+
+	// SetDataResidencyPolicy updates the cluster data residency policy.
+	SetDataResidencyPolicy(ctx context.Context, policy DataResidencyPolicy) error
+
+	// GetDataResidencyPolicy returns the cluster data residency policy.
+	GetDataResidencyPolicy(ctx context.Context) (*DataResidencyPolicy, error)
+
+	// GetDataResidencyStatus reports compliance information for the data
+	// residency policy.
+	GetDataResidencyStatus(ctx context.Context) (*DataResidencyStatus, error)
+
+	// GetBots returns all certificate renewal bots registered in the
+	// cluster.
+	GetBots(ctx context.Context) ([]*Bot, error)
+
+	// GetBot returns the certificate renewal bot with the given name.
+	GetBot(ctx context.Context, botName string) (*Bot, error)
+
+	// UpsertOutputTemplate creates or updates an output template.
+	UpsertOutputTemplate(ctx context.Context, tmpl OutputTemplate) error
+
+	// GetOutputTemplates returns all stored output templates.
+	GetOutputTemplates(ctx context.Context) ([]OutputTemplate, error)
+
+	// DeleteOutputTemplate removes an output template.
+	DeleteOutputTemplate(ctx context.Context, name string) error
+
+	// RenderOutputTemplates renders all stored output templates for the
+	// calling identity.
+	RenderOutputTemplates(ctx context.Context) ([]RenderedOutput, error)
+
+	// SetCertKeyPolicy updates the cluster-level subject key policy.
+	SetCertKeyPolicy(ctx context.Context, policy CertKeyPolicy) error
+
+	// GenerateSubjectKeyPair generates a policy-compliant keypair on the
+	// server for clients that cannot or do not want to generate their own.
+	GenerateSubjectKeyPair(ctx context.Context) (*SubjectKeyPair, error)
+
+	// UpsertSPIFFEFederation imports or updates the bundle of an external
+	// SPIFFE trust domain.
+	UpsertSPIFFEFederation(ctx context.Context, federation SPIFFEFederation) error
+
+	// GetSPIFFEFederations returns all imported external SPIFFE trust domains.
+	GetSPIFFEFederations(ctx context.Context) ([]SPIFFEFederation, error)
+
+	// DeleteSPIFFEFederation removes the bundle of an external SPIFFE trust
+	// domain.
+	DeleteSPIFFEFederation(ctx context.Context, trustDomain string) error
+
+	// PreviewTrustedCluster reports which remote roles would map to which
+	// local roles under the proposed trusted cluster, without committing it.
+	PreviewTrustedCluster(ctx context.Context, tc types.TrustedCluster) (*TrustedClusterPreview, error)
+
 	// ValidateTrustedCluster validates trusted cluster token with
 	// main cluster, in case if validation is successful, main cluster
 	// adds remote cluster
@@ -1653,6 +3667,45 @@ type ClientI interface {
 	// If the cluster has multiple TLS certs, they will all be concatenated.
 	GetClusterCACert(ctx context.Context) (*proto.GetClusterCACertResponse, error)
 
+	// GetClusterCACertPins returns the SPKI pins of the cluster host CA,
+	// including pending pins of an ongoing rotation.
+	GetClusterCACertPins(ctx context.Context) ([]CAPin, error)
+
+	// AckLockPropagation records that the calling agent has committed a lock
+	// to its local view.
+	AckLockPropagation(ctx context.Context, lockName string) error
+
+	// PromoteAuthServer promotes an auth server to the primary role, fencing
+	// CA signing operations of the previous primary.
+	PromoteAuthServer(ctx context.Context, serverID string) (*AuthLeadership, error)
+
+	// GetAuthLeadership returns the current auth server leadership record.
+	GetAuthLeadership(ctx context.Context) (*AuthLeadership, error)
+
+	// GetCertIssuances returns the certificate issuance log entries for a
+	// user.
+	GetCertIssuances(ctx context.Context, username string) ([]CertIssuance, error)
+
+	// GetLegacyMFAUsers returns the users who still have legacy U2F device
+	// records.
+	GetLegacyMFAUsers(ctx context.Context) ([]LegacyMFAUser, error)
+
+	// GetUserSessions enumerates the user's active web sessions, application
+	// sessions and unexpired certificates.
+	GetUserSessions(ctx context.Context, username string) (UserSessions, error)
+
+	// GetWebSessionsMetadata returns the client metadata recorded for all
+	// of the user's web sessions: IP, user agent and login method.
+	GetWebSessionsMetadata(ctx context.Context, username string) ([]WebSessionMetadata, error)
+
+	// RevokeUserSessions revokes all of the user's sessions and unexpired
+	// certificates in one sweep and returns what was revoked.
+	RevokeUserSessions(ctx context.Context, username string) (UserSessions, error)
+
+	// GetLockPropagationStatus returns the per-agent acknowledgments received
+	// for a lock.
+	GetLockPropagationStatus(ctx context.Context, lockName string) (*LockPropagationStatus, error)
+
 	// GenerateHostCerts generates new host certificates (signed
 	// by the host certificate authority) for a node
 	GenerateHostCerts(context.Context, *proto.HostCertsRequest) (*proto.Certs, error)
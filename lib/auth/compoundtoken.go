@@ -0,0 +1,108 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// compoundTokenPrefix marks the self-describing compound join token format.
+const compoundTokenPrefix = "teleport-token-v1."
+
+// CompoundToken is a self-describing join token: the secret together with
+// the cluster coordinates it belongs to, so copying a token between
+// environments can not pair it with the wrong proxy address or CA pin.
+type CompoundToken struct {
+	// Token is the plain join token secret.
+	Token string `json:"token"`
+	// Addr is the address of the cluster the token was issued for.
+	Addr string `json:"addr,omitempty"`
+	// CAPins are the SPKI pins of the cluster host CA.
+	CAPins []string `json:"ca_pins,omitempty"`
+}
+
+// compoundTokenMAC authenticates the payload with a key derived from the
+// embedded secret, so the cluster coordinates can not be altered without
+// knowledge of the secret.
+func compoundTokenMAC(secret string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(compoundTokenPrefix+secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// IsCompoundToken reports whether the token string uses the compound
+// format.
+func IsCompoundToken(token string) bool {
+	return strings.HasPrefix(token, compoundTokenPrefix)
+}
+
+// EncodeCompoundToken renders a compound join token embedding the cluster
+// address and CA pins alongside the secret.
+func EncodeCompoundToken(secret, addr string, caPins []string) (string, error) {
+	if secret == "" {
+		return "", trace.BadParameter("missing token secret")
+	}
+	payload, err := json.Marshal(CompoundToken{
+		Token:  secret,
+		Addr:   addr,
+		CAPins: caPins,
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return compoundTokenPrefix +
+		base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(compoundTokenMAC(secret, payload)), nil
+}
+
+// DecodeCompoundToken parses and verifies a compound join token. It returns
+// nil without an error when the string is not in the compound format, so
+// callers can fall through to treating it as a plain token.
+func DecodeCompoundToken(token string) (*CompoundToken, error) {
+	if !IsCompoundToken(token) {
+		return nil, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(token, compoundTokenPrefix), ".")
+	if len(parts) != 2 {
+		return nil, trace.BadParameter("malformed compound token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, trace.BadParameter("malformed compound token payload")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, trace.BadParameter("malformed compound token signature")
+	}
+	var compound CompoundToken
+	if err := json.Unmarshal(payload, &compound); err != nil {
+		return nil, trace.BadParameter("malformed compound token payload")
+	}
+	if compound.Token == "" {
+		return nil, trace.BadParameter("compound token carries no secret")
+	}
+	if !hmac.Equal(signature, compoundTokenMAC(compound.Token, payload)) {
+		return nil, trace.BadParameter("compound token signature does not match, the token was corrupted in transit")
+	}
+	return &compound, nil
+}
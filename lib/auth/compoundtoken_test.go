@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompoundTokenRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pins := []string{"sha256:0123456789abcdef"}
+	encoded, err := EncodeCompoundToken("secret-token", "proxy.example.com:3080", pins)
+	require.NoError(t, err)
+	require.True(t, IsCompoundToken(encoded))
+
+	compound, err := DecodeCompoundToken(encoded)
+	require.NoError(t, err)
+	require.NotNil(t, compound)
+	require.Equal(t, "secret-token", compound.Token)
+	require.Equal(t, "proxy.example.com:3080", compound.Addr)
+	require.Equal(t, pins, compound.CAPins)
+
+	// plain tokens pass through untouched
+	plain, err := DecodeCompoundToken("secret-token")
+	require.NoError(t, err)
+	require.Nil(t, plain)
+}
+
+func TestCompoundTokenTampering(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := EncodeCompoundToken("secret-token", "proxy.example.com:3080", nil)
+	require.NoError(t, err)
+
+	// re-point the payload at another cluster without re-signing
+	parts := strings.Split(strings.TrimPrefix(encoded, compoundTokenPrefix), ".")
+	require.Len(t, parts, 2)
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	var compound CompoundToken
+	require.NoError(t, json.Unmarshal(payload, &compound))
+	compound.Addr = "evil.example.com:3080"
+	tampered, err := json.Marshal(compound)
+	require.NoError(t, err)
+
+	_, err = DecodeCompoundToken(compoundTokenPrefix +
+		base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[1])
+	require.True(t, trace.IsBadParameter(err))
+
+	_, err = DecodeCompoundToken(compoundTokenPrefix + "not-base64")
+	require.True(t, trace.IsBadParameter(err))
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"text/template"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// UserConfigBundle holds client configuration rendered server-side for a
+// single user, covering only the resources their roles allow access to.
+type UserConfigBundle struct {
+	// Kubeconfig is a ready-to-use kubeconfig with a context per accessible
+	// kubernetes cluster, using tsh as the exec credentials plugin.
+	Kubeconfig string `json:"kubeconfig"`
+	// SSHConfig is an OpenSSH config snippet with ProxyCommand entries for
+	// the nodes the user can reach.
+	SSHConfig string `json:"ssh_config"`
+}
+
+// userKubeconfigTemplate renders a kubeconfig that routes requests through
+// the proxy and relies on tsh for per-cluster credentials.
+var userKubeconfigTemplate = template.Must(template.New("user-kubeconfig").Parse(`apiVersion: v1
+kind: Config
+clusters:
+- name: {{ .ClusterName }}
+  cluster:
+    server: https://{{ .ProxyAddr }}
+contexts:
+{{- range .KubeClusters }}
+- name: {{ $.ClusterName }}-{{ . }}
+  context:
+    cluster: {{ $.ClusterName }}
+    user: {{ $.ClusterName }}-{{ . }}
+{{- end }}
+users:
+{{- range .KubeClusters }}
+- name: {{ $.ClusterName }}-{{ . }}
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: tsh
+      args:
+      - kube
+      - credentials
+      - --kube-cluster={{ . }}
+      - --teleport-cluster={{ $.ClusterName }}
+{{- end }}
+{{- if .KubeClusters }}
+current-context: {{ .ClusterName }}-{{ index .KubeClusters 0 }}
+{{- end }}
+`))
+
+// userSSHConfigTemplate renders an OpenSSH config snippet routing the user's
+// reachable nodes through the proxy.
+var userSSHConfigTemplate = template.Must(template.New("user-ssh-config").Parse(`# Generated Teleport configuration for cluster {{ .ClusterName }}
+{{- range .Nodes }}
+Host {{ . }}.{{ $.ClusterName }}
+    HostName {{ . }}.{{ $.ClusterName }}
+    Port 3022
+    ProxyCommand tsh proxy ssh --cluster={{ $.ClusterName }} --proxy={{ $.ProxyHost }} %r@%h:%p
+{{ end }}`))
+
+// GenerateUserConfigBundle renders a kubeconfig and an ssh_config snippet for
+// the calling user. Resources are read through the caller's own RBAC filters,
+// so the rendered configuration only references kubernetes clusters and nodes
+// the user can actually access.
+func (a *ServerWithRoles) GenerateUserConfigBundle(ctx context.Context) (*UserConfigBundle, error) {
+	clusterName, err := a.authServer.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	proxies, err := a.authServer.GetProxies()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var proxyAddr string
+	for _, proxy := range proxies {
+		if addr := proxy.GetPublicAddr(); addr != "" {
+			proxyAddr = addr
+			break
+		}
+	}
+	if proxyAddr == "" {
+		return nil, trace.NotFound("no proxy with a public address is registered in the cluster")
+	}
+	proxyHost, err := utils.Host(proxyAddr)
+	if err != nil {
+		proxyHost = proxyAddr
+	}
+
+	kubeServers, err := a.GetKubeServices(ctx)
+	if err != nil && !trace.IsAccessDenied(err) {
+		return nil, trace.Wrap(err)
+	}
+	kubeClusters := map[string]struct{}{}
+	for _, server := range kubeServers {
+		for _, kubeCluster := range server.GetKubernetesClusters() {
+			kubeClusters[kubeCluster.Name] = struct{}{}
+		}
+	}
+
+	nodes, err := a.GetNodes(ctx, apidefaults.Namespace)
+	if err != nil && !trace.IsAccessDenied(err) {
+		return nil, trace.Wrap(err)
+	}
+	hostnames := map[string]struct{}{}
+	for _, node := range nodes {
+		if hostname := node.GetHostname(); hostname != "" {
+			hostnames[hostname] = struct{}{}
+		}
+	}
+
+	bundle := &UserConfigBundle{}
+	var kubeconfig bytes.Buffer
+	if err := userKubeconfigTemplate.Execute(&kubeconfig, map[string]interface{}{
+		"ClusterName":  clusterName.GetClusterName(),
+		"ProxyAddr":    proxyAddr,
+		"KubeClusters": sortedKeys(kubeClusters),
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bundle.Kubeconfig = kubeconfig.String()
+
+	var sshConfig bytes.Buffer
+	if err := userSSHConfigTemplate.Execute(&sshConfig, map[string]interface{}{
+		"ClusterName": clusterName.GetClusterName(),
+		"ProxyHost":   proxyHost,
+		"Nodes":       sortedKeys(hostnames),
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	bundle.SSHConfig = sshConfig.String()
+
+	return bundle, nil
+}
+
+// sortedKeys returns the keys of the set in sorted order.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// UserManagementDeniedRolesLabel is a role metadata label holding a
+// comma-separated list of glob patterns. Callers holding a role with this
+// label may not manage (update, delete or reset credentials of) users who
+// hold a role matching one of the patterns. This allows delegating helpdesk
+// duties without granting power over administrators.
+const UserManagementDeniedRolesLabel = "teleport.dev/deny-managing-roles"
+
+// checkUserManagementDelegation enforces delegation limits declared on the
+// caller's roles before a user-management operation targeting the given user.
+func (a *ServerWithRoles) checkUserManagementDelegation(targetUsername string) error {
+	var patterns []string
+	var basis []string
+	for _, role := range a.context.Checker.Roles() {
+		label, ok := role.GetMetadata().Labels[UserManagementDeniedRolesLabel]
+		if !ok || label == "" {
+			continue
+		}
+		for _, pattern := range strings.Split(label, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+		basis = append(basis, role.GetName())
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	target, err := a.authServer.GetUser(targetUsername, false)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			// creating a new user is never restricted by the target's roles
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	for _, targetRole := range target.GetRoles() {
+		for _, pattern := range patterns {
+			expr, err := regexp.Compile("^" + utils.GlobToRegexp(pattern) + "$")
+			if err != nil {
+				continue
+			}
+			if expr.MatchString(targetRole) {
+				return trace.AccessDenied("user %q holds role %q which roles %v of user %q are not delegated to manage",
+					targetUsername, targetRole, basis, a.context.User.GetName())
+			}
+		}
+	}
+	// record the delegation basis so restricted user management actions can
+	// be traced back to the roles that permitted them
+	log.Infof("User management action on %q permitted for %q under delegation limits of roles %v.",
+		targetUsername, a.context.User.GetName(), basis)
+	return nil
+}
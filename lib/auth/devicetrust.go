@@ -0,0 +1,358 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// trustedDevicePrefix is the backend prefix enrolled devices are stored
+	// under.
+	trustedDevicePrefix = "trusted_devices"
+
+	// deviceTrustPolicyPrefix is the backend key the device trust policy
+	// singleton is stored under.
+	deviceTrustPolicyPrefix = "device_trust_policy"
+)
+
+// Device trust modes.
+const (
+	// DeviceTrustModeOff disables device checks, enrolled devices are kept
+	// for inventory purposes only.
+	DeviceTrustModeOff = "off"
+	// DeviceTrustModeOptional verifies a device when one is presented but
+	// does not require one.
+	DeviceTrustModeOptional = "optional"
+	// DeviceTrustModeRequired refuses to issue user certificates unless a
+	// device enrolled for the user is presented.
+	DeviceTrustModeRequired = "required"
+)
+
+// TrustedDevice is a device key enrolled for a user. The key is expected to
+// be backed by a hardware keystore (TPM, Secure Enclave) on the device.
+type TrustedDevice struct {
+	// ID is an auto-generated device identifier.
+	ID string `json:"id"`
+	// Name is a human-friendly device name, unique per user.
+	Name string `json:"name"`
+	// User is the user the device is enrolled for.
+	User string `json:"user"`
+	// OSType describes the device platform, e.g. "macos" or "linux".
+	OSType string `json:"os_type,omitempty"`
+	// PublicKey is the device public key in SSH authorized_keys format.
+	PublicKey []byte `json:"public_key"`
+	// EnrolledAt is when the device was enrolled.
+	EnrolledAt time.Time `json:"enrolled_at"`
+}
+
+// CheckAndSetDefaults validates the device record.
+func (d *TrustedDevice) CheckAndSetDefaults() error {
+	if d.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if d.User == "" {
+		return trace.BadParameter("missing parameter User")
+	}
+	if len(d.PublicKey) == 0 {
+		return trace.BadParameter("missing parameter PublicKey")
+	}
+	if _, _, _, _, err := ssh.ParseAuthorizedKey(d.PublicKey); err != nil {
+		return trace.BadParameter("device public key must be in authorized_keys format: %v", err)
+	}
+	return nil
+}
+
+// EnrollDeviceRequest is a request to enroll a device key for a user.
+type EnrollDeviceRequest struct {
+	// User is the user to enroll the device for, defaults to the user
+	// making the request.
+	User string `json:"user,omitempty"`
+	// Name is a human-friendly device name, unique per user.
+	Name string `json:"name"`
+	// OSType describes the device platform, e.g. "macos" or "linux".
+	OSType string `json:"os_type,omitempty"`
+	// PublicKey is the device public key in SSH authorized_keys format.
+	PublicKey []byte `json:"public_key"`
+}
+
+func trustedDeviceKey(id string) []byte {
+	return backend.Key(trustedDevicePrefix, id)
+}
+
+// EnrollDevice binds a device key to a user and returns the stored device
+// record with its generated ID.
+func (a *Server) EnrollDevice(ctx context.Context, req EnrollDeviceRequest) (*TrustedDevice, error) {
+	device := TrustedDevice{
+		ID:         uuid.New().String(),
+		Name:       req.Name,
+		User:       req.User,
+		OSType:     req.OSType,
+		PublicKey:  req.PublicKey,
+		EnrolledAt: a.clock.Now().UTC(),
+	}
+	if err := device.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := a.Services.GetUser(device.User, false); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	devices, err := a.GetTrustedDevices(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, other := range devices {
+		if other.User == device.User && other.Name == device.Name {
+			return nil, trace.AlreadyExists("user %q already has a device named %q enrolled", device.User, device.Name)
+		}
+	}
+	value, err := utils.FastMarshal(device)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := a.bk.Create(ctx, backend.Item{
+		Key:   trustedDeviceKey(device.ID),
+		Value: value,
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &device, nil
+}
+
+// GetTrustedDevice returns the enrolled device with the given ID.
+func (a *Server) GetTrustedDevice(ctx context.Context, id string) (*TrustedDevice, error) {
+	if id == "" {
+		return nil, trace.BadParameter("missing parameter id")
+	}
+	item, err := a.bk.Get(ctx, trustedDeviceKey(id))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("device %q is not enrolled", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var device TrustedDevice
+	if err := utils.FastUnmarshal(item.Value, &device); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &device, nil
+}
+
+// GetTrustedDevices returns the device inventory of the cluster.
+func (a *Server) GetTrustedDevices(ctx context.Context) ([]TrustedDevice, error) {
+	startKey := backend.Key(trustedDevicePrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	devices := make([]TrustedDevice, 0, len(result.Items))
+	for _, item := range result.Items {
+		var device TrustedDevice
+		if err := utils.FastUnmarshal(item.Value, &device); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// DeleteTrustedDevice removes the enrolled device with the given ID.
+func (a *Server) DeleteTrustedDevice(ctx context.Context, id string) error {
+	if id == "" {
+		return trace.BadParameter("missing parameter id")
+	}
+	if err := a.bk.Delete(ctx, trustedDeviceKey(id)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("device %q is not enrolled", id)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeviceTrustPolicy controls whether user certificates must be requested
+// from an enrolled device.
+type DeviceTrustPolicy struct {
+	// Mode is one of "off", "optional" or "required".
+	Mode string `json:"mode"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *DeviceTrustPolicy) CheckAndSetDefaults() error {
+	if p.Mode == "" {
+		p.Mode = DeviceTrustModeOff
+	}
+	switch p.Mode {
+	case DeviceTrustModeOff, DeviceTrustModeOptional, DeviceTrustModeRequired:
+	default:
+		return trace.BadParameter("unsupported device trust mode %q, must be %q, %q or %q",
+			p.Mode, DeviceTrustModeOff, DeviceTrustModeOptional, DeviceTrustModeRequired)
+	}
+	return nil
+}
+
+// SetDeviceTrustPolicy configures the device trust mode of the cluster.
+func (a *Server) SetDeviceTrustPolicy(ctx context.Context, policy DeviceTrustPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(deviceTrustPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetDeviceTrustPolicy returns the configured device trust mode, falling
+// back to "off" when none was set.
+func (a *Server) GetDeviceTrustPolicy(ctx context.Context) (DeviceTrustPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(deviceTrustPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return DeviceTrustPolicy{Mode: DeviceTrustModeOff}, nil
+		}
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	var policy DeviceTrustPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteDeviceTrustPolicy disables device trust checks.
+func (a *Server) DeleteDeviceTrustPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(deviceTrustPolicyPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("device trust policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// checkDeviceTrust verifies the cert request against the device trust policy
+// of the cluster: in required mode a device enrolled for the user must be
+// presented, and any presented device must belong to the user.
+func (a *Server) checkDeviceTrust(ctx context.Context, req certRequest) error {
+	policy, err := a.GetDeviceTrustPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.Mode == DeviceTrustModeOff {
+		return nil
+	}
+	if req.deviceID == "" {
+		if policy.Mode == DeviceTrustModeRequired {
+			return trace.AccessDenied("this cluster requires an enrolled device to issue certificates")
+		}
+		return nil
+	}
+	device, err := a.GetTrustedDevice(ctx, req.deviceID)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.AccessDenied("device %q is not enrolled", req.deviceID)
+		}
+		return trace.Wrap(err)
+	}
+	if device.User != req.user.GetName() {
+		return trace.AccessDenied("device %q is not enrolled for user %q", req.deviceID, req.user.GetName())
+	}
+	return nil
+}
+
+// EnrollDevice binds a device key to a user and returns the stored device
+// record with its generated ID.
+func (a *ServerWithRoles) EnrollDevice(ctx context.Context, req EnrollDeviceRequest) (*TrustedDevice, error) {
+	if req.User == "" {
+		req.User = a.context.User.GetName()
+	}
+	if req.User == a.context.User.GetName() {
+		if err := a.currentUserAction(req.User); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	} else if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.EnrollDevice(ctx, req)
+}
+
+// GetTrustedDevice returns the enrolled device with the given ID.
+func (a *ServerWithRoles) GetTrustedDevice(ctx context.Context, id string) (*TrustedDevice, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetTrustedDevice(ctx, id)
+}
+
+// GetTrustedDevices returns the device inventory of the cluster.
+func (a *ServerWithRoles) GetTrustedDevices(ctx context.Context) ([]TrustedDevice, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetTrustedDevices(ctx)
+}
+
+// DeleteTrustedDevice removes the enrolled device with the given ID.
+func (a *ServerWithRoles) DeleteTrustedDevice(ctx context.Context, id string) error {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteTrustedDevice(ctx, id)
+}
+
+// SetDeviceTrustPolicy configures the device trust mode of the cluster.
+func (a *ServerWithRoles) SetDeviceTrustPolicy(ctx context.Context, policy DeviceTrustPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetDeviceTrustPolicy(ctx, policy)
+}
+
+// GetDeviceTrustPolicy returns the configured device trust mode, falling
+// back to "off" when none was set.
+func (a *ServerWithRoles) GetDeviceTrustPolicy(ctx context.Context) (DeviceTrustPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return DeviceTrustPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetDeviceTrustPolicy(ctx)
+}
+
+// DeleteDeviceTrustPolicy disables device trust checks.
+func (a *ServerWithRoles) DeleteDeviceTrustPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteDeviceTrustPolicy(ctx)
+}
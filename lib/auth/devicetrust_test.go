@@ -0,0 +1,151 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth/testauthority"
+)
+
+func deviceTestKey(t *testing.T) []byte {
+	t.Helper()
+	priv, err := testauthority.New().GeneratePrivateKey()
+	require.NoError(t, err)
+	return priv.MarshalSSHPublicKey()
+}
+
+func TestTrustedDeviceEnrollment(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "device-owner"
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	// Incomplete or malformed requests are rejected.
+	_, err = s.a.EnrollDevice(ctx, EnrollDeviceRequest{User: user, Name: "laptop"})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	_, err = s.a.EnrollDevice(ctx, EnrollDeviceRequest{
+		User:      user,
+		Name:      "laptop",
+		PublicKey: []byte("not a key"),
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Devices cannot be enrolled for unknown users.
+	_, err = s.a.EnrollDevice(ctx, EnrollDeviceRequest{
+		User:      "no-such-user",
+		Name:      "laptop",
+		PublicKey: deviceTestKey(t),
+	})
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+
+	device, err := s.a.EnrollDevice(ctx, EnrollDeviceRequest{
+		User:      user,
+		Name:      "laptop",
+		OSType:    "macos",
+		PublicKey: deviceTestKey(t),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, device.ID)
+	require.Equal(t, user, device.User)
+
+	// Device names are unique per user.
+	_, err = s.a.EnrollDevice(ctx, EnrollDeviceRequest{
+		User:      user,
+		Name:      "laptop",
+		PublicKey: deviceTestKey(t),
+	})
+	require.True(t, trace.IsAlreadyExists(err), "expected AlreadyExists, got %v", err)
+
+	// The inventory lists the enrolled device and deletion removes it.
+	devices, err := s.a.GetTrustedDevices(ctx)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	require.Equal(t, device.ID, devices[0].ID)
+
+	fetched, err := s.a.GetTrustedDevice(ctx, device.ID)
+	require.NoError(t, err)
+	require.Equal(t, device.Name, fetched.Name)
+
+	require.NoError(t, s.a.DeleteTrustedDevice(ctx, device.ID))
+	_, err = s.a.GetTrustedDevice(ctx, device.ID)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestDeviceTrustEnforcement(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	owner := "device-trust-user"
+	_, _, err := CreateUserAndRole(s.a, owner, []string{owner})
+	require.NoError(t, err)
+	other := "other-user"
+	_, _, err = CreateUserAndRole(s.a, other, []string{other})
+	require.NoError(t, err)
+
+	device, err := s.a.EnrollDevice(ctx, EnrollDeviceRequest{
+		User:      owner,
+		Name:      "workstation",
+		PublicKey: deviceTestKey(t),
+	})
+	require.NoError(t, err)
+
+	user, err := types.NewUser(owner)
+	require.NoError(t, err)
+
+	// With trust off any request passes, device or not.
+	require.NoError(t, s.a.checkDeviceTrust(ctx, certRequest{user: user}))
+
+	require.NoError(t, s.a.SetDeviceTrustPolicy(ctx, DeviceTrustPolicy{Mode: DeviceTrustModeRequired}))
+
+	// Required mode refuses requests without a device and unknown or
+	// foreign devices.
+	err = s.a.checkDeviceTrust(ctx, certRequest{user: user})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	err = s.a.checkDeviceTrust(ctx, certRequest{user: user, deviceID: "unknown-device"})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	otherUser, err := types.NewUser(other)
+	require.NoError(t, err)
+	err = s.a.checkDeviceTrust(ctx, certRequest{user: otherUser, deviceID: device.ID})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// The enrolled owner passes.
+	require.NoError(t, s.a.checkDeviceTrust(ctx, certRequest{user: user, deviceID: device.ID}))
+
+	// Optional mode verifies a presented device but does not demand one.
+	require.NoError(t, s.a.SetDeviceTrustPolicy(ctx, DeviceTrustPolicy{Mode: DeviceTrustModeOptional}))
+	require.NoError(t, s.a.checkDeviceTrust(ctx, certRequest{user: user}))
+	err = s.a.checkDeviceTrust(ctx, certRequest{user: user, deviceID: "unknown-device"})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// Unknown modes are rejected at configuration time.
+	err = s.a.SetDeviceTrustPolicy(ctx, DeviceTrustPolicy{Mode: "mandatory"})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Deleting the policy turns enforcement off again.
+	require.NoError(t, s.a.DeleteDeviceTrustPolicy(ctx))
+	require.NoError(t, s.a.checkDeviceTrust(ctx, certRequest{user: user}))
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// accessEscalationPolicyKey is the backend key the access escalation policy
+// is stored under.
+var accessEscalationPolicyKey = backend.Key("access_escalation_policy")
+
+// System annotations recording the escalation state of an access request.
+const (
+	// EscalationStepAnnotation holds the one-based index of the escalation
+	// step the request has reached.
+	EscalationStepAnnotation = "teleport.dev/escalation-step"
+	// EscalationReviewersAnnotation holds the reviewer roles the request is
+	// currently escalated to.
+	EscalationReviewersAnnotation = "teleport.dev/escalation-reviewers"
+	// EscalationTrailAnnotation accumulates one entry per escalation, so
+	// the full escalation history stays on the request resource.
+	EscalationTrailAnnotation = "teleport.dev/escalation-trail"
+)
+
+// AccessEscalationStep is a single step of the escalation chain: once a
+// pending request has waited for After without a review, it is escalated to
+// the listed reviewer roles.
+type AccessEscalationStep struct {
+	// After is how long a request may stay pending without reviews before
+	// this step fires.
+	After time.Duration `json:"after"`
+	// ReviewerRoles are the roles the request is escalated to.
+	ReviewerRoles []string `json:"reviewer_roles"`
+}
+
+// AccessEscalationPolicy configures timeout-based escalation of unreviewed
+// access requests. Escalating a request updates its system annotations,
+// which re-emits the request to watchers so notification plugins alert the
+// next reviewer group.
+type AccessEscalationPolicy struct {
+	// Steps is the escalation chain, ordered by increasing wait time.
+	Steps []AccessEscalationStep `json:"steps,omitempty"`
+	// UpdatedAt is the time the policy was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the escalation policy.
+func (p *AccessEscalationPolicy) CheckAndSetDefaults() error {
+	var previous time.Duration
+	for i, step := range p.Steps {
+		if step.After <= 0 {
+			return trace.BadParameter("escalation step %v must set a positive wait time", i+1)
+		}
+		if step.After <= previous {
+			return trace.BadParameter("escalation steps must be ordered by increasing wait time")
+		}
+		if len(step.ReviewerRoles) == 0 {
+			return trace.BadParameter("escalation step %v must list at least one reviewer role", i+1)
+		}
+		previous = step.After
+	}
+	return nil
+}
+
+// SetAccessEscalationPolicy updates the cluster access escalation policy.
+func (a *Server) SetAccessEscalationPolicy(ctx context.Context, policy AccessEscalationPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	policy.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   accessEscalationPolicyKey,
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetAccessEscalationPolicy returns the cluster access escalation policy.
+// An empty policy is returned if none was configured.
+func (a *Server) GetAccessEscalationPolicy(ctx context.Context) (*AccessEscalationPolicy, error) {
+	var policy AccessEscalationPolicy
+	item, err := a.bk.Get(ctx, accessEscalationPolicyKey)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return &policy, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// checkAccessRequestEscalations advances pending unreviewed access requests
+// along the escalation chain. It is called from the auth server's periodic
+// operations loop.
+func (a *Server) checkAccessRequestEscalations(ctx context.Context) error {
+	policy, err := a.GetAccessEscalationPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(policy.Steps) == 0 {
+		return nil
+	}
+	reqs, err := a.GetAccessRequests(ctx, types.AccessRequestFilter{
+		State: types.RequestState_PENDING,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	now := a.clock.Now().UTC()
+	for _, req := range reqs {
+		if len(req.GetReviews()) != 0 {
+			continue
+		}
+		age := now.Sub(req.GetCreationTime())
+		due := -1
+		for i, step := range policy.Steps {
+			if age >= step.After {
+				due = i
+			}
+		}
+		if due < 0 || due+1 <= currentEscalationStep(req) {
+			continue
+		}
+		if err := a.escalateAccessRequest(ctx, req, due, policy.Steps[due], now); err != nil {
+			log.Warnf("Failed to escalate access request %v: %v.", req.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// currentEscalationStep returns the one-based escalation step recorded on
+// the request, or zero if it has not been escalated.
+func currentEscalationStep(req types.AccessRequest) int {
+	values := req.GetSystemAnnotations()[EscalationStepAnnotation]
+	if len(values) == 0 {
+		return 0
+	}
+	step, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0
+	}
+	return step
+}
+
+// escalateAccessRequest records the escalation on the request and writes it
+// back, which re-emits the request to watchers and thereby re-notifies
+// reviewers.
+func (a *Server) escalateAccessRequest(ctx context.Context, req types.AccessRequest, step int, escalation AccessEscalationStep, now time.Time) error {
+	annotations := req.GetSystemAnnotations()
+	if annotations == nil {
+		annotations = make(map[string][]string)
+	}
+	annotations[EscalationStepAnnotation] = []string{strconv.Itoa(step + 1)}
+	annotations[EscalationReviewersAnnotation] = escalation.ReviewerRoles
+	annotations[EscalationTrailAnnotation] = append(annotations[EscalationTrailAnnotation],
+		fmt.Sprintf("escalated to %v after %v at %v", strings.Join(escalation.ReviewerRoles, ","), escalation.After, now.Format(time.RFC3339)))
+	req.SetSystemAnnotations(annotations)
+	if err := a.UpsertAccessRequest(ctx, req); err != nil {
+		return trace.Wrap(err)
+	}
+	log.Infof("Access request %v from user %v escalated to reviewers %v after waiting %v without reviews.",
+		req.GetName(), req.GetUser(), escalation.ReviewerRoles, escalation.After)
+	return nil
+}
+
+// SetAccessEscalationPolicy updates the cluster access escalation policy.
+func (a *ServerWithRoles) SetAccessEscalationPolicy(ctx context.Context, policy AccessEscalationPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetAccessEscalationPolicy(ctx, policy)
+}
+
+// GetAccessEscalationPolicy returns the cluster access escalation policy.
+func (a *ServerWithRoles) GetAccessEscalationPolicy(ctx context.Context) (*AccessEscalationPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessEscalationPolicy(ctx)
+}
@@ -388,13 +388,16 @@ func (a *Server) validateGithubAuthCallback(ctx context.Context, diagCtx *ssoDia
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create web session.")
 		}
+		a.recordWebSessionMetadata(ctx, session, events.LoginMethodGithub, nil)
 
 		auth.Session = session
 	}
 
 	// If a public key was provided, sign it and return a certificate.
 	if len(req.PublicKey) != 0 {
-		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, req.PublicKey, req.Compatibility, req.RouteToCluster, req.KubernetesCluster)
+		// GitHub asserts no amr/acr equivalents, so SSO MFA delegation does
+		// not apply here.
+		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, req.PublicKey, req.Compatibility, req.RouteToCluster, req.KubernetesCluster, "")
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create session certificate.")
 		}
@@ -18,6 +18,7 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -79,6 +80,12 @@ var (
 			Buckets: prometheus.LinearBuckets(0, 100, 20),
 		},
 	)
+	watcherEventsSuppressed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: teleport.MetricWatcherEventsSuppressed,
+			Help: "Number of watcher events suppressed because their payload was identical to the previous event for the same resource",
+		},
+	)
 	connectedResources = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: teleport.MetricNamespace,
@@ -358,6 +365,11 @@ func (g *GRPCServer) WatchEvents(watch *proto.Watch, stream proto.AuthService_Wa
 	}
 	defer watcher.Close()
 
+	// during config churn large resources are often re-upserted without any
+	// actual change, there is no point in pushing the identical payload to
+	// the watcher again
+	deduper := eventDeduper{}
+
 	for {
 		select {
 		case <-stream.Context().Done():
@@ -379,6 +391,11 @@ func (g *GRPCServer) WatchEvents(watch *proto.Watch, stream proto.AuthService_Wa
 				return trace.Wrap(err)
 			}
 
+			if deduper.suppress(event, out) {
+				watcherEventsSuppressed.Inc()
+				continue
+			}
+
 			watcherEventsEmitted.WithLabelValues(resourceLabel(event)).Observe(float64(out.Size()))
 			watcherEventSizes.Observe(float64(out.Size()))
 
@@ -389,6 +406,72 @@ func (g *GRPCServer) WatchEvents(watch *proto.Watch, stream proto.AuthService_Wa
 	}
 }
 
+// eventDeduper tracks the payload last sent to a watcher per resource so
+// that puts carrying no changes at all can be dropped. Note that a refreshed
+// expiry or any other field change alters the payload, so only genuinely
+// redundant events are suppressed.
+type eventDeduper struct {
+	seen map[string][sha256.Size]byte
+}
+
+// suppress records the event and reports whether it can be dropped from the
+// stream.
+func (d *eventDeduper) suppress(event types.Event, out *proto.Event) bool {
+	if event.Resource == nil {
+		return false
+	}
+	key := event.Resource.GetKind() + "/" + event.Resource.GetSubKind() + "/" + event.Resource.GetName()
+	if event.Type != types.OpPut {
+		// deletes must always go through and invalidate the dedup state
+		delete(d.seen, key)
+		return false
+	}
+	digest, err := normalizedEventDigest(out)
+	if err != nil {
+		return false
+	}
+	if prev, ok := d.seen[key]; ok && prev == digest {
+		return true
+	}
+	if d.seen == nil {
+		d.seen = make(map[string][sha256.Size]byte)
+	}
+	d.seen[key] = digest
+	return false
+}
+
+// normalizedEventDigest hashes the event payload with the backend resource
+// ID zeroed out, since every write bumps the ID even when nothing else
+// changed. The event is deep copied through a marshal roundtrip so neither
+// the outgoing message nor the cached resource is mutated.
+func normalizedEventDigest(out *proto.Event) ([sha256.Size]byte, error) {
+	data, err := out.Marshal()
+	if err != nil {
+		return [sha256.Size]byte{}, trace.Wrap(err)
+	}
+	var cp proto.Event
+	if err := cp.Unmarshal(data); err != nil {
+		return [sha256.Size]byte{}, trace.Wrap(err)
+	}
+	normalized, err := client.EventFromGRPC(cp)
+	if err != nil {
+		return [sha256.Size]byte{}, trace.Wrap(err)
+	}
+	if normalized.Resource == nil {
+		return [sha256.Size]byte{}, trace.BadParameter("event has no resource")
+	}
+	normalized.Resource.SetResourceID(0)
+	normalizedOut, err := client.EventToGRPC(*normalized)
+	if err != nil {
+		return [sha256.Size]byte{}, trace.Wrap(err)
+	}
+	normalizedData, err := normalizedOut.Marshal()
+	if err != nil {
+		return [sha256.Size]byte{}, trace.Wrap(err)
+	}
+	return sha256.Sum256(normalizedData), nil
+}
+
 // maybeFilterCertAuthorityWatches will add filters to the CertAuthority
 // WatchKinds in the watch if the client is authenticated as just a `Node` with
 // no other roles and if the client is older than the cutoff version, and if the
@@ -2471,8 +2554,12 @@ func validateUserSingleUseCertRequest(ctx context.Context, actx *grpcContext, re
 		if req.RouteToDatabase.ServiceName == "" {
 			return trace.BadParameter("missing ServiceName field in a database-only UserCertsRequest")
 		}
+	case proto.UserCertsRequest_App:
+		if req.RouteToApp.Name == "" {
+			return trace.BadParameter("missing Name field in an app-only UserCertsRequest")
+		}
 	case proto.UserCertsRequest_All:
-		return trace.BadParameter("must specify a concrete Usage in UserCertsRequest, one of SSH, Kubernetes or Database")
+		return trace.BadParameter("must specify a concrete Usage in UserCertsRequest, one of SSH, Kubernetes, Database or App")
 	case proto.UserCertsRequest_WindowsDesktop:
 		if req.RouteToWindowsDesktop.WindowsDesktop == "" {
 			return trace.BadParameter("missing WindowsDesktop field in a windows-desktop-only UserCertsRequest")
@@ -2542,7 +2629,7 @@ func userSingleUseCertsGenerate(ctx context.Context, actx *grpcContext, req prot
 	switch req.Usage {
 	case proto.UserCertsRequest_SSH:
 		resp.Cert = &proto.SingleUseUserCert_SSH{SSH: certs.SSH}
-	case proto.UserCertsRequest_Kubernetes, proto.UserCertsRequest_Database, proto.UserCertsRequest_WindowsDesktop:
+	case proto.UserCertsRequest_Kubernetes, proto.UserCertsRequest_Database, proto.UserCertsRequest_App, proto.UserCertsRequest_WindowsDesktop:
 		resp.Cert = &proto.SingleUseUserCert_TLS{TLS: certs.TLS}
 	default:
 		return nil, trace.BadParameter("unknown certificate usage %q", req.Usage)
@@ -4327,7 +4414,7 @@ func (cfg *GRPCServerConfig) CheckAndSetDefaults() error {
 
 // NewGRPCServer returns a new instance of GRPC server
 func NewGRPCServer(cfg GRPCServerConfig) (*GRPCServer, error) {
-	err := metrics.RegisterPrometheusCollectors(heartbeatConnectionsReceived, watcherEventsEmitted, watcherEventSizes, connectedResources)
+	err := metrics.RegisterPrometheusCollectors(heartbeatConnectionsReceived, watcherEventsEmitted, watcherEventSizes, watcherEventsSuppressed, connectedResources)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
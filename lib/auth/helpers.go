@@ -437,6 +437,7 @@ func generateCertificate(authServer *Server, identity TestIdentity) ([]byte, []b
 			traits:         user.GetTraits(),
 			renewable:      identity.Renewable,
 			generation:     identity.Generation,
+			mfaVerified:    identity.MFAVerified,
 		})
 		if err != nil {
 			return nil, nil, trace.Wrap(err)
@@ -684,6 +685,7 @@ type TestIdentity struct {
 	RouteToCluster string
 	Renewable      bool
 	Generation     uint64
+	MFAVerified    string
 }
 
 // TestUser returns TestIdentity for local user
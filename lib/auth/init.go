@@ -82,6 +82,11 @@ type InitConfig struct {
 	// bootstrap backend on first start.
 	Resources []types.Resource
 
+	// BootstrapManifestResources is the subset of Resources that came from a
+	// signed bootstrap manifest and is checked for drift against the backend
+	// when the cluster was previously initialized.
+	BootstrapManifestResources []types.Resource
+
 	// AuthServiceName is a human-readable name of this CA. If several Auth services are running
 	// (managing multiple teleport clusters) this field is used to tell them apart in UIs
 	// It usually defaults to the hostname of the machine the Auth service runs on.
@@ -186,6 +191,10 @@ type InitConfig struct {
 	// TraceClient is used to forward spans to the upstream telemetry collector
 	TraceClient otlptrace.Client
 
+	// HardwareKeyAttestationCAs is a list of PEM-encoded CA certificates
+	// trusted to attest that a private key resides on a hardware token.
+	HardwareKeyAttestationCAs [][]byte
+
 	// AssertionReplayService is a service that mitigatates SSO assertion replay.
 	*local.AssertionReplayService
 }
@@ -229,6 +238,10 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 			if err := local.CreateResources(ctx, cfg.Backend, cfg.Resources...); err != nil {
 				return nil, trace.Wrap(err, "backend bootstrap failed")
 			}
+		} else if len(cfg.BootstrapManifestResources) > 0 {
+			if err := reportBootstrapDrift(ctx, asrv, cfg.BootstrapManifestResources); err != nil {
+				return nil, trace.Wrap(err)
+			}
 		} else {
 			log.Warnf("Ignoring %v bootstrap resources (previously initialized)", len(cfg.Resources))
 		}
@@ -416,6 +429,18 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// Run preflight checks and surface anything unhealthy (slow backend,
+	// dangling rotations) early. Failed checks are not fatal.
+	if report, err := asrv.RunPreflightChecks(ctx); err != nil {
+		log.WithError(err).Warn("Failed to run preflight checks.")
+	} else {
+		for _, result := range report.Results {
+			if !result.Passed {
+				log.Warnf("Preflight check %q failed: %v.", result.Name, result.Details)
+			}
+		}
+	}
+
 	if !cfg.SkipPeriodicOperations {
 		log.Infof("Auth server is running periodic operations.")
 		go asrv.runPeriodicOperations()
@@ -559,6 +584,57 @@ func isFirstStart(ctx context.Context, authServer *Server, cfg InitConfig) (bool
 	return false, nil
 }
 
+// bootstrapDriftAlertName is the cluster alert raised when backend state no
+// longer matches the bootstrap manifest.
+const bootstrapDriftAlertName = "bootstrap-manifest-drift"
+
+// reportBootstrapDrift compares the resources from a signed bootstrap
+// manifest against the current backend state and surfaces any divergence as
+// log warnings and a cluster alert, so clusters stamped out from code can
+// detect manual edits.
+func reportBootstrapDrift(ctx context.Context, asrv *Server, resources []types.Resource) error {
+	var drifted []string
+	for _, rsc := range resources {
+		items, err := local.ItemsFromResources(rsc)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// only the primary item participates in drift detection; extra
+		// items carry user secrets which are expected to change
+		current, err := asrv.bk.Get(ctx, items[0].Key)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return trace.Wrap(err)
+			}
+			drifted = append(drifted, fmt.Sprintf("%v %q is missing", rsc.GetKind(), rsc.GetName()))
+			continue
+		}
+		stored, err := local.ItemsToResources(*current)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(stored) != 1 || services.CompareResources(stored[0], rsc) != services.Equal {
+			drifted = append(drifted, fmt.Sprintf("%v %q differs from the manifest", rsc.GetKind(), rsc.GetName()))
+		}
+	}
+	if len(drifted) == 0 {
+		log.Debugf("Backend state matches the bootstrap manifest.")
+		return nil
+	}
+	for _, description := range drifted {
+		log.Warnf("Bootstrap manifest drift: %v.", description)
+	}
+	alert, err := types.NewClusterAlert(
+		bootstrapDriftAlertName,
+		fmt.Sprintf("%v resources have drifted from the bootstrap manifest, check the auth server logs for details", len(drifted)),
+		types.WithAlertSeverity(types.AlertSeverity_MEDIUM),
+	)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(asrv.UpsertClusterAlert(ctx, alert))
+}
+
 // checkResourceConsistency checks far basic conflicting state issues.
 func checkResourceConsistency(keyStore keystore.KeyStore, clusterName string, resources ...types.Resource) error {
 	for _, rsc := range resources {
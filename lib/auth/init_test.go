@@ -33,6 +33,7 @@ import (
 	"github.com/gravitational/teleport/lib/backend/lite"
 	"github.com/gravitational/teleport/lib/fixtures"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
 	"github.com/gravitational/teleport/lib/services/suite"
 	"github.com/gravitational/teleport/lib/sshutils"
 	"github.com/gravitational/teleport/lib/utils"
@@ -1021,3 +1022,43 @@ func TestRotateDuplicatedCerts(t *testing.T) {
 	require.NotEqual(t, newHostCA.GetActiveKeys().TLS, newDatabaseCA.GetActiveKeys().TLS)
 	require.NotEqual(t, newHostCA.GetActiveKeys().SSH, newDatabaseCA.GetActiveKeys().SSH)
 }
+
+func TestReportBootstrapDrift(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	role, err := types.NewRole("drift-role", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Logins: []string{"root"},
+		},
+	})
+	require.NoError(t, err)
+	manifest := []types.Resource{role}
+	require.NoError(t, local.CreateResources(ctx, s.bk, role))
+
+	// backend matches the manifest, no alert expected
+	require.NoError(t, reportBootstrapDrift(ctx, s.a, manifest))
+	alerts, err := s.a.GetClusterAlerts(ctx, types.GetClusterAlertsRequest{AlertID: bootstrapDriftAlertName})
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	// a manual edit to a manifest-managed resource raises the alert
+	role.SetLogins(types.Allow, []string{"root", "admin"})
+	require.NoError(t, s.a.UpsertRole(ctx, role))
+	pristine, err := types.NewRole("drift-role", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Logins: []string{"root"},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, reportBootstrapDrift(ctx, s.a, []types.Resource{pristine}))
+	alerts, err = s.a.GetClusterAlerts(ctx, types.GetClusterAlertsRequest{AlertID: bootstrapDriftAlertName})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	// a missing resource counts as drift as well
+	missing, err := types.NewRole("gone-role", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, reportBootstrapDrift(ctx, s.a, []types.Resource{missing}))
+}
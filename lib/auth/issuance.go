@@ -0,0 +1,114 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/tlsca"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// CertIssuance is a single entry of the user certificate issuance log. It
+// describes a certificate that was issued and is possibly still valid, so
+// security teams can answer "what certificates exist for user X right now".
+type CertIssuance struct {
+	// Serial is the serial number of the issued TLS certificate.
+	Serial string `json:"serial"`
+	// Username is the Teleport user the certificate was issued for.
+	Username string `json:"username"`
+	// Roles are the roles encoded in the certificate.
+	Roles []string `json:"roles"`
+	// Impersonator is set when the certificate was issued on behalf of
+	// another user.
+	Impersonator string `json:"impersonator,omitempty"`
+	// ClientIP is the IP of the client that requested the certificate,
+	// when known.
+	ClientIP string `json:"client_ip,omitempty"`
+	// Usage lists the usage restrictions encoded in the certificate.
+	Usage []string `json:"usage,omitempty"`
+	// IssuedAt is when the certificate was issued.
+	IssuedAt time.Time `json:"issued_at"`
+	// NotAfter is when the certificate expires.
+	NotAfter time.Time `json:"not_after"`
+}
+
+// recordCertIssuance appends an entry to the issuance log. Entries are keyed
+// by serial number and kept until well after the certificate expires, so the
+// log remains queryable for the certificate's whole lifetime.
+func (a *Server) recordCertIssuance(ctx context.Context, tlsCertPEM []byte, identity tlsca.Identity, notAfter time.Time) error {
+	cert, err := tlsca.ParseCertificatePEM(tlsCertPEM)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	issuance := CertIssuance{
+		Serial:       cert.SerialNumber.String(),
+		Username:     identity.Username,
+		Roles:        identity.Groups,
+		Impersonator: identity.Impersonator,
+		ClientIP:     identity.ClientIP,
+		Usage:        identity.Usage,
+		IssuedAt:     a.clock.Now().UTC(),
+		NotAfter:     notAfter.UTC(),
+	}
+	value, err := utils.FastMarshal(&issuance)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the log is append-only: issuance entries are only ever created, never
+	// updated, and age out on their own
+	_, err = a.bk.Create(ctx, backend.Item{
+		Key:     backend.Key(certIssuancePrefix, identity.Username, issuance.Serial),
+		Value:   value,
+		Expires: notAfter.Add(certIssuanceRetention).UTC(),
+	})
+	return trace.Wrap(err)
+}
+
+// GetCertIssuances returns the issuance log entries for a user. Expired
+// entries age out of the backend shortly after the certificates they
+// describe expire.
+func (a *Server) GetCertIssuances(ctx context.Context, username string) ([]CertIssuance, error) {
+	if username == "" {
+		return nil, trace.BadParameter("missing parameter username")
+	}
+	startKey := backend.Key(certIssuancePrefix, username)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]CertIssuance, 0, len(result.Items))
+	for _, item := range result.Items {
+		var issuance CertIssuance
+		if err := utils.FastUnmarshal(item.Value, &issuance); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, issuance)
+	}
+	return out, nil
+}
+
+const (
+	certIssuancePrefix = "cert_issuance"
+	// certIssuanceRetention keeps issuance entries queryable for a while
+	// after the certificate has expired.
+	certIssuanceRetention = time.Hour
+)
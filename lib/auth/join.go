@@ -19,12 +19,15 @@ package auth
 import (
 	"context"
 	"fmt"
+	"net"
+	"regexp"
 	"strings"
 
 	"github.com/gravitational/teleport/api/client/proto"
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/trace"
 )
 
@@ -78,6 +81,18 @@ func (a *Server) checkTokenJoinRequestCommon(ctx context.Context, req *types.Reg
 		return nil, trace.BadParameter(msg)
 	}
 
+	// make sure the request satisfies the token's environment rules
+	if err := checkTokenEnvironmentRules(provisionToken, req); err != nil {
+		log.Warnf("Node %q [%v] failed the token environment rules: %v.", req.NodeName, req.HostID, err)
+		return nil, trace.Wrap(err)
+	}
+
+	// make sure the token's usage limit has not been reached
+	if err := a.checkTokenUsageLimit(ctx, provisionToken, req); err != nil {
+		log.Warnf("Node %q [%v] rejected by the token usage limit: %v.", req.NodeName, req.HostID, err)
+		return nil, trace.Wrap(err)
+	}
+
 	return provisionToken, nil
 }
 
@@ -98,6 +113,14 @@ func (a *Server) RegisterUsingToken(ctx context.Context, req *types.RegisterUsin
 		return nil, trace.Wrap(err)
 	}
 
+	// Clients normally unwrap compound tokens before dialing, but accept the
+	// compound form here too so older joining tools keep working with it.
+	if compound, err := DecodeCompoundToken(req.Token); err != nil {
+		return nil, trace.Wrap(err)
+	} else if compound != nil {
+		req.Token = compound.Token
+	}
+
 	switch a.tokenJoinMethod(ctx, req.Token) {
 	case types.JoinMethodEC2:
 		if err := a.checkEC2JoinRequest(ctx, req); err != nil {
@@ -108,6 +131,31 @@ func (a *Server) RegisterUsingToken(ctx context.Context, req *types.RegisterUsin
 		return nil, trace.AccessDenied("this token is only valid for the IAM " +
 			"join method but the node has connected to the wrong endpoint, make " +
 			"sure your node is configured to use the IAM join method")
+	case types.JoinMethodGCP:
+		// GCP join method must use RegisterUsingGCPMethod
+		return nil, trace.AccessDenied("this token is only valid for the GCP " +
+			"join method but the node has connected to the wrong endpoint, make " +
+			"sure your node is configured to use the GCP join method")
+	case types.JoinMethodKubernetes:
+		// Kubernetes join method must use RegisterUsingKubernetesMethod
+		return nil, trace.AccessDenied("this token is only valid for the Kubernetes " +
+			"join method but the node has connected to the wrong endpoint, make " +
+			"sure your node is configured to use the Kubernetes join method")
+	case types.JoinMethodGitLab:
+		// GitLab join method must use RegisterUsingGitLabMethod
+		return nil, trace.AccessDenied("this token is only valid for the GitLab " +
+			"join method but the node has connected to the wrong endpoint, make " +
+			"sure your node is configured to use the GitLab join method")
+	case types.JoinMethodCircleCI:
+		// CircleCI join method must use RegisterUsingCircleCIMethod
+		return nil, trace.AccessDenied("this token is only valid for the CircleCI " +
+			"join method but the node has connected to the wrong endpoint, make " +
+			"sure your node is configured to use the CircleCI join method")
+	case types.JoinMethodSPIFFE:
+		// SPIFFE join method must use RegisterUsingSPIFFEMethod
+		return nil, trace.AccessDenied("this token is only valid for the SPIFFE " +
+			"join method but the node has connected to the wrong endpoint, make " +
+			"sure your node is configured to use the SPIFFE join method")
 	case types.JoinMethodToken:
 		// carry on to common token checking logic
 	default:
@@ -167,6 +215,10 @@ func (a *Server) generateCerts(ctx context.Context, provisionToken types.Provisi
 			return nil, trace.BadParameter("unsupported join method %q for bot", joinMethod)
 		}
 
+		if err := a.recordTokenUse(ctx, provisionToken, req); err != nil {
+			log.WithError(err).Warn("Could not record the provision token use.")
+		}
+
 		log.Infof("Bot %q has joined the cluster.", botName)
 		return certs, nil
 	}
@@ -184,6 +236,12 @@ func (a *Server) generateCerts(ctx context.Context, provisionToken types.Provisi
 		}
 	}
 
+	// make sure the token allows the requested principals and DNS names
+	if err := checkTokenPrincipalRules(provisionToken, req); err != nil {
+		log.Warnf("Node %q [%v] requested principals not allowed by token: %v.", req.NodeName, req.HostID, err)
+		return nil, trace.Wrap(err)
+	}
+
 	// generate and return host certificate and keys
 	certs, err := a.GenerateHostCerts(ctx,
 		&proto.HostCertsRequest{
@@ -200,6 +258,119 @@ func (a *Server) generateCerts(ctx context.Context, provisionToken types.Provisi
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := a.recordTokenUse(ctx, provisionToken, req); err != nil {
+		log.WithError(err).Warn("Could not record the provision token use.")
+	}
+	if err := a.stampSuggestedLabels(ctx, provisionToken, req); err != nil {
+		log.WithError(err).Warnf("Could not stamp token-suggested labels onto node %q [%v].", req.NodeName, req.HostID)
+	}
+
 	log.Infof("Node %q [%v] has joined the cluster.", req.NodeName, req.HostID)
 	return certs, nil
 }
+
+// Token metadata labels restricting what host certificates a joining node may
+// request. Values are comma-separated glob patterns; tokens without the
+// labels impose no restriction.
+const (
+	// TokenPrincipalsLabel restricts the additional principals a node
+	// joining with the token may request.
+	TokenPrincipalsLabel = "teleport.dev/allow-principals"
+	// TokenDNSNamesLabel restricts the DNS names a node joining with the
+	// token may request.
+	TokenDNSNamesLabel = "teleport.dev/allow-dns-names"
+)
+
+// Token metadata labels restricting what environments a token may be used
+// from. They apply to every join method and tokens without the labels
+// impose no restriction.
+const (
+	// TokenSourceCIDRsLabel is a comma-separated list of CIDRs join
+	// requests must originate from.
+	TokenSourceCIDRsLabel = "teleport.dev/allow-source-cidrs"
+	// TokenHostnamesLabel is a comma-separated list of glob patterns the
+	// joining node's name must match.
+	TokenHostnamesLabel = "teleport.dev/allow-hostnames"
+)
+
+// checkTokenEnvironmentRules enforces the token's source CIDR and hostname
+// rules on a join request, so a leaked token can not be used from
+// unexpected environments.
+func checkTokenEnvironmentRules(provisionToken types.ProvisionToken, req *types.RegisterUsingTokenRequest) error {
+	labels := provisionToken.GetMetadata().Labels
+	if cidrs := labels[TokenSourceCIDRsLabel]; cidrs != "" {
+		if err := checkAddrAgainstCIDRs(cidrs, req.RemoteAddr); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if hostnames := labels[TokenHostnamesLabel]; hostnames != "" {
+		if err := checkPrincipalsAgainstRules(hostnames, []string{req.NodeName}, "node name"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// checkAddrAgainstCIDRs checks that addr is an address within one of the
+// comma-separated CIDR ranges.
+func checkAddrAgainstCIDRs(cidrs, addr string) error {
+	host, err := utils.Host(addr)
+	if err != nil {
+		return trace.AccessDenied("could not determine the join request source address: %v", err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return trace.AccessDenied("join request source address %q is not a valid IP address", host)
+	}
+	for _, cidr := range strings.Split(cidrs, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return trace.BadParameter("invalid CIDR %q in token rules: %v", cidr, err)
+		}
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return trace.AccessDenied("join request source address %q is not in the CIDR ranges allowed by the token", host)
+}
+
+// checkTokenPrincipalRules enforces the token's principal and DNS name allow
+// rules on a host join request, so a leaked node token can not be used to
+// mint host certificates for arbitrary hostnames.
+func checkTokenPrincipalRules(provisionToken types.ProvisionToken, req *types.RegisterUsingTokenRequest) error {
+	labels := provisionToken.GetMetadata().Labels
+	if err := checkPrincipalsAgainstRules(labels[TokenPrincipalsLabel], req.AdditionalPrincipals, "principal"); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := checkPrincipalsAgainstRules(labels[TokenDNSNamesLabel], req.DNSNames, "DNS name"); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+func checkPrincipalsAgainstRules(rules string, principals []string, kind string) error {
+	if rules == "" {
+		return nil
+	}
+	var expressions []*regexp.Regexp
+	for _, pattern := range strings.Split(rules, ",") {
+		expression, err := regexp.Compile("^" + utils.GlobToRegexp(strings.TrimSpace(pattern)) + "$")
+		if err != nil {
+			return trace.BadParameter("invalid %v pattern %q in token rules: %v", kind, pattern, err)
+		}
+		expressions = append(expressions, expression)
+	}
+	for _, principal := range principals {
+		allowed := false
+		for _, expression := range expressions {
+			if expression.MatchString(principal) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return trace.AccessDenied("%v %q is not allowed by the join token rules", kind, principal)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// Token metadata labels configuring the CircleCI join method.
+const (
+	// CircleCIOrganizationIDLabel is the ID of the CircleCI organization
+	// jobs may join from. Required for tokens using the CircleCI join
+	// method; it determines the OIDC issuer the ID token is verified
+	// against.
+	CircleCIOrganizationIDLabel = "teleport.dev/circleci-organization-id"
+	// CircleCIProjectIDsLabel lists the project IDs jobs may join from as
+	// comma-separated glob patterns. Empty means any project in the
+	// organization.
+	CircleCIProjectIDsLabel = "teleport.dev/circleci-project-ids"
+)
+
+// circleCIIssuerURL returns the OIDC issuer CircleCI uses for the given
+// organization.
+func circleCIIssuerURL(organizationID string) string {
+	return "https://oidc.circleci.com/org/" + organizationID
+}
+
+// circleCIIdentity holds the claims of a CircleCI OIDC token that are
+// relevant for joining.
+type circleCIIdentity struct {
+	// ProjectID is the ID of the project running the job, from the
+	// oidc.circleci.com/project-id claim.
+	ProjectID string `json:"oidc.circleci.com/project-id"`
+	// ContextIDs lists the CircleCI contexts attached to the job.
+	ContextIDs []string `json:"oidc.circleci.com/context-ids"`
+}
+
+// circleCIJWKSGetter fetches the key set CircleCI OIDC tokens are verified
+// against, overridable in tests.
+var circleCIJWKSGetter = fetchCircleCIJWKS
+
+// fetchCircleCIJWKS downloads the signing keys of the given CircleCI
+// organization issuer.
+func fetchCircleCIJWKS(ctx context.Context, issuerURL string) (*jose.JSONWebKeySet, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, issuerURL+"/.well-known/jwks-pub.json", nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("unexpected status %q fetching CircleCI signing keys", resp.Status)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &jwks, nil
+}
+
+// verifyCircleCIIDToken verifies the signature and standard claims of a
+// CircleCI OIDC token and returns the embedded identity. CircleCI sets both
+// the issuer path and the audience to the organization ID.
+func (a *Server) verifyCircleCIIDToken(ctx context.Context, organizationID, idToken string) (*circleCIIdentity, error) {
+	parsed, err := josejwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, trace.AccessDenied("failed to parse CircleCI OIDC token: %v", err)
+	}
+	issuerURL := circleCIIssuerURL(organizationID)
+	jwks, err := circleCIJWKSGetter(ctx, issuerURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var claims josejwt.Claims
+	var identity circleCIIdentity
+	verified := false
+	for i := range jwks.Keys {
+		if err := parsed.Claims(jwks.Keys[i].Key, &claims, &identity); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, trace.AccessDenied("CircleCI OIDC token is not signed by any of the organization's current keys")
+	}
+
+	if err := claims.Validate(josejwt.Expected{
+		Issuer:   issuerURL,
+		Audience: josejwt.Audience{organizationID},
+		Time:     a.clock.Now(),
+	}); err != nil {
+		return nil, trace.AccessDenied("CircleCI OIDC token claims are not valid: %v", err)
+	}
+	if identity.ProjectID == "" {
+		return nil, trace.AccessDenied("CircleCI OIDC token does not include a project-id claim")
+	}
+	return &identity, nil
+}
+
+// checkCircleCIAllowRules checks the verified job identity against the
+// project rules on the provision token.
+func checkCircleCIAllowRules(identity *circleCIIdentity, provisionToken types.ProvisionToken) error {
+	labels := provisionToken.GetMetadata().Labels
+	if projectIDs := labels[CircleCIProjectIDsLabel]; projectIDs != "" {
+		if err := checkPrincipalsAgainstRules(projectIDs, []string{identity.ProjectID}, "CircleCI project"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// RegisterUsingCircleCIMethod registers the caller using the CircleCI join
+// method and returns signed certs to join the cluster. The caller must
+// present an OIDC token issued to a CircleCI job of the organization named
+// on the provision token.
+func (a *Server) RegisterUsingCircleCIMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	log.Infof("Node %q [%v] is attempting to join with the CircleCI method.", req.NodeName, req.HostID)
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	provisionToken, err := a.checkTokenJoinRequestCommon(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if provisionToken.GetJoinMethod() != types.JoinMethodCircleCI {
+		return nil, trace.AccessDenied("this token does not support the CircleCI join method")
+	}
+	organizationID := provisionToken.GetMetadata().Labels[CircleCIOrganizationIDLabel]
+	if organizationID == "" {
+		return nil, trace.AccessDenied("token %q does not specify a CircleCI organization ID", provisionToken.GetName())
+	}
+
+	identity, err := a.verifyCircleCIIDToken(ctx, organizationID, idToken)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := checkCircleCIAllowRules(identity, provisionToken); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	certs, err := a.generateCerts(ctx, provisionToken, req)
+	return certs, trace.Wrap(err)
+}
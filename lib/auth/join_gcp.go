@@ -0,0 +1,180 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// Token metadata labels configuring the GCP join method. Values are
+// comma-separated glob patterns.
+const (
+	// GCPProjectsLabel lists the GCP projects instances may join from.
+	// Required for tokens using the GCP join method.
+	GCPProjectsLabel = "teleport.dev/gcp-projects"
+	// GCPServiceAccountsLabel lists the service account emails instances may
+	// join with. Empty means any service account in an allowed project.
+	GCPServiceAccountsLabel = "teleport.dev/gcp-service-accounts"
+)
+
+const (
+	// gcpIssuer is the expected issuer of GCP instance identity tokens.
+	gcpIssuer = "https://accounts.google.com"
+	// gcpJWKSURL serves the keys Google signs identity tokens with.
+	gcpJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// gcpIdentity holds the claims of a GCP instance identity token that are
+// relevant for joining.
+type gcpIdentity struct {
+	// Email is the email of the service account the instance runs as.
+	Email string `json:"email"`
+	// Google holds the GCE-specific claims, present when the token was
+	// requested with format=full on the instance metadata server.
+	Google struct {
+		ComputeEngine struct {
+			ProjectID    string `json:"project_id"`
+			Zone         string `json:"zone"`
+			InstanceID   string `json:"instance_id"`
+			InstanceName string `json:"instance_name"`
+		} `json:"compute_engine"`
+	} `json:"google"`
+}
+
+// gcpJWKSGetter fetches the key set GCP identity tokens are verified
+// against, overridable in tests.
+var gcpJWKSGetter = fetchGCPJWKS
+
+// fetchGCPJWKS downloads Google's current signing keys.
+func fetchGCPJWKS(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpJWKSURL, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("unexpected status %q fetching GCP signing keys", resp.Status)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &jwks, nil
+}
+
+// verifyGCPIdentityToken verifies the signature and standard claims of a GCP
+// instance identity token and returns the embedded identity.
+func (a *Server) verifyGCPIdentityToken(ctx context.Context, idToken string) (*gcpIdentity, error) {
+	parsed, err := josejwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, trace.AccessDenied("failed to parse GCP identity token: %v", err)
+	}
+	jwks, err := gcpJWKSGetter(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var claims josejwt.Claims
+	var identity gcpIdentity
+	verified := false
+	for i := range jwks.Keys {
+		if err := parsed.Claims(jwks.Keys[i].Key, &claims, &identity); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, trace.AccessDenied("GCP identity token is not signed by any of Google's current keys")
+	}
+
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := claims.Validate(josejwt.Expected{
+		Issuer:   gcpIssuer,
+		Audience: josejwt.Audience{clusterName.GetClusterName()},
+		Time:     a.clock.Now(),
+	}); err != nil {
+		return nil, trace.AccessDenied("GCP identity token claims are not valid: %v", err)
+	}
+	if identity.Google.ComputeEngine.ProjectID == "" {
+		return nil, trace.AccessDenied("GCP identity token does not include compute engine claims, request the token with format=full")
+	}
+	return &identity, nil
+}
+
+// checkGCPAllowRules checks the verified instance identity against the
+// project and service account rules on the provision token.
+func checkGCPAllowRules(identity *gcpIdentity, provisionToken types.ProvisionToken) error {
+	labels := provisionToken.GetMetadata().Labels
+	projects := labels[GCPProjectsLabel]
+	if projects == "" {
+		return trace.AccessDenied("token %q does not specify any allowed GCP projects", provisionToken.GetName())
+	}
+	if err := checkPrincipalsAgainstRules(projects, []string{identity.Google.ComputeEngine.ProjectID}, "GCP project"); err != nil {
+		return trace.Wrap(err)
+	}
+	if serviceAccounts := labels[GCPServiceAccountsLabel]; serviceAccounts != "" {
+		if err := checkPrincipalsAgainstRules(serviceAccounts, []string{identity.Email}, "GCP service account"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// RegisterUsingGCPMethod registers the caller using the GCP join method and
+// returns signed certs to join the cluster. The caller must present an
+// instance identity token signed by Google with the cluster name as the
+// audience.
+func (a *Server) RegisterUsingGCPMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	log.Infof("Node %q [%v] is attempting to join with the GCP method.", req.NodeName, req.HostID)
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	provisionToken, err := a.checkTokenJoinRequestCommon(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if provisionToken.GetJoinMethod() != types.JoinMethodGCP {
+		return nil, trace.AccessDenied("this token does not support the GCP join method")
+	}
+
+	identity, err := a.verifyGCPIdentityToken(ctx, idToken)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := checkGCPAllowRules(identity, provisionToken); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	certs, err := a.generateCerts(ctx, provisionToken, req)
+	return certs, trace.Wrap(err)
+}
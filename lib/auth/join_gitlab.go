@@ -0,0 +1,202 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// Token metadata labels configuring the GitLab join method. Values are
+// comma-separated glob patterns unless noted otherwise.
+const (
+	// GitLabInstanceURLLabel is the base URL of the GitLab instance that
+	// issues CI ID tokens, e.g. "https://gitlab.example.com". Defaults to
+	// gitlab.com.
+	GitLabInstanceURLLabel = "teleport.dev/gitlab-instance-url"
+	// GitLabProjectPathsLabel lists the project paths (e.g. "group/project")
+	// CI jobs may join from. Required for tokens using the GitLab join
+	// method.
+	GitLabProjectPathsLabel = "teleport.dev/gitlab-project-paths"
+	// GitLabRefsLabel lists the git refs CI jobs may join from. Empty means
+	// any ref in an allowed project.
+	GitLabRefsLabel = "teleport.dev/gitlab-refs"
+	// GitLabEnvironmentsLabel lists the CI environments jobs may join from.
+	// Empty means the environment claim is not checked.
+	GitLabEnvironmentsLabel = "teleport.dev/gitlab-environments"
+)
+
+// defaultGitLabInstanceURL is used when the token does not name a
+// self-hosted GitLab instance.
+const defaultGitLabInstanceURL = "https://gitlab.com"
+
+// gitlabIdentity holds the claims of a GitLab CI ID token that are relevant
+// for joining.
+type gitlabIdentity struct {
+	// ProjectPath is the full path of the project running the job.
+	ProjectPath string `json:"project_path"`
+	// Ref is the git ref the job runs against.
+	Ref string `json:"ref"`
+	// RefType is the type of the ref, e.g. "branch" or "tag".
+	RefType string `json:"ref_type"`
+	// Environment is the CI environment the job targets, if any.
+	Environment string `json:"environment"`
+	// PipelineSource is what triggered the pipeline, e.g. "push".
+	PipelineSource string `json:"pipeline_source"`
+}
+
+// gitlabJWKSGetter fetches the key set GitLab CI ID tokens are verified
+// against, overridable in tests.
+var gitlabJWKSGetter = fetchGitLabJWKS
+
+// fetchGitLabJWKS downloads the signing keys of the given GitLab instance.
+func fetchGitLabJWKS(ctx context.Context, instanceURL string) (*jose.JSONWebKeySet, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, instanceURL+"/-/jwks", nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("unexpected status %q fetching GitLab signing keys", resp.Status)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &jwks, nil
+}
+
+// gitlabInstanceURL returns the normalized GitLab instance URL configured on
+// the provision token.
+func gitlabInstanceURL(provisionToken types.ProvisionToken) string {
+	instanceURL := provisionToken.GetMetadata().Labels[GitLabInstanceURLLabel]
+	if instanceURL == "" {
+		return defaultGitLabInstanceURL
+	}
+	if !strings.Contains(instanceURL, "://") {
+		instanceURL = "https://" + instanceURL
+	}
+	return strings.TrimSuffix(instanceURL, "/")
+}
+
+// verifyGitLabIDToken verifies the signature and standard claims of a GitLab
+// CI ID token against the instance's JWKS and returns the embedded identity.
+func (a *Server) verifyGitLabIDToken(ctx context.Context, instanceURL, idToken string) (*gitlabIdentity, error) {
+	parsed, err := josejwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, trace.AccessDenied("failed to parse GitLab ID token: %v", err)
+	}
+	jwks, err := gitlabJWKSGetter(ctx, instanceURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var claims josejwt.Claims
+	var identity gitlabIdentity
+	verified := false
+	for i := range jwks.Keys {
+		if err := parsed.Claims(jwks.Keys[i].Key, &claims, &identity); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, trace.AccessDenied("GitLab ID token is not signed by any of the instance's current keys")
+	}
+
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := claims.Validate(josejwt.Expected{
+		Issuer:   instanceURL,
+		Audience: josejwt.Audience{clusterName.GetClusterName()},
+		Time:     a.clock.Now(),
+	}); err != nil {
+		return nil, trace.AccessDenied("GitLab ID token claims are not valid: %v", err)
+	}
+	if identity.ProjectPath == "" {
+		return nil, trace.AccessDenied("GitLab ID token does not include a project_path claim")
+	}
+	return &identity, nil
+}
+
+// checkGitLabAllowRules checks the verified CI job identity against the
+// project path, ref, and environment rules on the provision token.
+func checkGitLabAllowRules(identity *gitlabIdentity, provisionToken types.ProvisionToken) error {
+	labels := provisionToken.GetMetadata().Labels
+	projectPaths := labels[GitLabProjectPathsLabel]
+	if projectPaths == "" {
+		return trace.AccessDenied("token %q does not specify any allowed GitLab project paths", provisionToken.GetName())
+	}
+	if err := checkPrincipalsAgainstRules(projectPaths, []string{identity.ProjectPath}, "GitLab project path"); err != nil {
+		return trace.Wrap(err)
+	}
+	if refs := labels[GitLabRefsLabel]; refs != "" {
+		if err := checkPrincipalsAgainstRules(refs, []string{identity.Ref}, "GitLab ref"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if environments := labels[GitLabEnvironmentsLabel]; environments != "" {
+		if err := checkPrincipalsAgainstRules(environments, []string{identity.Environment}, "GitLab environment"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// RegisterUsingGitLabMethod registers the caller using the GitLab join
+// method and returns signed certs to join the cluster. The caller must
+// present a CI ID token issued by the GitLab instance named on the
+// provision token, with the cluster name as the audience.
+func (a *Server) RegisterUsingGitLabMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	log.Infof("Node %q [%v] is attempting to join with the GitLab method.", req.NodeName, req.HostID)
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	provisionToken, err := a.checkTokenJoinRequestCommon(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if provisionToken.GetJoinMethod() != types.JoinMethodGitLab {
+		return nil, trace.AccessDenied("this token does not support the GitLab join method")
+	}
+
+	identity, err := a.verifyGitLabIDToken(ctx, gitlabInstanceURL(provisionToken), idToken)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := checkGitLabAllowRules(identity, provisionToken); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	certs, err := a.generateCerts(ctx, provisionToken, req)
+	return certs, trace.Wrap(err)
+}
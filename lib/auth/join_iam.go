@@ -57,6 +57,11 @@ const (
 	// AWS SignedHeaders will always be lowercase
 	// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-auth-using-authorization-header.html#sigv4-auth-header-overview
 	challengeHeaderKey = "x-teleport-challenge"
+
+	// IAMAccountsLabel is a token metadata label further restricting the
+	// AWS accounts the token may be used from, as comma-separated glob
+	// patterns. It applies on top of the token's allow rules.
+	IAMAccountsLabel = "teleport.dev/allow-aws-accounts"
 )
 
 // validateStsIdentityRequest checks that a received sts:GetCallerIdentity
@@ -212,6 +217,35 @@ func arnMatches(pattern, arn string) (bool, error) {
 	return matched, trace.Wrap(err)
 }
 
+// assumedRoleARNPattern matches STS assumed-role identity ARNs, e.g.
+// arn:aws:sts::123456789012:assumed-role/my-role/session-name
+var assumedRoleARNPattern = regexp.MustCompile(`^arn:([^:]*):sts::([^:]*):assumed-role/([^/]+)/.+$`)
+
+// iamRoleARN returns the ARN of the IAM role behind an STS assumed-role
+// identity ARN, so that allow rules can be written against the role itself
+// rather than having to match every session name. Returns the empty string
+// if arn is not an assumed-role ARN.
+func iamRoleARN(arn string) string {
+	match := assumedRoleARNPattern.FindStringSubmatch(arn)
+	if match == nil {
+		return ""
+	}
+	return "arn:" + match[1] + ":iam::" + match[2] + ":role/" + match[3]
+}
+
+// identityMatchesARN returns true if the identity ARN, or the IAM role ARN
+// behind an assumed-role identity ARN, matches the pattern.
+func identityMatchesARN(pattern, arn string) (bool, error) {
+	matches, err := arnMatches(pattern, arn)
+	if err != nil || matches {
+		return matches, trace.Wrap(err)
+	}
+	if roleARN := iamRoleARN(arn); roleARN != "" {
+		return arnMatches(pattern, roleARN)
+	}
+	return false, nil
+}
+
 // checkIAMAllowRules checks if the given identity matches any of the given
 // allowRules.
 func checkIAMAllowRules(identity *awsIdentity, allowRules []*types.TokenRule) error {
@@ -225,7 +259,7 @@ func checkIAMAllowRules(identity *awsIdentity, allowRules []*types.TokenRule) er
 		}
 		// if this rule specifies an AWS ARN, the identity must match
 		if len(rule.AWSARN) > 0 {
-			matches, err := arnMatches(rule.AWSARN, identity.Arn)
+			matches, err := identityMatchesARN(rule.AWSARN, identity.Arn)
 			if err != nil {
 				return trace.Wrap(err)
 			}
@@ -276,6 +310,13 @@ func (a *Server) checkIAMRequest(ctx context.Context, challenge string, req *pro
 		return trace.Wrap(err)
 	}
 
+	// check the token's account filter label, if present
+	if accounts := provisionToken.GetMetadata().Labels[IAMAccountsLabel]; accounts != "" {
+		if err := checkPrincipalsAgainstRules(accounts, []string{identity.Account}, "AWS account"); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
@@ -195,6 +195,30 @@ func TestAuth_RegisterUsingIAMMethod(t *testing.T) {
 			requestTemplate: identityRequestTemplate,
 			assertError:     require.NoError,
 		},
+		{
+			desc:             "role arn matches assumed-role identity",
+			tokenName:        "test-token",
+			requestTokenName: "test-token",
+			tokenSpec: types.ProvisionTokenSpecV2{
+				Roles: []types.SystemRole{types.RoleNode},
+				Allow: []*types.TokenRule{
+					{
+						AWSAccount: "1234",
+						AWSARN:     "arn:aws:iam::1234:role/node-role",
+					},
+				},
+				JoinMethod: types.JoinMethodIAM,
+			},
+			stsClient: &mockClient{
+				respStatusCode: http.StatusOK,
+				respBody: responseFromAWSIdentity(awsIdentity{
+					Account: "1234",
+					Arn:     "arn:aws:sts::1234:assumed-role/node-role/i-0123456789",
+				}),
+			},
+			requestTemplate: identityRequestTemplate,
+			assertError:     require.NoError,
+		},
 		{
 			desc:             "wrong token",
 			tokenName:        "test-token",
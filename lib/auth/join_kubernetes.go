@@ -0,0 +1,114 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/trace"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// KubernetesServiceAccountsLabel is a token metadata label listing the
+// service accounts agents may join with, as comma-separated
+// "<namespace>:<name>" glob patterns. Required for tokens using the
+// Kubernetes join method.
+const KubernetesServiceAccountsLabel = "teleport.dev/kubernetes-service-accounts"
+
+// kubernetesServiceAccountPrefix prefixes service account usernames reported
+// by TokenReview.
+const kubernetesServiceAccountPrefix = "system:serviceaccount:"
+
+// kubernetesTokenValidator validates a projected service account token and
+// returns the authenticated username, overridable in tests.
+var kubernetesTokenValidator = validateKubernetesTokenInCluster
+
+// validateKubernetesTokenInCluster submits the token for a TokenReview to
+// the API server of the cluster the auth server itself runs in.
+func validateKubernetesTokenInCluster(ctx context.Context, idToken string) (string, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return "", trace.Wrap(err, "the Kubernetes join method requires the auth server to run inside a Kubernetes cluster")
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	review, err := clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: idToken,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if !review.Status.Authenticated {
+		return "", trace.AccessDenied("kubernetes service account token was rejected: %v", review.Status.Error)
+	}
+	return review.Status.User.Username, nil
+}
+
+// checkKubernetesAllowRules checks the authenticated service account against
+// the allow rules on the provision token.
+func checkKubernetesAllowRules(username string, provisionToken types.ProvisionToken) error {
+	if !strings.HasPrefix(username, kubernetesServiceAccountPrefix) {
+		return trace.AccessDenied("%q is not a Kubernetes service account", username)
+	}
+	// namespace:name, matching the format used in the token label
+	serviceAccount := strings.TrimPrefix(username, kubernetesServiceAccountPrefix)
+	allowed := provisionToken.GetMetadata().Labels[KubernetesServiceAccountsLabel]
+	if allowed == "" {
+		return trace.AccessDenied("token %q does not specify any allowed Kubernetes service accounts", provisionToken.GetName())
+	}
+	return trace.Wrap(checkPrincipalsAgainstRules(allowed, []string{serviceAccount}, "Kubernetes service account"))
+}
+
+// RegisterUsingKubernetesMethod registers the caller using a projected
+// Kubernetes service account token, validated via TokenReview against the
+// cluster the auth server runs in.
+func (a *Server) RegisterUsingKubernetesMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, idToken string) (*proto.Certs, error) {
+	log.Infof("Node %q [%v] is attempting to join with the Kubernetes method.", req.NodeName, req.HostID)
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	provisionToken, err := a.checkTokenJoinRequestCommon(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if provisionToken.GetJoinMethod() != types.JoinMethodKubernetes {
+		return nil, trace.AccessDenied("this token does not support the Kubernetes join method")
+	}
+
+	username, err := kubernetesTokenValidator(ctx, idToken)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := checkKubernetesAllowRules(username, provisionToken); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	certs, err := a.generateCerts(ctx, provisionToken, req)
+	return certs, trace.Wrap(err)
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// Token metadata labels configuring the SPIFFE join method.
+const (
+	// SPIFFETrustDomainLabel is the SPIFFE trust domain agents may join
+	// from, e.g. "example.org". Required for tokens using the SPIFFE join
+	// method; a trust bundle for the domain must be uploaded beforehand.
+	SPIFFETrustDomainLabel = "teleport.dev/spiffe-trust-domain"
+	// SPIFFEIDsLabel lists the SPIFFE IDs agents may join with as
+	// comma-separated glob patterns, e.g. "spiffe://example.org/agent/*".
+	// Empty means any ID in the trust domain.
+	SPIFFEIDsLabel = "teleport.dev/spiffe-ids"
+)
+
+// spiffeTrustBundlePrefix is the backend prefix SPIFFE trust bundles are
+// stored under.
+const spiffeTrustBundlePrefix = "spiffe_trust_bundles"
+
+// SPIFFETrustBundle holds the CA certificates of an external SPIRE trust
+// domain that SVIDs presented during joining are verified against.
+type SPIFFETrustBundle struct {
+	// TrustDomain is the SPIFFE trust domain the bundle belongs to.
+	TrustDomain string `json:"trust_domain"`
+	// CACertsPEM is the PEM-encoded CA certificates of the trust domain.
+	CACertsPEM string `json:"ca_certs_pem"`
+	// UpdatedAt is the time the bundle was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the trust bundle.
+func (b *SPIFFETrustBundle) CheckAndSetDefaults() error {
+	if b.TrustDomain == "" {
+		return trace.BadParameter("trust domain is required")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(b.CACertsPEM)) {
+		return trace.BadParameter("trust bundle for %q does not contain any valid PEM certificates", b.TrustDomain)
+	}
+	return nil
+}
+
+// UpsertSPIFFETrustBundle creates or updates the trust bundle of a SPIFFE
+// trust domain.
+func (a *Server) UpsertSPIFFETrustBundle(ctx context.Context, bundle SPIFFETrustBundle) error {
+	if err := bundle.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	bundle.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(spiffeTrustBundlePrefix, bundle.TrustDomain),
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetSPIFFETrustBundle returns the trust bundle of the given SPIFFE trust
+// domain.
+func (a *Server) GetSPIFFETrustBundle(ctx context.Context, trustDomain string) (*SPIFFETrustBundle, error) {
+	if trustDomain == "" {
+		return nil, trace.BadParameter("trust domain is required")
+	}
+	item, err := a.bk.Get(ctx, backend.Key(spiffeTrustBundlePrefix, trustDomain))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("no trust bundle is uploaded for SPIFFE trust domain %q", trustDomain)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var bundle SPIFFETrustBundle
+	if err := utils.FastUnmarshal(item.Value, &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bundle, nil
+}
+
+// DeleteSPIFFETrustBundle removes the trust bundle of the given SPIFFE
+// trust domain.
+func (a *Server) DeleteSPIFFETrustBundle(ctx context.Context, trustDomain string) error {
+	if trustDomain == "" {
+		return trace.BadParameter("trust domain is required")
+	}
+	err := a.bk.Delete(ctx, backend.Key(spiffeTrustBundlePrefix, trustDomain))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("no trust bundle is uploaded for SPIFFE trust domain %q", trustDomain)
+	}
+	return trace.Wrap(err)
+}
+
+// parseSVIDChain parses a PEM-encoded certificate chain into the leaf SVID
+// and any intermediates.
+func parseSVIDChain(chainPEM []byte) (*x509.Certificate, *x509.CertPool, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, chainPEM = pem.Decode(chainPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil, trace.BadParameter("no certificates found in SVID chain")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	return certs[0], intermediates, nil
+}
+
+// verifySVID verifies the SVID chain against the trust domain's bundle and
+// returns the SPIFFE ID of the leaf certificate.
+func (a *Server) verifySVID(ctx context.Context, trustDomain string, svidChainPEM []byte) (string, *x509.Certificate, error) {
+	bundle, err := a.GetSPIFFETrustBundle(ctx, trustDomain)
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(bundle.CACertsPEM)) {
+		return "", nil, trace.BadParameter("trust bundle for %q does not contain any valid PEM certificates", trustDomain)
+	}
+	leaf, intermediates, err := parseSVIDChain(svidChainPEM)
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   a.clock.Now(),
+		// SVIDs are not issued with TLS server auth usage.
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return "", nil, trace.AccessDenied("SVID does not chain to the %q trust bundle: %v", trustDomain, err)
+	}
+	if len(leaf.URIs) != 1 || leaf.URIs[0].Scheme != "spiffe" {
+		return "", nil, trace.AccessDenied("SVID does not carry exactly one spiffe URI SAN")
+	}
+	if leaf.URIs[0].Host != trustDomain {
+		return "", nil, trace.AccessDenied("SVID belongs to trust domain %q, token allows %q", leaf.URIs[0].Host, trustDomain)
+	}
+	return leaf.URIs[0].String(), leaf, nil
+}
+
+// verifySVIDSignature checks that the caller holds the SVID private key by
+// verifying a signature over the public keys submitted in the register
+// request, binding the issued certificates to the SVID holder.
+func verifySVIDSignature(leaf *x509.Certificate, req *types.RegisterUsingTokenRequest, signature []byte) error {
+	payload := sha256.Sum256(append(append([]byte{}, req.PublicSSHKey...), req.PublicTLSKey...))
+	switch pub := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, payload[:], signature); err != nil {
+			return trace.AccessDenied("SVID signature is not valid: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, payload[:], signature) {
+			return trace.AccessDenied("SVID signature is not valid")
+		}
+	default:
+		return trace.AccessDenied("unsupported SVID public key type %T", leaf.PublicKey)
+	}
+	return nil
+}
+
+// RegisterUsingSPIFFEMethod registers the caller using the SPIFFE join
+// method and returns signed certs to join the cluster. The caller presents
+// an X.509 SVID chain from the trust domain named on the provision token,
+// together with a signature made with the SVID key over the submitted
+// public keys as proof of possession.
+func (a *Server) RegisterUsingSPIFFEMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, svidChainPEM, signature []byte) (*proto.Certs, error) {
+	log.Infof("Node %q [%v] is attempting to join with the SPIFFE method.", req.NodeName, req.HostID)
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	provisionToken, err := a.checkTokenJoinRequestCommon(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if provisionToken.GetJoinMethod() != types.JoinMethodSPIFFE {
+		return nil, trace.AccessDenied("this token does not support the SPIFFE join method")
+	}
+	labels := provisionToken.GetMetadata().Labels
+	trustDomain := labels[SPIFFETrustDomainLabel]
+	if trustDomain == "" {
+		return nil, trace.AccessDenied("token %q does not specify a SPIFFE trust domain", provisionToken.GetName())
+	}
+
+	spiffeID, leaf, err := a.verifySVID(ctx, trustDomain, svidChainPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := verifySVIDSignature(leaf, req, signature); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if ids := labels[SPIFFEIDsLabel]; ids != "" {
+		if err := checkPrincipalsAgainstRules(ids, []string{spiffeID}, "SPIFFE ID"); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	certs, err := a.generateCerts(ctx, provisionToken, req)
+	return certs, trace.Wrap(err)
+}
+
+// UpsertSPIFFETrustBundle creates or updates the trust bundle of a SPIFFE
+// trust domain.
+func (a *ServerWithRoles) UpsertSPIFFETrustBundle(ctx context.Context, bundle SPIFFETrustBundle) error {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertSPIFFETrustBundle(ctx, bundle)
+}
+
+// GetSPIFFETrustBundle returns the trust bundle of the given SPIFFE trust
+// domain.
+func (a *ServerWithRoles) GetSPIFFETrustBundle(ctx context.Context, trustDomain string) (*SPIFFETrustBundle, error) {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetSPIFFETrustBundle(ctx, trustDomain)
+}
+
+// DeleteSPIFFETrustBundle removes the trust bundle of the given SPIFFE
+// trust domain.
+func (a *ServerWithRoles) DeleteSPIFFETrustBundle(ctx context.Context, trustDomain string) error {
+	if err := a.action(apidefaults.Namespace, types.KindCertAuthority, types.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteSPIFFETrustBundle(ctx, trustDomain)
+}
+
+// RegisterUsingSPIFFEMethod registers a new node using an X.509 SVID from a
+// trusted SPIFFE trust domain.
+func (a *ServerWithRoles) RegisterUsingSPIFFEMethod(ctx context.Context, req *types.RegisterUsingTokenRequest, svidChainPEM, signature []byte) (*proto.Certs, error) {
+	// tokens have authz mechanism  on their own, no need to check
+	return a.authServer.RegisterUsingSPIFFEMethod(ctx, req, svidChainPEM, signature)
+}
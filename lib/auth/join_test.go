@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/gravitational/teleport/api/client/proto"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/api/types/wrappers"
 	"github.com/gravitational/teleport/api/utils/sshutils"
@@ -255,6 +256,62 @@ func TestAuth_RegisterUsingToken(t *testing.T) {
 	}
 }
 
+func TestTokenSuggestedLabels(t *testing.T) {
+	ctx := context.Background()
+	p, err := newTestPack(ctx, t.TempDir())
+	require.NoError(t, err)
+	a := p.a
+
+	token, err := types.NewProvisionTokenFromSpec("labeled-token", a.clock.Now().Add(time.Hour), types.ProvisionTokenSpecV2{
+		Roles: []types.SystemRole{types.RoleNode},
+	})
+	require.NoError(t, err)
+	meta := token.GetMetadata()
+	meta.Labels = map[string]string{
+		TokenLabelPrefix + "env":  "staging",
+		TokenLabelPrefix + "team": "storage",
+		"unrelated":               "label",
+	}
+	token.SetMetadata(meta)
+	require.NoError(t, a.UpsertToken(ctx, token))
+
+	sshPrivateKey, sshPublicKey, err := native.GenerateKeyPair()
+	require.NoError(t, err)
+	tlsPublicKey, err := PrivateKeyToPublicKeyTLS(sshPrivateKey)
+	require.NoError(t, err)
+
+	_, err = a.RegisterUsingToken(ctx, &types.RegisterUsingTokenRequest{
+		Token:        "labeled-token",
+		HostID:       "labeled-host",
+		NodeName:     "node-name",
+		Role:         types.RoleNode,
+		PublicSSHKey: sshPublicKey,
+		PublicTLSKey: tlsPublicKey,
+	})
+	require.NoError(t, err)
+
+	// the node is announced with the token labels before its first heartbeat
+	node, err := a.GetNode(ctx, apidefaults.Namespace, "labeled-host")
+	require.NoError(t, err)
+	require.Equal(t, "staging", node.GetStaticLabels()["env"])
+	require.Equal(t, "storage", node.GetStaticLabels()["team"])
+
+	// the token labels override what the host reports about itself
+	heartbeat, err := types.NewServer("labeled-host", types.KindNode, types.ServerSpecV2{
+		Hostname: "node-name",
+	})
+	require.NoError(t, err)
+	heartbeat.SetStaticLabels(map[string]string{"env": "local-override", "extra": "kept"})
+	_, err = a.UpsertNode(ctx, heartbeat)
+	require.NoError(t, err)
+
+	node, err = a.GetNode(ctx, apidefaults.Namespace, "labeled-host")
+	require.NoError(t, err)
+	require.Equal(t, "staging", node.GetStaticLabels()["env"])
+	require.Equal(t, "storage", node.GetStaticLabels()["team"])
+	require.Equal(t, "kept", node.GetStaticLabels()["extra"])
+}
+
 func newBotToken(t *testing.T, tokenName, botName string, role types.SystemRole, expiry time.Time) types.ProvisionToken {
 	t.Helper()
 	token, err := types.NewProvisionTokenFromSpec(tokenName, expiry, types.ProvisionTokenSpecV2{
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"strconv"
+	"time"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/auth/native"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// Subject key algorithms accepted by the certificate key policy.
+const (
+	// CertKeyAlgorithmRSA is an RSA subject key.
+	CertKeyAlgorithmRSA = "rsa"
+	// CertKeyAlgorithmECDSA is an ECDSA subject key.
+	CertKeyAlgorithmECDSA = "ecdsa"
+	// CertKeyAlgorithmEd25519 is an Ed25519 subject key.
+	CertKeyAlgorithmEd25519 = "ed25519"
+)
+
+// CertKeyMinRSABitsLabel is a role metadata label that raises the minimum
+// RSA key size for users holding the role above the cluster-level policy.
+const CertKeyMinRSABitsLabel = "teleport.dev/cert-key-min-rsa-bits"
+
+// certKeyPolicyKey is the backend key the cluster policy is stored under.
+var certKeyPolicyKey = backend.Key("cert_key_policy")
+
+// CertKeyPolicy is the cluster-level policy for subject keys submitted in
+// certificate requests. Weak keys are rejected at issuance time.
+type CertKeyPolicy struct {
+	// AllowedAlgorithms lists the accepted subject key algorithms. An empty
+	// list accepts all supported algorithms.
+	AllowedAlgorithms []string `json:"allowed_algorithms,omitempty"`
+	// MinRSABits is the minimum accepted RSA key size in bits.
+	MinRSABits int `json:"min_rsa_bits"`
+	// UpdatedAt is the time the policy was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy and fills in defaults.
+func (p *CertKeyPolicy) CheckAndSetDefaults() error {
+	for _, algorithm := range p.AllowedAlgorithms {
+		switch algorithm {
+		case CertKeyAlgorithmRSA, CertKeyAlgorithmECDSA, CertKeyAlgorithmEd25519:
+		default:
+			return trace.BadParameter("unsupported subject key algorithm %q", algorithm)
+		}
+	}
+	if p.MinRSABits == 0 {
+		p.MinRSABits = 2048
+	}
+	if p.MinRSABits < 0 {
+		return trace.BadParameter("min_rsa_bits must be positive")
+	}
+	return nil
+}
+
+// SetCertKeyPolicy updates the cluster-level subject key policy.
+func (a *Server) SetCertKeyPolicy(ctx context.Context, policy CertKeyPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	policy.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   certKeyPolicyKey,
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetCertKeyPolicy returns the cluster-level subject key policy, or the
+// default policy if none was configured.
+func (a *Server) GetCertKeyPolicy(ctx context.Context) (*CertKeyPolicy, error) {
+	var policy CertKeyPolicy
+	item, err := a.bk.Get(ctx, certKeyPolicyKey)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	} else if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// checkCertificateKeyPolicy rejects a certificate request whose subject key
+// does not satisfy the cluster policy, or a stricter per-role minimum RSA
+// size configured via the CertKeyMinRSABitsLabel role label.
+func (a *Server) checkCertificateKeyPolicy(ctx context.Context, checker services.AccessChecker, publicSSHKey []byte) error {
+	policy, err := a.GetCertKeyPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(publicSSHKey)
+	if err != nil {
+		return trace.Wrap(err, "failed to parse public key")
+	}
+	var algorithm string
+	switch pub.Type() {
+	case ssh.KeyAlgoRSA:
+		algorithm = CertKeyAlgorithmRSA
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		algorithm = CertKeyAlgorithmECDSA
+	case ssh.KeyAlgoED25519:
+		algorithm = CertKeyAlgorithmEd25519
+	default:
+		return trace.BadParameter("unsupported subject key type %q", pub.Type())
+	}
+	if len(policy.AllowedAlgorithms) != 0 && !apiutils.SliceContainsStr(policy.AllowedAlgorithms, algorithm) {
+		return trace.BadParameter("subject key algorithm %q is not allowed by the cluster certificate key policy", algorithm)
+	}
+	if algorithm != CertKeyAlgorithmRSA {
+		return nil
+	}
+	minBits := policy.MinRSABits
+	if checker != nil {
+		for _, role := range checker.Roles() {
+			if bits := roleMinRSABits(role.GetMetadata().Labels); bits > minBits {
+				minBits = bits
+			}
+		}
+	}
+	cryptoKey, ok := pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return trace.BadParameter("failed to extract RSA public key")
+	}
+	rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+	if !ok {
+		return trace.BadParameter("failed to extract RSA public key")
+	}
+	if bits := rsaKey.N.BitLen(); bits < minBits {
+		return trace.BadParameter("%v-bit RSA subject keys are rejected by the certificate key policy, at least %v bits are required", bits, minBits)
+	}
+	return nil
+}
+
+func roleMinRSABits(labels map[string]string) int {
+	value, ok := labels[CertKeyMinRSABitsLabel]
+	if !ok {
+		return 0
+	}
+	bits, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warnf("Ignoring invalid %v role label value %q.", CertKeyMinRSABitsLabel, value)
+		return 0
+	}
+	return bits
+}
+
+// SubjectKeyPair is a server-side generated keypair that satisfies the
+// cluster certificate key policy.
+type SubjectKeyPair struct {
+	// PrivateKey is a PEM-encoded private key.
+	PrivateKey []byte `json:"private_key"`
+	// PublicKey is the matching public key in authorized_keys format.
+	PublicKey []byte `json:"public_key"`
+}
+
+// GenerateSubjectKeyPair generates a policy-compliant keypair on the server
+// for clients that cannot or do not want to generate their own.
+func (a *Server) GenerateSubjectKeyPair(ctx context.Context) (*SubjectKeyPair, error) {
+	priv, pub, err := native.GenerateKeyPair()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &SubjectKeyPair{PrivateKey: priv, PublicKey: pub}, nil
+}
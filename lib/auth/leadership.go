@@ -0,0 +1,210 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// authLeadershipPrefix is the backend key under which the current auth
+// server leadership record is stored.
+const authLeadershipPrefix = "auth_leadership"
+
+// authLeadershipRetries is how many times a promotion retries when it races
+// with a concurrent promotion.
+const authLeadershipRetries = 5
+
+// AuthLeadership records which auth server currently holds the primary role.
+// Auth servers that share a backend but keep their CA keys in server-local
+// keystores (e.g. HSMs) must not sign concurrently with different keys; the
+// leadership record fences CA signing operations to the primary. When no
+// record exists, leadership is not configured and every auth server may sign.
+type AuthLeadership struct {
+	// PrimaryID is the server ID (host UUID) of the primary auth server.
+	PrimaryID string `json:"primary_id"`
+	// Epoch increases on every promotion and acts as a fencing token: a
+	// demoted primary can not mistake a stale record for current leadership.
+	Epoch int64 `json:"epoch"`
+	// PromotedAt is when the current primary was promoted.
+	PromotedAt time.Time `json:"promoted_at"`
+}
+
+// GetAuthLeadership returns the current leadership record, or a NotFound
+// error when leadership is not configured.
+func (a *Server) GetAuthLeadership(ctx context.Context) (*AuthLeadership, error) {
+	item, err := a.bk.Get(ctx, backend.Key(authLeadershipPrefix))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var leadership AuthLeadership
+	if err := utils.FastUnmarshal(item.Value, &leadership); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &leadership, nil
+}
+
+// PromoteAuthServer promotes the auth server with the given server ID to the
+// primary role, demoting the previous primary to standby. The promotion bumps
+// the fencing epoch, so CA signing operations of the demoted primary fail
+// closed, and updates the leadership record in the backend, which notifies
+// the leadership watcher running on every auth server.
+func (a *Server) PromoteAuthServer(ctx context.Context, serverID string) (*AuthLeadership, error) {
+	if serverID == "" {
+		return nil, trace.BadParameter("missing server ID of the auth server to promote")
+	}
+	authServers, err := a.GetAuthServers()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	known := false
+	for _, server := range authServers {
+		if server.GetName() == serverID {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, trace.NotFound("auth server %q is not registered", serverID)
+	}
+
+	for i := 0; i < authLeadershipRetries; i++ {
+		item, err := a.bk.Get(ctx, backend.Key(authLeadershipPrefix))
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		var prev AuthLeadership
+		if err == nil {
+			if err := utils.FastUnmarshal(item.Value, &prev); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if prev.PrimaryID == serverID {
+				return &prev, nil
+			}
+		}
+		leadership := AuthLeadership{
+			PrimaryID:  serverID,
+			Epoch:      prev.Epoch + 1,
+			PromotedAt: a.clock.Now().UTC(),
+		}
+		value, marshalErr := utils.FastMarshal(&leadership)
+		if marshalErr != nil {
+			return nil, trace.Wrap(marshalErr)
+		}
+		newItem := backend.Item{
+			Key:   backend.Key(authLeadershipPrefix),
+			Value: value,
+		}
+		if trace.IsNotFound(err) {
+			if _, err := a.bk.Create(ctx, newItem); err != nil {
+				if trace.IsAlreadyExists(err) {
+					continue
+				}
+				return nil, trace.Wrap(err)
+			}
+		} else {
+			if _, err := a.bk.CompareAndSwap(ctx, *item, newItem); err != nil {
+				if trace.IsCompareFailed(err) {
+					continue
+				}
+				return nil, trace.Wrap(err)
+			}
+		}
+		log.Infof("Auth server %v promoted to primary (epoch %v), previous primary %q demoted to standby.",
+			serverID, leadership.Epoch, prev.PrimaryID)
+		return &leadership, nil
+	}
+	return nil, trace.CompareFailed("failed to promote auth server %q due to concurrent promotions", serverID)
+}
+
+// checkCASigningAllowed fails when this auth server has been demoted to
+// standby, fencing its CA signing operations. When leadership is not
+// configured, signing is allowed on every auth server.
+func (a *Server) checkCASigningAllowed(ctx context.Context) error {
+	leadership, err := a.GetAuthLeadership(ctx)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	if leadership.PrimaryID != a.ServerID {
+		return trace.AccessDenied("auth server %q is a standby (primary is %q, epoch %v): CA signing operations are fenced until this server is promoted",
+			a.ServerID, leadership.PrimaryID, leadership.Epoch)
+	}
+	return nil
+}
+
+// watchAuthLeadership streams leadership changes from the backend, so every
+// auth server observes promotions as they happen: a promoted standby starts
+// signing and a demoted primary logs that its signing operations are fenced.
+func (a *Server) watchAuthLeadership() {
+	for {
+		err := a.runAuthLeadershipWatch()
+		if err != nil {
+			log.WithError(err).Debug("Auth leadership watcher failed, restarting.")
+		}
+		select {
+		case <-a.closeCtx.Done():
+			return
+		case <-a.clock.After(defaults.HighResPollingPeriod):
+		}
+	}
+}
+
+// runAuthLeadershipWatch runs a single leadership watch until the watcher or
+// the auth server closes.
+func (a *Server) runAuthLeadershipWatch() error {
+	watcher, err := a.bk.NewWatcher(a.closeCtx, backend.Watch{
+		Name:     "auth-leadership",
+		Prefixes: [][]byte{backend.Key(authLeadershipPrefix)},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer watcher.Close()
+	for {
+		select {
+		case <-a.closeCtx.Done():
+			return nil
+		case <-watcher.Done():
+			return trace.ConnectionProblem(nil, "auth leadership watcher closed")
+		case event := <-watcher.Events():
+			if event.Type != types.OpPut {
+				continue
+			}
+			var leadership AuthLeadership
+			if err := utils.FastUnmarshal(event.Item.Value, &leadership); err != nil {
+				log.WithError(err).Debug("Failed to unmarshal auth leadership record.")
+				continue
+			}
+			if leadership.PrimaryID == a.ServerID {
+				log.Infof("This auth server has been promoted to primary (epoch %v).", leadership.Epoch)
+			} else {
+				log.Warnf("Auth server %v is now the primary (epoch %v); CA signing operations on this server are fenced.",
+					leadership.PrimaryID, leadership.Epoch)
+			}
+		}
+	}
+}
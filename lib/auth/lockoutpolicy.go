@@ -0,0 +1,266 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// lockoutPolicyPrefix is the backend key the lockout policy singleton is
+	// stored under.
+	lockoutPolicyPrefix = "lockout_policy"
+
+	// loginThrottlePrefix is the backend prefix per-address failed login
+	// counters are stored under.
+	loginThrottlePrefix = "login_throttle"
+)
+
+// LockoutPolicy controls how failed logins are counted and how accounts are
+// locked, replacing the built-in limits when configured.
+type LockoutPolicy struct {
+	// MaxAttempts is the number of failed login attempts after which the
+	// account is locked, zero means the built-in limit.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// AttemptWindow is how long failed attempts are counted for, zero means
+	// the built-in window.
+	AttemptWindow time.Duration `json:"attempt_window,omitempty"`
+	// LockDuration is how long an account stays locked after too many failed
+	// attempts, zero means the built-in duration.
+	LockDuration time.Duration `json:"lock_duration,omitempty"`
+	// MaxAttemptsPerIP is the number of failed login attempts allowed from a
+	// single client address within the attempt window, zero disables the
+	// per-address throttle.
+	MaxAttemptsPerIP int `json:"max_attempts_per_ip,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy and fills in unset limits with the
+// built-in ones.
+func (p *LockoutPolicy) CheckAndSetDefaults() error {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaults.MaxLoginAttempts
+	}
+	if p.MaxAttempts < 0 {
+		return trace.BadParameter("lockout policy max attempts must be positive, got %v", p.MaxAttempts)
+	}
+	if p.AttemptWindow == 0 {
+		p.AttemptWindow = defaults.AttemptTTL
+	}
+	if p.AttemptWindow < 0 {
+		return trace.BadParameter("lockout policy attempt window must be positive, got %v", p.AttemptWindow)
+	}
+	if p.LockDuration == 0 {
+		p.LockDuration = defaults.AccountLockInterval
+	}
+	if p.LockDuration < 0 {
+		return trace.BadParameter("lockout policy lock duration must be positive, got %v", p.LockDuration)
+	}
+	if p.MaxAttemptsPerIP < 0 {
+		return trace.BadParameter("lockout policy max attempts per IP must not be negative, got %v", p.MaxAttemptsPerIP)
+	}
+	return nil
+}
+
+// SetLockoutPolicy configures the lockout policy of the cluster.
+func (a *Server) SetLockoutPolicy(ctx context.Context, policy LockoutPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(lockoutPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetLockoutPolicy returns the configured lockout policy, falling back to the
+// built-in limits when none was set.
+func (a *Server) GetLockoutPolicy(ctx context.Context) (LockoutPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(lockoutPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			policy := LockoutPolicy{}
+			if err := policy.CheckAndSetDefaults(); err != nil {
+				return LockoutPolicy{}, trace.Wrap(err)
+			}
+			return policy, nil
+		}
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	var policy LockoutPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteLockoutPolicy reverts the cluster to the built-in lockout limits.
+func (a *Server) DeleteLockoutPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(lockoutPolicyPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("lockout policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// loginThrottleRecord counts failed login attempts from a single client
+// address within the attempt window.
+type loginThrottleRecord struct {
+	// Count is the number of failed attempts in the current window.
+	Count int `json:"count"`
+	// WindowStart is when the current window opened.
+	WindowStart time.Time `json:"window_start"`
+}
+
+// loginThrottleAddr extracts the host portion of the forwarded client
+// address, empty if no usable address was forwarded.
+func loginThrottleAddr(md *ForwardedClientMetadata) string {
+	if md == nil || md.RemoteAddr == "" {
+		return ""
+	}
+	host, err := utils.Host(md.RemoteAddr)
+	if err != nil {
+		return md.RemoteAddr
+	}
+	return host
+}
+
+// checkLoginThrottle rejects the login attempt when the client address has
+// exceeded the per-address limit of the lockout policy.
+func (a *Server) checkLoginThrottle(ctx context.Context, md *ForwardedClientMetadata) error {
+	addr := loginThrottleAddr(md)
+	if addr == "" {
+		return nil
+	}
+	policy, err := a.GetLockoutPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.MaxAttemptsPerIP == 0 {
+		return nil
+	}
+	item, err := a.bk.Get(ctx, backend.Key(loginThrottlePrefix, addr))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	var record loginThrottleRecord
+	if err := utils.FastUnmarshal(item.Value, &record); err != nil {
+		return trace.Wrap(err)
+	}
+	if a.clock.Now().After(record.WindowStart.Add(policy.AttemptWindow)) {
+		return nil
+	}
+	if record.Count >= policy.MaxAttemptsPerIP {
+		log.Debugf("Client address %v exceeds %v failed login attempts, throttled.", addr, policy.MaxAttemptsPerIP)
+		return trace.LimitExceeded(MaxFailedAttemptsErrMsg)
+	}
+	return nil
+}
+
+// recordFailedLoginAttempt counts a failed login against the client address.
+// Throttling is best effort, failures to record are logged and swallowed so a
+// flaky backend cannot turn into a login outage.
+func (a *Server) recordFailedLoginAttempt(ctx context.Context, md *ForwardedClientMetadata) {
+	addr := loginThrottleAddr(md)
+	if addr == "" {
+		return
+	}
+	policy, err := a.GetLockoutPolicy(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load the lockout policy.")
+		return
+	}
+	if policy.MaxAttemptsPerIP == 0 {
+		return
+	}
+	var record loginThrottleRecord
+	item, err := a.bk.Get(ctx, backend.Key(loginThrottlePrefix, addr))
+	if err == nil {
+		if err := utils.FastUnmarshal(item.Value, &record); err != nil {
+			log.WithError(err).Warn("Failed to decode the login throttle record.")
+			record = loginThrottleRecord{}
+		}
+	} else if !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to read the login throttle record.")
+		return
+	}
+	now := a.clock.Now().UTC()
+	if record.WindowStart.IsZero() || now.After(record.WindowStart.Add(policy.AttemptWindow)) {
+		record = loginThrottleRecord{WindowStart: now}
+	}
+	record.Count++
+	value, err := utils.FastMarshal(record)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode the login throttle record.")
+		return
+	}
+	// Records from closed windows are reset in place above rather than
+	// expired by the backend, which keeps the counters correct under the
+	// configurable clock.
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(loginThrottlePrefix, addr),
+		Value: value,
+	}); err != nil {
+		log.WithError(err).Warn("Failed to store the login throttle record.")
+	}
+}
+
+// SetLockoutPolicy configures the lockout policy of the cluster.
+func (a *ServerWithRoles) SetLockoutPolicy(ctx context.Context, policy LockoutPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetLockoutPolicy(ctx, policy)
+}
+
+// GetLockoutPolicy returns the configured lockout policy, falling back to the
+// built-in limits when none was set.
+func (a *ServerWithRoles) GetLockoutPolicy(ctx context.Context) (LockoutPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return LockoutPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetLockoutPolicy(ctx)
+}
+
+// DeleteLockoutPolicy reverts the cluster to the built-in lockout limits.
+func (a *ServerWithRoles) DeleteLockoutPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteLockoutPolicy(ctx)
+}
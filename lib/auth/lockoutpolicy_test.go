@@ -0,0 +1,155 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/defaults"
+)
+
+func TestLockoutPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy the built-in limits apply.
+	policy, err := s.a.GetLockoutPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, defaults.MaxLoginAttempts, policy.MaxAttempts)
+	require.Equal(t, defaults.AttemptTTL, policy.AttemptWindow)
+	require.Equal(t, defaults.AccountLockInterval, policy.LockDuration)
+	require.Zero(t, policy.MaxAttemptsPerIP)
+
+	// Negative limits are rejected.
+	for _, invalid := range []LockoutPolicy{
+		{MaxAttempts: -1},
+		{AttemptWindow: -time.Minute},
+		{LockDuration: -time.Minute},
+		{MaxAttemptsPerIP: -1},
+	} {
+		err := s.a.SetLockoutPolicy(ctx, invalid)
+		require.True(t, trace.IsBadParameter(err), "policy %+v: expected BadParameter, got %v", invalid, err)
+	}
+
+	// Unset limits are filled with the built-in ones.
+	require.NoError(t, s.a.SetLockoutPolicy(ctx, LockoutPolicy{MaxAttempts: 3}))
+	policy, err = s.a.GetLockoutPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, policy.MaxAttempts)
+	require.Equal(t, defaults.AttemptTTL, policy.AttemptWindow)
+
+	// Deleting reverts to the built-in limits.
+	require.NoError(t, s.a.DeleteLockoutPolicy(ctx))
+	policy, err = s.a.GetLockoutPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, defaults.MaxLoginAttempts, policy.MaxAttempts)
+	err = s.a.DeleteLockoutPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestLockoutPolicyMaxAttempts(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+
+	user := "lockout-policy-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	require.NoError(t, s.a.SetLockoutPolicy(ctx, LockoutPolicy{
+		MaxAttempts:  3,
+		LockDuration: time.Minute,
+	}))
+
+	// The account locks after three failed attempts instead of the built-in
+	// five.
+	for i := 0; i < 3; i++ {
+		err = s.a.WithUserLock(user, func() error {
+			return trace.AccessDenied("wrong password")
+		})
+		require.Error(t, err)
+	}
+	err = s.a.WithUserLock(user, func() error { return nil })
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Equal(t, MaxFailedAttemptsErrMsg, err.Error())
+
+	// The shortened lock duration expires and access is restored.
+	fakeClock.Advance(time.Minute + time.Second)
+	require.NoError(t, s.a.WithUserLock(user, func() error { return nil }))
+}
+
+func TestLockoutPolicyIPThrottle(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+
+	user := "throttle-policy-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	require.NoError(t, s.a.SetLockoutPolicy(ctx, LockoutPolicy{
+		AttemptWindow:    time.Minute,
+		MaxAttemptsPerIP: 2,
+	}))
+
+	req := AuthenticateUserRequest{
+		Username:       user,
+		Pass:           &PassCreds{Password: []byte("wrongpassword")},
+		ClientMetadata: &ForwardedClientMetadata{RemoteAddr: "10.0.0.1:3080"},
+	}
+
+	// The first two failures count against the address, the third is
+	// throttled before credentials are even checked.
+	for i := 0; i < 2; i++ {
+		_, err = s.a.AuthenticateUser(req)
+		require.Error(t, err)
+	}
+	_, err = s.a.AuthenticateUser(req)
+	require.True(t, trace.IsLimitExceeded(err), "expected LimitExceeded, got %v", err)
+
+	// Correct credentials are rejected as well while the throttle holds.
+	okReq := req
+	okReq.Pass = &PassCreds{Password: password}
+	_, err = s.a.AuthenticateUser(okReq)
+	require.True(t, trace.IsLimitExceeded(err), "expected LimitExceeded, got %v", err)
+
+	// Other addresses are unaffected.
+	otherReq := okReq
+	otherReq.ClientMetadata = &ForwardedClientMetadata{RemoteAddr: "10.0.0.2:3080"}
+	_, err = s.a.AuthenticateUser(otherReq)
+	require.NoError(t, err)
+
+	// The window expires and the throttled address may try again.
+	fakeClock.Advance(time.Minute + time.Second)
+	_, err = s.a.AuthenticateUser(okReq)
+	require.NoError(t, err)
+}
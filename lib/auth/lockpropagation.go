@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// lockAckTTL bounds how long individual lock propagation acknowledgments are
+// kept around; they only serve as recent SLO evidence.
+const lockAckTTL = 24 * time.Hour
+
+var lockPropagationLatencies = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: teleport.MetricNamespace,
+		Name:      "lock_propagation_latency_seconds",
+		Help:      "Time between a lock being created and an agent acknowledging that it has been committed locally",
+		// lowest bucket start of upper bound 0.1 sec with factor 2
+		// highest bucket start of 0.1 sec * 2^11 == 204.8 sec
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	},
+)
+
+// LockPropagationAck records that a single agent has committed a lock to its
+// local view.
+type LockPropagationAck struct {
+	// LockName is the name of the acknowledged lock.
+	LockName string `json:"lock_name"`
+	// ServerID identifies the agent that committed the lock.
+	ServerID string `json:"server_id"`
+	// AckedAt is when the acknowledgment was received by the auth server.
+	AckedAt time.Time `json:"acked_at"`
+	// Latency is the time between the lock creation and the acknowledgment,
+	// it is zero when the lock creation time is unknown.
+	Latency time.Duration `json:"latency"`
+}
+
+// LockPropagationStatus describes how far a lock has propagated through the
+// cluster, so security teams can verify revocation SLAs.
+type LockPropagationStatus struct {
+	// LockName is the name of the lock.
+	LockName string `json:"lock_name"`
+	// CreatedAt is when the lock was created on the auth server.
+	CreatedAt time.Time `json:"created_at"`
+	// Acks lists the per-agent acknowledgments received so far.
+	Acks []LockPropagationAck `json:"acks"`
+}
+
+// recordLockCreation stores the creation time of a lock so propagation
+// latencies can be computed from subsequent acknowledgments.
+func (a *Server) recordLockCreation(ctx context.Context, lockName string) error {
+	value, err := utils.FastMarshal(a.clock.Now().UTC())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(lockPropagationPrefix, lockName, "created"),
+		Value:   value,
+		Expires: a.clock.Now().Add(lockAckTTL).UTC(),
+	})
+	return trace.Wrap(err)
+}
+
+// getLockCreation returns the recorded creation time of a lock, or a zero
+// time if it is unknown.
+func (a *Server) getLockCreation(ctx context.Context, lockName string) (time.Time, error) {
+	item, err := a.bk.Get(ctx, backend.Key(lockPropagationPrefix, lockName, "created"))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, trace.Wrap(err)
+	}
+	var createdAt time.Time
+	if err := utils.FastUnmarshal(item.Value, &createdAt); err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return createdAt, nil
+}
+
+// ackLockPropagation records that the given agent has committed the lock to
+// its local view and observes the propagation latency.
+func (a *Server) ackLockPropagation(ctx context.Context, lockName, serverID string) error {
+	if lockName == "" {
+		return trace.BadParameter("missing parameter lockName")
+	}
+	if serverID == "" {
+		return trace.BadParameter("missing parameter serverID")
+	}
+	createdAt, err := a.getLockCreation(ctx, lockName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ack := LockPropagationAck{
+		LockName: lockName,
+		ServerID: serverID,
+		AckedAt:  a.clock.Now().UTC(),
+	}
+	if !createdAt.IsZero() {
+		ack.Latency = ack.AckedAt.Sub(createdAt)
+		lockPropagationLatencies.Observe(ack.Latency.Seconds())
+	}
+	value, err := utils.FastMarshal(&ack)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(lockPropagationPrefix, lockName, "acks", serverID),
+		Value:   value,
+		Expires: a.clock.Now().Add(lockAckTTL).UTC(),
+	})
+	return trace.Wrap(err)
+}
+
+// GetLockPropagationStatus returns the acknowledgments received for a lock so
+// far, together with the lock creation time.
+func (a *Server) GetLockPropagationStatus(ctx context.Context, lockName string) (*LockPropagationStatus, error) {
+	if lockName == "" {
+		return nil, trace.BadParameter("missing parameter lockName")
+	}
+	createdAt, err := a.getLockCreation(ctx, lockName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	startKey := backend.Key(lockPropagationPrefix, lockName, "acks")
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	status := &LockPropagationStatus{
+		LockName:  lockName,
+		CreatedAt: createdAt,
+		Acks:      make([]LockPropagationAck, 0, len(result.Items)),
+	}
+	for _, item := range result.Items {
+		var ack LockPropagationAck
+		if err := utils.FastUnmarshal(item.Value, &ack); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		status.Acks = append(status.Acks, ack)
+	}
+	return status, nil
+}
+
+const lockPropagationPrefix = "lock_propagation"
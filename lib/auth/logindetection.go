@@ -0,0 +1,281 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// loginFingerprintPrefix is the backend prefix known login fingerprints
+	// are stored under.
+	loginFingerprintPrefix = "login_fingerprints"
+
+	// loginNotificationPolicyPrefix is the backend key the login notification
+	// policy singleton is stored under.
+	loginNotificationPolicyPrefix = "login_notification_policy"
+
+	// loginFingerprintRetention is how long a fingerprint is remembered
+	// after it was last seen. A device the user stopped logging in from for
+	// this long is treated as new again.
+	loginFingerprintRetention = 90 * 24 * time.Hour
+
+	// newDeviceWebhookTimeout bounds the webhook delivery so a slow receiver
+	// cannot stall logins.
+	newDeviceWebhookTimeout = 5 * time.Second
+)
+
+// LoginFingerprint identifies the client a user logged in from, built from
+// the forwarded client metadata of a successful login.
+type LoginFingerprint struct {
+	// User is the name of the user who logged in.
+	User string `json:"user"`
+	// UserAgent is the user agent of the client.
+	UserAgent string `json:"user_agent,omitempty"`
+	// IP is the client address without the port.
+	IP string `json:"ip,omitempty"`
+	// DeviceID is the trusted device ID forwarded by the proxy, if any.
+	DeviceID string `json:"device_id,omitempty"`
+	// FirstSeen is when the fingerprint was first recorded.
+	FirstSeen time.Time `json:"first_seen"`
+	// LastSeen is when the fingerprint was last confirmed by a login.
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// hash returns a stable digest of the fingerprint components, used as the
+// backend key suffix.
+func (f *LoginFingerprint) hash() string {
+	sum := sha256.Sum256([]byte(f.UserAgent + "\x00" + f.IP + "\x00" + f.DeviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoginNotificationPolicy configures an optional webhook that is called when
+// a user logs in from a never-before-seen client.
+type LoginNotificationPolicy struct {
+	// WebhookURL receives a JSON description of the new-device login via
+	// POST, empty disables the webhook.
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *LoginNotificationPolicy) CheckAndSetDefaults() error {
+	if p.WebhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(p.WebhookURL)
+	if err != nil {
+		return trace.BadParameter("invalid webhook URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return trace.BadParameter("webhook URL scheme must be http or https, got %q", u.Scheme)
+	}
+	return nil
+}
+
+// SetLoginNotificationPolicy configures the login notification policy of the
+// cluster.
+func (a *Server) SetLoginNotificationPolicy(ctx context.Context, policy LoginNotificationPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(loginNotificationPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetLoginNotificationPolicy returns the configured login notification
+// policy, with the webhook disabled when none was set.
+func (a *Server) GetLoginNotificationPolicy(ctx context.Context) (LoginNotificationPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(loginNotificationPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return LoginNotificationPolicy{}, nil
+		}
+		return LoginNotificationPolicy{}, trace.Wrap(err)
+	}
+	var policy LoginNotificationPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return LoginNotificationPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteLoginNotificationPolicy removes the login notification policy.
+func (a *Server) DeleteLoginNotificationPolicy(ctx context.Context) error {
+	err := a.bk.Delete(ctx, backend.Key(loginNotificationPolicyPrefix))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("login notification policy is not set")
+	}
+	return trace.Wrap(err)
+}
+
+// checkNewDeviceLogin records the fingerprint of a successful login and, when
+// the user has never logged in from this client before, emits a dedicated
+// audit event and calls the configured webhook. Detection is best effort and
+// never fails the login.
+func (s *Server) checkNewDeviceLogin(ctx context.Context, username string, md *ForwardedClientMetadata) {
+	if md == nil || (md.UserAgent == "" && md.RemoteAddr == "" && md.DeviceID == "") {
+		return
+	}
+	ip := md.RemoteAddr
+	if host, err := utils.Host(md.RemoteAddr); err == nil {
+		ip = host
+	}
+	fingerprint := LoginFingerprint{
+		User:      username,
+		UserAgent: md.UserAgent,
+		IP:        ip,
+		DeviceID:  md.DeviceID,
+	}
+	key := backend.Key(loginFingerprintPrefix, username, fingerprint.hash())
+	now := s.clock.Now().UTC()
+
+	item, err := s.bk.Get(ctx, key)
+	if err == nil {
+		// Known client, refresh the retention window.
+		var known LoginFingerprint
+		if err := utils.FastUnmarshal(item.Value, &known); err != nil {
+			log.WithError(err).Warn("Failed to decode a login fingerprint record.")
+			known = fingerprint
+			known.FirstSeen = now
+		}
+		known.LastSeen = now
+		s.upsertLoginFingerprint(ctx, key, known)
+		return
+	}
+	if !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to read a login fingerprint record.")
+		return
+	}
+
+	fingerprint.FirstSeen = now
+	fingerprint.LastSeen = now
+	s.upsertLoginFingerprint(ctx, key, fingerprint)
+
+	event := &apievents.UserLogin{
+		Metadata: apievents.Metadata{
+			Type: events.UserNewDeviceLoginEvent,
+			Code: events.UserNewDeviceLoginCode,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: username,
+		},
+		Status: apievents.Status{
+			Success: true,
+		},
+	}
+	event.RemoteAddr = md.RemoteAddr
+	event.UserAgent = md.UserAgent
+	if err := s.emitter.EmitAuditEvent(s.closeCtx, event); err != nil {
+		log.WithError(err).Warn("Failed to emit new device login event.")
+	}
+
+	policy, err := s.GetLoginNotificationPolicy(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load the login notification policy.")
+		return
+	}
+	if policy.WebhookURL != "" {
+		s.callNewDeviceWebhook(policy.WebhookURL, fingerprint)
+	}
+}
+
+func (s *Server) upsertLoginFingerprint(ctx context.Context, key []byte, fingerprint LoginFingerprint) {
+	value, err := utils.FastMarshal(fingerprint)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode a login fingerprint record.")
+		return
+	}
+	if _, err := s.bk.Put(ctx, backend.Item{
+		Key:     key,
+		Value:   value,
+		Expires: fingerprint.LastSeen.Add(loginFingerprintRetention),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to store a login fingerprint record.")
+	}
+}
+
+// callNewDeviceWebhook delivers the fingerprint to the configured webhook.
+// Delivery is best effort, failures are logged and do not affect the login.
+func (s *Server) callNewDeviceWebhook(webhookURL string, fingerprint LoginFingerprint) {
+	body, err := json.Marshal(fingerprint)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode the new device login webhook payload.")
+		return
+	}
+	client := &http.Client{Timeout: newDeviceWebhookTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.WithError(err).Warn("Failed to deliver the new device login webhook.")
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		log.Warnf("New device login webhook returned status %v.", resp.StatusCode)
+	}
+}
+
+// SetLoginNotificationPolicy configures the login notification policy of the
+// cluster.
+func (a *ServerWithRoles) SetLoginNotificationPolicy(ctx context.Context, policy LoginNotificationPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetLoginNotificationPolicy(ctx, policy)
+}
+
+// GetLoginNotificationPolicy returns the login notification policy of the
+// cluster. Webhook URLs routinely embed credentials and every user holds the
+// implicit read rule on cluster auth preference, so reading the policy back
+// takes the same permissions as setting it.
+func (a *ServerWithRoles) GetLoginNotificationPolicy(ctx context.Context) (LoginNotificationPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return LoginNotificationPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetLoginNotificationPolicy(ctx)
+}
+
+// DeleteLoginNotificationPolicy removes the login notification policy of the
+// cluster.
+func (a *ServerWithRoles) DeleteLoginNotificationPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteLoginNotificationPolicy(ctx)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/events"
+)
+
+func TestNewDeviceLoginDetection(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "fingerprint-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	var webhookCalls int32
+	var lastPayload LoginFingerprint
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&webhookCalls, 1)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&lastPayload))
+	}))
+	t.Cleanup(webhook.Close)
+
+	// Only http and https webhook URLs are accepted.
+	err = s.a.SetLoginNotificationPolicy(ctx, LoginNotificationPolicy{WebhookURL: "ftp://example.com"})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	require.NoError(t, s.a.SetLoginNotificationPolicy(ctx, LoginNotificationPolicy{WebhookURL: webhook.URL}))
+
+	req := AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: password},
+		ClientMetadata: &ForwardedClientMetadata{
+			UserAgent:  "tsh/10.0.0",
+			RemoteAddr: "10.0.0.5:3022",
+		},
+	}
+
+	// The first login from a client emits the new device event and calls the
+	// webhook.
+	_, err = s.a.AuthenticateUser(req)
+	require.NoError(t, err)
+	require.Equal(t, events.UserNewDeviceLoginCode, s.mockEmitter.LastEvent().GetCode())
+	require.Equal(t, int32(1), atomic.LoadInt32(&webhookCalls))
+	require.Equal(t, user, lastPayload.User)
+	require.Equal(t, "10.0.0.5", lastPayload.IP)
+
+	// Logging in again from the same client is quiet.
+	_, err = s.a.AuthenticateUser(req)
+	require.NoError(t, err)
+	require.Equal(t, events.UserLocalLoginCode, s.mockEmitter.LastEvent().GetCode())
+	require.Equal(t, int32(1), atomic.LoadInt32(&webhookCalls))
+
+	// A different user agent counts as a new client.
+	req.ClientMetadata.UserAgent = "Mozilla/5.0"
+	_, err = s.a.AuthenticateUser(req)
+	require.NoError(t, err)
+	require.Equal(t, events.UserNewDeviceLoginCode, s.mockEmitter.LastEvent().GetCode())
+	require.Equal(t, int32(2), atomic.LoadInt32(&webhookCalls))
+
+	// Failed logins do not record fingerprints.
+	req.ClientMetadata.UserAgent = "curl/7.79"
+	req.Pass = &PassCreds{Password: []byte("wrongpassword")}
+	_, err = s.a.AuthenticateUser(req)
+	require.Error(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&webhookCalls))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+)
+
+// LoginAttemptChecker is consulted before a web authentication request is
+// processed. Deployments facing credential stuffing can plug in defenses like
+// CAPTCHA verification or external risk scoring by installing a checker with
+// WithLoginAttemptChecker; the default implementation admits every attempt.
+type LoginAttemptChecker interface {
+	// CheckLoginAttempt inspects the authentication request before any
+	// credentials are verified. Returning an error rejects the attempt and
+	// the error is surfaced to the client.
+	CheckLoginAttempt(ctx context.Context, req AuthenticateUserRequest) error
+}
+
+// WithLoginAttemptChecker installs checker as the login attempt checker of
+// the auth server.
+func WithLoginAttemptChecker(checker LoginAttemptChecker) ServerOption {
+	return func(s *Server) error {
+		if checker == nil {
+			return trace.BadParameter("missing login attempt checker")
+		}
+		s.loginChecker = checker
+		return nil
+	}
+}
+
+// noopLoginAttemptChecker admits every login attempt.
+type noopLoginAttemptChecker struct{}
+
+// CheckLoginAttempt implements LoginAttemptChecker.
+func (noopLoginAttemptChecker) CheckLoginAttempt(ctx context.Context, req AuthenticateUserRequest) error {
+	return nil
+}
+
+// checkLoginAttempt runs the installed login attempt checker against the
+// request.
+func (s *Server) checkLoginAttempt(ctx context.Context, req AuthenticateUserRequest) error {
+	if s.loginChecker == nil {
+		return nil
+	}
+	if err := s.loginChecker.CheckLoginAttempt(ctx, req); err != nil {
+		log.Debugf("Login attempt for user %q rejected by the login attempt checker: %v.", req.Username, err)
+		return trace.Wrap(err)
+	}
+	return nil
+}
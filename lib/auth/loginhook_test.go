@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLoginChecker struct {
+	reject   bool
+	attempts int
+	lastUser string
+}
+
+func (c *fakeLoginChecker) CheckLoginAttempt(ctx context.Context, req AuthenticateUserRequest) error {
+	c.attempts++
+	c.lastUser = req.Username
+	if c.reject {
+		return trace.AccessDenied("rejected by automation defense")
+	}
+	return nil
+}
+
+func TestLoginAttemptChecker(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "login-hook-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	req := AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: password},
+	}
+
+	// The default checker admits every attempt.
+	require.NoError(t, s.a.checkLoginAttempt(ctx, req))
+
+	// A nil checker cannot be installed.
+	err = WithLoginAttemptChecker(nil)(s.a)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A rejecting checker blocks web authentication before credentials are
+	// even looked at.
+	checker := &fakeLoginChecker{reject: true}
+	require.NoError(t, WithLoginAttemptChecker(checker)(s.a))
+	_, err = s.a.AuthenticateWebUser(ctx, req)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Equal(t, "rejected by automation defense", err.Error())
+	require.Equal(t, 1, checker.attempts)
+	require.Equal(t, user, checker.lastUser)
+
+	// An admitting checker lets the request through to the usual credential
+	// verification.
+	checker.reject = false
+	badReq := req
+	badReq.Pass = &PassCreds{Password: []byte("wrongpassword")}
+	_, err = s.a.AuthenticateWebUser(ctx, badReq)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.NotEqual(t, "rejected by automation defense", err.Error())
+	require.Equal(t, 2, checker.attempts)
+}
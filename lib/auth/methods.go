@@ -28,9 +28,11 @@ import (
 	"github.com/gravitational/teleport/api/constants"
 	"github.com/gravitational/teleport/api/types"
 	apievents "github.com/gravitational/teleport/api/types/events"
+	apiutils "github.com/gravitational/teleport/api/utils"
 	wanlib "github.com/gravitational/teleport/lib/auth/webauthn"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/tlsca"
 	"github.com/gravitational/teleport/lib/utils"
 )
 
@@ -55,6 +57,9 @@ type AuthenticateUserRequest struct {
 type ForwardedClientMetadata struct {
 	UserAgent  string `json:"user_agent,omitempty"`
 	RemoteAddr string `json:"remote_addr,omitempty"`
+	// DeviceID is the trusted device ID of the client, forwarded by proxies
+	// that know it.
+	DeviceID string `json:"device_id,omitempty"`
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -94,7 +99,15 @@ type SessionCreds struct {
 func (s *Server) AuthenticateUser(req AuthenticateUserRequest) (string, error) {
 	user := req.Username
 
-	mfaDev, actualUser, err := s.authenticateUser(context.TODO(), req)
+	var mfaDev *types.MFADevice
+	var actualUser string
+	err := s.checkLoginThrottle(context.TODO(), req.ClientMetadata)
+	if err == nil {
+		mfaDev, actualUser, err = s.authenticateUser(context.TODO(), req)
+		if err != nil && !trace.IsConnectionProblem(err) {
+			s.recordFailedLoginAttempt(context.TODO(), req.ClientMetadata)
+		}
+	}
 	// err is handled below.
 	switch {
 	case user != "" && actualUser != "" && user != actualUser:
@@ -133,6 +146,9 @@ func (s *Server) AuthenticateUser(req AuthenticateUserRequest) (string, error) {
 	if err := s.emitter.EmitAuditEvent(s.closeCtx, event); err != nil {
 		log.WithError(err).Warn("Failed to emit login event.")
 	}
+	if err == nil {
+		s.checkNewDeviceLogin(context.TODO(), user, req.ClientMetadata)
+	}
 	return user, trace.Wrap(err)
 }
 
@@ -169,6 +185,20 @@ func (s *Server) authenticateUser(ctx context.Context, req AuthenticateUserReque
 		return s.authenticatePasswordless(ctx, req)
 	}
 
+	// When the cluster pins its local connector to passwordless the
+	// connector is a requirement, not just a default for clients to
+	// render, so password-backed methods are rejected outright.
+	if req.Pass != nil || req.OTP != nil {
+		authPreference, err := s.GetAuthPreference(ctx)
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		if requirePasswordless(authPreference) {
+			log.Warningf("Password authentication attempt by user %q while the cluster requires passwordless, access denied.", user)
+			return nil, "", trace.AccessDenied("this cluster requires passwordless authentication")
+		}
+	}
+
 	// Try 2nd-factor-enabled authentication schemes first.
 	var authenticateFn func() (*types.MFADevice, error)
 	var authErr error // error message kept obscure on purpose, use logging for details
@@ -269,6 +299,14 @@ func (s *Server) authenticateUser(ctx context.Context, req AuthenticateUserReque
 	return nil, user, nil
 }
 
+// requirePasswordless returns true when the cluster's local connector is
+// pinned to passwordless, which makes passwordless login mandatory for local
+// users rather than a client-side default.
+func requirePasswordless(authPref types.AuthPreference) bool {
+	return authPref.GetType() == constants.Local &&
+		authPref.GetConnectorName() == constants.PasswordlessConnector
+}
+
 func (s *Server) authenticatePasswordless(ctx context.Context, req AuthenticateUserRequest) (*types.MFADevice, string, error) {
 	mfaResponse := &proto.MFAAuthenticateResponse{
 		Response: &proto.MFAAuthenticateResponse_Webauthn{
@@ -298,6 +336,12 @@ func (s *Server) authenticatePasswordless(ctx context.Context, req AuthenticateU
 func (s *Server) AuthenticateWebUser(ctx context.Context, req AuthenticateUserRequest) (types.WebSession, error) {
 	username := req.Username // Empty if passwordless.
 
+	// Give anti-automation defenses a chance to reject the attempt before
+	// any credentials are processed.
+	if err := s.checkLoginAttempt(ctx, req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	authPref, err := s.GetAuthPreference(ctx)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -334,10 +378,15 @@ func (s *Server) AuthenticateWebUser(ctx context.Context, req AuthenticateUserRe
 		return nil, trace.Wrap(err)
 	}
 
+	if err := s.enforceWebSessionLimit(ctx, username, ""); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	sess, err := s.createUserWebSession(context.TODO(), user)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	s.recordWebSessionMetadata(ctx, sess, events.LoginMethodLocal, req.ClientMetadata)
 
 	return sess, nil
 }
@@ -356,6 +405,9 @@ type AuthenticateSSHRequest struct {
 	// KubernetesCluster sets the target kubernetes cluster for the TLS
 	// certificate. This can be empty on older clients.
 	KubernetesCluster string `json:"kubernetes_cluster"`
+	// DeviceID identifies the enrolled device the login is performed from,
+	// required when the cluster device trust policy demands one.
+	DeviceID string `json:"device_id,omitempty"`
 }
 
 // CheckAndSetDefaults checks and sets default certificate values
@@ -460,6 +512,14 @@ func (s *Server) AuthenticateSSHUser(ctx context.Context, req AuthenticateSSHReq
 		return nil, trace.Wrap(err)
 	}
 	accessInfo := services.AccessInfoFromUser(user)
+	// Access list memberships with a fresh review grant additional roles.
+	listRoles, err := s.accessListRolesForUser(ctx, username)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(listRoles) != 0 {
+		accessInfo.Roles = apiutils.Deduplicate(append(accessInfo.Roles, listRoles...))
+	}
 	checker, err := services.NewAccessChecker(accessInfo, clusterName.GetClusterName(), s)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -499,6 +559,8 @@ func (s *Server) AuthenticateSSHUser(ctx context.Context, req AuthenticateSSHReq
 		routeToCluster:    req.RouteToCluster,
 		kubernetesCluster: req.KubernetesCluster,
 		sourceIP:          sourceIP,
+		clientOrigin:      tlsca.ClientOriginTSH,
+		deviceID:          req.DeviceID,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// RenameMFADeviceRequest renames one of the devices of the user who owns the
+// supplied privilege token.
+type RenameMFADeviceRequest struct {
+	// TokenID is the ID of a privilege token issued after a fresh MFA
+	// challenge; it identifies and authorizes the user.
+	TokenID string `json:"token_id"`
+	// DeviceName is the current name or ID of the device.
+	DeviceName string `json:"device_name"`
+	// NewName is the name the device should get.
+	NewName string `json:"new_name"`
+}
+
+// RenameMFADevice renames an MFA device of the user who owns the privilege
+// token in the request.
+func (a *Server) RenameMFADevice(ctx context.Context, req RenameMFADeviceRequest) error {
+	if req.NewName == "" {
+		return trace.BadParameter("missing new device name")
+	}
+	token, err := a.GetUserToken(ctx, req.TokenID)
+	if err != nil {
+		log.Error(trace.DebugReport(err))
+		return trace.AccessDenied("invalid token")
+	}
+	if err := a.verifyUserToken(token, UserTokenTypeRecoveryApproved, UserTokenTypePrivilege); err != nil {
+		return trace.Wrap(err)
+	}
+	user := token.GetUser()
+
+	devs, err := a.Services.GetMFADevices(ctx, user, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var deviceToRename *types.MFADevice
+	for _, d := range devs {
+		// Match device by name or ID, same as deletion does.
+		if d.GetName() == req.DeviceName || d.Id == req.DeviceName {
+			deviceToRename = d
+			continue
+		}
+		if d.GetName() == req.NewName {
+			return trace.AlreadyExists("MFA device %q already exists", req.NewName)
+		}
+	}
+	if deviceToRename == nil {
+		return trace.NotFound("MFA device %q does not exist", req.DeviceName)
+	}
+	if deviceToRename.GetName() == req.NewName {
+		return nil
+	}
+
+	deviceToRename.Metadata.Name = req.NewName
+	if err := a.UpsertMFADevice(ctx, user, deviceToRename); err != nil {
+		return trace.Wrap(err)
+	}
+	log.Infof("MFA device %q of user %v renamed to %q.", req.DeviceName, user, req.NewName)
+	return nil
+}
+
+// MFADeviceInfo is a secret-free summary of a registered MFA device.
+type MFADeviceInfo struct {
+	// ID is the UUID of the device.
+	ID string `json:"id"`
+	// Name is the device name chosen by the user.
+	Name string `json:"name"`
+	// Type is the device type, e.g. TOTP or WebAuthn.
+	Type string `json:"type"`
+	// AddedAt is when the device was registered.
+	AddedAt time.Time `json:"added_at"`
+	// LastUsed is when the device last passed a challenge.
+	LastUsed time.Time `json:"last_used"`
+}
+
+// GetMFADevicesByUser returns the registered MFA devices of the given user
+// without secrets, so administrators can audit device hygiene.
+func (a *Server) GetMFADevicesByUser(ctx context.Context, user string) ([]MFADeviceInfo, error) {
+	devs, err := a.Services.GetMFADevices(ctx, user, false /* withSecrets */)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	infos := make([]MFADeviceInfo, 0, len(devs))
+	for _, d := range devs {
+		infos = append(infos, MFADeviceInfo{
+			ID:       d.Id,
+			Name:     d.GetName(),
+			Type:     d.MFAType(),
+			AddedAt:  d.AddedAt,
+			LastUsed: d.LastUsed,
+		})
+	}
+	return infos, nil
+}
+
+// RenameMFADevice renames an MFA device of the user who owns the privilege
+// token in the request.
+func (a *ServerWithRoles) RenameMFADevice(ctx context.Context, req RenameMFADeviceRequest) error {
+	// The token provides its own authorization and authentication.
+	return a.authServer.RenameMFADevice(ctx, req)
+}
+
+// GetMFADevicesByUser returns the registered MFA devices of the given user
+// without secrets, so administrators can audit device hygiene.
+func (a *ServerWithRoles) GetMFADevicesByUser(ctx context.Context, user string) ([]MFADeviceInfo, error) {
+	if err := a.action(apidefaults.Namespace, types.KindMFADevice, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetMFADevicesByUser(ctx, user)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/client/proto"
+)
+
+func TestRenameMFADevice(t *testing.T) {
+	t.Parallel()
+	svr := newTestTLSServer(t)
+	authServer := svr.Auth()
+	ctx := context.Background()
+	mfa := configureForMFA(t, svr)
+
+	userClient, err := svr.NewClient(TestUser(mfa.User))
+	require.NoError(t, err)
+
+	// Acquire a privilege token through a fresh MFA challenge.
+	mfaChallenge, err := userClient.CreateAuthenticateChallenge(ctx, &proto.CreateAuthenticateChallengeRequest{
+		Request: &proto.CreateAuthenticateChallengeRequest_ContextUser{
+			ContextUser: &proto.ContextUser{},
+		},
+	})
+	require.NoError(t, err)
+	mfaResp, err := mfa.WebDev.SolveAuthn(mfaChallenge)
+	require.NoError(t, err)
+	token, err := userClient.CreatePrivilegeToken(ctx, &proto.CreatePrivilegeTokenRequest{
+		ExistingMFAResponse: mfaResp,
+	})
+	require.NoError(t, err)
+
+	// Rename without a valid token is rejected.
+	err = authServer.RenameMFADevice(ctx, RenameMFADeviceRequest{
+		TokenID:    "invalid",
+		DeviceName: "totp-1",
+		NewName:    "phone",
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// Renaming to an existing device name is rejected.
+	err = authServer.RenameMFADevice(ctx, RenameMFADeviceRequest{
+		TokenID:    token.GetName(),
+		DeviceName: "totp-1",
+		NewName:    "web-1",
+	})
+	require.True(t, trace.IsAlreadyExists(err), "expected AlreadyExists, got %v", err)
+
+	// A valid rename sticks.
+	err = authServer.RenameMFADevice(ctx, RenameMFADeviceRequest{
+		TokenID:    token.GetName(),
+		DeviceName: "totp-1",
+		NewName:    "phone",
+	})
+	require.NoError(t, err)
+
+	devices, err := authServer.GetMFADevicesByUser(ctx, mfa.User)
+	require.NoError(t, err)
+	names := make([]string, 0, len(devices))
+	for _, d := range devices {
+		names = append(names, d.Name)
+	}
+	require.ElementsMatch(t, []string{"phone", "web-1"}, names)
+}
+
+func TestGetMFADevicesByUser(t *testing.T) {
+	t.Parallel()
+	svr := newTestTLSServer(t)
+	ctx := context.Background()
+	mfa := configureForMFA(t, svr)
+
+	// Admins can audit any user's devices, without secrets.
+	adminClient, err := svr.NewClient(TestAdmin())
+	require.NoError(t, err)
+	devices, err := adminClient.GetMFADevicesByUser(ctx, mfa.User)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	for _, d := range devices {
+		require.NotEmpty(t, d.ID)
+		require.NotEmpty(t, d.Type)
+	}
+
+	// Regular users may not list other users' devices.
+	other := "mfa-list-other"
+	_, _, err = CreateUserAndRole(svr.Auth(), other, []string{other})
+	require.NoError(t, err)
+	otherClient, err := svr.NewClient(TestUser(other))
+	require.NoError(t, err)
+	_, err = otherClient.GetMFADevicesByUser(ctx, mfa.User)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+}
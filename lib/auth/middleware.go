@@ -23,6 +23,7 @@ import (
 	"math"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gravitational/teleport"
@@ -44,6 +45,7 @@ import (
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 )
 
@@ -68,6 +70,9 @@ type TLSServerConfig struct {
 	ID string
 	// Metrics are optional TLSServer metrics
 	Metrics *Metrics
+	// MaxConcurrentRequestsPerIdentity caps the number of in-flight RPCs a
+	// single user identity may have, negative values disable the limit
+	MaxConcurrentRequestsPerIdentity int64
 }
 
 // CheckAndSetDefaults checks and sets default values
@@ -100,6 +105,9 @@ func (c *TLSServerConfig) CheckAndSetDefaults() error {
 	if c.Metrics == nil {
 		c.Metrics = &Metrics{}
 	}
+	if c.MaxConcurrentRequestsPerIdentity == 0 {
+		c.MaxConcurrentRequestsPerIdentity = defaults.LimiterMaxConcurrentRequestsPerIdentity
+	}
 	return nil
 }
 
@@ -145,10 +153,12 @@ func NewTLSServer(cfg TLSServerConfig) (*TLSServer, error) {
 	// adds authentication information to the context
 	// and passes it to the API server
 	authMiddleware := &Middleware{
-		AccessPoint:   cfg.AccessPoint,
-		AcceptedUsage: cfg.AcceptedUsage,
-		Limiter:       limiter,
-		GRPCMetrics:   grpcMetrics,
+		AccessPoint:                      cfg.AccessPoint,
+		AcceptedUsage:                    cfg.AcceptedUsage,
+		Limiter:                          limiter,
+		GRPCMetrics:                      grpcMetrics,
+		MaxConcurrentRequestsPerIdentity: cfg.MaxConcurrentRequestsPerIdentity,
+		CertExpiryWarningWindow:          DefaultCertExpiryWarningWindow,
 	}
 
 	apiServer, err := NewAPIServer(&cfg.APIConfig)
@@ -329,6 +339,22 @@ type Middleware struct {
 	Limiter *limiter.Limiter
 	// GRPCMetrics is the configured grpc metrics for the interceptors
 	GRPCMetrics *om.ServerMetrics
+	// MaxConcurrentRequestsPerIdentity caps the number of in-flight RPCs a
+	// single user identity may have, so one client with a runaway watcher
+	// or bulk scan can not consume all server capacity. Zero or negative
+	// values disable the limit.
+	MaxConcurrentRequestsPerIdentity int64
+	// CertExpiryWarningWindow is how close to its expiry a client
+	// certificate has to be before responses carry an expiry warning, so
+	// clients can renew proactively instead of failing mid-operation. Zero
+	// or negative values disable the warnings.
+	CertExpiryWarningWindow time.Duration
+
+	// identityInFlightMu guards identityInFlight
+	identityInFlightMu sync.Mutex
+	// identityInFlight tracks the number of in-flight requests per user
+	// identity
+	identityInFlight map[string]int64
 }
 
 // Wrap sets next handler in chain
@@ -336,6 +362,76 @@ func (a *Middleware) Wrap(h http.Handler) {
 	a.Handler = h
 }
 
+// Response metadata keys carrying certificate warnings to clients. They are
+// set both as HTTP response headers and as gRPC header metadata.
+const (
+	// CertExpiryWarningHeader carries the expiry time of the client
+	// certificate, RFC 3339 encoded, when it falls within the warning
+	// window.
+	CertExpiryWarningHeader = "x-teleport-cert-expires"
+	// CertRotationWarningHeader carries the rotation phase of the user CA
+	// when a rotation is in progress and clients should reissue their
+	// certificates.
+	CertRotationWarningHeader = "x-teleport-ca-rotation"
+)
+
+// DefaultCertExpiryWarningWindow is how close to its expiry a client
+// certificate has to be before the middleware starts warning the client.
+const DefaultCertExpiryWarningWindow = 10 * time.Minute
+
+// clientWarnings returns metadata values warning the client about imminent
+// certificate expiry or an in-progress CA rotation, keyed by header name.
+func (a *Middleware) clientWarnings(ctx context.Context, user IdentityGetter) map[string]string {
+	if a.CertExpiryWarningWindow <= 0 {
+		return nil
+	}
+	warnings := make(map[string]string)
+	expires := user.GetIdentity().Expires
+	if !expires.IsZero() && time.Until(expires) <= a.CertExpiryWarningWindow {
+		warnings[CertExpiryWarningHeader] = expires.UTC().Format(time.RFC3339)
+	}
+	if phase := a.rotationPhase(ctx); phase != "" {
+		warnings[CertRotationWarningHeader] = phase
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// clientWarningsFromContext is like clientWarnings for contexts that passed
+// through the authentication middleware.
+func (a *Middleware) clientWarningsFromContext(ctx context.Context) map[string]string {
+	user, ok := ctx.Value(ContextUser).(IdentityGetter)
+	if !ok {
+		return nil
+	}
+	return a.clientWarnings(ctx, user)
+}
+
+// rotationPhase returns the rotation phase of the local user CA when a
+// rotation is in progress, or an empty string.
+func (a *Middleware) rotationPhase(ctx context.Context) string {
+	clusterName, err := a.AccessPoint.GetClusterName()
+	if err != nil {
+		return ""
+	}
+	ca, err := a.AccessPoint.GetCertAuthority(ctx, types.CertAuthID{
+		Type:       types.UserCA,
+		DomainName: clusterName.GetClusterName(),
+	}, false)
+	if err != nil {
+		return ""
+	}
+	rotation := ca.GetRotation()
+	switch rotation.Phase {
+	case types.RotationPhaseInit, types.RotationPhaseUpdateClients,
+		types.RotationPhaseUpdateServers, types.RotationPhaseRollback:
+		return rotation.Phase
+	}
+	return ""
+}
+
 func getCustomRate(endpoint string) *ratelimit.RateSet {
 	switch endpoint {
 	// Account recovery RPCs.
@@ -381,10 +477,69 @@ func (a *Middleware) withAuthenticatedUser(ctx context.Context) (context.Context
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := checkPinnedIP(user, peerInfo.Addr.String()); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	ctx = context.WithValue(ctx, ContextClientAddr, peerInfo.Addr)
 	return context.WithValue(ctx, ContextUser, user), nil
 }
 
+// checkPinnedIP rejects the connection when the presented certificate is
+// pinned to a source IP and the peer connects from a different address.
+func checkPinnedIP(user IdentityGetter, remoteAddr string) error {
+	pinnedIP := user.GetIdentity().PinnedIP
+	if pinnedIP == "" {
+		return nil
+	}
+	host, err := utils.Host(remoteAddr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if host != pinnedIP {
+		log.Warnf("Rejecting connection of user %q: certificate is pinned to %v, connection from %v.",
+			user.GetIdentity().Username, pinnedIP, host)
+		return trace.AccessDenied("access denied: certificate is pinned to another source address")
+	}
+	return nil
+}
+
+// acquireRequestSlot reserves an in-flight request slot for the calling
+// identity and returns a function releasing the slot when the request
+// completes. Built-in server identities (proxies, nodes and other services)
+// legitimately hold many concurrent streams and are not limited.
+func (a *Middleware) acquireRequestSlot(ctx context.Context) (func(), error) {
+	if a.MaxConcurrentRequestsPerIdentity <= 0 {
+		return func() {}, nil
+	}
+	var username string
+	switch user := ctx.Value(ContextUser).(type) {
+	case LocalUser:
+		username = user.Username
+	case RemoteUser:
+		username = user.ClusterName + "." + user.Username
+	default:
+		return func() {}, nil
+	}
+	a.identityInFlightMu.Lock()
+	defer a.identityInFlightMu.Unlock()
+	if a.identityInFlight[username] >= a.MaxConcurrentRequestsPerIdentity {
+		identityRequestsRejected.Inc()
+		return nil, trace.LimitExceeded("identity %q has exceeded the limit of %v concurrent requests, try again later", username, a.MaxConcurrentRequestsPerIdentity)
+	}
+	if a.identityInFlight == nil {
+		a.identityInFlight = make(map[string]int64)
+	}
+	a.identityInFlight[username]++
+	return func() {
+		a.identityInFlightMu.Lock()
+		defer a.identityInFlightMu.Unlock()
+		a.identityInFlight[username]--
+		if a.identityInFlight[username] <= 0 {
+			delete(a.identityInFlight, username)
+		}
+	}, nil
+}
+
 // withAuthenticatedUserUnaryInterceptor is a gRPC unary server interceptor
 // which sets the ContextUser field on the request context to the caller's user
 // identity as authenticated by their client TLS certificate.
@@ -393,6 +548,16 @@ func (a *Middleware) withAuthenticatedUserUnaryInterceptor(ctx context.Context,
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	release, err := a.acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer release()
+	if warnings := a.clientWarningsFromContext(ctx); len(warnings) != 0 {
+		if err := grpc.SetHeader(ctx, metadata.New(warnings)); err != nil {
+			logrus.WithError(err).Debug("Failed to set certificate warning headers.")
+		}
+	}
 	return handler(ctx, req)
 }
 
@@ -404,6 +569,16 @@ func (a *Middleware) withAuthenticatedUserStreamInterceptor(srv interface{}, ser
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	release, err := a.acquireRequestSlot(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer release()
+	if warnings := a.clientWarningsFromContext(ctx); len(warnings) != 0 {
+		if err := serverStream.SetHeader(metadata.New(warnings)); err != nil {
+			logrus.WithError(err).Debug("Failed to set certificate warning headers.")
+		}
+	}
 	return handler(srv, &authenticatedStream{ctx: ctx, ServerStream: serverStream})
 }
 
@@ -605,6 +780,14 @@ func (a *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		trace.WriteError(w, err)
 		return
 	}
+	if err := checkPinnedIP(user, r.RemoteAddr); err != nil {
+		trace.WriteError(w, err)
+		return
+	}
+
+	for key, value := range a.clientWarnings(baseContext, user) {
+		w.Header().Set(key, value)
+	}
 
 	// determine authenticated user based on the request parameters
 	requestWithContext := r.WithContext(context.WithValue(baseContext, ContextUser, user))
@@ -625,6 +808,9 @@ func (a *Middleware) WrapContextWithUser(ctx context.Context, conn utils.TLSConn
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := checkPinnedIP(user, conn.RemoteAddr().String()); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	requestWithContext := context.WithValue(ctx, ContextUser, user)
 	return requestWithContext, nil
 }
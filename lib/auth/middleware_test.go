@@ -23,6 +23,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gravitational/trace"
+
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/tlsca"
@@ -213,3 +215,45 @@ func TestMiddlewareGetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckPinnedIP(t *testing.T) {
+	t.Parallel()
+	pinnedIdentity := tlsca.Identity{
+		Username: "pinned-user",
+		Groups:   []string{"role"},
+		PinnedIP: "192.0.2.10",
+	}
+	tests := []struct {
+		desc       string
+		identity   tlsca.Identity
+		remoteAddr string
+		assertErr  require.ErrorAssertionFunc
+	}{
+		{
+			desc:       "unpinned certificates connect from anywhere",
+			identity:   tlsca.Identity{Username: "user", Groups: []string{"role"}},
+			remoteAddr: "198.51.100.1:3022",
+			assertErr:  require.NoError,
+		},
+		{
+			desc:       "pinned certificate from the pinned address",
+			identity:   pinnedIdentity,
+			remoteAddr: "192.0.2.10:54321",
+			assertErr:  require.NoError,
+		},
+		{
+			desc:       "pinned certificate from another address",
+			identity:   pinnedIdentity,
+			remoteAddr: "198.51.100.1:54321",
+			assertErr: func(t require.TestingT, err error, i ...interface{}) {
+				require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := checkPinnedIP(WrapIdentity(tt.identity), tt.remoteAddr)
+			tt.assertErr(t, err)
+		})
+	}
+}
@@ -317,12 +317,28 @@ func (k *Keygen) GenerateUserCertWithoutValidation(c services.UserCertParams) ([
 	}
 
 	for _, extension := range c.CertificateExtensions {
-		// TODO(lxea): update behavior when non ssh, non extensions are supported.
-		if extension.Mode != types.CertExtensionMode_EXTENSION ||
-			extension.Type != types.CertExtensionType_SSH {
+		// TODO(lxea): update behavior when non-ssh extensions are supported.
+		if extension.Type != types.CertExtensionType_SSH {
 			continue
 		}
-		cert.Extensions[extension.Name] = extension.Value
+		switch extension.Mode {
+		case types.CertExtensionMode_EXTENSION:
+			// values set by Teleport itself and by earlier roles win over
+			// later role-configured ones
+			if _, ok := cert.Extensions[extension.Name]; ok {
+				continue
+			}
+			cert.Extensions[extension.Name] = extension.Value
+		case types.CertExtensionModeCriticalOption:
+			if cert.CriticalOptions == nil {
+				cert.CriticalOptions = make(map[string]string)
+			}
+			// a source-address pin or an option from an earlier role wins
+			if _, ok := cert.CriticalOptions[extension.Name]; ok {
+				continue
+			}
+			cert.CriticalOptions[extension.Name] = extension.Value
+		}
 	}
 
 	// Add roles, traits, and route to cluster in the certificate extensions if
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// nodePresenceHistoryPrefix is the backend prefix under which the compacted
+// per-node presence history is stored.
+const nodePresenceHistoryPrefix = "node_history"
+
+// nodePresenceRetention is how long presence history is kept.
+const nodePresenceRetention = 30 * 24 * time.Hour
+
+// nodePresenceMergeWindow is the maximum heartbeat gap folded into the
+// current presence interval. Longer gaps start a new interval.
+const nodePresenceMergeWindow = 30 * time.Minute
+
+// maxNodePresenceRecords caps the number of intervals kept per node, so
+// nodes that flap or relabel frequently can not grow their history
+// unboundedly.
+const maxNodePresenceRecords = 256
+
+// NodePresenceRecord is one compacted interval during which a node was
+// present with a fixed set of labels.
+type NodePresenceRecord struct {
+	// Name is the node id (host UUID).
+	Name string `json:"name"`
+	// Hostname is the hostname the node reported during the interval.
+	Hostname string `json:"hostname"`
+	// Labels is the combined set of static and dynamic labels the node
+	// carried during the interval.
+	Labels map[string]string `json:"labels,omitempty"`
+	// FirstSeen is the first heartbeat of the interval.
+	FirstSeen time.Time `json:"first_seen"`
+	// LastSeen is the last heartbeat of the interval.
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// nodePresenceHistory is the backend representation of a node's compacted
+// presence history, ordered oldest first.
+type nodePresenceHistory struct {
+	// Records is the list of presence intervals.
+	Records []NodePresenceRecord `json:"records"`
+}
+
+// recordNodePresence folds a heartbeat into the node's compacted presence
+// history. Consecutive heartbeats with identical labels extend the current
+// interval; a label change or a long gap starts a new one.
+func (a *Server) recordNodePresence(ctx context.Context, server types.Server) error {
+	key := backend.Key(nodePresenceHistoryPrefix, server.GetNamespace(), server.GetName())
+	var history nodePresenceHistory
+	item, err := a.bk.Get(ctx, key)
+	if err == nil {
+		if err := utils.FastUnmarshal(item.Value, &history); err != nil {
+			return trace.Wrap(err)
+		}
+	} else if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+
+	now := a.clock.Now().UTC()
+	labels := server.GetAllLabels()
+	if n := len(history.Records); n > 0 {
+		last := &history.Records[n-1]
+		if utils.StringMapsEqual(last.Labels, labels) && now.Sub(last.LastSeen) <= nodePresenceMergeWindow {
+			last.LastSeen = now
+			last.Hostname = server.GetHostname()
+			return trace.Wrap(a.putNodePresenceHistory(ctx, key, &history, now))
+		}
+	}
+	history.Records = append(history.Records, NodePresenceRecord{
+		Name:      server.GetName(),
+		Hostname:  server.GetHostname(),
+		Labels:    labels,
+		FirstSeen: now,
+		LastSeen:  now,
+	})
+	return trace.Wrap(a.putNodePresenceHistory(ctx, key, &history, now))
+}
+
+// putNodePresenceHistory compacts and stores a node's presence history:
+// intervals past the retention period are dropped and the interval count is
+// capped, keeping the most recent ones.
+func (a *Server) putNodePresenceHistory(ctx context.Context, key []byte, history *nodePresenceHistory, now time.Time) error {
+	cutoff := now.Add(-nodePresenceRetention)
+	records := history.Records[:0]
+	for _, record := range history.Records {
+		if record.LastSeen.Before(cutoff) {
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) > maxNodePresenceRecords {
+		records = records[len(records)-maxNodePresenceRecords:]
+	}
+	history.Records = records
+
+	value, err := utils.FastMarshal(history)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     key,
+		Value:   value,
+		Expires: now.Add(nodePresenceRetention),
+	})
+	return trace.Wrap(err)
+}
+
+// GetNodesAt returns the nodes that were present in the namespace at the
+// given time together with the labels they carried, reconstructed from the
+// compacted presence history, ordered by node id.
+func (a *Server) GetNodesAt(ctx context.Context, namespace string, at time.Time) ([]NodePresenceRecord, error) {
+	if namespace == "" {
+		namespace = apidefaults.Namespace
+	}
+	if at.IsZero() {
+		return nil, trace.BadParameter("missing timestamp to query the node history at")
+	}
+	startKey := backend.Key(nodePresenceHistoryPrefix, namespace)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var nodes []NodePresenceRecord
+	for _, item := range result.Items {
+		var history nodePresenceHistory
+		if err := utils.FastUnmarshal(item.Value, &history); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, record := range history.Records {
+			// heartbeats are periodic, so an interval covers the merge
+			// window past its last heartbeat
+			if !at.Before(record.FirstSeen) && !at.After(record.LastSeen.Add(nodePresenceMergeWindow)) {
+				nodes = append(nodes, record)
+				break
+			}
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+// GetNodesAt returns the nodes that were present in the namespace at the
+// given time, reconstructed from the compacted presence history.
+func (a *ServerWithRoles) GetNodesAt(ctx context.Context, namespace string, at time.Time) ([]NodePresenceRecord, error) {
+	if err := a.action(namespace, types.KindNode, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetNodesAt(ctx, namespace, at)
+}
@@ -469,6 +469,19 @@ func (a *Server) validateOIDCAuthCallback(ctx context.Context, diagCtx *ssoDiagC
 		log.Debugf("OIDC ACR values %q successfully validated.", acrValue)
 	}
 
+	// When the second factor is delegated to the IdP, an accepted amr/acr
+	// claim marks the issued certificates as MFA-verified so the user is not
+	// prompted a second time by Teleport.
+	mfaVerified := ""
+	methods, contexts := oidcMFAClaims(claims)
+	verified, err := a.ssoMFAVerified(ctx, methods, contexts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if verified {
+		mfaVerified = SSOMFAVerifiedMarker
+	}
+
 	ident, err := oidc.IdentityFromClaims(claims)
 	if err != nil {
 		return nil, trace.OAuth2(
@@ -543,12 +556,13 @@ func (a *Server) validateOIDCAuthCallback(ctx context.Context, diagCtx *ssoDiagC
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create web session.")
 		}
+		a.recordWebSessionMetadata(ctx, session, events.LoginMethodOIDC, nil)
 		auth.Session = session
 	}
 
 	// If a public key was provided, sign it and return a certificate.
 	if len(req.PublicKey) != 0 {
-		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, req.PublicKey, req.Compatibility, req.RouteToCluster, req.KubernetesCluster)
+		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, req.PublicKey, req.Compatibility, req.RouteToCluster, req.KubernetesCluster, mfaVerified)
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create session certificate.")
 		}
@@ -914,6 +928,20 @@ func getOAuthClient(oidcClient *oidc.Client, connector types.OIDCConnector) (*oa
 	return oac, err
 }
 
+// oidcMFAClaims extracts the authentication method (amr) and context (acr)
+// claims the IdP asserted, for evaluation against the SSO MFA delegation
+// policy. Absent or malformed claims yield empty results rather than errors:
+// they simply do not prove MFA.
+func oidcMFAClaims(claims jose.Claims) (methods, contexts []string) {
+	if amr, ok, _ := claims.StringsClaim("amr"); ok {
+		methods = amr
+	}
+	if acr, ok, _ := claims.StringClaim("acr"); ok && acr != "" {
+		contexts = []string{acr}
+	}
+	return methods, contexts
+}
+
 // validateACRValues validates that we get an appropriate response for acr values. By default
 // we expect the same value we send, but this function also handles Identity Provider specific
 // forms of validation.
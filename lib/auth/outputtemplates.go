@@ -0,0 +1,187 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"text/template"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// outputTemplatePrefix is the backend prefix under which output templates
+// are stored.
+const outputTemplatePrefix = "output_templates"
+
+// Output template kinds describing the type of client configuration a
+// template renders.
+const (
+	// OutputTemplateKindKubeconfig renders a kubeconfig file.
+	OutputTemplateKindKubeconfig = "kubeconfig"
+	// OutputTemplateKindSSHConfig renders an OpenSSH client configuration.
+	OutputTemplateKindSSHConfig = "ssh_config"
+	// OutputTemplateKindDatabaseConfig renders a database client
+	// configuration.
+	OutputTemplateKindDatabaseConfig = "db_config"
+)
+
+// OutputTemplate is a server-stored template for a client configuration file
+// that is rendered for machines consuming Teleport identities, so each bot
+// does not have to reimplement config file generation.
+type OutputTemplate struct {
+	// Name is a unique name of the template.
+	Name string `json:"name"`
+	// Kind describes the type of configuration the template renders, see
+	// OutputTemplateKind constants.
+	Kind string `json:"kind"`
+	// Template is the text/template body. It may reference .ClusterName,
+	// .ProxyAddr, .Username and .Roles.
+	Template string `json:"template"`
+	// UpdatedAt is the time the template was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the output template.
+func (t *OutputTemplate) CheckAndSetDefaults() error {
+	if t.Name == "" {
+		return trace.BadParameter("missing output template name")
+	}
+	switch t.Kind {
+	case OutputTemplateKindKubeconfig, OutputTemplateKindSSHConfig, OutputTemplateKindDatabaseConfig:
+	default:
+		return trace.BadParameter("unsupported output template kind %q", t.Kind)
+	}
+	if _, err := template.New(t.Name).Parse(t.Template); err != nil {
+		return trace.BadParameter("failed to parse output template %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+// OutputTemplateData is the data available to output templates during
+// rendering.
+type OutputTemplateData struct {
+	// ClusterName is the name of this Teleport cluster.
+	ClusterName string `json:"cluster_name"`
+	// ProxyAddr is the public address of a cluster proxy, if known.
+	ProxyAddr string `json:"proxy_addr"`
+	// Username is the name of the identity the output is rendered for.
+	Username string `json:"username"`
+	// Roles are the roles held by the identity.
+	Roles []string `json:"roles"`
+}
+
+// RenderedOutput is a single rendered output destination.
+type RenderedOutput struct {
+	// Name is the name of the template that produced the output.
+	Name string `json:"name"`
+	// Kind is the kind of the template that produced the output.
+	Kind string `json:"kind"`
+	// Contents is the rendered configuration file.
+	Contents string `json:"contents"`
+}
+
+// UpsertOutputTemplate creates or updates an output template.
+func (a *Server) UpsertOutputTemplate(ctx context.Context, tmpl OutputTemplate) error {
+	if err := tmpl.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	tmpl.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(tmpl)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(outputTemplatePrefix, tmpl.Name),
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetOutputTemplates returns all stored output templates.
+func (a *Server) GetOutputTemplates(ctx context.Context) ([]OutputTemplate, error) {
+	startKey := backend.Key(outputTemplatePrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	templates := make([]OutputTemplate, 0, len(result.Items))
+	for _, item := range result.Items {
+		var tmpl OutputTemplate
+		if err := utils.FastUnmarshal(item.Value, &tmpl); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// DeleteOutputTemplate removes an output template.
+func (a *Server) DeleteOutputTemplate(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("missing output template name")
+	}
+	if err := a.bk.Delete(ctx, backend.Key(outputTemplatePrefix, name)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("output template %q not found", name)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// RenderOutputTemplates renders all stored output templates for the given
+// identity, typically alongside a bot certificate renewal.
+func (a *Server) RenderOutputTemplates(ctx context.Context, data OutputTemplateData) ([]RenderedOutput, error) {
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	data.ClusterName = clusterName.GetClusterName()
+	if data.ProxyAddr == "" {
+		if proxies, err := a.GetProxies(); err == nil && len(proxies) != 0 {
+			data.ProxyAddr = proxies[0].GetPublicAddr()
+		}
+	}
+	templates, err := a.GetOutputTemplates(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	outputs := make([]RenderedOutput, 0, len(templates))
+	for _, tmpl := range templates {
+		parsed, err := template.New(tmpl.Name).Parse(tmpl.Template)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to parse output template %q", tmpl.Name)
+		}
+		var buf bytes.Buffer
+		if err := parsed.Execute(&buf, data); err != nil {
+			return nil, trace.Wrap(err, "failed to render output template %q", tmpl.Name)
+		}
+		outputs = append(outputs, RenderedOutput{
+			Name:     tmpl.Name,
+			Kind:     tmpl.Kind,
+			Contents: buf.String(),
+		})
+	}
+	return outputs, nil
+}
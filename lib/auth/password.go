@@ -20,9 +20,7 @@ import (
 	"net/mail"
 
 	"github.com/gravitational/trace"
-	"github.com/pquerna/otp"
 	"github.com/pquerna/otp/totp"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/client/proto"
@@ -33,6 +31,7 @@ import (
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/passwordhash"
 )
 
 // This is bcrypt hash for password "barbaz".
@@ -106,8 +105,25 @@ func (s *Server) ChangePassword(req services.ChangePasswordReq) error {
 		return trace.Wrap(err)
 	}
 
-	// Authenticate.
+	// Rotating credentials requires proving the current ones: the existing
+	// password when one is set and, for users with enrolled devices, a fresh
+	// second factor response even when the cluster does not mandate one. A
+	// hijacked web session alone is not enough to take over the account.
 	user := req.User
+	if _, err := s.GetPasswordHash(user); err == nil && len(req.OldPassword) == 0 {
+		return trace.BadParameter("current password is required to set a new one")
+	} else if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	devs, err := s.Services.GetMFADevices(ctx, user, false /* withSecrets */)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(devs) != 0 && req.SecondFactorToken == "" && req.WebauthnResponse == nil {
+		return trace.AccessDenied("second factor authentication is required to change the password")
+	}
+
+	// Authenticate.
 	authReq := AuthenticateUserRequest{
 		Username: user,
 		Webauthn: req.WebauthnResponse,
@@ -166,17 +182,35 @@ func (s *Server) checkPasswordWOToken(user string, password []byte) error {
 		hash = fakePasswordHash
 	}
 
-	if err = bcrypt.CompareHashAndPassword(hash, password); err != nil {
+	if err = passwordhash.Compare(hash, password); err != nil {
 		log.Debugf("Password for %q does not match", user)
 		return trace.BadParameter(errMsg)
 	}
 
-	// Careful! The bcrypt check above may succeed for an unknown user when the
+	// Careful! The check above may succeed for an unknown user when the
 	// provided password is "barbaz", which is what fakePasswordHash hashes to.
 	if !userFound {
 		return trace.BadParameter(errMsg)
 	}
 
+	if err := s.checkPasswordAge(context.TODO(), user); err != nil {
+		return trace.Wrap(err)
+	}
+
+	// Transparently migrate the stored hash to the configured hashing method
+	// now that the plaintext password is known to be correct. The hash is
+	// replaced in place: the password itself does not change, so the policy
+	// checks and history bookkeeping of UpsertPassword do not apply.
+	if policy, err := s.GetPasswordHashPolicy(context.TODO()); err == nil && passwordhash.Method(hash) != policy.Method {
+		newHash, err := policy.hash(password)
+		if err == nil {
+			err = s.Services.UpsertPasswordHash(user, newHash)
+		}
+		if err != nil {
+			log.WithError(err).Warnf("Failed to re-hash password for %q under the %v policy.", user, policy.Method)
+		}
+	}
+
 	return nil
 }
 
@@ -266,14 +300,17 @@ func (s *Server) checkTOTP(ctx context.Context, user, otpToken string, dev *type
 	if dev.GetTotp() == nil {
 		return trace.BadParameter("checkTOTP called with non-TOTP MFADevice %T", dev.Device)
 	}
+	policy, err := s.GetTOTPPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	opts, err := policy.validateOpts()
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	// we use totp.ValidateCustom over totp.Validate so we can use
 	// a fake clock in tests to get reliable results
-	valid, err := totp.ValidateCustom(otpToken, dev.GetTotp().Key, s.clock.Now(), totp.ValidateOpts{
-		Period:    teleport.TOTPValidityPeriod,
-		Skew:      teleport.TOTPSkew,
-		Digits:    otp.DigitsSix,
-		Algorithm: otp.AlgorithmSHA1,
-	})
+	valid, err := totp.ValidateCustom(otpToken, dev.GetTotp().Key, s.clock.Now(), opts)
 	if err != nil {
 		return trace.AccessDenied("failed to validate TOTP code: %v", err)
 	}
@@ -318,6 +355,8 @@ func (s *Server) changeUserAuthentication(ctx context.Context, req *proto.Change
 
 	reqPasswordless := len(req.GetNewPassword()) == 0 && authPref.GetAllowPasswordless()
 	switch {
+	case len(req.GetNewPassword()) != 0 && requirePasswordless(authPref):
+		return nil, trace.BadParameter("this cluster requires passwordless authentication, register a passwordless device instead of setting a password")
 	case reqPasswordless:
 		if req.GetNewMFARegisterResponse() == nil || req.NewMFARegisterResponse.GetWebauthn() == nil {
 			return nil, trace.BadParameter("passwordless: missing webauthn credentials")
@@ -338,6 +377,18 @@ func (s *Server) changeUserAuthentication(ctx context.Context, req *proto.Change
 		return nil, trace.BadParameter("expired token")
 	}
 
+	// The reset flow wipes enrolled second factors before the token is issued
+	// and invite tokens belong to new accounts, so remaining devices mean the
+	// token was not minted by either. Refuse to rotate credentials that are
+	// still protected by a second factor.
+	devs, err := s.Services.GetMFADevices(ctx, token.GetUser(), false /* withSecrets */)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(devs) != 0 {
+		return nil, trace.AccessDenied("user %q still has enrolled second factors, request a password reset to rotate credentials", token.GetUser())
+	}
+
 	err = s.changeUserSecondFactor(ctx, req, token)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -715,3 +715,62 @@ func (s *passwordSuite) prepareForPasswordChange(user string, pass []byte, secon
 
 	return req, nil
 }
+
+func TestChangePasswordRequiresCurrentCredentials(t *testing.T) {
+	t.Parallel()
+
+	s := setupPasswordSuite(t)
+	ctx := context.Background()
+	req, err := s.prepareForPasswordChange("user3", []byte("abc123"), constants.SecondFactorOff)
+	require.NoError(t, err)
+
+	// Omitting the current password is rejected outright.
+	err = s.a.ChangePassword(services.ChangePasswordReq{
+		User:        req.User,
+		NewPassword: []byte("abce456"),
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Users with enrolled devices must present a fresh second factor even
+	// though the cluster itself does not mandate one.
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+	otpSecret := base32.StdEncoding.EncodeToString([]byte("def456"))
+	dev, err := services.NewTOTPDevice("otp", otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertMFADevice(ctx, req.User, dev))
+
+	req.NewPassword = []byte("abce456")
+	err = s.a.ChangePassword(req)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	validToken, err := totp.GenerateCode(otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	req.SecondFactorToken = validToken
+	require.NoError(t, s.a.ChangePassword(req))
+}
+
+func TestChangeUserAuthenticationEnrolledSecondFactor(t *testing.T) {
+	t.Parallel()
+
+	s := setupPasswordSuite(t)
+	ctx := context.Background()
+	_, err := s.prepareForPasswordChange("user4", []byte("abc123"), constants.SecondFactorOff)
+	require.NoError(t, err)
+
+	resetToken, err := s.a.CreateResetPasswordToken(ctx, CreateUserTokenRequest{Name: "user4"})
+	require.NoError(t, err)
+
+	// A device enrolled after the token was minted means the token did not go
+	// through the reset flow, so the credentials must not be rotated.
+	otpSecret := base32.StdEncoding.EncodeToString([]byte("def456"))
+	dev, err := services.NewTOTPDevice("otp", otpSecret, s.a.GetClock().Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertMFADevice(ctx, "user4", dev))
+
+	_, err = s.a.ChangeUserAuthentication(ctx, &proto.ChangeUserAuthenticationRequest{
+		TokenID:     resetToken.GetName(),
+		NewPassword: []byte("abce456"),
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/passwordhash"
+)
+
+// passwordHashPolicyPrefix is the backend key the password hash policy
+// singleton is stored under.
+const passwordHashPolicyPrefix = "password_hash_policy"
+
+// PasswordHashPolicy selects the key derivation function user passwords are
+// stored with. Existing hashes are transparently re-derived under the
+// configured method on the next successful login.
+type PasswordHashPolicy struct {
+	// Method is the hashing method, "bcrypt" or "argon2id".
+	Method string `json:"method"`
+	// Argon2Time is the number of argon2id passes over the memory,
+	// zero means the default.
+	Argon2Time uint32 `json:"argon2_time,omitempty"`
+	// Argon2Memory is the amount of memory argon2id uses in KiB,
+	// zero means the default.
+	Argon2Memory uint32 `json:"argon2_memory,omitempty"`
+	// Argon2Parallelism is the number of argon2id threads,
+	// zero means the default.
+	Argon2Parallelism uint8 `json:"argon2_parallelism,omitempty"`
+}
+
+// DefaultPasswordHashPolicy returns the policy in force when none was
+// configured, keeping the bcrypt storage Teleport has always used.
+func DefaultPasswordHashPolicy() PasswordHashPolicy {
+	return PasswordHashPolicy{Method: passwordhash.MethodBcrypt}
+}
+
+// CheckAndSetDefaults validates the policy and fills in unset argon2id
+// parameters with their defaults.
+func (p *PasswordHashPolicy) CheckAndSetDefaults() error {
+	if p.Method == "" {
+		p.Method = passwordhash.MethodBcrypt
+	}
+	switch p.Method {
+	case passwordhash.MethodBcrypt, passwordhash.MethodArgon2id:
+	default:
+		return trace.BadParameter("unsupported password hashing method %q, must be %q or %q",
+			p.Method, passwordhash.MethodBcrypt, passwordhash.MethodArgon2id)
+	}
+	defaults := passwordhash.DefaultArgon2Params()
+	if p.Argon2Time == 0 {
+		p.Argon2Time = defaults.Time
+	}
+	if p.Argon2Memory == 0 {
+		p.Argon2Memory = defaults.Memory
+	}
+	if p.Argon2Parallelism == 0 {
+		p.Argon2Parallelism = defaults.Parallelism
+	}
+	if p.Argon2Time > 10 {
+		return trace.BadParameter("argon2 time parameter must not exceed 10, got %v", p.Argon2Time)
+	}
+	if p.Argon2Memory < 8*1024 || p.Argon2Memory > 1024*1024 {
+		return trace.BadParameter("argon2 memory parameter must be between 8192 and 1048576 KiB, got %v", p.Argon2Memory)
+	}
+	if p.Argon2Parallelism > 16 {
+		return trace.BadParameter("argon2 parallelism parameter must not exceed 16, got %v", p.Argon2Parallelism)
+	}
+	return nil
+}
+
+// argon2Params returns the argon2id parameters the policy translates to.
+func (p *PasswordHashPolicy) argon2Params() passwordhash.Argon2Params {
+	return passwordhash.Argon2Params{
+		Time:        p.Argon2Time,
+		Memory:      p.Argon2Memory,
+		Parallelism: p.Argon2Parallelism,
+	}
+}
+
+// hash derives the password hash under the policy.
+func (p *PasswordHashPolicy) hash(password []byte) ([]byte, error) {
+	if p.Method == passwordhash.MethodArgon2id {
+		return passwordhash.Argon2id(password, p.argon2Params())
+	}
+	return passwordhash.Bcrypt(password)
+}
+
+// SetPasswordHashPolicy configures the password hashing method of the
+// cluster.
+func (a *Server) SetPasswordHashPolicy(ctx context.Context, policy PasswordHashPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(passwordHashPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetPasswordHashPolicy returns the configured password hashing method,
+// falling back to bcrypt when none was set.
+func (a *Server) GetPasswordHashPolicy(ctx context.Context) (PasswordHashPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(passwordHashPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return DefaultPasswordHashPolicy(), nil
+		}
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	var policy PasswordHashPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeletePasswordHashPolicy reverts the cluster to bcrypt password storage.
+func (a *Server) DeletePasswordHashPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(passwordHashPolicyPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("password hash policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// UpsertPassword hashes the password under the configured hashing policy and
+// stores it for the user.
+func (a *Server) UpsertPassword(user string, password []byte) error {
+	if user == "" {
+		return trace.BadParameter("missing username")
+	}
+	if err := services.VerifyPassword(password); err != nil {
+		return trace.Wrap(err)
+	}
+	ctx := context.TODO()
+	if err := a.enforcePasswordPolicy(ctx, user, password); err != nil {
+		return trace.Wrap(err)
+	}
+	policy, err := a.GetPasswordHashPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	hash, err := policy.hash(password)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.Services.UpsertPasswordHash(user, hash); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(a.recordPasswordChange(ctx, user, hash))
+}
+
+// SetPasswordHashPolicy configures the password hashing method of the
+// cluster.
+func (a *ServerWithRoles) SetPasswordHashPolicy(ctx context.Context, policy PasswordHashPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetPasswordHashPolicy(ctx, policy)
+}
+
+// GetPasswordHashPolicy returns the configured password hashing method,
+// falling back to bcrypt when none was set.
+func (a *ServerWithRoles) GetPasswordHashPolicy(ctx context.Context) (PasswordHashPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return PasswordHashPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetPasswordHashPolicy(ctx)
+}
+
+// DeletePasswordHashPolicy reverts the cluster to bcrypt password storage.
+func (a *ServerWithRoles) DeletePasswordHashPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeletePasswordHashPolicy(ctx)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/utils/passwordhash"
+)
+
+func TestPasswordHashPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy bcrypt remains in force.
+	policy, err := s.a.GetPasswordHashPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, passwordhash.MethodBcrypt, policy.Method)
+
+	// Unknown methods and out-of-range parameters are rejected.
+	err = s.a.SetPasswordHashPolicy(ctx, PasswordHashPolicy{Method: "scrypt"})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.SetPasswordHashPolicy(ctx, PasswordHashPolicy{
+		Method:       passwordhash.MethodArgon2id,
+		Argon2Memory: 1024,
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Unset argon2 parameters are filled with the defaults.
+	require.NoError(t, s.a.SetPasswordHashPolicy(ctx, PasswordHashPolicy{Method: passwordhash.MethodArgon2id}))
+	policy, err = s.a.GetPasswordHashPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, passwordhash.MethodArgon2id, policy.Method)
+	require.Equal(t, passwordhash.DefaultArgon2Params().Memory, policy.Argon2Memory)
+
+	// Deleting reverts to bcrypt.
+	require.NoError(t, s.a.DeletePasswordHashPolicy(ctx))
+	policy, err = s.a.GetPasswordHashPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, passwordhash.MethodBcrypt, policy.Method)
+	err = s.a.DeletePasswordHashPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestPasswordHashMigration(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "hash-migration-user"
+	password := []byte("supersecurepassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	// The user starts with a bcrypt hash from before the policy change.
+	require.NoError(t, s.a.UpsertPassword(user, password))
+	hash, err := s.a.GetPasswordHash(user)
+	require.NoError(t, err)
+	require.Equal(t, passwordhash.MethodBcrypt, passwordhash.Method(hash))
+
+	require.NoError(t, s.a.SetPasswordHashPolicy(ctx, PasswordHashPolicy{Method: passwordhash.MethodArgon2id}))
+
+	// A successful login transparently re-hashes the password.
+	require.NoError(t, s.a.checkPasswordWOToken(user, password))
+	hash, err = s.a.GetPasswordHash(user)
+	require.NoError(t, err)
+	require.Equal(t, passwordhash.MethodArgon2id, passwordhash.Method(hash))
+
+	// The migrated hash still verifies and a wrong password still fails.
+	require.NoError(t, s.a.checkPasswordWOToken(user, password))
+	require.Error(t, s.a.checkPasswordWOToken(user, []byte("wrongpassword")))
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+	"unicode"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/passwordhash"
+)
+
+const (
+	// passwordPolicyPrefix is the backend key the password policy singleton
+	// is stored under.
+	passwordPolicyPrefix = "password_policy"
+
+	// passwordHistoryPrefix is the backend prefix per-user password history
+	// records are stored under.
+	passwordHistoryPrefix = "password_history"
+
+	// maxPasswordHistorySize bounds the reuse history a policy may demand.
+	maxPasswordHistorySize = 24
+)
+
+// PasswordPolicy constrains the passwords local users may set, on top of the
+// built-in length sanity checks.
+type PasswordPolicy struct {
+	// MinLength is the minimum password length, zero means the built-in
+	// minimum.
+	MinLength int `json:"min_length,omitempty"`
+	// MinCharacterClasses is the number of character classes (lowercase,
+	// uppercase, digits, symbols) a password must draw from, 0 to 4.
+	MinCharacterClasses int `json:"min_character_classes,omitempty"`
+	// HistorySize is the number of previous passwords that may not be
+	// reused, zero disables the reuse check.
+	HistorySize int `json:"history_size,omitempty"`
+	// MaxAge is how long a password may be used before it expires and a
+	// reset is required, zero disables expiry.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *PasswordPolicy) CheckAndSetDefaults() error {
+	if p.MinLength == 0 {
+		p.MinLength = defaults.MinPasswordLength
+	}
+	if p.MinLength < defaults.MinPasswordLength || p.MinLength > defaults.MaxPasswordLength {
+		return trace.BadParameter("password policy minimum length must be between %v and %v, got %v",
+			defaults.MinPasswordLength, defaults.MaxPasswordLength, p.MinLength)
+	}
+	if p.MinCharacterClasses < 0 || p.MinCharacterClasses > 4 {
+		return trace.BadParameter("password policy character classes must be between 0 and 4, got %v", p.MinCharacterClasses)
+	}
+	if p.HistorySize < 0 || p.HistorySize > maxPasswordHistorySize {
+		return trace.BadParameter("password policy history size must be between 0 and %v, got %v",
+			maxPasswordHistorySize, p.HistorySize)
+	}
+	if p.MaxAge < 0 {
+		return trace.BadParameter("password policy max age must not be negative, got %v", p.MaxAge)
+	}
+	return nil
+}
+
+// checkComposition verifies the password meets the length and character
+// class requirements of the policy.
+func (p *PasswordPolicy) checkComposition(password []byte) error {
+	if len(password) < p.MinLength {
+		return trace.BadParameter("password does not meet the policy: must be at least %v characters long", p.MinLength)
+	}
+	if p.MinCharacterClasses == 0 {
+		return nil
+	}
+	var lower, upper, digit, symbol bool
+	for _, r := range string(password) {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < p.MinCharacterClasses {
+		return trace.BadParameter("password does not meet the policy: must contain characters from at least %v of the classes lowercase, uppercase, digits and symbols", p.MinCharacterClasses)
+	}
+	return nil
+}
+
+// passwordHistory records the previous password hashes of a user and when
+// the password was last changed.
+type passwordHistory struct {
+	// Hashes are the most recent password hashes, newest last.
+	Hashes [][]byte `json:"hashes"`
+	// ChangedAt is when the password was last set.
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func passwordHistoryKey(user string) []byte {
+	return backend.Key(passwordHistoryPrefix, user)
+}
+
+// SetPasswordPolicy configures the password policy of the cluster.
+func (a *Server) SetPasswordPolicy(ctx context.Context, policy PasswordPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(passwordPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetPasswordPolicy returns the configured password policy, falling back to
+// the built-in checks when none was set.
+func (a *Server) GetPasswordPolicy(ctx context.Context) (PasswordPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(passwordPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			policy := PasswordPolicy{}
+			if err := policy.CheckAndSetDefaults(); err != nil {
+				return PasswordPolicy{}, trace.Wrap(err)
+			}
+			return policy, nil
+		}
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	var policy PasswordPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeletePasswordPolicy reverts the cluster to the built-in password checks.
+func (a *Server) DeletePasswordPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(passwordPolicyPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("password policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// enforcePasswordPolicy verifies a new password against the configured
+// policy, including the reuse history of the user.
+func (a *Server) enforcePasswordPolicy(ctx context.Context, user string, password []byte) error {
+	policy, err := a.GetPasswordPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := policy.checkComposition(password); err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.HistorySize == 0 {
+		return nil
+	}
+	history, err := a.getPasswordHistory(ctx, user)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	hashes := history.Hashes
+	if len(hashes) > policy.HistorySize {
+		hashes = hashes[len(hashes)-policy.HistorySize:]
+	}
+	for _, hash := range hashes {
+		if passwordhash.Compare(hash, password) == nil {
+			return trace.BadParameter("password does not meet the policy: the previous %v passwords may not be reused", policy.HistorySize)
+		}
+	}
+	return nil
+}
+
+// getPasswordHistory returns the password history of the user, empty if none
+// was recorded yet.
+func (a *Server) getPasswordHistory(ctx context.Context, user string) (passwordHistory, error) {
+	item, err := a.bk.Get(ctx, passwordHistoryKey(user))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return passwordHistory{}, nil
+		}
+		return passwordHistory{}, trace.Wrap(err)
+	}
+	var history passwordHistory
+	if err := utils.FastUnmarshal(item.Value, &history); err != nil {
+		return passwordHistory{}, trace.Wrap(err)
+	}
+	return history, nil
+}
+
+// recordPasswordChange appends the new hash to the password history of the
+// user and stamps the change time for expiry tracking.
+func (a *Server) recordPasswordChange(ctx context.Context, user string, hash []byte) error {
+	history, err := a.getPasswordHistory(ctx, user)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	history.Hashes = append(history.Hashes, hash)
+	if len(history.Hashes) > maxPasswordHistorySize {
+		history.Hashes = history.Hashes[len(history.Hashes)-maxPasswordHistorySize:]
+	}
+	history.ChangedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(history)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   passwordHistoryKey(user),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// checkPasswordAge verifies the password of the user has not outlived the
+// maximum age of the policy.
+func (a *Server) checkPasswordAge(ctx context.Context, user string) error {
+	policy, err := a.GetPasswordPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.MaxAge == 0 {
+		return nil
+	}
+	history, err := a.getPasswordHistory(ctx, user)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if history.ChangedAt.IsZero() {
+		// The password predates age tracking, let the next change pick it up.
+		return nil
+	}
+	if a.clock.Now().After(history.ChangedAt.Add(policy.MaxAge)) {
+		return trace.BadParameter("password has expired under the cluster password policy, please reset it")
+	}
+	return nil
+}
+
+// SetPasswordPolicy configures the password policy of the cluster.
+func (a *ServerWithRoles) SetPasswordPolicy(ctx context.Context, policy PasswordPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetPasswordPolicy(ctx, policy)
+}
+
+// GetPasswordPolicy returns the configured password policy, falling back to
+// the built-in checks when none was set.
+func (a *ServerWithRoles) GetPasswordPolicy(ctx context.Context) (PasswordPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return PasswordPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetPasswordPolicy(ctx)
+}
+
+// DeletePasswordPolicy reverts the cluster to the built-in password checks.
+func (a *ServerWithRoles) DeletePasswordPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeletePasswordPolicy(ctx)
+}
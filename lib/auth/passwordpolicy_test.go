@@ -0,0 +1,143 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/defaults"
+)
+
+func TestPasswordPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy only the built-in checks apply.
+	policy, err := s.a.GetPasswordPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, defaults.MinPasswordLength, policy.MinLength)
+	require.Zero(t, policy.MinCharacterClasses)
+	require.Zero(t, policy.HistorySize)
+	require.Zero(t, policy.MaxAge)
+
+	// Out-of-range policies are rejected.
+	for _, invalid := range []PasswordPolicy{
+		{MinLength: defaults.MaxPasswordLength + 1},
+		{MinCharacterClasses: 5},
+		{HistorySize: maxPasswordHistorySize + 1},
+		{MaxAge: -time.Hour},
+	} {
+		err := s.a.SetPasswordPolicy(ctx, invalid)
+		require.True(t, trace.IsBadParameter(err), "policy %+v: expected BadParameter, got %v", invalid, err)
+	}
+
+	require.NoError(t, s.a.SetPasswordPolicy(ctx, PasswordPolicy{
+		MinLength:           12,
+		MinCharacterClasses: 3,
+		HistorySize:         2,
+		MaxAge:              90 * 24 * time.Hour,
+	}))
+	policy, err = s.a.GetPasswordPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 12, policy.MinLength)
+	require.Equal(t, 3, policy.MinCharacterClasses)
+
+	// Deleting reverts to the built-in checks.
+	require.NoError(t, s.a.DeletePasswordPolicy(ctx))
+	policy, err = s.a.GetPasswordPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, defaults.MinPasswordLength, policy.MinLength)
+	err = s.a.DeletePasswordPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestPasswordPolicyEnforcement(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "password-policy-user"
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	require.NoError(t, s.a.SetPasswordPolicy(ctx, PasswordPolicy{
+		MinLength:           12,
+		MinCharacterClasses: 3,
+		HistorySize:         2,
+	}))
+
+	// Too short for the policy, even though it passes the built-in checks.
+	err = s.a.UpsertPassword(user, []byte("Short1!"))
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Long enough but drawn from a single character class.
+	err = s.a.UpsertPassword(user, []byte("alllowercaseletters"))
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	first := []byte("FirstPassword1")
+	require.NoError(t, s.a.UpsertPassword(user, first))
+
+	// The current password may not be set again while within the history.
+	err = s.a.UpsertPassword(user, first)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	second := []byte("SecondPassword2")
+	require.NoError(t, s.a.UpsertPassword(user, second))
+
+	// Both recent passwords remain blocked.
+	err = s.a.UpsertPassword(user, first)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A third change pushes the first password out of the history window.
+	require.NoError(t, s.a.UpsertPassword(user, []byte("ThirdPassword3")))
+	require.NoError(t, s.a.UpsertPassword(user, first))
+}
+
+func TestPasswordPolicyMaxAge(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+
+	user := "password-age-user"
+	password := []byte("ExpiringPassword1")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	require.NoError(t, s.a.SetPasswordPolicy(ctx, PasswordPolicy{MaxAge: 30 * 24 * time.Hour}))
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	// Within the allowed age the password verifies.
+	require.NoError(t, s.a.checkPasswordWOToken(user, password))
+
+	// Past the maximum age the login is rejected until a reset.
+	fakeClock.Advance(31 * 24 * time.Hour)
+	err = s.a.checkPasswordWOToken(user, password)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Resetting the password restores access.
+	require.NoError(t, s.a.UpsertPassword(user, []byte("FreshPassword2")))
+	require.NoError(t, s.a.checkPasswordWOToken(user, []byte("FreshPassword2")))
+}
@@ -145,6 +145,15 @@ Loop:
 	return lockTargets
 }
 
+// clientOrigin returns the client origin recorded in the context's
+// authenticated identity, or an empty string if none was recorded.
+func (c *Context) clientOrigin() string {
+	if c.Identity == nil {
+		return ""
+	}
+	return c.Identity.GetIdentity().ClientOrigin
+}
+
 // UseSearchAsRoles extends the roles of the Checker on the current Context with
 // the set of roles the user is allowed to search as.
 func (c *Context) UseSearchAsRoles(access services.RoleGetter, clusterName string) error {
@@ -679,8 +688,15 @@ func contextForBuiltinRole(r BuiltinRole, recConfig types.SessionRecordingConfig
 			return nil, trace.BadParameter("cannot create instance context, no additional system roles recognized")
 		}
 	} else {
-		// all other certs encode a single system role
+		// all other certs encode a single primary system role, and may carry
+		// additional system roles when a combined agent requested one cert
+		// for all the services it runs
 		systemRoles = []types.SystemRole{r.Role}
+		for _, role := range r.AdditionalSystemRoles {
+			if role != r.Role {
+				systemRoles = append(systemRoles, role)
+			}
+		}
 	}
 	roleSet, err := RoleSetForBuiltinRoles(r.ClusterName, recConfig, systemRoles...)
 	if err != nil {
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+)
+
+const (
+	// preflightBackendLatencyThreshold is the backend read latency above
+	// which the backend latency check is reported as failed.
+	preflightBackendLatencyThreshold = 100 * time.Millisecond
+	// preflightRotationStuckThreshold is how long a CA rotation may remain
+	// in progress before it is reported as dangling.
+	preflightRotationStuckThreshold = 24 * time.Hour
+)
+
+// PreflightCheckResult is the machine-readable outcome of a single preflight
+// check.
+type PreflightCheckResult struct {
+	// Name identifies the check, e.g. "backend_latency".
+	Name string `json:"name"`
+	// Passed indicates whether the check passed.
+	Passed bool `json:"passed"`
+	// Details is a human-readable explanation of the outcome.
+	Details string `json:"details,omitempty"`
+}
+
+// PreflightReport aggregates the results of all preflight checks.
+type PreflightReport struct {
+	// CheckedAt is the time the checks were run.
+	CheckedAt time.Time `json:"checked_at"`
+	// Passed indicates whether every check passed.
+	Passed bool `json:"passed"`
+	// Results holds the per-check outcomes.
+	Results []PreflightCheckResult `json:"results"`
+}
+
+// RunPreflightChecks validates the health of this auth server's dependencies:
+// backend latency, CA consistency, keystore availability and dangling
+// rotation states. It returns a machine-readable report; checks failing does
+// not produce an error, only a failed report entry.
+func (a *Server) RunPreflightChecks(ctx context.Context) (*PreflightReport, error) {
+	report := &PreflightReport{
+		CheckedAt: a.clock.Now().UTC(),
+		Passed:    true,
+	}
+	report.add(a.checkBackendLatency(ctx))
+	report.add(a.checkCertAuthorities(ctx)...)
+	return report, nil
+}
+
+func (r *PreflightReport) add(results ...PreflightCheckResult) {
+	for _, result := range results {
+		if !result.Passed {
+			r.Passed = false
+		}
+		r.Results = append(r.Results, result)
+	}
+}
+
+// checkBackendLatency measures the latency of a single backend read.
+func (a *Server) checkBackendLatency(ctx context.Context) PreflightCheckResult {
+	result := PreflightCheckResult{Name: "backend_latency"}
+	start := a.clock.Now()
+	// the probe key is not expected to exist, only to exercise a read
+	_, err := a.bk.Get(ctx, backend.Key("preflight", "probe"))
+	if err != nil && !trace.IsNotFound(err) {
+		result.Details = fmt.Sprintf("backend read failed: %v", err)
+		return result
+	}
+	latency := a.clock.Since(start)
+	if latency > preflightBackendLatencyThreshold {
+		result.Details = fmt.Sprintf("backend read took %v, threshold is %v", latency, preflightBackendLatencyThreshold)
+		return result
+	}
+	result.Passed = true
+	result.Details = fmt.Sprintf("backend read took %v", latency)
+	return result
+}
+
+// checkCertAuthorities verifies that the cluster's own certificate
+// authorities exist, have usable signing keys in the keystore, and are not
+// stuck mid-rotation.
+func (a *Server) checkCertAuthorities(ctx context.Context) []PreflightCheckResult {
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return []PreflightCheckResult{{
+			Name:    "ca_consistency",
+			Details: fmt.Sprintf("failed to fetch cluster name: %v", err),
+		}}
+	}
+	var results []PreflightCheckResult
+	for _, caType := range types.CertAuthTypes {
+		caID := types.CertAuthID{Type: caType, DomainName: clusterName.GetClusterName()}
+		result := PreflightCheckResult{Name: fmt.Sprintf("ca_consistency/%v", caType)}
+		ca, err := a.Services.GetCertAuthority(ctx, caID, true)
+		if err != nil {
+			result.Details = fmt.Sprintf("failed to fetch CA: %v", err)
+			results = append(results, result)
+			continue
+		}
+		if err := a.checkKeystoreUsable(ca); err != nil {
+			result.Details = fmt.Sprintf("keystore cannot use CA keys: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.Passed = true
+		results = append(results, result)
+
+		rotation := ca.GetRotation()
+		if rotation.State == types.RotationStateInProgress {
+			result := PreflightCheckResult{Name: fmt.Sprintf("ca_rotation/%v", caType)}
+			stuck := !rotation.Started.IsZero() && a.clock.Since(rotation.Started) > preflightRotationStuckThreshold
+			if stuck {
+				result.Details = fmt.Sprintf("rotation in phase %q since %v, may be dangling", rotation.Phase, rotation.Started.Format(time.RFC3339))
+			} else {
+				result.Passed = true
+				result.Details = fmt.Sprintf("rotation in progress, phase %q", rotation.Phase)
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// checkKeystoreUsable verifies the keystore holds a usable signer for the CA.
+func (a *Server) checkKeystoreUsable(ca types.CertAuthority) error {
+	if len(ca.GetActiveKeys().SSH) != 0 {
+		if _, err := a.keyStore.GetSSHSigner(ca); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if len(ca.GetActiveKeys().TLS) != 0 {
+		if _, _, err := a.keyStore.GetTLSCertAndSigner(ca); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if len(ca.GetActiveKeys().JWT) != 0 {
+		if _, err := a.keyStore.GetJWTSigner(ca); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
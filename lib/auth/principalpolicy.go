@@ -0,0 +1,187 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// HostPrincipalPolicyModeOff disables principal validation. This is the
+	// default.
+	HostPrincipalPolicyModeOff = ""
+	// HostPrincipalPolicyModeWarn validates requested principals but only
+	// logs a warning when validation fails.
+	HostPrincipalPolicyModeWarn = "warn"
+	// HostPrincipalPolicyModeEnforce rejects host cert requests containing
+	// principals that fail validation.
+	HostPrincipalPolicyModeEnforce = "enforce"
+)
+
+// hostPrincipalPolicyKey is the backend key the host principal policy is
+// stored under.
+var hostPrincipalPolicyKey = backend.Key("host_principal_policy")
+
+// HostPrincipalPolicy controls validation of additional principals requested
+// in host cert requests. When enabled, hostname principals must resolve back
+// to the address the request was made from (forward-confirmed DNS) and IP
+// principals must match the request address, closing a gap where a
+// compromised host could request certs for names it does not own.
+type HostPrincipalPolicy struct {
+	// Mode is one of "", "warn" or "enforce".
+	Mode string `json:"mode"`
+	// ExemptPrincipals are glob patterns for principals that are excluded
+	// from validation, e.g. internal load balancer names that do not resolve
+	// to the requesting host.
+	ExemptPrincipals []string `json:"exempt_principals,omitempty"`
+	// UpdatedAt is the time the policy was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the host principal policy.
+func (p *HostPrincipalPolicy) CheckAndSetDefaults() error {
+	switch p.Mode {
+	case HostPrincipalPolicyModeOff, HostPrincipalPolicyModeWarn, HostPrincipalPolicyModeEnforce:
+	default:
+		return trace.BadParameter("unsupported host principal policy mode %q", p.Mode)
+	}
+	for _, pattern := range p.ExemptPrincipals {
+		if _, err := regexp.Compile("^" + utils.GlobToRegexp(pattern) + "$"); err != nil {
+			return trace.BadParameter("invalid exempt principal pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// exempts reports whether the principal matches one of the policy's exempt
+// patterns.
+func (p *HostPrincipalPolicy) exempts(principal string) bool {
+	for _, pattern := range p.ExemptPrincipals {
+		expr, err := regexp.Compile("^" + utils.GlobToRegexp(pattern) + "$")
+		if err != nil {
+			continue
+		}
+		if expr.MatchString(principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetHostPrincipalPolicy updates the cluster host principal policy.
+func (a *Server) SetHostPrincipalPolicy(ctx context.Context, policy HostPrincipalPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	policy.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   hostPrincipalPolicyKey,
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetHostPrincipalPolicy returns the cluster host principal policy. A
+// disabled policy is returned if none was configured.
+func (a *Server) GetHostPrincipalPolicy(ctx context.Context) (*HostPrincipalPolicy, error) {
+	var policy HostPrincipalPolicy
+	item, err := a.bk.Get(ctx, hostPrincipalPolicyKey)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return &policy, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// checkHostCertPrincipals validates the additional principals requested in a
+// host cert request according to the cluster host principal policy.
+func (a *Server) checkHostCertPrincipals(ctx context.Context, req *proto.HostCertsRequest) error {
+	policy, err := a.GetHostPrincipalPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.Mode == HostPrincipalPolicyModeOff {
+		return nil
+	}
+	remoteHost, err := utils.Host(req.RemoteAddr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, principal := range req.AdditionalPrincipals {
+		if principal == "" || principal == req.NodeName || principal == req.HostID {
+			continue
+		}
+		if apiutils.SliceContainsStr([]string{"localhost", "127.0.0.1", "::1"}, principal) {
+			continue
+		}
+		if policy.exempts(principal) {
+			continue
+		}
+		err := checkPrincipalResolvesTo(ctx, net.DefaultResolver.LookupHost, principal, remoteHost)
+		if err == nil {
+			continue
+		}
+		if policy.Mode == HostPrincipalPolicyModeEnforce {
+			return trace.Wrap(err)
+		}
+		log.Warnf("Host %q [%v] requested unverified principal: %v.", req.NodeName, req.HostID, err)
+	}
+	return nil
+}
+
+// checkPrincipalResolvesTo verifies that the principal belongs to the host at
+// remoteHost: IP principals must equal the remote address, hostname
+// principals must forward-resolve to it.
+func checkPrincipalResolvesTo(ctx context.Context, lookupHost func(context.Context, string) ([]string, error), principal, remoteHost string) error {
+	if ip := net.ParseIP(principal); ip != nil {
+		if remoteIP := net.ParseIP(remoteHost); remoteIP != nil && ip.Equal(remoteIP) {
+			return nil
+		}
+		return trace.AccessDenied("principal %q does not match the request address %q", principal, remoteHost)
+	}
+	addrs, err := lookupHost(ctx, principal)
+	if err != nil {
+		return trace.AccessDenied("principal %q failed to resolve: %v", principal, err)
+	}
+	for _, addr := range addrs {
+		if addr == remoteHost {
+			return nil
+		}
+	}
+	return trace.AccessDenied("principal %q does not resolve to the request address %q", principal, remoteHost)
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/mail"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// recoveryFlowPolicyPrefix is the backend key the recovery flow policy
+	// singleton is stored under.
+	recoveryFlowPolicyPrefix = "recovery_flow_policy"
+
+	// recoveryApprovalPrefix is the backend prefix pending recovery approval
+	// requests are stored under.
+	recoveryApprovalPrefix = "recovery_approvals"
+
+	// RecoveryVerifierCodes verifies account recovery through a previously
+	// issued recovery code.
+	RecoveryVerifierCodes = "recovery_codes"
+	// RecoveryVerifierAdminApproval verifies account recovery through an
+	// explicit administrator approval.
+	RecoveryVerifierAdminApproval = "admin_approval"
+)
+
+// RecoveryFlowPolicy controls which verification steps can unlock account
+// recovery for locked-out users.
+type RecoveryFlowPolicy struct {
+	// Verifiers are the enabled verification steps, any of
+	// RecoveryVerifierCodes and RecoveryVerifierAdminApproval.
+	Verifiers []string `json:"verifiers,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy, defaulting to recovery codes
+// which is the built-in verification step.
+func (p *RecoveryFlowPolicy) CheckAndSetDefaults() error {
+	if len(p.Verifiers) == 0 {
+		p.Verifiers = []string{RecoveryVerifierCodes}
+	}
+	for _, verifier := range p.Verifiers {
+		if verifier != RecoveryVerifierCodes && verifier != RecoveryVerifierAdminApproval {
+			return trace.BadParameter("unknown recovery verifier %q", verifier)
+		}
+	}
+	return nil
+}
+
+// VerifierEnabled returns true when the policy enables the verification step.
+func (p *RecoveryFlowPolicy) VerifierEnabled(verifier string) bool {
+	return apiutils.SliceContainsStr(p.Verifiers, verifier)
+}
+
+// SetRecoveryFlowPolicy configures the recovery flow policy of the cluster.
+func (a *Server) SetRecoveryFlowPolicy(ctx context.Context, policy RecoveryFlowPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(recoveryFlowPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetRecoveryFlowPolicy returns the configured recovery flow policy, falling
+// back to the built-in recovery codes verifier when none was set.
+func (a *Server) GetRecoveryFlowPolicy(ctx context.Context) (RecoveryFlowPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(recoveryFlowPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			policy := RecoveryFlowPolicy{}
+			if err := policy.CheckAndSetDefaults(); err != nil {
+				return RecoveryFlowPolicy{}, trace.Wrap(err)
+			}
+			return policy, nil
+		}
+		return RecoveryFlowPolicy{}, trace.Wrap(err)
+	}
+	var policy RecoveryFlowPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return RecoveryFlowPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteRecoveryFlowPolicy removes the recovery flow policy, reverting to the
+// built-in recovery codes verifier.
+func (a *Server) DeleteRecoveryFlowPolicy(ctx context.Context) error {
+	err := a.bk.Delete(ctx, backend.Key(recoveryFlowPolicyPrefix))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("recovery flow policy is not set")
+	}
+	return trace.Wrap(err)
+}
+
+// RecoveryApprovalRequest is a pending request from a locked-out user asking
+// an administrator to approve their account recovery.
+type RecoveryApprovalRequest struct {
+	// Username is the name of the user recovering their account.
+	Username string `json:"username"`
+	// RecoverType marks what the user wants to recover, a password or a
+	// second factor.
+	RecoverType types.UserTokenUsage `json:"recover_type"`
+	// CreatedAt is when the request was filed.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestRecoveryApproval files a recovery approval request that an
+// administrator can approve or deny. The request expires on its own after the
+// recovery start token TTL and only one request per user can be pending,
+// which keeps a rejected user from flooding the queue.
+func (s *Server) RequestRecoveryApproval(ctx context.Context, req RecoveryApprovalRequest) (*RecoveryApprovalRequest, error) {
+	if err := s.isAccountRecoveryAllowed(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	policy, err := s.GetRecoveryFlowPolicy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !policy.VerifierEnabled(RecoveryVerifierAdminApproval) {
+		return nil, trace.AccessDenied("administrator approval is not an enabled recovery verifier")
+	}
+	// Mirror StartAccountRecovery, only users with an email as their
+	// username can recover.
+	if _, err := mail.ParseAddress(req.Username); err != nil {
+		log.Debugf("Failed to request recovery approval, user %s is not in valid email format", req.Username)
+		return nil, trace.AccessDenied(startRecoveryGenericErrMsg)
+	}
+	if req.RecoverType != types.UserTokenUsage_USER_TOKEN_RECOVER_PASSWORD &&
+		req.RecoverType != types.UserTokenUsage_USER_TOKEN_RECOVER_MFA {
+		return nil, trace.BadParameter("invalid recover type %s", req.RecoverType.String())
+	}
+	// Whether the user exists is left for the resolving administrator to
+	// find out, so the endpoint cannot be used as a username oracle.
+	req.CreatedAt = s.clock.Now().UTC()
+	value, err := utils.FastMarshal(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	_, err = s.bk.Create(ctx, backend.Item{
+		Key:     backend.Key(recoveryApprovalPrefix, req.Username),
+		Value:   value,
+		Expires: req.CreatedAt.Add(defaults.RecoveryStartTokenTTL),
+	})
+	if err != nil {
+		if trace.IsAlreadyExists(err) {
+			return nil, trace.AlreadyExists("a recovery approval request for user %q is already pending", req.Username)
+		}
+		return nil, trace.Wrap(err)
+	}
+	if err := s.emitter.EmitAuditEvent(s.closeCtx, &apievents.UserTokenCreate{
+		Metadata: apievents.Metadata{
+			Type: events.RecoveryApprovalRequestedEvent,
+			Code: events.RecoveryApprovalRequestedCode,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: req.Username,
+		},
+		ResourceMetadata: apievents.ResourceMetadata{
+			Name:    req.Username,
+			Expires: req.CreatedAt.Add(defaults.RecoveryStartTokenTTL),
+		},
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit recovery approval requested event.")
+	}
+	return &req, nil
+}
+
+// GetRecoveryApprovalRequests returns the pending recovery approval requests.
+func (s *Server) GetRecoveryApprovalRequests(ctx context.Context) ([]RecoveryApprovalRequest, error) {
+	startKey := backend.Key(recoveryApprovalPrefix)
+	result, err := s.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out []RecoveryApprovalRequest
+	for _, item := range result.Items {
+		var req RecoveryApprovalRequest
+		if err := utils.FastUnmarshal(item.Value, &req); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+// ApproveRecoveryRequest resolves a pending recovery approval request and
+// returns a recovery approved token, which the administrator hands to the
+// user out of band like a password reset link.
+func (s *Server) ApproveRecoveryRequest(ctx context.Context, username string) (types.UserToken, error) {
+	item, err := s.bk.Get(ctx, backend.Key(recoveryApprovalPrefix, username))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("no pending recovery approval request for user %q", username)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var req RecoveryApprovalRequest
+	if err := utils.FastUnmarshal(item.Value, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := s.bk.Delete(ctx, item.Key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// Remove any other existing tokens for this user before creating a token.
+	if err := s.deleteUserTokens(ctx, username); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	token, err := s.createRecoveryToken(ctx, username, UserTokenTypeRecoveryApproved, req.RecoverType)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	s.emitRecoveryApprovalResolvedEvent(ctx, username, events.RecoveryApprovalApprovedCode)
+	return token, nil
+}
+
+// DenyRecoveryRequest removes a pending recovery approval request without
+// issuing a token.
+func (s *Server) DenyRecoveryRequest(ctx context.Context, username string) error {
+	err := s.bk.Delete(ctx, backend.Key(recoveryApprovalPrefix, username))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("no pending recovery approval request for user %q", username)
+		}
+		return trace.Wrap(err)
+	}
+	s.emitRecoveryApprovalResolvedEvent(ctx, username, events.RecoveryApprovalDeniedCode)
+	return nil
+}
+
+func (s *Server) emitRecoveryApprovalResolvedEvent(ctx context.Context, username, code string) {
+	if err := s.emitter.EmitAuditEvent(ctx, &apievents.UserTokenCreate{
+		Metadata: apievents.Metadata{
+			Type: events.RecoveryApprovalResolvedEvent,
+			Code: code,
+		},
+		UserMetadata: ClientUserMetadata(ctx),
+		ResourceMetadata: apievents.ResourceMetadata{
+			Name: username,
+		},
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit recovery approval resolved event.")
+	}
+}
+
+// RequestRecoveryApproval files a recovery approval request for a locked-out
+// user. The users asking for approval are not logged in, so like the
+// authenticate endpoints the request is restricted to proxies acting on
+// their behalf.
+func (a *ServerWithRoles) RequestRecoveryApproval(ctx context.Context, req RecoveryApprovalRequest) (*RecoveryApprovalRequest, error) {
+	if !a.hasBuiltinRole(types.RoleProxy) {
+		return nil, trace.AccessDenied("this request can be only executed by a proxy")
+	}
+	return a.authServer.RequestRecoveryApproval(ctx, req)
+}
+
+// GetRecoveryApprovalRequests returns the pending recovery approval requests.
+func (a *ServerWithRoles) GetRecoveryApprovalRequests(ctx context.Context) ([]RecoveryApprovalRequest, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetRecoveryApprovalRequests(ctx)
+}
+
+// ApproveRecoveryRequest resolves a pending recovery approval request and
+// returns a recovery approved token.
+func (a *ServerWithRoles) ApproveRecoveryRequest(ctx context.Context, username string) (types.UserToken, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.ApproveRecoveryRequest(ctx, username)
+}
+
+// DenyRecoveryRequest removes a pending recovery approval request.
+func (a *ServerWithRoles) DenyRecoveryRequest(ctx context.Context, username string) error {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DenyRecoveryRequest(ctx, username)
+}
+
+// SetRecoveryFlowPolicy configures the recovery flow policy of the cluster.
+func (a *ServerWithRoles) SetRecoveryFlowPolicy(ctx context.Context, policy RecoveryFlowPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetRecoveryFlowPolicy(ctx, policy)
+}
+
+// GetRecoveryFlowPolicy returns the recovery flow policy of the cluster.
+func (a *ServerWithRoles) GetRecoveryFlowPolicy(ctx context.Context) (RecoveryFlowPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return RecoveryFlowPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetRecoveryFlowPolicy(ctx)
+}
+
+// DeleteRecoveryFlowPolicy removes the recovery flow policy of the cluster.
+func (a *ServerWithRoles) DeleteRecoveryFlowPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteRecoveryFlowPolicy(ctx)
+}
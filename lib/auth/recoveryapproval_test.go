@@ -0,0 +1,149 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/modules"
+)
+
+func TestRecoveryFlowPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy only recovery codes are enabled.
+	policy, err := s.a.GetRecoveryFlowPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{RecoveryVerifierCodes}, policy.Verifiers)
+	require.True(t, policy.VerifierEnabled(RecoveryVerifierCodes))
+	require.False(t, policy.VerifierEnabled(RecoveryVerifierAdminApproval))
+
+	// Unknown verifiers are rejected.
+	err = s.a.SetRecoveryFlowPolicy(ctx, RecoveryFlowPolicy{Verifiers: []string{"carrier-pigeon"}})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	require.NoError(t, s.a.SetRecoveryFlowPolicy(ctx, RecoveryFlowPolicy{
+		Verifiers: []string{RecoveryVerifierAdminApproval},
+	}))
+	policy, err = s.a.GetRecoveryFlowPolicy(ctx)
+	require.NoError(t, err)
+	require.True(t, policy.VerifierEnabled(RecoveryVerifierAdminApproval))
+	require.False(t, policy.VerifierEnabled(RecoveryVerifierCodes))
+
+	// Deleting reverts to the built-in recovery codes verifier.
+	require.NoError(t, s.a.DeleteRecoveryFlowPolicy(ctx))
+	policy, err = s.a.GetRecoveryFlowPolicy(ctx)
+	require.NoError(t, err)
+	require.True(t, policy.VerifierEnabled(RecoveryVerifierCodes))
+	err = s.a.DeleteRecoveryFlowPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestRecoveryApprovalFlow(t *testing.T) {
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	modules.SetTestModules(t, &modules.TestModules{
+		TestFeatures: modules.Features{
+			Cloud: true,
+		},
+	})
+
+	authPreference, err := types.NewAuthPreference(types.AuthPreferenceSpecV2{
+		Type:         constants.Local,
+		SecondFactor: constants.SecondFactorOTP,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.SetAuthPreference(ctx, authPreference))
+
+	user := "llama@example.com"
+	_, _, err = CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	req := RecoveryApprovalRequest{
+		Username:    user,
+		RecoverType: types.UserTokenUsage_USER_TOKEN_RECOVER_PASSWORD,
+	}
+
+	// Admin approval is not enabled by default.
+	_, err = s.a.RequestRecoveryApproval(ctx, req)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	require.NoError(t, s.a.SetRecoveryFlowPolicy(ctx, RecoveryFlowPolicy{
+		Verifiers: []string{RecoveryVerifierAdminApproval},
+	}))
+
+	// With recovery codes disabled, the code based flow is rejected.
+	_, err = s.a.StartAccountRecovery(ctx, &proto.StartAccountRecoveryRequest{
+		Username:     user,
+		RecoveryCode: []byte("tele-llama-llama-llama-llama-llama-llama-llama-llama"),
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// Invalid requests are rejected before anything is stored.
+	_, err = s.a.RequestRecoveryApproval(ctx, RecoveryApprovalRequest{
+		Username:    "not-an-email",
+		RecoverType: types.UserTokenUsage_USER_TOKEN_RECOVER_PASSWORD,
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	_, err = s.a.RequestRecoveryApproval(ctx, RecoveryApprovalRequest{Username: user})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Filing a request is audited, and only one can be pending per user.
+	created, err := s.a.RequestRecoveryApproval(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, user, created.Username)
+	require.False(t, created.CreatedAt.IsZero())
+	require.Equal(t, events.RecoveryApprovalRequestedCode, s.mockEmitter.LastEvent().GetCode())
+	_, err = s.a.RequestRecoveryApproval(ctx, req)
+	require.True(t, trace.IsAlreadyExists(err), "expected AlreadyExists, got %v", err)
+
+	pending, err := s.a.GetRecoveryApprovalRequests(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	// Denying removes the request without issuing a token.
+	require.NoError(t, s.a.DenyRecoveryRequest(ctx, user))
+	require.Equal(t, events.RecoveryApprovalDeniedCode, s.mockEmitter.LastEvent().GetCode())
+	err = s.a.DenyRecoveryRequest(ctx, user)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+	pending, err = s.a.GetRecoveryApprovalRequests(ctx)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// Approving converts the request into a recovery approved token.
+	_, err = s.a.RequestRecoveryApproval(ctx, req)
+	require.NoError(t, err)
+	token, err := s.a.ApproveRecoveryRequest(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, UserTokenTypeRecoveryApproved, token.GetSubKind())
+	require.Equal(t, user, token.GetUser())
+	require.Equal(t, types.UserTokenUsage_USER_TOKEN_RECOVER_PASSWORD, token.GetUsage())
+	require.Equal(t, events.RecoveryApprovalApprovedCode, s.mockEmitter.LastEvent().GetCode())
+	_, err = s.a.ApproveRecoveryRequest(ctx, user)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
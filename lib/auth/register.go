@@ -18,14 +18,23 @@ package auth
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/breaker"
 	"github.com/gravitational/teleport/api/client"
 	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/api/client/webclient"
+	"github.com/gravitational/teleport/api/constants"
 	"github.com/gravitational/teleport/api/metadata"
 	"github.com/gravitational/teleport/api/types"
+	apiutils "github.com/gravitational/teleport/api/utils"
 	"github.com/gravitational/teleport/lib"
 	"github.com/gravitational/teleport/lib/auth/native"
 	"github.com/gravitational/teleport/lib/defaults"
@@ -107,6 +116,10 @@ type RegisterParams struct {
 	CAPins []string
 	// CAPath is the path to the CA file.
 	CAPath string
+	// ProxyURL is an optional HTTPS CONNECT proxy to tunnel the registration
+	// connection through. When unset, proxy settings from the environment
+	// (HTTPS_PROXY) are honored.
+	ProxyURL *url.URL
 	// GetHostCredentials is a client that can fetch host credentials.
 	GetHostCredentials HostCredentials
 	// Clock specifies the time provider. Will be used to override the time anchor
@@ -120,6 +133,24 @@ type RegisterParams struct {
 	ec2IdentityDocument []byte
 	// CircuitBreakerConfig defines how the circuit breaker should behave.
 	CircuitBreakerConfig breaker.Config
+	// Retry is the retry policy applied when every registration method
+	// fails. The zero value attempts each method once.
+	Retry RegisterRetryPolicy
+	// alpnProtocols is set when registering through a proxy in TLS routing
+	// mode to route the connection to the auth server.
+	alpnProtocols []string
+}
+
+// RegisterRetryPolicy configures how often Register retries when all
+// registration methods fail, with a linearly increasing delay between
+// rounds.
+type RegisterRetryPolicy struct {
+	// Attempts is the total number of rounds over all registration methods.
+	// Values below one are treated as a single round.
+	Attempts int
+	// Backoff is the delay before the second round; subsequent delays
+	// increase linearly.
+	Backoff time.Duration
 }
 
 func (r *RegisterParams) setDefaults() {
@@ -128,6 +159,37 @@ func (r *RegisterParams) setDefaults() {
 	}
 }
 
+// registerDialer returns the dialer used to reach the registration endpoint
+// when an explicitly configured CONNECT proxy must be used. When it returns
+// nil the client falls back to its default dialer, which honors proxy
+// settings from the environment. The TLS session is tunneled through the
+// proxy without termination, so CA pins are still validated against the
+// certificate the auth server presents.
+func registerDialer(params RegisterParams) client.ContextDialer {
+	if params.ProxyURL == nil {
+		return nil
+	}
+	return client.ContextDialerFunc(func(ctx context.Context, network, _ string) (conn net.Conn, err error) {
+		for _, addr := range params.Servers {
+			conn, err = client.DialProxy(ctx, params.ProxyURL, addr.String())
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, trace.Wrap(err)
+	})
+}
+
+// clientTLSConfig returns the base TLS configuration used to dial the
+// registration endpoint, including the ALPN protocols that route the
+// connection through a proxy in TLS routing mode, if any.
+func (r *RegisterParams) clientTLSConfig() *tls.Config {
+	tlsConfig := utils.TLSConfig(r.CipherSuites)
+	tlsConfig.Time = r.Clock.Now
+	tlsConfig.NextProtos = r.alpnProtocols
+	return tlsConfig
+}
+
 // CredGetter is an interface for a client that can be used to get host
 // credentials. This interface is needed because lib/client can not be imported
 // in lib/auth due to circular imports.
@@ -146,6 +208,27 @@ func Register(params RegisterParams) (*proto.Certs, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// A compound token carries the cluster address and CA pins alongside the
+	// secret. Unpack it here so the embedded coordinates win over whatever was
+	// configured, which is what rules out mismatched address/pin errors when a
+	// token is copied between environments.
+	if compound, err := DecodeCompoundToken(token); err != nil {
+		return nil, trace.Wrap(err)
+	} else if compound != nil {
+		token = compound.Token
+		if len(compound.CAPins) > 0 {
+			params.CAPins = apiutils.Deduplicate(append(params.CAPins, compound.CAPins...))
+		}
+		if compound.Addr != "" {
+			addr, err := utils.ParseAddr(compound.Addr)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			log.Debugf("Using cluster address %v embedded in the join token.", addr)
+			params.Servers = []utils.NetAddr{*addr}
+		}
+	}
+
 	// add EC2 Identity Document to params if required for given join method
 	if params.JoinMethod == types.JoinMethodEC2 {
 		if !utils.IsEC2NodeID(params.ID.HostUUID) {
@@ -169,29 +252,106 @@ func Register(params RegisterParams) (*proto.Certs, error) {
 	}
 	registerThroughAuth := registerMethod{registerThroughAuth, "with auth server"}
 	registerThroughProxy := registerMethod{registerThroughProxy, "via proxy server"}
+	registerThroughALPNProxy := registerMethod{registerThroughALPNProxy, "via proxy TLS routing port"}
 
-	registerMethods := []registerMethod{registerThroughAuth, registerThroughProxy}
+	registerMethods := []registerMethod{registerThroughAuth, registerThroughProxy, registerThroughALPNProxy}
 	if params.GetHostCredentials == nil {
 		log.Debugf("Missing client, it is not possible to register through proxy.")
-		registerMethods = []registerMethod{registerThroughAuth}
+		registerMethods = []registerMethod{registerThroughAuth, registerThroughALPNProxy}
 	} else if authServerIsProxy(params.Servers) {
 		log.Debugf("The first specified auth server appears to be a proxy.")
-		registerMethods = []registerMethod{registerThroughProxy, registerThroughAuth}
+		registerMethods = []registerMethod{registerThroughProxy, registerThroughALPNProxy, registerThroughAuth}
+	}
+
+	rounds := params.Retry.Attempts
+	if rounds < 1 {
+		rounds = 1
+	}
+	var server string
+	if len(params.Servers) > 0 {
+		server = params.Servers[0].String()
+	}
+	diagnostic := RegisterDiagnostic{}
+	for round := 1; round <= rounds; round++ {
+		for _, method := range registerMethods {
+			log.Infof("Attempting registration %s.", method.desc)
+			certs, err := method.call(token, params)
+			if err != nil {
+				diagnostic.Attempts = append(diagnostic.Attempts, RegisterAttempt{
+					Method: method.desc,
+					Server: server,
+					Round:  round,
+					Error:  err,
+				})
+				log.WithError(err).Debugf("Registration %s failed.", method.desc)
+				continue
+			}
+			log.Infof("Successfully registered %s.", method.desc)
+			return certs, nil
+		}
+		if round < rounds && params.Retry.Backoff > 0 {
+			params.Clock.Sleep(params.Retry.Backoff * time.Duration(round))
+		}
 	}
+	return nil, trace.Wrap(&diagnostic)
+}
 
-	var collectedErrs []error
-	for _, method := range registerMethods {
-		log.Infof("Attempting registration %s.", method.desc)
-		certs, err := method.call(token, params)
-		if err != nil {
-			collectedErrs = append(collectedErrs, err)
-			log.WithError(err).Debugf("Registration %s failed.", method.desc)
-			continue
-		}
-		log.Infof("Successfully registered %s.", method.desc)
-		return certs, nil
+// RegisterAttempt records the outcome of a single failed registration
+// attempt.
+type RegisterAttempt struct {
+	// Method describes the registration method attempted.
+	Method string
+	// Server is the server the attempt was made against.
+	Server string
+	// Round is the 1-based retry round the attempt belongs to.
+	Round int
+	// Error is the error the attempt failed with.
+	Error error
+}
+
+// Classify returns a short actionable category for the attempt error, so
+// installers can surface the likely cause without parsing error strings.
+func (a RegisterAttempt) Classify() string {
+	message := a.Error.Error()
+	switch {
+	case strings.Contains(message, "cluster pin does not match"):
+		return "CA pin mismatch"
+	case strings.Contains(message, "x509:"), strings.Contains(message, "tls:"):
+		return "TLS failure"
+	case trace.IsAccessDenied(a.Error):
+		return "access denied"
+	case trace.IsConnectionProblem(a.Error):
+		return "connection problem"
+	default:
+		return "error"
 	}
-	return nil, trace.NewAggregate(collectedErrs...)
+}
+
+// RegisterDiagnostic aggregates the per-server results of the failed
+// registration attempts. It is returned by Register as the error when every
+// method fails, so callers can inspect individual attempts via errors.As.
+type RegisterDiagnostic struct {
+	// Attempts are the failed attempts in the order they were made.
+	Attempts []RegisterAttempt
+}
+
+// Error formats the diagnostic as a single human-readable error message.
+func (d *RegisterDiagnostic) Error() string {
+	parts := make([]string, 0, len(d.Attempts))
+	for _, attempt := range d.Attempts {
+		parts = append(parts, fmt.Sprintf("registration %v against %v failed on round %v (%v): %v",
+			attempt.Method, attempt.Server, attempt.Round, attempt.Classify(), attempt.Error))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap returns the underlying attempt errors.
+func (d *RegisterDiagnostic) Unwrap() []error {
+	errs := make([]error, 0, len(d.Attempts))
+	for _, attempt := range d.Attempts {
+		errs = append(errs, attempt.Error)
+	}
+	return errs
 }
 
 // authServerIsProxy returns true if the first specified auth server
@@ -246,6 +406,39 @@ func registerThroughProxy(token string, params RegisterParams) (*proto.Certs, er
 	return certs, nil
 }
 
+// registerThroughALPNProxy is used to register through a proxy running in TLS
+// routing mode when the auth server is not directly reachable. The connection
+// negotiates the teleport-auth ALPN protocol on the proxy's single web port
+// and the proxy forwards the TLS session to the auth server without
+// terminating it, so the CA pins and the CA file on disk still validate the
+// auth server the same way they do for a direct connection.
+func registerThroughALPNProxy(token string, params RegisterParams) (*proto.Certs, error) {
+	if len(params.Servers) == 0 {
+		return nil, trace.BadParameter("no proxy servers set")
+	}
+	proxyAddr := params.Servers[0].String()
+
+	// Fetch the cluster name from the proxy, it is needed to build the ALPN
+	// value that routes the connection to the auth server.
+	ping, err := webclient.Find(&webclient.Config{
+		Context:   context.Background(),
+		ProxyAddr: proxyAddr,
+		Insecure:  lib.IsInsecureDevMode(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !ping.Proxy.TLSRoutingEnabled {
+		return nil, trace.NotImplemented("proxy %v does not support TLS routing", proxyAddr)
+	}
+
+	params.alpnProtocols = []string{
+		constants.ALPNSNIAuthProtocol + apiutils.EncodeClusterName(ping.ClusterName),
+		http2.NextProtoTLS,
+	}
+	return registerThroughAuth(token, params)
+}
+
 // registerThroughAuth is used to register through the auth server.
 func registerThroughAuth(token string, params RegisterParams) (*proto.Certs, error) {
 	var client *Client
@@ -307,12 +500,20 @@ func proxyJoinServiceClient(params RegisterParams) (*client.JoinServiceClient, e
 		log.Warnf("Joining cluster without validating the identity of the Proxy Server.")
 	}
 
-	conn, err := grpc.Dial(
-		params.Servers[0].String(),
+	dialOpts := []grpc.DialOption{
 		grpc.WithUnaryInterceptor(metadata.UnaryClientInterceptor),
 		grpc.WithStreamInterceptor(metadata.StreamClientInterceptor),
 		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
-	)
+	}
+	// gRPC honors proxy settings from the environment on its own, only an
+	// explicitly configured CONNECT proxy needs to be dialed here.
+	if dialer := registerDialer(params); dialer != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}))
+	}
+
+	conn, err := grpc.Dial(params.Servers[0].String(), dialOpts...)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -324,8 +525,7 @@ func proxyJoinServiceClient(params RegisterParams) (*client.JoinServiceClient, e
 // CA on disk. If no CA is found on disk, Teleport will not verify the Auth
 // Server it is connecting to.
 func insecureRegisterClient(params RegisterParams) (*Client, error) {
-	tlsConfig := utils.TLSConfig(params.CipherSuites)
-	tlsConfig.Time = params.Clock.Now
+	tlsConfig := params.clientTLSConfig()
 
 	cert, err := readCA(params.CAPath)
 	if err != nil && !trace.IsNotFound(err) {
@@ -351,7 +551,8 @@ func insecureRegisterClient(params RegisterParams) (*Client, error) {
 	}
 
 	client, err := NewClient(client.Config{
-		Addrs: utils.NetAddrsToStrings(params.Servers),
+		Addrs:  utils.NetAddrsToStrings(params.Servers),
+		Dialer: registerDialer(params),
 		Credentials: []client.Credentials{
 			client.LoadTLS(tlsConfig),
 		},
@@ -386,11 +587,11 @@ func readCA(path string) (*x509.Certificate, error) {
 func pinRegisterClient(params RegisterParams) (*Client, error) {
 	// Build a insecure client to the Auth Server. This is safe because even if
 	// an attacker were to MITM this connection the CA pin will not match below.
-	tlsConfig := utils.TLSConfig(params.CipherSuites)
+	tlsConfig := params.clientTLSConfig()
 	tlsConfig.InsecureSkipVerify = true
-	tlsConfig.Time = params.Clock.Now
 	authClient, err := NewClient(client.Config{
-		Addrs: utils.NetAddrsToStrings(params.Servers),
+		Addrs:  utils.NetAddrsToStrings(params.Servers),
+		Dialer: registerDialer(params),
 		Credentials: []client.Credentials{
 			client.LoadTLS(tlsConfig),
 		},
@@ -432,8 +633,7 @@ func pinRegisterClient(params RegisterParams) (*Client, error) {
 
 	// Create another client, but this time with the CA provided to validate
 	// that the Auth Server was issued a certificate by the same CA.
-	tlsConfig = utils.TLSConfig(params.CipherSuites)
-	tlsConfig.Time = params.Clock.Now
+	tlsConfig = params.clientTLSConfig()
 	certPool := x509.NewCertPool()
 	for _, cert := range certs {
 		certPool.AddCert(cert)
@@ -441,7 +641,8 @@ func pinRegisterClient(params RegisterParams) (*Client, error) {
 	tlsConfig.RootCAs = certPool
 
 	authClient, err = NewClient(client.Config{
-		Addrs: utils.NetAddrsToStrings(params.Servers),
+		Addrs:  utils.NetAddrsToStrings(params.Servers),
+		Dialer: registerDialer(params),
 		Credentials: []client.Credentials{
 			client.LoadTLS(tlsConfig),
 		},
@@ -451,9 +652,34 @@ func pinRegisterClient(params RegisterParams) (*Client, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// Fetch the full pin set over the now-verified connection and record any
+	// upcoming pins of an ongoing CA rotation, so operators can update the
+	// pinned configuration before the old pin stops working.
+	if pins, err := authClient.GetClusterCACertPins(context.TODO()); err == nil {
+		recordPendingCAPins(params.CAPins, pins)
+	} else if !trace.IsNotFound(err) && !trace.IsNotImplemented(err) {
+		log.WithError(err).Debug("Failed to fetch cluster CA pin set.")
+	}
+
 	return authClient, nil
 }
 
+// recordPendingCAPins surfaces pending CA pins the joining node does not have
+// in its configuration yet.
+func recordPendingCAPins(knownPins []string, pins []CAPin) {
+	known := make(map[string]bool, len(knownPins))
+	for _, pin := range knownPins {
+		known[pin] = true
+	}
+	for _, pin := range pins {
+		if pin.Pending && !known[pin.Pin] {
+			log.Warnf("Cluster CA rotation in progress: record upcoming CA pin %v (valid until %v) "+
+				"in this node's configuration to keep joining after the rotation completes.",
+				pin.Pin, pin.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
+
 type joinServiceClient interface {
 	RegisterUsingIAMMethod(ctx context.Context, challengeResponse client.RegisterChallengeResponseFunc) (*proto.Certs, error)
 }
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+const (
+	// rotationReissuesPerMinute is how many host certificates may be
+	// re-issued per minute while a CA rotation phase is in progress. The
+	// cap spreads the re-issuance load of large clusters over the phase
+	// grace period instead of letting every host reconnect at once and
+	// starve interactive logins.
+	rotationReissuesPerMinute = 1200
+	// rotationReissueBurst is the size of the token bucket, i.e. how many
+	// re-issues may happen back to back before pacing kicks in.
+	rotationReissueBurst = 200
+	// rotationHostCooldown is the minimum interval between re-issues
+	// granted to the same host during a rotation. A host that has already
+	// been served yields to hosts that have not, so a tight client retry
+	// loop can not drain the bucket on its own.
+	rotationHostCooldown = time.Minute
+)
+
+// rotationThrottleApplies reports whether the rotation is in a phase during
+// which hosts mass re-issue their certificates.
+func rotationThrottleApplies(rotation types.Rotation) bool {
+	if rotation.State != types.RotationStateInProgress {
+		return false
+	}
+	switch rotation.Phase {
+	case types.RotationPhaseUpdateClients, types.RotationPhaseUpdateServers, types.RotationPhaseRollback:
+		return true
+	}
+	return false
+}
+
+// hostReissueThrottle is a token bucket pacing host certificate re-issuance
+// while a CA rotation is in progress. It is consulted by GenerateHostCerts
+// only when the rotation is in a phase that makes hosts re-issue en masse;
+// steady state issuance is not affected.
+type hostReissueThrottle struct {
+	mu sync.Mutex
+	// tokens is the current token bucket fill.
+	tokens float64
+	// lastRefill is when tokens were last added to the bucket.
+	lastRefill time.Time
+	// hosts records when each host was last granted a re-issue, enforcing
+	// the per-host cooldown.
+	hosts map[string]time.Time
+}
+
+func newHostReissueThrottle() *hostReissueThrottle {
+	return &hostReissueThrottle{
+		tokens: rotationReissueBurst,
+		hosts:  make(map[string]time.Time),
+	}
+}
+
+// acquire attempts to take a re-issue slot for the given host. It returns
+// true when the host may proceed, otherwise it returns how long the host
+// should wait before retrying.
+func (t *hostReissueThrottle) acquire(now time.Time, hostID string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// refill the bucket for the time passed since the last grant
+	if !t.lastRefill.IsZero() {
+		t.tokens += now.Sub(t.lastRefill).Minutes() * rotationReissuesPerMinute
+		if t.tokens > rotationReissueBurst {
+			t.tokens = rotationReissueBurst
+		}
+	}
+	t.lastRefill = now
+
+	// a host that was served recently waits out its cooldown without
+	// consuming tokens, leaving the bucket to hosts still in line
+	if granted, ok := t.hosts[hostID]; ok {
+		if wait := rotationHostCooldown - now.Sub(granted); wait > 0 {
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, false
+		}
+	}
+
+	if t.tokens < 1 {
+		wait := time.Duration((1 - t.tokens) / rotationReissuesPerMinute * float64(time.Minute))
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return wait, false
+	}
+
+	t.tokens--
+	t.hosts[hostID] = now
+	t.prune(now)
+	return 0, true
+}
+
+// prune drops host entries whose cooldown has long expired so the map does
+// not grow with the all-time number of hosts seen.
+func (t *hostReissueThrottle) prune(now time.Time) {
+	for hostID, granted := range t.hosts {
+		if now.Sub(granted) > 2*rotationHostCooldown {
+			delete(t.hosts, hostID)
+		}
+	}
+}
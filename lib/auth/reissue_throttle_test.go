@@ -0,0 +1,83 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestRotationThrottleApplies(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, rotationThrottleApplies(types.Rotation{}))
+	require.False(t, rotationThrottleApplies(types.Rotation{
+		State: types.RotationStateInProgress,
+		Phase: types.RotationPhaseInit,
+	}))
+	for _, phase := range []string{
+		types.RotationPhaseUpdateClients,
+		types.RotationPhaseUpdateServers,
+		types.RotationPhaseRollback,
+	} {
+		require.True(t, rotationThrottleApplies(types.Rotation{
+			State: types.RotationStateInProgress,
+			Phase: phase,
+		}), "expected throttle to apply in phase %q", phase)
+	}
+}
+
+func TestHostReissueThrottle(t *testing.T) {
+	t.Parallel()
+	throttle := newHostReissueThrottle()
+	now := time.Now()
+
+	// distinct hosts drain the burst back to back
+	for i := 0; i < rotationReissueBurst; i++ {
+		_, ok := throttle.acquire(now, fmt.Sprintf("host-%v", i))
+		require.True(t, ok, "expected host %v to fit in the burst", i)
+	}
+
+	// the bucket is empty, the next host has to wait for a refill
+	retryAfter, ok := throttle.acquire(now, "host-late")
+	require.False(t, ok)
+	require.Greater(t, retryAfter, time.Duration(0))
+
+	// tokens come back at the configured rate
+	now = now.Add(time.Minute)
+	_, ok = throttle.acquire(now, "host-late")
+	require.True(t, ok)
+
+	// a host that was just served waits out its cooldown even though
+	// tokens are available
+	retryAfter, ok = throttle.acquire(now.Add(time.Second), "host-late")
+	require.False(t, ok)
+	require.Greater(t, retryAfter, time.Duration(0))
+
+	// while a fresh host is served right away
+	_, ok = throttle.acquire(now.Add(time.Second), "host-fresh")
+	require.True(t, ok)
+
+	// after the cooldown the host may re-issue again
+	_, ok = throttle.acquire(now.Add(rotationHostCooldown+time.Second), "host-late")
+	require.True(t, ok)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// accessRequestDurationPolicyPrefix is the backend key the request
+	// duration policy singleton is stored under.
+	accessRequestDurationPolicyPrefix = "access_request_duration_policy"
+
+	// accessRequestDurationPrefix is the backend prefix the per-request
+	// elevation windows are stored under.
+	accessRequestDurationPrefix = "access_request_durations"
+)
+
+// AccessRequestDurationPolicy limits how long elevated access granted by an
+// approved request may be used. The window is distinct from the request
+// expiry: once it closes, the elevated roles drop out of newly issued
+// certificates and the user has to get re-approved.
+type AccessRequestDurationPolicy struct {
+	// DefaultMaxDuration limits elevation for requests whose roles have no
+	// specific entry, zero means unlimited.
+	DefaultMaxDuration time.Duration `json:"default_max_duration,omitempty"`
+	// RoleMaxDurations limits elevation per requested role. When a request
+	// spans several listed roles, the strictest limit wins.
+	RoleMaxDurations map[string]time.Duration `json:"role_max_durations,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *AccessRequestDurationPolicy) CheckAndSetDefaults() error {
+	if p.DefaultMaxDuration < 0 {
+		return trace.BadParameter("default max duration cannot be negative, got %v", p.DefaultMaxDuration)
+	}
+	for role, maxDuration := range p.RoleMaxDurations {
+		if role == "" {
+			return trace.BadParameter("access request duration policy contains an empty role name")
+		}
+		if maxDuration <= 0 {
+			return trace.BadParameter("max duration for role %q must be positive, got %v", role, maxDuration)
+		}
+	}
+	return nil
+}
+
+// maxDurationFor returns the elevation limit for a request spanning the
+// given roles, zero when the policy does not limit it.
+func (p *AccessRequestDurationPolicy) maxDurationFor(roles []string) time.Duration {
+	limit := p.DefaultMaxDuration
+	for _, role := range roles {
+		roleLimit, ok := p.RoleMaxDurations[role]
+		if !ok {
+			continue
+		}
+		if limit == 0 || roleLimit < limit {
+			limit = roleLimit
+		}
+	}
+	return limit
+}
+
+// accessRequestDuration records the elevation window of a single access
+// request. The expiry is only filled in at approval time, when the window
+// starts.
+type accessRequestDuration struct {
+	// RequestID is the ID of the access request.
+	RequestID string `json:"request_id"`
+	// MaxDuration is how long elevated access stays usable after approval.
+	MaxDuration time.Duration `json:"max_duration"`
+	// ElevationExpiry is when the elevation window closes, zero until the
+	// request is approved.
+	ElevationExpiry time.Time `json:"elevation_expiry,omitempty"`
+}
+
+// SetAccessRequestDurationPolicy updates the cluster access request duration
+// policy.
+func (a *Server) SetAccessRequestDurationPolicy(ctx context.Context, policy AccessRequestDurationPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(accessRequestDurationPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetAccessRequestDurationPolicy returns the cluster access request duration
+// policy, with no limits when none was set.
+func (a *Server) GetAccessRequestDurationPolicy(ctx context.Context) (AccessRequestDurationPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(accessRequestDurationPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return AccessRequestDurationPolicy{}, nil
+		}
+		return AccessRequestDurationPolicy{}, trace.Wrap(err)
+	}
+	var policy AccessRequestDurationPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return AccessRequestDurationPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteAccessRequestDurationPolicy removes the cluster access request
+// duration policy.
+func (a *Server) DeleteAccessRequestDurationPolicy(ctx context.Context) error {
+	err := a.bk.Delete(ctx, backend.Key(accessRequestDurationPolicyPrefix))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("access request duration policy is not set")
+	}
+	return trace.Wrap(err)
+}
+
+// CreateAccessRequestWithMaxDuration creates an access request whose
+// elevated access expires the given duration after approval, regardless of
+// the request expiry. The duration is clamped to the cluster policy limit
+// for the requested roles.
+func (a *Server) CreateAccessRequestWithMaxDuration(ctx context.Context, req types.AccessRequest, maxDuration time.Duration) error {
+	if maxDuration <= 0 {
+		return trace.BadParameter("max duration must be positive, got %v", maxDuration)
+	}
+	policy, err := a.GetAccessRequestDurationPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if limit := policy.maxDurationFor(req.GetRoles()); limit != 0 && maxDuration > limit {
+		maxDuration = limit
+	}
+	if err := a.CreateAccessRequest(ctx, req); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(a.setAccessRequestDuration(ctx, accessRequestDuration{
+		RequestID:   req.GetName(),
+		MaxDuration: maxDuration,
+	}, req.GetAccessExpiry()))
+}
+
+func (a *Server) setAccessRequestDuration(ctx context.Context, duration accessRequestDuration, expires time.Time) error {
+	value, err := utils.FastMarshal(duration)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(accessRequestDurationPrefix, duration.RequestID),
+		Value:   value,
+		Expires: expires,
+	})
+	return trace.Wrap(err)
+}
+
+func (a *Server) getAccessRequestDuration(ctx context.Context, requestID string) (accessRequestDuration, bool, error) {
+	item, err := a.bk.Get(ctx, backend.Key(accessRequestDurationPrefix, requestID))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return accessRequestDuration{}, false, nil
+		}
+		return accessRequestDuration{}, false, trace.Wrap(err)
+	}
+	var duration accessRequestDuration
+	if err := utils.FastUnmarshal(item.Value, &duration); err != nil {
+		return accessRequestDuration{}, false, trace.Wrap(err)
+	}
+	return duration, true, nil
+}
+
+// startAccessRequestElevation opens the elevation window of a freshly
+// approved access request. Requests created without an explicit max duration
+// still pick up the cluster policy limit for their roles.
+func (a *Server) startAccessRequestElevation(ctx context.Context, req types.AccessRequest) error {
+	duration, ok, err := a.getAccessRequestDuration(ctx, req.GetName())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !ok {
+		policy, err := a.GetAccessRequestDurationPolicy(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		limit := policy.maxDurationFor(req.GetRoles())
+		if limit == 0 {
+			return nil
+		}
+		duration = accessRequestDuration{
+			RequestID:   req.GetName(),
+			MaxDuration: limit,
+		}
+	}
+	if !duration.ElevationExpiry.IsZero() {
+		// Re-approving a request does not restart its window.
+		return nil
+	}
+	duration.ElevationExpiry = a.clock.Now().UTC().Add(duration.MaxDuration)
+	return trace.Wrap(a.setAccessRequestDuration(ctx, duration, req.GetAccessExpiry()))
+}
+
+// getAccessRequestElevationExpiry returns when the elevation window of the
+// access request closes, zero when it is not limited or not yet open.
+func (a *Server) getAccessRequestElevationExpiry(ctx context.Context, requestID string) (time.Time, error) {
+	duration, ok, err := a.getAccessRequestDuration(ctx, requestID)
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	if !ok {
+		return time.Time{}, nil
+	}
+	return duration.ElevationExpiry, nil
+}
+
+// CreateAccessRequestWithMaxDuration creates an access request whose
+// elevated access expires the given duration after approval.
+func (a *ServerWithRoles) CreateAccessRequestWithMaxDuration(ctx context.Context, req types.AccessRequest, maxDuration time.Duration) error {
+	if err := a.createAccessRequestAction(req); err != nil {
+		return trace.Wrap(err)
+	}
+	// Ensure that an access request cannot outlive the identity that creates it.
+	if req.GetAccessExpiry().Before(a.authServer.GetClock().Now()) || req.GetAccessExpiry().After(a.context.Identity.GetIdentity().Expires) {
+		req.SetAccessExpiry(a.context.Identity.GetIdentity().Expires)
+	}
+	return a.authServer.CreateAccessRequestWithMaxDuration(ctx, req, maxDuration)
+}
+
+// SetAccessRequestDurationPolicy updates the cluster access request duration
+// policy.
+func (a *ServerWithRoles) SetAccessRequestDurationPolicy(ctx context.Context, policy AccessRequestDurationPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetAccessRequestDurationPolicy(ctx, policy)
+}
+
+// GetAccessRequestDurationPolicy returns the cluster access request duration
+// policy.
+func (a *ServerWithRoles) GetAccessRequestDurationPolicy(ctx context.Context) (AccessRequestDurationPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbRead); err != nil {
+		return AccessRequestDurationPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessRequestDurationPolicy(ctx)
+}
+
+// DeleteAccessRequestDurationPolicy removes the cluster access request
+// duration policy.
+func (a *ServerWithRoles) DeleteAccessRequestDurationPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAccessRequestDurationPolicy(ctx)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestAccessRequestDurationPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// The default policy does not limit elevation.
+	policy, err := s.a.GetAccessRequestDurationPolicy(ctx)
+	require.NoError(t, err)
+	require.Zero(t, policy.DefaultMaxDuration)
+
+	// Invalid policies are rejected.
+	err = s.a.SetAccessRequestDurationPolicy(ctx, AccessRequestDurationPolicy{DefaultMaxDuration: -time.Hour})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.SetAccessRequestDurationPolicy(ctx, AccessRequestDurationPolicy{
+		RoleMaxDurations: map[string]time.Duration{"dba": 0},
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A valid policy round-trips, the strictest role limit wins.
+	err = s.a.SetAccessRequestDurationPolicy(ctx, AccessRequestDurationPolicy{
+		DefaultMaxDuration: 4 * time.Hour,
+		RoleMaxDurations: map[string]time.Duration{
+			"dba":   time.Hour,
+			"admin": 30 * time.Minute,
+		},
+	})
+	require.NoError(t, err)
+	policy, err = s.a.GetAccessRequestDurationPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, policy.maxDurationFor([]string{"dba", "admin"}))
+	require.Equal(t, time.Hour, policy.maxDurationFor([]string{"dba"}))
+	require.Equal(t, 4*time.Hour, policy.maxDurationFor([]string{"other"}))
+
+	require.NoError(t, s.a.DeleteAccessRequestDurationPolicy(ctx))
+	err = s.a.DeleteAccessRequestDurationPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestAccessRequestMaxDuration(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	clock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(clock)
+
+	requestable, err := types.NewRoleV3("elevated", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requestable))
+
+	requester, err := types.NewRoleV3("duration-requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"elevated"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	user, err := types.NewUser("duration-user")
+	require.NoError(t, err)
+	user.SetRoles([]string{"duration-requester"})
+	require.NoError(t, s.a.UpsertUser(user))
+
+	newRequest := func() types.AccessRequest {
+		req, err := types.NewAccessRequest(uuid.New().String(), "duration-user", "elevated")
+		require.NoError(t, err)
+		req.SetAccessExpiry(clock.Now().Add(8 * time.Hour))
+		return req
+	}
+	approve := func(id string) {
+		require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+			RequestID: id,
+			State:     types.RequestState_APPROVED,
+		}))
+	}
+
+	// The max duration must be positive.
+	err = s.a.CreateAccessRequestWithMaxDuration(ctx, newRequest(), 0)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// The elevation window starts at approval, not at creation.
+	req := newRequest()
+	require.NoError(t, s.a.CreateAccessRequestWithMaxDuration(ctx, req, time.Hour))
+	clock.Advance(30 * time.Minute)
+	approve(req.GetName())
+
+	// Inside the window the request is assumable, but certificates are
+	// clamped to the window.
+	validated, err := s.a.getValidatedAccessRequest(ctx, "duration-user", req.GetName())
+	require.NoError(t, err)
+	require.WithinDuration(t, clock.Now().Add(time.Hour), validated.GetAccessExpiry(), time.Second)
+
+	// Once the window closes the request is no longer assumable even though
+	// the request object has not expired.
+	clock.Advance(61 * time.Minute)
+	_, err = s.a.getValidatedAccessRequest(ctx, "duration-user", req.GetName())
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Contains(t, err.Error(), "maximum elevation duration")
+	reqs, err := s.a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: req.GetName()})
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+
+	// The policy clamps requested durations and applies to requests created
+	// without one.
+	require.NoError(t, s.a.SetAccessRequestDurationPolicy(ctx, AccessRequestDurationPolicy{
+		RoleMaxDurations: map[string]time.Duration{"elevated": 30 * time.Minute},
+	}))
+
+	clamped := newRequest()
+	require.NoError(t, s.a.CreateAccessRequestWithMaxDuration(ctx, clamped, 5*time.Hour))
+	approve(clamped.GetName())
+	validated, err = s.a.getValidatedAccessRequest(ctx, "duration-user", clamped.GetName())
+	require.NoError(t, err)
+	require.WithinDuration(t, clock.Now().Add(30*time.Minute), validated.GetAccessExpiry(), time.Second)
+
+	plain := newRequest()
+	require.NoError(t, s.a.CreateAccessRequest(ctx, plain))
+	approve(plain.GetName())
+	clock.Advance(31 * time.Minute)
+	_, err = s.a.getValidatedAccessRequest(ctx, "duration-user", plain.GetName())
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// requestInsightsPrefix is the backend prefix under which access
+	// request aggregates are stored.
+	requestInsightsPrefix = "access_request_insights"
+	// requestInsightsRetries bounds the retries of concurrent aggregate
+	// updates.
+	requestInsightsRetries = 5
+	// requestUsageTTL is how long the per-request usage markers feeding
+	// the granted-but-unused metric are retained.
+	requestUsageTTL = 90 * 24 * time.Hour
+	// topRequestersLimit caps how many requesters the report lists.
+	topRequestersLimit = 10
+)
+
+// approvalLatencyBuckets are the upper bounds of the approval latency
+// histogram kept per role. The aggregates stay fixed-size regardless of
+// request volume and the median is derived from the histogram. A zero bound
+// marks the catch-all bucket.
+var approvalLatencyBuckets = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	4 * time.Hour,
+	24 * time.Hour,
+	0,
+}
+
+// roleInsights is the incrementally updated aggregate per requested role.
+type roleInsights struct {
+	// Approved is the number of approved requests for the role.
+	Approved int `json:"approved"`
+	// LatencyCounts is the approval latency histogram, one counter per
+	// entry of approvalLatencyBuckets.
+	LatencyCounts []int `json:"latency_counts"`
+}
+
+// requesterInsights is the incrementally updated aggregate per requester.
+type requesterInsights struct {
+	// Requests is the number of access requests the user has created.
+	Requests int `json:"requests"`
+}
+
+// requestUsage marks whether an approved request was followed by a
+// certificate issued with it.
+type requestUsage struct {
+	// Used is true once a certificate was issued carrying the request.
+	Used bool `json:"used"`
+}
+
+func requestInsightsRoleKey(role string) []byte {
+	return backend.Key(requestInsightsPrefix, "roles", role)
+}
+
+func requestInsightsRequesterKey(user string) []byte {
+	return backend.Key(requestInsightsPrefix, "requesters", user)
+}
+
+func requestInsightsUsageKey(requestID string) []byte {
+	return backend.Key(requestInsightsPrefix, "requests", requestID)
+}
+
+// updateInsightsItem applies fn to the JSON value stored under key with a
+// compare-and-swap retry loop, creating the item when it does not exist.
+func (a *Server) updateInsightsItem(ctx context.Context, key []byte, expires time.Time, fn func(value []byte) ([]byte, error)) error {
+	for i := 0; ; i++ {
+		item, err := a.bk.Get(ctx, key)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if err != nil {
+			value, err := fn(nil)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if _, err := a.bk.Create(ctx, backend.Item{Key: key, Value: value, Expires: expires}); err != nil {
+				if trace.IsAlreadyExists(err) && i < requestInsightsRetries {
+					continue
+				}
+				return trace.Wrap(err)
+			}
+			return nil
+		}
+		value, err := fn(item.Value)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := a.bk.CompareAndSwap(ctx, *item, backend.Item{Key: key, Value: value, Expires: expires}); err != nil {
+			if trace.IsCompareFailed(err) && i < requestInsightsRetries {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+}
+
+// recordAccessRequestCreated counts the request towards its requester.
+func (a *Server) recordAccessRequestCreated(ctx context.Context, req types.AccessRequest) error {
+	return trace.Wrap(a.updateInsightsItem(ctx, requestInsightsRequesterKey(req.GetUser()), time.Time{}, func(value []byte) ([]byte, error) {
+		var insights requesterInsights
+		if value != nil {
+			if err := utils.FastUnmarshal(value, &insights); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		insights.Requests++
+		return utils.FastMarshal(insights)
+	}))
+}
+
+// recordAccessRequestResolved folds an approved request into the per-role
+// latency aggregates and starts tracking whether the grant gets used.
+func (a *Server) recordAccessRequestResolved(ctx context.Context, req types.AccessRequest, state types.RequestState) error {
+	if !state.IsApproved() {
+		return nil
+	}
+	latency := a.clock.Now().Sub(req.GetCreationTime())
+	bucket := len(approvalLatencyBuckets) - 1
+	for i, bound := range approvalLatencyBuckets {
+		if bound != 0 && latency <= bound {
+			bucket = i
+			break
+		}
+	}
+	for _, role := range req.GetRoles() {
+		err := a.updateInsightsItem(ctx, requestInsightsRoleKey(role), time.Time{}, func(value []byte) ([]byte, error) {
+			var insights roleInsights
+			if value != nil {
+				if err := utils.FastUnmarshal(value, &insights); err != nil {
+					return nil, trace.Wrap(err)
+				}
+			}
+			if len(insights.LatencyCounts) != len(approvalLatencyBuckets) {
+				insights.LatencyCounts = make([]int, len(approvalLatencyBuckets))
+			}
+			insights.Approved++
+			insights.LatencyCounts[bucket]++
+			return utils.FastMarshal(insights)
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	value, err := utils.FastMarshal(requestUsage{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     requestInsightsUsageKey(req.GetName()),
+		Value:   value,
+		Expires: a.clock.Now().Add(requestUsageTTL),
+	})
+	return trace.Wrap(err)
+}
+
+// recordAccessRequestUsage marks the given requests as used after a
+// certificate carrying them was issued.
+func (a *Server) recordAccessRequestUsage(ctx context.Context, requestIDs []string) {
+	for _, requestID := range requestIDs {
+		item, err := a.bk.Get(ctx, requestInsightsUsageKey(requestID))
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				log.WithError(err).Debugf("Failed to read usage marker of access request %v.", requestID)
+			}
+			continue
+		}
+		var usage requestUsage
+		if err := utils.FastUnmarshal(item.Value, &usage); err != nil || usage.Used {
+			continue
+		}
+		usage.Used = true
+		value, err := utils.FastMarshal(usage)
+		if err != nil {
+			continue
+		}
+		if _, err := a.bk.Put(ctx, backend.Item{Key: item.Key, Value: value, Expires: item.Expires}); err != nil {
+			log.WithError(err).Debugf("Failed to mark access request %v as used.", requestID)
+		}
+	}
+}
+
+// RoleApprovalInsight reports the approval statistics of one requested role.
+type RoleApprovalInsight struct {
+	// Role is the requested role.
+	Role string `json:"role"`
+	// Approved is the number of approved requests for the role.
+	Approved int `json:"approved"`
+	// MedianApprovalLatency is an upper bound estimate of the median time
+	// between request creation and approval, derived from a fixed latency
+	// histogram.
+	MedianApprovalLatency time.Duration `json:"median_approval_latency"`
+}
+
+// RequesterInsight reports the request volume of one user.
+type RequesterInsight struct {
+	// Name is the requesting user.
+	Name string `json:"name"`
+	// Requests is the number of access requests the user has created.
+	Requests int `json:"requests"`
+}
+
+// AccessRequestInsights is an aggregated report over access requests meant
+// to drive least-privilege policy decisions.
+type AccessRequestInsights struct {
+	// Roles are the per-role approval statistics, sorted by request
+	// volume.
+	Roles []RoleApprovalInsight `json:"roles"`
+	// TopRequesters are the users with the most access requests.
+	TopRequesters []RequesterInsight `json:"top_requesters"`
+	// ApprovedTracked is the number of approved requests the usage metric
+	// is based on (markers are retained for a limited period).
+	ApprovedTracked int `json:"approved_tracked"`
+	// UnusedGrantPercent is the percentage of tracked approved requests
+	// that were never followed by a certificate issued with them.
+	UnusedGrantPercent float64 `json:"unused_grant_percent"`
+}
+
+// GetAccessRequestInsights assembles the aggregated access request report
+// from the incrementally maintained aggregates.
+func (a *Server) GetAccessRequestInsights(ctx context.Context) (*AccessRequestInsights, error) {
+	insights := &AccessRequestInsights{}
+
+	startKey := backend.Key(requestInsightsPrefix, "roles")
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, item := range result.Items {
+		var role roleInsights
+		if err := utils.FastUnmarshal(item.Value, &role); err != nil {
+			continue
+		}
+		insights.Roles = append(insights.Roles, RoleApprovalInsight{
+			Role:                  insightsKeyName(item.Key),
+			Approved:              role.Approved,
+			MedianApprovalLatency: medianFromHistogram(role.LatencyCounts),
+		})
+	}
+	sort.Slice(insights.Roles, func(i, j int) bool {
+		return insights.Roles[i].Approved > insights.Roles[j].Approved
+	})
+
+	startKey = backend.Key(requestInsightsPrefix, "requesters")
+	result, err = a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, item := range result.Items {
+		var requester requesterInsights
+		if err := utils.FastUnmarshal(item.Value, &requester); err != nil {
+			continue
+		}
+		insights.TopRequesters = append(insights.TopRequesters, RequesterInsight{
+			Name:     insightsKeyName(item.Key),
+			Requests: requester.Requests,
+		})
+	}
+	sort.Slice(insights.TopRequesters, func(i, j int) bool {
+		return insights.TopRequesters[i].Requests > insights.TopRequesters[j].Requests
+	})
+	if len(insights.TopRequesters) > topRequestersLimit {
+		insights.TopRequesters = insights.TopRequesters[:topRequestersLimit]
+	}
+
+	startKey = backend.Key(requestInsightsPrefix, "requests")
+	result, err = a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	unused := 0
+	for _, item := range result.Items {
+		var usage requestUsage
+		if err := utils.FastUnmarshal(item.Value, &usage); err != nil {
+			continue
+		}
+		insights.ApprovedTracked++
+		if !usage.Used {
+			unused++
+		}
+	}
+	if insights.ApprovedTracked > 0 {
+		insights.UnusedGrantPercent = 100 * float64(unused) / float64(insights.ApprovedTracked)
+	}
+	return insights, nil
+}
+
+// insightsKeyName returns the last component of a backend key.
+func insightsKeyName(key []byte) string {
+	parts := bytes.Split(key, []byte{backend.Separator})
+	return string(parts[len(parts)-1])
+}
+
+// medianFromHistogram estimates the median as the upper bound of the bucket
+// the middle sample falls into. The catch-all bucket reports the largest
+// finite bound.
+func medianFromHistogram(counts []int) time.Duration {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	half := (total + 1) / 2
+	cumulative := 0
+	for i, count := range counts {
+		cumulative += count
+		if cumulative >= half {
+			if approvalLatencyBuckets[i] != 0 {
+				return approvalLatencyBuckets[i]
+			}
+			return approvalLatencyBuckets[len(approvalLatencyBuckets)-2]
+		}
+	}
+	return 0
+}
+
+// GetAccessRequestInsights returns the aggregated access request report.
+func (a *ServerWithRoles) GetAccessRequestInsights(ctx context.Context) (*AccessRequestInsights, error) {
+	if err := a.action(apidefaults.Namespace, types.KindAccessRequest, types.VerbRead, types.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessRequestInsights(ctx)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestAccessRequestInsights(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	newRequest := func(name, user string, age time.Duration, roles ...string) types.AccessRequest {
+		req, err := types.NewAccessRequest(name, user, roles...)
+		require.NoError(t, err)
+		req.SetCreationTime(s.a.clock.Now().Add(-age))
+		return req
+	}
+
+	// two approvals for dev, a fast and a slow one, one for dba
+	first := newRequest("req-1", "alice", 30*time.Second, "dev")
+	require.NoError(t, s.a.recordAccessRequestCreated(ctx, first))
+	require.NoError(t, s.a.recordAccessRequestResolved(ctx, first, types.RequestState_APPROVED))
+
+	second := newRequest("req-2", "alice", 2*time.Hour, "dev")
+	require.NoError(t, s.a.recordAccessRequestCreated(ctx, second))
+	require.NoError(t, s.a.recordAccessRequestResolved(ctx, second, types.RequestState_APPROVED))
+
+	third := newRequest("req-3", "bob", 10*time.Minute, "dba")
+	require.NoError(t, s.a.recordAccessRequestCreated(ctx, third))
+	require.NoError(t, s.a.recordAccessRequestResolved(ctx, third, types.RequestState_APPROVED))
+
+	// denied requests do not count towards approvals
+	denied := newRequest("req-4", "bob", time.Minute, "dba")
+	require.NoError(t, s.a.recordAccessRequestCreated(ctx, denied))
+	require.NoError(t, s.a.recordAccessRequestResolved(ctx, denied, types.RequestState_DENIED))
+
+	// only the first grant is followed by a certificate
+	s.a.recordAccessRequestUsage(ctx, []string{"req-1", "not-tracked"})
+
+	insights, err := s.a.GetAccessRequestInsights(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, insights.Roles, 2)
+	require.Equal(t, "dev", insights.Roles[0].Role)
+	require.Equal(t, 2, insights.Roles[0].Approved)
+	require.Equal(t, time.Minute, insights.Roles[0].MedianApprovalLatency)
+	require.Equal(t, "dba", insights.Roles[1].Role)
+	require.Equal(t, 1, insights.Roles[1].Approved)
+	require.Equal(t, 15*time.Minute, insights.Roles[1].MedianApprovalLatency)
+
+	require.Len(t, insights.TopRequesters, 2)
+	require.Equal(t, "alice", insights.TopRequesters[0].Name)
+	require.Equal(t, 2, insights.TopRequesters[0].Requests)
+
+	require.Equal(t, 3, insights.ApprovedTracked)
+	require.InDelta(t, 100.0*2/3, insights.UnusedGrantPercent, 0.01)
+}
+
+func TestMedianFromHistogram(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, time.Duration(0), medianFromHistogram(nil))
+	require.Equal(t, time.Minute, medianFromHistogram([]int{3, 1, 0, 0, 0, 0, 0}))
+	require.Equal(t, 5*time.Minute, medianFromHistogram([]int{1, 1, 2, 0, 0, 0, 0}))
+	// the catch-all bucket reports the largest finite bound
+	require.Equal(t, 24*time.Hour, medianFromHistogram([]int{0, 0, 0, 0, 0, 0, 5}))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// AccessRequestPreview describes what submitting a prospective access
+// request would do, so UIs can show the outcome before anything is created.
+type AccessRequestPreview struct {
+	// Roles are the roles the request would grant, with wildcards expanded.
+	Roles []string `json:"roles,omitempty"`
+	// Resources are the resources the request would grant, in the same
+	// format tsh accepts.
+	Resources []string `json:"resources,omitempty"`
+	// AccessExpiry is when the granted access would end.
+	AccessExpiry time.Time `json:"access_expiry"`
+	// Thresholds are the review thresholds that would govern the request.
+	Thresholds []types.AccessReviewThreshold `json:"thresholds,omitempty"`
+	// SuggestedReviewers are the reviewers suggested for the request.
+	SuggestedReviewers []string `json:"suggested_reviewers,omitempty"`
+	// RequiresMultipleApprovals is true when the request carries review
+	// thresholds beyond the single-approval default.
+	RequiresMultipleApprovals bool `json:"requires_multiple_approvals"`
+	// AutoDecision is the decision an auto-approval rule would apply on
+	// submission, empty when the request would stay pending for review.
+	AutoDecision string `json:"auto_decision,omitempty"`
+	// AutoRule is the name of the auto-approval rule behind AutoDecision.
+	AutoRule string `json:"auto_rule,omitempty"`
+}
+
+// PreviewAccessRequest runs the prospective request through the same
+// validation and expansion as a real submission, without creating anything,
+// and reports what would be granted and how it would be resolved.
+func (a *Server) PreviewAccessRequest(ctx context.Context, req types.AccessRequest) (*AccessRequestPreview, error) {
+	req.SetDryRun(true)
+	// A dry run exercises the full creation path: validation, variable
+	// expansion and threshold population, stopping short of the backend
+	// write.
+	if err := a.CreateAccessRequest(ctx, req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	preview := &AccessRequestPreview{
+		Roles:                     req.GetRoles(),
+		AccessExpiry:              req.GetAccessExpiry(),
+		Thresholds:                req.GetThresholds(),
+		SuggestedReviewers:        req.GetSuggestedReviewers(),
+		RequiresMultipleApprovals: requestHasCustomThresholds(req),
+	}
+	for _, id := range req.GetRequestedResourceIDs() {
+		preview.Resources = append(preview.Resources, types.ResourceIDToString(id))
+	}
+
+	matched, err := a.matchAutoApprovalRules(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if matched != nil {
+		// Mirror the runtime behavior: approvals never override a
+		// multi-approver policy.
+		if matched.Decision != AutoApprovalDecisionApprove || !preview.RequiresMultipleApprovals {
+			preview.AutoDecision = matched.Decision
+			preview.AutoRule = matched.Name
+		}
+	}
+	return preview, nil
+}
+
+// PreviewAccessRequest reports what submitting the prospective access
+// request would do, without creating it.
+func (a *ServerWithRoles) PreviewAccessRequest(ctx context.Context, req types.AccessRequest) (*AccessRequestPreview, error) {
+	// Previewing takes the same permissions as filing the request itself.
+	if err := a.createAccessRequestAction(req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.PreviewAccessRequest(ctx, req)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestPreviewAccessRequest(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	elevated, err := types.NewRoleV3("preview-elevated", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, elevated))
+
+	requester, err := types.NewRoleV3("preview-requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"preview-elevated"},
+				Thresholds: []types.AccessReviewThreshold{
+					{Name: "two-approvers", Approve: 2, Deny: 1},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	user, err := types.NewUser("preview-user")
+	require.NoError(t, err)
+	user.SetRoles([]string{"preview-requester"})
+	require.NoError(t, s.a.UpsertUser(user))
+
+	newRequest := func() types.AccessRequest {
+		req, err := types.NewAccessRequest(uuid.New().String(), "preview-user", "preview-elevated")
+		require.NoError(t, err)
+		req.SetAccessExpiry(s.a.GetClock().Now().Add(4 * time.Hour))
+		return req
+	}
+
+	// The preview reports grants and thresholds without creating anything.
+	req := newRequest()
+	preview, err := s.a.PreviewAccessRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{"preview-elevated"}, preview.Roles)
+	require.False(t, preview.AccessExpiry.IsZero())
+	require.True(t, preview.RequiresMultipleApprovals)
+	require.NotEmpty(t, preview.Thresholds)
+	require.Empty(t, preview.AutoDecision)
+	reqs, err := s.a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: req.GetName()})
+	require.NoError(t, err)
+	require.Empty(t, reqs)
+
+	// Requests the user cannot file fail validation in the preview too.
+	invalid, err := types.NewAccessRequest(uuid.New().String(), "preview-user", "nonexistent-role")
+	require.NoError(t, err)
+	_, err = s.a.PreviewAccessRequest(ctx, invalid)
+	require.Error(t, err)
+
+	// A matching deny rule shows up as the predicted decision, but an
+	// approve rule never overrides a multi-approver policy.
+	require.NoError(t, s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:     "approve-all",
+		Decision: AutoApprovalDecisionApprove,
+	}))
+	preview, err = s.a.PreviewAccessRequest(ctx, newRequest())
+	require.NoError(t, err)
+	require.Empty(t, preview.AutoDecision)
+
+	require.NoError(t, s.a.UpsertAccessAutoApprovalRule(ctx, AccessAutoApprovalRule{
+		Name:     "deny-all",
+		Decision: AutoApprovalDecisionDeny,
+	}))
+	preview, err = s.a.PreviewAccessRequest(ctx, newRequest())
+	require.NoError(t, err)
+	require.Equal(t, AutoApprovalDecisionDeny, preview.AutoDecision)
+	require.Equal(t, "deny-all", preview.AutoRule)
+
+	// The deny prediction matches what submission actually does.
+	submitted := newRequest()
+	require.NoError(t, s.a.CreateAccessRequest(ctx, submitted))
+	reqs, err = s.a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: submitted.GetName()})
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+	require.True(t, reqs[0].GetState().IsDenied())
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// dataResidencyPolicyKey is the backend key the residency policy is stored
+// under.
+var dataResidencyPolicyKey = backend.Key("data_residency_policy")
+
+// DataResidencyRule routes session data for resources carrying the given
+// labels to a region-specific storage location.
+type DataResidencyRule struct {
+	// Region is the region session data for matching resources must be
+	// stored in, e.g. "eu".
+	Region string `json:"region"`
+	// StorageURI is the storage location serving the region, e.g. an
+	// s3:// or gs:// bucket URI.
+	StorageURI string `json:"storage_uri"`
+	// Labels are resource labels that select this rule. All labels must
+	// match.
+	Labels map[string]string `json:"labels"`
+}
+
+// matches reports whether all of the rule's labels are present on the
+// resource.
+func (r *DataResidencyRule) matches(labels map[string]string) bool {
+	for key, value := range r.Labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return len(r.Labels) != 0
+}
+
+// DataResidencyPolicy controls where session recordings and audit events for
+// labeled resources may be stored.
+type DataResidencyPolicy struct {
+	// LocalRegion is the region served by this cluster's configured audit
+	// storage. Sessions routed to other regions are rejected at upload time.
+	LocalRegion string `json:"local_region"`
+	// Rules route resources to regions by label. The first matching rule
+	// wins; resources matching no rule are unrestricted.
+	Rules []DataResidencyRule `json:"rules,omitempty"`
+	// UpdatedAt is the time the policy was last modified.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// CheckAndSetDefaults validates the residency policy.
+func (p *DataResidencyPolicy) CheckAndSetDefaults() error {
+	for i := range p.Rules {
+		if p.Rules[i].Region == "" {
+			return trace.BadParameter("data residency rule %v is missing a region", i)
+		}
+		if len(p.Rules[i].Labels) == 0 {
+			return trace.BadParameter("data residency rule %v is missing labels", i)
+		}
+	}
+	if len(p.Rules) != 0 && p.LocalRegion == "" {
+		return trace.BadParameter("data residency policy with rules requires local_region")
+	}
+	return nil
+}
+
+// resolveRegion returns the region session data for a resource with the
+// given labels must be stored in, if any rule matches.
+func (p *DataResidencyPolicy) resolveRegion(labels map[string]string) (string, bool) {
+	for i := range p.Rules {
+		if p.Rules[i].matches(labels) {
+			return p.Rules[i].Region, true
+		}
+	}
+	return "", false
+}
+
+// SetDataResidencyPolicy updates the cluster data residency policy.
+func (a *Server) SetDataResidencyPolicy(ctx context.Context, policy DataResidencyPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	policy.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   dataResidencyPolicyKey,
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetDataResidencyPolicy returns the cluster data residency policy. A policy
+// with no rules is returned if none was configured.
+func (a *Server) GetDataResidencyPolicy(ctx context.Context) (*DataResidencyPolicy, error) {
+	var policy DataResidencyPolicy
+	item, err := a.bk.Get(ctx, dataResidencyPolicyKey)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return &policy, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &policy, nil
+}
+
+// checkServerResidency rejects session uploads from a server whose labels
+// route its session data to a region other than the one served by this
+// cluster's audit storage.
+func (a *Server) checkServerResidency(ctx context.Context, hostID string) error {
+	policy, err := a.GetDataResidencyPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(policy.Rules) == 0 {
+		return nil
+	}
+	node, err := a.GetNode(ctx, apidefaults.Namespace, hostID)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			// non-node servers (proxies, kube, etc) are not routed by labels
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	region, ok := policy.resolveRegion(node.GetAllLabels())
+	if !ok || region == policy.LocalRegion {
+		return nil
+	}
+	return trace.AccessDenied("session data for server %q must be stored in region %q, this cluster's audit storage serves region %q", hostID, region, policy.LocalRegion)
+}
+
+// DataResidencyRegionStatus reports compliance information for a single
+// residency rule.
+type DataResidencyRegionStatus struct {
+	// Region is the region the rule routes to.
+	Region string `json:"region"`
+	// StorageURI is the storage location serving the region.
+	StorageURI string `json:"storage_uri"`
+	// MatchingServers is the number of currently registered servers whose
+	// session data is routed to the region.
+	MatchingServers int `json:"matching_servers"`
+}
+
+// DataResidencyStatus is the compliance report for the data residency
+// policy.
+type DataResidencyStatus struct {
+	// Configured indicates whether a residency policy with rules is in
+	// effect.
+	Configured bool `json:"configured"`
+	// LocalRegion is the region served by this cluster's audit storage.
+	LocalRegion string `json:"local_region,omitempty"`
+	// Regions reports per-rule compliance information.
+	Regions []DataResidencyRegionStatus `json:"regions,omitempty"`
+}
+
+// GetDataResidencyStatus reports which regions the residency policy routes
+// to and how many registered servers each rule currently matches.
+func (a *Server) GetDataResidencyStatus(ctx context.Context) (*DataResidencyStatus, error) {
+	policy, err := a.GetDataResidencyPolicy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	status := &DataResidencyStatus{
+		Configured:  len(policy.Rules) != 0,
+		LocalRegion: policy.LocalRegion,
+	}
+	if !status.Configured {
+		return status, nil
+	}
+	nodes, err := a.GetNodes(ctx, apidefaults.Namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i := range policy.Rules {
+		regionStatus := DataResidencyRegionStatus{
+			Region:     policy.Rules[i].Region,
+			StorageURI: policy.Rules[i].StorageURI,
+		}
+		for _, node := range nodes {
+			if policy.Rules[i].matches(node.GetAllLabels()) {
+				regionStatus.MatchingServers++
+			}
+		}
+		status.Regions = append(status.Regions, regionStatus)
+	}
+	return status, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// TestReviewThresholdGuard verifies that requests carrying custom review
+// thresholds can only be approved through matching reviews, not by a single
+// direct state transition.
+func TestReviewThresholdGuard(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	elevated, err := types.NewRoleV3("elevated-access", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, elevated))
+
+	requester, err := types.NewRoleV3("threshold-requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"elevated-access"},
+				Thresholds: []types.AccessReviewThreshold{
+					{Name: "two-approvers", Approve: 2, Deny: 1},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	reviewer, err := types.NewRoleV3("threshold-reviewer", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			ReviewRequests: &types.AccessReviewConditions{
+				Roles: []string{"elevated-access"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, reviewer))
+
+	newUser := func(name string, roles ...string) {
+		user, err := types.NewUser(name)
+		require.NoError(t, err)
+		user.SetRoles(roles)
+		require.NoError(t, s.a.UpsertUser(user))
+	}
+	newUser("threshold-user", "threshold-requester")
+	newUser("first-reviewer", "threshold-reviewer")
+	newUser("second-reviewer", "threshold-reviewer")
+
+	newRequest := func() types.AccessRequest {
+		req, err := types.NewAccessRequest(uuid.New().String(), "threshold-user", "elevated-access")
+		require.NoError(t, err)
+		req.SetAccessExpiry(s.a.GetClock().Now().Add(8 * time.Hour))
+		require.NoError(t, s.a.CreateAccessRequest(ctx, req))
+		return req
+	}
+	review := func(requestID, author string, state types.RequestState) (types.AccessRequest, error) {
+		return s.a.SubmitAccessReview(ctx, types.AccessReviewSubmission{
+			RequestID: requestID,
+			Review: types.AccessReview{
+				Author:        author,
+				ProposedState: state,
+			},
+		})
+	}
+
+	// A request with a custom threshold cannot be approved with a direct
+	// state transition.
+	req := newRequest()
+	err = s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: req.GetName(),
+		State:     types.RequestState_APPROVED,
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Contains(t, err.Error(), "review thresholds")
+
+	// The first approving review leaves the request pending, the second one
+	// approves it.
+	reviewed, err := review(req.GetName(), "first-reviewer", types.RequestState_APPROVED)
+	require.NoError(t, err)
+	require.True(t, reviewed.GetState().IsPending())
+	reviewed, err = review(req.GetName(), "second-reviewer", types.RequestState_APPROVED)
+	require.NoError(t, err)
+	require.True(t, reviewed.GetState().IsApproved())
+
+	// A single denying review resolves the request, deny wins.
+	req = newRequest()
+	_, err = review(req.GetName(), "first-reviewer", types.RequestState_APPROVED)
+	require.NoError(t, err)
+	reviewed, err = review(req.GetName(), "second-reviewer", types.RequestState_DENIED)
+	require.NoError(t, err)
+	require.True(t, reviewed.GetState().IsDenied())
+
+	// Direct denial of a thresholded request stays possible, denying never
+	// requires a quorum.
+	req = newRequest()
+	require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: req.GetName(),
+		State:     types.RequestState_DENIED,
+	}))
+
+	// Requests with only the default threshold keep working with direct
+	// state transitions.
+	plain, err := types.NewAccessRequest(uuid.New().String(), "threshold-user", "elevated-access")
+	require.NoError(t, err)
+	plain.SetThresholds([]types.AccessReviewThreshold{{Name: "default", Approve: 1, Deny: 1}})
+	require.False(t, requestHasCustomThresholds(plain))
+}
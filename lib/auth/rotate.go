@@ -300,6 +300,14 @@ func (a *Server) RotateExternalCertAuthority(ctx context.Context, ca types.CertA
 		return nil
 	}
 
+	// Require chain-of-trust continuity: the updated key set must share at
+	// least one key with the currently trusted one. Rotations are phased, so
+	// legitimate updates always overlap; an update made of entirely unrelated
+	// key material is rejected even from an authenticated remote proxy.
+	if err := checkCertAuthorityContinuity(existing, updated); err != nil {
+		return trace.Wrap(err)
+	}
+
 	// use compare and swap to protect from concurrent updates
 	// by trusted cluster API
 	if err := a.CompareAndSwapCertAuthority(updated, existing); err != nil {
@@ -309,6 +317,92 @@ func (a *Server) RotateExternalCertAuthority(ctx context.Context, ca types.CertA
 	return nil
 }
 
+// checkCertAuthorityContinuity verifies that the updated certificate
+// authority shares at least one public key with the currently trusted one.
+func checkCertAuthorityContinuity(existing, updated types.CertAuthority) error {
+	trusted := make(map[string]struct{})
+	for _, keySet := range []types.CAKeySet{existing.GetActiveKeys(), existing.GetAdditionalTrustedKeys()} {
+		for _, keyPair := range keySet.SSH {
+			trusted[string(keyPair.PublicKey)] = struct{}{}
+		}
+		for _, keyPair := range keySet.TLS {
+			trusted[string(keyPair.Cert)] = struct{}{}
+		}
+		for _, keyPair := range keySet.JWT {
+			trusted[string(keyPair.PublicKey)] = struct{}{}
+		}
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+	for _, keySet := range []types.CAKeySet{updated.GetActiveKeys(), updated.GetAdditionalTrustedKeys()} {
+		for _, keyPair := range keySet.SSH {
+			if _, ok := trusted[string(keyPair.PublicKey)]; ok {
+				return nil
+			}
+		}
+		for _, keyPair := range keySet.TLS {
+			if _, ok := trusted[string(keyPair.Cert)]; ok {
+				return nil
+			}
+		}
+		for _, keyPair := range keySet.JWT {
+			if _, ok := trusted[string(keyPair.PublicKey)]; ok {
+				return nil
+			}
+		}
+	}
+	return trace.AccessDenied("refusing to rotate certificate authority %v for cluster %q: updated key material shares no keys with the currently trusted authority", updated.GetType(), updated.GetClusterName())
+}
+
+// RotateExternalCertAuthorities is the batch equivalent of
+// RotateExternalCertAuthority: it validates all certificate authorities of a
+// remote cluster as a set before applying any of them, so the HostCA and
+// UserCA of a leaf cluster are not observed in a half-updated state.
+func (a *Server) RotateExternalCertAuthorities(ctx context.Context, cas []types.CertAuthority) error {
+	if len(cas) == 0 {
+		return trace.BadParameter("missing certificate authorities")
+	}
+	clusterName, err := a.GetClusterName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	remoteCluster := cas[0].GetClusterName()
+	if remoteCluster == clusterName.GetClusterName() {
+		return trace.BadParameter("can not rotate local certificate authority")
+	}
+	seen := make(map[types.CertAuthType]bool, len(cas))
+	for _, ca := range cas {
+		if ca.GetClusterName() != remoteCluster {
+			return trace.BadParameter("all certificate authorities must belong to the same cluster, got %q and %q",
+				remoteCluster, ca.GetClusterName())
+		}
+		if seen[ca.GetType()] {
+			return trace.BadParameter("duplicate certificate authority of type %q", ca.GetType())
+		}
+		seen[ca.GetType()] = true
+	}
+
+	// make sure every authority of the set exists before updating any of
+	// them, so a missing authority does not abort the batch halfway through
+	for _, ca := range cas {
+		if _, err := a.Services.GetCertAuthority(ctx, types.CertAuthID{
+			Type:       ca.GetType(),
+			DomainName: ca.GetClusterName(),
+		}, false); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	for _, ca := range cas {
+		if err := a.RotateExternalCertAuthority(ctx, ca); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // autoRotateCertAuthorities automatically rotates cert authorities,
 // does nothing if no rotation parameters were set up
 // or it is too early to rotate per schedule
@@ -436,6 +436,22 @@ func (a *Server) checkIDPInitiatedSAML(ctx context.Context, connector types.SAML
 	return trace.Wrap(err)
 }
 
+// samlMFAContexts extracts the authentication context class references the
+// IdP asserted, for evaluation against the SSO MFA delegation policy.
+func samlMFAContexts(assertionInfo saml2.AssertionInfo) []string {
+	var contexts []string
+	for _, assertion := range assertionInfo.Assertions {
+		statement := assertion.AuthnStatement
+		if statement == nil || statement.AuthnContext == nil || statement.AuthnContext.AuthnContextClassRef == nil {
+			continue
+		}
+		if value := statement.AuthnContext.AuthnContextClassRef.Value; value != "" {
+			contexts = append(contexts, value)
+		}
+	}
+	return contexts
+}
+
 func (a *Server) validateSAMLResponse(ctx context.Context, diagCtx *ssoDiagContext, samlResponse string, connectorID string) (*SAMLAuthResponse, error) {
 	idpInitiated := false
 	var connector types.SAMLConnector
@@ -541,6 +557,18 @@ func (a *Server) validateSAMLResponse(ctx context.Context, diagCtx *ssoDiagConte
 		return nil, trace.Wrap(err, "Failed to create user from provided parameters.")
 	}
 
+	// When the second factor is delegated to the IdP, an accepted
+	// authentication context class marks the issued certificates as
+	// MFA-verified so the user is not prompted a second time by Teleport.
+	mfaVerified := ""
+	verified, err := a.ssoMFAVerified(ctx, nil, samlMFAContexts(*assertionInfo))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if verified {
+		mfaVerified = SSOMFAVerifiedMarker
+	}
+
 	// Auth was successful, return session, certificate, etc. to caller.
 	auth := &SAMLAuthResponse{
 		Identity: types.ExternalIdentity{
@@ -576,13 +604,14 @@ func (a *Server) validateSAMLResponse(ctx context.Context, diagCtx *ssoDiagConte
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create web session.")
 		}
+		a.recordWebSessionMetadata(ctx, session, events.LoginMethodSAML, nil)
 
 		auth.Session = session
 	}
 
 	// If a public key was provided, sign it and return a certificate.
 	if request != nil && len(request.PublicKey) != 0 {
-		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, request.PublicKey, request.Compatibility, request.RouteToCluster, request.KubernetesCluster)
+		sshCert, tlsCert, err := a.createSessionCert(user, params.sessionTTL, request.PublicKey, request.Compatibility, request.RouteToCluster, request.KubernetesCluster, mfaVerified)
 		if err != nil {
 			return nil, trace.Wrap(err, "Failed to create session certificate.")
 		}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// accessRequestSchedulePrefix is the backend prefix the start times of
+// scheduled access requests are stored under.
+const accessRequestSchedulePrefix = "access_request_schedules"
+
+// accessRequestSchedule records the future start time of a scheduled access
+// request. The access request proto cannot carry the start time, it is kept
+// in a sidecar record that expires with the request.
+type accessRequestSchedule struct {
+	// RequestID is the ID of the scheduled access request.
+	RequestID string `json:"request_id"`
+	// User is the user the request belongs to.
+	User string `json:"user"`
+	// Start is when the request becomes assumable.
+	Start time.Time `json:"start"`
+}
+
+// CreateScheduledAccessRequest creates an access request that only becomes
+// assumable at the given future start time, supporting pre-approved
+// maintenance windows. Approval works as usual, but certificates cannot be
+// issued for the request before the window opens.
+func (a *Server) CreateScheduledAccessRequest(ctx context.Context, req types.AccessRequest, start time.Time) error {
+	if start.IsZero() {
+		return trace.BadParameter("scheduled access request requires a start time")
+	}
+	if !start.After(a.clock.Now()) {
+		return trace.BadParameter("scheduled start time %v is not in the future", start)
+	}
+	if err := a.CreateAccessRequest(ctx, req); err != nil {
+		return trace.Wrap(err)
+	}
+	// The definitive access expiry is only known after creation, a window
+	// that opens after the request expires can never be assumed.
+	if !start.Before(req.GetAccessExpiry()) {
+		if err := a.Services.DeleteAccessRequest(ctx, req.GetName()); err != nil {
+			log.WithError(err).Warnf("Failed to delete unassumable scheduled access request %q.", req.GetName())
+		}
+		return trace.BadParameter("scheduled start time %v is not before the request expiry %v", start, req.GetAccessExpiry())
+	}
+	schedule := accessRequestSchedule{
+		RequestID: req.GetName(),
+		User:      req.GetUser(),
+		Start:     start.UTC(),
+	}
+	value, err := utils.FastMarshal(schedule)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(accessRequestSchedulePrefix, schedule.RequestID),
+		Value:   value,
+		Expires: req.GetAccessExpiry(),
+	})
+	return trace.Wrap(err)
+}
+
+// getAccessRequestStart returns the scheduled start time of the access
+// request, zero when the request is not scheduled.
+func (a *Server) getAccessRequestStart(ctx context.Context, requestID string) (time.Time, error) {
+	item, err := a.bk.Get(ctx, backend.Key(accessRequestSchedulePrefix, requestID))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, trace.Wrap(err)
+	}
+	var schedule accessRequestSchedule
+	if err := utils.FastUnmarshal(item.Value, &schedule); err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return schedule.Start, nil
+}
+
+// CreateScheduledAccessRequest creates an access request that only becomes
+// assumable at the given future start time.
+func (a *ServerWithRoles) CreateScheduledAccessRequest(ctx context.Context, req types.AccessRequest, start time.Time) error {
+	if err := a.createAccessRequestAction(req); err != nil {
+		return trace.Wrap(err)
+	}
+	// Unlike immediate requests, a scheduled request may outlive the
+	// identity that creates it: the point of a maintenance window is to
+	// approve access long before it is used.
+	return a.authServer.CreateScheduledAccessRequest(ctx, req, start)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestScheduledAccessRequests(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	clock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(clock)
+
+	requestable, err := types.NewRoleV3("requestable", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requestable))
+
+	requester, err := types.NewRoleV3("requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"requestable"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	user, err := types.NewUser("window-user")
+	require.NoError(t, err)
+	user.SetRoles([]string{"requester"})
+	require.NoError(t, s.a.UpsertUser(user))
+
+	newRequest := func(name string, expiry time.Time) types.AccessRequest {
+		req, err := types.NewAccessRequest(name, "window-user", "requestable")
+		require.NoError(t, err)
+		req.SetAccessExpiry(expiry)
+		return req
+	}
+
+	// The start time must be set and in the future.
+	req := newRequest(uuid.New().String(), clock.Now().Add(2*time.Hour))
+	err = s.a.CreateScheduledAccessRequest(ctx, req, time.Time{})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.CreateScheduledAccessRequest(ctx, req, clock.Now().Add(-time.Minute))
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A window that only opens after the request expires is rejected and the
+	// request is not left behind.
+	lateID := uuid.New().String()
+	req = newRequest(lateID, clock.Now().Add(time.Hour))
+	err = s.a.CreateScheduledAccessRequest(ctx, req, clock.Now().Add(2*time.Hour))
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	reqs, err := s.a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: lateID})
+	require.NoError(t, err)
+	require.Empty(t, reqs)
+
+	// A valid window is accepted and the request can be approved as usual.
+	windowID := uuid.New().String()
+	req = newRequest(windowID, clock.Now().Add(2*time.Hour))
+	start := clock.Now().Add(time.Hour)
+	require.NoError(t, s.a.CreateScheduledAccessRequest(ctx, req, start))
+	require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: windowID,
+		State:     types.RequestState_APPROVED,
+	}))
+
+	// Approved or not, the request cannot be assumed before the window opens.
+	_, err = s.a.getValidatedAccessRequest(ctx, "window-user", windowID)
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Contains(t, err.Error(), "scheduled start")
+
+	// Once the window opens the request behaves like any approved request.
+	clock.Advance(61 * time.Minute)
+	validated, err := s.a.getValidatedAccessRequest(ctx, "window-user", windowID)
+	require.NoError(t, err)
+	require.Equal(t, windowID, validated.GetName())
+
+	// Requests without a schedule are assumable right after approval.
+	nowID := uuid.New().String()
+	req = newRequest(nowID, clock.Now().Add(2*time.Hour))
+	require.NoError(t, s.a.CreateAccessRequest(ctx, req))
+	require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: nowID,
+		State:     types.RequestState_APPROVED,
+	}))
+	_, err = s.a.getValidatedAccessRequest(ctx, "window-user", nowID)
+	require.NoError(t, err)
+}
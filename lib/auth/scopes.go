@@ -0,0 +1,172 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth/native"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/tlsca"
+)
+
+// API scopes restrict what operations a certificate minted with
+// CreateScopedAPICerts may perform. Scope checks run in front of RBAC, so a
+// scoped certificate can never exercise more of the underlying role set than
+// its scopes allow. Certificates without scopes remain unrestricted.
+const (
+	// ScopeReadNodes allows read-only access to registered nodes.
+	ScopeReadNodes = "read:nodes"
+	// ScopeCreateAccessRequests allows creating access requests.
+	ScopeCreateAccessRequests = "create:access_requests"
+)
+
+// scopeRules maps each scope to the resource kinds and verbs it permits.
+var scopeRules = map[string]map[string][]string{
+	ScopeReadNodes: {
+		types.KindNode: {types.VerbList, types.VerbRead},
+	},
+	ScopeCreateAccessRequests: {
+		types.KindAccessRequest: {types.VerbCreate},
+	},
+}
+
+// ValidateScopes checks that every scope in the list is known.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return trace.BadParameter("missing parameter Scopes")
+	}
+	for _, scope := range scopes {
+		if _, ok := scopeRules[scope]; !ok {
+			return trace.BadParameter("unknown API scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// ScopesAllow reports whether the scope set permits the verb on the resource
+// kind.
+func ScopesAllow(scopes []string, kind, verb string) bool {
+	for _, scope := range scopes {
+		for _, allowed := range scopeRules[scope][kind] {
+			if allowed == verb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAPIScopes rejects the action if the caller's identity carries a
+// restricted set of API scopes that does not cover the resource kind and
+// verbs. Identities without scopes are unrestricted. This runs before RBAC,
+// see the API scope constants above.
+func (c *Context) checkAPIScopes(resource string, verbs ...string) error {
+	if c.Identity == nil {
+		return nil
+	}
+	scopes := c.Identity.GetIdentity().Scopes
+	if len(scopes) == 0 {
+		return nil
+	}
+	for _, verb := range verbs {
+		if !ScopesAllow(scopes, resource, verb) {
+			return trace.AccessDenied("session scopes do not permit %q on %q", verb, resource)
+		}
+	}
+	return nil
+}
+
+// scopedIdentity reports whether the caller presented a scope-restricted
+// certificate.
+func (c *Context) scopedIdentity() bool {
+	return c.Identity != nil && len(c.Identity.GetIdentity().Scopes) != 0
+}
+
+// ScopedCertsRequest is a request to mint credentials derived from the
+// caller's own session, restricted to a set of API scopes.
+type ScopedCertsRequest struct {
+	// Scopes restricts which API operations the minted certificate may
+	// perform, see the API scope constants above.
+	Scopes []string `json:"scopes"`
+	// Expires is an optional expiry time, it can only shorten the lifetime
+	// inherited from the caller's session.
+	Expires time.Time `json:"expires"`
+}
+
+// ScopedAPICerts is a credentials bundle minted for a scoped API session.
+type ScopedAPICerts struct {
+	// TLSCert is the PEM-encoded TLS certificate with the scopes encoded
+	// in its subject.
+	TLSCert []byte `json:"tls_cert"`
+	// Key is the PEM-encoded private key matching TLSCert.
+	Key []byte `json:"key"`
+}
+
+// CreateScopedAPICerts mints a scope-restricted TLS certificate for the user,
+// derived from an existing web session identity. The certificate never
+// outlives the session it is derived from and cannot be used to reissue
+// further credentials.
+func (s *Server) CreateScopedAPICerts(ctx context.Context, user types.User, identity tlsca.Identity, checker services.AccessChecker, req ScopedCertsRequest) (*ScopedAPICerts, error) {
+	if err := ValidateScopes(req.Scopes); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// Don't let the scoped certificate outlive the session it is derived
+	// from; the caller may only shorten it.
+	expires := identity.Expires
+	if !req.Expires.IsZero() && req.Expires.Before(expires) {
+		expires = req.Expires
+	}
+	ttl := expires.Sub(s.clock.Now())
+	if ttl <= 0 {
+		return nil, trace.BadParameter("requested expiry time %v is in the past", req.Expires)
+	}
+
+	_, traits, err := services.ExtractFromIdentity(s, identity)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	privateKey, publicKey, err := native.GenerateKeyPair()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	certs, err := s.generateUserCert(certRequest{
+		user:           user,
+		publicKey:      publicKey,
+		checker:        checker,
+		ttl:            ttl,
+		traits:         traits,
+		activeRequests: services.RequestIDs{AccessRequests: identity.ActiveRequests},
+		scopes:         req.Scopes,
+		// scoped certificates are strictly narrower than the session they
+		// are derived from, they can never mint new credentials
+		disallowReissue: true,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &ScopedAPICerts{
+		TLSCert: certs.TLS,
+		Key:     privateKey,
+	}, nil
+}
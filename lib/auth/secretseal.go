@@ -0,0 +1,292 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// backendEncryptionPrefix is the backend prefix under which envelope
+	// encryption keys are stored.
+	backendEncryptionPrefix = "backend_encryption"
+	// sealedSecretHeader prefixes sealed values so they can be told apart
+	// from legacy plaintext secrets.
+	sealedSecretHeader = "sealed:aes256gcm:"
+)
+
+// backendEncryptionKey is an envelope key used to encrypt sensitive values
+// (web session bearer secrets, recovery codes, OTP secrets) before they are
+// written to the backend. Keys are only ever rotated, never deleted, so
+// secrets sealed under a previous key remain readable.
+type backendEncryptionKey struct {
+	// ID is a unique identifier of the key, referenced by sealed values.
+	ID string `json:"id"`
+	// Key is the raw 256-bit AES key.
+	Key []byte `json:"key"`
+	// CreatedAt is the time the key was generated.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// sealedSecret is the envelope wrapped around an encrypted value.
+type sealedSecret struct {
+	// KeyID identifies the backendEncryptionKey the value was sealed with.
+	KeyID string `json:"key_id"`
+	// Nonce is the AES-GCM nonce.
+	Nonce []byte `json:"nonce"`
+	// Ciphertext is the encrypted value.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func backendEncryptionKeyKey(id string) []byte {
+	return backend.Key(backendEncryptionPrefix, "keys", id)
+}
+
+func activeBackendEncryptionKeyKey() []byte {
+	return backend.Key(backendEncryptionPrefix, "active")
+}
+
+// getBackendEncryptionKey returns the envelope key with the given ID.
+func (a *Server) getBackendEncryptionKey(ctx context.Context, id string) (*backendEncryptionKey, error) {
+	item, err := a.bk.Get(ctx, backendEncryptionKeyKey(id))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("backend encryption key %q not found", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var key backendEncryptionKey
+	if err := utils.FastUnmarshal(item.Value, &key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
+// activeBackendEncryptionKey returns the envelope key new secrets are sealed
+// with, generating the initial key on first use.
+func (a *Server) activeBackendEncryptionKey(ctx context.Context) (*backendEncryptionKey, error) {
+	item, err := a.bk.Get(ctx, activeBackendEncryptionKeyKey())
+	if err == nil {
+		return a.getBackendEncryptionKey(ctx, string(item.Value))
+	}
+	if !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	key, err := a.newBackendEncryptionKey(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return key, nil
+}
+
+// newBackendEncryptionKey generates a fresh envelope key, stores it and marks
+// it active.
+func (a *Server) newBackendEncryptionKey(ctx context.Context) (*backendEncryptionKey, error) {
+	key := backendEncryptionKey{
+		ID:        uuid.NewString(),
+		Key:       make([]byte, 32),
+		CreatedAt: a.clock.Now().UTC(),
+	}
+	if _, err := rand.Read(key.Key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := a.bk.Create(ctx, backend.Item{
+		Key:   backendEncryptionKeyKey(key.ID),
+		Value: value,
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   activeBackendEncryptionKeyKey(),
+		Value: []byte(key.ID),
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
+// RotateBackendEncryptionKey generates a new envelope key and makes it the
+// active one. Secrets sealed under previous keys remain readable; newly
+// written secrets use the new key.
+func (a *Server) RotateBackendEncryptionKey(ctx context.Context) error {
+	if _, err := a.newBackendEncryptionKey(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	log.Info("Rotated backend secret encryption key.")
+	return nil
+}
+
+// sealSecret encrypts a sensitive value with the active envelope key. The
+// result carries the sealedSecretHeader prefix so openSecret can distinguish
+// it from legacy plaintext values.
+func (a *Server) sealSecret(ctx context.Context, plaintext string) (string, error) {
+	key, err := a.activeBackendEncryptionKey(ctx)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", trace.Wrap(err)
+	}
+	envelope, err := utils.FastMarshal(sealedSecret{
+		KeyID:      key.ID,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, []byte(plaintext), nil),
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return sealedSecretHeader + base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// openSecret decrypts a value sealed by sealSecret. Values without the sealed
+// header are returned as-is to remain compatible with secrets written before
+// envelope encryption was introduced.
+func (a *Server) openSecret(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, sealedSecretHeader) {
+		return value, nil
+	}
+	envelope, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedSecretHeader))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var sealed sealedSecret
+	if err := utils.FastUnmarshal(envelope, &sealed); err != nil {
+		return "", trace.Wrap(err)
+	}
+	key, err := a.getBackendEncryptionKey(ctx, sealed.KeyID)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return "", trace.BadParameter("failed to decrypt sealed secret: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// WebSessions returns the web session manager with bearer secrets sealed at
+// rest (see sealSecret) and idle sessions invalidated server-side (see
+// idleWebSessions).
+func (a *Server) WebSessions() types.WebSessionInterface {
+	return &idleWebSessions{auth: a, ws: &sealingWebSessions{auth: a, ws: a.Services.WebSessions()}}
+}
+
+// sealingWebSessions wraps a web session service, sealing bearer tokens
+// before they reach the backend and opening them on the way out.
+type sealingWebSessions struct {
+	auth *Server
+	ws   types.WebSessionInterface
+}
+
+// Get returns the web session described by req with its bearer token opened.
+func (s *sealingWebSessions) Get(ctx context.Context, req types.GetWebSessionRequest) (types.WebSession, error) {
+	session, err := s.ws.Get(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := s.openSessionSecrets(ctx, session); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return session, nil
+}
+
+// List returns all web sessions with their bearer tokens opened.
+func (s *sealingWebSessions) List(ctx context.Context) ([]types.WebSession, error) {
+	sessions, err := s.ws.List(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, session := range sessions {
+		if err := s.openSessionSecrets(ctx, session); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return sessions, nil
+}
+
+// Upsert stores the web session with its bearer token sealed.
+func (s *sealingWebSessions) Upsert(ctx context.Context, session types.WebSession) error {
+	sessionV2, ok := session.(*types.WebSessionV2)
+	if !ok {
+		return trace.Wrap(s.ws.Upsert(ctx, session))
+	}
+	sealed, err := s.auth.sealSecret(ctx, sessionV2.Spec.BearerToken)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// seal on a copy so the caller's session keeps the usable bearer token
+	sealedSession := *sessionV2
+	sealedSession.Spec.BearerToken = sealed
+	return trace.Wrap(s.ws.Upsert(ctx, &sealedSession))
+}
+
+// Delete deletes the web session described by req.
+func (s *sealingWebSessions) Delete(ctx context.Context, req types.DeleteWebSessionRequest) error {
+	return trace.Wrap(s.ws.Delete(ctx, req))
+}
+
+// DeleteAll removes all web sessions.
+func (s *sealingWebSessions) DeleteAll(ctx context.Context) error {
+	return trace.Wrap(s.ws.DeleteAll(ctx))
+}
+
+func (s *sealingWebSessions) openSessionSecrets(ctx context.Context, session types.WebSession) error {
+	sessionV2, ok := session.(*types.WebSessionV2)
+	if !ok {
+		return nil
+	}
+	token, err := s.auth.openSecret(ctx, sessionV2.Spec.BearerToken)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sessionV2.Spec.BearerToken = token
+	return nil
+}
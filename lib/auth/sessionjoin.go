@@ -0,0 +1,231 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// sessionJoinPrefix is the backend prefix under which session join
+	// authorizations are stored.
+	sessionJoinPrefix = "session_join_tokens"
+	// defaultSessionJoinTTL is how long a session join URL stays valid
+	// when the request does not specify a TTL.
+	defaultSessionJoinTTL = 10 * time.Minute
+	// maxSessionJoinTTL caps the validity of a session join URL; the URLs
+	// are bearer secrets and must stay short-lived.
+	maxSessionJoinTTL = time.Hour
+	// sessionJoinTokenBytes is the entropy of a join token in bytes.
+	sessionJoinTokenBytes = 16
+)
+
+// CreateSessionJoinURLRequest is a request to generate a join URL for an
+// active session.
+type CreateSessionJoinURLRequest struct {
+	// SessionID is the ID of the session to join.
+	SessionID string `json:"session_id"`
+	// Mode is the participant mode visitors attach with, defaults to
+	// observer.
+	Mode types.SessionParticipantMode `json:"mode,omitempty"`
+	// TTL is how long the URL stays valid, defaults to 10 minutes and is
+	// capped at an hour.
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// SessionJoinURL is a short-lived URL granting its visitors a validated way
+// to attach to a session.
+type SessionJoinURL struct {
+	// URL is the shareable link pointing at the web UI session page.
+	URL string `json:"url"`
+	// Token is the join token embedded in the URL.
+	Token string `json:"token"`
+	// SessionID is the session the URL joins.
+	SessionID string `json:"session_id"`
+	// Expires is when the URL stops working.
+	Expires time.Time `json:"expires"`
+}
+
+// sessionJoinAuthorization is the backend record a join URL maps to.
+type sessionJoinAuthorization struct {
+	// SessionID is the session the token grants access to.
+	SessionID string `json:"session_id"`
+	// Mode is the participant mode granted to visitors.
+	Mode types.SessionParticipantMode `json:"mode"`
+	// CreatedBy is the user that generated the URL.
+	CreatedBy string `json:"created_by"`
+	// Expires is when the authorization lapses.
+	Expires time.Time `json:"expires"`
+}
+
+// SessionJoinAuthorization describes what a redeemed join token grants. The
+// visitor's identity was validated by the redeeming call, attaching in the
+// granted mode is subject to the visitor's own RBAC as usual.
+type SessionJoinAuthorization struct {
+	// SessionID is the session the token grants access to.
+	SessionID string `json:"session_id"`
+	// Mode is the participant mode granted to the visitor.
+	Mode types.SessionParticipantMode `json:"mode"`
+	// CreatedBy is the user that generated the URL.
+	CreatedBy string `json:"created_by"`
+}
+
+func sessionJoinKey(token string) []byte {
+	return backend.Key(sessionJoinPrefix, token)
+}
+
+// CreateSessionJoinURL generates a short-lived URL that lets its visitors
+// attach to an active session, so sharing a session does not require
+// copy-pasting session IDs. The URL embeds a random token mapping to a join
+// authorization; the token says nothing about the visitor, who still has to
+// authenticate to the cluster before redeeming it.
+func (a *Server) CreateSessionJoinURL(ctx context.Context, req CreateSessionJoinURLRequest) (*SessionJoinURL, error) {
+	if req.SessionID == "" {
+		return nil, trace.BadParameter("missing session ID")
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = types.SessionObserverMode
+	case types.SessionObserverMode, types.SessionModeratorMode, types.SessionPeerMode:
+	default:
+		return nil, trace.BadParameter("unsupported session participant mode %q", req.Mode)
+	}
+	if req.TTL == 0 {
+		req.TTL = defaultSessionJoinTTL
+	}
+	if req.TTL < 0 || req.TTL > maxSessionJoinTTL {
+		return nil, trace.BadParameter("the TTL of a session join URL must be between 0 and %v", maxSessionJoinTTL)
+	}
+
+	// the URL is only useful for sessions that are still running
+	if _, err := a.GetSessionTracker(ctx, req.SessionID); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	token, err := utils.CryptoRandomHex(sessionJoinTokenBytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	expires := a.clock.Now().Add(req.TTL).UTC()
+	value, err := utils.FastMarshal(sessionJoinAuthorization{
+		SessionID: req.SessionID,
+		Mode:      req.Mode,
+		CreatedBy: ClientUsername(ctx),
+		Expires:   expires,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if _, err := a.bk.Create(ctx, backend.Item{
+		Key:     sessionJoinKey(token),
+		Value:   value,
+		Expires: expires,
+	}); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &SessionJoinURL{
+		URL:       a.formatSessionJoinURL(req.SessionID, token),
+		Token:     token,
+		SessionID: req.SessionID,
+		Expires:   expires,
+	}, nil
+}
+
+// RedeemSessionJoinURL resolves a join token into the authorization it
+// grants. It verifies the token is still valid and the session is still
+// running; tokens stay redeemable until they expire so a URL can be shared
+// with several visitors.
+func (a *Server) RedeemSessionJoinURL(ctx context.Context, token string) (*SessionJoinAuthorization, error) {
+	if token == "" {
+		return nil, trace.BadParameter("missing join token")
+	}
+	item, err := a.bk.Get(ctx, sessionJoinKey(token))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.AccessDenied("the session join URL is invalid or has expired")
+		}
+		return nil, trace.Wrap(err)
+	}
+	var authorization sessionJoinAuthorization
+	if err := utils.FastUnmarshal(item.Value, &authorization); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !a.clock.Now().Before(authorization.Expires) {
+		return nil, trace.AccessDenied("the session join URL is invalid or has expired")
+	}
+	if _, err := a.GetSessionTracker(ctx, authorization.SessionID); err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("the session has already ended")
+		}
+		return nil, trace.Wrap(err)
+	}
+	return &SessionJoinAuthorization{
+		SessionID: authorization.SessionID,
+		Mode:      authorization.Mode,
+		CreatedBy: authorization.CreatedBy,
+	}, nil
+}
+
+// formatSessionJoinURL builds the web UI link for joining the session. When
+// no proxy has advertised a public address yet, a relative link is returned.
+func (a *Server) formatSessionJoinURL(sessionID, token string) string {
+	clusterName := "localhost"
+	if cn, err := a.GetClusterName(); err == nil {
+		clusterName = cn.GetClusterName()
+	}
+	path := fmt.Sprintf("/web/cluster/%v/console/session/%v?join_token=%v",
+		url.PathEscape(clusterName), url.PathEscape(sessionID), url.QueryEscape(token))
+	proxyAddr := a.firstProxyPublicAddr()
+	if proxyAddr == "" {
+		return path
+	}
+	return fmt.Sprintf("https://%v%v", proxyAddr, path)
+}
+
+// CreateSessionJoinURL generates a short-lived session join URL. Generating
+// a URL requires the same access as joining the session's trackers.
+func (a *ServerWithRoles) CreateSessionJoinURL(ctx context.Context, req CreateSessionJoinURLRequest) (*SessionJoinURL, error) {
+	if err := a.action(apidefaults.Namespace, types.KindSessionTracker, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.CreateSessionJoinURL(ctx, req)
+}
+
+// RedeemSessionJoinURL resolves a session join token for the calling user.
+// The caller has already authenticated to the cluster; the token only
+// removes the need to know the session ID, it does not widen what the
+// caller's roles allow.
+func (a *ServerWithRoles) RedeemSessionJoinURL(ctx context.Context, token string) (*SessionJoinAuthorization, error) {
+	authorization, err := a.authServer.RedeemSessionJoinURL(ctx, token)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	log.Debugf("User %v redeemed a join URL for session %v as %v.",
+		a.context.User.GetName(), authorization.SessionID, authorization.Mode)
+	return authorization, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+)
+
+func TestSessionJoinURL(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	tracker, err := types.NewSessionTracker(types.SessionTrackerSpecV1{
+		SessionID: "shared-session",
+		Kind:      string(types.SSHSessionKind),
+		State:     types.SessionState_SessionStateRunning,
+		Expires:   s.a.clock.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = s.a.CreateSessionTracker(ctx, tracker)
+	require.NoError(t, err)
+
+	// URLs can not be generated for unknown sessions
+	_, err = s.a.CreateSessionJoinURL(ctx, CreateSessionJoinURLRequest{
+		SessionID: "no-such-session",
+	})
+	require.Error(t, err)
+
+	joinURL, err := s.a.CreateSessionJoinURL(ctx, CreateSessionJoinURLRequest{
+		SessionID: "shared-session",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, joinURL.Token)
+	require.Contains(t, joinURL.URL, "shared-session")
+	require.Contains(t, joinURL.URL, joinURL.Token)
+
+	// the token resolves to an observer authorization for the session
+	authorization, err := s.a.RedeemSessionJoinURL(ctx, joinURL.Token)
+	require.NoError(t, err)
+	require.Equal(t, "shared-session", authorization.SessionID)
+	require.Equal(t, types.SessionObserverMode, authorization.Mode)
+
+	// the URL may be redeemed by several visitors until it expires
+	_, err = s.a.RedeemSessionJoinURL(ctx, joinURL.Token)
+	require.NoError(t, err)
+
+	// made up tokens are rejected
+	_, err = s.a.RedeemSessionJoinURL(ctx, "0000deadbeef")
+	require.True(t, trace.IsAccessDenied(err))
+
+	// once the session is gone the URL stops working
+	require.NoError(t, s.a.RemoveSessionTracker(ctx, "shared-session"))
+	_, err = s.a.RedeemSessionJoinURL(ctx, joinURL.Token)
+	require.Error(t, err)
+
+	// overly long TTLs are rejected
+	_, err = s.a.CreateSessionJoinURL(ctx, CreateSessionJoinURLRequest{
+		SessionID: "shared-session",
+		TTL:       24 * time.Hour,
+	})
+	require.True(t, trace.IsBadParameter(err))
+}
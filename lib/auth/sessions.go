@@ -22,6 +22,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/types"
+	apiutils "github.com/gravitational/teleport/api/utils"
 	"github.com/gravitational/teleport/lib/auth/native"
 	"github.com/gravitational/teleport/lib/jwt"
 	"github.com/gravitational/teleport/lib/modules"
@@ -225,6 +226,15 @@ func (s *Server) generateAppToken(ctx context.Context, username string, roles []
 }
 
 func (s *Server) createWebSession(ctx context.Context, req types.NewWebSessionRequest) (types.WebSession, error) {
+	// Fresh access list memberships contribute their roles to every new
+	// login session.
+	listRoles, err := s.accessListRolesForUser(ctx, req.User)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(listRoles) != 0 {
+		req.Roles = apiutils.Deduplicate(append(req.Roles, listRoles...))
+	}
 	session, err := s.NewWebSession(ctx, req)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -238,7 +248,7 @@ func (s *Server) createWebSession(ctx context.Context, req types.NewWebSessionRe
 	return session, nil
 }
 
-func (s *Server) createSessionCert(user types.User, sessionTTL time.Duration, publicKey []byte, compatibility, routeToCluster, kubernetesCluster string) ([]byte, []byte, error) {
+func (s *Server) createSessionCert(user types.User, sessionTTL time.Duration, publicKey []byte, compatibility, routeToCluster, kubernetesCluster, mfaVerified string) ([]byte, []byte, error) {
 	// It's safe to extract the access info directly from services.User because
 	// this occurs during the initial login before the first certs have been
 	// generated, so there's no possibility of any active access requests.
@@ -261,6 +271,8 @@ func (s *Server) createSessionCert(user types.User, sessionTTL time.Duration, pu
 		traits:            user.GetTraits(),
 		routeToCluster:    routeToCluster,
 		kubernetesCluster: kubernetesCluster,
+		clientOrigin:      tlsca.ClientOriginTSH,
+		mfaVerified:       mfaVerified,
 	})
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// webSessionTTLPolicyPrefix is the backend key the web session TTL
+	// policy singleton is stored under.
+	webSessionTTLPolicyPrefix = "web_session_ttl_policy"
+
+	// WebSessionTTLLocalConnector is the reserved connector name covering
+	// local logins in the web session TTL policy.
+	WebSessionTTLLocalConnector = "local"
+)
+
+// WebSessionTTLPolicy caps web session TTLs per auth connector, since
+// identity providers carry different assurance levels. Sessions of users
+// logging in through a capped connector never outlive the configured TTL, no
+// matter what the roles allow.
+type WebSessionTTLPolicy struct {
+	// ConnectorTTLs maps an auth connector name, or a connector type like
+	// "saml" to cover all connectors of that type, to the maximum web
+	// session TTL for users created by it. The reserved name "local" covers
+	// local logins.
+	ConnectorTTLs map[string]time.Duration `json:"connector_ttls,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *WebSessionTTLPolicy) CheckAndSetDefaults() error {
+	for connector, ttl := range p.ConnectorTTLs {
+		if connector == "" {
+			return trace.BadParameter("web session TTL policy contains an empty connector name")
+		}
+		if ttl <= 0 {
+			return trace.BadParameter("web session TTL for connector %q must be positive, got %v", connector, ttl)
+		}
+	}
+	return nil
+}
+
+// ttlFor returns the maximum web session TTL for a user created by the given
+// connector, zero when the policy does not cap it. The connector name takes
+// precedence over its type.
+func (p *WebSessionTTLPolicy) ttlFor(connectorName, connectorType string) time.Duration {
+	if ttl, ok := p.ConnectorTTLs[connectorName]; ok {
+		return ttl
+	}
+	return p.ConnectorTTLs[connectorType]
+}
+
+// SetWebSessionTTLPolicy configures the per-connector web session TTLs of
+// the cluster.
+func (a *Server) SetWebSessionTTLPolicy(ctx context.Context, policy WebSessionTTLPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(webSessionTTLPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetWebSessionTTLPolicy returns the configured per-connector web session
+// TTLs, with no caps when none were set.
+func (a *Server) GetWebSessionTTLPolicy(ctx context.Context) (WebSessionTTLPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(webSessionTTLPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return WebSessionTTLPolicy{}, nil
+		}
+		return WebSessionTTLPolicy{}, trace.Wrap(err)
+	}
+	var policy WebSessionTTLPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return WebSessionTTLPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteWebSessionTTLPolicy removes the per-connector web session TTLs.
+func (a *Server) DeleteWebSessionTTLPolicy(ctx context.Context) error {
+	err := a.bk.Delete(ctx, backend.Key(webSessionTTLPolicyPrefix))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("web session TTL policy is not set")
+	}
+	return trace.Wrap(err)
+}
+
+// webSessionTTLLimit returns the maximum web session TTL for the user based
+// on the connector that created them, zero when the TTL is not capped.
+func (a *Server) webSessionTTLLimit(ctx context.Context, user types.User) (time.Duration, error) {
+	policy, err := a.GetWebSessionTTLPolicy(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	connectorName := WebSessionTTLLocalConnector
+	connectorType := WebSessionTTLLocalConnector
+	if connector := user.GetCreatedBy().Connector; connector != nil {
+		connectorName = connector.ID
+		connectorType = connector.Type
+	}
+	return policy.ttlFor(connectorName, connectorType), nil
+}
+
+// SetWebSessionTTLPolicy configures the per-connector web session TTLs of
+// the cluster.
+func (a *ServerWithRoles) SetWebSessionTTLPolicy(ctx context.Context, policy WebSessionTTLPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetWebSessionTTLPolicy(ctx, policy)
+}
+
+// GetWebSessionTTLPolicy returns the per-connector web session TTLs of the
+// cluster.
+func (a *ServerWithRoles) GetWebSessionTTLPolicy(ctx context.Context) (WebSessionTTLPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return WebSessionTTLPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetWebSessionTTLPolicy(ctx)
+}
+
+// DeleteWebSessionTTLPolicy removes the per-connector web session TTLs of
+// the cluster.
+func (a *ServerWithRoles) DeleteWebSessionTTLPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteWebSessionTTLPolicy(ctx)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestWebSessionTTLPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// The default policy does not cap any connector.
+	policy, err := s.a.GetWebSessionTTLPolicy(ctx)
+	require.NoError(t, err)
+	require.Empty(t, policy.ConnectorTTLs)
+
+	// Invalid policies are rejected.
+	err = s.a.SetWebSessionTTLPolicy(ctx, WebSessionTTLPolicy{
+		ConnectorTTLs: map[string]time.Duration{"": time.Hour},
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+	err = s.a.SetWebSessionTTLPolicy(ctx, WebSessionTTLPolicy{
+		ConnectorTTLs: map[string]time.Duration{"okta": -time.Minute},
+	})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A valid policy round-trips.
+	err = s.a.SetWebSessionTTLPolicy(ctx, WebSessionTTLPolicy{
+		ConnectorTTLs: map[string]time.Duration{
+			WebSessionTTLLocalConnector: 10 * time.Minute,
+			"saml":                      time.Hour,
+		},
+	})
+	require.NoError(t, err)
+	policy, err = s.a.GetWebSessionTTLPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, policy.ConnectorTTLs[WebSessionTTLLocalConnector])
+	require.Equal(t, time.Hour, policy.ConnectorTTLs["saml"])
+
+	// Deleting the policy reverts to no caps.
+	require.NoError(t, s.a.DeleteWebSessionTTLPolicy(ctx))
+	policy, err = s.a.GetWebSessionTTLPolicy(ctx)
+	require.NoError(t, err)
+	require.Empty(t, policy.ConnectorTTLs)
+	err = s.a.DeleteWebSessionTTLPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestWebSessionTTLPolicyEnforcement(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	localUser, role, err := CreateUserAndRole(s.a, "ttl-local-user", []string{"ttl-local-user"})
+	require.NoError(t, err)
+
+	ssoUser, err := types.NewUser("ttl-sso-user")
+	require.NoError(t, err)
+	ssoUser.SetRoles([]string{role.GetName()})
+	ssoUser.SetCreatedBy(types.CreatedBy{
+		Connector: &types.ConnectorRef{Type: "saml", ID: "okta"},
+	})
+	require.NoError(t, s.a.UpsertUser(ssoUser))
+
+	newSession := func(user types.User) types.WebSession {
+		ws, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+			User:  user.GetName(),
+			Roles: user.GetRoles(),
+		})
+		require.NoError(t, err)
+		return ws
+	}
+
+	// Without a policy the session uses the role-adjusted default.
+	ws := newSession(localUser)
+	uncapped := ws.GetExpiryTime().Sub(s.a.GetClock().Now())
+	require.Greater(t, uncapped, time.Hour)
+
+	// Local logins are capped by the reserved "local" entry.
+	err = s.a.SetWebSessionTTLPolicy(ctx, WebSessionTTLPolicy{
+		ConnectorTTLs: map[string]time.Duration{
+			WebSessionTTLLocalConnector: 10 * time.Minute,
+			"saml":                      30 * time.Minute,
+		},
+	})
+	require.NoError(t, err)
+	ws = newSession(localUser)
+	require.WithinDuration(t, s.a.GetClock().Now().Add(10*time.Minute), ws.GetExpiryTime(), time.Minute)
+
+	// SSO users fall back to their connector type when the name has no entry.
+	ws = newSession(ssoUser)
+	require.WithinDuration(t, s.a.GetClock().Now().Add(30*time.Minute), ws.GetExpiryTime(), time.Minute)
+
+	// An entry for the connector name takes precedence over its type.
+	err = s.a.SetWebSessionTTLPolicy(ctx, WebSessionTTLPolicy{
+		ConnectorTTLs: map[string]time.Duration{
+			"saml": 30 * time.Minute,
+			"okta": 5 * time.Minute,
+		},
+	})
+	require.NoError(t, err)
+	ws = newSession(ssoUser)
+	require.WithinDuration(t, s.a.GetClock().Now().Add(5*time.Minute), ws.GetExpiryTime(), time.Minute)
+
+	// Connectors without an entry keep the default TTL.
+	ws = newSession(localUser)
+	require.WithinDuration(t, s.a.GetClock().Now().Add(uncapped), ws.GetExpiryTime(), time.Minute)
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// slackNotifierConfigPrefix is the backend key the Slack notifier
+	// configuration singleton is stored under.
+	slackNotifierConfigPrefix = "slack_notifier_config"
+
+	// slackMessagePrefix is the backend prefix the Slack message handles
+	// posted for access requests are stored under, so later state changes
+	// can amend the original message instead of posting a new one.
+	slackMessagePrefix = "slack_messages"
+
+	// slackAPITimeout bounds a single Slack API call so a slow Slack outage
+	// cannot stall access request processing.
+	slackAPITimeout = 5 * time.Second
+
+	// defaultSlackAPIURL is the Slack Web API endpoint.
+	defaultSlackAPIURL = "https://slack.com/api"
+)
+
+// SlackNotifierConfig configures the built-in Slack notifier for access
+// requests. When a bot token and channel are set, the auth server posts every
+// access request to the channel and keeps the message in sync with the
+// request state, removing the need for a separately deployed plugin.
+type SlackNotifierConfig struct {
+	// BotToken is the Slack bot token used to authenticate API calls, empty
+	// disables the notifier.
+	BotToken string `json:"bot_token,omitempty"`
+	// Channel is the channel ID or name access requests are posted to.
+	Channel string `json:"channel,omitempty"`
+	// APIURL overrides the Slack API endpoint, used in tests.
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (c *SlackNotifierConfig) CheckAndSetDefaults() error {
+	if c.BotToken == "" && c.Channel == "" {
+		return nil
+	}
+	if c.BotToken == "" {
+		return trace.BadParameter("slack notifier requires a bot token")
+	}
+	if c.Channel == "" {
+		return trace.BadParameter("slack notifier requires a channel")
+	}
+	if c.APIURL != "" {
+		u, err := url.Parse(c.APIURL)
+		if err != nil {
+			return trace.BadParameter("invalid slack API URL: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return trace.BadParameter("slack API URL scheme must be http or https, got %q", u.Scheme)
+		}
+	}
+	return nil
+}
+
+// enabled reports whether the notifier is configured to post messages.
+func (c *SlackNotifierConfig) enabled() bool {
+	return c.BotToken != ""
+}
+
+// apiURL returns the Slack API endpoint to call.
+func (c *SlackNotifierConfig) apiURL() string {
+	if c.APIURL != "" {
+		return strings.TrimRight(c.APIURL, "/")
+	}
+	return defaultSlackAPIURL
+}
+
+// SetSlackNotifierConfig configures the built-in Slack notifier.
+func (a *Server) SetSlackNotifierConfig(ctx context.Context, config SlackNotifierConfig) error {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(slackNotifierConfigPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetSlackNotifierConfig returns the Slack notifier configuration, disabled
+// when none was set.
+func (a *Server) GetSlackNotifierConfig(ctx context.Context) (SlackNotifierConfig, error) {
+	item, err := a.bk.Get(ctx, backend.Key(slackNotifierConfigPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return SlackNotifierConfig{}, nil
+		}
+		return SlackNotifierConfig{}, trace.Wrap(err)
+	}
+	var config SlackNotifierConfig
+	if err := utils.FastUnmarshal(item.Value, &config); err != nil {
+		return SlackNotifierConfig{}, trace.Wrap(err)
+	}
+	return config, nil
+}
+
+// DeleteSlackNotifierConfig removes the Slack notifier configuration.
+func (a *Server) DeleteSlackNotifierConfig(ctx context.Context) error {
+	err := a.bk.Delete(ctx, backend.Key(slackNotifierConfigPrefix))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("slack notifier is not configured")
+	}
+	return trace.Wrap(err)
+}
+
+// slackMessage is the handle of the Slack message posted for an access
+// request, kept so state changes can amend it.
+type slackMessage struct {
+	// RequestID is the name of the access request.
+	RequestID string `json:"request_id"`
+	// Channel is the channel the message was posted to, as resolved by
+	// Slack.
+	Channel string `json:"channel"`
+	// Timestamp identifies the message within the channel.
+	Timestamp string `json:"ts"`
+}
+
+// slackAPIResponse is the subset of the Slack Web API response the notifier
+// cares about.
+type slackAPIResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
+
+// slackRequestText renders the message body for an access request in the
+// given state.
+func slackRequestText(req types.AccessRequest, state, reason string) string {
+	text := fmt.Sprintf("Access request %v: user %v requests roles %v",
+		req.GetName(), req.GetUser(), strings.Join(req.GetRoles(), ", "))
+	if req.GetRequestReason() != "" {
+		text += fmt.Sprintf(" (%v)", req.GetRequestReason())
+	}
+	text += fmt.Sprintf(" — %v", state)
+	if reason != "" {
+		text += fmt.Sprintf(": %v", reason)
+	}
+	return text
+}
+
+// notifySlackRequestCreated posts a newly filed access request to the
+// configured Slack channel and remembers the message handle so later state
+// changes can amend it. Delivery is best effort and never fails the request.
+func (s *Server) notifySlackRequestCreated(ctx context.Context, req types.AccessRequest) {
+	config, err := s.GetSlackNotifierConfig(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load the Slack notifier configuration.")
+		return
+	}
+	if !config.enabled() {
+		return
+	}
+	resp, err := s.slackCall(config, "chat.postMessage", map[string]interface{}{
+		"channel": config.Channel,
+		"text":    slackRequestText(req, req.GetState().String(), ""),
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to post the access request to Slack.")
+		return
+	}
+	s.storeSlackMessage(ctx, req, slackMessage{
+		RequestID: req.GetName(),
+		Channel:   resp.Channel,
+		Timestamp: resp.TS,
+	})
+}
+
+// notifySlackRequestResolved amends the Slack message of the request with its
+// new state, or posts a fresh message when none was recorded, e.g. when the
+// notifier was enabled after the request was filed.
+func (s *Server) notifySlackRequestResolved(ctx context.Context, req types.AccessRequest, state types.RequestState, reason string) {
+	config, err := s.GetSlackNotifierConfig(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load the Slack notifier configuration.")
+		return
+	}
+	if !config.enabled() {
+		return
+	}
+	text := slackRequestText(req, state.String(), reason)
+
+	msg, err := s.getSlackMessage(ctx, req.GetName())
+	if err == nil {
+		if _, err := s.slackCall(config, "chat.update", map[string]interface{}{
+			"channel": msg.Channel,
+			"ts":      msg.Timestamp,
+			"text":    text,
+		}); err != nil {
+			log.WithError(err).Warn("Failed to update the access request message in Slack.")
+		}
+		return
+	}
+	if !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to look up the Slack message of an access request.")
+		return
+	}
+	resp, err := s.slackCall(config, "chat.postMessage", map[string]interface{}{
+		"channel": config.Channel,
+		"text":    text,
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to post the access request to Slack.")
+		return
+	}
+	s.storeSlackMessage(ctx, req, slackMessage{
+		RequestID: req.GetName(),
+		Channel:   resp.Channel,
+		Timestamp: resp.TS,
+	})
+}
+
+// storeSlackMessage records the message handle, expiring with the access
+// window of the request since no state changes are expected beyond it.
+func (s *Server) storeSlackMessage(ctx context.Context, req types.AccessRequest, msg slackMessage) {
+	value, err := utils.FastMarshal(msg)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode a Slack message record.")
+		return
+	}
+	if _, err := s.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(slackMessagePrefix, msg.RequestID),
+		Value:   value,
+		Expires: req.GetAccessExpiry(),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to store a Slack message record.")
+	}
+}
+
+// getSlackMessage returns the recorded message handle of the request.
+func (s *Server) getSlackMessage(ctx context.Context, requestID string) (*slackMessage, error) {
+	item, err := s.bk.Get(ctx, backend.Key(slackMessagePrefix, requestID))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var msg slackMessage
+	if err := utils.FastUnmarshal(item.Value, &msg); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &msg, nil
+}
+
+// slackCall performs a single Slack Web API call.
+func (s *Server) slackCall(config SlackNotifierConfig, method string, payload map[string]interface{}) (*slackAPIResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, config.apiURL()+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+config.BotToken)
+	client := &http.Client{Timeout: slackAPITimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	var apiResp slackAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !apiResp.OK {
+		return nil, trace.Errorf("slack API method %v failed: %v", method, apiResp.Error)
+	}
+	return &apiResp, nil
+}
+
+// SetSlackNotifierConfig configures the built-in Slack notifier.
+func (a *ServerWithRoles) SetSlackNotifierConfig(ctx context.Context, config SlackNotifierConfig) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetSlackNotifierConfig(ctx, config)
+}
+
+// GetSlackNotifierConfig returns the Slack notifier configuration. The
+// config embeds the bot credential, and every user holds the implicit read
+// rule on cluster auth preference, so reading it back takes the same
+// permissions as setting it.
+func (a *ServerWithRoles) GetSlackNotifierConfig(ctx context.Context) (SlackNotifierConfig, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return SlackNotifierConfig{}, trace.Wrap(err)
+	}
+	return a.authServer.GetSlackNotifierConfig(ctx)
+}
+
+// DeleteSlackNotifierConfig removes the Slack notifier configuration.
+func (a *ServerWithRoles) DeleteSlackNotifierConfig(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteSlackNotifierConfig(ctx)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestSlackNotifierConfig(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// A token without a channel, and vice versa, are rejected.
+	err := s.a.SetSlackNotifierConfig(ctx, SlackNotifierConfig{BotToken: "xoxb-test"})
+	require.True(t, trace.IsBadParameter(err))
+	err = s.a.SetSlackNotifierConfig(ctx, SlackNotifierConfig{Channel: "#requests"})
+	require.True(t, trace.IsBadParameter(err))
+	err = s.a.SetSlackNotifierConfig(ctx, SlackNotifierConfig{
+		BotToken: "xoxb-test",
+		Channel:  "#requests",
+		APIURL:   "ftp://slack.example.com",
+	})
+	require.True(t, trace.IsBadParameter(err))
+
+	// The notifier is disabled until configured.
+	config, err := s.a.GetSlackNotifierConfig(ctx)
+	require.NoError(t, err)
+	require.False(t, config.enabled())
+
+	err = s.a.SetSlackNotifierConfig(ctx, SlackNotifierConfig{
+		BotToken: "xoxb-test",
+		Channel:  "#requests",
+	})
+	require.NoError(t, err)
+	config, err = s.a.GetSlackNotifierConfig(ctx)
+	require.NoError(t, err)
+	require.True(t, config.enabled())
+	require.Equal(t, defaultSlackAPIURL, config.apiURL())
+
+	require.NoError(t, s.a.DeleteSlackNotifierConfig(ctx))
+	err = s.a.DeleteSlackNotifierConfig(ctx)
+	require.True(t, trace.IsNotFound(err))
+}
+
+// slackRecorder is a fake Slack Web API that records the calls it receives.
+type slackRecorder struct {
+	mu    sync.Mutex
+	calls []slackRecordedCall
+}
+
+type slackRecordedCall struct {
+	Method  string
+	Auth    string
+	Payload map[string]interface{}
+}
+
+func (r *slackRecorder) handler(w http.ResponseWriter, req *http.Request) {
+	var payload map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, slackRecordedCall{
+		Method:  req.URL.Path,
+		Auth:    req.Header.Get("Authorization"),
+		Payload: payload,
+	})
+	n := len(r.calls)
+	r.mu.Unlock()
+	fmt.Fprintf(w, `{"ok": true, "channel": "C123", "ts": "%v.000"}`, n)
+}
+
+func (r *slackRecorder) recorded() []slackRecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]slackRecordedCall(nil), r.calls...)
+}
+
+func TestSlackNotifierAccessRequests(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	recorder := &slackRecorder{}
+	fakeSlack := httptest.NewServer(http.HandlerFunc(recorder.handler))
+	t.Cleanup(fakeSlack.Close)
+
+	require.NoError(t, s.a.SetSlackNotifierConfig(ctx, SlackNotifierConfig{
+		BotToken: "xoxb-test",
+		Channel:  "#requests",
+		APIURL:   fakeSlack.URL,
+	}))
+
+	requestable, err := types.NewRoleV3("requestable", types.RoleSpecV5{})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requestable))
+
+	requester, err := types.NewRoleV3("requester", types.RoleSpecV5{
+		Allow: types.RoleConditions{
+			Request: &types.AccessRequestConditions{
+				Roles: []string{"requestable"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertRole(ctx, requester))
+
+	user, err := types.NewUser("slack-user")
+	require.NoError(t, err)
+	user.SetRoles([]string{"requester"})
+	require.NoError(t, s.a.UpsertUser(user))
+
+	// Filing a request posts it to the configured channel.
+	requestID := uuid.New().String()
+	req, err := types.NewAccessRequest(requestID, "slack-user", "requestable")
+	require.NoError(t, err)
+	req.SetAccessExpiry(s.a.clock.Now().Add(time.Hour))
+	require.NoError(t, s.a.CreateAccessRequest(ctx, req))
+
+	calls := recorder.recorded()
+	require.Len(t, calls, 1)
+	require.Equal(t, "/chat.postMessage", calls[0].Method)
+	require.Equal(t, "Bearer xoxb-test", calls[0].Auth)
+	require.Equal(t, "#requests", calls[0].Payload["channel"])
+	require.Contains(t, calls[0].Payload["text"], requestID)
+	require.Contains(t, calls[0].Payload["text"], "slack-user")
+	require.Contains(t, calls[0].Payload["text"], "PENDING")
+
+	// Approving the request amends the original message instead of posting a
+	// new one.
+	require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: requestID,
+		State:     types.RequestState_APPROVED,
+		Reason:    "looks good",
+	}))
+
+	calls = recorder.recorded()
+	require.Len(t, calls, 2)
+	require.Equal(t, "/chat.update", calls[1].Method)
+	require.Equal(t, "C123", calls[1].Payload["channel"])
+	require.Equal(t, "1.000", calls[1].Payload["ts"])
+	require.Contains(t, calls[1].Payload["text"], "APPROVED")
+	require.Contains(t, calls[1].Payload["text"], "looks good")
+
+	// A request filed before the notifier knew about it gets a fresh message
+	// on resolution.
+	unknownID := uuid.New().String()
+	unknown, err := types.NewAccessRequest(unknownID, "slack-user", "requestable")
+	require.NoError(t, err)
+	unknown.SetAccessExpiry(s.a.clock.Now().Add(time.Hour))
+	require.NoError(t, s.a.Services.CreateAccessRequest(ctx, unknown))
+	require.NoError(t, s.a.SetAccessRequestState(ctx, types.AccessRequestUpdate{
+		RequestID: unknownID,
+		State:     types.RequestState_DENIED,
+	}))
+
+	calls = recorder.recorded()
+	require.Len(t, calls, 3)
+	require.Equal(t, "/chat.postMessage", calls[2].Method)
+	require.Contains(t, calls[2].Payload["text"], "DENIED")
+}
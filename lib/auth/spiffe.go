@@ -0,0 +1,122 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// SPIFFEFederation describes an external SPIFFE trust domain whose bundle has
+// been imported into this cluster. Workloads presenting SVIDs issued by the
+// external trust domain can be validated against the imported bundle,
+// enabling mTLS federation with existing SPIRE deployments.
+type SPIFFEFederation struct {
+	// TrustDomain is the name of the external SPIFFE trust domain,
+	// e.g. "example.org" (without the spiffe:// scheme).
+	TrustDomain string `json:"trust_domain"`
+	// Bundle is the SPIFFE bundle document (a JWK set with x509-svid
+	// entries) of the external trust domain.
+	Bundle json.RawMessage `json:"bundle"`
+	// UpdatedAt records when the bundle was last imported.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckAndSetDefaults validates the federation entry.
+func (f *SPIFFEFederation) CheckAndSetDefaults() error {
+	if f.TrustDomain == "" {
+		return trace.BadParameter("missing parameter TrustDomain")
+	}
+	if strings.Contains(f.TrustDomain, "://") {
+		return trace.BadParameter("trust domain %q must not include a scheme", f.TrustDomain)
+	}
+	if len(f.Bundle) == 0 {
+		return trace.BadParameter("missing parameter Bundle")
+	}
+	var bundle struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(f.Bundle, &bundle); err != nil {
+		return trace.BadParameter("bundle of trust domain %q is not a valid JWK set: %v", f.TrustDomain, err)
+	}
+	if len(bundle.Keys) == 0 {
+		return trace.BadParameter("bundle of trust domain %q contains no keys", f.TrustDomain)
+	}
+	return nil
+}
+
+// UpsertSPIFFEFederation imports or updates the bundle of an external SPIFFE
+// trust domain.
+func (a *Server) UpsertSPIFFEFederation(ctx context.Context, federation SPIFFEFederation) error {
+	if err := federation.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	federation.UpdatedAt = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(&federation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   spiffeFederationKey(federation.TrustDomain),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetSPIFFEFederations returns all imported external SPIFFE trust domains.
+func (a *Server) GetSPIFFEFederations(ctx context.Context) ([]SPIFFEFederation, error) {
+	startKey := backend.Key(spiffeFederationPrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]SPIFFEFederation, 0, len(result.Items))
+	for _, item := range result.Items {
+		var federation SPIFFEFederation
+		if err := utils.FastUnmarshal(item.Value, &federation); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, federation)
+	}
+	return out, nil
+}
+
+// DeleteSPIFFEFederation removes the bundle of an external SPIFFE trust
+// domain.
+func (a *Server) DeleteSPIFFEFederation(ctx context.Context, trustDomain string) error {
+	if trustDomain == "" {
+		return trace.BadParameter("missing parameter trustDomain")
+	}
+	err := a.bk.Delete(ctx, spiffeFederationKey(trustDomain))
+	if trace.IsNotFound(err) {
+		return trace.NotFound("SPIFFE federation with trust domain %q is not found", trustDomain)
+	}
+	return trace.Wrap(err)
+}
+
+func spiffeFederationKey(trustDomain string) []byte {
+	return backend.Key(spiffeFederationPrefix, trustDomain)
+}
+
+const spiffeFederationPrefix = "spiffe_federations"
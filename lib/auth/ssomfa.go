@@ -0,0 +1,153 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apiutils "github.com/gravitational/teleport/api/utils"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// ssoMFAPrefix is the backend key the SSO MFA policy singleton is stored
+// under.
+const ssoMFAPrefix = "sso_mfa_policy"
+
+// SSOMFAVerifiedMarker is the value recorded in the MFAVerified field of
+// certificates whose MFA check was delegated to the SSO identity provider.
+// It takes the place of an MFA device UUID, which delegated logins do not
+// have.
+const SSOMFAVerifiedMarker = "sso-delegated"
+
+// SSOMFAPolicy delegates the second factor to the SSO identity provider:
+// users whose IdP assertion carries an accepted authentication method (amr)
+// or context (acr) claim are considered MFA-verified, including for
+// per-session MFA, and are not prompted a second time by Teleport.
+type SSOMFAPolicy struct {
+	// Enabled turns the delegation on.
+	Enabled bool `json:"enabled"`
+	// AcceptedMethods are amr claim values accepted as proof of MFA at the
+	// IdP, e.g. "mfa", "otp" or "hwk".
+	AcceptedMethods []string `json:"accepted_methods,omitempty"`
+	// AcceptedContexts are acr claim values accepted as proof of MFA at the
+	// IdP.
+	AcceptedContexts []string `json:"accepted_contexts,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *SSOMFAPolicy) CheckAndSetDefaults() error {
+	if p.Enabled && len(p.AcceptedMethods) == 0 && len(p.AcceptedContexts) == 0 {
+		return trace.BadParameter("SSO MFA delegation requires at least one accepted amr or acr claim value")
+	}
+	return nil
+}
+
+// SetSSOMFAPolicy configures second factor delegation to the SSO provider.
+func (a *Server) SetSSOMFAPolicy(ctx context.Context, policy SSOMFAPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(ssoMFAPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetSSOMFAPolicy returns the configured SSO MFA delegation policy, with
+// delegation disabled when none was set.
+func (a *Server) GetSSOMFAPolicy(ctx context.Context) (SSOMFAPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(ssoMFAPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return SSOMFAPolicy{}, nil
+		}
+		return SSOMFAPolicy{}, trace.Wrap(err)
+	}
+	var policy SSOMFAPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return SSOMFAPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteSSOMFAPolicy disables second factor delegation to the SSO provider.
+func (a *Server) DeleteSSOMFAPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(ssoMFAPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("SSO MFA policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ssoMFAVerified reports whether the amr/acr claims asserted by the IdP
+// satisfy the SSO MFA delegation policy.
+func (a *Server) ssoMFAVerified(ctx context.Context, methods, contexts []string) (bool, error) {
+	policy, err := a.GetSSOMFAPolicy(ctx)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if !policy.Enabled {
+		return false, nil
+	}
+	for _, method := range methods {
+		if apiutils.SliceContainsStr(policy.AcceptedMethods, method) {
+			return true, nil
+		}
+	}
+	for _, context := range contexts {
+		if apiutils.SliceContainsStr(policy.AcceptedContexts, context) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetSSOMFAPolicy configures second factor delegation to the SSO provider.
+func (a *ServerWithRoles) SetSSOMFAPolicy(ctx context.Context, policy SSOMFAPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetSSOMFAPolicy(ctx, policy)
+}
+
+// GetSSOMFAPolicy returns the configured SSO MFA delegation policy.
+func (a *ServerWithRoles) GetSSOMFAPolicy(ctx context.Context) (SSOMFAPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return SSOMFAPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetSSOMFAPolicy(ctx)
+}
+
+// DeleteSSOMFAPolicy disables second factor delegation to the SSO provider.
+func (a *ServerWithRoles) DeleteSSOMFAPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteSSOMFAPolicy(ctx)
+}
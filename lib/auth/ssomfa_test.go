@@ -0,0 +1,105 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSOMFAPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy delegation is disabled.
+	policy, err := s.a.GetSSOMFAPolicy(ctx)
+	require.NoError(t, err)
+	require.False(t, policy.Enabled)
+
+	// An enabled policy must accept at least one claim value.
+	err = s.a.SetSSOMFAPolicy(ctx, SSOMFAPolicy{Enabled: true})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	require.NoError(t, s.a.SetSSOMFAPolicy(ctx, SSOMFAPolicy{
+		Enabled:         true,
+		AcceptedMethods: []string{"mfa", "hwk"},
+	}))
+	policy, err = s.a.GetSSOMFAPolicy(ctx)
+	require.NoError(t, err)
+	require.True(t, policy.Enabled)
+	require.Equal(t, []string{"mfa", "hwk"}, policy.AcceptedMethods)
+
+	require.NoError(t, s.a.DeleteSSOMFAPolicy(ctx))
+	err = s.a.DeleteSSOMFAPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestSSOMFAVerified(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a policy no claims prove MFA.
+	verified, err := s.a.ssoMFAVerified(ctx, []string{"mfa"}, []string{"urn:mace:incommon:iap:silver"})
+	require.NoError(t, err)
+	require.False(t, verified)
+
+	require.NoError(t, s.a.SetSSOMFAPolicy(ctx, SSOMFAPolicy{
+		Enabled:          true,
+		AcceptedMethods:  []string{"mfa", "hwk"},
+		AcceptedContexts: []string{"urn:mace:incommon:iap:silver"},
+	}))
+
+	tests := []struct {
+		desc     string
+		methods  []string
+		contexts []string
+		verified bool
+	}{
+		{desc: "no claims", verified: false},
+		{desc: "accepted method", methods: []string{"pwd", "mfa"}, verified: true},
+		{desc: "accepted context", contexts: []string{"urn:mace:incommon:iap:silver"}, verified: true},
+		{desc: "unaccepted claims", methods: []string{"pwd"}, contexts: []string{"other"}, verified: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			verified, err := s.a.ssoMFAVerified(ctx, tt.methods, tt.contexts)
+			require.NoError(t, err)
+			require.Equal(t, tt.verified, verified)
+		})
+	}
+}
+
+func TestOIDCMFAClaims(t *testing.T) {
+	t.Parallel()
+	methods, contexts := oidcMFAClaims(jose.Claims{
+		"amr": []interface{}{"pwd", "mfa"},
+		"acr": "urn:mace:incommon:iap:silver",
+	})
+	require.Equal(t, []string{"pwd", "mfa"}, methods)
+	require.Equal(t, []string{"urn:mace:incommon:iap:silver"}, contexts)
+
+	// Absent or malformed claims yield empty results.
+	methods, contexts = oidcMFAClaims(jose.Claims{"acr": 42})
+	require.Empty(t, methods)
+	require.Empty(t, contexts)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+)
+
+// staticTokensLockName is the backend lock serializing concurrent edits of
+// the static tokens resource.
+const staticTokensLockName = "static_tokens_update"
+
+// staticTokensLockTTL bounds how long an edit may hold the lock before it is
+// considered stale.
+const staticTokensLockTTL = 10 * time.Second
+
+// AddStaticToken appends a token to the static tokens resource. The change
+// takes effect on the next join attempt: token validation reads the resource
+// from the backend, so no service restart is needed. Appending a token whose
+// value is already present fails with an already exists error.
+func (a *Server) AddStaticToken(ctx context.Context, token types.ProvisionTokenV1) error {
+	if token.Token == "" {
+		return trace.BadParameter("missing token value")
+	}
+	if err := types.SystemRoles(token.Roles).Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	err := backend.RunWhileLocked(ctx, a.bk, staticTokensLockName, staticTokensLockTTL, func(ctx context.Context) error {
+		spec := types.StaticTokensSpecV2{}
+		current, err := a.GetStaticTokens()
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if err == nil {
+			spec.StaticTokens = types.ProvisionTokensToV1(current.GetStaticTokens())
+		}
+		for _, existing := range spec.StaticTokens {
+			if existing.Token == token.Token {
+				return trace.AlreadyExists("static token already exists")
+			}
+		}
+		spec.StaticTokens = append(spec.StaticTokens, token)
+		staticTokens, err := types.NewStaticTokens(spec)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(a.SetStaticTokens(staticTokens))
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	a.emitStaticTokenEvent(ctx, events.StaticTokenAddEvent, events.StaticTokenAddCode, token.Roles)
+	return nil
+}
+
+// RemoveStaticToken removes the token with the given value from the static
+// tokens resource. Like AddStaticToken the change is picked up live by token
+// validation. Removing a token that is not present fails with a not found
+// error.
+func (a *Server) RemoveStaticToken(ctx context.Context, token string) error {
+	if token == "" {
+		return trace.BadParameter("missing token value")
+	}
+	var removedRoles types.SystemRoles
+	err := backend.RunWhileLocked(ctx, a.bk, staticTokensLockName, staticTokensLockTTL, func(ctx context.Context) error {
+		current, err := a.GetStaticTokens()
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return trace.NotFound("static token is not found")
+			}
+			return trace.Wrap(err)
+		}
+		var remaining []types.ProvisionTokenV1
+		found := false
+		for _, existing := range types.ProvisionTokensToV1(current.GetStaticTokens()) {
+			if existing.Token == token {
+				found = true
+				removedRoles = existing.Roles
+				continue
+			}
+			remaining = append(remaining, existing)
+		}
+		if !found {
+			return trace.NotFound("static token is not found")
+		}
+		staticTokens, err := types.NewStaticTokens(types.StaticTokensSpecV2{
+			StaticTokens: remaining,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(a.SetStaticTokens(staticTokens))
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	a.emitStaticTokenEvent(ctx, events.StaticTokenRemoveEvent, events.StaticTokenRemoveCode, removedRoles)
+	return nil
+}
+
+// emitStaticTokenEvent emits an audit event describing a static token being
+// added or removed. Token values are secrets and are never recorded; the
+// event carries the token roles instead. The trusted cluster token message is
+// reused as it carries exactly the resource and user metadata needed here.
+func (a *Server) emitStaticTokenEvent(ctx context.Context, eventType, code string, roles types.SystemRoles) {
+	if err := a.emitter.EmitAuditEvent(ctx, &apievents.TrustedClusterTokenCreate{
+		Metadata: apievents.Metadata{
+			Type: eventType,
+			Code: code,
+		},
+		ResourceMetadata: apievents.ResourceMetadata{
+			Name: roles.String(),
+		},
+		UserMetadata: ClientUserMetadata(ctx),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit static token event.")
+	}
+}
+
+// AddStaticToken appends a token to the static tokens resource.
+func (a *ServerWithRoles) AddStaticToken(ctx context.Context, token types.ProvisionTokenV1) error {
+	if err := a.action(apidefaults.Namespace, types.KindStaticTokens, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.AddStaticToken(ctx, token)
+}
+
+// RemoveStaticToken removes a token from the static tokens resource.
+func (a *ServerWithRoles) RemoveStaticToken(ctx context.Context, token string) error {
+	if err := a.action(apidefaults.Namespace, types.KindStaticTokens, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.RemoveStaticToken(ctx, token)
+}
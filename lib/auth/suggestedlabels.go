@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// TokenLabelPrefix marks token metadata labels that define resource labels
+// for hosts joining with the token. The part of the key after the prefix is
+// the label name, e.g. a token label "teleport.dev/label/env: staging" stamps
+// "env: staging" onto every node that joins with it. Stamped labels override
+// the host's own static labels, so fleet grouping does not depend on each
+// host's local configuration being correct.
+const TokenLabelPrefix = "teleport.dev/label/"
+
+// suggestedLabelsPrefix is the backend prefix under which labels stamped at
+// join time are stored per host.
+const suggestedLabelsPrefix = "suggested_labels"
+
+// tokenSuggestedLabels extracts the resource labels the token suggests for
+// joining hosts from its metadata labels.
+func tokenSuggestedLabels(provisionToken types.ProvisionToken) map[string]string {
+	var labels map[string]string
+	for key, value := range provisionToken.GetMetadata().Labels {
+		name := strings.TrimPrefix(key, TokenLabelPrefix)
+		if name == key || name == "" {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[name] = value
+	}
+	return labels
+}
+
+func suggestedLabelsKey(hostID string) []byte {
+	return backend.Key(suggestedLabelsPrefix, hostID)
+}
+
+// stampSuggestedLabels records the token's suggested labels for a host that
+// just joined and announces an initial heartbeat carrying them, so the node
+// shows up in its fleet groups before its first own heartbeat arrives.
+func (a *Server) stampSuggestedLabels(ctx context.Context, provisionToken types.ProvisionToken, req *types.RegisterUsingTokenRequest) error {
+	labels := tokenSuggestedLabels(provisionToken)
+	if len(labels) == 0 {
+		return nil
+	}
+	value, err := utils.FastMarshal(labels)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:   suggestedLabelsKey(req.HostID),
+		Value: value,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	if req.Role != types.RoleNode {
+		return nil
+	}
+	server := &types.ServerV2{
+		Kind:    types.KindNode,
+		Version: types.V2,
+		Metadata: types.Metadata{
+			Name:      req.HostID,
+			Namespace: apidefaults.Namespace,
+			Labels:    labels,
+		},
+		Spec: types.ServerSpecV2{
+			Hostname: req.NodeName,
+		},
+	}
+	server.SetExpiry(a.clock.Now().Add(apidefaults.ServerAnnounceTTL))
+	if _, err := a.UpsertNode(ctx, server); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// applySuggestedLabels overrides the static labels of a heartbeating node
+// with the labels stamped when it joined, if any.
+func (a *Server) applySuggestedLabels(ctx context.Context, server types.Server) {
+	item, err := a.bk.Get(ctx, suggestedLabelsKey(server.GetName()))
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.WithError(err).Debugf("Failed to read suggested labels of node %v.", server.GetName())
+		}
+		return
+	}
+	suggested := make(map[string]string)
+	if err := utils.FastUnmarshal(item.Value, &suggested); err != nil {
+		log.WithError(err).Debugf("Failed to unmarshal suggested labels of node %v.", server.GetName())
+		return
+	}
+	labels := server.GetStaticLabels()
+	if labels == nil {
+		labels = make(map[string]string, len(suggested))
+	}
+	for key, value := range suggested {
+		labels[key] = value
+	}
+	server.SetStaticLabels(labels)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/defaults"
+)
+
+// proxyAddrPlaceholder is rendered into join commands when no proxy has
+// advertised a public address yet.
+const proxyAddrPlaceholder = "<proxy-address>"
+
+// TokenBundle is a self-contained joining bundle: the token together with
+// the cluster coordinates and rendered commands a node needs to join, so
+// callers do not have to assemble them from several APIs.
+type TokenBundle struct {
+	// Token is the generated join token.
+	Token string `json:"token"`
+	// CompoundToken is the same token in the compound format, with the
+	// cluster address and CA pins embedded alongside the secret.
+	CompoundToken string `json:"compound_token"`
+	// Expires is when the token stops being usable.
+	Expires time.Time `json:"expires"`
+	// ProxyAddr is the public address of the cluster proxy, empty when no
+	// proxy has advertised one yet.
+	ProxyAddr string `json:"proxy_addr"`
+	// CAPins are the active SPKI pins of the cluster host CA.
+	CAPins []string `json:"ca_pins"`
+	// JoinCommand is a rendered teleport start command joining with the
+	// token.
+	JoinCommand string `json:"join_command"`
+	// InstallSnippet is a rendered one-liner installing teleport on the
+	// new node and joining it with the token.
+	InstallSnippet string `json:"install_snippet"`
+}
+
+// GenerateTokenBundle generates a provision token and returns it together
+// with the proxy address, CA pins and rendered join commands.
+func (a *Server) GenerateTokenBundle(ctx context.Context, req *proto.GenerateTokenRequest) (*TokenBundle, error) {
+	token, err := a.GenerateToken(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pins, err := a.GetClusterCACertPins(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var pinValues []string
+	for _, pin := range pins {
+		if !pin.Pending {
+			pinValues = append(pinValues, pin.Pin)
+		}
+	}
+
+	ttl := req.TTL.Get()
+	if ttl == 0 {
+		ttl = defaults.ProvisioningTokenTTL
+	}
+	proxyAddr := a.firstProxyPublicAddr()
+	addr := proxyAddr
+	if addr == "" {
+		addr = proxyAddrPlaceholder
+	}
+
+	compound, err := EncodeCompoundToken(token, proxyAddr, pinValues)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	roles := strings.ToLower(types.SystemRoles(req.Roles).String())
+	joinCommand := fmt.Sprintf("teleport start --roles=%v --token=%v", roles, token)
+	for _, pin := range pinValues {
+		joinCommand += fmt.Sprintf(" --ca-pin=%v", pin)
+	}
+	joinCommand += fmt.Sprintf(" --auth-server=%v", addr)
+
+	return &TokenBundle{
+		Token:         token,
+		CompoundToken: compound,
+		Expires:       a.clock.Now().Add(ttl).UTC(),
+		ProxyAddr:     proxyAddr,
+		CAPins:        pinValues,
+		JoinCommand:   joinCommand,
+		InstallSnippet: fmt.Sprintf(`sudo bash -c "$(curl -fsSL https://%v/scripts/%v/install-node.sh)"`,
+			addr, token),
+	}, nil
+}
+
+// firstProxyPublicAddr returns the public address of the first proxy that
+// advertises one, or an empty string when there is none.
+func (a *Server) firstProxyPublicAddr() string {
+	proxies, err := a.GetProxies()
+	if err != nil {
+		return ""
+	}
+	for _, p := range proxies {
+		if addr := p.GetPublicAddr(); addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
+
+// GenerateTokenBundle generates a provision token together with the cluster
+// coordinates and rendered commands a node needs to join.
+func (a *ServerWithRoles) GenerateTokenBundle(ctx context.Context, req *proto.GenerateTokenRequest) (*TokenBundle, error) {
+	if err := a.action(apidefaults.Namespace, types.KindToken, types.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GenerateTokenBundle(ctx, req)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// TokenMaxUsesLabel is a token metadata label limiting how many successful
+// registrations the token may be used for. Set it to "1" for a one-time
+// token. Tokens without the label can be used any number of times until
+// they expire.
+const TokenMaxUsesLabel = "teleport.dev/max-uses"
+
+// tokenUsagePrefix is the backend prefix under which per-token usage
+// counters are stored.
+const tokenUsagePrefix = "token_usage"
+
+// tokenUsageRetries bounds how many times an increment is retried when
+// concurrent registrations race on the same counter.
+const tokenUsageRetries = 5
+
+// tokenUsage is the backend representation of a token usage counter.
+type tokenUsage struct {
+	// Count is the number of successful registrations performed with the
+	// token.
+	Count int `json:"count"`
+}
+
+// tokenMaxUses parses the usage limit label of the token. It returns zero
+// when the token is not usage-limited.
+func tokenMaxUses(provisionToken types.ProvisionToken) (int, error) {
+	limit := provisionToken.GetMetadata().Labels[TokenMaxUsesLabel]
+	if limit == "" {
+		return 0, nil
+	}
+	maxUses, err := strconv.Atoi(limit)
+	if err != nil || maxUses <= 0 {
+		return 0, trace.BadParameter("the token %q label must be a positive integer, got %q", TokenMaxUsesLabel, limit)
+	}
+	return maxUses, nil
+}
+
+// getTokenUsage returns the number of successful registrations recorded for
+// the token so far.
+func (a *Server) getTokenUsage(ctx context.Context, tokenName string) (int, error) {
+	item, err := a.bk.Get(ctx, backend.Key(tokenUsagePrefix, tokenName))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, trace.Wrap(err)
+	}
+	var usage tokenUsage
+	if err := utils.FastUnmarshal(item.Value, &usage); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return usage.Count, nil
+}
+
+// checkTokenUsageLimit rejects the join request when the token's usage
+// limit has already been reached. An exhausted dynamic token is normally
+// deleted once the limit is hit, so this check mainly covers static tokens
+// and registrations racing with the final use.
+func (a *Server) checkTokenUsageLimit(ctx context.Context, provisionToken types.ProvisionToken, req *types.RegisterUsingTokenRequest) error {
+	maxUses, err := tokenMaxUses(provisionToken)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if maxUses == 0 {
+		return nil
+	}
+	count, err := a.getTokenUsage(ctx, provisionToken.GetName())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if count >= maxUses {
+		a.emitTokenUsageEvent(ctx, req, events.ProvisionTokenReuseCode, false,
+			fmt.Sprintf("node %q attempted to register with an exhausted join token", req.NodeName))
+		return trace.AccessDenied("the token has reached its limit of %v registrations", maxUses)
+	}
+	return nil
+}
+
+// recordTokenUse atomically increments the token's usage counter after a
+// successful registration. When the limit is reached, the dynamic token and
+// its counter are deleted so the token can not be reused, and an audit
+// event records the exhaustion.
+func (a *Server) recordTokenUse(ctx context.Context, provisionToken types.ProvisionToken, req *types.RegisterUsingTokenRequest) error {
+	maxUses, err := tokenMaxUses(provisionToken)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if maxUses == 0 {
+		return nil
+	}
+	key := backend.Key(tokenUsagePrefix, provisionToken.GetName())
+	var count int
+	for i := 0; ; i++ {
+		item, err := a.bk.Get(ctx, key)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if err != nil {
+			// no counter yet, record the first use
+			value, err := utils.FastMarshal(tokenUsage{Count: 1})
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if _, err := a.bk.Create(ctx, backend.Item{Key: key, Value: value, Expires: provisionToken.Expiry()}); err != nil {
+				if trace.IsAlreadyExists(err) && i < tokenUsageRetries {
+					continue
+				}
+				return trace.Wrap(err)
+			}
+			count = 1
+			break
+		}
+		var usage tokenUsage
+		if err := utils.FastUnmarshal(item.Value, &usage); err != nil {
+			return trace.Wrap(err)
+		}
+		usage.Count++
+		value, err := utils.FastMarshal(usage)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := a.bk.CompareAndSwap(ctx, *item, backend.Item{Key: key, Value: value, Expires: item.Expires}); err != nil {
+			if trace.IsCompareFailed(err) && i < tokenUsageRetries {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		count = usage.Count
+		break
+	}
+	if count < maxUses {
+		return nil
+	}
+	// the limit was reached, retire the token so it can not be reused
+	if err := a.DeleteToken(ctx, provisionToken.GetName()); err != nil && !trace.IsNotFound(err) {
+		log.WithError(err).Warnf("Could not delete exhausted provision token %q.",
+			string(backend.MaskKeyName(provisionToken.GetName())))
+	}
+	if err := a.bk.Delete(ctx, key); err != nil && !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Could not delete the usage counter of an exhausted provision token.")
+	}
+	a.emitTokenUsageEvent(ctx, req, events.ProvisionTokenExhaustedCode, true,
+		fmt.Sprintf("node %q performed the last registration allowed by the join token", req.NodeName))
+	return nil
+}
+
+// emitTokenUsageEvent emits an auth attempt event describing a usage-limited
+// token being exhausted or reused past its limit.
+func (a *Server) emitTokenUsageEvent(ctx context.Context, req *types.RegisterUsingTokenRequest, code string, success bool, message string) {
+	event := &apievents.AuthAttempt{
+		Metadata: apievents.Metadata{
+			Type: events.AuthAttemptEvent,
+			Code: code,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: req.NodeName,
+		},
+		ConnectionMetadata: apievents.ConnectionMetadata{
+			RemoteAddr: req.RemoteAddr,
+		},
+		Status: apievents.Status{
+			Success: success,
+		},
+	}
+	if success {
+		event.Status.UserMessage = message
+	} else {
+		event.Status.Error = message
+	}
+	if err := a.emitter.EmitAuditEvent(ctx, event); err != nil {
+		log.WithError(err).Warn("Failed to emit token usage event.")
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// nodeTombstone retains the metadata of a node that has expired or been
+// deleted, so a re-registration within the grace period can restore labels
+// and metadata, and so resurrections of decommissioned hosts can be flagged.
+type nodeTombstone struct {
+	// Server is the marshaled node resource as it was last seen.
+	Server json.RawMessage `json:"server"`
+	// Decommissioned is true when the node was explicitly deleted rather
+	// than silently expired.
+	Decommissioned bool `json:"decommissioned"`
+	// DeletedAt is when the tombstone was last written.
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UpsertNode registers a node, restoring metadata from a tombstone when the
+// node re-registers within the grace period and flagging resurrections of
+// decommissioned hosts.
+func (a *Server) UpsertNode(ctx context.Context, server types.Server) (*types.KeepAlive, error) {
+	a.checkNodeTombstone(ctx, server)
+	// labels stamped by the join token override whatever the host reports
+	a.applySuggestedLabels(ctx, server)
+	keepAlive, err := a.Services.UpsertNode(ctx, server)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// refresh the tombstone on every heartbeat so node metadata outlives a
+	// silent expiry by the grace period
+	if err := a.writeNodeTombstone(ctx, server, false); err != nil {
+		log.WithError(err).Debugf("Failed to write tombstone for node %v.", server.GetName())
+	}
+	if err := a.recordNodePresence(ctx, server); err != nil {
+		log.WithError(err).Debugf("Failed to record presence of node %v.", server.GetName())
+	}
+	return keepAlive, nil
+}
+
+// DeleteNode deletes a node and marks its tombstone as decommissioned, so an
+// unexpected re-registration of the host can be flagged.
+func (a *Server) DeleteNode(ctx context.Context, namespace, name string) error {
+	if node, err := a.Services.GetNode(ctx, namespace, name); err == nil {
+		if err := a.writeNodeTombstone(ctx, node, true); err != nil {
+			log.WithError(err).Debugf("Failed to write tombstone for node %v.", name)
+		}
+	}
+	if err := a.bk.Delete(ctx, suggestedLabelsKey(name)); err != nil && !trace.IsNotFound(err) {
+		log.WithError(err).Debugf("Failed to delete suggested labels of node %v.", name)
+	}
+	return trace.Wrap(a.Services.DeleteNode(ctx, namespace, name))
+}
+
+// checkNodeTombstone inspects the tombstone of a registering node. If the
+// node is unknown but a tombstone exists, labels recorded before the node
+// went away are restored, and resurrections of decommissioned hosts are
+// flagged.
+func (a *Server) checkNodeTombstone(ctx context.Context, server types.Server) {
+	if _, err := a.GetNode(ctx, server.GetNamespace(), server.GetName()); err == nil {
+		// regular heartbeat of a known node
+		return
+	}
+	item, err := a.bk.Get(ctx, nodeTombstoneKey(server.GetNamespace(), server.GetName()))
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.WithError(err).Debugf("Failed to read tombstone for node %v.", server.GetName())
+		}
+		return
+	}
+	var tombstone nodeTombstone
+	if err := utils.FastUnmarshal(item.Value, &tombstone); err != nil {
+		log.WithError(err).Debugf("Failed to unmarshal tombstone for node %v.", server.GetName())
+		return
+	}
+	if tombstone.Decommissioned {
+		log.Warnf("Decommissioned node %v (deleted at %v) is re-registering; "+
+			"this could indicate an unexpected resurrection of a retired host.",
+			server.GetName(), tombstone.DeletedAt.Format(time.RFC3339))
+	}
+	previous, err := services.UnmarshalServer(tombstone.Server, types.KindNode)
+	if err != nil {
+		log.WithError(err).Debugf("Failed to unmarshal tombstoned node %v.", server.GetName())
+		return
+	}
+	// restore static labels the node carried before it went away, without
+	// overriding any labels of the new registration
+	labels := server.GetStaticLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	restored := false
+	for key, value := range previous.GetStaticLabels() {
+		if _, ok := labels[key]; !ok {
+			labels[key] = value
+			restored = true
+		}
+	}
+	if restored {
+		server.SetStaticLabels(labels)
+		log.Debugf("Restored labels of node %v from tombstone.", server.GetName())
+	}
+}
+
+// writeNodeTombstone records the node metadata with the tombstone grace
+// period as TTL.
+func (a *Server) writeNodeTombstone(ctx context.Context, server types.Server, decommissioned bool) error {
+	data, err := services.MarshalServer(server)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(&nodeTombstone{
+		Server:         data,
+		Decommissioned: decommissioned,
+		DeletedAt:      a.clock.Now().UTC(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	expires := a.clock.Now().Add(defaults.NodeTombstoneGracePeriod)
+	if serverExpiry := server.Expiry(); !decommissioned && serverExpiry.After(a.clock.Now()) {
+		expires = serverExpiry.Add(defaults.NodeTombstoneGracePeriod)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     nodeTombstoneKey(server.GetNamespace(), server.GetName()),
+		Value:   value,
+		Expires: expires.UTC(),
+	})
+	return trace.Wrap(err)
+}
+
+func nodeTombstoneKey(namespace, name string) []byte {
+	return backend.Key(nodeTombstonePrefix, namespace, name)
+}
+
+const nodeTombstonePrefix = "node_tombstones"
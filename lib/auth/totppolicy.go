@@ -0,0 +1,205 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/gravitational/teleport"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// totpPolicyPrefix is the backend key the TOTP policy singleton is stored
+// under.
+const totpPolicyPrefix = "totp_policy"
+
+// TOTPPolicy configures the parameters used when registering and validating
+// TOTP tokens, so clusters can interoperate with corporate token policies
+// instead of relying on the built-in defaults.
+type TOTPPolicy struct {
+	// Digits is the number of digits a token consists of, 6 or 8.
+	Digits int `json:"digits"`
+	// Period is the number of seconds a token is valid for.
+	Period uint `json:"period"`
+	// Skew is the number of periods accepted before and after the current
+	// one, to tolerate clock drift between the token and the cluster.
+	// Zero means the default of one period.
+	Skew uint `json:"skew"`
+	// Algorithm is the HMAC algorithm tokens are derived with, one of
+	// SHA1, SHA256 or SHA512.
+	Algorithm string `json:"algorithm"`
+}
+
+// DefaultTOTPPolicy returns the policy in force when none was configured,
+// matching the parameters Teleport has always used.
+func DefaultTOTPPolicy() TOTPPolicy {
+	return TOTPPolicy{
+		Digits:    otp.DigitsSix.Length(),
+		Period:    teleport.TOTPValidityPeriod,
+		Skew:      teleport.TOTPSkew,
+		Algorithm: otp.AlgorithmSHA1.String(),
+	}
+}
+
+// CheckAndSetDefaults validates the policy and fills in unset parameters
+// with their defaults.
+func (p *TOTPPolicy) CheckAndSetDefaults() error {
+	defaults := DefaultTOTPPolicy()
+	if p.Digits == 0 {
+		p.Digits = defaults.Digits
+	}
+	if p.Digits != otp.DigitsSix.Length() && p.Digits != otp.DigitsEight.Length() {
+		return trace.BadParameter("TOTP digits must be 6 or 8, got %v", p.Digits)
+	}
+	if p.Period == 0 {
+		p.Period = defaults.Period
+	}
+	if p.Period < 15 || p.Period > 120 {
+		return trace.BadParameter("TOTP period must be between 15 and 120 seconds, got %v", p.Period)
+	}
+	if p.Skew == 0 {
+		p.Skew = defaults.Skew
+	}
+	if p.Skew > 2 {
+		return trace.BadParameter("TOTP skew must not exceed 2 periods, got %v", p.Skew)
+	}
+	if p.Algorithm == "" {
+		p.Algorithm = defaults.Algorithm
+	}
+	if _, err := p.algorithm(); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// digits returns the digit count in the library representation.
+func (p *TOTPPolicy) digits() otp.Digits {
+	if p.Digits == otp.DigitsEight.Length() {
+		return otp.DigitsEight
+	}
+	return otp.DigitsSix
+}
+
+// algorithm returns the HMAC algorithm in the library representation.
+func (p *TOTPPolicy) algorithm() (otp.Algorithm, error) {
+	switch strings.ToUpper(p.Algorithm) {
+	case otp.AlgorithmSHA1.String():
+		return otp.AlgorithmSHA1, nil
+	case otp.AlgorithmSHA256.String():
+		return otp.AlgorithmSHA256, nil
+	case otp.AlgorithmSHA512.String():
+		return otp.AlgorithmSHA512, nil
+	default:
+		return otp.AlgorithmSHA1, trace.BadParameter("unsupported TOTP algorithm %q, must be one of SHA1, SHA256 or SHA512", p.Algorithm)
+	}
+}
+
+// validateOpts returns the validation options the policy translates to.
+func (p *TOTPPolicy) validateOpts() (totp.ValidateOpts, error) {
+	algorithm, err := p.algorithm()
+	if err != nil {
+		return totp.ValidateOpts{}, trace.Wrap(err)
+	}
+	return totp.ValidateOpts{
+		Period:    p.Period,
+		Skew:      p.Skew,
+		Digits:    p.digits(),
+		Algorithm: algorithm,
+	}, nil
+}
+
+// SetTOTPPolicy configures the TOTP parameters of the cluster. Tokens of
+// devices registered under different parameters stop validating, so the
+// policy should be set before users enroll their devices.
+func (a *Server) SetTOTPPolicy(ctx context.Context, policy TOTPPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(totpPolicyPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetTOTPPolicy returns the configured TOTP parameters, falling back to the
+// defaults when none were set.
+func (a *Server) GetTOTPPolicy(ctx context.Context) (TOTPPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(totpPolicyPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return DefaultTOTPPolicy(), nil
+		}
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	var policy TOTPPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteTOTPPolicy reverts the cluster to the default TOTP parameters.
+func (a *Server) DeleteTOTPPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(totpPolicyPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("TOTP policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// SetTOTPPolicy configures the TOTP parameters of the cluster.
+func (a *ServerWithRoles) SetTOTPPolicy(ctx context.Context, policy TOTPPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetTOTPPolicy(ctx, policy)
+}
+
+// GetTOTPPolicy returns the configured TOTP parameters, falling back to the
+// defaults when none were set.
+func (a *ServerWithRoles) GetTOTPPolicy(ctx context.Context) (TOTPPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return TOTPPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetTOTPPolicy(ctx)
+}
+
+// DeleteTOTPPolicy reverts the cluster to the default TOTP parameters.
+func (a *ServerWithRoles) DeleteTOTPPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteTOTPPolicy(ctx)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/services"
+)
+
+func TestTOTPPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy the defaults are in force.
+	policy, err := s.a.GetTOTPPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultTOTPPolicy(), policy)
+
+	// Invalid parameters are rejected.
+	for _, invalid := range []TOTPPolicy{
+		{Digits: 7},
+		{Period: 10},
+		{Skew: 5},
+		{Algorithm: "MD5"},
+	} {
+		err := s.a.SetTOTPPolicy(ctx, invalid)
+		require.True(t, trace.IsBadParameter(err), "expected BadParameter for %+v, got %v", invalid, err)
+	}
+
+	// Unset parameters are filled with the defaults.
+	require.NoError(t, s.a.SetTOTPPolicy(ctx, TOTPPolicy{Digits: 8, Algorithm: "SHA256"}))
+	policy, err = s.a.GetTOTPPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, TOTPPolicy{
+		Digits:    8,
+		Period:    DefaultTOTPPolicy().Period,
+		Skew:      DefaultTOTPPolicy().Skew,
+		Algorithm: "SHA256",
+	}, policy)
+
+	// Deleting reverts to the defaults.
+	require.NoError(t, s.a.DeleteTOTPPolicy(ctx))
+	policy, err = s.a.GetTOTPPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultTOTPPolicy(), policy)
+	err = s.a.DeleteTOTPPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestCheckOTPHonorsTOTPPolicy(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "totp-policy-user"
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+
+	require.NoError(t, s.a.SetTOTPPolicy(ctx, TOTPPolicy{
+		Digits:    8,
+		Period:    60,
+		Algorithm: "SHA256",
+	}))
+
+	otpSecret := base32.StdEncoding.EncodeToString([]byte("def456"))
+	dev, err := services.NewTOTPDevice("otp", otpSecret, s.a.clock.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertMFADevice(ctx, user, dev))
+
+	// A token derived with the default parameters no longer validates.
+	defaultToken, err := totp.GenerateCode(otpSecret, s.a.clock.Now())
+	require.NoError(t, err)
+	_, err = s.a.checkOTP(user, defaultToken)
+	require.Error(t, err)
+
+	// A token derived with the configured parameters does.
+	policyToken, err := totp.GenerateCodeCustom(otpSecret, s.a.clock.Now(), totp.ValidateOpts{
+		Period:    60,
+		Digits:    otp.DigitsEight,
+		Algorithm: otp.AlgorithmSHA256,
+	})
+	require.NoError(t, err)
+	gotDev, err := s.a.checkOTP(user, policyToken)
+	require.NoError(t, err)
+	require.Equal(t, dev.GetName(), gotDev.GetName())
+}
@@ -163,6 +163,71 @@ func (a *Server) UpsertTrustedCluster(ctx context.Context, trustedCluster types.
 	return tc, nil
 }
 
+// TrustedClusterRoleMapping describes how a single remote role would map to
+// local roles under a trusted cluster's role map.
+type TrustedClusterRoleMapping struct {
+	// RemoteRole is the role as reported by the remote cluster.
+	RemoteRole string `json:"remote_role"`
+	// LocalRoles are the local roles the remote role would map to.
+	LocalRoles []string `json:"local_roles"`
+	// MissingLocalRoles lists mapped local roles that do not exist in this
+	// cluster and would cause login failures.
+	MissingLocalRoles []string `json:"missing_local_roles,omitempty"`
+}
+
+// TrustedClusterPreview reports the effect a trusted cluster relationship
+// would have without committing it.
+type TrustedClusterPreview struct {
+	// ClusterName is the name of the remote cluster as reported during the
+	// validation handshake.
+	ClusterName string `json:"cluster_name"`
+	// RoleMappings describes how the remote cluster's roles would map to
+	// local roles.
+	RoleMappings []TrustedClusterRoleMapping `json:"role_mappings"`
+}
+
+// PreviewTrustedCluster performs the validation handshake with the root
+// cluster and reports exactly which remote roles would map to which local
+// roles, without storing certificate authorities, reverse tunnels or the
+// trusted cluster resource locally.
+func (a *Server) PreviewTrustedCluster(ctx context.Context, trustedCluster types.TrustedCluster) (*TrustedClusterPreview, error) {
+	if err := a.checkLocalRoles(ctx, trustedCluster.GetRoleMap()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	remoteCAs, err := a.establishTrust(ctx, trustedCluster)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	preview := &TrustedClusterPreview{
+		ClusterName: remoteCAs[0].GetClusterName(),
+	}
+	seen := make(map[string]bool)
+	for _, ca := range remoteCAs {
+		if ca.GetType() != types.UserCA {
+			continue
+		}
+		for _, remoteRole := range ca.GetRoles() {
+			if seen[remoteRole] {
+				continue
+			}
+			seen[remoteRole] = true
+			mapping := TrustedClusterRoleMapping{RemoteRole: remoteRole}
+			localRoles, err := services.MapRoles(trustedCluster.GetRoleMap(), []string{remoteRole})
+			if err != nil {
+				log.Debugf("Remote role %v does not map to any local roles: %v.", remoteRole, err)
+			}
+			mapping.LocalRoles = localRoles
+			for _, localRole := range localRoles {
+				if _, err := a.GetRole(ctx, localRole); err != nil {
+					mapping.MissingLocalRoles = append(mapping.MissingLocalRoles, localRole)
+				}
+			}
+			preview.RoleMappings = append(preview.RoleMappings, mapping)
+		}
+	}
+	return preview, nil
+}
+
 func (a *Server) checkLocalRoles(ctx context.Context, roleMap types.RoleMap) error {
 	for _, mapping := range roleMap {
 		for _, localRole := range mapping.Local {
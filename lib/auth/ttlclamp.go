@@ -0,0 +1,277 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// certTTLClampPrefix is the backend prefix the clamp policies are
+	// stored under.
+	certTTLClampPrefix = "cert_ttl_clamps"
+
+	// defaultNightStartHour and defaultNightEndHour bound the night
+	// window (UTC) when a policy does not set its own.
+	defaultNightStartHour = 22
+	defaultNightEndHour   = 6
+)
+
+// CertTTLClampPolicy further caps the TTL of user certificates routing to
+// targets matching its labels, on top of the role-based TTL computation.
+// An optional tighter cap can be applied during a night window, when
+// interactive access to sensitive systems is least expected.
+type CertTTLClampPolicy struct {
+	// Name is the policy name.
+	Name string `json:"name"`
+	// TargetLabels must all be present on the labels of the target the
+	// certificate routes to for the policy to apply. The value "*"
+	// matches any label value.
+	TargetLabels map[string]string `json:"target_labels"`
+	// MaxTTL is the cap applied during the day.
+	MaxTTL time.Duration `json:"max_ttl"`
+	// NightMaxTTL is the cap applied during the night window, zero means
+	// MaxTTL applies around the clock.
+	NightMaxTTL time.Duration `json:"night_max_ttl,omitempty"`
+	// NightStartHour is the UTC hour the night window starts at,
+	// defaults to 22.
+	NightStartHour int `json:"night_start_hour,omitempty"`
+	// NightEndHour is the UTC hour the night window ends before,
+	// defaults to 6.
+	NightEndHour int `json:"night_end_hour,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy and fills in the night window
+// bounds.
+func (p *CertTTLClampPolicy) CheckAndSetDefaults() error {
+	if p.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if len(p.TargetLabels) == 0 {
+		return trace.BadParameter("cert TTL clamp policy %q matches no target labels", p.Name)
+	}
+	if p.MaxTTL <= 0 {
+		return trace.BadParameter("cert TTL clamp policy %q must set a positive MaxTTL", p.Name)
+	}
+	if p.NightMaxTTL < 0 || p.NightMaxTTL > p.MaxTTL {
+		return trace.BadParameter("cert TTL clamp policy %q night cap must not exceed MaxTTL", p.Name)
+	}
+	if p.NightStartHour == 0 && p.NightEndHour == 0 {
+		p.NightStartHour = defaultNightStartHour
+		p.NightEndHour = defaultNightEndHour
+	}
+	if p.NightStartHour < 0 || p.NightStartHour > 23 || p.NightEndHour < 0 || p.NightEndHour > 23 {
+		return trace.BadParameter("cert TTL clamp policy %q night window hours must be within 0-23", p.Name)
+	}
+	return nil
+}
+
+// maxTTLAt returns the cap the policy puts in force at the given time.
+func (p *CertTTLClampPolicy) maxTTLAt(now time.Time) time.Duration {
+	if p.NightMaxTTL == 0 {
+		return p.MaxTTL
+	}
+	hour := now.UTC().Hour()
+	var night bool
+	if p.NightStartHour <= p.NightEndHour {
+		night = hour >= p.NightStartHour && hour < p.NightEndHour
+	} else {
+		// the window wraps around midnight
+		night = hour >= p.NightStartHour || hour < p.NightEndHour
+	}
+	if night {
+		return p.NightMaxTTL
+	}
+	return p.MaxTTL
+}
+
+// matchesLabels reports whether all policy labels are present on the target
+// labels.
+func (p *CertTTLClampPolicy) matchesLabels(target map[string]string) bool {
+	for key, value := range p.TargetLabels {
+		targetValue, ok := target[key]
+		if !ok {
+			return false
+		}
+		if value != types.Wildcard && value != targetValue {
+			return false
+		}
+	}
+	return true
+}
+
+func certTTLClampKey(name string) []byte {
+	return backend.Key(certTTLClampPrefix, name)
+}
+
+// UpsertCertTTLClampPolicy creates or updates a cert TTL clamp policy.
+func (a *Server) UpsertCertTTLClampPolicy(ctx context.Context, policy CertTTLClampPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   certTTLClampKey(policy.Name),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetCertTTLClampPolicies returns all cert TTL clamp policies.
+func (a *Server) GetCertTTLClampPolicies(ctx context.Context) ([]CertTTLClampPolicy, error) {
+	startKey := backend.Key(certTTLClampPrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	policies := make([]CertTTLClampPolicy, 0, len(result.Items))
+	for _, item := range result.Items {
+		var policy CertTTLClampPolicy
+		if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// DeleteCertTTLClampPolicy deletes a cert TTL clamp policy by name.
+func (a *Server) DeleteCertTTLClampPolicy(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	if err := a.bk.Delete(ctx, certTTLClampKey(name)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("cert TTL clamp policy %q is not found", name)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// clampSessionTTL applies the configured clamp policies to the session TTL
+// computed from the roles, using the labels of the targets the certificate
+// routes to.
+func (a *Server) clampSessionTTL(ctx context.Context, req certRequest, sessionTTL time.Duration) (time.Duration, error) {
+	policies, err := a.GetCertTTLClampPolicies(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	if len(policies) == 0 {
+		return sessionTTL, nil
+	}
+	targets, err := a.certTargetLabels(ctx, req)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	if len(targets) == 0 {
+		return sessionTTL, nil
+	}
+	now := a.clock.Now()
+	for _, policy := range policies {
+		for _, labels := range targets {
+			if !policy.matchesLabels(labels) {
+				continue
+			}
+			if limit := policy.maxTTLAt(now); limit < sessionTTL {
+				log.Debugf("Cert TTL clamp policy %q caps session TTL for user %q from %v to %v.",
+					policy.Name, req.user.GetName(), sessionTTL, limit)
+				sessionTTL = limit
+			}
+			break
+		}
+	}
+	return sessionTTL, nil
+}
+
+// certTargetLabels collects the labels of the resources the certificate
+// request routes to. A plain user certificate without routing information
+// has no targets and is not subject to clamping.
+func (a *Server) certTargetLabels(ctx context.Context, req certRequest) ([]map[string]string, error) {
+	var targets []map[string]string
+	if req.appName != "" {
+		servers, err := a.GetApplicationServers(ctx, apidefaults.Namespace)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, server := range servers {
+			if server.GetApp().GetName() == req.appName {
+				targets = append(targets, server.GetApp().GetAllLabels())
+			}
+		}
+	}
+	if req.dbService != "" {
+		servers, err := a.GetDatabaseServers(ctx, apidefaults.Namespace)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, server := range servers {
+			if server.GetDatabase().GetName() == req.dbService {
+				targets = append(targets, server.GetDatabase().GetAllLabels())
+			}
+		}
+	}
+	if req.kubernetesCluster != "" {
+		servers, err := a.GetKubeServices(ctx)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, server := range servers {
+			for _, cluster := range server.GetKubernetesClusters() {
+				if cluster.Name == req.kubernetesCluster {
+					targets = append(targets, types.CombineLabels(cluster.StaticLabels, cluster.DynamicLabels))
+				}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// UpsertCertTTLClampPolicy creates or updates a cert TTL clamp policy.
+func (a *ServerWithRoles) UpsertCertTTLClampPolicy(ctx context.Context, policy CertTTLClampPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertCertTTLClampPolicy(ctx, policy)
+}
+
+// GetCertTTLClampPolicies returns all cert TTL clamp policies.
+func (a *ServerWithRoles) GetCertTTLClampPolicies(ctx context.Context) ([]CertTTLClampPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetCertTTLClampPolicies(ctx)
+}
+
+// DeleteCertTTLClampPolicy deletes a cert TTL clamp policy by name.
+func (a *ServerWithRoles) DeleteCertTTLClampPolicy(ctx context.Context, name string) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteCertTTLClampPolicy(ctx, name)
+}
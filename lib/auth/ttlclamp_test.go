@@ -0,0 +1,114 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestCertTTLClampPolicyWindow(t *testing.T) {
+	t.Parallel()
+
+	policy := CertTTLClampPolicy{
+		Name:         "pci",
+		TargetLabels: map[string]string{"pci": "true"},
+		MaxTTL:       time.Hour,
+		NightMaxTTL:  15 * time.Minute,
+	}
+	require.NoError(t, policy.CheckAndSetDefaults())
+
+	// the default night window is 22:00-06:00 UTC
+	noon := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Hour, policy.maxTTLAt(noon))
+	require.Equal(t, 15*time.Minute, policy.maxTTLAt(midnight))
+
+	// without a night cap the day cap applies around the clock
+	policy.NightMaxTTL = 0
+	require.Equal(t, time.Hour, policy.maxTTLAt(midnight))
+
+	require.True(t, policy.matchesLabels(map[string]string{"pci": "true", "env": "prod"}))
+	require.False(t, policy.matchesLabels(map[string]string{"pci": "false"}))
+	require.False(t, policy.matchesLabels(map[string]string{"env": "prod"}))
+
+	policy.TargetLabels = map[string]string{"pci": types.Wildcard}
+	require.True(t, policy.matchesLabels(map[string]string{"pci": "false"}))
+}
+
+func TestClampSessionTTL(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	db, err := types.NewDatabaseV3(types.Metadata{
+		Name:   "cardholder-db",
+		Labels: map[string]string{"pci": "true"},
+	}, types.DatabaseSpecV3{
+		Protocol: "postgres",
+		URI:      "localhost:5432",
+	})
+	require.NoError(t, err)
+	server, err := types.NewDatabaseServerV3(types.Metadata{
+		Name: "cardholder-db",
+	}, types.DatabaseServerSpecV3{
+		HostID:   "host-1",
+		Hostname: "host-1",
+		Database: db,
+	})
+	require.NoError(t, err)
+	_, err = s.a.UpsertDatabaseServer(ctx, server)
+	require.NoError(t, err)
+
+	require.NoError(t, s.a.UpsertCertTTLClampPolicy(ctx, CertTTLClampPolicy{
+		Name:         "pci",
+		TargetLabels: map[string]string{"pci": "true"},
+		MaxTTL:       time.Hour,
+	}))
+
+	user, err := types.NewUser("alice")
+	require.NoError(t, err)
+
+	// requests routing to the labeled database are clamped to one hour
+	ttl, err := s.a.clampSessionTTL(ctx, certRequest{
+		user:      user,
+		dbService: "cardholder-db",
+	}, 8*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, ttl)
+
+	// requests without a matching target keep the role-based TTL
+	ttl, err = s.a.clampSessionTTL(ctx, certRequest{
+		user: user,
+	}, 8*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 8*time.Hour, ttl)
+
+	// deleting the policy lifts the clamp
+	require.NoError(t, s.a.DeleteCertTTLClampPolicy(ctx, "pci"))
+	ttl, err = s.a.clampSessionTTL(ctx, certRequest{
+		user:      user,
+		dbService: "cardholder-db",
+	}, 8*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 8*time.Hour, ttl)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+)
+
+// LegacyMFAUser describes a user who still owns unmigrated U2F device
+// records. Legacy records convert to WebAuthn credentials on their first
+// successful login, so the list shrinks as users log in.
+type LegacyMFAUser struct {
+	// Username is the name of the user.
+	Username string `json:"username"`
+	// Devices are the names of the user's remaining U2F devices.
+	Devices []string `json:"devices"`
+}
+
+// GetLegacyMFAUsers returns the users who still have legacy U2F device
+// records, so operators can tell when the U2F code path can be retired.
+func (a *Server) GetLegacyMFAUsers(ctx context.Context) ([]LegacyMFAUser, error) {
+	users, err := a.GetUsers(false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out []LegacyMFAUser
+	for _, user := range users {
+		devices, err := a.Services.GetMFADevices(ctx, user.GetName(), false)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var legacy []string
+		for _, device := range devices {
+			if device.GetU2F() != nil {
+				legacy = append(legacy, device.GetName())
+			}
+		}
+		if len(legacy) != 0 {
+			out = append(out, LegacyMFAUser{
+				Username: user.GetName(),
+				Devices:  legacy,
+			})
+		}
+	}
+	return out, nil
+}
+
+// GetLegacyMFAUsers returns the users who still have legacy U2F device
+// records.
+func (a *ServerWithRoles) GetLegacyMFAUsers(ctx context.Context) ([]LegacyMFAUser, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbList, types.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetLegacyMFAUsers(ctx)
+}
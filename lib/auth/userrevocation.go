@@ -0,0 +1,244 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// revokedCertPrefix is the backend key prefix revoked certificate records
+// are stored under.
+const revokedCertPrefix = "revoked_certs"
+
+// UserSessions is an inventory of everything that currently grants a user
+// access to the cluster: active web sessions, application sessions and
+// unexpired certificates from the issuance log.
+type UserSessions struct {
+	// WebSessionIDs are the IDs of the user's active web sessions.
+	WebSessionIDs []string `json:"web_session_ids"`
+	// AppSessionIDs are the IDs of the user's active application sessions.
+	AppSessionIDs []string `json:"app_session_ids"`
+	// Certificates are the user's unexpired issuance log entries.
+	Certificates []CertIssuance `json:"certificates"`
+}
+
+// RevokedCertificate marks a certificate serial as revoked. Revoked serials
+// are published in the CRLs generated by the cluster CA.
+type RevokedCertificate struct {
+	// Serial is the serial number of the revoked certificate.
+	Serial string `json:"serial"`
+	// Username is the user the certificate was issued for.
+	Username string `json:"username"`
+	// RevokedAt is when the certificate was revoked.
+	RevokedAt time.Time `json:"revoked_at"`
+	// NotAfter is when the certificate expires on its own, after which the
+	// revocation record is no longer needed.
+	NotAfter time.Time `json:"not_after"`
+}
+
+// GetUserSessions enumerates the user's active web sessions, application
+// sessions and unexpired certificates, so an operator can see everything
+// that would have to be revoked to cut off a compromised account.
+func (a *Server) GetUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	if username == "" {
+		return UserSessions{}, trace.BadParameter("missing parameter username")
+	}
+	now := a.clock.Now()
+	out := UserSessions{
+		WebSessionIDs: []string{},
+		AppSessionIDs: []string{},
+		Certificates:  []CertIssuance{},
+	}
+
+	webSessions, err := a.Services.WebSessions().List(ctx)
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	for _, session := range webSessions {
+		if session.GetUser() != username {
+			continue
+		}
+		if expires := session.GetExpiryTime(); !expires.IsZero() && expires.Before(now) {
+			continue
+		}
+		out.WebSessionIDs = append(out.WebSessionIDs, session.GetName())
+	}
+
+	appSessions, err := a.GetAppSessions(ctx)
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	for _, session := range appSessions {
+		if session.GetUser() != username {
+			continue
+		}
+		if expires := session.GetExpiryTime(); !expires.IsZero() && expires.Before(now) {
+			continue
+		}
+		out.AppSessionIDs = append(out.AppSessionIDs, session.GetName())
+	}
+
+	issuances, err := a.GetCertIssuances(ctx, username)
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	revocations, err := a.GetRevokedCertificates(ctx)
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	revoked := make(map[string]struct{}, len(revocations))
+	for _, revocation := range revocations {
+		revoked[revocation.Serial] = struct{}{}
+	}
+	for _, issuance := range issuances {
+		if issuance.NotAfter.Before(now) {
+			continue
+		}
+		// Certificates that were already revoked no longer grant access.
+		if _, ok := revoked[issuance.Serial]; ok {
+			continue
+		}
+		out.Certificates = append(out.Certificates, issuance)
+	}
+	return out, nil
+}
+
+// RevokeUserSessions revokes everything returned by GetUserSessions in one
+// sweep: web sessions and application sessions are deleted, and unexpired
+// certificate serials are recorded as revoked so subsequently generated CRLs
+// list them. It returns the inventory that was revoked.
+func (a *Server) RevokeUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	sessions, err := a.GetUserSessions(ctx, username)
+	if err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	for _, sessionID := range sessions.WebSessionIDs {
+		// Delete through the wrapper chain so idle activity records and
+		// sealed secrets are cleaned up alongside the session.
+		if err := a.WebSessions().Delete(ctx, types.DeleteWebSessionRequest{
+			User:      username,
+			SessionID: sessionID,
+		}); err != nil && !trace.IsNotFound(err) {
+			return UserSessions{}, trace.Wrap(err)
+		}
+	}
+	if len(sessions.AppSessionIDs) != 0 {
+		if err := a.DeleteUserAppSessions(ctx, &proto.DeleteUserAppSessionsRequest{
+			Username: username,
+		}); err != nil {
+			return UserSessions{}, trace.Wrap(err)
+		}
+	}
+	for _, issuance := range sessions.Certificates {
+		if err := a.revokeCertificate(ctx, issuance); err != nil {
+			return UserSessions{}, trace.Wrap(err)
+		}
+	}
+	return sessions, nil
+}
+
+// revokeCertificate records a certificate serial as revoked. The record is
+// kept until the certificate would have expired anyway, when revoking it
+// stops being meaningful.
+func (a *Server) revokeCertificate(ctx context.Context, issuance CertIssuance) error {
+	revocation := RevokedCertificate{
+		Serial:    issuance.Serial,
+		Username:  issuance.Username,
+		RevokedAt: a.clock.Now().UTC(),
+		NotAfter:  issuance.NotAfter,
+	}
+	value, err := utils.FastMarshal(&revocation)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(revokedCertPrefix, revocation.Serial),
+		Value:   value,
+		Expires: revocation.NotAfter.Add(certIssuanceRetention).UTC(),
+	})
+	return trace.Wrap(err)
+}
+
+// GetRevokedCertificates returns all certificate revocation records that
+// have not aged out yet.
+func (a *Server) GetRevokedCertificates(ctx context.Context) ([]RevokedCertificate, error) {
+	startKey := backend.Key(revokedCertPrefix)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]RevokedCertificate, 0, len(result.Items))
+	for _, item := range result.Items {
+		var revocation RevokedCertificate
+		if err := utils.FastUnmarshal(item.Value, &revocation); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, revocation)
+	}
+	return out, nil
+}
+
+// crlEntries converts the revocation records into CRL entries for
+// GenerateCertAuthorityCRL. Serials that cannot be parsed are skipped
+// rather than invalidating the whole CRL.
+func crlEntries(revocations []RevokedCertificate) []x509.RevocationListEntry {
+	entries := make([]x509.RevocationListEntry, 0, len(revocations))
+	for _, revocation := range revocations {
+		serial, ok := new(big.Int).SetString(revocation.Serial, 10)
+		if !ok {
+			log.Warnf("Skipping revoked certificate with unparsable serial %q.", revocation.Serial)
+			continue
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: revocation.RevokedAt,
+		})
+	}
+	return entries
+}
+
+// GetUserSessions enumerates the user's active sessions and unexpired
+// certificates. Users may inspect their own sessions, inspecting other
+// users' sessions requires read access to users.
+func (a *ServerWithRoles) GetUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	if err := a.currentUserAction(username); err != nil {
+		if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead, types.VerbList); err != nil {
+			return UserSessions{}, trace.Wrap(err)
+		}
+	}
+	return a.authServer.GetUserSessions(ctx, username)
+}
+
+// RevokeUserSessions revokes all of the user's sessions and unexpired
+// certificates in one sweep.
+func (a *ServerWithRoles) RevokeUserSessions(ctx context.Context, username string) (UserSessions, error) {
+	if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbUpdate); err != nil {
+		return UserSessions{}, trace.Wrap(err)
+	}
+	return a.authServer.RevokeUserSessions(ctx, username)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth/native"
+)
+
+func TestUserSessionRevocation(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user, _, err := CreateUserAndRole(s.a, "revoked-user", []string{"revoked-user"})
+	require.NoError(t, err)
+
+	// An active web session.
+	webSession, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:       user.GetName(),
+		Roles:      user.GetRoles(),
+		Traits:     user.GetTraits(),
+		LoginTime:  s.a.clock.Now().UTC(),
+		SessionTTL: apidefaults.CertDuration,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.upsertWebSession(ctx, user.GetName(), webSession))
+
+	// An active app session.
+	appSession, err := types.NewWebSession("app-session-id", types.KindAppSession, types.WebSessionSpecV2{
+		User:    user.GetName(),
+		Expires: s.a.clock.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertAppSession(ctx, appSession))
+
+	// An unexpired certificate, recorded in the issuance log as a side
+	// effect of issuance.
+	_, pub, err := native.GenerateKeyPair()
+	require.NoError(t, err)
+	_, _, err = s.a.GenerateUserTestCerts(pub, user.GetName(), time.Hour, "", "", "")
+	require.NoError(t, err)
+
+	sessions, err := s.a.GetUserSessions(ctx, user.GetName())
+	require.NoError(t, err)
+	require.Len(t, sessions.WebSessionIDs, 1)
+	require.Len(t, sessions.AppSessionIDs, 1)
+	// The web session certificate is logged alongside the explicitly
+	// generated one.
+	require.Len(t, sessions.Certificates, 2)
+
+	// Other users see an empty inventory.
+	other, err := s.a.GetUserSessions(ctx, "somebody-else")
+	require.NoError(t, err)
+	require.Empty(t, other.WebSessionIDs)
+	require.Empty(t, other.AppSessionIDs)
+	require.Empty(t, other.Certificates)
+
+	revoked, err := s.a.RevokeUserSessions(ctx, user.GetName())
+	require.NoError(t, err)
+	require.Equal(t, sessions, revoked)
+
+	// The web and app sessions are gone.
+	_, err = s.a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      user.GetName(),
+		SessionID: webSession.GetName(),
+	})
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+	_, err = s.a.GetAppSession(ctx, types.GetAppSessionRequest{SessionID: appSession.GetName()})
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+
+	// The certificate serials are recorded for CRL generation.
+	revocations, err := s.a.GetRevokedCertificates(ctx)
+	require.NoError(t, err)
+	require.Len(t, revocations, 2)
+	for _, revocation := range revocations {
+		require.Equal(t, user.GetName(), revocation.Username)
+	}
+
+	// A second sweep finds nothing left to revoke.
+	revoked, err = s.a.RevokeUserSessions(ctx, user.GetName())
+	require.NoError(t, err)
+	require.Empty(t, revoked.WebSessionIDs)
+	require.Empty(t, revoked.AppSessionIDs)
+	require.Empty(t, revoked.Certificates)
+}
@@ -253,7 +253,7 @@ func (s *Server) RotateUserTokenSecrets(ctx context.Context, tokenID string) (ty
 		return nil, trace.Wrap(err)
 	}
 
-	otpKey, _, err := s.newTOTPKey(token.GetUser())
+	otpKey, _, err := s.newTOTPKey(ctx, token.GetUser())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -289,7 +289,7 @@ func (s *Server) createTOTPUserTokenSecrets(ctx context.Context, token types.Use
 	return secrets, nil
 }
 
-func (s *Server) newTOTPKey(user string) (*otp.Key, *totp.GenerateOpts, error) {
+func (s *Server) newTOTPKey(ctx context.Context, user string) (*otp.Key, *totp.GenerateOpts, error) {
 	// Fetch account name to display in OTP apps.
 	accountName, err := formatAccountName(s, user, s.AuthServiceName)
 	if err != nil {
@@ -299,13 +299,21 @@ func (s *Server) newTOTPKey(user string) (*otp.Key, *totp.GenerateOpts, error) {
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
+	policy, err := s.GetTOTPPolicy(ctx)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	algorithm, err := policy.algorithm()
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
 
 	opts := totp.GenerateOpts{
 		Issuer:      clusterName.GetClusterName(),
 		AccountName: accountName,
-		Period:      30, // seconds
-		Digits:      otp.DigitsSix,
-		Algorithm:   otp.AlgorithmSHA1,
+		Period:      policy.Period,
+		Digits:      policy.digits(),
+		Algorithm:   algorithm,
 	}
 	key, err := totp.Generate(opts)
 	if err != nil {
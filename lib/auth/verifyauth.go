@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/events"
+)
+
+// VerifyAuthenticationRequest is a request to verify a user's password and
+// optional second factor without creating a session.
+type VerifyAuthenticationRequest struct {
+	// Username is the name of the user whose credentials are verified.
+	Username string `json:"username"`
+	// Password is the password to verify.
+	Password []byte `json:"password"`
+	// OTPToken is an optional second factor token.
+	OTPToken string `json:"otp_token,omitempty"`
+	// ClientMetadata includes forwarded information about the client on whose
+	// behalf the credentials are verified.
+	ClientMetadata *ForwardedClientMetadata `json:"client_metadata,omitempty"`
+}
+
+// CheckAndSetDefaults checks and sets defaults
+func (r *VerifyAuthenticationRequest) CheckAndSetDefaults() error {
+	if r.Username == "" {
+		return trace.BadParameter("missing parameter 'username'")
+	}
+	if len(r.Password) == 0 {
+		return trace.BadParameter("missing parameter 'password'")
+	}
+	return nil
+}
+
+// VerifyAuthentication checks the user's password and optional OTP token
+// without creating a session. Unlike the deprecated CheckPassword path,
+// failed attempts count towards the account lockout and the per-address
+// throttle, and every attempt is recorded in the audit log, so the endpoint
+// cannot be used to probe credentials silently.
+func (a *Server) VerifyAuthentication(ctx context.Context, req VerifyAuthenticationRequest) error {
+	var mfaDev *types.MFADevice
+	err := req.CheckAndSetDefaults()
+	if err == nil {
+		err = a.checkLoginThrottle(ctx, req.ClientMetadata)
+	}
+	if err == nil {
+		err = a.WithUserLock(req.Username, func() error {
+			res, fnErr := a.checkPassword(req.Username, req.Password, req.OTPToken)
+			if fnErr != nil {
+				return trace.Wrap(fnErr)
+			}
+			mfaDev = res.mfaDev
+			return nil
+		})
+		if err != nil && !trace.IsConnectionProblem(err) {
+			a.recordFailedLoginAttempt(ctx, req.ClientMetadata)
+		}
+	}
+	event := &apievents.UserLogin{
+		Metadata: apievents.Metadata{
+			Type: events.UserLoginEvent,
+			Code: events.AuthVerificationFailureCode,
+		},
+		UserMetadata: apievents.UserMetadata{
+			User: req.Username,
+		},
+		Method: events.LoginMethodLocal,
+	}
+	if mfaDev != nil {
+		m := mfaDeviceEventMetadata(mfaDev)
+		event.MFADevice = &m
+	}
+	if req.ClientMetadata != nil {
+		event.RemoteAddr = req.ClientMetadata.RemoteAddr
+		event.UserAgent = req.ClientMetadata.UserAgent
+	}
+	if err != nil {
+		event.Status.Success = false
+		event.Status.Error = err.Error()
+	} else {
+		event.Code = events.AuthVerificationCode
+		event.Status.Success = true
+	}
+	if emitErr := a.emitter.EmitAuditEvent(a.closeCtx, event); emitErr != nil {
+		log.WithError(emitErr).Warn("Failed to emit authentication verification event.")
+	}
+	return trace.Wrap(err)
+}
+
+// VerifyAuthentication verifies the user's password and optional second
+// factor without creating a session.
+func (a *ServerWithRoles) VerifyAuthentication(ctx context.Context, req VerifyAuthenticationRequest) error {
+	if err := a.currentUserAction(req.Username); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.VerifyAuthentication(ctx, req)
+}
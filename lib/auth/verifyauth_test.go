@@ -0,0 +1,154 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+func TestVerifyAuthentication(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+
+	user := "verify-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	otpSecret := base32.StdEncoding.EncodeToString([]byte("def456"))
+	dev, err := services.NewTOTPDevice("otp", otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertMFADevice(ctx, user, dev))
+
+	// Malformed requests are rejected before credentials are touched.
+	err = s.a.VerifyAuthentication(ctx, VerifyAuthenticationRequest{Username: user})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// A valid password and OTP token verify successfully and the audit event
+	// carries the device that produced the token.
+	validToken, err := totp.GenerateCode(otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	err = s.a.VerifyAuthentication(ctx, VerifyAuthenticationRequest{
+		Username: user,
+		Password: password,
+		OTPToken: validToken,
+	})
+	require.NoError(t, err)
+	event, ok := s.mockEmitter.LastEvent().(*apievents.UserLogin)
+	require.True(t, ok, "expected UserLogin event, got %T", s.mockEmitter.LastEvent())
+	require.Equal(t, events.AuthVerificationCode, event.GetCode())
+	require.True(t, event.Status.Success)
+	require.NotNil(t, event.MFADevice)
+
+	// A wrong password fails and is audited as well.
+	err = s.a.VerifyAuthentication(ctx, VerifyAuthenticationRequest{
+		Username: user,
+		Password: []byte("wrongpassword"),
+		OTPToken: validToken,
+	})
+	require.Error(t, err)
+	require.Equal(t, events.AuthVerificationFailureCode, s.mockEmitter.LastEvent().GetCode())
+
+	// Failed verifications count towards the account lockout: after enough of
+	// them even the correct credentials are rejected.
+	require.NoError(t, s.a.SetLockoutPolicy(ctx, LockoutPolicy{MaxAttempts: 3}))
+	for i := 0; i < 2; i++ {
+		err = s.a.VerifyAuthentication(ctx, VerifyAuthenticationRequest{
+			Username: user,
+			Password: []byte("wrongpassword"),
+			OTPToken: validToken,
+		})
+		require.Error(t, err)
+	}
+	validToken, err = totp.GenerateCode(otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	err = s.a.VerifyAuthentication(ctx, VerifyAuthenticationRequest{
+		Username: user,
+		Password: password,
+		OTPToken: validToken,
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Equal(t, MaxFailedAttemptsErrMsg, err.Error())
+	require.Equal(t, events.AuthVerificationFailureCode, s.mockEmitter.LastEvent().GetCode())
+}
+
+func TestVerifyAuthenticationThrottle(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClock()
+	s.a.SetClock(fakeClock)
+
+	user := "verify-throttle-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	otpSecret := base32.StdEncoding.EncodeToString([]byte("def456"))
+	dev, err := services.NewTOTPDevice("otp", otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertMFADevice(ctx, user, dev))
+
+	require.NoError(t, s.a.SetLockoutPolicy(ctx, LockoutPolicy{
+		AttemptWindow:    time.Minute,
+		MaxAttemptsPerIP: 2,
+	}))
+
+	validToken, err := totp.GenerateCode(otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	req := VerifyAuthenticationRequest{
+		Username:       user,
+		Password:       []byte("wrongpassword"),
+		OTPToken:       validToken,
+		ClientMetadata: &ForwardedClientMetadata{RemoteAddr: "10.0.0.1:3080"},
+	}
+
+	// The first two failures count against the address, the third is
+	// throttled before credentials are checked and still audited.
+	for i := 0; i < 2; i++ {
+		require.Error(t, s.a.VerifyAuthentication(ctx, req))
+	}
+	err = s.a.VerifyAuthentication(ctx, req)
+	require.True(t, trace.IsLimitExceeded(err), "expected LimitExceeded, got %v", err)
+	require.Equal(t, events.AuthVerificationFailureCode, s.mockEmitter.LastEvent().GetCode())
+
+	// The window expires and verification from the address works again.
+	fakeClock.Advance(time.Minute + time.Second)
+	okReq := req
+	okReq.Password = password
+	okReq.OTPToken, err = totp.GenerateCode(otpSecret, fakeClock.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.a.VerifyAuthentication(ctx, okReq))
+	require.Equal(t, events.AuthVerificationCode, s.mockEmitter.LastEvent().GetCode())
+}
@@ -71,6 +71,53 @@ func deviceToCredential(dev *types.MFADevice, idOnly bool) (wan.Credential, bool
 	}
 }
 
+// u2fAttestationType is the attestation format of U2F authenticators, also
+// used to recognize migrated legacy records: credentials registered through
+// WebAuthn carry an attestation object, migrated ones do not.
+// https://www.w3.org/TR/webauthn-2/#sctn-fido-u2f-attestation.
+const u2fAttestationType = "fido-u2f"
+
+// migrateU2FDevice converts a legacy U2F device record into an equivalent
+// WebAuthn credential in place. The credential keeps authenticating under
+// the U2F App ID, see deviceUsesAppID.
+func migrateU2FDevice(dev *types.MFADevice) error {
+	u2f := dev.GetU2F()
+	if u2f == nil {
+		return trace.BadParameter("device %q is not a U2F device", dev.GetName())
+	}
+	pubKeyCBOR, err := u2fDERKeyToCBOR(u2f.PubKey)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dev.Device = &types.MFADevice_Webauthn{
+		Webauthn: &types.WebauthnDevice{
+			CredentialId:     u2f.KeyHandle,
+			PublicKeyCbor:    pubKeyCBOR,
+			AttestationType:  u2fAttestationType,
+			SignatureCounter: u2f.Counter,
+		},
+	}
+	return nil
+}
+
+// deviceUsesAppID reports whether login assertions for the device must use
+// RPID = U2F App ID. True for legacy U2F records and for records migrated
+// from them: the underlying key was registered under the App ID and signs
+// under no other relying party. Migrated records are told apart from
+// genuine registrations by what migration cannot reconstruct: an
+// attestation object and an AAGUID (zeroed but present for registered U2F
+// authenticators).
+func deviceUsesAppID(dev *types.MFADevice) bool {
+	if dev.GetU2F() != nil {
+		return true
+	}
+	wd := dev.GetWebauthn()
+	return wd != nil &&
+		wd.AttestationType == u2fAttestationType &&
+		len(wd.AttestationObject) == 0 &&
+		len(wd.Aaguid) == 0
+}
+
 func u2fDERKeyToCBOR(der []byte) ([]byte, error) {
 	pubKeyI, err := x509.ParsePKIXPublicKey(der)
 	if err != nil {
@@ -237,9 +237,9 @@ func (f *loginFlow) finish(ctx context.Context, user string, resp *CredentialAss
 	// [1] https://www.w3.org/TR/webauthn-2/#sctn-appid-extension.
 	rpID := f.Webauthn.RPID
 	switch {
-	case dev.GetU2F() != nil && f.U2F == nil:
+	case deviceUsesAppID(dev) && f.U2F == nil:
 		return nil, "", trace.BadParameter("U2F device attempted login, but U2F configuration not present")
-	case dev.GetU2F() != nil:
+	case deviceUsesAppID(dev):
 		rpID = f.U2F.AppID
 	}
 	u := newWebUser(user, webID, false /* credentialIDOnly */, []*types.MFADevice{dev})
@@ -276,6 +276,15 @@ func (f *loginFlow) finish(ctx context.Context, user string, resp *CredentialAss
 			"WebAuthn: Clone warning detected for user %q / device %q. Device counter may be malfunctioning.", user, dev.GetName())
 	}
 
+	// Legacy U2F records are converted to WebAuthn credentials on their
+	// first successful use, so the U2F code path can eventually be retired.
+	// Failure to convert is not fatal, the legacy record keeps working.
+	if dev.GetU2F() != nil {
+		if err := migrateU2FDevice(dev); err != nil {
+			log.Warnf("WebAuthn: failed to migrate U2F device %q of user %q: %v.", dev.GetName(), user, err)
+		}
+	}
+
 	// Update last used timestamp and device counter.
 	if err := setCounterAndTimestamps(dev, credential); err != nil {
 		return nil, "", trace.Wrap(err)
@@ -613,3 +613,46 @@ func (f *fakeIdentity) DeleteGlobalWebauthnSessionData(ctx context.Context, scop
 func globalSessionDataKey(scope string, id string) string {
 	return fmt.Sprintf("global/%v/%v", scope, id)
 }
+
+func TestLoginFlow_U2FMigration(t *testing.T) {
+	// Simulate a previously registered U2F device.
+	u2fKey, err := mocku2f.Create()
+	require.NoError(t, err)
+	u2fKey.SetCounter(10)
+	devAddedAt := time.Now().Add(-5 * time.Minute)
+	u2fDev, err := keyToMFADevice(u2fKey, devAddedAt, devAddedAt)
+	require.NoError(t, err)
+
+	const user = "alpaca"
+	identity := newFakeIdentity(user, u2fDev)
+	webLogin := &wanlib.LoginFlow{
+		U2F:      &types.U2F{AppID: "https://example.com:3080"},
+		Webauthn: &types.Webauthn{RPID: "example.com"},
+		Identity: identity,
+	}
+	const origin = "https://example.com:3080"
+	ctx := context.Background()
+
+	login := func() *types.MFADevice {
+		assertion, err := webLogin.Begin(ctx, user)
+		require.NoError(t, err)
+		assertionResp, err := u2fKey.SignAssertion(origin, assertion)
+		require.NoError(t, err)
+		dev, err := webLogin.Finish(ctx, user, assertionResp)
+		require.NoError(t, err)
+		return dev
+	}
+
+	// The first successful login converts the record to WebAuthn.
+	wantCounter := u2fKey.Counter() // recorded before the key increments it
+	migrated := login()
+	require.Nil(t, migrated.GetU2F())
+	wd := migrated.GetWebauthn()
+	require.NotNil(t, wd)
+	require.Equal(t, u2fKey.KeyHandle, wd.CredentialId)
+	require.Equal(t, wantCounter, wd.SignatureCounter)
+
+	// The migrated credential keeps working under the U2F App ID.
+	again := login()
+	require.NotNil(t, again.GetWebauthn())
+}
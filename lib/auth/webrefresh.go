@@ -0,0 +1,316 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// webRefreshTokenPrefix is the backend key prefix refresh token records
+	// are stored under.
+	webRefreshTokenPrefix = "web_refresh_tokens"
+	// webSessionChainPrefix is the backend key prefix session chain records
+	// are stored under.
+	webSessionChainPrefix = "web_session_chains"
+	// webRefreshTokenRetention keeps rotated tokens around after the chain's
+	// latest session expires, so late replays of old tokens are still
+	// recognized as reuse.
+	webRefreshTokenRetention = time.Hour
+)
+
+// webRefreshToken is a single-use token that lets the holder renew a web
+// session. Tokens are rotated on every renewal: a rotated token that is
+// presented again is evidence of theft and invalidates the whole chain.
+type webRefreshToken struct {
+	// Token is the opaque token value.
+	Token string `json:"token"`
+	// ChainID identifies the session chain the token belongs to.
+	ChainID string `json:"chain_id"`
+	// User is the user the token renews sessions for.
+	User string `json:"user"`
+	// SessionID is the web session the token renews.
+	SessionID string `json:"session_id"`
+	// Rotated is set once the token has been exchanged for a new one.
+	Rotated bool `json:"rotated,omitempty"`
+}
+
+// webSessionChain links the web sessions and refresh tokens produced by
+// successive renewals, so the whole chain can be revoked at once when a
+// rotated token is replayed.
+type webSessionChain struct {
+	// ChainID is the unique ID of the chain.
+	ChainID string `json:"chain_id"`
+	// User is the user the chain belongs to.
+	User string `json:"user"`
+	// SessionIDs are the web sessions created in the chain, oldest first.
+	SessionIDs []string `json:"session_ids"`
+	// Tokens are the refresh tokens issued in the chain, oldest first.
+	Tokens []string `json:"tokens"`
+}
+
+// RenewWebSessionRequest is a request to renew a web session with a refresh
+// token.
+type RenewWebSessionRequest struct {
+	// User is the user the session belongs to.
+	User string `json:"user"`
+	// RefreshToken is the refresh token issued with the session.
+	RefreshToken string `json:"refresh_token"`
+}
+
+// IssueWebSessionRefreshToken starts a session chain rooted at the given web
+// session and returns its first refresh token. Renewing with the token
+// rotates it, presenting a rotated token revokes the whole chain.
+func (a *Server) IssueWebSessionRefreshToken(ctx context.Context, user, sessionID string) (string, error) {
+	session, err := a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      user,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	token, err := utils.CryptoRandomHex(SessionTokenBytes)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	chain := webSessionChain{
+		ChainID:    uuid.New().String(),
+		User:       user,
+		SessionIDs: []string{sessionID},
+		Tokens:     []string{token},
+	}
+	expires := session.GetExpiryTime().Add(webRefreshTokenRetention).UTC()
+	if err := a.upsertRefreshToken(ctx, webRefreshToken{
+		Token:     token,
+		ChainID:   chain.ChainID,
+		User:      user,
+		SessionID: sessionID,
+	}, expires); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := a.upsertWebSessionChain(ctx, chain, expires); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return token, nil
+}
+
+// RenewWebSession exchanges a refresh token for a new web session and a new
+// refresh token. The presented token is rotated out: if it is ever presented
+// again, every session and token in the chain is revoked on the spot and the
+// reuse is audited, since two holders of the same token mean one of them
+// stole it.
+func (a *Server) RenewWebSession(ctx context.Context, req RenewWebSessionRequest) (types.WebSession, string, error) {
+	token, err := a.getRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, "", trace.AccessDenied("invalid refresh token")
+		}
+		return nil, "", trace.Wrap(err)
+	}
+	if token.User != req.User {
+		return nil, "", trace.AccessDenied("invalid refresh token")
+	}
+	chain, err := a.getWebSessionChain(ctx, token.ChainID)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	if token.Rotated {
+		if err := a.revokeWebSessionChain(ctx, chain); err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.SessionReject{
+			Metadata: apievents.Metadata{
+				Type: events.SessionRejectedEvent,
+				Code: events.RefreshTokenReuseCode,
+			},
+			UserMetadata: apievents.UserMetadata{
+				User: req.User,
+			},
+			Reason: events.SessionRejectedReasonRefreshTokenReuse,
+		}); err != nil {
+			log.WithError(err).Warn("Failed to emit refresh token reuse event.")
+		}
+		return nil, "", trace.AccessDenied("refresh token has already been used, all sessions in its chain have been revoked")
+	}
+
+	prevSession, err := a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      req.User,
+		SessionID: token.SessionID,
+	})
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	// Renewal never outlives the session being renewed, matching
+	// ExtendWebSession.
+	expiresAt := prevSession.GetExpiryTime()
+	if !expiresAt.IsZero() && expiresAt.Before(a.clock.Now().UTC()) {
+		return nil, "", trace.NotFound("web session has expired")
+	}
+
+	user, err := a.GetUser(req.User, false)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	session, err := a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:       req.User,
+		Roles:      user.GetRoles(),
+		Traits:     user.GetTraits(),
+		SessionTTL: utils.ToTTL(a.clock, expiresAt),
+	})
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	session.SetLoginTime(prevSession.GetLoginTime())
+
+	// The previous session is being replaced, it does not count against the
+	// concurrent web session limit.
+	if err := a.enforceWebSessionLimit(ctx, req.User, prevSession.GetName()); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	if err := a.upsertWebSession(ctx, req.User, session); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+
+	// Rotate: retire the presented token and extend the chain with the new
+	// session and token. The retired token is kept so its reuse can be
+	// detected.
+	expires := expiresAt.Add(webRefreshTokenRetention).UTC()
+	token.Rotated = true
+	if err := a.upsertRefreshToken(ctx, *token, expires); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	newToken, err := utils.CryptoRandomHex(SessionTokenBytes)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	if err := a.upsertRefreshToken(ctx, webRefreshToken{
+		Token:     newToken,
+		ChainID:   chain.ChainID,
+		User:      req.User,
+		SessionID: session.GetName(),
+	}, expires); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	chain.SessionIDs = append(chain.SessionIDs, session.GetName())
+	chain.Tokens = append(chain.Tokens, newToken)
+	if err := a.upsertWebSessionChain(ctx, *chain, expires); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return session, newToken, nil
+}
+
+// revokeWebSessionChain deletes every session and refresh token of a chain.
+func (a *Server) revokeWebSessionChain(ctx context.Context, chain *webSessionChain) error {
+	for _, sessionID := range chain.SessionIDs {
+		if err := a.WebSessions().Delete(ctx, types.DeleteWebSessionRequest{
+			User:      chain.User,
+			SessionID: sessionID,
+		}); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	for _, token := range chain.Tokens {
+		if err := a.bk.Delete(ctx, backend.Key(webRefreshTokenPrefix, token)); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	if err := a.bk.Delete(ctx, backend.Key(webSessionChainPrefix, chain.ChainID)); err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+func (a *Server) upsertRefreshToken(ctx context.Context, token webRefreshToken, expires time.Time) error {
+	value, err := utils.FastMarshal(&token)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(webRefreshTokenPrefix, token.Token),
+		Value:   value,
+		Expires: expires,
+	})
+	return trace.Wrap(err)
+}
+
+func (a *Server) getRefreshToken(ctx context.Context, token string) (*webRefreshToken, error) {
+	if token == "" {
+		return nil, trace.BadParameter("missing parameter refresh token")
+	}
+	item, err := a.bk.Get(ctx, backend.Key(webRefreshTokenPrefix, token))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out webRefreshToken
+	if err := utils.FastUnmarshal(item.Value, &out); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &out, nil
+}
+
+func (a *Server) upsertWebSessionChain(ctx context.Context, chain webSessionChain, expires time.Time) error {
+	value, err := utils.FastMarshal(&chain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(webSessionChainPrefix, chain.ChainID),
+		Value:   value,
+		Expires: expires,
+	})
+	return trace.Wrap(err)
+}
+
+func (a *Server) getWebSessionChain(ctx context.Context, chainID string) (*webSessionChain, error) {
+	item, err := a.bk.Get(ctx, backend.Key(webSessionChainPrefix, chainID))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out webSessionChain
+	if err := utils.FastUnmarshal(item.Value, &out); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &out, nil
+}
+
+// IssueWebSessionRefreshToken starts a session chain rooted at the given web
+// session and returns its first refresh token.
+func (a *ServerWithRoles) IssueWebSessionRefreshToken(ctx context.Context, user, sessionID string) (string, error) {
+	if err := a.currentUserAction(user); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return a.authServer.IssueWebSessionRefreshToken(ctx, user, sessionID)
+}
+
+// RenewWebSession exchanges a refresh token for a new web session and a new
+// refresh token, see Server.RenewWebSession.
+func (a *ServerWithRoles) RenewWebSession(ctx context.Context, req RenewWebSessionRequest) (types.WebSession, string, error) {
+	if err := a.currentUserAction(req.User); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return a.authServer.RenewWebSession(ctx, req)
+}
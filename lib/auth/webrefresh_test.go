@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/events"
+)
+
+func TestWebSessionRefreshRotation(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(fakeClock)
+
+	user, _, err := CreateUserAndRole(s.a, "refresh-user", []string{"refresh-user"})
+	require.NoError(t, err)
+
+	session, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:       user.GetName(),
+		Roles:      user.GetRoles(),
+		Traits:     user.GetTraits(),
+		LoginTime:  fakeClock.Now().UTC(),
+		SessionTTL: apidefaults.CertDuration,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.a.upsertWebSession(ctx, user.GetName(), session))
+
+	// Tokens are only issued against existing sessions.
+	_, err = s.a.IssueWebSessionRefreshToken(ctx, user.GetName(), "no-such-session")
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+
+	token, err := s.a.IssueWebSessionRefreshToken(ctx, user.GetName(), session.GetName())
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// An unknown token is rejected without leaking whether it ever existed.
+	_, _, err = s.a.RenewWebSession(ctx, RenewWebSessionRequest{
+		User:         user.GetName(),
+		RefreshToken: "no-such-token",
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+
+	// Renewal mints a new session and rotates the token.
+	renewed, newToken, err := s.a.RenewWebSession(ctx, RenewWebSessionRequest{
+		User:         user.GetName(),
+		RefreshToken: token,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, session.GetName(), renewed.GetName())
+	require.NotEqual(t, token, newToken)
+	require.Equal(t, session.GetLoginTime(), renewed.GetLoginTime())
+
+	// Replaying the rotated token revokes the whole chain and is audited.
+	_, _, err = s.a.RenewWebSession(ctx, RenewWebSessionRequest{
+		User:         user.GetName(),
+		RefreshToken: token,
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+	require.Equal(t, events.RefreshTokenReuseCode, s.mockEmitter.LastEvent().GetCode())
+
+	for _, sessionID := range []string{session.GetName(), renewed.GetName()} {
+		_, err = s.a.GetWebSession(ctx, types.GetWebSessionRequest{
+			User:      user.GetName(),
+			SessionID: sessionID,
+		})
+		require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+	}
+
+	// The still unrotated token went down with the chain too.
+	_, _, err = s.a.RenewWebSession(ctx, RenewWebSessionRequest{
+		User:         user.GetName(),
+		RefreshToken: newToken,
+	})
+	require.True(t, trace.IsAccessDenied(err), "expected AccessDenied, got %v", err)
+}
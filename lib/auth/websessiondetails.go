@@ -0,0 +1,126 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/api/utils/sshutils"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+// AssumedRequestDetails describes one access request assumed by a web
+// session: what it granted and until when.
+type AssumedRequestDetails struct {
+	// RequestID is the ID of the assumed access request.
+	RequestID string `json:"request_id"`
+	// Roles are the roles the request granted.
+	Roles []string `json:"roles,omitempty"`
+	// Resources are the specific resources the request granted, rendered
+	// in the same format tsh accepts.
+	Resources []string `json:"resources,omitempty"`
+	// Expires is when the elevated access ends.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// WebSessionDetails is a web session enriched with the access requests it
+// has assumed, so the UI can render elevation banners without parsing
+// certificates.
+type WebSessionDetails struct {
+	// Session is the web session stripped of secrets.
+	Session types.WebSession `json:"session"`
+	// AssumedRequests are the access requests currently applied to the
+	// session's certificates.
+	AssumedRequests []AssumedRequestDetails `json:"assumed_requests,omitempty"`
+	// Elevated is true when at least one access request is assumed.
+	Elevated bool `json:"elevated"`
+	// ElevatedUntil is the earliest expiry among the assumed requests,
+	// zero when the session is not elevated.
+	ElevatedUntil time.Time `json:"elevated_until,omitempty"`
+	// Metadata describes the client that established the session, nil when
+	// none was recorded.
+	Metadata *WebSessionMetadata `json:"metadata,omitempty"`
+}
+
+// GetWebSessionDetails returns the web session together with the details of
+// the access requests its certificates have assumed.
+func (a *Server) GetWebSessionDetails(ctx context.Context, user, sessionID string) (*WebSessionDetails, error) {
+	sess, err := a.GetWebSession(ctx, types.GetWebSessionRequest{User: user, SessionID: sessionID})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	details := &WebSessionDetails{
+		Session: sess.WithoutSecrets(),
+	}
+	if metadata, err := a.GetWebSessionMetadata(ctx, user, sessionID); err == nil {
+		details.Metadata = &metadata
+	}
+
+	cert, err := sshutils.ParseCertificate(sess.GetPub())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rawRequests, ok := cert.Extensions[teleport.CertExtensionTeleportActiveRequests]
+	if !ok || rawRequests == "" {
+		return details, nil
+	}
+	var requestIDs services.RequestIDs
+	if err := requestIDs.Unmarshal([]byte(rawRequests)); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	for _, requestID := range requestIDs.AccessRequests {
+		assumed := AssumedRequestDetails{RequestID: requestID}
+		requests, err := a.GetAccessRequests(ctx, types.AccessRequestFilter{ID: requestID})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		// a request deleted after assumption still shows up by ID, just
+		// without grant details
+		if len(requests) != 0 {
+			req := requests[0]
+			assumed.Roles = req.GetRoles()
+			assumed.Expires = req.GetAccessExpiry()
+			if resourceIDs := req.GetRequestedResourceIDs(); len(resourceIDs) != 0 {
+				for _, id := range resourceIDs {
+					assumed.Resources = append(assumed.Resources, types.ResourceIDToString(id))
+				}
+			}
+			if details.ElevatedUntil.IsZero() || assumed.Expires.Before(details.ElevatedUntil) {
+				details.ElevatedUntil = assumed.Expires
+			}
+		}
+		details.AssumedRequests = append(details.AssumedRequests, assumed)
+	}
+	details.Elevated = len(details.AssumedRequests) != 0
+	return details, nil
+}
+
+// GetWebSessionDetails returns the web session together with the details of
+// the access requests its certificates have assumed.
+func (a *ServerWithRoles) GetWebSessionDetails(ctx context.Context, user, sessionID string) (*WebSessionDetails, error) {
+	if err := a.currentUserAction(user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetWebSessionDetails(ctx, user, sessionID)
+}
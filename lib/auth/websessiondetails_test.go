@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+func TestGetWebSessionDetails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tt := setupAuthContext(ctx, t)
+
+	clt, err := tt.server.NewClient(TestAdmin())
+	require.NoError(t, err)
+
+	user := "details-user"
+	pass := []byte("abc123")
+
+	_, err = CreateUserRoleAndRequestable(clt, user, "details-request-role")
+	require.NoError(t, err)
+	err = clt.UpsertPassword(user, pass)
+	require.NoError(t, err)
+
+	proxy, err := tt.server.NewClient(TestBuiltin(types.RoleProxy))
+	require.NoError(t, err)
+
+	ws, err := proxy.AuthenticateWebUser(ctx, AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: pass},
+	})
+	require.NoError(t, err)
+
+	web, err := tt.server.NewClientFromWebSession(ws)
+	require.NoError(t, err)
+
+	// The initial session has not assumed anything.
+	details, err := web.GetWebSessionDetails(ctx, user, ws.GetName())
+	require.NoError(t, err)
+	require.False(t, details.Elevated)
+	require.Empty(t, details.AssumedRequests)
+	require.True(t, details.ElevatedUntil.IsZero())
+	require.Equal(t, ws.GetName(), details.Session.GetName())
+
+	// Assume an approved access request and verify it shows up.
+	accessReq, err := services.NewAccessRequest(user, "details-request-role")
+	require.NoError(t, err)
+	expiry := tt.clock.Now().UTC().Add(time.Minute * 10)
+	accessReq.SetAccessExpiry(expiry)
+	accessReq.SetState(types.RequestState_APPROVED)
+	err = clt.CreateAccessRequest(ctx, accessReq)
+	require.NoError(t, err)
+
+	sess, err := web.ExtendWebSession(ctx, WebSessionReq{
+		User:            user,
+		PrevSessionID:   ws.GetName(),
+		AccessRequestID: accessReq.GetName(),
+	})
+	require.NoError(t, err)
+
+	details, err = web.GetWebSessionDetails(ctx, user, sess.GetName())
+	require.NoError(t, err)
+	require.True(t, details.Elevated)
+	require.Len(t, details.AssumedRequests, 1)
+	require.Equal(t, accessReq.GetName(), details.AssumedRequests[0].RequestID)
+	require.Equal(t, []string{"details-request-role"}, details.AssumedRequests[0].Roles)
+	require.Equal(t, expiry, details.AssumedRequests[0].Expires)
+	require.Equal(t, expiry, details.ElevatedUntil)
+
+	// Another user may not read the session details.
+	otherUser := "details-other"
+	_, _, err = CreateUserAndRole(clt, otherUser, []string{otherUser})
+	require.NoError(t, err)
+	other, err := tt.server.NewClient(TestUser(otherUser))
+	require.NoError(t, err)
+	_, err = other.GetWebSessionDetails(ctx, user, sess.GetName())
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+const (
+	// webSessionActivityPrefix is the backend prefix last-activity records of
+	// web sessions are stored under.
+	webSessionActivityPrefix = "web_session_activity"
+
+	// webSessionActivityGrace keeps an activity record slightly past the
+	// session expiry, covering sessions that are renewed near the margin.
+	webSessionActivityGrace = time.Hour
+)
+
+// webSessionActivity records when a web session was last used.
+type webSessionActivity struct {
+	// LastActive is when the session was last fetched for a request.
+	LastActive time.Time `json:"last_active"`
+}
+
+func webSessionActivityKey(sessionID string) []byte {
+	return backend.Key(webSessionActivityPrefix, sessionID)
+}
+
+// idleWebSessions wraps a web session service, tracking last activity on
+// each session and invalidating sessions that sat idle longer than the
+// timeout stamped into them from the cluster networking configuration. The
+// check runs server-side so an abandoned browser session cannot be replayed
+// after the idle timeout, regardless of what the UI does.
+type idleWebSessions struct {
+	auth *Server
+	ws   types.WebSessionInterface
+}
+
+// Get returns the web session described by req, refusing sessions that have
+// exceeded their idle timeout and marking the session active otherwise.
+func (s *idleWebSessions) Get(ctx context.Context, req types.GetWebSessionRequest) (types.WebSession, error) {
+	session, err := s.ws.Get(ctx, req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	expired, err := s.checkIdle(ctx, session)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if expired {
+		if err := s.Delete(ctx, types.DeleteWebSessionRequest{
+			User:      session.GetUser(),
+			SessionID: session.GetName(),
+		}); err != nil && !trace.IsNotFound(err) {
+			log.WithError(err).Warn("Failed to delete idle web session.")
+		}
+		return nil, trace.NotFound("web session has expired due to inactivity")
+	}
+	if err := s.touch(ctx, session); err != nil {
+		log.WithError(err).Warn("Failed to record web session activity.")
+	}
+	return session, nil
+}
+
+// List returns all web sessions. Idle sessions are not filtered here, the
+// check belongs to the request path in Get.
+func (s *idleWebSessions) List(ctx context.Context) ([]types.WebSession, error) {
+	return s.ws.List(ctx)
+}
+
+// Upsert stores the web session and opens its activity window.
+func (s *idleWebSessions) Upsert(ctx context.Context, session types.WebSession) error {
+	if err := s.ws.Upsert(ctx, session); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := s.touch(ctx, session); err != nil {
+		log.WithError(err).Warn("Failed to record web session activity.")
+	}
+	return nil
+}
+
+// Delete deletes the web session described by req along with its activity
+// record.
+func (s *idleWebSessions) Delete(ctx context.Context, req types.DeleteWebSessionRequest) error {
+	if err := s.auth.bk.Delete(ctx, webSessionActivityKey(req.SessionID)); err != nil && !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to delete web session activity record.")
+	}
+	return trace.Wrap(s.ws.Delete(ctx, req))
+}
+
+// DeleteAll removes all web sessions and their activity records.
+func (s *idleWebSessions) DeleteAll(ctx context.Context) error {
+	startKey := backend.Key(webSessionActivityPrefix)
+	if err := s.auth.bk.DeleteRange(ctx, startKey, backend.RangeEnd(startKey)); err != nil && !trace.IsNotFound(err) {
+		log.WithError(err).Warn("Failed to delete web session activity records.")
+	}
+	return trace.Wrap(s.ws.DeleteAll(ctx))
+}
+
+// checkIdle reports whether the session has been inactive longer than its
+// idle timeout.
+func (s *idleWebSessions) checkIdle(ctx context.Context, session types.WebSession) (bool, error) {
+	idleTimeout := session.GetIdleTimeout()
+	if idleTimeout <= 0 {
+		return false, nil
+	}
+	lastActive := session.GetLoginTime()
+	item, err := s.auth.bk.Get(ctx, webSessionActivityKey(session.GetName()))
+	if err == nil {
+		var activity webSessionActivity
+		if err := utils.FastUnmarshal(item.Value, &activity); err != nil {
+			return false, trace.Wrap(err)
+		}
+		lastActive = activity.LastActive
+	} else if !trace.IsNotFound(err) {
+		return false, trace.Wrap(err)
+	}
+	if lastActive.IsZero() {
+		// Nothing to measure idleness against yet, the touch in Get opens
+		// the window.
+		return false, nil
+	}
+	return s.auth.clock.Now().After(lastActive.Add(idleTimeout)), nil
+}
+
+// touch stamps the session as active now. The record expires shortly after
+// the session itself, so sessions that lapse through their natural TTL do
+// not orphan their activity records.
+func (s *idleWebSessions) touch(ctx context.Context, session types.WebSession) error {
+	value, err := utils.FastMarshal(webSessionActivity{
+		LastActive: s.auth.clock.Now().UTC(),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var expires time.Time
+	if exp := session.GetExpiryTime(); !exp.IsZero() {
+		expires = exp.Add(webSessionActivityGrace)
+	}
+	_, err = s.auth.bk.Put(ctx, backend.Item{
+		Key:     webSessionActivityKey(session.GetName()),
+		Value:   value,
+		Expires: expires,
+	})
+	return trace.Wrap(err)
+}
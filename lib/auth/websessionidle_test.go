@@ -0,0 +1,110 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestWebSessionIdleTimeout(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(fakeClock)
+
+	netCfg := types.DefaultClusterNetworkingConfig()
+	netCfg.SetWebIdleTimeout(10 * time.Minute)
+	require.NoError(t, s.a.SetClusterNetworkingConfig(ctx, netCfg))
+
+	user, _, err := CreateUserAndRole(s.a, "idle-session-user", []string{"idle-session-user"})
+	require.NoError(t, err)
+
+	session, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:       user.GetName(),
+		Roles:      user.GetRoles(),
+		Traits:     user.GetTraits(),
+		LoginTime:  fakeClock.Now().UTC(),
+		SessionTTL: apidefaults.CertDuration,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, session.GetIdleTimeout())
+	require.NoError(t, s.a.upsertWebSession(ctx, user.GetName(), session))
+
+	getReq := types.GetWebSessionRequest{
+		User:      user.GetName(),
+		SessionID: session.GetName(),
+	}
+
+	// Activity within the timeout keeps the session alive well past a
+	// single idle window.
+	for i := 0; i < 3; i++ {
+		fakeClock.Advance(9 * time.Minute)
+		_, err = s.a.GetWebSession(ctx, getReq)
+		require.NoError(t, err)
+	}
+
+	// Once the session sits idle past the timeout it is invalidated
+	// server-side and removed from the backend.
+	fakeClock.Advance(11 * time.Minute)
+	_, err = s.a.GetWebSession(ctx, getReq)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+	_, err = s.a.Services.GetWebSession(ctx, getReq)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestWebSessionWithoutIdleTimeout(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(fakeClock)
+
+	// The default networking configuration does not set an idle timeout.
+	require.NoError(t, s.a.SetClusterNetworkingConfig(ctx, types.DefaultClusterNetworkingConfig()))
+
+	user, _, err := CreateUserAndRole(s.a, "no-idle-user", []string{"no-idle-user"})
+	require.NoError(t, err)
+
+	session, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+		User:       user.GetName(),
+		Roles:      user.GetRoles(),
+		Traits:     user.GetTraits(),
+		LoginTime:  fakeClock.Now().UTC(),
+		SessionTTL: apidefaults.CertDuration,
+	})
+	require.NoError(t, err)
+	require.Zero(t, session.GetIdleTimeout())
+	require.NoError(t, s.a.upsertWebSession(ctx, user.GetName(), session))
+
+	// Long gaps do not invalidate sessions without an idle timeout.
+	fakeClock.Advance(6 * time.Hour)
+	_, err = s.a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      user.GetName(),
+		SessionID: session.GetName(),
+	})
+	require.NoError(t, err)
+}
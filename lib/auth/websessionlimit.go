@@ -0,0 +1,203 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// webSessionLimitPrefix is the backend key the web session limit policy
+// singleton is stored under.
+const webSessionLimitPrefix = "web_session_limit_policy"
+
+// WebSessionLimitPolicy caps the number of simultaneously active web
+// sessions a user may hold.
+type WebSessionLimitPolicy struct {
+	// MaxSessions is the number of active web sessions a user may hold at
+	// once, zero disables the limit.
+	MaxSessions int `json:"max_sessions,omitempty"`
+	// EvictOldest makes a new login evict the oldest session of the user
+	// instead of being rejected when the limit is reached.
+	EvictOldest bool `json:"evict_oldest,omitempty"`
+}
+
+// CheckAndSetDefaults validates the policy.
+func (p *WebSessionLimitPolicy) CheckAndSetDefaults() error {
+	if p.MaxSessions < 0 {
+		return trace.BadParameter("web session limit must not be negative, got %v", p.MaxSessions)
+	}
+	return nil
+}
+
+// SetWebSessionLimitPolicy configures the web session limit of the cluster.
+func (a *Server) SetWebSessionLimitPolicy(ctx context.Context, policy WebSessionLimitPolicy) error {
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(policy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(ctx, backend.Item{
+		Key:   backend.Key(webSessionLimitPrefix),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetWebSessionLimitPolicy returns the configured web session limit, with
+// the limit disabled when none was set.
+func (a *Server) GetWebSessionLimitPolicy(ctx context.Context) (WebSessionLimitPolicy, error) {
+	item, err := a.bk.Get(ctx, backend.Key(webSessionLimitPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return WebSessionLimitPolicy{}, nil
+		}
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	var policy WebSessionLimitPolicy
+	if err := utils.FastUnmarshal(item.Value, &policy); err != nil {
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	if err := policy.CheckAndSetDefaults(); err != nil {
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	return policy, nil
+}
+
+// DeleteWebSessionLimitPolicy disables the web session limit.
+func (a *Server) DeleteWebSessionLimitPolicy(ctx context.Context) error {
+	if err := a.bk.Delete(ctx, backend.Key(webSessionLimitPrefix)); err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("web session limit policy is not set")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// enforceWebSessionLimit applies the web session limit before a new web
+// session is created for the user. Sessions carrying excludeSessionID are
+// not counted, which lets session extension replace a session without
+// tripping the limit. Depending on the policy the oldest sessions are
+// evicted or the new login is rejected.
+func (a *Server) enforceWebSessionLimit(ctx context.Context, user, excludeSessionID string) error {
+	policy, err := a.GetWebSessionLimitPolicy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.MaxSessions == 0 {
+		return nil
+	}
+	sessions, err := a.Services.WebSessions().List(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	now := a.clock.Now()
+	active := make([]types.WebSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.GetUser() != user || session.GetName() == excludeSessionID {
+			continue
+		}
+		if expires := session.GetExpiryTime(); !expires.IsZero() && expires.Before(now) {
+			continue
+		}
+		active = append(active, session)
+	}
+	if len(active) < policy.MaxSessions {
+		return nil
+	}
+	if !policy.EvictOldest {
+		if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.SessionReject{
+			Metadata: apievents.Metadata{
+				Type: events.SessionRejectedEvent,
+				Code: events.SessionRejectedCode,
+			},
+			UserMetadata: apievents.UserMetadata{
+				User: user,
+			},
+			Reason:  events.SessionRejectedReasonMaxWebSessions,
+			Maximum: int64(policy.MaxSessions),
+		}); err != nil {
+			log.WithError(err).Warn("Failed to emit session reject event.")
+		}
+		return trace.LimitExceeded("too many concurrent web sessions, log out of another session and try again")
+	}
+	// Evict oldest sessions first until the new session fits the limit.
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].GetLoginTime().Before(active[j].GetLoginTime())
+	})
+	for len(active) >= policy.MaxSessions {
+		evicted := active[0]
+		active = active[1:]
+		if err := a.WebSessions().Delete(ctx, types.DeleteWebSessionRequest{
+			User:      user,
+			SessionID: evicted.GetName(),
+		}); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if err := a.emitter.EmitAuditEvent(a.closeCtx, &apievents.SessionReject{
+			Metadata: apievents.Metadata{
+				Type: events.SessionRejectedEvent,
+				Code: events.WebSessionEvictedCode,
+			},
+			UserMetadata: apievents.UserMetadata{
+				User: user,
+			},
+			Reason:  events.SessionRejectedReasonWebSessionEvicted,
+			Maximum: int64(policy.MaxSessions),
+		}); err != nil {
+			log.WithError(err).Warn("Failed to emit web session eviction event.")
+		}
+	}
+	return nil
+}
+
+// SetWebSessionLimitPolicy configures the web session limit of the cluster.
+func (a *ServerWithRoles) SetWebSessionLimitPolicy(ctx context.Context, policy WebSessionLimitPolicy) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbCreate, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.SetWebSessionLimitPolicy(ctx, policy)
+}
+
+// GetWebSessionLimitPolicy returns the configured web session limit, with
+// the limit disabled when none was set.
+func (a *ServerWithRoles) GetWebSessionLimitPolicy(ctx context.Context) (WebSessionLimitPolicy, error) {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbRead); err != nil {
+		return WebSessionLimitPolicy{}, trace.Wrap(err)
+	}
+	return a.authServer.GetWebSessionLimitPolicy(ctx)
+}
+
+// DeleteWebSessionLimitPolicy disables the web session limit.
+func (a *ServerWithRoles) DeleteWebSessionLimitPolicy(ctx context.Context) error {
+	if err := a.action(apidefaults.Namespace, types.KindClusterAuthPreference, types.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteWebSessionLimitPolicy(ctx)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/events"
+)
+
+func TestWebSessionLimitPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	// Without a stored policy the limit is disabled.
+	policy, err := s.a.GetWebSessionLimitPolicy(ctx)
+	require.NoError(t, err)
+	require.Zero(t, policy.MaxSessions)
+
+	err = s.a.SetWebSessionLimitPolicy(ctx, WebSessionLimitPolicy{MaxSessions: -1})
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	require.NoError(t, s.a.SetWebSessionLimitPolicy(ctx, WebSessionLimitPolicy{MaxSessions: 3, EvictOldest: true}))
+	policy, err = s.a.GetWebSessionLimitPolicy(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, policy.MaxSessions)
+	require.True(t, policy.EvictOldest)
+
+	require.NoError(t, s.a.DeleteWebSessionLimitPolicy(ctx))
+	err = s.a.DeleteWebSessionLimitPolicy(ctx)
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
+
+func TestWebSessionLimitEnforcement(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+	fakeClock := clockwork.NewFakeClockAt(time.Now())
+	s.a.SetClock(fakeClock)
+
+	user, _, err := CreateUserAndRole(s.a, "session-limit-user", []string{"session-limit-user"})
+	require.NoError(t, err)
+
+	newSession := func() types.WebSession {
+		session, err := s.a.NewWebSession(ctx, types.NewWebSessionRequest{
+			User:       user.GetName(),
+			Roles:      user.GetRoles(),
+			Traits:     user.GetTraits(),
+			LoginTime:  fakeClock.Now().UTC(),
+			SessionTTL: apidefaults.CertDuration,
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.a.upsertWebSession(ctx, user.GetName(), session))
+		// keep login times apart so the eviction order is deterministic
+		fakeClock.Advance(time.Minute)
+		return session
+	}
+	oldest := newSession()
+	newSession()
+
+	// Without a policy any number of sessions is allowed.
+	require.NoError(t, s.a.enforceWebSessionLimit(ctx, user.GetName(), ""))
+
+	// At the limit new logins are rejected and the rejection is audited.
+	require.NoError(t, s.a.SetWebSessionLimitPolicy(ctx, WebSessionLimitPolicy{MaxSessions: 2}))
+	err = s.a.enforceWebSessionLimit(ctx, user.GetName(), "")
+	require.True(t, trace.IsLimitExceeded(err), "expected LimitExceeded, got %v", err)
+	require.Equal(t, events.SessionRejectedCode, s.mockEmitter.LastEvent().GetCode())
+
+	// Sessions being replaced are excluded, extension does not trip the
+	// limit.
+	require.NoError(t, s.a.enforceWebSessionLimit(ctx, user.GetName(), oldest.GetName()))
+
+	// With eviction enabled the oldest session is logged out instead.
+	require.NoError(t, s.a.SetWebSessionLimitPolicy(ctx, WebSessionLimitPolicy{MaxSessions: 2, EvictOldest: true}))
+	require.NoError(t, s.a.enforceWebSessionLimit(ctx, user.GetName(), ""))
+	require.Equal(t, events.WebSessionEvictedCode, s.mockEmitter.LastEvent().GetCode())
+	_, err = s.a.GetWebSession(ctx, types.GetWebSessionRequest{
+		User:      user.GetName(),
+		SessionID: oldest.GetName(),
+	})
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// webSessionMetadataPrefix is the backend key prefix the per-session client
+// metadata records are stored under.
+const webSessionMetadataPrefix = "web_session_metadata"
+
+// WebSessionMetadata describes the client that established a web session, so
+// users and admins can answer "where am I logged in". The web session proto
+// cannot carry these fields, they are kept in a sidecar record that expires
+// with the session.
+type WebSessionMetadata struct {
+	// User is the user the session belongs to.
+	User string `json:"user"`
+	// SessionID is the ID of the web session.
+	SessionID string `json:"session_id"`
+	// IP is the client IP the session was established from, empty when the
+	// proxy did not forward it.
+	IP string `json:"ip,omitempty"`
+	// UserAgent is the client user agent, empty when not forwarded.
+	UserAgent string `json:"user_agent,omitempty"`
+	// LoginMethod is how the user logged in, one of the events.LoginMethod*
+	// constants.
+	LoginMethod string `json:"login_method"`
+	// LoginTime is when the session was established.
+	LoginTime time.Time `json:"login_time"`
+}
+
+// recordWebSessionMetadata stores the client metadata of a freshly created
+// web session. It is best effort, a login never fails because its metadata
+// could not be recorded.
+func (a *Server) recordWebSessionMetadata(ctx context.Context, sess types.WebSession, method string, md *ForwardedClientMetadata) {
+	record := WebSessionMetadata{
+		User:        sess.GetUser(),
+		SessionID:   sess.GetName(),
+		LoginMethod: method,
+		LoginTime:   sess.GetLoginTime(),
+	}
+	if record.LoginTime.IsZero() {
+		record.LoginTime = a.clock.Now().UTC()
+	}
+	if md != nil {
+		record.IP = md.RemoteAddr
+		if host, err := utils.Host(md.RemoteAddr); err == nil {
+			record.IP = host
+		}
+		record.UserAgent = md.UserAgent
+	}
+	value, err := utils.FastMarshal(record)
+	if err != nil {
+		log.WithError(err).Warn("Failed to marshal web session metadata.")
+		return
+	}
+	if _, err := a.bk.Put(ctx, backend.Item{
+		Key:     backend.Key(webSessionMetadataPrefix, record.User, record.SessionID),
+		Value:   value,
+		Expires: sess.GetExpiryTime(),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to record web session metadata.")
+	}
+}
+
+// GetWebSessionMetadata returns the client metadata recorded for the web
+// session, if any was.
+func (a *Server) GetWebSessionMetadata(ctx context.Context, user, sessionID string) (WebSessionMetadata, error) {
+	item, err := a.bk.Get(ctx, backend.Key(webSessionMetadataPrefix, user, sessionID))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return WebSessionMetadata{}, trace.NotFound("no metadata recorded for session %q", sessionID)
+		}
+		return WebSessionMetadata{}, trace.Wrap(err)
+	}
+	var record WebSessionMetadata
+	if err := utils.FastUnmarshal(item.Value, &record); err != nil {
+		return WebSessionMetadata{}, trace.Wrap(err)
+	}
+	return record, nil
+}
+
+// GetWebSessionsMetadata returns the client metadata recorded for all of the
+// user's web sessions.
+func (a *Server) GetWebSessionsMetadata(ctx context.Context, user string) ([]WebSessionMetadata, error) {
+	startKey := backend.Key(webSessionMetadataPrefix, user)
+	result, err := a.bk.GetRange(ctx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var records []WebSessionMetadata
+	for _, item := range result.Items {
+		var record WebSessionMetadata
+		if err := utils.FastUnmarshal(item.Value, &record); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// GetWebSessionsMetadata returns the client metadata recorded for all of the
+// user's web sessions. Users may list their own sessions, listing other
+// users' sessions requires read access to users.
+func (a *ServerWithRoles) GetWebSessionsMetadata(ctx context.Context, user string) ([]WebSessionMetadata, error) {
+	if err := a.currentUserAction(user); err != nil {
+		if err := a.action(apidefaults.Namespace, types.KindUser, types.VerbRead, types.VerbList); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return a.authServer.GetWebSessionsMetadata(ctx, user)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/events"
+)
+
+func TestWebSessionMetadata(t *testing.T) {
+	t.Parallel()
+	s := newAuthSuite(t)
+	ctx := context.Background()
+
+	user := "metadata-user"
+	password := []byte("correctpassword")
+	_, _, err := CreateUserAndRole(s.a, user, []string{user})
+	require.NoError(t, err)
+	require.NoError(t, s.a.UpsertPassword(user, password))
+
+	// A login with forwarded client metadata records it on the session.
+	sess, err := s.a.AuthenticateWebUser(ctx, AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: password},
+		ClientMetadata: &ForwardedClientMetadata{
+			UserAgent:  "Mozilla/5.0",
+			RemoteAddr: "10.0.0.7:50000",
+		},
+	})
+	require.NoError(t, err)
+
+	record, err := s.a.GetWebSessionMetadata(ctx, user, sess.GetName())
+	require.NoError(t, err)
+	require.Equal(t, user, record.User)
+	require.Equal(t, sess.GetName(), record.SessionID)
+	require.Equal(t, "10.0.0.7", record.IP)
+	require.Equal(t, "Mozilla/5.0", record.UserAgent)
+	require.Equal(t, events.LoginMethodLocal, record.LoginMethod)
+	require.False(t, record.LoginTime.IsZero())
+
+	// The session details expose the recorded metadata.
+	details, err := s.a.GetWebSessionDetails(ctx, user, sess.GetName())
+	require.NoError(t, err)
+	require.NotNil(t, details.Metadata)
+	require.Equal(t, record, *details.Metadata)
+
+	// A login without forwarded metadata still records the method.
+	sess2, err := s.a.AuthenticateWebUser(ctx, AuthenticateUserRequest{
+		Username: user,
+		Pass:     &PassCreds{Password: password},
+	})
+	require.NoError(t, err)
+	record2, err := s.a.GetWebSessionMetadata(ctx, user, sess2.GetName())
+	require.NoError(t, err)
+	require.Empty(t, record2.IP)
+	require.Empty(t, record2.UserAgent)
+	require.Equal(t, events.LoginMethodLocal, record2.LoginMethod)
+
+	// The listing returns both sessions.
+	records, err := s.a.GetWebSessionsMetadata(ctx, user)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	// Sessions that never recorded metadata return NotFound.
+	_, err = s.a.GetWebSessionMetadata(ctx, user, "no-such-session")
+	require.True(t, trace.IsNotFound(err), "expected NotFound, got %v", err)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/jwt"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// workloadIdentityTokenDefaultTTL is the lifetime of a workload identity
+	// token when the caller does not request one.
+	workloadIdentityTokenDefaultTTL = time.Hour
+	// workloadIdentityTokenMaxTTL caps the lifetime a caller may request.
+	// Cloud identity federation exchanges the token for short-lived
+	// credentials on every use, so there is no reason to mint long-lived
+	// tokens.
+	workloadIdentityTokenMaxTTL = 24 * time.Hour
+)
+
+// WorkloadIdentityTokenRequest is a request to mint an OIDC ID token for the
+// calling identity, to be exchanged for cloud credentials via identity
+// federation (e.g. AWS AssumeRoleWithWebIdentity or a GCP workload identity
+// pool).
+type WorkloadIdentityTokenRequest struct {
+	// Audience is the intended consumer of the token, e.g.
+	// "sts.amazonaws.com". Required.
+	Audience string `json:"audience"`
+	// TTL is the requested token lifetime. Optional, defaults to one hour.
+	TTL time.Duration `json:"ttl"`
+}
+
+// CheckAndSetDefaults validates the request and fills in defaults.
+func (r *WorkloadIdentityTokenRequest) CheckAndSetDefaults() error {
+	if strings.TrimSpace(r.Audience) == "" {
+		return trace.BadParameter("audience is required")
+	}
+	if r.TTL == 0 {
+		r.TTL = workloadIdentityTokenDefaultTTL
+	}
+	if r.TTL < 0 || r.TTL > workloadIdentityTokenMaxTTL {
+		return trace.BadParameter("requested token TTL must be between 0 and %v", workloadIdentityTokenMaxTTL)
+	}
+	return nil
+}
+
+// WorkloadIdentityTokenResponse contains a signed workload identity token.
+type WorkloadIdentityTokenResponse struct {
+	// Token is the signed OIDC ID token.
+	Token string `json:"token"`
+	// Expires is the expiry time of the token.
+	Expires time.Time `json:"expires"`
+}
+
+// generateWorkloadIdentityToken mints an ID token for the given identity,
+// signed by the cluster's JWT CA under the public issuer URL served by the
+// proxy, so external verifiers can validate it against the published JWKS.
+func (a *Server) generateWorkloadIdentityToken(ctx context.Context, username string, roles []string, req WorkloadIdentityTokenRequest) (*WorkloadIdentityTokenResponse, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	issuer, err := a.workloadIdentityIssuer()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	clusterName, err := a.GetDomainName()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ca, err := a.GetCertAuthority(ctx, types.CertAuthID{
+		Type:       types.JWTSigner,
+		DomainName: clusterName,
+	}, true)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	signer, err := a.GetKeyStore().GetJWTSigner(ca)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	privateKey, err := services.GetJWTSigner(signer, ca.GetClusterName(), a.clock)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	expires := a.clock.Now().Add(req.TTL)
+	token, err := privateKey.SignIDToken(jwt.SignParams{
+		Username: username,
+		Roles:    roles,
+		URI:      req.Audience,
+		Expires:  expires,
+	}, issuer)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &WorkloadIdentityTokenResponse{
+		Token:   token,
+		Expires: expires,
+	}, nil
+}
+
+// workloadIdentityIssuer returns the issuer URL embedded in workload
+// identity tokens. It must match the issuer in the discovery document
+// served by the proxy, so it is derived from the proxy public address the
+// same way.
+func (a *Server) workloadIdentityIssuer() (string, error) {
+	proxies, err := a.GetProxies()
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var proxyAddr string
+	for _, proxy := range proxies {
+		if addr := proxy.GetPublicAddr(); addr != "" {
+			proxyAddr = addr
+			break
+		}
+	}
+	if proxyAddr == "" {
+		return "", trace.NotFound("no proxy with a public address is registered in the cluster")
+	}
+	proxyAddr = strings.TrimPrefix(strings.TrimPrefix(proxyAddr, "https://"), "http://")
+	return "https://" + strings.TrimSuffix(proxyAddr, "/"), nil
+}
+
+// GenerateWorkloadIdentityToken mints an OIDC ID token for the calling
+// identity. The subject is always the caller's own identity, so no
+// additional permission checks are needed: the token asserts nothing the
+// caller's certificate does not already assert, just in a format cloud
+// identity federation endpoints understand.
+func (a *ServerWithRoles) GenerateWorkloadIdentityToken(ctx context.Context, req WorkloadIdentityTokenRequest) (*WorkloadIdentityTokenResponse, error) {
+	identity := a.context.Identity.GetIdentity()
+	resp, err := a.authServer.generateWorkloadIdentityToken(ctx, a.context.User.GetName(), identity.Groups, req)
+	return resp, trace.Wrap(err)
+}
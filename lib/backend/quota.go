@@ -0,0 +1,284 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaWarningThreshold is the fraction of the item quota at which a
+// near-limit warning is logged.
+const quotaWarningThreshold = 0.9
+
+// quotaWarningInterval throttles repeated near-limit warnings for the same
+// prefix.
+const quotaWarningInterval = 5 * time.Minute
+
+// Quota limits how much a single key prefix may grow.
+type Quota struct {
+	// Prefix is the top-level key prefix the quota applies to, e.g.
+	// "tokens" or "locks".
+	Prefix string `json:"prefix"`
+	// MaxItems caps the number of items stored under the prefix. Zero
+	// means unlimited.
+	MaxItems int `json:"max_items"`
+	// MaxItemSize caps the value size in bytes of a single item stored
+	// under the prefix. Zero means unlimited.
+	MaxItemSize int `json:"max_item_size"`
+}
+
+// DefaultQuotas returns quotas for the kinds that are written by automated
+// controllers and can realistically flood the backend.
+func DefaultQuotas() []Quota {
+	return []Quota{
+		{Prefix: "cluster-alerts", MaxItems: 1000, MaxItemSize: 64 * 1024},
+		{Prefix: "tokens", MaxItems: 5000, MaxItemSize: 64 * 1024},
+		{Prefix: "locks", MaxItems: 5000, MaxItemSize: 64 * 1024},
+	}
+}
+
+// QuotaGuardConfig configures the quota wrapper.
+type QuotaGuardConfig struct {
+	// Backend is a backend to wrap.
+	Backend Backend
+	// Quotas are the per-prefix limits to enforce. When empty,
+	// DefaultQuotas are used.
+	Quotas []Quota
+}
+
+// CheckAndSetDefaults checks and sets defaults.
+func (c *QuotaGuardConfig) CheckAndSetDefaults() error {
+	if c.Backend == nil {
+		return trace.BadParameter("missing parameter Backend")
+	}
+	if len(c.Quotas) == 0 {
+		c.Quotas = DefaultQuotas()
+	}
+	for _, quota := range c.Quotas {
+		if quota.Prefix == "" {
+			return trace.BadParameter("backend quota is missing a prefix")
+		}
+		if quota.MaxItems < 0 || quota.MaxItemSize < 0 {
+			return trace.BadParameter("backend quota for %q has a negative limit", quota.Prefix)
+		}
+	}
+	return nil
+}
+
+// quotaState tracks one enforced quota together with its warning throttle.
+type quotaState struct {
+	Quota
+	// keyPrefix is the raw key prefix the quota matches,
+	// e.g. "/tokens/".
+	keyPrefix []byte
+
+	mu          sync.Mutex
+	lastWarning time.Time
+}
+
+// QuotaGuard wraps a Backend implementation and rejects writes that would
+// push a key prefix over its configured item-count or item-size quota, so a
+// misbehaving controller can not flood the backend.
+type QuotaGuard struct {
+	backend Backend
+	quotas  []*quotaState
+}
+
+// NewQuotaGuard returns a new QuotaGuard.
+func NewQuotaGuard(cfg QuotaGuardConfig) (*QuotaGuard, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	g := &QuotaGuard{
+		backend: cfg.Backend,
+	}
+	for _, quota := range cfg.Quotas {
+		g.quotas = append(g.quotas, &quotaState{
+			Quota:     quota,
+			keyPrefix: append(Key(quota.Prefix), Separator),
+		})
+	}
+	return g, nil
+}
+
+// match returns the quota covering the key, or nil when the key is not
+// subject to one.
+func (g *QuotaGuard) match(key []byte) *quotaState {
+	for _, quota := range g.quotas {
+		if bytes.HasPrefix(key, quota.keyPrefix) {
+			return quota
+		}
+	}
+	return nil
+}
+
+// checkSize rejects values larger than the per-item size quota.
+func (g *QuotaGuard) checkSize(quota *quotaState, i Item) error {
+	if quota == nil || quota.MaxItemSize == 0 {
+		return nil
+	}
+	if len(i.Value) > quota.MaxItemSize {
+		return trace.LimitExceeded(
+			"item %q exceeds the %v byte size quota for %q items",
+			i.Key, quota.MaxItemSize, quota.Prefix)
+	}
+	return nil
+}
+
+// checkCount rejects a write that would push the prefix over its item-count
+// quota and logs a warning when the prefix is close to it.
+func (g *QuotaGuard) checkCount(ctx context.Context, quota *quotaState, key []byte) error {
+	if quota == nil || quota.MaxItems == 0 {
+		return nil
+	}
+	startKey := Key(quota.Prefix)
+	result, err := g.backend.GetRange(ctx, startKey, RangeEnd(startKey), quota.MaxItems)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	count := len(result.Items)
+	if count >= quota.MaxItems {
+		return trace.LimitExceeded(
+			"%q already holds %v items which is its configured quota, refusing to create %q; delete unused items or raise the quota",
+			quota.Prefix, quota.MaxItems, key)
+	}
+	if float64(count+1) >= quotaWarningThreshold*float64(quota.MaxItems) {
+		g.warnNearLimit(quota, count+1)
+	}
+	return nil
+}
+
+// warnNearLimit logs a throttled warning that the prefix is approaching its
+// quota.
+func (g *QuotaGuard) warnNearLimit(quota *quotaState, count int) {
+	now := g.backend.Clock().Now()
+	quota.mu.Lock()
+	defer quota.mu.Unlock()
+	if now.Sub(quota.lastWarning) < quotaWarningInterval {
+		return
+	}
+	quota.lastWarning = now
+	log.Warnf("Backend prefix %q holds %v of at most %v items and is close to its quota, new writes will be rejected once it is reached.",
+		quota.Prefix, count, quota.MaxItems)
+}
+
+// GetRange returns query range
+func (g *QuotaGuard) GetRange(ctx context.Context, startKey []byte, endKey []byte, limit int) (*GetResult, error) {
+	return g.backend.GetRange(ctx, startKey, endKey, limit)
+}
+
+// Create creates item if it does not exist
+func (g *QuotaGuard) Create(ctx context.Context, i Item) (*Lease, error) {
+	quota := g.match(i.Key)
+	if err := g.checkSize(quota, i); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := g.checkCount(ctx, quota, i.Key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return g.backend.Create(ctx, i)
+}
+
+// Put puts value into backend (creates if it does not
+// exists, updates it otherwise)
+func (g *QuotaGuard) Put(ctx context.Context, i Item) (*Lease, error) {
+	quota := g.match(i.Key)
+	if err := g.checkSize(quota, i); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// overwriting an existing item does not grow the prefix, only check
+	// the count when the item is new
+	if quota != nil && quota.MaxItems != 0 {
+		if _, err := g.backend.Get(ctx, i.Key); err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			if err := g.checkCount(ctx, quota, i.Key); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+	}
+	return g.backend.Put(ctx, i)
+}
+
+// Update updates value in the backend
+func (g *QuotaGuard) Update(ctx context.Context, i Item) (*Lease, error) {
+	if err := g.checkSize(g.match(i.Key), i); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return g.backend.Update(ctx, i)
+}
+
+// Get returns a single item or not found error
+func (g *QuotaGuard) Get(ctx context.Context, key []byte) (*Item, error) {
+	return g.backend.Get(ctx, key)
+}
+
+// CompareAndSwap compares item with existing item
+// and replaces is with replaceWith item
+func (g *QuotaGuard) CompareAndSwap(ctx context.Context, expected Item, replaceWith Item) (*Lease, error) {
+	if err := g.checkSize(g.match(replaceWith.Key), replaceWith); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return g.backend.CompareAndSwap(ctx, expected, replaceWith)
+}
+
+// Delete deletes item by key
+func (g *QuotaGuard) Delete(ctx context.Context, key []byte) error {
+	return g.backend.Delete(ctx, key)
+}
+
+// DeleteRange deletes range of items
+func (g *QuotaGuard) DeleteRange(ctx context.Context, startKey []byte, endKey []byte) error {
+	return g.backend.DeleteRange(ctx, startKey, endKey)
+}
+
+// KeepAlive keeps object from expiring, updates lease on the existing object,
+// expires contains the new expiry to set on the lease,
+// some backends may ignore expires based on the implementation
+// in case if the lease managed server side
+func (g *QuotaGuard) KeepAlive(ctx context.Context, lease Lease, expires time.Time) error {
+	return g.backend.KeepAlive(ctx, lease, expires)
+}
+
+// NewWatcher returns a new event watcher
+func (g *QuotaGuard) NewWatcher(ctx context.Context, watch Watch) (Watcher, error) {
+	return g.backend.NewWatcher(ctx, watch)
+}
+
+// Close releases the resources taken up by this backend
+func (g *QuotaGuard) Close() error {
+	return g.backend.Close()
+}
+
+// Clock returns clock used by this backend
+func (g *QuotaGuard) Clock() clockwork.Clock {
+	return g.backend.Clock()
+}
+
+// CloseWatchers closes all the watchers
+// without closing the backend
+func (g *QuotaGuard) CloseWatchers() {
+	g.backend.CloseWatchers()
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+// mapBackend is a minimal in-process backend for exercising the quota
+// wrapper; the real memory backend can not be imported here without an
+// import cycle.
+type mapBackend struct {
+	nopBackend
+	items map[string][]byte
+}
+
+func newMapBackend() *mapBackend {
+	return &mapBackend{items: make(map[string][]byte)}
+}
+
+func (m *mapBackend) Get(_ context.Context, key []byte) (*Item, error) {
+	value, ok := m.items[string(key)]
+	if !ok {
+		return nil, trace.NotFound("key %q is not found", key)
+	}
+	return &Item{Key: key, Value: value}, nil
+}
+
+func (m *mapBackend) GetRange(_ context.Context, startKey []byte, endKey []byte, limit int) (*GetResult, error) {
+	var result GetResult
+	keys := make([]string, 0, len(m.items))
+	for key := range m.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if bytes.Compare([]byte(key), startKey) >= 0 && bytes.Compare([]byte(key), endKey) <= 0 {
+			result.Items = append(result.Items, Item{Key: []byte(key), Value: m.items[key]})
+			if limit != NoLimit && len(result.Items) >= limit {
+				break
+			}
+		}
+	}
+	return &result, nil
+}
+
+func (m *mapBackend) Create(ctx context.Context, i Item) (*Lease, error) {
+	if _, ok := m.items[string(i.Key)]; ok {
+		return nil, trace.AlreadyExists("key %q already exists", i.Key)
+	}
+	return m.Put(ctx, i)
+}
+
+func (m *mapBackend) Put(_ context.Context, i Item) (*Lease, error) {
+	m.items[string(i.Key)] = i.Value
+	return &Lease{}, nil
+}
+
+func TestQuotaGuardItemCount(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	guard, err := NewQuotaGuard(QuotaGuardConfig{
+		Backend: newMapBackend(),
+		Quotas:  []Quota{{Prefix: "tokens", MaxItems: 3}},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := guard.Create(ctx, Item{Key: Key("tokens", string(rune('a'+i))), Value: []byte("v")})
+		require.NoError(t, err)
+	}
+
+	// the prefix is full, both Create and a Put of a new key are rejected
+	_, err = guard.Create(ctx, Item{Key: Key("tokens", "d"), Value: []byte("v")})
+	require.True(t, trace.IsLimitExceeded(err))
+	_, err = guard.Put(ctx, Item{Key: Key("tokens", "d"), Value: []byte("v")})
+	require.True(t, trace.IsLimitExceeded(err))
+
+	// overwriting an existing item is still allowed
+	_, err = guard.Put(ctx, Item{Key: Key("tokens", "a"), Value: []byte("v2")})
+	require.NoError(t, err)
+
+	// other prefixes are not affected
+	_, err = guard.Create(ctx, Item{Key: Key("nodes", "d"), Value: []byte("v")})
+	require.NoError(t, err)
+}
+
+func TestQuotaGuardItemSize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	guard, err := NewQuotaGuard(QuotaGuardConfig{
+		Backend: newMapBackend(),
+		Quotas:  []Quota{{Prefix: "locks", MaxItemSize: 4}},
+	})
+	require.NoError(t, err)
+
+	_, err = guard.Create(ctx, Item{Key: Key("locks", "a"), Value: []byte("1234")})
+	require.NoError(t, err)
+
+	_, err = guard.Create(ctx, Item{Key: Key("locks", "b"), Value: []byte("12345")})
+	require.True(t, trace.IsLimitExceeded(err))
+	_, err = guard.Put(ctx, Item{Key: Key("locks", "a"), Value: []byte("12345")})
+	require.True(t, trace.IsLimitExceeded(err))
+	_, err = guard.CompareAndSwap(ctx,
+		Item{Key: Key("locks", "a"), Value: []byte("1234")},
+		Item{Key: Key("locks", "a"), Value: []byte("12345")})
+	require.True(t, trace.IsLimitExceeded(err))
+}
+
+func TestQuotaGuardConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewQuotaGuard(QuotaGuardConfig{})
+	require.True(t, trace.IsBadParameter(err))
+
+	_, err = NewQuotaGuard(QuotaGuardConfig{
+		Backend: newMapBackend(),
+		Quotas:  []Quota{{MaxItems: 1}},
+	})
+	require.True(t, trace.IsBadParameter(err))
+
+	// defaults kick in when no quotas are configured
+	guard, err := NewQuotaGuard(QuotaGuardConfig{Backend: newMapBackend()})
+	require.NoError(t, err)
+	require.Len(t, guard.quotas, len(DefaultQuotas()))
+}
@@ -513,6 +513,8 @@ func (proxy *ProxyClient) IssueUserCertsWithMFA(ctx context.Context, params Reis
 				return nil, trace.Wrap(err)
 			}
 			key.DBTLSCerts[params.RouteToDatabase.ServiceName] = dbCert
+		case proto.UserCertsRequest_App:
+			key.AppTLSCerts[params.RouteToApp.Name] = crt.TLS
 		case proto.UserCertsRequest_WindowsDesktop:
 			key.WindowsDesktopCerts[params.RouteToWindowsDesktop.WindowsDesktop] = crt.TLS
 		default:
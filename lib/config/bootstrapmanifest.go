@@ -0,0 +1,149 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/yaml.v2"
+	kyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+// BootstrapManifestVersion is the only manifest version this release
+// understands.
+const BootstrapManifestVersion = "v1"
+
+// BootstrapManifest is a signed set of resources (roles, connectors, tokens,
+// trusted clusters, auth preference) used to stamp out a cluster from code.
+// The manifest is applied on the first auth server start and compared against
+// the backend for drift on every start after that.
+type BootstrapManifest struct {
+	// Version is the manifest format version.
+	Version string `yaml:"version"`
+	// Signature is the base64-encoded ed25519 signature over the raw
+	// Resources block.
+	Signature string `yaml:"signature"`
+	// Resources is a YAML stream of resources in the same format accepted
+	// by --bootstrap.
+	Resources string `yaml:"resources"`
+}
+
+// SignBootstrapManifest wraps a YAML resource stream into a manifest signed
+// with the supplied ed25519 private key.
+func SignBootstrapManifest(resourcesYAML []byte, key ed25519.PrivateKey) ([]byte, error) {
+	if len(resourcesYAML) == 0 {
+		return nil, trace.BadParameter("missing resources to sign")
+	}
+	manifest := BootstrapManifest{
+		Version:   BootstrapManifestVersion,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, resourcesYAML)),
+		Resources: string(resourcesYAML),
+	}
+	out, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return out, nil
+}
+
+// ReadBootstrapManifest reads a signed bootstrap manifest from manifestPath
+// and verifies its signature against the PEM-encoded ed25519 public key at
+// keyPath before decoding the resources it carries.
+func ReadBootstrapManifest(manifestPath, keyPath string) ([]types.Resource, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	key, err := readBootstrapManifestKey(keyPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ParseBootstrapManifest(data, key)
+}
+
+// ParseBootstrapManifest verifies the manifest signature with the supplied
+// public key and decodes the resources the manifest carries.
+func ParseBootstrapManifest(data []byte, key ed25519.PublicKey) ([]types.Resource, error) {
+	var manifest BootstrapManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, trace.BadParameter("failed to parse bootstrap manifest: %v", err)
+	}
+	if manifest.Version != BootstrapManifestVersion {
+		return nil, trace.BadParameter("unsupported bootstrap manifest version %q, expected %q", manifest.Version, BootstrapManifestVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil {
+		return nil, trace.BadParameter("failed to decode bootstrap manifest signature: %v", err)
+	}
+	if !ed25519.Verify(key, []byte(manifest.Resources), signature) {
+		return nil, trace.BadParameter("bootstrap manifest signature verification failed")
+	}
+	decoder := kyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest.Resources), defaults.LookaheadBufSize)
+	var resources []types.Resource
+	for {
+		var raw services.UnknownResource
+		err := decoder.Decode(&raw)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, trace.Wrap(err)
+		}
+		rsc, err := services.UnmarshalResource(raw.Kind, raw.Raw)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, rsc)
+	}
+	if len(resources) < 1 {
+		return nil, trace.BadParameter("bootstrap manifest carries no resources")
+	}
+	return resources, nil
+}
+
+// readBootstrapManifestKey reads a PEM-encoded ed25519 public key used to
+// verify bootstrap manifest signatures.
+func readBootstrapManifestKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, trace.BadParameter("no PEM block found in %q", path)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, trace.BadParameter("failed to parse public key in %q: %v", path, err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, trace.BadParameter("expected an ed25519 public key in %q, got %T", path, pub)
+	}
+	return key, nil
+}
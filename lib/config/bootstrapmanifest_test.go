@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+const bootstrapManifestResources = `kind: role
+version: v5
+metadata:
+  name: manifest-role
+spec:
+  allow:
+    logins: ["root"]
+---
+kind: token
+version: v2
+metadata:
+  name: manifest-token
+spec:
+  roles: ["Node"]
+`
+
+func TestBootstrapManifestRoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	manifest, err := SignBootstrapManifest([]byte(bootstrapManifestResources), priv)
+	require.NoError(t, err)
+
+	resources, err := ParseBootstrapManifest(manifest, pub)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	require.Equal(t, types.KindRole, resources[0].GetKind())
+	require.Equal(t, "manifest-role", resources[0].GetName())
+	require.Equal(t, types.KindToken, resources[1].GetKind())
+	require.Equal(t, "manifest-token", resources[1].GetName())
+}
+
+func TestBootstrapManifestTampering(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	manifest, err := SignBootstrapManifest([]byte(bootstrapManifestResources), priv)
+	require.NoError(t, err)
+
+	// A signature produced by a different key must not verify.
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = ParseBootstrapManifest(manifest, otherPub)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+
+	// Swapping in a different resource block must not verify either.
+	var tampered BootstrapManifest
+	require.NoError(t, yaml.Unmarshal(manifest, &tampered))
+	tampered.Resources = bootstrapManifestResources + `---
+kind: role
+version: v5
+metadata:
+  name: backdoor
+spec: {}
+`
+	tamperedManifest, err := yaml.Marshal(tampered)
+	require.NoError(t, err)
+	_, err = ParseBootstrapManifest(tamperedManifest, pub)
+	require.True(t, trace.IsBadParameter(err), "expected BadParameter, got %v", err)
+}
+
+func TestReadBootstrapManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	manifest, err := SignBootstrapManifest([]byte(bootstrapManifestResources), priv)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, manifest, 0600))
+
+	keyDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "manifest.pub")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+
+	resources, err := ReadBootstrapManifest(manifestPath, keyPath)
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+}
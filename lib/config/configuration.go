@@ -81,6 +81,13 @@ type CommandLineFlags struct {
 	// Bootstrap flag contains a YAML file that defines a set of resources to bootstrap
 	// a cluster.
 	BootstrapFile string
+	// BootstrapManifestFile contains a signed manifest of resources to bootstrap
+	// a cluster; unlike --bootstrap, the manifest is verified against
+	// BootstrapManifestKeyFile and checked for drift on subsequent starts.
+	BootstrapManifestFile string
+	// BootstrapManifestKeyFile is the path to the PEM-encoded ed25519 public
+	// key used to verify the bootstrap manifest signature.
+	BootstrapManifestKeyFile string
 	// ConfigString is a base64 encoded configuration string
 	// set by --config-string or TELEPORT_CONFIG environment variable
 	ConfigString string
@@ -710,6 +717,8 @@ func applyProxyConfig(fc *FileConfig, cfg *service.Config) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	cfg.Proxy.EnableStatusEndpoint = fc.Proxy.EnableStatusEndpoint
+	cfg.Proxy.EnableAPIGateway = fc.Proxy.EnableAPIGateway
 	if fc.Proxy.ListenAddress != "" {
 		addr, err := utils.ParseHostPortAddr(fc.Proxy.ListenAddress, defaults.SSHProxyListenPort)
 		if err != nil {
@@ -1053,6 +1062,11 @@ func applySSHConfig(fc *FileConfig, cfg *service.Config) (err error) {
 
 	cfg.SSH.AllowTCPForwarding = fc.SSH.AllowTCPForwarding()
 
+	if fc.SSH.CaptureEnv != nil {
+		cfg.SSH.CaptureEnv = fc.SSH.CaptureEnv.Enabled
+		cfg.SSH.CaptureEnvRedact = fc.SSH.CaptureEnv.Redact
+	}
+
 	cfg.SSH.X11, err = fc.SSH.X11ServerConfig()
 	if err != nil {
 		return trace.Wrap(err)
@@ -1766,6 +1780,21 @@ func Configure(clf *CommandLineFlags, cfg *service.Config) error {
 		cfg.Auth.Resources = resources
 	}
 
+	if clf.BootstrapManifestFile != "" {
+		if clf.BootstrapFile != "" {
+			return trace.BadParameter("--bootstrap and --bootstrap-manifest are mutually exclusive")
+		}
+		if clf.BootstrapManifestKeyFile == "" {
+			return trace.BadParameter("--bootstrap-manifest requires --bootstrap-manifest-key")
+		}
+		resources, err := ReadBootstrapManifest(clf.BootstrapManifestFile, clf.BootstrapManifestKeyFile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		cfg.Auth.Resources = resources
+		cfg.Auth.BootstrapManifestResources = resources
+	}
+
 	// Apply command line --debug flag to override logger severity.
 	if clf.Debug {
 		// If debug logging is requested and no file configuration exists, set the
@@ -1042,6 +1042,20 @@ type SSH struct {
 
 	// AWSMatchers are used to match EC2 instances
 	AWSMatchers []AWSEC2Matcher `yaml:"aws,omitempty"`
+
+	// CaptureEnv configures capture of session environment variables
+	// into the audit log.
+	CaptureEnv *CaptureEnv `yaml:"capture_env,omitempty"`
+}
+
+// CaptureEnv configures capture of session environment variables into the
+// audit log.
+type CaptureEnv struct {
+	// Enabled turns environment capture on.
+	Enabled bool `yaml:"enabled"`
+	// Redact overrides the default redaction patterns matched against
+	// variable names.
+	Redact []string `yaml:"redact,omitempty"`
 }
 
 // AllowTCPForwarding checks whether the config file allows TCP forwarding or not.
@@ -1483,6 +1497,12 @@ type Proxy struct {
 	// as only admin knows whether service is in front of trusted load balancer
 	// or not.
 	ProxyProtocol string `yaml:"proxy_protocol,omitempty"`
+	// EnableStatusEndpoint enables the unauthenticated /webapi/status
+	// endpoint used by load balancers and uptime checks.
+	EnableStatusEndpoint bool `yaml:"enable_status_endpoint,omitempty"`
+	// EnableAPIGateway enables the REST/JSON gateway under /webapi/gateway
+	// that transcodes a curated subset of APIs for non-gRPC clients.
+	EnableAPIGateway bool `yaml:"enable_api_gateway,omitempty"`
 	// KubeProxy configures kubernetes protocol support of the proxy
 	Kube KubeProxy `yaml:"kubernetes,omitempty"`
 	// KubeAddr is a shorthand for enabling the Kubernetes endpoint without a
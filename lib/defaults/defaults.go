@@ -299,6 +299,11 @@ const (
 	// DELETE IN 11.0.0
 	UploadGracePeriod = 24 * time.Hour
 
+	// NodeTombstoneGracePeriod is a period during which the metadata of an
+	// expired or deleted node is retained, so re-registrations within the
+	// window restore labels and metadata.
+	NodeTombstoneGracePeriod = 24 * time.Hour
+
 	// ProxyPingInterval is the interval ping messages are going to be sent.
 	// This is only applicable for TLS routing protocols that support ping
 	// wrapping.
@@ -415,6 +420,10 @@ const (
 
 // Default rate limits for unauthenticated passwordless endpoints.
 const (
+	// LimiterMaxConcurrentRequestsPerIdentity limits the number of in-flight
+	// auth API requests a single user identity may have at once
+	LimiterMaxConcurrentRequestsPerIdentity = 100
+
 	// LimiterPasswordlessPeriod is the default period for passwordless limiters.
 	LimiterPasswordlessPeriod = 1 * time.Minute
 	// LimiterPasswordlessAverage is the default average for passwordless
@@ -155,6 +155,9 @@ const (
 
 	// UserLoginEvent indicates that a user logged into web UI or via tsh
 	UserLoginEvent = "user.login"
+	// UserNewDeviceLoginEvent is emitted when a user logs in from a client
+	// fingerprint that was never seen for them before.
+	UserNewDeviceLoginEvent = "user.new_device_login"
 	// LoginMethod is the event field indicating how the login was performed
 	LoginMethod = "method"
 	// LoginMethodLocal represents login with username/password
@@ -204,6 +207,9 @@ const (
 	// AccessRequestResourceSearch is emitted when a user searches for
 	// resources as part of a search-based access request.
 	AccessRequestResourceSearch = "access_request.search"
+	// AccessRequestAutoResolvedEvent is emitted when an auto-approval rule
+	// resolves an access request without human review.
+	AccessRequestAutoResolvedEvent = "access_request.auto_resolved"
 	// AccessRequestDelegator is used by teleport plugins to indicate the identity
 	// which caused them to update state.
 	AccessRequestDelegator = "delegator"
@@ -228,6 +234,12 @@ const (
 
 	// RecoveryTokenCreateEvent is emitted when a new recovery token is created.
 	RecoveryTokenCreateEvent = "recovery_token.create"
+	// RecoveryApprovalRequestedEvent is emitted when a locked-out user asks an
+	// administrator to approve their account recovery.
+	RecoveryApprovalRequestedEvent = "recovery_approval.requested"
+	// RecoveryApprovalResolvedEvent is emitted when an administrator approves
+	// or denies a pending recovery approval request.
+	RecoveryApprovalResolvedEvent = "recovery_approval.resolved"
 	// ResetPasswordTokenCreateEvent is emitted when a new reset password token is created.
 	ResetPasswordTokenCreateEvent = "reset_password_token.create"
 	// BotTokenCreateEvent is emitted when a new bot join user token is created
@@ -286,6 +298,11 @@ const (
 	ResizeEvent  = "resize"
 	TerminalSize = "size" // expressed as 'W:H'
 
+	// SessionEnvEvent is emitted when capture of session environment
+	// variables is enabled, it carries a redacted snapshot of the
+	// environment the session started with
+	SessionEnvEvent = "session.env"
+
 	// SessionUploadIndex is a very large number of the event index
 	// to indicate that this is the last event in the chain
 	// used for the last event of the sesion - session upload
@@ -355,6 +372,13 @@ const (
 	// creating new join token for a trusted cluster.
 	TrustedClusterTokenCreateEvent = "trusted_cluster_token.create"
 
+	// StaticTokenAddEvent is emitted when a token is appended to the
+	// static tokens resource.
+	StaticTokenAddEvent = "static_token.add"
+	// StaticTokenRemoveEvent is emitted when a token is removed from the
+	// static tokens resource.
+	StaticTokenRemoveEvent = "static_token.remove"
+
 	// GithubConnectorCreatedEvent fires when a Github connector is created/updated.
 	GithubConnectorCreatedEvent = "github.created"
 	// GithubConnectorDeletedEvent fires when a Github connector is deleted.
@@ -490,6 +514,21 @@ const (
 	// SessionRejectedReasonMaxSessions indicates that a session.rejected event
 	// corresponds to enforcement of the max_sessions control.
 	SessionRejectedReasonMaxSessions = "max_sessions limit reached"
+	// SessionRejectedReasonCommandPolicy indicates that a session.rejected
+	// event corresponds to enforcement of a role command policy.
+	SessionRejectedReasonCommandPolicy = "command rejected by role command policy"
+	// SessionRejectedReasonMaxWebSessions indicates that a session.rejected
+	// event corresponds to enforcement of the concurrent web session limit.
+	SessionRejectedReasonMaxWebSessions = "concurrent web session limit reached"
+	// SessionRejectedReasonWebSessionEvicted indicates that a
+	// session.rejected event corresponds to the eviction of the oldest web
+	// session of a user who reached the concurrent web session limit.
+	SessionRejectedReasonWebSessionEvicted = "oldest web session evicted over the concurrent web session limit"
+
+	// SessionRejectedReasonRefreshTokenReuse indicates that a
+	// session.rejected event was caused by a rotated web session refresh
+	// token being presented again, which revoked the whole session chain.
+	SessionRejectedReasonRefreshTokenReuse = "refresh token reuse detected, session chain revoked"
 
 	// Maximum is an event field specifying a maximal value (e.g. the value
 	// of `max_connections` for a `session.rejected` event).
@@ -531,6 +570,9 @@ const (
 	// CertificateTypeUser is the CertificateType for certificate events pertaining to user certificates.
 	CertificateTypeUser = "user"
 
+	// CertificateTypeHost is the CertificateType for certificate events pertaining to host certificates.
+	CertificateTypeHost = "host"
+
 	// DesktopRecordingEvent is emitted as a desktop access session is recorded.
 	DesktopRecordingEvent = "desktop.recording"
 	// DesktopClipboardReceiveEvent is emitted when Teleport receives
@@ -29,12 +29,12 @@ type Event struct {
 // There is no strict algorithm for picking an event code, however existing
 // event codes are currently loosely categorized as follows:
 //
-//  * Teleport event codes start with "T" and belong in this const block.
+//   - Teleport event codes start with "T" and belong in this const block.
 //
-//  * Related events are grouped starting with the same number.
-//		eg: All user related events are grouped under 1xxx.
+//   - Related events are grouped starting with the same number.
+//     eg: All user related events are grouped under 1xxx.
 //
-//  * Suffix code with one of these letters: I (info), W (warn), E (error).
+//   - Suffix code with one of these letters: I (info), W (warn), E (error).
 const (
 	// UserLocalLoginCode is the successful local user login event code.
 	UserLocalLoginCode = "T1000I"
@@ -68,6 +68,15 @@ const (
 	UserSSOTestFlowLoginCode = "T1010I"
 	// UserSSOTestFlowLoginFailureCode is the unsuccessful SSO test flow user login event code.
 	UserSSOTestFlowLoginFailureCode = "T1011W"
+	// AuthVerificationCode is an event code for a successful credential
+	// verification that did not create a session.
+	AuthVerificationCode = "T1012I"
+	// AuthVerificationFailureCode is an event code for a failed credential
+	// verification.
+	AuthVerificationFailureCode = "T1012W"
+	// UserNewDeviceLoginCode is an event code for when a user logs in from a
+	// client fingerprint that was never seen for them before.
+	UserNewDeviceLoginCode = "T1013I"
 
 	// BillingCardCreateCode is an event code for when a user creates a new credit card.
 	BillingCardCreateCode = "TBL00I"
@@ -108,6 +117,8 @@ const (
 	AppSessionEndCode = "T2011I"
 	// SessionRecordingAccessCode is the session recording view data event code.
 	SessionRecordingAccessCode = "T2012I"
+	// SessionEnvCode is the session environment snapshot event code.
+	SessionEnvCode = "T2013I"
 
 	// AppCreateCode is the app.create event code.
 	AppCreateCode = "TAP03I"
@@ -224,6 +235,21 @@ const (
 	// Note: some requests (like exec into a pod) use other codes (like
 	// ExecCode).
 	KubeRequestCode = "T3009I"
+	// ProvisionTokenExhaustedCode is emitted when a usage-limited provision
+	// token is used up by a successful registration.
+	ProvisionTokenExhaustedCode = "T3010I"
+	// ProvisionTokenReuseCode is emitted when a registration is rejected
+	// because the provision token's usage limit was already reached.
+	ProvisionTokenReuseCode = "T3011W"
+	// AdminActionMFAFailureCode is emitted when a destructive administrative
+	// action is rejected because the caller has no recent MFA verification.
+	AdminActionMFAFailureCode = "T3012W"
+	// WebSessionEvictedCode is emitted when the oldest web session of a user
+	// is evicted because the concurrent web session limit was reached.
+	WebSessionEvictedCode = "T3013I"
+	// RefreshTokenReuseCode is emitted when an already rotated web session
+	// refresh token is presented again, indicating the token was stolen.
+	RefreshTokenReuseCode = "T3014W"
 
 	// The following codes correspond to SFTP file operations.
 	SFTPOpenCode            = "TS001I"
@@ -280,6 +306,12 @@ const (
 	AccessRequestDeleteCode = "T5003I"
 	// AccessRequestResourceSearchCode is the access request resource search code.
 	AccessRequestResourceSearchCode = "T5004I"
+	// AccessRequestAutoApprovedCode is the code of the event emitted when an
+	// auto-approval rule approves an access request.
+	AccessRequestAutoApprovedCode = "T5005I"
+	// AccessRequestAutoDeniedCode is the code of the event emitted when an
+	// auto-approval rule denies an access request.
+	AccessRequestAutoDeniedCode = "T5005W"
 
 	// ResetPasswordTokenCreateCode is the token create event code.
 	ResetPasswordTokenCreateCode = "T6000I"
@@ -287,6 +319,15 @@ const (
 	RecoveryTokenCreateCode = "T6001I"
 	// PrivilegeTokenCreateCode is the privilege token create event code.
 	PrivilegeTokenCreateCode = "T6002I"
+	// RecoveryApprovalRequestedCode is an event code for when a locked-out
+	// user asks an administrator to approve their account recovery.
+	RecoveryApprovalRequestedCode = "T6003I"
+	// RecoveryApprovalApprovedCode is an event code for when an administrator
+	// approves a recovery approval request.
+	RecoveryApprovalApprovedCode = "T6004I"
+	// RecoveryApprovalDeniedCode is an event code for when an administrator
+	// denies a recovery approval request.
+	RecoveryApprovalDeniedCode = "T6004W"
 
 	// TrustedClusterCreateCode is the event code for creating a trusted cluster.
 	TrustedClusterCreateCode = "T7000I"
@@ -295,6 +336,12 @@ const (
 	// TrustedClusterTokenCreateCode is the event code for
 	// creating new join token for a trusted cluster.
 	TrustedClusterTokenCreateCode = "T7002I"
+	// StaticTokenAddCode is the event code for appending a token to the
+	// static tokens resource.
+	StaticTokenAddCode = "T7003I"
+	// StaticTokenRemoveCode is the event code for removing a token from
+	// the static tokens resource.
+	StaticTokenRemoveCode = "T7004I"
 
 	// GithubConnectorCreatedCode is the Github connector created event code.
 	GithubConnectorCreatedCode = "T8000I"
@@ -55,6 +55,10 @@ func FromEventFields(fields EventFields) (events.AuditEvent, error) {
 		e = &events.SessionPrint{}
 	case SessionStartEvent:
 		e = &events.SessionStart{}
+	// the env snapshot reuses the session start shape, its InitialCommand
+	// field carries the captured KEY=VALUE pairs
+	case SessionEnvEvent:
+		e = &events.SessionStart{}
 	case SessionEndEvent:
 		e = &events.SessionEnd{}
 	case SessionUploadEvent:
@@ -129,6 +133,8 @@ func FromEventFields(fields EventFields) (events.AuditEvent, error) {
 		e = &events.TrustedClusterDelete{}
 	case TrustedClusterTokenCreateEvent:
 		e = &events.TrustedClusterTokenCreate{}
+	case StaticTokenAddEvent, StaticTokenRemoveEvent:
+		e = &events.TrustedClusterTokenCreate{}
 	case GithubConnectorCreatedEvent:
 		e = &events.GithubConnectorCreate{}
 	case GithubConnectorDeletedEvent:
@@ -21,11 +21,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/client"
 	"github.com/gravitational/teleport/api/client/proto"
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/utils"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 )
@@ -177,6 +179,10 @@ func (h *downstreamHandle) handleStream(stream client.DownstreamInventoryControl
 		return nil
 	}
 
+	// the downstream hello carries the auth server's version, nudge the
+	// operator when this agent has fallen behind
+	h.nudgeUpgrade(downstreamHello)
+
 	sender := downstreamSender{stream, downstreamHello}
 
 	// handle incoming messages and distribute sender references
@@ -203,6 +209,29 @@ func (h *downstreamHandle) handleStream(stream client.DownstreamInventoryControl
 	}
 }
 
+// nudgeUpgrade logs an upgrade nudge when the auth server advertises a newer
+// major version than the one this agent is running.
+func (h *downstreamHandle) nudgeUpgrade(hello proto.DownstreamInventoryHello) {
+	if shouldNudgeUpgrade(teleport.Version, hello.Version) {
+		log.Warnf("Teleport agent is running version %v while the auth server is on %v, upgrade the agent to avoid falling outside the supported version range.",
+			teleport.Version, hello.Version)
+	}
+}
+
+// shouldNudgeUpgrade reports whether the agent version trails the auth
+// version by at least one major version. Unparsable versions never nudge.
+func shouldNudgeUpgrade(agentVersion, authVersion string) bool {
+	agent, err := semver.NewVersion(agentVersion)
+	if err != nil {
+		return false
+	}
+	auth, err := semver.NewVersion(authVersion)
+	if err != nil {
+		return false
+	}
+	return agent.Major < auth.Major
+}
+
 func (h *downstreamHandle) handlePing(sender DownstreamSender, msg proto.DownstreamInventoryPing) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
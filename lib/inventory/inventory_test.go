@@ -0,0 +1,34 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldNudgeUpgrade(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, shouldNudgeUpgrade("10.3.1", "11.0.0"))
+	require.True(t, shouldNudgeUpgrade("9.0.0", "11.2.0"))
+	require.False(t, shouldNudgeUpgrade("11.0.0", "11.2.3"))
+	require.False(t, shouldNudgeUpgrade("11.1.0", "10.0.0"))
+	require.False(t, shouldNudgeUpgrade("not-a-version", "11.0.0"))
+	require.False(t, shouldNudgeUpgrade("11.0.0", ""))
+}
@@ -197,6 +197,27 @@ func (k *Key) SignSnowflake(p SignParams, issuer string) (string, error) {
 	return k.sign(claims)
 }
 
+// SignIDToken returns a signed OIDC ID token for the given identity. Unlike
+// app access tokens it carries the cluster's public issuer URL rather than
+// the cluster name, so external relying parties can verify it against the
+// JWKS published in the issuer's discovery document.
+func (k *Key) SignIDToken(p SignParams, issuer string) (string, error) {
+	claims := Claims{
+		Claims: jwt.Claims{
+			Subject:   p.Username,
+			Issuer:    issuer,
+			Audience:  jwt.Audience{p.URI},
+			NotBefore: jwt.NewNumericDate(k.config.Clock.Now().Add(-10 * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(k.config.Clock.Now()),
+			Expiry:    jwt.NewNumericDate(p.Expires),
+		},
+		Username: p.Username,
+		Roles:    p.Roles,
+	}
+
+	return k.sign(claims)
+}
+
 // VerifyParams are the parameters needed to pass the token and data needed to verify.
 type VerifyParams struct {
 	// Username is the Teleport identity.
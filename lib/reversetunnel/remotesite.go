@@ -518,6 +518,7 @@ func (s *remoteSite) watchCertAuthorities(remoteWatcher *services.CertAuthorityW
 	}()
 
 	localCAs := make(map[types.CertAuthType]types.CertAuthority, len(filter))
+	cas := make([]types.CertAuthority, 0, len(filter))
 	for caType, clusterName := range filter {
 		caID := types.CertAuthID{
 			Type:       caType,
@@ -527,12 +528,26 @@ func (s *remoteSite) watchCertAuthorities(remoteWatcher *services.CertAuthorityW
 		if err != nil {
 			return trace.Wrap(err, "failed to get local cert authority")
 		}
-		if err := s.remoteClient.RotateExternalCertAuthority(s.ctx, ca); err != nil {
-			return trace.Wrap(err, "failed to push local cert authority")
-		}
-		s.Debugf("Pushed local cert authority %v", caID.String())
+		cas = append(cas, ca)
 		localCAs[caType] = ca
 	}
+	// push all local cert authorities in one batch so the remote cluster
+	// updates them as a set instead of observing them one at a time
+	if err := s.remoteClient.RotateExternalCertAuthorities(s.ctx, cas); err != nil {
+		if !trace.IsNotFound(err) && !trace.IsNotImplemented(err) {
+			return trace.Wrap(err, "failed to push local cert authorities")
+		}
+		// fall back to per-CA pushes for remote clusters that do not
+		// implement the batch API
+		for _, ca := range cas {
+			if err := s.remoteClient.RotateExternalCertAuthority(s.ctx, ca); err != nil {
+				return trace.Wrap(err, "failed to push local cert authority")
+			}
+			s.Debugf("Pushed local cert authority %v", ca.GetID().String())
+		}
+	} else {
+		s.Debugf("Pushed %v local cert authorities", len(cas))
+	}
 
 	remoteCA, err := s.remoteAccessPoint.GetCertAuthority(s.ctx, types.CertAuthID{
 		Type:       types.HostCA,
@@ -391,6 +391,14 @@ type ProxyConfig struct {
 	// EnableProxyProtocol enables proxy protocol support
 	EnableProxyProtocol bool
 
+	// EnableStatusEndpoint enables the unauthenticated /webapi/status
+	// endpoint used by load balancers and uptime checks.
+	EnableStatusEndpoint bool
+
+	// EnableAPIGateway enables the REST/JSON gateway under /webapi/gateway
+	// that transcodes a curated subset of APIs for non-gRPC clients.
+	EnableAPIGateway bool
+
 	// WebAddr is address for web portal of the proxy
 	WebAddr utils.NetAddr
 
@@ -571,6 +579,11 @@ type AuthConfig struct {
 	// used to bootstrap backend state on the first start.
 	Resources []types.Resource
 
+	// BootstrapManifestResources is the set of resources supplied via a
+	// signed bootstrap manifest; applied on the first start and compared
+	// against the backend for drift on every start after that.
+	BootstrapManifestResources []types.Resource
+
 	// Roles is a set of roles to pre-provision for this cluster
 	Roles []types.Role
 
@@ -659,6 +672,14 @@ type SSHConfig struct {
 
 	// AWSMatchers are used to match EC2 instances for auto enrollment.
 	AWSMatchers []services.AWSMatcher
+
+	// CaptureEnv enables capture of a redacted snapshot of session
+	// environment variables into the audit log.
+	CaptureEnv bool
+
+	// CaptureEnvRedact overrides the default redaction patterns applied
+	// to captured environment variable names.
+	CaptureEnvRedact []string
 }
 
 // KubeConfig specifies configuration for kubernetes service
@@ -1526,36 +1526,37 @@ func (process *TeleportProcess) initAuthService() error {
 
 	// first, create the AuthServer
 	authServer, err := auth.Init(auth.InitConfig{
-		Backend:                 b,
-		Authority:               cfg.Keygen,
-		ClusterConfiguration:    cfg.ClusterConfiguration,
-		ClusterAuditConfig:      cfg.Auth.AuditConfig,
-		ClusterNetworkingConfig: cfg.Auth.NetworkingConfig,
-		SessionRecordingConfig:  cfg.Auth.SessionRecordingConfig,
-		ClusterName:             cfg.Auth.ClusterName,
-		AuthServiceName:         cfg.Hostname,
-		DataDir:                 cfg.DataDir,
-		HostUUID:                cfg.HostUUID,
-		NodeName:                cfg.Hostname,
-		Authorities:             cfg.Auth.Authorities,
-		Resources:               cfg.Auth.Resources,
-		ReverseTunnels:          cfg.ReverseTunnels,
-		Trust:                   cfg.Trust,
-		Presence:                cfg.Presence,
-		Events:                  cfg.Events,
-		Provisioner:             cfg.Provisioner,
-		Identity:                cfg.Identity,
-		Access:                  cfg.Access,
-		StaticTokens:            cfg.Auth.StaticTokens,
-		Roles:                   cfg.Auth.Roles,
-		AuthPreference:          cfg.Auth.Preference,
-		OIDCConnectors:          cfg.OIDCConnectors,
-		AuditLog:                process.auditLog,
-		CipherSuites:            cfg.CipherSuites,
-		KeyStoreConfig:          cfg.Auth.KeyStore,
-		Emitter:                 checkingEmitter,
-		Streamer:                events.NewReportingStreamer(checkingStreamer, process.Config.UploadEventsC),
-		TraceClient:             traceClt,
+		Backend:                    b,
+		Authority:                  cfg.Keygen,
+		ClusterConfiguration:       cfg.ClusterConfiguration,
+		ClusterAuditConfig:         cfg.Auth.AuditConfig,
+		ClusterNetworkingConfig:    cfg.Auth.NetworkingConfig,
+		SessionRecordingConfig:     cfg.Auth.SessionRecordingConfig,
+		ClusterName:                cfg.Auth.ClusterName,
+		AuthServiceName:            cfg.Hostname,
+		DataDir:                    cfg.DataDir,
+		HostUUID:                   cfg.HostUUID,
+		NodeName:                   cfg.Hostname,
+		Authorities:                cfg.Auth.Authorities,
+		Resources:                  cfg.Auth.Resources,
+		BootstrapManifestResources: cfg.Auth.BootstrapManifestResources,
+		ReverseTunnels:             cfg.ReverseTunnels,
+		Trust:                      cfg.Trust,
+		Presence:                   cfg.Presence,
+		Events:                     cfg.Events,
+		Provisioner:                cfg.Provisioner,
+		Identity:                   cfg.Identity,
+		Access:                     cfg.Access,
+		StaticTokens:               cfg.Auth.StaticTokens,
+		Roles:                      cfg.Auth.Roles,
+		AuthPreference:             cfg.Auth.Preference,
+		OIDCConnectors:             cfg.OIDCConnectors,
+		AuditLog:                   process.auditLog,
+		CipherSuites:               cfg.CipherSuites,
+		KeyStoreConfig:             cfg.Auth.KeyStore,
+		Emitter:                    checkingEmitter,
+		Streamer:                   events.NewReportingStreamer(checkingStreamer, process.Config.UploadEventsC),
+		TraceClient:                traceClt,
 	}, func(as *auth.Server) error {
 		if !process.Config.CachePolicy.Enabled {
 			return nil
@@ -2287,6 +2288,10 @@ func (process *TeleportProcess) initSSH() error {
 			regular.SetStoragePresenceService(storagePresence),
 			regular.SetInventoryControlHandle(process.inventoryHandle),
 			regular.SetAWSMatchers(cfg.SSH.AWSMatchers),
+			regular.SetEnvCapturePolicy(srv.EnvCapturePolicy{
+				Enabled:        cfg.SSH.CaptureEnv,
+				RedactPatterns: cfg.SSH.CaptureEnvRedact,
+			}),
 		)
 		if err != nil {
 			return trace.Wrap(err)
@@ -3418,24 +3423,26 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		}
 
 		webConfig := web.Config{
-			Proxy:            tsrv,
-			AuthServers:      cfg.AuthServers[0],
-			DomainName:       cfg.Hostname,
-			ProxyClient:      conn.Client,
-			ProxySSHAddr:     proxySSHAddr,
-			ProxyWebAddr:     cfg.Proxy.WebAddr,
-			ProxyPublicAddrs: cfg.Proxy.PublicAddrs,
-			CipherSuites:     cfg.CipherSuites,
-			FIPS:             cfg.FIPS,
-			AccessPoint:      accessPoint,
-			Emitter:          streamEmitter,
-			PluginRegistry:   process.PluginRegistry,
-			HostUUID:         process.Config.HostUUID,
-			Context:          process.ExitContext(),
-			StaticFS:         fs,
-			ClusterFeatures:  process.getClusterFeatures(),
-			ProxySettings:    proxySettings,
-			PublicProxyAddr:  process.proxyPublicAddr().Addr,
+			Proxy:                tsrv,
+			AuthServers:          cfg.AuthServers[0],
+			DomainName:           cfg.Hostname,
+			ProxyClient:          conn.Client,
+			ProxySSHAddr:         proxySSHAddr,
+			ProxyWebAddr:         cfg.Proxy.WebAddr,
+			ProxyPublicAddrs:     cfg.Proxy.PublicAddrs,
+			CipherSuites:         cfg.CipherSuites,
+			FIPS:                 cfg.FIPS,
+			AccessPoint:          accessPoint,
+			Emitter:              streamEmitter,
+			PluginRegistry:       process.PluginRegistry,
+			HostUUID:             process.Config.HostUUID,
+			Context:              process.ExitContext(),
+			StaticFS:             fs,
+			ClusterFeatures:      process.getClusterFeatures(),
+			ProxySettings:        proxySettings,
+			PublicProxyAddr:      process.proxyPublicAddr().Addr,
+			EnableStatusEndpoint: cfg.Proxy.EnableStatusEndpoint,
+			EnableAPIGateway:     cfg.Proxy.EnableAPIGateway,
 		}
 		webHandler, err = web.NewHandler(webConfig)
 		if err != nil {
@@ -4459,9 +4466,24 @@ func (process *TeleportProcess) initAuthStorage() (bk backend.Backend, err error
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	// optional per-prefix storage quotas from the "quotas" storage
+	// parameter, guarding the defaults when not set
+	var quotas []backend.Quota
+	if raw, ok := bc.Params["quotas"]; ok {
+		if err := apiutils.ObjectToStruct(raw, &quotas); err != nil {
+			return nil, trace.BadParameter("failed to parse storage quotas: %v", err)
+		}
+	}
+	guard, err := backend.NewQuotaGuard(backend.QuotaGuardConfig{
+		Backend: backend.NewSanitizer(bk),
+		Quotas:  quotas,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	reporter, err := backend.NewReporter(backend.ReporterConfig{
 		Component: teleport.ComponentBackend,
-		Backend:   backend.NewSanitizer(bk),
+		Backend:   guard,
 		Tracer:    process.TracingProvider.Tracer(teleport.ComponentBackend),
 	})
 	if err != nil {
@@ -24,16 +24,16 @@ import (
 
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/passwordhash"
 
 	"github.com/google/uuid"
 	"github.com/gravitational/trace"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // ValidateLocalAuthSecrets validates local auth secret members.
 func ValidateLocalAuthSecrets(l *types.LocalAuthSecrets) error {
 	if len(l.PasswordHash) > 0 {
-		if _, err := bcrypt.Cost(l.PasswordHash); err != nil {
+		if err := passwordhash.Validate(l.PasswordHash); err != nil {
 			return trace.BadParameter("invalid password hash")
 		}
 	}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// Role metadata labels configuring shell command policies. Values are
+// comma-separated glob patterns matched against the full command line of
+// "exec" requests.
+const (
+	// RoleAllowCommandsLabel restricts users holding the role to the listed
+	// commands. If any role in the user's role set carries the label, every
+	// executed command must match at least one pattern from that role.
+	RoleAllowCommandsLabel = "teleport.dev/allow-commands"
+	// RoleDenyCommandsLabel forbids the listed commands. Deny patterns from
+	// any role in the user's role set take precedence over allow patterns.
+	RoleDenyCommandsLabel = "teleport.dev/deny-commands"
+)
+
+// commandPolicyRule is a single compiled command pattern together with the
+// role it came from, kept for error messages and audit events.
+type commandPolicyRule struct {
+	role    string
+	pattern string
+	expr    *regexp.Regexp
+}
+
+// CommandPolicy is the compiled set of command allow and deny rules from a
+// user's role set, evaluated by SSH services when a command execution is
+// requested.
+type CommandPolicy struct {
+	allow []commandPolicyRule
+	deny  []commandPolicyRule
+}
+
+// NewCommandPolicy compiles the command policy labels of the given roles.
+// Deny rules always win; if any role carries allow rules, the executed
+// command must match one of them.
+func NewCommandPolicy(roles []types.Role) (*CommandPolicy, error) {
+	var policy CommandPolicy
+	for _, role := range roles {
+		labels := role.GetMetadata().Labels
+		allow, err := compileCommandPolicyRules(role.GetName(), labels[RoleAllowCommandsLabel])
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policy.allow = append(policy.allow, allow...)
+		deny, err := compileCommandPolicyRules(role.GetName(), labels[RoleDenyCommandsLabel])
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policy.deny = append(policy.deny, deny...)
+	}
+	return &policy, nil
+}
+
+func compileCommandPolicyRules(roleName, rules string) ([]commandPolicyRule, error) {
+	if rules == "" {
+		return nil, nil
+	}
+	var compiled []commandPolicyRule
+	for _, pattern := range strings.Split(rules, ",") {
+		pattern = strings.TrimSpace(pattern)
+		expr, err := regexp.Compile("^" + utils.GlobToRegexp(pattern) + "$")
+		if err != nil {
+			return nil, trace.BadParameter("invalid command pattern %q in role %q: %v", pattern, roleName, err)
+		}
+		compiled = append(compiled, commandPolicyRule{
+			role:    roleName,
+			pattern: pattern,
+			expr:    expr,
+		})
+	}
+	return compiled, nil
+}
+
+// IsEmpty returns true if the policy carries no rules and command execution
+// is unrestricted.
+func (p *CommandPolicy) IsEmpty() bool {
+	return len(p.allow) == 0 && len(p.deny) == 0
+}
+
+// CheckInteractiveShell checks whether the policy permits an interactive
+// shell. Shells are rejected when any role carries command allow rules,
+// since an unrestricted shell would bypass them.
+func (p *CommandPolicy) CheckInteractiveShell() error {
+	if len(p.allow) == 0 {
+		return nil
+	}
+	return trace.AccessDenied("interactive shells are not permitted: role %q restricts command execution to an allow-list", p.allow[0].role)
+}
+
+// CheckCommand checks the given command line against the policy. The
+// returned error is an access denied error naming the role the violated
+// rule came from.
+func (p *CommandPolicy) CheckCommand(command string) error {
+	command = strings.TrimSpace(command)
+	for _, rule := range p.deny {
+		if rule.expr.MatchString(command) {
+			return trace.AccessDenied("command %q is denied by role %q", command, rule.role)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.allow {
+		if rule.expr.MatchString(command) {
+			return nil
+		}
+	}
+	return trace.AccessDenied("command %q does not match any allowed command pattern", command)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func TestCommandPolicy(t *testing.T) {
+	t.Parallel()
+
+	newRole := func(name string, labels map[string]string) types.Role {
+		role, err := types.NewRoleV3(name, types.RoleSpecV5{})
+		require.NoError(t, err)
+		meta := role.GetMetadata()
+		meta.Labels = labels
+		role.SetMetadata(meta)
+		return role
+	}
+
+	tests := []struct {
+		desc    string
+		roles   []types.Role
+		command string
+		assert  require.ErrorAssertionFunc
+	}{
+		{
+			desc:    "no rules allows any command",
+			roles:   []types.Role{newRole("access", nil)},
+			command: "rm -rf /",
+			assert:  require.NoError,
+		},
+		{
+			desc: "deny rule matches",
+			roles: []types.Role{newRole("access", map[string]string{
+				RoleDenyCommandsLabel: "rm *,shutdown*",
+			})},
+			command: "rm -rf /",
+			assert: func(t require.TestingT, err error, _ ...interface{}) {
+				require.True(t, trace.IsAccessDenied(err))
+			},
+		},
+		{
+			desc: "allow list permits matching command",
+			roles: []types.Role{newRole("deploy", map[string]string{
+				RoleAllowCommandsLabel: "systemctl restart *,uptime",
+			})},
+			command: "systemctl restart nginx",
+			assert:  require.NoError,
+		},
+		{
+			desc: "allow list rejects other commands",
+			roles: []types.Role{newRole("deploy", map[string]string{
+				RoleAllowCommandsLabel: "systemctl restart *",
+			})},
+			command: "bash",
+			assert: func(t require.TestingT, err error, _ ...interface{}) {
+				require.True(t, trace.IsAccessDenied(err))
+			},
+		},
+		{
+			desc: "deny wins over allow across roles",
+			roles: []types.Role{
+				newRole("deploy", map[string]string{
+					RoleAllowCommandsLabel: "systemctl *",
+				}),
+				newRole("restricted", map[string]string{
+					RoleDenyCommandsLabel: "systemctl stop *",
+				}),
+			},
+			command: "systemctl stop sshd",
+			assert: func(t require.TestingT, err error, _ ...interface{}) {
+				require.True(t, trace.IsAccessDenied(err))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			policy, err := NewCommandPolicy(tt.roles)
+			require.NoError(t, err)
+			tt.assert(t, policy.CheckCommand(tt.command))
+		})
+	}
+
+	t.Run("interactive shell rejected with allow list", func(t *testing.T) {
+		policy, err := NewCommandPolicy([]types.Role{newRole("deploy", map[string]string{
+			RoleAllowCommandsLabel: "systemctl restart *",
+		})})
+		require.NoError(t, err)
+		require.True(t, trace.IsAccessDenied(policy.CheckInteractiveShell()))
+	})
+
+	t.Run("interactive shell permitted with deny rules only", func(t *testing.T) {
+		policy, err := NewCommandPolicy([]types.Role{newRole("access", map[string]string{
+			RoleDenyCommandsLabel: "rm *",
+		})})
+		require.NoError(t, err)
+		require.NoError(t, policy.CheckInteractiveShell())
+	})
+}
@@ -39,8 +39,9 @@ import (
 // PresenceService records and reports the presence of all components
 // of the cluster - Nodes, Proxies and SSH nodes
 type PresenceService struct {
-	log    *logrus.Entry
-	jitter utils.Jitter
+	log       *logrus.Entry
+	jitter    utils.Jitter
+	coalescer presenceCoalescer
 	backend.Backend
 }
 
@@ -308,6 +309,19 @@ func (s *PresenceService) DeleteAuthServer(name string) error {
 // UpsertProxy registers proxy server presence, permanently if ttl is 0 or
 // for the specified duration with second resolution if it's >= 1 second
 func (s *PresenceService) UpsertProxy(server types.Server) error {
+	// coalesce heartbeats that only refresh the expiry, the marshaled value
+	// is normalized by stripping the volatile fields
+	normalized := server.DeepCopy()
+	normalized.SetExpiry(time.Time{})
+	normalized.SetResourceID(0)
+	value, err := services.MarshalServer(normalized)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	key := backend.Key(proxiesPrefix, server.GetName())
+	if s.coalescer.coalesce(types.KindProxy, key, value, s.Clock().Now(), server.Expiry()) {
+		return nil
+	}
 	return s.upsertServer(context.TODO(), proxiesPrefix, server)
 }
 
@@ -318,6 +332,7 @@ func (s *PresenceService) GetProxies() ([]types.Server, error) {
 
 // DeleteAllProxies deletes all proxies
 func (s *PresenceService) DeleteAllProxies() error {
+	s.coalescer.reset()
 	startKey := backend.Key(proxiesPrefix)
 	return s.DeleteRange(context.TODO(), startKey, backend.RangeEnd(startKey))
 }
@@ -325,6 +340,7 @@ func (s *PresenceService) DeleteAllProxies() error {
 // DeleteProxy deletes proxy
 func (s *PresenceService) DeleteProxy(name string) error {
 	key := backend.Key(proxiesPrefix, name)
+	s.coalescer.forget(key)
 	return s.Delete(context.TODO(), key)
 }
 
@@ -466,12 +482,28 @@ func (s *PresenceService) UpsertTunnelConnection(conn types.TunnelConnection) er
 		return trace.Wrap(err)
 	}
 
+	key := backend.Key(tunnelConnectionsPrefix, conn.GetClusterName(), conn.GetName())
+
+	// coalesce heartbeats that only advance timestamps, the marshaled value
+	// is normalized by stripping the volatile fields
+	normalized := conn.Clone()
+	normalized.SetExpiry(time.Time{})
+	normalized.SetLastHeartbeat(time.Time{})
+	normalized.SetResourceID(0)
+	normalizedValue, err := services.MarshalTunnelConnection(normalized)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if s.coalescer.coalesce(types.KindTunnelConnection, key, normalizedValue, s.Clock().Now(), conn.Expiry()) {
+		return nil
+	}
+
 	value, err := services.MarshalTunnelConnection(conn)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	_, err = s.Put(context.TODO(), backend.Item{
-		Key:     backend.Key(tunnelConnectionsPrefix, conn.GetClusterName(), conn.GetName()),
+		Key:     key,
 		Value:   value,
 		Expires: conn.Expiry(),
 		ID:      conn.GetResourceID(),
@@ -553,7 +585,9 @@ func (s *PresenceService) DeleteTunnelConnection(clusterName, connectionName str
 	if connectionName == "" {
 		return trace.BadParameter("missing connection name")
 	}
-	return s.Delete(context.TODO(), backend.Key(tunnelConnectionsPrefix, clusterName, connectionName))
+	key := backend.Key(tunnelConnectionsPrefix, clusterName, connectionName)
+	s.coalescer.forget(key)
+	return s.Delete(context.TODO(), key)
 }
 
 // DeleteTunnelConnections deletes all tunnel connections for cluster
@@ -561,6 +595,7 @@ func (s *PresenceService) DeleteTunnelConnections(clusterName string) error {
 	if clusterName == "" {
 		return trace.BadParameter("missing cluster name")
 	}
+	s.coalescer.reset()
 	startKey := backend.ExactKey(tunnelConnectionsPrefix, clusterName)
 	err := s.DeleteRange(context.TODO(), startKey, backend.RangeEnd(startKey))
 	return trace.Wrap(err)
@@ -568,6 +603,7 @@ func (s *PresenceService) DeleteTunnelConnections(clusterName string) error {
 
 // DeleteAllTunnelConnections deletes all tunnel connections
 func (s *PresenceService) DeleteAllTunnelConnections() error {
+	s.coalescer.reset()
 	startKey := backend.Key(tunnelConnectionsPrefix)
 	err := s.DeleteRange(context.TODO(), startKey, backend.RangeEnd(startKey))
 	return trace.Wrap(err)
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+// presenceWriteIntervals bounds how stale a coalesced presence resource may
+// be in the backend, per resource kind. High-frequency presence writes
+// (tunnel connections, proxy heartbeats) that do not change anything but
+// their timestamps are suppressed within this window, cutting backend write
+// IOPS during proxy restarts when thousands of such resources churn. A zero
+// interval disables coalescing for the kind.
+var presenceWriteIntervals = map[string]time.Duration{
+	types.KindTunnelConnection: 10 * time.Second,
+	types.KindProxy:            10 * time.Second,
+}
+
+// SetPresenceWriteInterval overrides the coalescing window for a presence
+// resource kind. Intended to be called during process setup, before the
+// presence service handles traffic.
+func SetPresenceWriteInterval(kind string, interval time.Duration) {
+	presenceWriteIntervals[kind] = interval
+}
+
+// coalescedWrite remembers the last value written for a presence resource.
+type coalescedWrite struct {
+	digest  [sha256.Size]byte
+	written time.Time
+	expires time.Time
+}
+
+// presenceCoalescer suppresses redundant presence writes. A write may be
+// skipped when an equivalent value was recently written and the backend item
+// is in no danger of expiring before the next write is allowed through.
+type presenceCoalescer struct {
+	mu   sync.Mutex
+	last map[string]coalescedWrite
+}
+
+// coalesce records the write of value under key and reports whether the
+// backend write can be skipped. The value passed in must be normalized by
+// the caller, i.e. have volatile timestamp fields zeroed out.
+func (c *presenceCoalescer) coalesce(kind string, key []byte, value []byte, now, expires time.Time) bool {
+	interval := presenceWriteIntervals[kind]
+	if interval <= 0 {
+		return false
+	}
+	digest := sha256.Sum256(value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.last[string(key)]; ok &&
+		prev.digest == digest &&
+		now.Sub(prev.written) < interval &&
+		prev.expires.After(now.Add(interval)) {
+		return true
+	}
+	if c.last == nil {
+		c.last = make(map[string]coalescedWrite)
+	}
+	c.last[string(key)] = coalescedWrite{
+		digest:  digest,
+		written: now,
+		expires: expires,
+	}
+	return false
+}
+
+// forget drops the remembered write for a key, forcing the next write
+// through to the backend.
+func (c *presenceCoalescer) forget(key []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.last, string(key))
+}
+
+// reset drops all remembered writes.
+func (c *presenceCoalescer) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = nil
+}
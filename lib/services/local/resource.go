@@ -99,6 +99,10 @@ func itemsFromResource(resource types.Resource) ([]backend.Item, error) {
 		item, err = itemFromOIDCConnector(r)
 	case types.SAMLConnector:
 		item, err = itemFromSAMLConnector(r)
+	case types.ProvisionToken:
+		item, err = itemFromProvisionToken(r)
+	case types.AuthPreference:
+		item, err = itemFromAuthPreference(r)
 	default:
 		return nil, trace.NotImplemented("cannot itemFrom resource of type %T", resource)
 	}
@@ -162,6 +166,10 @@ func itemToResource(item backend.Item) (types.Resource, error) {
 		rsc, err = itemToOIDCConnector(item)
 	case types.KindSAMLConnector:
 		rsc, err = itemToSAMLConnector(item)
+	case types.KindToken:
+		rsc, err = itemToProvisionToken(item)
+	case types.KindClusterAuthPreference:
+		rsc, err = itemToAuthPreference(item)
 	case types.KindMFADevice:
 		rsc, err = itemToMFADevice(item)
 	case "":
@@ -406,6 +414,72 @@ func itemToSAMLConnector(item backend.Item) (types.SAMLConnector, error) {
 	return connector, nil
 }
 
+// itemFromProvisionToken attempts to encode the supplied provisioning token
+// as an instance of `backend.Item` suitable for storage.
+func itemFromProvisionToken(p types.ProvisionToken) (*backend.Item, error) {
+	if err := p.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	value, err := services.MarshalProvisionToken(p)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	item := &backend.Item{
+		Key:     backend.Key(tokensPrefix, p.GetName()),
+		Value:   value,
+		Expires: p.Expiry(),
+		ID:      p.GetResourceID(),
+	}
+	return item, nil
+}
+
+// itemToProvisionToken attempts to decode the supplied `backend.Item` as
+// a provisioning token resource.
+func itemToProvisionToken(item backend.Item) (types.ProvisionToken, error) {
+	token, err := services.UnmarshalProvisionToken(
+		item.Value,
+		services.WithResourceID(item.ID),
+		services.WithExpires(item.Expires),
+	)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return token, nil
+}
+
+// itemFromAuthPreference attempts to encode the supplied auth preference
+// as an instance of `backend.Item` suitable for storage.
+func itemFromAuthPreference(ap types.AuthPreference) (*backend.Item, error) {
+	if err := ap.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	value, err := services.MarshalAuthPreference(ap)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	item := &backend.Item{
+		Key:     backend.Key(authPrefix, preferencePrefix, generalPrefix),
+		Value:   value,
+		Expires: ap.Expiry(),
+		ID:      ap.GetResourceID(),
+	}
+	return item, nil
+}
+
+// itemToAuthPreference attempts to decode the supplied `backend.Item` as
+// an auth preference resource.
+func itemToAuthPreference(item backend.Item) (types.AuthPreference, error) {
+	ap, err := services.UnmarshalAuthPreference(
+		item.Value,
+		services.WithResourceID(item.ID),
+		services.WithExpires(item.Expires),
+	)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ap, nil
+}
+
 func itemToMFADevice(item backend.Item) (*types.MFADevice, error) {
 	var d types.MFADevice
 	err := json.Unmarshal(item.Value, &d)
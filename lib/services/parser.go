@@ -181,6 +181,10 @@ type Context struct {
 	Session events.AuditEvent
 	// SSHSession is an optional (active) SSH session.
 	SSHSession *session.Session
+	// ClientOrigin is the kind of client the request came from, e.g. a web
+	// UI session or a tsh login, taken from the authenticated identity. It
+	// is empty for identities that predate origin tracking.
+	ClientOrigin string
 }
 
 // String returns user friendly representation of this context
@@ -205,6 +209,9 @@ const (
 	ImpersonateRoleIdentifier = "impersonate_role"
 	// ImpersonateUserIdentifier is a user to impersonate
 	ImpersonateUserIdentifier = "impersonate_user"
+	// ClientOriginIdentifier refers to the kind of client the request came
+	// from, e.g. "web" for the web UI or "tsh" for a tsh login.
+	ClientOriginIdentifier = "client_origin"
 )
 
 // GetResource returns resource specified in the context,
@@ -246,6 +253,11 @@ func (ctx *Context) GetIdentifier(fields []string) (interface{}, error) {
 		// Do not expose the original session.Session, instead transform it into a
 		// ctxSession so the exposed fields match our desired API.
 		return predicate.GetFieldByTag(toCtxSession(ctx.SSHSession), teleport.JSON, fields[1:])
+	case ClientOriginIdentifier:
+		if len(fields) > 1 {
+			return nil, trace.NotFound("%v is not defined", strings.Join(fields, "."))
+		}
+		return ctx.ClientOrigin, nil
 	default:
 		return nil, trace.NotFound("%v is not defined", strings.Join(fields, "."))
 	}
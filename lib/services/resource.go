@@ -510,6 +510,44 @@ func init() {
 		}
 		return role, nil
 	})
+
+	RegisterResourceMarshaler(types.KindToken, func(resource types.Resource, opts ...MarshalOption) ([]byte, error) {
+		token, ok := resource.(types.ProvisionToken)
+		if !ok {
+			return nil, trace.BadParameter("expected ProvisionToken, got %T", resource)
+		}
+		bytes, err := MarshalProvisionToken(token, opts...)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return bytes, nil
+	})
+	RegisterResourceUnmarshaler(types.KindToken, func(bytes []byte, opts ...MarshalOption) (types.Resource, error) {
+		token, err := UnmarshalProvisionToken(bytes, opts...)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return token, nil
+	})
+
+	RegisterResourceMarshaler(types.KindClusterAuthPreference, func(resource types.Resource, opts ...MarshalOption) ([]byte, error) {
+		authPref, ok := resource.(types.AuthPreference)
+		if !ok {
+			return nil, trace.BadParameter("expected AuthPreference, got %T", resource)
+		}
+		bytes, err := MarshalAuthPreference(authPref, opts...)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return bytes, nil
+	})
+	RegisterResourceUnmarshaler(types.KindClusterAuthPreference, func(bytes []byte, opts ...MarshalOption) (types.Resource, error) {
+		authPref, err := UnmarshalAuthPreference(bytes, opts...)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return authPref, nil
+	})
 }
 
 // MarshalResource attempts to marshal a resource dynamically, returning NotImplementedError
@@ -425,10 +425,22 @@ func serverMapValues(serverMap map[string]types.Server) []types.Server {
 	return servers
 }
 
+// LockAcker acknowledges to the auth server that lock updates have been
+// committed to the local view, so lock propagation latency can be measured.
+type LockAcker interface {
+	// AckLockPropagation records that the calling agent has committed a lock
+	// to its local view.
+	AckLockPropagation(ctx context.Context, lockName string) error
+}
+
 // LockWatcherConfig is a LockWatcher configuration.
 type LockWatcherConfig struct {
 	ResourceWatcherConfig
 	LockGetter
+	// Acker, if set, is notified once lock updates have been committed to
+	// the local view. It defaults to the Client when the Client supports
+	// acknowledgments.
+	Acker LockAcker
 }
 
 // CheckAndSetDefaults checks parameters and sets default values.
@@ -439,6 +451,11 @@ func (cfg *LockWatcherConfig) CheckAndSetDefaults() error {
 	if cfg.MaxStaleness == 0 {
 		cfg.MaxStaleness = defaults.LockMaxStaleness
 	}
+	if cfg.Acker == nil {
+		if acker, ok := cfg.Client.(LockAcker); ok {
+			cfg.Acker = acker
+		}
+	}
 	if cfg.LockGetter == nil {
 		getter, ok := cfg.Client.(LockGetter)
 		if !ok {
@@ -593,6 +610,7 @@ func (p *lockCollector) processEventAndUpdateCurrent(ctx context.Context, event
 		if lock.IsInForce(p.Clock.Now()) {
 			p.current[lock.GetName()] = lock
 			p.fanout.Emit(event)
+			p.ackLock(ctx, lock.GetName())
 		} else {
 			delete(p.current, lock.GetName())
 		}
@@ -601,6 +619,19 @@ func (p *lockCollector) processEventAndUpdateCurrent(ctx context.Context, event
 	}
 }
 
+// ackLock acknowledges a committed lock to the auth server without blocking
+// the event loop; acknowledgment failures only degrade propagation metrics.
+func (p *lockCollector) ackLock(ctx context.Context, lockName string) {
+	if p.Acker == nil {
+		return
+	}
+	go func() {
+		if err := p.Acker.AckLockPropagation(ctx, lockName); err != nil {
+			p.Log.WithError(err).Debugf("Failed to acknowledge lock %q.", lockName)
+		}
+	}()
+}
+
 // notifyStale is called when the maximum acceptable staleness (if specified)
 // is exceeded.
 func (p *lockCollector) notifyStale() {
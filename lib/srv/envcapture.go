@@ -0,0 +1,107 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/gravitational/trace"
+)
+
+// envRedactedValue replaces environment variable values matching a
+// redaction rule in captured session metadata.
+const envRedactedValue = "<redacted>"
+
+// TshVersionEnvVar is the environment variable tsh uses to advertise its
+// version to the session; when present it is captured alongside the rest
+// of the session environment.
+const TshVersionEnvVar = "TELEPORT_TSH_VERSION"
+
+// defaultEnvRedactPatterns matches environment variable names that commonly
+// hold credentials and must never make it into the audit log verbatim.
+var defaultEnvRedactPatterns = []string{
+	`(?i)(secret|token|password|passwd|credential|private|api_?key)`,
+}
+
+// EnvCapturePolicy controls whether and how client environment variables
+// are captured into session audit metadata. Values of variables whose name
+// matches a redaction pattern are replaced before emission, so the captured
+// snapshot aids forensic reconstruction without archiving secrets.
+type EnvCapturePolicy struct {
+	// Enabled turns environment capture on; disabled by default.
+	Enabled bool
+	// RedactPatterns are regular expressions matched against variable
+	// names, matching values are replaced with a placeholder. When empty
+	// a default list covering common credential names is used.
+	RedactPatterns []string
+	// MaxValueLength truncates captured values, defaults to 256.
+	MaxValueLength int
+
+	compiled []*regexp.Regexp
+}
+
+// CheckAndSetDefaults validates the policy and compiles the redaction
+// rules.
+func (p *EnvCapturePolicy) CheckAndSetDefaults() error {
+	if len(p.RedactPatterns) == 0 {
+		p.RedactPatterns = defaultEnvRedactPatterns
+	}
+	if p.MaxValueLength == 0 {
+		p.MaxValueLength = 256
+	}
+	p.compiled = nil
+	for _, pattern := range p.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return trace.BadParameter("invalid env capture redaction pattern %q: %v", pattern, err)
+		}
+		p.compiled = append(p.compiled, re)
+	}
+	return nil
+}
+
+// Capture renders a redacted, sorted KEY=VALUE snapshot of the session
+// environment. The visit function is expected to walk the environment the
+// way ServerContext.VisitEnv does.
+func (p *EnvCapturePolicy) Capture(visit func(func(key, val string))) []string {
+	if !p.Enabled {
+		return nil
+	}
+	// later visits win so child contexts override parent values, the way
+	// the environment is assembled for the session itself
+	captured := make(map[string]string)
+	visit(func(key, val string) {
+		for _, re := range p.compiled {
+			if re.MatchString(key) {
+				val = envRedactedValue
+				break
+			}
+		}
+		if len(val) > p.MaxValueLength {
+			val = val[:p.MaxValueLength]
+		}
+		captured[key] = val
+	})
+	pairs := make([]string, 0, len(captured))
+	for key, val := range captured {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", key, val))
+	}
+	sort.Strings(pairs)
+	return pairs
+}
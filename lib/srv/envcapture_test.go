@@ -0,0 +1,63 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvCapturePolicy(t *testing.T) {
+	t.Parallel()
+
+	visit := func(fn func(key, val string)) {
+		fn("TERM", "xterm-256color")
+		fn("AWS_SECRET_ACCESS_KEY", "hunter2")
+		fn("MY_API_TOKEN", "abcdef")
+		fn("LONG", strings.Repeat("x", 1024))
+		fn(TshVersionEnvVar, "11.0.0")
+	}
+
+	// disabled policies capture nothing
+	disabled := EnvCapturePolicy{}
+	require.NoError(t, disabled.CheckAndSetDefaults())
+	require.Empty(t, disabled.Capture(visit))
+
+	policy := EnvCapturePolicy{Enabled: true}
+	require.NoError(t, policy.CheckAndSetDefaults())
+	pairs := policy.Capture(visit)
+	require.Equal(t, []string{
+		"AWS_SECRET_ACCESS_KEY=" + envRedactedValue,
+		"LONG=" + strings.Repeat("x", 256),
+		"MY_API_TOKEN=" + envRedactedValue,
+		"TELEPORT_TSH_VERSION=11.0.0",
+		"TERM=xterm-256color",
+	}, pairs)
+
+	// later visits override earlier ones, mirroring environment assembly
+	pairs = policy.Capture(func(fn func(key, val string)) {
+		fn("TERM", "dumb")
+		fn("TERM", "xterm")
+	})
+	require.Equal(t, []string{"TERM=xterm"}, pairs)
+
+	// invalid redaction patterns are rejected
+	invalid := EnvCapturePolicy{Enabled: true, RedactPatterns: []string{"("}}
+	require.Error(t, invalid.CheckAndSetDefaults())
+}
@@ -221,6 +221,10 @@ type Server struct {
 	// users is used to start the automatic user deletion loop
 	users srv.HostUsers
 
+	// envCapturePolicy controls capture of session environment variables
+	// into audit metadata.
+	envCapturePolicy srv.EnvCapturePolicy
+
 	// cloudWatcher periodically retrieves cloud resources, currently
 	// only EC2
 	cloudWatcher *server.Watcher
@@ -602,6 +606,15 @@ func SetAllowTCPForwarding(allow bool) ServerOption {
 	}
 }
 
+// SetEnvCapturePolicy sets the policy controlling capture of session
+// environment variables into audit metadata.
+func SetEnvCapturePolicy(policy srv.EnvCapturePolicy) ServerOption {
+	return func(s *Server) error {
+		s.envCapturePolicy = policy
+		return nil
+	}
+}
+
 // SetLockWatcher sets the server's lock watcher.
 func SetLockWatcher(lockWatcher *services.LockWatcher) ServerOption {
 	return func(s *Server) error {
@@ -772,6 +785,7 @@ func New(addr utils.NetAddr,
 	s.reg, err = srv.NewSessionRegistry(srv.SessionRegistryConfig{
 		Srv:                   s,
 		SessionTrackerService: auth,
+		EnvCapture:            s.envCapturePolicy,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -806,6 +820,18 @@ func New(addr utils.NetAddr,
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		// surface installation failures as cluster alerts when the access
+		// point supports them
+		alerts, _ := s.authService.(server.ClusterAlerter)
+		installer, err := server.NewSSMInstaller(server.SSMInstallerConfig{
+			Alerts: alerts,
+			Clock:  s.clock,
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		go s.cloudWatcher.Run()
+		go s.handleDiscoveredInstances(installer)
 	}
 
 	server, err := sshutils.NewServer(
@@ -977,6 +1003,24 @@ func (s *Server) GetInfo() types.Server {
 	return s.getBasicInfo()
 }
 
+// handleDiscoveredInstances installs the teleport agent on EC2 instances
+// discovered by the cloud watcher via SSM RunCommand.
+func (s *Server) handleDiscoveredInstances(installer *server.SSMInstaller) {
+	for instances := range s.cloudWatcher.InstancesC {
+		ssmClient, err := s.clients.GetAWSSSMClient(instances.Region)
+		if err != nil {
+			s.Logger.WithError(err).Errorf("Failed to get SSM client for region %v.", instances.Region)
+			continue
+		}
+		if err := installer.Run(s.ctx, server.SSMRunRequest{
+			Instances: instances,
+			SSM:       ssmClient,
+		}); err != nil {
+			s.Logger.WithError(err).Error("Failed to install teleport on discovered EC2 instances.")
+		}
+	}
+}
+
 func (s *Server) getBasicInfo() *types.ServerV2 {
 	// Only set the address for non-tunnel nodes.
 	var addr string
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+// ClusterAlerter is the subset of the auth API the installer uses to
+// surface installation failures as cluster alerts.
+type ClusterAlerter interface {
+	// UpsertClusterAlert creates or updates a cluster alert.
+	UpsertClusterAlert(ctx context.Context, alert types.ClusterAlert) error
+}
+
+// SSMInstallationStatus describes the latest known outcome of running the
+// installer document on a single EC2 instance.
+type SSMInstallationStatus struct {
+	// InstanceID is the EC2 instance the installer ran on.
+	InstanceID string
+	// AccountID is the AWS account the instance belongs to.
+	AccountID string
+	// Region is the AWS region the instance is located in.
+	Region string
+	// CommandID is the SSM RunCommand invocation ID.
+	CommandID string
+	// Status is the SSM invocation status, e.g. Success or Failed.
+	Status string
+	// Error holds the failure details when the invocation did not succeed.
+	Error string
+	// UpdatedAt is when the status was last refreshed.
+	UpdatedAt time.Time
+}
+
+// SSMInstallerConfig configures the SSM installer.
+type SSMInstallerConfig struct {
+	// Alerts is used to surface installation failures as cluster alerts,
+	// optional.
+	Alerts ClusterAlerter
+	// Clock is used to stamp installation statuses.
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults checks and sets defaults.
+func (c *SSMInstallerConfig) CheckAndSetDefaults() error {
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// SSMInstaller runs the installer document on discovered EC2 instances via
+// AWS SSM RunCommand and keeps track of per-instance installation status.
+type SSMInstaller struct {
+	cfg SSMInstallerConfig
+
+	mu sync.Mutex
+	// status is the latest installation status keyed by instance ID.
+	status map[string]SSMInstallationStatus
+}
+
+// SSMRunRequest bundles a batch of discovered instances with the SSM client
+// of their region.
+type SSMRunRequest struct {
+	// Instances is a batch of discovered EC2 instances sharing one region
+	// and installer document.
+	Instances EC2Instances
+	// SSM is the SSM client for the region the instances are located in.
+	SSM ssmiface.SSMAPI
+}
+
+// NewSSMInstaller returns a new SSM installer.
+func NewSSMInstaller(cfg SSMInstallerConfig) (*SSMInstaller, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &SSMInstaller{
+		cfg:    cfg,
+		status: make(map[string]SSMInstallationStatus),
+	}, nil
+}
+
+// Run sends the installer document to the instances in the request and waits
+// for every invocation to reach a terminal state.
+func (i *SSMInstaller) Run(ctx context.Context, req SSMRunRequest) error {
+	if req.Instances.Document == "" {
+		return trace.BadParameter("missing SSM document name")
+	}
+	ids := make([]string, 0, len(req.Instances.Instances))
+	for _, instance := range req.Instances.Instances {
+		ids = append(ids, aws.StringValue(instance.InstanceId))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	params := make(map[string][]*string)
+	for key, value := range req.Instances.Parameters {
+		params[key] = []*string{aws.String(value)}
+	}
+
+	output, err := req.SSM.SendCommandWithContext(ctx, &ssm.SendCommandInput{
+		DocumentName: aws.String(req.Instances.Document),
+		InstanceIds:  aws.StringSlice(ids),
+		Parameters:   params,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	commandID := aws.StringValue(output.Command.CommandId)
+
+	var errs []error
+	for _, id := range ids {
+		if err := i.waitForInstance(ctx, req, commandID, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return trace.NewAggregate(errs...)
+}
+
+// waitForInstance waits for one invocation to finish, records its status and
+// raises a cluster alert when it failed.
+func (i *SSMInstaller) waitForInstance(ctx context.Context, req SSMRunRequest, commandID, instanceID string) error {
+	invocation := &ssm.GetCommandInvocationInput{
+		CommandId:  aws.String(commandID),
+		InstanceId: aws.String(instanceID),
+	}
+	status := SSMInstallationStatus{
+		InstanceID: instanceID,
+		AccountID:  req.Instances.AccountID,
+		Region:     req.Instances.Region,
+		CommandID:  commandID,
+	}
+	err := req.SSM.WaitUntilCommandExecutedWithContext(ctx, invocation)
+	result, invErr := req.SSM.GetCommandInvocationWithContext(ctx, invocation)
+	switch {
+	case invErr != nil:
+		status.Status = ssm.CommandInvocationStatusFailed
+		status.Error = invErr.Error()
+	default:
+		status.Status = aws.StringValue(result.Status)
+		if err != nil || status.Status != ssm.CommandInvocationStatusSuccess {
+			status.Error = aws.StringValue(result.StandardErrorContent)
+		}
+	}
+	i.setStatus(status)
+
+	if status.Error == "" && status.Status == ssm.CommandInvocationStatusSuccess {
+		return nil
+	}
+	i.alertFailure(ctx, status)
+	return trace.Errorf("failed to install teleport on instance %v: %v %v",
+		instanceID, status.Status, status.Error)
+}
+
+// setStatus records the latest status of one instance.
+func (i *SSMInstaller) setStatus(status SSMInstallationStatus) {
+	status.UpdatedAt = i.cfg.Clock.Now().UTC()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.status[status.InstanceID] = status
+}
+
+// Status returns the latest known installation status of every instance the
+// installer has run on.
+func (i *SSMInstaller) Status() []SSMInstallationStatus {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	statuses := make([]SSMInstallationStatus, 0, len(i.status))
+	for _, status := range i.status {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// alertFailure surfaces a failed installation as a cluster alert so it is
+// visible without digging through service logs.
+func (i *SSMInstaller) alertFailure(ctx context.Context, status SSMInstallationStatus) {
+	if i.cfg.Alerts == nil {
+		return
+	}
+	alert, err := types.NewClusterAlert(
+		fmt.Sprintf("ec2-install-failure-%v", status.InstanceID),
+		fmt.Sprintf("Automatic agent installation failed on EC2 instance %v (%v/%v): %v %v",
+			status.InstanceID, status.AccountID, status.Region, status.Status, status.Error),
+		types.WithAlertSeverity(types.AlertSeverity_MEDIUM),
+	)
+	if err != nil {
+		log.WithError(err).Warn("Failed to build cluster alert for failed EC2 installation.")
+		return
+	}
+	if err := i.cfg.Alerts.UpsertClusterAlert(ctx, alert); err != nil {
+		log.WithError(err).Warn("Failed to upsert cluster alert for failed EC2 installation.")
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+type mockSSMClient struct {
+	ssmiface.SSMAPI
+	// statuses maps instance IDs to the final invocation status.
+	statuses map[string]string
+	// sent records the last SendCommand input.
+	sent *ssm.SendCommandInput
+}
+
+func (m *mockSSMClient) SendCommandWithContext(_ context.Context, input *ssm.SendCommandInput, _ ...awsrequest.Option) (*ssm.SendCommandOutput, error) {
+	m.sent = input
+	return &ssm.SendCommandOutput{
+		Command: &ssm.Command{CommandId: aws.String("command-id-1")},
+	}, nil
+}
+
+func (m *mockSSMClient) WaitUntilCommandExecutedWithContext(_ context.Context, input *ssm.GetCommandInvocationInput, _ ...awsrequest.WaiterOption) error {
+	return nil
+}
+
+func (m *mockSSMClient) GetCommandInvocationWithContext(_ context.Context, input *ssm.GetCommandInvocationInput, _ ...awsrequest.Option) (*ssm.GetCommandInvocationOutput, error) {
+	return &ssm.GetCommandInvocationOutput{
+		Status:               aws.String(m.statuses[aws.StringValue(input.InstanceId)]),
+		StandardErrorContent: aws.String("command exited with status 1"),
+	}, nil
+}
+
+type mockAlerter struct {
+	alerts []types.ClusterAlert
+}
+
+func (m *mockAlerter) UpsertClusterAlert(_ context.Context, alert types.ClusterAlert) error {
+	m.alerts = append(m.alerts, alert)
+	return nil
+}
+
+func TestSSMInstaller(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	client := &mockSSMClient{statuses: map[string]string{
+		"i-success": ssm.CommandInvocationStatusSuccess,
+		"i-failure": ssm.CommandInvocationStatusFailed,
+	}}
+	alerter := &mockAlerter{}
+	installer, err := NewSSMInstaller(SSMInstallerConfig{Alerts: alerter})
+	require.NoError(t, err)
+
+	err = installer.Run(ctx, SSMRunRequest{
+		SSM: client,
+		Instances: EC2Instances{
+			Region:     "us-west-2",
+			AccountID:  "123456789012",
+			Document:   "teleport-installer",
+			Parameters: map[string]string{"token": "join-token"},
+			Instances: []*ec2.Instance{
+				{InstanceId: aws.String("i-success")},
+				{InstanceId: aws.String("i-failure")},
+			},
+		},
+	})
+	require.Error(t, err)
+
+	require.Equal(t, "teleport-installer", aws.StringValue(client.sent.DocumentName))
+	require.Equal(t, []*string{aws.String("join-token")}, client.sent.Parameters["token"])
+
+	statuses := make(map[string]SSMInstallationStatus)
+	for _, status := range installer.Status() {
+		statuses[status.InstanceID] = status
+	}
+	require.Len(t, statuses, 2)
+	require.Equal(t, ssm.CommandInvocationStatusSuccess, statuses["i-success"].Status)
+	require.Empty(t, statuses["i-success"].Error)
+	require.Equal(t, ssm.CommandInvocationStatusFailed, statuses["i-failure"].Status)
+	require.NotEmpty(t, statuses["i-failure"].Error)
+
+	// only the failed instance raised a cluster alert
+	require.Len(t, alerter.alerts, 1)
+	require.Equal(t, "ec2-install-failure-i-failure", alerter.alerts[0].Metadata.Name)
+}
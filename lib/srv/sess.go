@@ -104,6 +104,10 @@ type SessionRegistryConfig struct {
 	// sessiontrackerService is used to share session activity to
 	// other teleport components through the auth server.
 	SessionTrackerService services.SessionTrackerService
+
+	// EnvCapture controls capture of client environment variables into
+	// session audit metadata, disabled unless configured.
+	EnvCapture EnvCapturePolicy
 }
 
 func (sc *SessionRegistryConfig) CheckAndSetDefaults() error {
@@ -115,6 +119,10 @@ func (sc *SessionRegistryConfig) CheckAndSetDefaults() error {
 		return trace.BadParameter("server is required")
 	}
 
+	if err := sc.EnvCapture.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
 	if sc.clock == nil {
 		sc.clock = sc.Srv.GetClock()
 	}
@@ -711,6 +719,43 @@ func (s *session) emitSessionStartEvent(ctx *ServerContext) {
 	if err := s.emitAuditEvent(ctx.srv.Context(), sessionStartEvent); err != nil {
 		s.log.WithError(err).Warn("Failed to emit session start event.")
 	}
+
+	s.emitSessionEnvEvent(ctx)
+}
+
+// emitSessionEnvEvent emits a snapshot of the session environment when
+// capture is enabled. The event reuses the session start shape: the
+// InitialCommand field carries the redacted KEY=VALUE pairs and the
+// terminal size records the PTY dimensions at capture time.
+func (s *session) emitSessionEnvEvent(ctx *ServerContext) {
+	pairs := s.registry.EnvCapture.Capture(ctx.VisitEnv)
+	if len(pairs) == 0 {
+		return
+	}
+	sessionEnvEvent := &apievents.SessionStart{
+		Metadata: apievents.Metadata{
+			Type:        events.SessionEnvEvent,
+			Code:        events.SessionEnvCode,
+			ClusterName: ctx.ClusterName,
+			ID:          uuid.New().String(),
+		},
+		ServerMetadata: s.serverMeta,
+		SessionMetadata: apievents.SessionMetadata{
+			SessionID: string(s.id),
+		},
+		UserMetadata: ctx.Identity.GetUserMetadata(),
+		ConnectionMetadata: apievents.ConnectionMetadata{
+			RemoteAddr: ctx.ServerConn.RemoteAddr().String(),
+		},
+		InitialCommand: pairs,
+	}
+	if s.term != nil {
+		params := s.term.GetTerminalParams()
+		sessionEnvEvent.TerminalSize = params.Serialize()
+	}
+	if err := s.emitAuditEvent(ctx.srv.Context(), sessionEnvEvent); err != nil {
+		s.log.WithError(err).Warn("Failed to emit session env event.")
+	}
 }
 
 // emitSessionJoinEvent emits a session join event to both the Audit Log as
@@ -24,6 +24,9 @@ import (
 
 	"github.com/gravitational/trace"
 
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
 	rsession "github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/sshutils"
 )
@@ -47,6 +50,11 @@ func (t *TermHandlers) HandleExec(ctx context.Context, ch ssh.Channel, req *ssh.
 		return trace.Wrap(err)
 	}
 
+	// Enforce role command policies before the command is started.
+	if err := t.checkCommandPolicy(ctx, scx, scx.ExecRequest.GetCommand()); err != nil {
+		return trace.Wrap(err)
+	}
+
 	// If a terminal was previously allocated for this command, run command in
 	// an interactive session. Otherwise run it in an exec session.
 	if scx.GetTerm() != nil {
@@ -114,6 +122,13 @@ func (t *TermHandlers) HandleShell(ctx context.Context, ch ssh.Channel, req *ssh
 	if err != nil {
 		return trace.Wrap(err)
 	}
+
+	// Enforce role command policies: an unrestricted shell would bypass a
+	// command allow-list.
+	if err := t.checkCommandPolicy(ctx, scx, ""); err != nil {
+		return trace.Wrap(err)
+	}
+
 	if err := t.SessionRegistry.OpenSession(ctx, ch, scx); err != nil {
 		return trace.Wrap(err)
 	}
@@ -121,6 +136,52 @@ func (t *TermHandlers) HandleShell(ctx context.Context, ch ssh.Channel, req *ssh
 	return nil
 }
 
+// checkCommandPolicy enforces the command allow/deny rules carried by the
+// user's roles before a session is opened. An empty command stands for an
+// interactive shell. Violations are recorded in the audit log as rejected
+// sessions.
+func (t *TermHandlers) checkCommandPolicy(ctx context.Context, scx *ServerContext, command string) error {
+	policy, err := services.NewCommandPolicy(scx.Identity.AccessChecker.Roles())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if policy.IsEmpty() {
+		return nil
+	}
+
+	var policyErr error
+	if command == "" {
+		policyErr = policy.CheckInteractiveShell()
+	} else {
+		policyErr = policy.CheckCommand(command)
+	}
+	if policyErr == nil {
+		return nil
+	}
+
+	if err := scx.GetServer().EmitAuditEvent(ctx, &apievents.SessionReject{
+		Metadata: apievents.Metadata{
+			Type: events.SessionRejectedEvent,
+			Code: events.SessionRejectedCode,
+		},
+		UserMetadata: scx.Identity.GetUserMetadata(),
+		ConnectionMetadata: apievents.ConnectionMetadata{
+			Protocol:   events.EventProtocolSSH,
+			LocalAddr:  scx.ServerConn.LocalAddr().String(),
+			RemoteAddr: scx.ServerConn.RemoteAddr().String(),
+		},
+		ServerMetadata: apievents.ServerMetadata{
+			ServerID:        scx.GetServer().HostUUID(),
+			ServerNamespace: scx.GetServer().GetNamespace(),
+		},
+		Reason: events.SessionRejectedReasonCommandPolicy,
+	}); err != nil {
+		scx.WithError(err).Warn("Failed to emit session reject event.")
+	}
+
+	return trace.Wrap(policyErr)
+}
+
 // HandleWinChange handles requests of type "window-change" which update the
 // size of the PTY running on the server and update any other members in the
 // party.
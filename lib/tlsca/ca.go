@@ -170,8 +170,34 @@ type Identity struct {
 	// AllowedResourceIDs lists the resources the identity should be allowed to
 	// access.
 	AllowedResourceIDs []types.ResourceID
+	// Scopes restricts the API operations this identity may perform. An
+	// empty list means the identity is unrestricted.
+	Scopes []string
+	// PrivateKeyPolicy is the private key policy the subject private key was
+	// verified to satisfy, e.g. that it resides on a hardware token.
+	PrivateKeyPolicy string
+	// ClientOrigin records what kind of client the identity was issued to,
+	// e.g. a web UI session or a tsh login. An empty origin means the
+	// identity predates origin tracking or was issued out of band.
+	ClientOrigin string
+	// PinnedIP is the source IP the certificate is pinned to: TLS
+	// authorizers reject connections presenting the certificate from any
+	// other address. Empty means the certificate is not pinned.
+	PinnedIP string
+	// DeviceID is the ID of the trusted device the certificate was issued
+	// to, empty when the certificate was issued without a device check.
+	DeviceID string
 }
 
+// Client origins recorded in identities. Role conditions can match on them
+// to restrict operations to specific client types.
+const (
+	// ClientOriginWeb marks identities issued to web UI sessions.
+	ClientOriginWeb = "web"
+	// ClientOriginTSH marks identities issued through a tsh login.
+	ClientOriginTSH = "tsh"
+)
+
 // RouteToApp holds routing information for applications.
 type RouteToApp struct {
 	// SessionID is a UUIDv4 used to identify application sessions created by
@@ -401,6 +427,26 @@ var (
 	// system role, and use `pkix.Name.Organization` to encode this value. This extension
 	// is specifically used for "multi-role" certs.
 	SystemRolesASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 11}
+
+	// APIScopesASN1ExtensionOID is an extension OID used to list the API
+	// scopes the certificate is restricted to.
+	APIScopesASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 12}
+
+	// PrivateKeyPolicyASN1ExtensionOID is an extension OID used to encode the
+	// private key policy the subject key was verified to satisfy.
+	PrivateKeyPolicyASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 13}
+
+	// ClientOriginASN1ExtensionOID is an extension OID used to encode the
+	// kind of client the certificate was issued to.
+	ClientOriginASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 14}
+
+	// DeviceIDASN1ExtensionOID is an extension OID used to encode the ID of
+	// the trusted device the certificate was issued to.
+	DeviceIDASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 15}
+
+	// PinnedIPASN1ExtensionOID is an extension OID used to encode the
+	// source IP the certificate is pinned to.
+	PinnedIPASN1ExtensionOID = asn1.ObjectIdentifier{1, 3, 9999, 2, 16}
 )
 
 // Subject converts identity to X.509 subject name
@@ -623,6 +669,51 @@ func (id *Identity) Subject() (pkix.Name, error) {
 		)
 	}
 
+	for i := range id.Scopes {
+		subject.ExtraNames = append(subject.ExtraNames,
+			pkix.AttributeTypeAndValue{
+				Type:  APIScopesASN1ExtensionOID,
+				Value: id.Scopes[i],
+			},
+		)
+	}
+
+	if id.PrivateKeyPolicy != "" {
+		subject.ExtraNames = append(subject.ExtraNames,
+			pkix.AttributeTypeAndValue{
+				Type:  PrivateKeyPolicyASN1ExtensionOID,
+				Value: id.PrivateKeyPolicy,
+			},
+		)
+	}
+
+	if id.ClientOrigin != "" {
+		subject.ExtraNames = append(subject.ExtraNames,
+			pkix.AttributeTypeAndValue{
+				Type:  ClientOriginASN1ExtensionOID,
+				Value: id.ClientOrigin,
+			},
+		)
+	}
+
+	if id.DeviceID != "" {
+		subject.ExtraNames = append(subject.ExtraNames,
+			pkix.AttributeTypeAndValue{
+				Type:  DeviceIDASN1ExtensionOID,
+				Value: id.DeviceID,
+			},
+		)
+	}
+
+	if id.PinnedIP != "" {
+		subject.ExtraNames = append(subject.ExtraNames,
+			pkix.AttributeTypeAndValue{
+				Type:  PinnedIPASN1ExtensionOID,
+				Value: id.PinnedIP,
+			},
+		)
+	}
+
 	return subject, nil
 }
 
@@ -782,6 +873,31 @@ func FromSubject(subject pkix.Name, expires time.Time) (*Identity, error) {
 				}
 				id.AllowedResourceIDs = allowedResourceIDs
 			}
+		case attr.Type.Equal(APIScopesASN1ExtensionOID):
+			val, ok := attr.Value.(string)
+			if ok {
+				id.Scopes = append(id.Scopes, val)
+			}
+		case attr.Type.Equal(PrivateKeyPolicyASN1ExtensionOID):
+			val, ok := attr.Value.(string)
+			if ok {
+				id.PrivateKeyPolicy = val
+			}
+		case attr.Type.Equal(ClientOriginASN1ExtensionOID):
+			val, ok := attr.Value.(string)
+			if ok {
+				id.ClientOrigin = val
+			}
+		case attr.Type.Equal(DeviceIDASN1ExtensionOID):
+			val, ok := attr.Value.(string)
+			if ok {
+				id.DeviceID = val
+			}
+		case attr.Type.Equal(PinnedIPASN1ExtensionOID):
+			val, ok := attr.Value.(string)
+			if ok {
+				id.PinnedIP = val
+			}
 		}
 	}
 
@@ -291,3 +291,25 @@ func CalculatePins(certsBytes []byte) ([]string, error) {
 	}
 	return pins, nil
 }
+
+// CalculatePinsWithAlgorithms returns one SPKI pin per certificate and
+// hashing algorithm for the given set of concatenated PEM-encoded
+// certificates, so joining scripts can carry pins of several algorithms
+// during a pin algorithm migration.
+func CalculatePinsWithAlgorithms(certsBytes []byte, algorithms []string) ([]string, error) {
+	certs, err := ParseCertificatePEMs(certsBytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pins := make([]string, 0, len(certs)*len(algorithms))
+	for _, cert := range certs {
+		for _, algorithm := range algorithms {
+			pin, err := utils.CalculateSPKIWithAlgorithm(cert, algorithm)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			pins = append(pins, pin)
+		}
+	}
+	return pins, nil
+}
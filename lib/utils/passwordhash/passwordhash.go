@@ -0,0 +1,157 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package passwordhash derives and verifies password hashes, supporting both
+// the historical bcrypt format and argon2id for deployments with modern KDF
+// requirements. The hashing method is recognized from the stored hash, so
+// the two formats can coexist during a migration.
+package passwordhash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MethodBcrypt identifies the bcrypt hashing method.
+	MethodBcrypt = "bcrypt"
+	// MethodArgon2id identifies the argon2id hashing method.
+	MethodArgon2id = "argon2id"
+
+	// argon2idPrefix starts every argon2id hash in the standard PHC string
+	// format: $argon2id$v=19$m=...,t=...,p=...$<salt>$<key>.
+	argon2idPrefix = "$argon2id$"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2Params tunes the argon2id key derivation.
+type Argon2Params struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Memory is the amount of memory used, in KiB.
+	Memory uint32
+	// Parallelism is the number of threads used.
+	Parallelism uint8
+}
+
+// DefaultArgon2Params returns the recommended argon2id parameters.
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Time:        1,
+		Memory:      64 * 1024,
+		Parallelism: 4,
+	}
+}
+
+// Bcrypt hashes the password with bcrypt at the default cost.
+func Bcrypt(password []byte) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	return hash, trace.Wrap(err)
+}
+
+// Argon2id hashes the password with argon2id under the given parameters,
+// producing a PHC-formatted string.
+func Argon2id(password []byte, params Argon2Params) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	key := argon2.IDKey(password, salt, params.Time, params.Memory, params.Parallelism, argon2KeyLength)
+	hash := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Time, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+	return []byte(hash), nil
+}
+
+// Method returns the hashing method the stored hash was derived with.
+func Method(hash []byte) string {
+	if bytes.HasPrefix(hash, []byte(argon2idPrefix)) {
+		return MethodArgon2id
+	}
+	return MethodBcrypt
+}
+
+// Compare checks the password against the stored hash, recognizing the
+// hashing method from the hash itself.
+func Compare(hash, password []byte) error {
+	if Method(hash) != MethodArgon2id {
+		return trace.Wrap(bcrypt.CompareHashAndPassword(hash, password))
+	}
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	computed := argon2.IDKey(password, salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return trace.BadParameter("password does not match")
+	}
+	return nil
+}
+
+// Validate checks that the stored hash is well-formed in either of the
+// supported formats.
+func Validate(hash []byte) error {
+	if Method(hash) == MethodArgon2id {
+		_, _, _, err := parseArgon2id(hash)
+		return trace.Wrap(err)
+	}
+	if _, err := bcrypt.Cost(hash); err != nil {
+		return trace.BadParameter("invalid password hash")
+	}
+	return nil
+}
+
+// parseArgon2id splits a PHC-formatted argon2id hash into its parameters,
+// salt and derived key.
+func parseArgon2id(hash []byte) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(string(hash), "$")
+	// Leading separator produces an empty first element:
+	// ["", "argon2id", "v=19", "m=...,t=...,p=...", salt, key]
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, trace.BadParameter("invalid argon2id hash format")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, trace.BadParameter("invalid argon2id hash version")
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, trace.BadParameter("unsupported argon2id version %v", version)
+	}
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, trace.BadParameter("invalid argon2id hash parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, trace.BadParameter("invalid argon2id hash salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, trace.BadParameter("invalid argon2id hash key")
+	}
+	return params, salt, key, nil
+}
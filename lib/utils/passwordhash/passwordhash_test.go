@@ -0,0 +1,61 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package passwordhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRoundTrip(t *testing.T) {
+	t.Parallel()
+	password := []byte("supersecurepassword")
+
+	bcryptHash, err := Bcrypt(password)
+	require.NoError(t, err)
+	require.Equal(t, MethodBcrypt, Method(bcryptHash))
+	require.NoError(t, Compare(bcryptHash, password))
+	require.Error(t, Compare(bcryptHash, []byte("wrong")))
+
+	argonHash, err := Argon2id(password, DefaultArgon2Params())
+	require.NoError(t, err)
+	require.Equal(t, MethodArgon2id, Method(argonHash))
+	require.NoError(t, Compare(argonHash, password))
+	require.Error(t, Compare(argonHash, []byte("wrong")))
+
+	// Salts are random, two hashes of the same password differ.
+	otherHash, err := Argon2id(password, DefaultArgon2Params())
+	require.NoError(t, err)
+	require.NotEqual(t, argonHash, otherHash)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	password := []byte("supersecurepassword")
+
+	bcryptHash, err := Bcrypt(password)
+	require.NoError(t, err)
+	require.NoError(t, Validate(bcryptHash))
+
+	argonHash, err := Argon2id(password, DefaultArgon2Params())
+	require.NoError(t, err)
+	require.NoError(t, Validate(argonHash))
+
+	require.Error(t, Validate([]byte("not a hash")))
+	require.Error(t, Validate([]byte("$argon2id$v=19$garbage")))
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/rand"
+
+	"github.com/gravitational/trace"
+)
+
+// SplitSecret splits a secret into n shares of which any threshold suffice
+// to reconstruct it, using Shamir secret sharing over GF(2^8). Each share is
+// one byte longer than the secret; fewer than threshold shares reveal
+// nothing about it.
+func SplitSecret(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, trace.BadParameter("can not split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, trace.BadParameter("the threshold must be at least 2, got %v", threshold)
+	}
+	if n < threshold {
+		return nil, trace.BadParameter("the number of shares %v is less than the threshold %v", n, threshold)
+	}
+	if n > 255 {
+		return nil, trace.BadParameter("at most 255 shares are supported, got %v", n)
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		// the share's evaluation point, zero is reserved for the secret
+		shares[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for i, secretByte := range secret {
+		// pick a random polynomial with the secret byte as the constant term
+		coefficients[0] = secretByte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, share := range shares {
+			share[i+1] = gfPolyEval(coefficients, share[0])
+		}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs a secret from shares produced by SplitSecret.
+// At least as many shares as the split threshold must be provided; the
+// shares themselves do not carry the threshold, providing too few yields
+// garbage rather than an error.
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, trace.BadParameter("at least 2 shares are required")
+	}
+	length := len(shares[0])
+	if length < 2 {
+		return nil, trace.BadParameter("malformed share")
+	}
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != length {
+			return nil, trace.BadParameter("shares have different lengths")
+		}
+		if seen[share[0]] {
+			return nil, trace.BadParameter("duplicate share provided")
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, length-1)
+	for i := range secret {
+		// interpolate the polynomial at zero to recover the secret byte
+		var value byte
+		for j, share := range shares {
+			basis := byte(1)
+			for k, other := range shares {
+				if j == k {
+					continue
+				}
+				basis = gfMul(basis, gfDiv(other[0], other[0]^share[0]))
+			}
+			value ^= gfMul(basis, share[i+1])
+		}
+		secret[i] = value
+	}
+	return secret, nil
+}
+
+// gfMul multiplies two elements of GF(2^8) with the AES reduction
+// polynomial.
+func gfMul(a, b byte) byte {
+	var product byte
+	for b > 0 {
+		if b&1 != 0 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return product
+}
+
+// gfDiv divides two elements of GF(2^8). Division by zero yields zero.
+func gfDiv(a, b byte) byte {
+	if b == 0 {
+		return 0
+	}
+	// b^254 is the multiplicative inverse of b
+	inverse := byte(1)
+	for i := 0; i < 254; i++ {
+		inverse = gfMul(inverse, b)
+	}
+	return gfMul(a, inverse)
+}
+
+// gfPolyEval evaluates the polynomial with the given coefficients, lowest
+// degree first, at point x.
+func gfPolyEval(coefficients []byte, x byte) byte {
+	var value byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		value = gfMul(value, x) ^ coefficients[i]
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombineSecret(t *testing.T) {
+	t.Parallel()
+	secret := []byte("some thirty two byte long secret")
+
+	shares, err := SplitSecret(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	// any subset of threshold size reconstructs the secret
+	recovered, err := CombineShares([][]byte{shares[0], shares[2], shares[4]})
+	require.NoError(t, err)
+	require.Equal(t, secret, recovered)
+
+	recovered, err = CombineShares([][]byte{shares[3], shares[1], shares[0]})
+	require.NoError(t, err)
+	require.Equal(t, secret, recovered)
+
+	// extra shares do not hurt
+	recovered, err = CombineShares(shares)
+	require.NoError(t, err)
+	require.Equal(t, secret, recovered)
+
+	// fewer shares than the threshold yield garbage, not the secret
+	garbage, err := CombineShares([][]byte{shares[0], shares[1]})
+	require.NoError(t, err)
+	require.NotEqual(t, secret, garbage)
+}
+
+func TestSplitSecretValidation(t *testing.T) {
+	t.Parallel()
+	secret := []byte("secret")
+
+	_, err := SplitSecret(nil, 3, 2)
+	require.Error(t, err)
+	_, err = SplitSecret(secret, 3, 1)
+	require.Error(t, err)
+	_, err = SplitSecret(secret, 2, 3)
+	require.Error(t, err)
+	_, err = SplitSecret(secret, 256, 2)
+	require.Error(t, err)
+}
+
+func TestCombineSharesValidation(t *testing.T) {
+	t.Parallel()
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	_, err = CombineShares([][]byte{shares[0]})
+	require.Error(t, err)
+	_, err = CombineShares([][]byte{shares[0], shares[0]})
+	require.Error(t, err)
+	_, err = CombineShares([][]byte{shares[0], shares[1][:3]})
+	require.Error(t, err)
+}
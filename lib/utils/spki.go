@@ -18,6 +18,7 @@ package utils
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/subtle"
 	"crypto/x509"
 	"encoding/hex"
@@ -26,23 +27,90 @@ import (
 	"github.com/gravitational/trace"
 )
 
-// CalculateSPKI the hash value of the SPKI header in a certificate.
+const (
+	// SPKIAlgSHA256 pins the SPKI header with SHA-256.
+	SPKIAlgSHA256 = "sha256"
+	// SPKIAlgSHA512 pins the SPKI header with SHA-512.
+	SPKIAlgSHA512 = "sha512"
+)
+
+// SPKIAlgorithms lists the hashing algorithms supported for certificate
+// pins, in the order pins are generated.
+var SPKIAlgorithms = []string{SPKIAlgSHA256, SPKIAlgSHA512}
+
+// CalculateSPKI the hash value of the SPKI header in a certificate, using
+// the default sha256 algorithm.
 func CalculateSPKI(cert *x509.Certificate) string {
 	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
-	return "sha256:" + hex.EncodeToString(sum[:])
+	return SPKIAlgSHA256 + ":" + hex.EncodeToString(sum[:])
+}
+
+// CalculateSPKIWithAlgorithm returns the pin of the SPKI header in a
+// certificate computed with the given hashing algorithm.
+func CalculateSPKIWithAlgorithm(cert *x509.Certificate, algorithm string) (string, error) {
+	switch algorithm {
+	case SPKIAlgSHA256:
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		return SPKIAlgSHA256 + ":" + hex.EncodeToString(sum[:]), nil
+	case SPKIAlgSHA512:
+		sum := sha512.Sum512(cert.RawSubjectPublicKeyInfo)
+		return SPKIAlgSHA512 + ":" + hex.EncodeToString(sum[:]), nil
+	default:
+		return "", trace.BadParameter("unsupported hashing algorithm %q for certificate pin, supported algorithms: %v",
+			algorithm, strings.Join(SPKIAlgorithms, ", "))
+	}
+}
+
+// ParseSPKIPin validates the structured algorithm:digest pin format and
+// returns the pin's hashing algorithm.
+func ParseSPKIPin(pin string) (string, error) {
+	algorithm, digest, err := parseSPKIAlgorithm(pin)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var digestLen int
+	switch algorithm {
+	case SPKIAlgSHA256:
+		digestLen = sha256.Size
+	case SPKIAlgSHA512:
+		digestLen = sha512.Size
+	}
+	decoded, err := hex.DecodeString(digest)
+	if err != nil {
+		return "", trace.BadParameter("certificate pin digest is not valid hex")
+	}
+	if len(decoded) != digestLen {
+		return "", trace.BadParameter("certificate pin digest has invalid length %v for algorithm %q, expected %v",
+			len(decoded), algorithm, digestLen)
+	}
+	return algorithm, nil
+}
+
+// parseSPKIAlgorithm checks the algorithm:pin format and that the algorithm
+// prefix is supported, returning the algorithm and the digest. The digest
+// itself is not validated: a malformed digest never matches a certificate,
+// which keeps sets mixing pins of several clusters usable.
+func parseSPKIAlgorithm(pin string) (string, string, error) {
+	parts := strings.Split(pin, ":")
+	if len(parts) != 2 {
+		return "", "", trace.BadParameter("invalid format for certificate pin, expected algorithm:pin")
+	}
+	switch parts[0] {
+	case SPKIAlgSHA256, SPKIAlgSHA512:
+	default:
+		return "", "", trace.BadParameter("unsupported hashing algorithm %q for certificate pin, supported algorithms: %v",
+			parts[0], strings.Join(SPKIAlgorithms, ", "))
+	}
+	return parts[0], parts[1], nil
 }
 
 // CheckSPKI the passed in pin against the calculated value from a certificate.
 func CheckSPKI(pins []string, certs []*x509.Certificate) error {
 	// check pins
 	for _, pin := range pins {
-		// Check that the format of the pin is valid.
-		parts := strings.Split(pin, ":")
-		if len(parts) != 2 {
-			return trace.BadParameter("invalid format for certificate pin, expected algorithm:pin")
-		}
-		if parts[0] != "sha256" {
-			return trace.BadParameter("sha256 only supported hashing algorithm for certificate pin")
+		// Check that the format and algorithm prefix of the pin are valid.
+		if _, _, err := parseSPKIAlgorithm(pin); err != nil {
+			return trace.Wrap(err)
 		}
 	}
 	// Timing of this check depends only on the number of pins and certs, not
@@ -50,9 +118,17 @@ func CheckSPKI(pins []string, certs []*x509.Certificate) error {
 outer:
 	for _, cert := range certs {
 		for _, pin := range pins {
+			algorithm, _, err := parseSPKIAlgorithm(pin)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			calculated, err := CalculateSPKIWithAlgorithm(cert, algorithm)
+			if err != nil {
+				return trace.Wrap(err)
+			}
 			// Check that that pin itself matches that value calculated from the passed
 			// in certificate.
-			if subtle.ConstantTimeCompare([]byte(CalculateSPKI(cert)), []byte(pin)) == 1 {
+			if subtle.ConstantTimeCompare([]byte(calculated), []byte(pin)) == 1 {
 				continue outer
 			}
 		}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func generateTestCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	creds, err := GenerateSelfSignedCert([]string{"localhost"})
+	require.NoError(t, err)
+	block, _ := pem.Decode(creds.Cert)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestCheckSPKI(t *testing.T) {
+	t.Parallel()
+	cert := generateTestCert(t)
+	otherCert := generateTestCert(t)
+
+	sha256Pin := CalculateSPKI(cert)
+	sha512Pin, err := CalculateSPKIWithAlgorithm(cert, SPKIAlgSHA512)
+	require.NoError(t, err)
+
+	// pins of either supported algorithm match the certificate
+	require.NoError(t, CheckSPKI([]string{sha256Pin}, []*x509.Certificate{cert}))
+	require.NoError(t, CheckSPKI([]string{sha512Pin}, []*x509.Certificate{cert}))
+
+	// several pins may be provided, any match per certificate suffices
+	otherPin, err := CalculateSPKIWithAlgorithm(otherCert, SPKIAlgSHA512)
+	require.NoError(t, err)
+	require.NoError(t, CheckSPKI([]string{otherPin, sha256Pin}, []*x509.Certificate{cert}))
+
+	// a malformed digest never matches but does not fail the whole set
+	require.NoError(t, CheckSPKI([]string{"sha256:123", sha256Pin}, []*x509.Certificate{cert}))
+
+	// a pin of another certificate does not match
+	require.Error(t, CheckSPKI([]string{otherPin}, []*x509.Certificate{cert}))
+
+	// an unsupported algorithm prefix is rejected
+	require.Error(t, CheckSPKI([]string{"sha1:123", sha256Pin}, []*x509.Certificate{cert}))
+}
+
+func TestParseSPKIPin(t *testing.T) {
+	t.Parallel()
+	cert := generateTestCert(t)
+
+	algorithm, err := ParseSPKIPin(CalculateSPKI(cert))
+	require.NoError(t, err)
+	require.Equal(t, SPKIAlgSHA256, algorithm)
+
+	sha512Pin, err := CalculateSPKIWithAlgorithm(cert, SPKIAlgSHA512)
+	require.NoError(t, err)
+	algorithm, err = ParseSPKIPin(sha512Pin)
+	require.NoError(t, err)
+	require.Equal(t, SPKIAlgSHA512, algorithm)
+
+	for _, pin := range []string{
+		"",
+		"sha256",
+		"sha1:0000",
+		"sha256:not-hex",
+		"sha256:abcd",
+		"sha512:abcd",
+	} {
+		_, err := ParseSPKIPin(pin)
+		require.Error(t, err, "expected pin %q to be rejected", pin)
+	}
+}
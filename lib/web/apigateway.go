@@ -0,0 +1,222 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/gravitational/trace"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/httplib"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+// gatewayResourceKinds is the curated set of resource kinds the REST/JSON
+// gateway exposes for listing.
+var gatewayResourceKinds = []string{
+	types.KindNode,
+	types.KindAppServer,
+	types.KindDatabaseServer,
+	types.KindKubernetesCluster,
+	types.KindWindowsDesktop,
+}
+
+// gatewayRoute describes one gateway endpoint for the generated OpenAPI
+// specification.
+type gatewayRoute struct {
+	// Method is the HTTP method of the endpoint.
+	Method string
+	// Path is the URL path of the endpoint.
+	Path string
+	// Summary is a one-line description of the endpoint.
+	Summary string
+	// QueryParams lists the supported query parameters with descriptions.
+	QueryParams map[string]string
+}
+
+// gatewayRoutes is the route table the gateway endpoints and the OpenAPI
+// specification are generated from.
+var gatewayRoutes = []gatewayRoute{
+	{
+		Method:  http.MethodGet,
+		Path:    "/webapi/gateway/resources/{kind}",
+		Summary: "List resources of the given kind the calling identity can access.",
+		QueryParams: map[string]string{
+			"limit":    "maximum number of resources to return",
+			"startKey": "pagination key to resume listing from",
+			"search":   "space-separated search keywords",
+			"query":    "predicate expression to filter resources",
+			"sort":     "sort order in <field>:<asc|desc> format",
+		},
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/webapi/gateway/alerts",
+		Summary: "List cluster alerts visible to the calling identity.",
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/webapi/gateway/accessrequests",
+		Summary: "Submit an access request for the calling identity.",
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/webapi/gateway/openapi.json",
+		Summary: "OpenAPI specification of the gateway endpoints.",
+	},
+}
+
+// gatewayListResources transcodes a JSON listing request into the
+// ListResources API for a curated set of resource kinds.
+func (h *Handler) gatewayListResources(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	kind := p.ByName("kind")
+	supported := false
+	for _, k := range gatewayResourceKinds {
+		if k == kind {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, trace.BadParameter("resource kind %q is not served by the API gateway, supported kinds: %v", kind, gatewayResourceKinds)
+	}
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := listResources(clt, r, kind)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return listResourcesGetResponse{
+		Items:      resp.Resources,
+		StartKey:   resp.NextKey,
+		TotalCount: resp.TotalCount,
+	}, nil
+}
+
+// gatewayGetClusterAlerts returns the cluster alerts visible to the calling
+// identity.
+func (h *Handler) gatewayGetClusterAlerts(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	alerts, err := clt.GetClusterAlerts(r.Context(), types.GetClusterAlertsRequest{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return alerts, nil
+}
+
+// gatewaySubmitAccessRequestReq is the body of a gateway access request
+// submission.
+type gatewaySubmitAccessRequestReq struct {
+	// Roles are the roles being requested.
+	Roles []string `json:"roles"`
+	// Reason is the request reason shown to reviewers.
+	Reason string `json:"reason,omitempty"`
+}
+
+// gatewaySubmitAccessRequest submits an access request for the calling
+// identity.
+func (h *Handler) gatewaySubmitAccessRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	var req gatewaySubmitAccessRequestReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(req.Roles) == 0 {
+		return nil, trace.BadParameter("missing roles to request")
+	}
+	accessRequest, err := services.NewAccessRequest(ctx.GetUser(), req.Roles...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.Reason != "" {
+		accessRequest.SetRequestReason(req.Reason)
+	}
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := clt.CreateAccessRequest(r.Context(), accessRequest); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return accessRequest, nil
+}
+
+// gatewayOpenAPISpec serves the OpenAPI 3 specification generated from the
+// gateway route table, so non-Go tools can generate clients without
+// protobuf tooling.
+func (h *Handler) gatewayOpenAPISpec(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+	paths := map[string]interface{}{}
+	for _, route := range gatewayRoutes {
+		var parameters []map[string]interface{}
+		for name, description := range route.QueryParams {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        name,
+				"in":          "query",
+				"description": description,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		operation := map[string]interface{}{
+			"summary": route.Summary,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "successful response",
+				},
+			},
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+		methods, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			methods = map[string]interface{}{}
+			paths[route.Path] = methods
+		}
+		methods[lowercaseMethod(route.Method)] = operation
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Teleport Proxy API Gateway",
+			"version": teleport.WebAPIVersion,
+		},
+		"paths": paths,
+	}, nil
+}
+
+func lowercaseMethod(method string) string {
+	switch method {
+	case http.MethodGet:
+		return "get"
+	case http.MethodPost:
+		return "post"
+	case http.MethodPut:
+		return "put"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return method
+	}
+}
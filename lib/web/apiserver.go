@@ -200,6 +200,14 @@ type Config struct {
 	// PublicProxyAddr is used to template the public proxy address
 	// into the installer script responses
 	PublicProxyAddr string
+
+	// EnableStatusEndpoint enables the unauthenticated /webapi/status
+	// endpoint used by load balancers and uptime checks.
+	EnableStatusEndpoint bool
+
+	// EnableAPIGateway enables the REST/JSON gateway under /webapi/gateway
+	// that transcodes a curated subset of APIs for non-gRPC clients.
+	EnableAPIGateway bool
 }
 
 type APIHandler struct {
@@ -454,9 +462,34 @@ func (h *Handler) bindDefaultEndpoints(challengeLimiter *limiter.RateLimiter) {
 	// Unauthenticated access to JWT public keys.
 	h.GET("/.well-known/jwks.json", httplib.MakeHandler(h.jwks))
 
+	// Unauthenticated access to OIDC provider metadata, so the cluster can
+	// be registered as an identity provider with external relying parties
+	// such as cloud identity federation.
+	h.GET("/.well-known/openid-configuration", httplib.MakeHandler(h.openidConfiguration))
+
+	// Unauthenticated access to the cluster CA certificates in SPIFFE trust
+	// bundle format, used for federation with external SPIRE deployments.
+	h.GET("/webapi/spiffe/bundle.json", httplib.MakeHandler(h.spiffeBundle))
+
 	// Unauthenticated access to the message of the day
 	h.GET("/webapi/motd", httplib.MakeHandler(h.motd))
 
+	// Unauthenticated, minimal cluster status for load balancers and
+	// uptime checks. Only registered when explicitly enabled.
+	if h.cfg.EnableStatusEndpoint {
+		h.GET("/webapi/status", httplib.MakeHandler(h.status))
+	}
+
+	// REST/JSON gateway for a curated subset of APIs, so tools in non-Go
+	// languages can integrate without generating protobuf clients. Only
+	// registered when explicitly enabled.
+	if h.cfg.EnableAPIGateway {
+		h.GET("/webapi/gateway/openapi.json", httplib.MakeHandler(h.gatewayOpenAPISpec))
+		h.GET("/webapi/gateway/resources/:kind", h.WithAuth(h.gatewayListResources))
+		h.GET("/webapi/gateway/alerts", h.WithAuth(h.gatewayGetClusterAlerts))
+		h.POST("/webapi/gateway/accessrequests", h.WithAuth(h.gatewaySubmitAccessRequest))
+	}
+
 	// Unauthenticated access to retrieving the script used to install
 	// Teleport
 	h.GET("/webapi/scripts/installer", httplib.MakeHandler(h.installer))
@@ -471,6 +504,10 @@ func (h *Handler) bindDefaultEndpoints(challengeLimiter *limiter.RateLimiter) {
 	h.POST("/webapi/sessions/app", h.WithAuth(h.createAppSession))
 	h.DELETE("/webapi/sessions", h.WithAuth(h.deleteSession))
 	h.POST("/webapi/sessions/renew", h.WithAuth(h.renewSession))
+	// mint a scope-restricted credential derived from the web session, for
+	// use by browser extensions and dashboards that only need a narrow
+	// slice of the API
+	h.POST("/webapi/sessions/scoped", h.WithAuth(h.createScopedAPISession))
 
 	h.POST("/webapi/users", h.WithAuth(h.createUserHandle))
 	h.PUT("/webapi/users", h.WithAuth(h.updateUserHandle))
@@ -1768,6 +1805,25 @@ func (h *Handler) renewSession(w http.ResponseWriter, r *http.Request, params ht
 	return res, nil
 }
 
+// createScopedAPISession mints a scope-restricted TLS credential derived from
+// the caller's web session. The returned certificate is validated by the auth
+// middleware with scope checks preceding RBAC.
+func (h *Handler) createScopedAPISession(w http.ResponseWriter, r *http.Request, params httprouter.Params, ctx *SessionContext) (interface{}, error) {
+	var req auth.ScopedCertsRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	certs, err := clt.CreateScopedAPICerts(r.Context(), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return certs, nil
+}
+
 type changeUserAuthenticationRequest struct {
 	// SecondFactorToken is the TOTP code.
 	SecondFactorToken string `json:"second_factor_token"`
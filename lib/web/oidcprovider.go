@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+)
+
+// openIDConfiguration is an OIDC provider metadata document as defined by
+// the OpenID Connect Discovery specification. Only the fields relevant to
+// verifying Teleport-issued ID tokens are populated: cloud identity
+// federation (AWS IAM OIDC providers, GCP workload identity pools) reads
+// the issuer and jwks_uri and verifies tokens offline, there is no
+// authorization or token endpoint.
+type openIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+}
+
+// openidConfiguration returns the OIDC discovery document for this cluster,
+// allowing it to be registered as an OIDC identity provider with external
+// relying parties. The signing keys it points at are the same ones served
+// by the /.well-known/jwks.json endpoint.
+func (h *Handler) openidConfiguration(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+	issuer, err := h.publicIssuerURL()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &openIDConfiguration{
+		Issuer:                           issuer,
+		JWKSURI:                          issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+		ClaimsSupported:                  []string{"iss", "sub", "aud", "exp", "iat", "nbf", "username"},
+		ScopesSupported:                  []string{"openid"},
+	}, nil
+}
+
+// publicIssuerURL returns the issuer URL embedded in Teleport-issued ID
+// tokens and published in the discovery document. It is derived from the
+// public proxy address, which is the address external verifiers can reach.
+func (h *Handler) publicIssuerURL() (string, error) {
+	addr := h.cfg.PublicProxyAddr
+	if addr == "" && len(h.cfg.ProxyPublicAddrs) > 0 {
+		addr = h.cfg.ProxyPublicAddrs[0].String()
+	}
+	if addr == "" {
+		return "", trace.NotFound("no public proxy address is configured")
+	}
+	addr = strings.TrimPrefix(strings.TrimPrefix(addr, "https://"), "http://")
+	return "https://" + strings.TrimSuffix(addr, "/"), nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/tlsca"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// spiffeRefreshHint is the refresh interval suggested to bundle consumers
+	// while the CA is in steady state.
+	spiffeRefreshHint = time.Hour
+	// spiffeRotationRefreshHint is the refresh interval suggested to bundle
+	// consumers while a CA rotation is in progress, so federated trust
+	// domains pick up the new CA certificates quickly.
+	spiffeRotationRefreshHint = 5 * time.Minute
+)
+
+// spiffeBundleKey is a single x509-svid entry of a SPIFFE trust bundle.
+type spiffeBundleKey struct {
+	// KeyType is the type of the CA public key.
+	KeyType string `json:"kty"`
+	// Use is always "x509-svid" for CA certificates.
+	Use string `json:"use"`
+	// X5C holds the DER-encoded CA certificate.
+	X5C []string `json:"x5c"`
+}
+
+// spiffeBundleResponse is a SPIFFE trust bundle document as defined by the
+// SPIFFE Trust Domain and Bundle specification.
+type spiffeBundleResponse struct {
+	// Keys is the list of CA certificates of this trust domain.
+	Keys []spiffeBundleKey `json:"keys"`
+	// RefreshHint suggests how often (in seconds) consumers should re-fetch
+	// the bundle. It is lowered while a CA rotation is in progress.
+	RefreshHint int64 `json:"spiffe_refresh_hint"`
+	// Sequence is a monotonically increasing bundle revision.
+	Sequence int64 `json:"spiffe_sequence"`
+}
+
+// spiffeBundle returns the cluster CA certificates as a SPIFFE trust bundle,
+// allowing external SPIRE deployments to federate with workload identities
+// issued by this cluster. The endpoint is unauthenticated, just like the
+// bundle endpoints served by SPIRE itself: the bundle only contains public
+// CA material.
+func (h *Handler) spiffeBundle(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+	clusterName, err := h.cfg.ProxyClient.GetDomainName(r.Context())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ca, err := h.cfg.ProxyClient.GetCertAuthority(r.Context(), types.CertAuthID{
+		Type:       types.HostCA,
+		DomainName: clusterName,
+	}, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	// GetTrustedTLSKeyPairs returns both active and additional trusted keys,
+	// so consumers keep trusting certificates issued by the old CA while a
+	// rotation is in progress.
+	pairs := ca.GetTrustedTLSKeyPairs()
+	resp := spiffeBundleResponse{
+		Keys:        make([]spiffeBundleKey, 0, len(pairs)),
+		RefreshHint: int64(spiffeRefreshHint / time.Second),
+		Sequence:    ca.GetResourceID(),
+	}
+	if ca.GetRotation().State == types.RotationStateInProgress {
+		resp.RefreshHint = int64(spiffeRotationRefreshHint / time.Second)
+	}
+
+	for _, pair := range pairs {
+		cert, err := tlsca.ParseCertificatePEM(pair.Cert)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var keyType string
+		switch cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			keyType = "RSA"
+		case *ecdsa.PublicKey:
+			keyType = "EC"
+		default:
+			return nil, trace.BadParameter("unsupported CA public key type %T", cert.PublicKey)
+		}
+		resp.Keys = append(resp.Keys, spiffeBundleKey{
+			KeyType: keyType,
+			Use:     "x509-svid",
+			X5C:     []string{base64.StdEncoding.EncodeToString(cert.Raw)},
+		})
+	}
+	return &resp, nil
+}
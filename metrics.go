@@ -28,6 +28,10 @@ const (
 	// MetricGenerateRequestsCurrent measures current in-flight requests
 	MetricGenerateRequestsCurrent = "auth_generate_requests"
 
+	// MetricIdentityRequestsRejected counts RPCs rejected because the
+	// calling identity exceeded its concurrent request limit
+	MetricIdentityRequestsRejected = "auth_identity_requests_rejected_total"
+
 	// MetricGenerateRequestsHistogram measures generate requests latency
 	MetricGenerateRequestsHistogram = "auth_generate_seconds"
 
@@ -79,6 +83,11 @@ const (
 	// MetricWatcherEventSizes measures the size of watcher events that are emitted
 	MetricWatcherEventSizes = "watcher_event_sizes"
 
+	// MetricWatcherEventsSuppressed counts watcher events that were not sent
+	// because their payload was identical to the previous event for the
+	// same resource
+	MetricWatcherEventsSuppressed = "watcher_events_suppressed_total"
+
 	// MetricMissingSSHTunnels returns the number of missing SSH tunnels for this proxy.
 	MetricMissingSSHTunnels = "proxy_missing_ssh_tunnels"
 
@@ -126,6 +126,10 @@ func Run(options Options) (app *kingpin.Application, executedCommand string, con
 		Short('c').ExistingFileVar(&ccf.ConfigFile)
 	start.Flag("bootstrap",
 		"Path to bootstrap file (ignored if already initialized)").ExistingFileVar(&ccf.BootstrapFile)
+	start.Flag("bootstrap-manifest",
+		"Path to a signed bootstrap manifest (applied on first start, checked for drift afterwards)").ExistingFileVar(&ccf.BootstrapManifestFile)
+	start.Flag("bootstrap-manifest-key",
+		"Path to the PEM-encoded ed25519 public key used to verify the bootstrap manifest").ExistingFileVar(&ccf.BootstrapManifestKeyFile)
 	start.Flag("config-string",
 		"Base64 encoded configuration string").Hidden().Envar(defaults.ConfigEnvar).
 		StringVar(&ccf.ConfigString)